@@ -0,0 +1,3 @@
+module github.com/zeroclaw-labs/zeroclaw/sdk/go
+
+go 1.21
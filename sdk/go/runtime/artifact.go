@@ -0,0 +1,75 @@
+package runtime
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ArtifactGzipEnv tells an SDK-built skill the artifact size from which to
+// gzip artifacts; it matches skill.ArtifactGzipEnv. The executor sets it
+// from the manifest:
+//
+//	"artifacts": {"compress": true, "gzip_threshold": 65536}
+const ArtifactGzipEnv = "ZEROCLAW_ARTIFACT_GZIP_MIN"
+
+// DefaultArtifactGzipThreshold is the gzip threshold for manifests that do
+// not set artifacts.gzip_threshold.
+const DefaultArtifactGzipThreshold = 64 << 10
+
+// ArtifactGzip is the Artifact.Encoding of gzip-compressed content.
+const ArtifactGzip = "gzip"
+
+// maxArtifactBytes bounds what one compressed artifact may inflate to.
+const maxArtifactBytes = 256 << 20
+
+// ErrArtifactTooLarge is returned when a compressed artifact inflates past
+// 256 MiB.
+var ErrArtifactTooLarge = errors.New("artifact inflates past 256 MiB")
+
+// Artifact is a named file a skill returns beside its output (see
+// skill.Artifact). The executor inflates compressed ones, so Encoding is
+// empty and Content is the file itself by the time a Result is returned; a
+// text artifact has Text instead.
+type Artifact struct {
+	Name      string `json:"name"`
+	MediaType string `json:"media_type,omitempty"`
+	Encoding  string `json:"encoding,omitempty"`
+	Text      string `json:"text,omitempty"`
+	Content   []byte `json:"content,omitempty"`
+}
+
+// Artifact returns the artifact r carries under name, if any.
+func (r ToolResult) Artifact(name string) (Artifact, bool) {
+	for _, a := range r.Artifacts {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Artifact{}, false
+}
+
+// inflateArtifacts decompresses res's gzip artifacts in place. Artifacts
+// with an encoding it does not know are left as they are.
+func inflateArtifacts(res *ToolResult) error {
+	for i, a := range res.Artifacts {
+		if a.Encoding != ArtifactGzip {
+			continue
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(a.Content))
+		if err != nil {
+			return fmt.Errorf("artifact %q: %w", a.Name, err)
+		}
+		content, err := io.ReadAll(io.LimitReader(zr, maxArtifactBytes+1))
+		if err != nil {
+			return fmt.Errorf("artifact %q: %w", a.Name, err)
+		}
+		if len(content) > maxArtifactBytes {
+			return fmt.Errorf("artifact %q: %w", a.Name, ErrArtifactTooLarge)
+		}
+		res.Artifacts[i].Encoding, res.Artifacts[i].Content = "", content
+	}
+	return nil
+}
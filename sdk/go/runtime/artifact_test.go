@@ -0,0 +1,72 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestExecuteInflatesGzipArtifacts(t *testing.T) {
+	wasm := skillDir(t, buildSkill(t, "artifact"), `{"name":"artifact","artifacts":{"gzip_threshold":1000}}`)
+	ctx := context.Background()
+
+	var raw bytes.Buffer
+	if _, err := ExecuteReader(ctx, wasm, strings.NewReader(`{"size":5000}`), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(raw.String(), `"encoding":"gzip"`) || len(raw.String()) > 1000 {
+		t.Fatalf("a large artifact should go over the wire gzipped, got %d bytes: %.120s", raw.Len(), raw.String())
+	}
+	for _, size := range []int{5000, 10} {
+		res, err := Execute(ctx, wasm, []byte(`{"size":`+strconv.Itoa(size)+`}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Output != "1000" {
+			t.Fatalf("skill saw threshold %q, want the manifest's 1000", res.Output)
+		}
+		a := res.Artifacts[0]
+		if a.Encoding != "" || string(a.Content) != strings.Repeat("z", size) {
+			t.Fatalf("size %d: got encoding %q and %d content bytes, want the raw file", size, a.Encoding, len(a.Content))
+		}
+	}
+}
+
+func TestManifestArtifactCompression(t *testing.T) {
+	for manifest, want := range map[string]string{
+		`{"name":"artifact"}`:                                   strconv.Itoa(DefaultArtifactGzipThreshold),
+		`{"name":"artifact","artifacts":{"compress":false}}`:    "",
+		`{"name":"artifact","artifacts":{"gzip_threshold":64}}`: "64",
+	} {
+		res, err := Execute(context.Background(), skillDir(t, buildSkill(t, "artifact"), manifest), []byte(`{"size":1}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Output != want {
+			t.Errorf("%s: skill saw threshold %q, want %q", manifest, res.Output, want)
+		}
+	}
+
+	wasm := skillDir(t, buildSkill(t, "artifact"), `{"name":"artifact","artifacts":{"gzip_threshold":-1}}`)
+	if _, err := Execute(context.Background(), wasm, []byte(`{}`)); err == nil || !strings.Contains(err.Error(), "gzip_threshold") {
+		t.Fatalf("expected a negative threshold to be rejected, got %v", err)
+	}
+}
+
+func TestResultArtifactLooksUpByName(t *testing.T) {
+	res, err := Execute(context.Background(), buildSkill(t, "artifact"), []byte(`{"size":3}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, ok := res.Artifact("zz.md"); !ok || a.Text != "# 3" || a.MediaType != "text/markdown" || a.Content != nil {
+		t.Fatalf("zz.md: got %+v, %v", a, ok)
+	}
+	if a, ok := res.Artifact("z.txt"); !ok || string(a.Content) != "zzz" {
+		t.Fatalf("z.txt: got %+v, %v", a, ok)
+	}
+	if _, ok := res.Artifact("missing"); ok {
+		t.Fatal("an artifact the skill did not return should not be found")
+	}
+}
@@ -0,0 +1,110 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// AskEnv tells an SDK-built skill that the host answers skill.Ask; it
+// matches skill.AskEnv.
+const AskEnv = "ZEROCLAW_ASK"
+
+// Ask is a question a skill puts to the host mid-execution (see skill.Ask).
+type Ask struct {
+	Prompt string `json:"prompt"`
+	// Schema is the JSON Schema the answer should match, if the skill gave one.
+	Schema json.RawMessage `json:"schema,omitempty"`
+}
+
+// askLine is the control line a skill writes to ask.
+type askLine struct {
+	Type string `json:"type"`
+	Ask
+}
+
+// asker answers a skill's questions. Its stdout side intercepts ask lines,
+// hands them to Config.OnAsk, and queues each answer for its stdin side;
+// every other line passes through to w.
+type asker struct {
+	w       io.Writer
+	onAsk   func(Ask) (json.RawMessage, error)
+	args    io.Reader
+	answers bytes.Buffer
+	buf     []byte
+}
+
+// newAsker reads all of args and puts it on one line, as skill.Ask expects:
+// raw newlines cannot occur inside JSON strings, so turning them into spaces
+// leaves valid JSON meaning the same.
+func newAsker(w io.Writer, args io.Reader, onAsk func(Ask) (json.RawMessage, error)) (*asker, error) {
+	data, err := io.ReadAll(args)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.ReplaceAll(data, []byte("\r"), []byte(" "))
+	data = bytes.ReplaceAll(data, []byte("\n"), []byte(" "))
+	return &asker{w: w, onAsk: onAsk, args: bytes.NewReader(append(data, '\n'))}, nil
+}
+
+// Read serves the args line, then answers as they are queued. The guest only
+// reads for an answer after writing its question, so an empty queue is EOF.
+func (a *asker) Read(p []byte) (int, error) {
+	if n, err := a.args.Read(p); err != io.EOF {
+		return n, err
+	}
+	return a.answers.Read(p)
+}
+
+func (a *asker) Write(p []byte) (int, error) {
+	a.buf = append(a.buf, p...)
+	for {
+		i := bytes.IndexByte(a.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := a.buf[:i+1]
+		a.buf = a.buf[i+1:]
+		if err := a.line(line); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// line answers line if it is a question and passes it on otherwise.
+func (a *asker) line(line []byte) error {
+	var q askLine
+	if !bytes.Contains(line, []byte(`"type"`)) || json.Unmarshal(line, &q) != nil || q.Type != "ask" {
+		_, err := a.w.Write(line)
+		return err
+	}
+	var reply struct {
+		Answer json.RawMessage `json:"answer,omitempty"`
+		Error  string          `json:"error,omitempty"`
+	}
+	answer, err := a.onAsk(q.Ask)
+	switch {
+	case err != nil:
+		reply.Error = err.Error()
+	case len(answer) == 0:
+		reply.Answer = json.RawMessage("null")
+	default:
+		reply.Answer = answer
+	}
+	out, err := json.Marshal(reply)
+	if err != nil {
+		out, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	a.answers.Write(append(out, '\n'))
+	return nil
+}
+
+// flush passes on an unterminated last line, such as a non-streaming result.
+func (a *asker) flush() error {
+	if len(a.buf) == 0 {
+		return nil
+	}
+	_, err := a.w.Write(a.buf)
+	a.buf = nil
+	return err
+}
@@ -0,0 +1,53 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExecutorOnAskAnswersSkill(t *testing.T) {
+	wasm := buildSkill(t, "ask")
+	var asked []Ask
+	exec := New(Config{OnAsk: func(q Ask) (json.RawMessage, error) {
+		asked = append(asked, q)
+		return json.RawMessage(`"b.txt"`), nil
+	}})
+	var out bytes.Buffer
+	_, err := exec.ExecuteReader(context.Background(), wasm, strings.NewReader("{\n  \"prompt\": \"which file?\"\n}"), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(asked) != 1 || asked[0].Prompt != "which file?" || string(asked[0].Schema) != `{"type":"string"}` {
+		t.Fatalf("OnAsk got %+v", asked)
+	}
+	if want := `{"output":"\"b.txt\"","success":true}`; out.String() != want {
+		t.Fatalf("stdout should hold only the result, got %s", out.String())
+	}
+}
+
+func TestExecutorOnAskErrorReachesSkill(t *testing.T) {
+	exec := New(Config{OnAsk: func(Ask) (json.RawMessage, error) {
+		return nil, errors.New("user cancelled")
+	}})
+	res, err := exec.Execute(context.Background(), buildSkill(t, "ask"), []byte(`{"prompt":"which file?"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || res.Error == nil || *res.Error != "user cancelled" {
+		t.Fatalf("expected the skill to see the refusal, got %+v", res.ToolResult)
+	}
+}
+
+func TestAskNotSupportedWithoutOnAsk(t *testing.T) {
+	res, err := Execute(context.Background(), buildSkill(t, "ask"), []byte(`{"prompt":"which file?"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || res.ErrorCode != "not_supported" {
+		t.Fatalf("expected a not_supported failure, got %+v", res.ToolResult)
+	}
+}
@@ -0,0 +1,131 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+)
+
+// Environment variables through which a skill learns its limits; they match
+// skill.MaxOutputBytesEnv, skill.MaxInputBytesEnv, and skill.DeadlineEnv.
+const (
+	MaxOutputBytesEnv = "ZEROCLAW_MAX_OUTPUT_BYTES"
+	MaxInputBytesEnv  = "ZEROCLAW_MAX_INPUT_BYTES"
+	DeadlineEnv       = "ZEROCLAW_DEADLINE"
+)
+
+// DefaultMaxInputBytes is the input cap advertised when Config.MaxInputBytes
+// is zero.
+const DefaultMaxInputBytes = 16 << 20
+
+// ErrOutputTooLarge is returned when a skill writes more than
+// Config.MaxOutputBytes to stdout.
+var ErrOutputTooLarge = errors.New("skill output exceeds MaxOutputBytes")
+
+// OnOversizeEnv tells an SDK-built skill to cut a result over
+// MaxOutputBytesEnv down to fit rather than write it whole; it matches
+// skill.OnOversizeEnv. The executor sets it to OnOversizeTruncate for a skill
+// whose manifest sets "on_oversize": "truncate". Output over the cap still
+// fails with ErrOutputTooLarge.
+const OnOversizeEnv = "ZEROCLAW_ON_OVERSIZE"
+
+// Values of the manifest's "on_oversize" field.
+const (
+	OnOversizeError    = "error"
+	OnOversizeTruncate = "truncate"
+)
+
+// CodeResourceExhausted is the error code of the result the executor returns
+// for a skill that writes more to stdout than its manifest's
+// "max_output_bytes". The rest of the output is discarded unread.
+const CodeResourceExhausted = "resource_exhausted"
+
+// oversized is how a call whose output went over c's cap ends: with a failed
+// CodeResourceExhausted result when the cap was the manifest's
+// max_output_bytes, and with ErrOutputTooLarge, noting a skill that promised
+// to truncate its output, when it was Config.MaxOutputBytes.
+func (e *Executor) oversized(path string, caps capabilities, c *capWriter) (ToolResult, error) {
+	switch {
+	case c.manifest:
+		msg := fmt.Sprintf("skill output exceeds max_output_bytes (%d bytes)", c.max)
+		return ToolResult{
+			Error:     &msg,
+			ErrorCode: CodeResourceExhausted,
+			Truncated: true,
+			Warnings:  []string{fmt.Sprintf("stdout truncated at %d bytes; the rest was discarded", c.max)},
+		}, nil
+	case caps.truncate:
+		return ToolResult{}, fmt.Errorf("run %s: %w (%d bytes) despite on_oversize %q", path, ErrOutputTooLarge, c.max, OnOversizeTruncate)
+	}
+	return ToolResult{}, fmt.Errorf("run %s: %w (%d bytes)", path, ErrOutputTooLarge, c.max)
+}
+
+// StreamFlushGrace is how long before ctx's deadline a streaming skill is
+// told to stop, so it can write its own Final result before it is killed. A
+// skill is never given less than half the time left.
+const StreamFlushGrace = 100 * time.Millisecond
+
+// withBudget advertises the input and output caps and ctx's deadline to the
+// guest. A deadline also switches the guest to the host's wall clock;
+// wazero's default clock is fixed, so the guest could not tell how much time
+// is left. A streaming skill sees the deadline StreamFlushGrace early.
+func (e *Executor) withBudget(ctx context.Context, cfg wazero.ModuleConfig, caps capabilities) wazero.ModuleConfig {
+	if limit, _ := e.outputCap(caps); limit > 0 {
+		cfg = cfg.WithEnv(MaxOutputBytesEnv, strconv.Itoa(limit))
+	}
+	switch n := e.cfg.MaxInputBytes; {
+	case n == 0:
+		cfg = cfg.WithEnv(MaxInputBytesEnv, strconv.Itoa(DefaultMaxInputBytes))
+	case n > 0:
+		cfg = cfg.WithEnv(MaxInputBytesEnv, strconv.Itoa(n))
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if caps.streaming {
+			deadline = deadline.Add(-min(StreamFlushGrace, time.Until(deadline)/2))
+		}
+		cfg = cfg.WithEnv(DeadlineEnv, deadline.UTC().Format(time.RFC3339Nano)).WithSysWalltime()
+	}
+	return cfg
+}
+
+// outputCap is the most a skill may write to stdout: the smaller of
+// Config.MaxOutputBytes and the manifest's max_output_bytes, with manifest
+// set when the manifest's is the one that applies. Zero means no cap.
+func (e *Executor) outputCap(caps capabilities) (limit int, manifest bool) {
+	limit = e.cfg.MaxOutputBytes
+	if m := caps.maxOutput; m > 0 && (limit == 0 || m < limit) {
+		return m, true
+	}
+	return limit, false
+}
+
+// capWriter passes writes through to w until one would take the total past
+// max, then fails that write and every later one. A zero max never fails.
+type capWriter struct {
+	w        io.Writer
+	max, n   int
+	exceeded bool
+	// manifest is set when max is the manifest's max_output_bytes.
+	manifest bool
+}
+
+func (e *Executor) capOutput(w io.Writer, caps capabilities) *capWriter {
+	c := &capWriter{w: w}
+	c.max, c.manifest = e.outputCap(caps)
+	return c
+}
+
+func (c *capWriter) Write(p []byte) (int, error) {
+	if c.exceeded || (c.max > 0 && c.n+len(p) > c.max) {
+		c.exceeded = true
+		return 0, ErrOutputTooLarge
+	}
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
@@ -0,0 +1,186 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBudgetLetsSkillTrimItsOutput(t *testing.T) {
+	wasm := buildSkill(t, "budget")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	ex := New(Config{MaxOutputBytes: 256})
+	res, err := ex.Execute(ctx, wasm, []byte(`{"n":1000}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []int
+	if err := json.Unmarshal(res.Data, &items); err != nil {
+		t.Fatal(err)
+	}
+	if !res.Truncated || len(items) == 0 || len(items) >= 1000 {
+		t.Fatalf("want a truncated, non-empty list; got %d items, truncated=%v", len(items), res.Truncated)
+	}
+	if res.Output != "deadline in future: true" {
+		t.Fatalf("guest did not see the deadline: %q", res.Output)
+	}
+}
+
+func TestBudgetEnvIsOptional(t *testing.T) {
+	res, err := Execute(context.Background(), buildSkill(t, "budget"), []byte(`{"n":1000}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Truncated || res.Output != "no deadline" {
+		t.Fatalf("unexpected result without limits: output %q, truncated=%v", res.Output, res.Truncated)
+	}
+}
+
+func TestOutputOverCapFails(t *testing.T) {
+	ex := New(Config{MaxOutputBytes: 8})
+	_, err := ex.Execute(context.Background(), buildSkill(t, "echo"), []byte(`{"text":"far too long"}`))
+	if !errors.Is(err, ErrOutputTooLarge) {
+		t.Fatalf("got %v, want ErrOutputTooLarge", err)
+	}
+
+	m, err := ex.Compile(context.Background(), buildSkill(t, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(context.Background())
+	in, err := m.NewInstance(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.Call(context.Background(), []byte(`{"text":"far too long"}`)); !errors.Is(err, ErrOutputTooLarge) {
+		t.Fatalf("instance: got %v, want ErrOutputTooLarge", err)
+	}
+}
+
+func TestOnOversizeTruncate(t *testing.T) {
+	listing := buildSkill(t, "listing")
+	ex := New(Config{MaxOutputBytes: 256})
+	args := []byte(`{"n":100}`)
+
+	if _, err := ex.Execute(context.Background(), skillDir(t, listing, `{"name":"ls"}`), args); !errors.Is(err, ErrOutputTooLarge) {
+		t.Fatalf("without on_oversize: got %v, want ErrOutputTooLarge", err)
+	}
+
+	wasm := skillDir(t, listing, `{"name":"ls","on_oversize":"truncate"}`)
+	res, err := ex.Execute(context.Background(), wasm, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	if err := json.Unmarshal(res.Data, &names); err != nil {
+		t.Fatal(err)
+	}
+	if !res.Truncated || len(names) == 0 || len(names) >= 100 || res.Output != "100 files" {
+		t.Fatalf("want a truncated, non-empty list; got %d names, truncated=%v", len(names), res.Truncated)
+	}
+
+	m, err := ex.Compile(context.Background(), wasm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(context.Background())
+	in, err := m.NewInstance(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := in.Call(context.Background(), args); err != nil || !res.Truncated {
+		t.Fatalf("instance: got %+v, %v; want a truncated result", res, err)
+	}
+}
+
+func TestOnOversizeStillEnforcesTheCap(t *testing.T) {
+	ex := New(Config{MaxOutputBytes: 8})
+	wasm := skillDir(t, buildSkill(t, "echo"), `{"name":"echo","on_oversize":"truncate"}`)
+	_, err := ex.Execute(context.Background(), wasm, []byte(`{"text":"far too long"}`))
+	if !errors.Is(err, ErrOutputTooLarge) || !strings.Contains(err.Error(), `despite on_oversize "truncate"`) {
+		t.Fatalf("got %v, want ErrOutputTooLarge naming on_oversize", err)
+	}
+
+	wasm = skillDir(t, buildSkill(t, "echo"), `{"name":"echo","on_oversize":"shrink"}`)
+	if _, err := ex.Execute(context.Background(), wasm, nil); err == nil || !strings.Contains(err.Error(), `unknown on_oversize "shrink"`) {
+		t.Fatalf("got %v, want an unknown on_oversize error", err)
+	}
+}
+
+func TestMaxInputBytesIsAdvertised(t *testing.T) {
+	echo := buildSkill(t, "echo")
+	args := []byte(`{"text":"hello"}`) // 16 bytes
+	for _, tc := range []struct {
+		max     int
+		success bool
+	}{
+		{0, true}, // DefaultMaxInputBytes
+		{-1, true},
+		{len(args), true},
+		{len(args) - 1, false},
+	} {
+		res, err := New(Config{MaxInputBytes: tc.max}).Execute(context.Background(), echo, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Success != tc.success {
+			t.Errorf("MaxInputBytes %d: got %+v, want success %v", tc.max, res.ToolResult, tc.success)
+		}
+		if !tc.success && (res.ErrorCode != "invalid_input" || *res.Error != "input exceeds 15 bytes") {
+			t.Errorf("MaxInputBytes %d: unexpected failure %+v", tc.max, res.ToolResult)
+		}
+	}
+}
+
+func TestManifestMaxOutputBytesCutsOffStdout(t *testing.T) {
+	wasm := skillDir(t, buildSkill(t, "echo"), `{"name":"echo","max_output_bytes":64}`)
+	args := []byte(`{"text":"` + strings.Repeat("flood ", 10000) + `"}`)
+	check := func(how string, res ToolResult) {
+		t.Helper()
+		if res.Success || res.ErrorCode != CodeResourceExhausted || !res.Truncated {
+			t.Fatalf("%s: want a truncated resource_exhausted failure, got %+v", how, res)
+		}
+		if len(res.Warnings) != 1 || res.Warnings[0] != "stdout truncated at 64 bytes; the rest was discarded" {
+			t.Fatalf("%s: warnings %q", how, res.Warnings)
+		}
+	}
+
+	ex := New(Config{MaxOutputBytes: 1 << 20})
+	res, err := ex.Execute(context.Background(), wasm, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("Execute", res.ToolResult)
+	if res.Usage.BytesOut > 64 {
+		t.Fatalf("read %d bytes of stdout past the cap", res.Usage.BytesOut)
+	}
+
+	m, err := ex.Compile(context.Background(), wasm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(context.Background())
+	in, err := m.NewInstance(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := in.Call(context.Background(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("Instance", got)
+
+	// The host's own, smaller cap still fails the call outright.
+	if _, err := New(Config{MaxOutputBytes: 8}).Execute(context.Background(), wasm, args); !errors.Is(err, ErrOutputTooLarge) {
+		t.Fatalf("Config cap: got %v, want ErrOutputTooLarge", err)
+	}
+	bad := skillDir(t, buildSkill(t, "echo"), `{"name":"echo","max_output_bytes":-1}`)
+	if _, err := ex.Execute(context.Background(), bad, nil); err == nil || !strings.Contains(err.Error(), "negative max_output_bytes") {
+		t.Fatalf("negative cap: got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ExecuteBatch runs the skill at wasmPath once for each of argsJSON,
+// concurrently, and returns their results in the same order, for a host
+// fanning one request out to many inputs; Combine merges them back into one.
+//
+// The module is compiled once and every call runs on a fresh Instance, so
+// the limits in Config and the manifest apply to each call alone; the calls
+// share ctx's deadline. Config.Retries retries each call as for Pipe. An
+// error is returned for the first call, in argsJSON order, that could not
+// be run; the results of the others are still filled in.
+func (e *Executor) ExecuteBatch(ctx context.Context, wasmPath string, argsJSON [][]byte) ([]ToolResult, error) {
+	m, err := e.Compile(ctx, wasmPath)
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close(ctx)
+
+	results := make([]ToolResult, len(argsJSON))
+	errs := make([]error, len(argsJSON))
+	var wg sync.WaitGroup
+	for i, args := range argsJSON {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = e.retry(ctx, m.caps, func() (ToolResult, error) {
+				in, err := m.NewInstance(ctx)
+				if err != nil {
+					return ToolResult{}, err
+				}
+				return in.Call(ctx, args)
+			})
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return results, fmt.Errorf("batch item %d: %w", i, err)
+		}
+	}
+	return results, nil
+}
+
+// CombineMode says how Combine merges results.
+type CombineMode string
+
+const (
+	// CombineConcatOutput joins the results' Output with newlines, in order.
+	CombineConcatOutput CombineMode = "concat-output"
+	// CombineMergeDataArrays also concatenates their Data, each of which
+	// must be a JSON array or absent, into one array.
+	CombineMergeDataArrays CombineMode = "merge-data-arrays"
+	// CombineFirstError returns the first failed result's Output and error
+	// as they are, or, when none failed, what CombineConcatOutput would.
+	CombineFirstError CombineMode = "first-error"
+)
+
+// Combine merges the results of a batch (see ExecuteBatch) into one
+// ToolResult, so a host can present the batch to the agent as a single tool
+// call. Whatever the mode, the combined result succeeds only if every
+// result did, and keeps their Warnings and Artifacts in order. In the
+// concatenating modes a failed combined result says how many failed and
+// carries the ErrorCode of the first. Data that is not an array fails
+// CombineMergeDataArrays with an error, as does an unknown mode.
+func Combine(results []ToolResult, mode CombineMode) (ToolResult, error) {
+	switch mode {
+	case CombineConcatOutput, CombineMergeDataArrays:
+	case CombineFirstError:
+		for _, res := range results {
+			if !res.Success {
+				return ToolResult{
+					Output:      res.Output,
+					OutputType:  res.OutputType,
+					Error:       res.Error,
+					ErrorCode:   res.ErrorCode,
+					FieldErrors: res.FieldErrors,
+				}, nil
+			}
+		}
+	default:
+		return ToolResult{}, fmt.Errorf("unknown combine mode %q", mode)
+	}
+
+	out := ToolResult{Success: true}
+	outputs := make([]string, len(results))
+	var items []json.RawMessage
+	failed := 0
+	for i, res := range results {
+		outputs[i] = res.Output
+		out.Warnings = append(out.Warnings, res.Warnings...)
+		out.Artifacts = append(out.Artifacts, res.Artifacts...)
+		out.Truncated = out.Truncated || res.Truncated
+		if !res.Success {
+			if failed == 0 {
+				out.ErrorCode = res.ErrorCode
+			}
+			failed++
+		}
+		if mode == CombineMergeDataArrays {
+			data := bytes.TrimSpace(res.Data)
+			if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+				continue
+			}
+			var elems []json.RawMessage
+			if err := json.Unmarshal(data, &elems); err != nil {
+				return ToolResult{}, fmt.Errorf("result %d: data is not a JSON array", i)
+			}
+			items = append(items, elems...)
+		}
+	}
+	out.Output = strings.Join(outputs, "\n")
+	if mode == CombineMergeDataArrays {
+		if items == nil {
+			items = []json.RawMessage{}
+		}
+		data, err := json.Marshal(items)
+		if err != nil {
+			return ToolResult{}, err
+		}
+		out.Data = data
+	}
+	if failed > 0 {
+		msg := fmt.Sprintf("%d of %d results failed", failed, len(results))
+		out.Success, out.Error = false, &msg
+	}
+	return out, nil
+}
@@ -0,0 +1,80 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func strptr(s string) *string { return &s }
+
+func TestExecuteBatchKeepsInputOrder(t *testing.T) {
+	wasm := buildSkill(t, "echo")
+	args := [][]byte{[]byte(`{"n":1}`), []byte(`{"n":2}`), []byte(`{"n":3}`)}
+	results, err := New(Config{}).ExecuteBatch(context.Background(), wasm, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, res := range results {
+		if !res.Success || res.Output != string(args[i]) {
+			t.Fatalf("result %d is %+v, want the echo of %s", i, res, args[i])
+		}
+	}
+
+	combined, err := Combine(results, CombineConcatOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"n":1}` + "\n" + `{"n":2}` + "\n" + `{"n":3}`; !combined.Success || combined.Output != want {
+		t.Fatalf("combined %+v, want output %q", combined, want)
+	}
+}
+
+func TestCombineModes(t *testing.T) {
+	ok := func(output, data string) ToolResult {
+		return ToolResult{Success: true, Output: output, Data: json.RawMessage(data)}
+	}
+	bad := ToolResult{
+		Output: "partial", Error: strptr("city not found"), ErrorCode: "not_found",
+		FieldErrors: []FieldError{{Path: "/city", Message: "unknown"}},
+	}
+	worse := ToolResult{Error: strptr("boom"), ErrorCode: "internal"}
+
+	res, err := Combine([]ToolResult{ok("a", `[1,2]`), ok("b", ``), ok("c", `[{"x":3}]`)}, CombineMergeDataArrays)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.Output != "a\nb\nc" || string(res.Data) != `[1,2,{"x":3}]` {
+		t.Fatalf("merge-data-arrays got %+v (data %s)", res, res.Data)
+	}
+	if _, err := Combine([]ToolResult{ok("a", `{"x":1}`)}, CombineMergeDataArrays); err == nil {
+		t.Fatal("merging object data should fail")
+	}
+
+	res, err = Combine([]ToolResult{ok("a", ``), bad, worse}, CombineConcatOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || res.Output != "a\npartial\n" || res.ErrorCode != "not_found" || *res.Error != "2 of 3 results failed" {
+		t.Fatalf("concat-output with failures got %+v", res)
+	}
+
+	res, err = Combine([]ToolResult{ok("a", ``), bad, worse}, CombineFirstError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ToolResult{Output: "partial", Error: bad.Error, ErrorCode: "not_found", FieldErrors: bad.FieldErrors}
+	if !reflect.DeepEqual(res, want) {
+		t.Fatalf("first-error got %+v, want %+v", res, want)
+	}
+	res, err = Combine([]ToolResult{ok("a", ``), ok("b", ``)}, CombineFirstError)
+	if err != nil || !res.Success || res.Output != "a\nb" {
+		t.Fatalf("first-error with no failure got %+v, %v", res, err)
+	}
+
+	if _, err := Combine(nil, "zip"); err == nil || !strings.Contains(err.Error(), `"zip"`) {
+		t.Fatalf("unknown mode: got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package runtime
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrComponent is returned for a skill built as a WebAssembly component,
+// such as by `zeroclaw skill build --target wasip2`. wazero runs only core
+// modules, so Compile and Execute cannot run a component yet; `zeroclaw
+// skill test`, which runs skills with wasmtime, can. Build with the default
+// wasip1 target for this executor.
+var ErrComponent = errors.New("skill is a WebAssembly component; this executor runs only wasip1 core modules")
+
+// wasmMagic starts every WebAssembly binary, core module or component.
+var wasmMagic = []byte("\x00asm")
+
+// componentLayer is the layer field that follows the magic and the version
+// in a component's preamble; a core module's is 0.
+var componentLayer = []byte{0x01, 0x00}
+
+// checkKind fails with ErrComponent when wasm is a component rather than a
+// core module. Anything else, valid or not, is left to the compiler.
+func checkKind(path string, wasm []byte) error {
+	if len(wasm) >= 8 && bytes.HasPrefix(wasm, wasmMagic) && bytes.Equal(wasm[6:8], componentLayer) {
+		return fmt.Errorf("%s: %w", path, ErrComponent)
+	}
+	return nil
+}
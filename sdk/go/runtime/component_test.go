@@ -0,0 +1,34 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestComponentFailsWithErrComponent(t *testing.T) {
+	// The preamble of a component: magic, version 0x0d, layer 1. Nothing
+	// past it is read.
+	path := filepath.Join(t.TempDir(), "tool.wasm")
+	if err := os.WriteFile(path, []byte("\x00asm\x0d\x00\x01\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := Compile(ctx, path); !errors.Is(err, ErrComponent) {
+		t.Fatalf("Compile: got %v, want ErrComponent", err)
+	}
+	if _, err := Execute(ctx, path, []byte(`{}`)); !errors.Is(err, ErrComponent) {
+		t.Fatalf("Execute: got %v, want ErrComponent", err)
+	}
+
+	if err := checkKind("core.wasm", []byte("\x00asm\x01\x00\x00\x00")); err != nil {
+		t.Fatalf("a core module was taken for a component: %v", err)
+	}
+	mod, err := Compile(ctx, buildSkill(t, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod.Close(ctx)
+}
@@ -0,0 +1,88 @@
+package runtime
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// CompressionEnv tells an SDK-built skill that stdin and stdout are gzip
+// streams; it matches skill.CompressionEnv. The executor sets it for a skill
+// whose manifest sets "compression": "gzip", compressing the args and
+// inflating stdout itself, so callers see plain JSON either way.
+const CompressionEnv = "ZEROCLAW_COMPRESSION"
+
+// CompressionGzip is the manifest "compression" value for gzip streams.
+const CompressionGzip = "gzip"
+
+// maxInflatedStdout bounds what a compressed stdout may inflate to.
+const maxInflatedStdout = 256 << 20
+
+// errStdoutTooLarge is returned when a compressed stdout inflates past
+// maxInflatedStdout.
+var errStdoutTooLarge = errors.New("compressed stdout inflates past 256 MiB")
+
+// ExecuteCompressed runs the skill at wasmPath with argsJSON using the default
+// Config, sending the args gzip-compressed. See Executor.ExecuteCompressed.
+func ExecuteCompressed(ctx context.Context, wasmPath string, argsJSON []byte) (*Result, error) {
+	return New(Config{}).ExecuteCompressed(ctx, wasmPath, argsJSON)
+}
+
+// ExecuteCompressed is Execute with argsJSON gzip-compressed on the way in,
+// for large args; the result comes back as plain JSON. An SDK-built skill
+// recognizes compressed args by gzip's magic bytes and inflates them before
+// decoding (see skill.InputEncodingEnv), and MaxInputBytes caps the
+// inflated size, so a decompression bomb fails as invalid_input. Skills not
+// built on the SDK must inflate the args themselves. Usage.BytesIn counts
+// the compressed bytes.
+func (e *Executor) ExecuteCompressed(ctx context.Context, wasmPath string, argsJSON []byte) (*Result, error) {
+	return e.Execute(ctx, wasmPath, gzipBytes(argsJSON))
+}
+
+// gzipStream returns r gzip-compressed, read as it is compressed. Call stop
+// once the guest is done so the compressing goroutine ends even if the
+// guest left stdin unread.
+func gzipStream(r io.Reader) (_ io.Reader, stop func()) {
+	pr, pw := io.Pipe()
+	go func() {
+		zw := gzip.NewWriter(pw)
+		_, err := io.Copy(zw, r)
+		if err == nil {
+			err = zw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, func() { pr.Close() }
+}
+
+// gzipBytes returns b gzip-compressed.
+func gzipBytes(b []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(b)
+	zw.Close()
+	return buf.Bytes()
+}
+
+// inflateStdout writes the gzip stream packed to w. A guest that wrote
+// nothing leaves w untouched.
+func inflateStdout(w io.Writer, packed []byte) error {
+	if len(packed) == 0 {
+		return nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(packed))
+	if err != nil {
+		return fmt.Errorf("compressed stdout: %w", err)
+	}
+	n, err := io.Copy(w, io.LimitReader(zr, maxInflatedStdout+1))
+	if err != nil {
+		return fmt.Errorf("compressed stdout: %w", err)
+	}
+	if n > maxInflatedStdout {
+		return errStdoutTooLarge
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCompressedSkillMatchesPlain(t *testing.T) {
+	wordCount := buildTemplate(t, "word_count")
+	plain := skillDir(t, wordCount, `{"name":"wc"}`)
+	packed := skillDir(t, wordCount, `{"name":"wc","compression":"gzip"}`)
+	args := []byte(`{"text":"` + strings.Repeat("the quick brown fox ", 5000) + `"}`)
+
+	// decoded runs one skill each way and returns its results without the
+	// per-invocation trace ID.
+	decoded := func(wasm string) []ToolResult {
+		ctx := context.Background()
+		res, err := Execute(ctx, wasm, args)
+		if err != nil {
+			t.Fatalf("%s: %v", wasm, err)
+		}
+		var out bytes.Buffer
+		if _, err := ExecuteReader(ctx, wasm, bytes.NewReader(args), &out); err != nil {
+			t.Fatalf("%s: %v", wasm, err)
+		}
+		var streamed ToolResult
+		if err := json.Unmarshal(out.Bytes(), &streamed); err != nil {
+			t.Fatalf("%s: w got %q: %v", wasm, out.Bytes(), err)
+		}
+		mod, err := Compile(ctx, wasm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer mod.Close(ctx)
+		in, err := mod.NewInstance(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		called, err := in.Call(ctx, args)
+		if err != nil {
+			t.Fatalf("%s: %v", wasm, err)
+		}
+		results := []ToolResult{res.ToolResult, streamed, called}
+		for i := range results {
+			results[i].Meta = nil
+		}
+		return results
+	}
+
+	want, got := decoded(plain), decoded(packed)
+	if !want[0].Success {
+		t.Fatalf("unexpected result: %+v", want[0])
+	}
+	for i := range want {
+		w, _ := json.Marshal(want[i])
+		g, _ := json.Marshal(got[i])
+		if !bytes.Equal(w, g) {
+			t.Errorf("run %d: compressed %s, plain %s", i, g, w)
+		}
+	}
+}
+
+func TestManifestRejectsCompressionItCannotStream(t *testing.T) {
+	for _, manifest := range []string{
+		`{"compression":"zstd"}`,
+		`{"compression":"gzip","input":"ndjson"}`,
+		`{"compression":"gzip","streaming":true}`,
+	} {
+		if _, err := parseCapabilities([]byte(manifest), "tool.wasm"); err == nil {
+			t.Errorf("%s: expected an error", manifest)
+		}
+	}
+}
+
+func TestExecuteCompressedMatchesPlain(t *testing.T) {
+	ctx := context.Background()
+	wasm := buildTemplate(t, "word_count")
+	args := []byte(`{"text":"` + strings.Repeat("the quick brown fox ", 5000) + `"}`)
+	plain, err := Execute(ctx, wasm, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rec bytes.Buffer
+	packed, err := New(Config{Recorder: &rec}).ExecuteCompressed(ctx, wasm, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !packed.Success || packed.Output != plain.Output || !bytes.Equal(packed.Data, plain.Data) {
+		t.Fatalf("compressed args: got %+v, want %+v", packed.ToolResult, plain.ToolResult)
+	}
+	if packed.Usage.BytesIn >= len(args)/10 {
+		t.Fatalf("sent %d bytes for %d of args", packed.Usage.BytesIn, len(args))
+	}
+	if !strings.Contains(rec.String(), `"args":{"text":"the quick`) {
+		t.Fatalf("record holds %.80q, want the inflated args", rec.String())
+	}
+}
+
+func TestExecuteCompressedBombHitsInputLimit(t *testing.T) {
+	ctx := context.Background()
+	wasm := buildTemplate(t, "word_count")
+	bomb := []byte(`{"text":"` + strings.Repeat(" ", 64<<20) + `"}`)
+	res, err := New(Config{MaxInputBytes: 1 << 20}).ExecuteCompressed(ctx, wasm, bomb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ErrorCode != "invalid_input" || res.Error == nil || !strings.Contains(*res.Error, "exceeds 1048576 bytes") {
+		t.Fatalf("got %+v, want the input limit", res.ToolResult)
+	}
+}
@@ -0,0 +1,7 @@
+package runtime
+
+// IfNoneMatchField is the args field through which a caller names the ETag
+// of a result it already holds; it matches skill.IfNoneMatchField. A skill
+// whose data has not changed since then answers with
+// ToolResult.NotModified set.
+const IfNoneMatchField = "if_none_match"
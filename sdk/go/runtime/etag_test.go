@@ -0,0 +1,26 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNotModifiedSurfacesOnResult(t *testing.T) {
+	wasm := buildSkill(t, "etag")
+	exec := New(Config{})
+	res, err := exec.Execute(context.Background(), wasm, []byte(`{"city":"Oslo"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.NotModified || res.ETag != "v1" || res.Output != "sunny in Oslo" {
+		t.Fatalf("first call: got %+v", res.ToolResult)
+	}
+
+	res, err = exec.Execute(context.Background(), wasm, []byte(`{"city":"Oslo","if_none_match":"v1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || !res.NotModified || res.ETag != "v1" {
+		t.Fatalf("matching ETag: got %+v, want not_modified with etag v1", res.ToolResult)
+	}
+}
@@ -0,0 +1,188 @@
+package runtime
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"golang.org/x/time/rate"
+)
+
+// HostModule and HTTPFetchFunc name the host import a skill calls to fetch a
+// URL from Go:
+//
+//	//go:wasmimport env zeroclaw_http_fetch
+//	func httpFetch(url unsafe.Pointer, urlLen uint32, out unsafe.Pointer, outCap uint32) int32
+//
+// It GETs the URL and copies up to outCap bytes of a 2xx response body to out.
+// It returns the full body length, which exceeds outCap when the copy was
+// truncated, or one of the negative Fetch* codes.
+//
+// HTTPGetFunc is the same request for any status: it also stores the status
+// code as a little-endian uint32 at status (see skill.Get).
+//
+//	//go:wasmimport env zeroclaw_http_get
+//	func httpGet(url unsafe.Pointer, urlLen uint32, out unsafe.Pointer, outCap uint32, status unsafe.Pointer) int32
+//
+// Both need the "net" capability in the skill's manifest.
+const (
+	HostModule    = "env"
+	HTTPFetchFunc = "zeroclaw_http_fetch"
+	HTTPGetFunc   = "zeroclaw_http_get"
+)
+
+// Negative results of zeroclaw_http_fetch and zeroclaw_http_get.
+const (
+	// FetchDenied means the Executor has no Config.HTTPClient or the skill's
+	// manifest does not grant capabilities.net.
+	FetchDenied int32 = -1
+	// FetchFailed means the request could not be made or, for
+	// zeroclaw_http_fetch, was not answered 2xx.
+	FetchFailed int32 = -2
+	// FetchRateLimited means the HTTPRateLimit budget could not admit the
+	// request before the invocation's deadline.
+	FetchRateLimited int32 = -3
+	// FetchLimitReached means the invocation already made MaxFetches requests.
+	FetchLimitReached int32 = -4
+	// FetchBadRequest means the URL or output buffer is not valid guest memory
+	// or the URL does not parse.
+	FetchBadRequest int32 = -5
+	// FetchDeadlineExceeded means the invocation's deadline passed, or its
+	// context was cancelled, before the response was read; the request was
+	// abandoned rather than left to hold the invocation past its deadline.
+	FetchDeadlineExceeded int32 = -6
+)
+
+// maxFetchBody bounds how much of a response body the host reads when
+// Config.MaxResponseBytes is zero.
+const maxFetchBody = 8 << 20
+
+// fetchKey carries the invocation's *fetchState in the call context.
+type fetchKey struct{}
+
+// fetchState is the fetch budget of one invocation. Guests are single-threaded,
+// so it needs no locking.
+type fetchState struct {
+	net     bool
+	limiter *rate.Limiter
+	count   int
+}
+
+// newFetchState returns a fresh budget for a skill granted caps. A zero
+// HTTPRateLimit means unlimited.
+func (e *Executor) newFetchState(caps capabilities) *fetchState {
+	limit, burst := e.cfg.HTTPRateLimit, e.cfg.HTTPBurst
+	if limit == 0 {
+		limit = rate.Inf
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &fetchState{net: caps.Net, limiter: rate.NewLimiter(limit, burst)}
+}
+
+func withFetchState(ctx context.Context, st *fetchState) context.Context {
+	return context.WithValue(ctx, fetchKey{}, st)
+}
+
+// instantiateHost registers the host functions skills may import, unless
+// Config.Sandboxed leaves them out.
+func (e *Executor) instantiateHost(ctx context.Context, rt wazero.Runtime) error {
+	if e.cfg.Sandboxed {
+		return nil
+	}
+	_, err := rt.NewHostModuleBuilder(HostModule).
+		NewFunctionBuilder().WithFunc(e.httpFetch).Export(HTTPFetchFunc).
+		NewFunctionBuilder().WithFunc(e.httpGet).Export(HTTPGetFunc).
+		Instantiate(ctx)
+	return err
+}
+
+// httpFetch implements zeroclaw_http_fetch on top of get, failing any
+// response that is not 2xx.
+func (e *Executor) httpFetch(ctx context.Context, m api.Module, urlPtr, urlLen, outPtr, outCap uint32) int32 {
+	status, body, code := e.get(ctx, m, urlPtr, urlLen)
+	if code != 0 {
+		return code
+	}
+	if status < 200 || status > 299 {
+		return FetchFailed
+	}
+	return writeBody(m, body, outPtr, outCap)
+}
+
+// httpGet implements zeroclaw_http_get on top of get.
+func (e *Executor) httpGet(ctx context.Context, m api.Module, urlPtr, urlLen, outPtr, outCap, statusPtr uint32) int32 {
+	status, body, code := e.get(ctx, m, urlPtr, urlLen)
+	if code != 0 {
+		return code
+	}
+	if !m.Memory().WriteUint32Le(statusPtr, uint32(status)) {
+		return FetchBadRequest
+	}
+	return writeBody(m, body, outPtr, outCap)
+}
+
+// get sends the GET request the guest asked for and reads the response, or
+// returns a negative Fetch* code. Requests beyond the rate budget wait for a
+// token; if waiting would outlast ctx's deadline the call fails with
+// FetchRateLimited instead. Only requests actually sent are counted. The
+// request, body read included, is bound to ctx, so a slow server is cut off
+// at the deadline with FetchDeadlineExceeded.
+func (e *Executor) get(ctx context.Context, m api.Module, urlPtr, urlLen uint32) (int, []byte, int32) {
+	st, _ := ctx.Value(fetchKey{}).(*fetchState)
+	if e.cfg.HTTPClient == nil || st == nil || !st.net {
+		return 0, nil, FetchDenied
+	}
+	if e.cfg.MaxFetches > 0 && st.count >= e.cfg.MaxFetches {
+		return 0, nil, FetchLimitReached
+	}
+	url, ok := m.Memory().Read(urlPtr, urlLen)
+	if !ok {
+		return 0, nil, FetchBadRequest
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, string(url), nil)
+	if err != nil {
+		return 0, nil, FetchBadRequest
+	}
+	if err := st.limiter.Wait(ctx); err != nil {
+		return 0, nil, FetchRateLimited
+	}
+
+	st.count++
+	resp, err := e.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return 0, nil, fetchFailure(ctx)
+	}
+	defer resp.Body.Close()
+	limit := e.cfg.MaxResponseBytes
+	if limit <= 0 {
+		limit = maxFetchBody
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)))
+	if err != nil {
+		return 0, nil, fetchFailure(ctx)
+	}
+	return resp.StatusCode, body, 0
+}
+
+// fetchFailure is the code for a request that failed: FetchDeadlineExceeded
+// once ctx is done, since that is why, and FetchFailed otherwise.
+func fetchFailure(ctx context.Context) int32 {
+	if ctx.Err() != nil {
+		return FetchDeadlineExceeded
+	}
+	return FetchFailed
+}
+
+// writeBody copies up to outCap bytes of body to guest memory and returns the
+// full body length.
+func writeBody(m api.Module, body []byte, outPtr, outCap uint32) int32 {
+	n := min(uint32(len(body)), outCap)
+	if !m.Memory().Write(outPtr, body[:n]) {
+		return FetchBadRequest
+	}
+	return int32(len(body))
+}
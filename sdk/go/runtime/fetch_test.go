@@ -0,0 +1,214 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// fetchCodes runs the fetch test skill against srv and returns what each call
+// of zeroclaw_http_fetch returned.
+func fetchCodes(t *testing.T, ctx context.Context, ex *Executor, url string, times int) ([]int32, *Result) {
+	t.Helper()
+	args := fmt.Sprintf(`{"url":%q,"times":%d}`, url, times)
+	wasm := skillDir(t, buildSkill(t, "fetch"), `{"capabilities":{"net":true}}`)
+	res, err := ex.Execute(ctx, wasm, []byte(args))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data struct {
+		Codes []int32 `json:"codes"`
+	}
+	if err := json.Unmarshal(res.Data, &data); err != nil {
+		t.Fatalf("bad data %s: %v", res.Data, err)
+	}
+	return data.Codes, res
+}
+
+func helloServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHTTPFetchDeniedWithoutClient(t *testing.T) {
+	codes, res := fetchCodes(t, context.Background(), New(Config{}), helloServer(t).URL, 1)
+	if len(codes) != 1 || codes[0] != FetchDenied || res.Fetches != 0 {
+		t.Fatalf("got codes %v and %d fetches, want [FetchDenied] and 0", codes, res.Fetches)
+	}
+}
+
+func TestHTTPFetchHonorsMaxFetches(t *testing.T) {
+	srv := helloServer(t)
+	ex := New(Config{HTTPClient: srv.Client(), MaxFetches: 2})
+	codes, res := fetchCodes(t, context.Background(), ex, srv.URL, 3)
+
+	want := []int32{5, 5, FetchLimitReached}
+	if fmt.Sprint(codes) != fmt.Sprint(want) {
+		t.Fatalf("got codes %v, want %v", codes, want)
+	}
+	if res.Output != "hello" || res.Fetches != 2 {
+		t.Fatalf("got output %q and %d fetches, want hello and 2", res.Output, res.Fetches)
+	}
+}
+
+func TestHTTPFetchRateLimitBlocksWithinBudget(t *testing.T) {
+	srv := helloServer(t)
+	ex := New(Config{HTTPClient: srv.Client(), HTTPRateLimit: rate.Limit(20), HTTPBurst: 1})
+
+	start := time.Now()
+	codes, res := fetchCodes(t, context.Background(), ex, srv.URL, 3)
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 fetches at 20/s took %v; expected the limiter to wait ~100ms", elapsed)
+	}
+	if fmt.Sprint(codes) != "[5 5 5]" || res.Fetches != 3 {
+		t.Fatalf("got codes %v and %d fetches, want three successes", codes, res.Fetches)
+	}
+}
+
+func TestHTTPFetchRateLimitedNearDeadline(t *testing.T) {
+	srv := helloServer(t)
+	ex := New(Config{HTTPClient: srv.Client(), HTTPRateLimit: rate.Every(time.Hour), HTTPBurst: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	codes, res := fetchCodes(t, ctx, ex, srv.URL, 2)
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("took %v; a fetch that cannot fit the deadline should fail fast", elapsed)
+	}
+	want := []int32{5, FetchRateLimited}
+	if fmt.Sprint(codes) != fmt.Sprint(want) || res.Fetches != 1 {
+		t.Fatalf("got codes %v and %d fetches, want %v and 1", codes, res.Fetches, want)
+	}
+}
+
+func TestHTTPFetchCancelledAtDeadline(t *testing.T) {
+	cancelled := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Send part of the body, then stall until the client gives up.
+		fmt.Fprint(w, "hel")
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+			close(cancelled)
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+	wasm := skillDir(t, buildSkill(t, "fetch"), `{"capabilities":{"net":true}}`)
+	mod, err := New(Config{HTTPClient: srv.Client()}).Compile(ctx, wasm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mod.Close(ctx)
+	in, err := mod.NewInstance(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	callCtx, cancel := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = in.Call(callCtx, []byte(fmt.Sprintf(`{"url":%q,"times":1}`, srv.URL)))
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("took %v; the fetch should be abandoned at the 300ms deadline", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want the deadline", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server never saw the request cancelled")
+	}
+}
+
+func TestHTTPFetchHonorsMaxResponseBytes(t *testing.T) {
+	srv := helloServer(t)
+	ex := New(Config{HTTPClient: srv.Client(), MaxResponseBytes: 3})
+	codes, res := fetchCodes(t, context.Background(), ex, srv.URL, 1)
+	if fmt.Sprint(codes) != "[3]" || res.Output != "hel" {
+		t.Fatalf("got codes %v and output %q, want the first 3 bytes", codes, res.Output)
+	}
+}
+
+func TestHTTPFetchDeniedWithoutNetCapability(t *testing.T) {
+	srv := helloServer(t)
+	ex := New(Config{HTTPClient: srv.Client()})
+	args := []byte(fmt.Sprintf(`{"url":%q,"times":1}`, srv.URL))
+	for name, wasm := range map[string]string{
+		"no manifest":   buildSkill(t, "fetch"),
+		"net not set":   skillDir(t, buildSkill(t, "fetch"), `{"capabilities":{"fs":["/data"]}}`),
+		"net set false": skillDir(t, buildSkill(t, "fetch"), `{"capabilities":{"net":false}}`),
+	} {
+		res, err := ex.Execute(context.Background(), wasm, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(res.Data) != fmt.Sprintf(`{"codes":[%d]}`, FetchDenied) || res.Fetches != 0 {
+			t.Errorf("%s: got %s and %d fetches, want FetchDenied and none sent", name, res.Data, res.Fetches)
+		}
+	}
+}
+
+// httpFetchTemplate runs templates/go/http_fetch, with its own manifest, on url.
+func httpFetchTemplate(t *testing.T, ex *Executor, manifest, url string) *Result {
+	t.Helper()
+	res, err := ex.Execute(context.Background(), skillDir(t, buildTemplate(t, "http_fetch"), manifest), []byte(fmt.Sprintf(`{"url":%q}`, url)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestHTTPFetchTemplate(t *testing.T) {
+	manifest, err := os.ReadFile(filepath.Join("..", "..", "..", "templates", "go", "http_fetch", ManifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path != "/hello" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "hello")
+	}))
+	defer srv.Close()
+	ex := New(Config{HTTPClient: srv.Client()})
+
+	t.Run("success", func(t *testing.T) {
+		res := httpFetchTemplate(t, ex, string(manifest), srv.URL+"/hello")
+		if !res.Success || string(res.Data) != `{"status":200,"length":5}` {
+			t.Fatalf("got %+v, data %s", res.ToolResult, res.Data)
+		}
+	})
+	t.Run("not found", func(t *testing.T) {
+		res := httpFetchTemplate(t, ex, string(manifest), srv.URL+"/missing")
+		if res.Success || res.ErrorCode != "not_found" || string(res.Data) != `{"status":404,"length":19}` {
+			t.Fatalf("got %+v, data %s", res.ToolResult, res.Data)
+		}
+	})
+	t.Run("capability denied", func(t *testing.T) {
+		before := hits.Load()
+		res := httpFetchTemplate(t, ex, `{"name":"http_fetch"}`, srv.URL+"/hello")
+		if sent := hits.Load() - before; res.Success || res.ErrorCode != "permission_denied" || sent != 0 {
+			t.Fatalf("got %+v after %d requests; want permission_denied and none sent", res.ToolResult, sent)
+		}
+	})
+}
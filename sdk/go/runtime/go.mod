@@ -0,0 +1,10 @@
+module github.com/zeroclaw-labs/zeroclaw/sdk/go/runtime
+
+go 1.25.0
+
+require (
+	github.com/tetratelabs/wazero v1.12.0
+	golang.org/x/time v0.15.0
+)
+
+require golang.org/x/sys v0.44.0 // indirect
@@ -0,0 +1,48 @@
+package runtime
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Health paths HealthHandler answers.
+const (
+	HealthzPath = "/healthz"
+	ReadyzPath  = "/readyz"
+)
+
+// HealthHandler answers a supervisor's liveness and readiness probes for a
+// host serving m, e.g. http.ListenAndServe(":8081", m.HealthHandler()):
+//
+//	GET /healthz  200 while m is compiled and not closed
+//	GET /readyz   200 once m has instantiated an Instance successfully
+//
+// and 503 otherwise. The body is {"status":"ok"}, or {"status":"unavailable"}
+// with a "reason". Other paths are 404.
+func (m *Module) HealthHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(HealthzPath, func(w http.ResponseWriter, _ *http.Request) {
+		writeHealth(w, m.closed.Load(), "module is closed")
+	})
+	mux.HandleFunc(ReadyzPath, func(w http.ResponseWriter, _ *http.Request) {
+		if m.closed.Load() {
+			writeHealth(w, true, "module is closed")
+			return
+		}
+		writeHealth(w, !m.instantiated.Load(), "module has not been instantiated yet")
+	})
+	return mux
+}
+
+// writeHealth writes a probe's answer: 200, or 503 with reason when down.
+func writeHealth(w http.ResponseWriter, down bool, reason string) {
+	body := map[string]string{"status": "ok"}
+	code := http.StatusOK
+	if down {
+		body = map[string]string{"status": "unavailable", "reason": reason}
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
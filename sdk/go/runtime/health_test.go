@@ -0,0 +1,50 @@
+package runtime
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthHandlerReportsReadiness(t *testing.T) {
+	mod, err := Compile(context.Background(), buildSkill(t, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(mod.HealthHandler())
+	defer srv.Close()
+	probe := func(path string) (int, string) {
+		t.Helper()
+		resp, err := srv.Client().Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if code, body := probe(HealthzPath); code != 200 || body != `{"status":"ok"}`+"\n" {
+		t.Fatalf("a compiled module should be live, got %d %s", code, body)
+	}
+	if code, body := probe(ReadyzPath); code != 503 || body != `{"reason":"module has not been instantiated yet","status":"unavailable"}`+"\n" {
+		t.Fatalf("a never-instantiated module should not be ready, got %d %s", code, body)
+	}
+
+	in, err := mod.NewInstance(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	in.Close(context.Background())
+	if code, body := probe(ReadyzPath); code != 200 {
+		t.Fatalf("an instantiated module should be ready, got %d %s", code, body)
+	}
+
+	mod.Close(context.Background())
+	for _, path := range []string{HealthzPath, ReadyzPath} {
+		if code, body := probe(path); code != 503 {
+			t.Fatalf("%s: a closed module should be down, got %d %s", path, code, body)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// tinyPNG is a 3x2 RGB PNG.
+const tinyPNG = "iVBORw0KGgoAAAANSUhEUgAAAAMAAAACCAIAAAASFvFNAAAAEElEQVR4nGP4z8AAQQxwFgBB0gX7h/C5SAAAAABJRU5ErkJggg=="
+
+func TestImageInfoDecodesPNG(t *testing.T) {
+	res, err := Execute(context.Background(), buildTemplate(t, "image_info"),
+		[]byte(`{"data_base64":"`+tinyPNG+`","mime":"image/png"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info struct {
+		Bytes, Width, Height int
+		Format, Mime         string
+	}
+	if err := json.Unmarshal(res.Data, &info); err != nil || !res.Success {
+		t.Fatalf("unexpected result %+v: %v", res.ToolResult, err)
+	}
+	if info.Bytes != 73 || info.Format != "PNG" || info.Width != 3 || info.Height != 2 {
+		t.Fatalf("got %+v, want a 73-byte 3x2 PNG", info)
+	}
+}
+
+func TestImageInfoRejectsBadData(t *testing.T) {
+	wasm := buildTemplate(t, "image_info")
+	for name, c := range map[string]struct{ args, want string }{
+		"garbage":   {`{"data_base64":"aGVsbG8gd29ybGQ="}`, "not a PNG, JPEG, or GIF"},
+		"truncated": {`{"data_base64":"iVBORw0KGgoAAAAN"}`, "corrupt PNG"},
+		"mime":      {`{"data_base64":"` + tinyPNG + `","mime":"image/jpeg"}`, "but the data is image/png"},
+		"base64":    {`{"data_base64":"not base64!"}`, "illegal base64"},
+	} {
+		res, err := Execute(context.Background(), wasm, []byte(c.args))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if res.Success || res.ErrorCode != "invalid_input" || res.Error == nil || !strings.Contains(*res.Error, c.want) {
+			t.Errorf("%s: want invalid_input mentioning %q, got %+v", name, c.want, res.ToolResult)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// CapabilityNet is the manifest capability the HTTP host functions need.
+const CapabilityNet = "net"
+
+// hostFuncCapabilities maps each function of HostModule to the manifest
+// capability that grants it.
+var hostFuncCapabilities = map[string]string{
+	HTTPFetchFunc: CapabilityNet,
+	HTTPGetFunc:   CapabilityNet,
+}
+
+// CapabilityReport is what InspectCapabilities finds in a skill: the host
+// functions its code imports, the capabilities its manifest declares, and
+// what it says calling it does. It marshals to JSON for a policy engine.
+type CapabilityReport struct {
+	// Imports lists every function the module imports other than WASI's,
+	// in import order.
+	Imports []HostImport `json:"imports"`
+	// Declared lists the capabilities the manifest grants: CapabilityNet,
+	// and "secret:NAME" for each secret it names.
+	Declared []string `json:"declared"`
+	// Undeclared lists, as "module.name", the Imports whose capability the
+	// manifest does not grant, and those no capability grants because the
+	// Executor does not provide them.
+	Undeclared []string `json:"undeclared,omitempty"`
+	// SideEffects and Idempotent are the manifest's "side_effects" and
+	// "idempotent": SideEffectsWrite and false unless it says otherwise, so
+	// a policy can, say, approve SideEffectsRead skills without asking.
+	SideEffects string `json:"side_effects"`
+	Idempotent  bool   `json:"idempotent"`
+}
+
+// HostImport is one function a skill imports from the host.
+type HostImport struct {
+	Module string `json:"module"`
+	Name   string `json:"name"`
+	// Capability is the manifest capability that grants the function, or ""
+	// when the Executor does not provide it and instantiating would fail.
+	Capability string `json:"capability,omitempty"`
+}
+
+// InspectCapabilities is (*Executor).InspectCapabilities with a zero Config.
+func InspectCapabilities(ctx context.Context, wasmPath string) (*CapabilityReport, error) {
+	return New(Config{}).InspectCapabilities(ctx, wasmPath)
+}
+
+// InspectCapabilities reads the import section of the skill at wasmPath, a
+// module or package as for Execute, and reports which host functions it
+// depends on against what its manifest declares. The module is compiled
+// but never instantiated, so none of its code runs. A skill whose report
+// has Undeclared entries asks for more at run time than its manifest says.
+func (e *Executor) InspectCapabilities(ctx context.Context, wasmPath string) (*CapabilityReport, error) {
+	wasm, caps, err := e.loadModule(wasmPath)
+	if err != nil {
+		return nil, err
+	}
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfigInterpreter())
+	defer rt.Close(ctx)
+	compiled, err := rt.CompileModule(ctx, wasm)
+	if err != nil {
+		return nil, fmt.Errorf("compile %s: %w", wasmPath, err)
+	}
+
+	report := &CapabilityReport{
+		Imports:     []HostImport{},
+		Declared:    []string{},
+		SideEffects: caps.sideEffects,
+		Idempotent:  caps.idempotent,
+	}
+	if caps.Net {
+		report.Declared = append(report.Declared, CapabilityNet)
+	}
+	for _, name := range caps.Secrets {
+		report.Declared = append(report.Declared, "secret:"+name)
+	}
+	for _, def := range compiled.ImportedFunctions() {
+		module, name, _ := def.Import()
+		if module == wasi_snapshot_preview1.ModuleName {
+			continue
+		}
+		imp := HostImport{Module: module, Name: name}
+		if module == HostModule {
+			imp.Capability = hostFuncCapabilities[name]
+		}
+		report.Imports = append(report.Imports, imp)
+		if imp.Capability == "" || !slices.Contains(report.Declared, imp.Capability) {
+			report.Undeclared = append(report.Undeclared, module+"."+name)
+		}
+	}
+	return report, nil
+}
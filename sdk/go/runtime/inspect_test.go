@@ -0,0 +1,50 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestInspectCapabilitiesFlagsUndeclaredImports(t *testing.T) {
+	fetch := buildSkill(t, "fetch")
+	report, err := InspectCapabilities(context.Background(), skillDir(t, fetch, `{"name":"fetch"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []HostImport{{Module: HostModule, Name: HTTPFetchFunc, Capability: CapabilityNet}}
+	if !slices.Equal(report.Imports, want) || !slices.Equal(report.Undeclared, []string{"env.zeroclaw_http_fetch"}) {
+		t.Fatalf("undeclared net: got %+v", report)
+	}
+
+	report, err = InspectCapabilities(context.Background(), skillDir(t, fetch, `{"name":"fetch","capabilities":{"net":true,"secrets":["API_KEY"]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := json.Marshal(report)
+	if got := `{"imports":[{"module":"env","name":"zeroclaw_http_fetch","capability":"net"}],"declared":["net","secret:API_KEY"],"side_effects":"write","idempotent":false}`; string(raw) != got {
+		t.Fatalf("declared net: got %s, want %s", raw, got)
+	}
+}
+
+func TestInspectCapabilitiesOfPureSkill(t *testing.T) {
+	report, err := InspectCapabilities(context.Background(), buildSkill(t, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Imports) != 0 || len(report.Declared) != 0 || len(report.Undeclared) != 0 {
+		t.Fatalf("a WASI-only skill with no manifest should report nothing, got %+v", report)
+	}
+	if report.SideEffects != SideEffectsWrite || report.Idempotent {
+		t.Fatalf("a skill with no manifest should be taken to write, got %+v", report)
+	}
+
+	report, err = InspectCapabilities(context.Background(), skillDir(t, buildSkill(t, "echo"), `{"side_effects":"none","idempotent":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.SideEffects != SideEffectsNone || !report.Idempotent {
+		t.Fatalf("declared side effects: got %+v", report)
+	}
+}
@@ -0,0 +1,532 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+var (
+	// ErrInstanceBusy is returned when Call is entered while another goroutine
+	// is already calling the same Instance.
+	ErrInstanceBusy = errors.New("instance is already handling a call")
+	// ErrInstanceUsed is returned when Call is made on an Instance that has
+	// already run. WASI commands run _start once; use a fresh Instance.
+	ErrInstanceUsed = errors.New("instance has already been called")
+	// ErrPoolBusy is returned by Call on a pool from NewBoundedPool when every
+	// instance is busy and the queue of waiting calls is full.
+	ErrPoolBusy = errors.New("pool is at capacity")
+)
+
+// Module is a compiled skill that can be instantiated many times without
+// paying compile cost again. It is safe for concurrent use.
+type Module struct {
+	exec    *Executor
+	path    string
+	caps    capabilities
+	secrets map[string]string
+	red     *redactor
+	rt      wazero.Runtime
+	// code is swapped by WatchFile, which reloadMu serializes.
+	code     atomic.Pointer[compiledModule]
+	reloadMu sync.Mutex
+	// instantiated and closed feed HealthHandler.
+	instantiated, closed atomic.Bool
+}
+
+// Compile compiles the skill at wasmPath using the default Config.
+func Compile(ctx context.Context, wasmPath string) (*Module, error) {
+	return New(Config{}).Compile(ctx, wasmPath)
+}
+
+// Compile compiles the skill at wasmPath for repeated instantiation. The
+// Module owns a wazero runtime; call Close when done with it.
+func (e *Executor) Compile(ctx context.Context, wasmPath string) (*Module, error) {
+	if err := checkResultFields(e.cfg.ResultFields); err != nil {
+		return nil, err
+	}
+	wasm, caps, err := e.loadModule(wasmPath)
+	if err != nil {
+		return nil, err
+	}
+	if e.cfg.Sandboxed {
+		if err := checkSandboxCaps(wasmPath, caps); err != nil {
+			return nil, err
+		}
+	}
+	secrets, err := e.secretsFor(wasmPath, caps)
+	if err != nil {
+		return nil, err
+	}
+	stamp, err := stampFile(wasmPath)
+	if err != nil {
+		return nil, fmt.Errorf("read skill module: %w", err)
+	}
+
+	// Closing a Call's ctx closes only the instance it was calling; the
+	// runtime outlives any one call.
+	rt := wazero.NewRuntimeWithConfig(ctx, e.runtimeConfig())
+	wasi_snapshot_preview1.MustInstantiate(ctx, rt)
+	if err := e.instantiateHost(ctx, rt); err != nil {
+		rt.Close(ctx)
+		return nil, err
+	}
+
+	start := time.Now()
+	compiled, err := rt.CompileModule(ctx, wasm)
+	e.span(SpanCompile, start)
+	if err != nil {
+		rt.Close(ctx)
+		return nil, fmt.Errorf("compile %s: %w", wasmPath, err)
+	}
+	if e.cfg.Sandboxed {
+		if err := checkSandboxImports(wasmPath, compiled); err != nil {
+			rt.Close(ctx)
+			return nil, err
+		}
+	}
+	m := &Module{
+		exec: e, path: wasmPath, caps: caps, secrets: secrets, red: newRedactor(secrets),
+		rt: rt,
+	}
+	m.code.Store(&compiledModule{code: compiled, stamp: stamp, schema: new(lazySchema)})
+	return m, nil
+}
+
+// Close releases the runtime and every Instance created from m.
+func (m *Module) Close(ctx context.Context) error {
+	m.closed.Store(true)
+	return m.rt.Close(ctx)
+}
+
+// Instance is an instantiated, not-yet-run skill. A WASI command's _start can
+// run only once and leaves its linear memory dirty, so an Instance serves
+// exactly one Call; further calls fail with ErrInstanceUsed and a concurrent
+// call fails with ErrInstanceBusy. Use a Pool to keep fresh instances ready.
+type Instance struct {
+	mod    *Module
+	inst   api.Module
+	stdin  callReader
+	stdout bytes.Buffer
+	out    *capWriter
+	stderr bytes.Buffer
+	// flushErr flushes the last line of stderr to Config.StderrSink.
+	flushErr func() error
+	// removeScratch removes the instance's Config.ScratchRoot directory.
+	removeScratch func()
+	state         atomic.Int32
+}
+
+const (
+	instanceReady int32 = iota
+	instanceBusy
+	instanceUsed
+)
+
+// NewInstance instantiates m without running it, so the instantiate cost can
+// be paid ahead of the request that will use it. The guest environment is
+// fixed here, so an Instance is told Config.MaxOutputBytes but not the
+// deadline of the Call that later runs it. With Config.AutoReload, m is
+// first recompiled if its file changed; see WatchFile.
+func (m *Module) NewInstance(ctx context.Context) (*Instance, error) {
+	if m.exec.cfg.AutoReload {
+		if _, err := m.WatchFile(ctx); err != nil {
+			return nil, err
+		}
+	}
+	in := &Instance{mod: m}
+	scratch, removeScratch, err := m.exec.newScratch()
+	if err != nil {
+		return nil, fmt.Errorf("scratch dir for %s: %w", m.path, err)
+	}
+	in.removeScratch = removeScratch
+	in.out = m.exec.capOutput(&in.stdout, m.caps)
+	var errOut io.Writer
+	errOut, in.flushErr = m.exec.stderrTo(&in.stderr, m.red)
+	cfg := wazero.NewModuleConfig().
+		WithName(""). // anonymous, so one runtime can hold many instances
+		WithStdin(&in.stdin).
+		WithStdout(in.out).
+		WithStderr(errOut).
+		WithStartFunctions()
+	if !m.exec.cfg.Sandboxed {
+		cfg = withManifest(m.exec.withBudget(context.WithoutCancel(ctx), cfg, m.caps), m.caps) // no deadline
+		cfg = withSecrets(cfg.WithEnv(TraceIDEnv, newTraceID()), m.secrets)
+		cfg = withSeed(withScratch(cfg, scratch), m.exec.cfg.Seed)
+	}
+
+	start := time.Now()
+	inst, err := m.rt.InstantiateModule(ctx, m.code.Load().code, cfg)
+	m.exec.span(SpanInstantiate, start)
+	if err != nil {
+		removeScratch()
+		return nil, fmt.Errorf("instantiate %s: %w", m.path, err)
+	}
+	in.inst = inst
+	m.instantiated.Store(true)
+	return in, nil
+}
+
+// Call runs the instance with argsJSON on stdin and parses its ToolResult.
+// The fetch and output limits in Config apply to each Call on its own, and a
+// skill still running when ctx is done is stopped with ctx's error.
+func (in *Instance) Call(ctx context.Context, argsJSON []byte) (ToolResult, error) {
+	res, err := in.observe(ctx, argsJSON)
+	return in.mod.exec.project(res), err
+}
+
+// observe is Call before the result is projected to Config.ResultFields.
+func (in *Instance) observe(ctx context.Context, argsJSON []byte) (ToolResult, error) {
+	start := time.Now()
+	res, err := in.call(ctx, argsJSON)
+	if m := in.mod.exec.cfg.Metrics; m != nil {
+		m.observe(&res, err, time.Since(start))
+	}
+	return res, err
+}
+
+func (in *Instance) call(ctx context.Context, argsJSON []byte) (ToolResult, error) {
+	if !in.state.CompareAndSwap(instanceReady, instanceBusy) {
+		if in.state.Load() == instanceBusy {
+			return ToolResult{}, ErrInstanceBusy
+		}
+		return ToolResult{}, ErrInstanceUsed
+	}
+	defer in.state.Store(instanceUsed)
+	defer in.removeScratch() // after the guest's files are closed
+	defer in.inst.Close(ctx)
+
+	run := in.inst.ExportedFunction("_start")
+	if run == nil {
+		return ToolResult{}, fmt.Errorf("%s: no _start export (build with -target=wasip1)", in.mod.path)
+	}
+	if in.mod.caps.gzip {
+		argsJSON = gzipBytes(argsJSON)
+	}
+	in.stdin.r = bytes.NewReader(argsJSON)
+
+	start := time.Now()
+	_, err := run.Call(withFetchState(ctx, in.mod.exec.newFetchState(in.mod.caps)))
+	in.mod.exec.span(SpanExecute, start)
+	if err := in.flushErr(); err != nil {
+		return ToolResult{}, fmt.Errorf("run %s: stderr sink: %w", in.mod.path, err)
+	}
+	if in.out.exceeded && ctx.Err() == nil {
+		return in.mod.exec.oversized(in.mod.path, in.mod.caps, in.out)
+	}
+	if ctx.Err() == nil && isStackOverflow(err) {
+		return stackOverflow(), nil
+	}
+	if _, err := exitCode(ctx, err); err != nil {
+		return ToolResult{}, fmt.Errorf("run %s: %w\n%s", in.mod.path, err, in.mod.red.redact(in.stderr.Bytes()))
+	}
+
+	stdout := in.stdout.Bytes()
+	if in.mod.caps.gzip {
+		var plain bytes.Buffer
+		if err := inflateStdout(&plain, stdout); err != nil {
+			return ToolResult{}, fmt.Errorf("run %s: %w", in.mod.path, err)
+		}
+		stdout = plain.Bytes()
+	}
+	if _, _, ok := cutRawHeader(in.stderr.Bytes()); ok {
+		return ToolResult{}, fmt.Errorf("%s: returned a raw result, which only Execute and ExecuteReader can hold", in.mod.path)
+	}
+	if err := checkUTF8(in.mod.path, stdout); err != nil {
+		return ToolResult{}, err
+	}
+	var res ToolResult
+	if err := decodeResult(in.mod.red.redact(stdout), &res); err != nil {
+		return ToolResult{}, fmt.Errorf("%s: stdout is not a JSON ToolResult: %w", in.mod.path, err)
+	}
+	if err := checkOutputType(res.OutputType); err != nil {
+		return ToolResult{}, fmt.Errorf("%s: %w", in.mod.path, err)
+	}
+	if err := renderOutput(in.mod.path, in.mod.caps.outputTemplate, &res); err != nil {
+		return ToolResult{}, err
+	}
+	return res, nil
+}
+
+// Close releases an instance that will not be called.
+func (in *Instance) Close(ctx context.Context) error {
+	in.state.Store(instanceUsed)
+	defer in.removeScratch()
+	return in.inst.Close(ctx)
+}
+
+// callReader lets stdin be bound at instantiate time and filled at Call time.
+type callReader struct {
+	r io.Reader
+}
+
+func (c *callReader) Read(p []byte) (int, error) {
+	if c.r == nil {
+		return 0, io.EOF
+	}
+	return c.r.Read(p)
+}
+
+// Pool keeps up to size fresh Instances of a Module ready. Each Call takes one,
+// runs it, and discards it; a replacement is instantiated in the background,
+// so no instance ever sees a second request. Pool is safe for concurrent use.
+type Pool struct {
+	mod   *Module
+	ready chan *Instance
+	wg    sync.WaitGroup
+	once  sync.Once
+	done  chan struct{}
+	// slots is nil but for a pool from NewBoundedPool, where it holds one
+	// token for each instance the pool may still create. An instance holds
+	// its token from before it is instantiated until its Call returns.
+	slots   chan struct{}
+	queue   int64
+	waiting atomic.Int64
+	// peak is the most tokens a bounded pool has had out at once.
+	peak atomic.Int64
+}
+
+// NewPool returns a Pool for m and starts filling it with size instances.
+func NewPool(ctx context.Context, m *Module, size int) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	p := &Pool{mod: m, ready: make(chan *Instance, size), done: make(chan struct{})}
+	for i := 0; i < size; i++ {
+		p.refill(ctx)
+	}
+	return p
+}
+
+// Prewarm is NewPool for hosts that cannot have the first calls wait: it
+// instantiates n instances of m before returning, where NewPool fills its
+// pool in the background, so up to n calls right after it pay no
+// instantiate cost. The pool then keeps n ready as NewPool's does. If an
+// instance cannot be made, Prewarm closes the others and returns the error.
+func (m *Module) Prewarm(ctx context.Context, n int) (*Pool, error) {
+	if n < 1 {
+		n = 1
+	}
+	p := &Pool{mod: m, ready: make(chan *Instance, n), done: make(chan struct{})}
+	for i := 0; i < n; i++ {
+		in, err := m.NewInstance(ctx)
+		if err != nil {
+			p.Close(ctx)
+			return nil, err
+		}
+		p.ready <- in
+	}
+	return p, nil
+}
+
+// NewBoundedPool is NewPool for hosts that must cap what a burst of requests
+// costs: no more than size instances of m exist at once, idle and running
+// together. A Call that finds all of them busy waits for one, up to queue
+// such calls at a time; one more fails with ErrPoolBusy at once, and a
+// waiting call gives up with ctx's error when ctx is done.
+func NewBoundedPool(ctx context.Context, m *Module, size, queue int) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	p := &Pool{
+		mod: m, ready: make(chan *Instance, size), done: make(chan struct{}),
+		slots: make(chan struct{}, size), queue: int64(max(queue, 0)),
+	}
+	for i := 0; i < size; i++ {
+		p.slots <- struct{}{}
+	}
+	for i := 0; i < size; i++ {
+		p.refill(ctx)
+	}
+	return p
+}
+
+// Call runs argsJSON on a fresh instance. When none is ready it instantiates
+// one inline rather than waiting for the background refill; a pool from
+// NewBoundedPool does so only while it is below its size. A retry under
+// Config.Retries takes another instance the same way.
+func (p *Pool) Call(ctx context.Context, argsJSON []byte) (ToolResult, error) {
+	res, err := p.call(ctx, argsJSON)
+	return p.mod.exec.project(res), err
+}
+
+// call is Call before the result is projected to Config.ResultFields.
+func (p *Pool) call(ctx context.Context, argsJSON []byte) (ToolResult, error) {
+	return p.mod.exec.retry(ctx, p.mod.caps, func() (ToolResult, error) {
+		return p.attempt(ctx, argsJSON)
+	})
+}
+
+// attempt runs argsJSON once, on an instance no call has used.
+func (p *Pool) attempt(ctx context.Context, argsJSON []byte) (ToolResult, error) {
+	in, err := p.Get(ctx)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	res, err := in.observe(ctx, argsJSON)
+	p.Put(ctx, in)
+	return res, err
+}
+
+// Get takes a fresh instance from p for a host that calls it itself, e.g.
+// to hold it across a request's setup; Call does Get, Instance.Call, and
+// Put in one, with retries. It instantiates one inline when none is ready,
+// as Call does, and a pool from NewBoundedPool may make it wait or fail
+// with ErrPoolBusy the same way. Give the instance back with Put.
+func (p *Pool) Get(ctx context.Context) (*Instance, error) {
+	if p.slots != nil {
+		return p.take(ctx)
+	}
+	select {
+	case in := <-p.ready:
+		p.refill(context.WithoutCancel(ctx))
+		return in, nil
+	default:
+	}
+	in, err := p.mod.NewInstance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.refill(context.WithoutCancel(ctx))
+	return in, nil
+}
+
+// Put gives back an instance from Get once any Call on it has returned.
+// One that was never called is kept for the next Get or Call if there is
+// room, and closed if not; one that was is done with, and a pool from
+// NewBoundedPool instantiates its replacement.
+func (p *Pool) Put(ctx context.Context, in *Instance) {
+	if in.state.Load() == instanceReady {
+		select {
+		case p.ready <- in:
+			return
+		default:
+		}
+		in.Close(ctx)
+		p.drop()
+		return
+	}
+	if p.slots != nil {
+		p.release()
+		p.refill(context.WithoutCancel(ctx))
+	}
+}
+
+// take returns a ready instance, or instantiates one under a free token,
+// waiting for either when there is room in the queue.
+func (p *Pool) take(ctx context.Context) (*Instance, error) {
+	select {
+	case in := <-p.ready:
+		return in, nil
+	case <-p.slots:
+		return p.instantiate(ctx)
+	default:
+	}
+	if p.waiting.Add(1) > p.queue {
+		p.waiting.Add(-1)
+		return nil, ErrPoolBusy
+	}
+	defer p.waiting.Add(-1)
+	select {
+	case in := <-p.ready:
+		return in, nil
+	case <-p.slots:
+		return p.instantiate(ctx)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// instantiate makes an instance under a token just taken from p.slots, and
+// gives the token back if it cannot.
+func (p *Pool) instantiate(ctx context.Context) (*Instance, error) {
+	p.hold()
+	in, err := p.mod.NewInstance(ctx)
+	if err != nil {
+		p.release()
+		return nil, err
+	}
+	return in, nil
+}
+
+// hold records that a token was taken from p.slots.
+func (p *Pool) hold() {
+	out := int64(cap(p.slots) - len(p.slots))
+	for {
+		peak := p.peak.Load()
+		if out <= peak || p.peak.CompareAndSwap(peak, out) {
+			return
+		}
+	}
+}
+
+// release returns a token to p.slots.
+func (p *Pool) release() {
+	p.slots <- struct{}{}
+}
+
+// refill instantiates one instance in the background; it is dropped if the
+// pool is full or closed. A bounded pool refills only under a free token.
+func (p *Pool) refill(ctx context.Context) {
+	if p.slots != nil {
+		select {
+		case <-p.slots:
+			p.hold()
+		default:
+			return
+		}
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		select {
+		case <-p.done:
+			p.drop()
+			return
+		default:
+		}
+		in, err := p.mod.NewInstance(ctx)
+		if err != nil {
+			p.drop()
+			return // the next Call reports the error when it instantiates inline
+		}
+		select {
+		case p.ready <- in:
+		default:
+			in.Close(ctx)
+			p.drop()
+		}
+	}()
+}
+
+// drop gives back the token of an instance a bounded pool will not keep.
+func (p *Pool) drop() {
+	if p.slots != nil {
+		p.release()
+	}
+}
+
+// Close stops refilling and releases idle instances. It must not race with
+// Call, and it does not close the Module.
+func (p *Pool) Close(ctx context.Context) {
+	p.once.Do(func() { close(p.done) })
+	p.wg.Wait()
+	for {
+		select {
+		case in := <-p.ready:
+			in.Close(ctx)
+			p.drop()
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInstanceIsSingleUse(t *testing.T) {
+	ctx := context.Background()
+	mod, err := Compile(ctx, buildSkill(t, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mod.Close(ctx)
+
+	in, err := mod.NewInstance(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := in.Call(ctx, []byte("first"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.Output != "first" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if _, err := in.Call(ctx, []byte("second")); !errors.Is(err, ErrInstanceUsed) {
+		t.Fatalf("second call: got %v, want ErrInstanceUsed", err)
+	}
+}
+
+func TestInstanceRejectsConcurrentCall(t *testing.T) {
+	ctx := context.Background()
+	mod, err := Compile(ctx, buildSkill(t, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mod.Close(ctx)
+
+	in, err := mod.NewInstance(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in.state.Store(instanceBusy) // as if another goroutine were mid-call
+	if _, err := in.Call(ctx, nil); !errors.Is(err, ErrInstanceBusy) {
+		t.Fatalf("got %v, want ErrInstanceBusy", err)
+	}
+}
+
+func TestPoolServesConcurrentCalls(t *testing.T) {
+	ctx := context.Background()
+	mod, err := Compile(ctx, buildSkill(t, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mod.Close(ctx)
+	pool := NewPool(ctx, mod, 4)
+
+	const calls = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, calls)
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			want := fmt.Sprintf("call-%d", i)
+			res, err := pool.Call(ctx, []byte(want))
+			if err == nil && res.Output != want {
+				err = fmt.Errorf("got output %q, want %q", res.Output, want)
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	pool.Close(ctx)
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestBoundedPoolCapsInstances(t *testing.T) {
+	ctx := context.Background()
+	mod, err := Compile(ctx, buildSkill(t, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mod.Close(ctx)
+	pool := NewBoundedPool(ctx, mod, 3, 64)
+
+	const calls = 24
+	var wg sync.WaitGroup
+	errs := make(chan error, calls)
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			want := fmt.Sprintf("call-%d", i)
+			res, err := pool.Call(ctx, []byte(want))
+			if err == nil && res.Output != want {
+				err = fmt.Errorf("got output %q, want %q", res.Output, want)
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	pool.Close(ctx)
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if peak := pool.peak.Load(); peak < 1 || peak > 3 {
+		t.Fatalf("peak of %d instances, want 1 to 3", peak)
+	}
+}
+
+func TestBoundedPoolPushesBack(t *testing.T) {
+	ctx := context.Background()
+	mod, err := Compile(ctx, buildSkill(t, "spin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mod.Close(ctx)
+	pool := NewBoundedPool(ctx, mod, 1, 1)
+	defer pool.Close(ctx)
+
+	// The spinning call holds the only instance until its context ends. It
+	// must find it ready: if it had to queue for it, a call started after
+	// it could take the queue's one place.
+	waitFor(t, "the pool's instance to be ready", func() bool { return len(pool.ready) > 0 })
+	spinCtx, stop := context.WithCancel(ctx)
+	spun := make(chan error, 1)
+	go func() {
+		_, err := pool.Call(spinCtx, []byte(`{}`))
+		spun <- err
+	}()
+	waitFor(t, "the spinning call to take the instance", func() bool {
+		return pool.peak.Load() > 0 && len(pool.ready) == 0
+	})
+
+	waitCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	waited := make(chan error, 1)
+	go func() {
+		_, err := pool.Call(waitCtx, []byte(`{}`))
+		waited <- err
+	}()
+	waitFor(t, "a call to queue", func() bool { return pool.waiting.Load() > 0 })
+	if _, err := pool.Call(ctx, []byte(`{}`)); !errors.Is(err, ErrPoolBusy) {
+		t.Fatalf("call past the queue: got %v, want ErrPoolBusy", err)
+	}
+	if err := <-waited; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("queued call: got %v, want its deadline", err)
+	}
+	stop()
+	<-spun
+	if peak := pool.peak.Load(); peak != 1 {
+		t.Fatalf("peak of %d instances, want 1", peak)
+	}
+}
+
+// waitFor polls cond until it holds, failing t if it does not within ten
+// seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPrewarmFillsThePoolBeforeReturning(t *testing.T) {
+	ctx := context.Background()
+	mod, err := Compile(ctx, buildSkill(t, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mod.Close(ctx)
+	pool, err := mod.Prewarm(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close(ctx)
+	if n := len(pool.ready); n != 3 {
+		t.Fatalf("%d instances ready, want 3", n)
+	}
+
+	// An instance put back unused is the next one handed out; a used one is not.
+	in, err := pool.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Put(ctx, in)
+	for i := 0; i < cap(pool.ready); i++ {
+		got, err := pool.Get(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := got.Call(ctx, []byte("hi"))
+		if err != nil || res.Output != "hi" {
+			t.Fatalf("call %d: got %+v, %v", i, res, err)
+		}
+		pool.Put(ctx, got)
+	}
+	if _, err := in.Call(ctx, nil); !errors.Is(err, ErrInstanceUsed) {
+		t.Fatalf("the instance put back unused was not handed out again: got %v", err)
+	}
+}
+
+func TestBoundedPoolGetHoldsItsInstanceUntilPut(t *testing.T) {
+	ctx := context.Background()
+	mod, err := Compile(ctx, buildSkill(t, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mod.Close(ctx)
+	pool := NewBoundedPool(ctx, mod, 1, 1)
+	defer pool.Close(ctx)
+
+	in, err := pool.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Get(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second Get: got %v, want its deadline", err)
+	}
+	if _, err := in.Call(ctx, []byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	pool.Put(ctx, in)
+	res, err := pool.Call(ctx, []byte("again"))
+	if err != nil || res.Output != "again" {
+		t.Fatalf("call after Put: got %+v, %v", res, err)
+	}
+	if peak := pool.peak.Load(); peak != 1 {
+		t.Fatalf("peak of %d instances, want 1", peak)
+	}
+}
+
+// BenchmarkFirstCall times the first call on a module just compiled, as a
+// host pays it at startup, with and without prewarming.
+func BenchmarkFirstCall(b *testing.B) {
+	ctx := context.Background()
+	wasm := buildSkill(b, "echo")
+	for _, prewarm := range []bool{false, true} {
+		name := "cold"
+		if prewarm {
+			name = "prewarmed"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				mod, err := Compile(ctx, wasm)
+				if err != nil {
+					b.Fatal(err)
+				}
+				var pool *Pool
+				if prewarm {
+					pool, err = mod.Prewarm(ctx, 1)
+				} else {
+					pool = NewPool(ctx, mod, 1)
+				}
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				if _, err := pool.Call(ctx, []byte("hi")); err != nil {
+					b.Fatal(err)
+				}
+				b.StopTimer()
+				pool.Close(ctx)
+				mod.Close(ctx)
+				b.StartTimer()
+			}
+		})
+	}
+}
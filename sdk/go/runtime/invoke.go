@@ -0,0 +1,108 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// InvokePath is the path InvokeHandler answers.
+const InvokePath = "/invoke"
+
+// InvokeHandler serves POST InvokePath for a host exposing p over plain HTTP,
+// e.g. http.ListenAndServe(":8080", p.InvokeHandler(5*time.Second)). The
+// request body is the args JSON; the response is the ToolResult, with a
+// status from its ErrorCode when Success is false:
+//
+//	invalid_input 400   permission_denied 403   not_found 404
+//	rate_limited  429   not_supported     501   timeout   504
+//
+// and 500 for any other code. A body that is not JSON fails as invalid_input
+// without running the skill. Each request runs under timeout, when it is
+// positive, and the Module's manifest capabilities and Config limits as any
+// Call does; a call that runs out of time fails as timeout, and one the host
+// could not complete as HostErrorCode with status 500. A pool from
+// NewBoundedPool with no room left fails the request as rate_limited. With
+// Config.ResultFields the status still follows the whole result, and the
+// body holds only the named fields.
+func (p *Pool) InvokeHandler(timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != InvokePath {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "invoke takes POST", http.StatusMethodNotAllowed)
+			return
+		}
+		fields := p.mod.exec.cfg.ResultFields
+		args, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeInvoke(w, failed("invalid_input", fmt.Sprintf("read args: %v", err)), fields)
+			return
+		}
+		if !json.Valid(args) {
+			writeInvoke(w, failed("invalid_input", "args are not valid JSON"), fields)
+			return
+		}
+
+		ctx := r.Context()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		res, err := p.call(ctx, args)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			res = failed("timeout", fmt.Sprintf("skill did not finish within %s", timeout))
+		case errors.Is(err, ErrPoolBusy):
+			res = failed("rate_limited", err.Error())
+		case err != nil:
+			res = failed(HostErrorCode, err.Error())
+		}
+		writeInvoke(w, res, fields)
+	})
+}
+
+// failed returns a failed ToolResult the host writes on the skill's behalf.
+func failed(code, msg string) ToolResult {
+	return ToolResult{Error: &msg, ErrorCode: code}
+}
+
+// writeInvoke writes res, projected to fields, with the status its
+// ErrorCode maps to.
+func writeInvoke(w http.ResponseWriter, res ToolResult, fields []string) {
+	code := http.StatusOK
+	if !res.Success {
+		code = invokeStatus(res.ErrorCode)
+	}
+	body, _ := res.MarshalFields(fields)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(append(body, '\n'))
+}
+
+// invokeStatus maps a failed result's ErrorCode to an HTTP status.
+func invokeStatus(errorCode string) int {
+	switch errorCode {
+	case "invalid_input":
+		return http.StatusBadRequest
+	case "permission_denied":
+		return http.StatusForbidden
+	case "not_found":
+		return http.StatusNotFound
+	case "rate_limited":
+		return http.StatusTooManyRequests
+	case "not_supported":
+		return http.StatusNotImplemented
+	case "timeout":
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,59 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInvokeHandler(t *testing.T) {
+	ctx := context.Background()
+	post := func(t *testing.T, skill, args string, timeout time.Duration) (int, ToolResult) {
+		t.Helper()
+		mod, err := Compile(ctx, buildSkill(t, skill))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer mod.Close(ctx)
+		pool := NewPool(ctx, mod, 1)
+		defer pool.Close(ctx)
+		srv := httptest.NewServer(pool.InvokeHandler(timeout))
+		defer srv.Close()
+
+		resp, err := srv.Client().Post(srv.URL+InvokePath, "application/json", strings.NewReader(args))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var res ToolResult
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, res
+	}
+
+	if code, res := post(t, "echo", `{"text":"hi"}`, time.Minute); code != http.StatusOK || !res.Success || res.Output != `{"text":"hi"}` {
+		t.Fatalf("valid args: got %d %+v", code, res)
+	}
+	if code, res := post(t, "echo", `{"text":`, time.Minute); code != http.StatusBadRequest || res.ErrorCode != "invalid_input" {
+		t.Fatalf("invalid JSON: got %d %+v", code, res)
+	}
+	if code, res := post(t, "exitcode", `{"code":0}`, time.Minute); code != http.StatusBadRequest || res.Error == nil || *res.Error != "rejected" {
+		t.Fatalf("the skill's own invalid_input: got %d %+v", code, res)
+	}
+	if code, res := post(t, "spin", `{}`, 200*time.Millisecond); code != http.StatusGatewayTimeout || res.ErrorCode != "timeout" {
+		t.Fatalf("a skill past its timeout: got %d %+v", code, res)
+	}
+}
+
+func TestInvokeHandlerWantsPost(t *testing.T) {
+	rec := httptest.NewRecorder()
+	(&Pool{}).InvokeHandler(0).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, InvokePath, nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != http.MethodPost {
+		t.Fatalf("got %d, Allow %q", rec.Code, rec.Header().Get("Allow"))
+	}
+}
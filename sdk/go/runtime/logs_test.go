@@ -0,0 +1,86 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// notifyingBuffer is a concurrency-safe sink that signals its first write.
+type notifyingBuffer struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	first chan struct{}
+	once  sync.Once
+}
+
+func (b *notifyingBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.once.Do(func() { close(b.first) })
+	return b.buf.Write(p)
+}
+
+func (b *notifyingBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestStderrSinkSeesLogsWhileSkillRuns(t *testing.T) {
+	wasm := buildSkill(t, "logs")
+	sink := &notifyingBuffer{first: make(chan struct{})}
+	exec := New(Config{StderrSink: sink})
+
+	stdin, feed := io.Pipe()
+	done := make(chan *Result, 1)
+	go func() {
+		res, err := exec.ExecuteReader(context.Background(), wasm, stdin, nil)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- res
+	}()
+
+	// The skill logs before it reads stdin, so the line must arrive while
+	// it is still blocked on the args no one has written yet.
+	select {
+	case <-sink.first:
+	case <-done:
+		t.Fatal("skill finished without input")
+	case <-time.After(30 * time.Second):
+		t.Fatal("no stderr reached the sink before the skill got its args")
+	}
+	if got := sink.String(); got != "started\n" {
+		t.Fatalf("sink = %q before the args, want the first line only", got)
+	}
+	feed.Write([]byte(`{}`))
+	feed.Close()
+
+	res := <-done
+	if res == nil {
+		return
+	}
+	if got := sink.String(); got != "started\nread 2 bytes" {
+		t.Fatalf("sink = %q, want both lines", got)
+	}
+	if !strings.HasSuffix(string(res.Stderr), "] read 2 bytes") {
+		t.Fatalf("Result.Stderr should still hold the logs, got %q", res.Stderr)
+	}
+}
+
+func TestStderrSinkRedactsSecrets(t *testing.T) {
+	wasm := skillDir(t, buildSkill(t, "secret"), secretManifest)
+	sink := &notifyingBuffer{first: make(chan struct{})}
+	exec := New(Config{StderrSink: sink, Secrets: map[string]string{"API_KEY": "k-789"}})
+	if _, err := exec.Execute(context.Background(), wasm, []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := sink.String(); got != "using key "+Redacted+"\n" {
+		t.Fatalf("sink = %q", got)
+	}
+}
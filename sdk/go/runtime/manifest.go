@@ -0,0 +1,224 @@
+package runtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"text/template"
+
+	"github.com/tetratelabs/wazero"
+)
+
+// ManifestFile names the skill manifest the executor reads capabilities from:
+// the file beside a bare module, or the entry of that name in a package.
+const ManifestFile = "manifest.json"
+
+// ManifestSchemaVersion is the newest manifest "schema_version" the executor
+// understands. Manifests without one are version 1, which reads the same for
+// everything the executor uses; a newer one is refused rather than misread.
+const ManifestSchemaVersion = 2
+
+// Values of the manifest's "input" field. A skill declaring InputNDJSON
+// reads one JSON args object per line of stdin and writes one ToolResult line
+// for each; the executor tells it so by setting JSONLinesEnv.
+const (
+	InputJSON   = "json"
+	InputNDJSON = "ndjson"
+)
+
+// Values of the manifest's "side_effects" field: what calling the skill does
+// to state outside it. A manifest that does not say is taken to be
+// SideEffectsWrite, as it is not idempotent unless it sets "idempotent".
+const (
+	SideEffectsNone  = "none"
+	SideEffectsRead  = "read"
+	SideEffectsWrite = "write"
+)
+
+// JSONLinesEnv switches an SDK-built skill to line-oriented input; it matches
+// skill.JSONLinesEnv.
+const JSONLinesEnv = "ZEROCLAW_JSONL"
+
+// capabilities are the host capabilities a skill's manifest grants it, and
+// how it expects its input. A skill without a manifest gets none and reads a
+// single JSON object.
+type capabilities struct {
+	// Net lets the skill use the HTTP host functions; without it they fail
+	// with FetchDenied whatever Config.HTTPClient says.
+	Net bool `json:"net"`
+	// Secrets names the Config.Secrets the skill needs; each is passed in
+	// its SecretEnvPrefix variable and redacted from the skill's output.
+	Secrets []string `json:"secrets"`
+	// lines is set when the manifest's "input" is InputNDJSON.
+	lines bool
+	// gzipMin is the artifact size from which the skill gzips artifacts,
+	// from the manifest's "artifacts" object; zero turns compression off.
+	gzipMin int
+	// name is the manifest's "name", which labels Config.Recorder records.
+	name string
+	// streaming is the manifest's "streaming" flag: stdout is NDJSON, and a
+	// deadline ends it with a DeadlineResult line instead of an error.
+	streaming bool
+	// gzip is set when the manifest's "compression" is CompressionGzip:
+	// stdin and stdout are gzip streams (see CompressionEnv).
+	gzip bool
+	// truncate is set when the manifest's "on_oversize" is
+	// OnOversizeTruncate (see OnOversizeEnv).
+	truncate bool
+	// maxOutput is the manifest's "max_output_bytes", a stdout cap the
+	// skill sets for itself; zero means none (see CodeResourceExhausted).
+	maxOutput int
+	// retryErrors is the manifest's "retryable_errors": the error codes a
+	// failed result is retried for under Config.Retries. Nil means
+	// DefaultRetryableErrors.
+	retryErrors []string
+	// sideEffects and idempotent are the manifest's "side_effects", one of
+	// the SideEffects values, and "idempotent" flag, reported in every
+	// Result.Meta.
+	sideEffects string
+	idempotent  bool
+	// outputTemplate is the manifest's "output_template", which fills the
+	// Output of each successful result from its Data; nil leaves the
+	// skill's own Output (see renderOutput).
+	outputTemplate *template.Template
+}
+
+// artifactsConfig is the manifest's "artifacts" object. Compression is on
+// by default, from DefaultArtifactGzipThreshold bytes.
+type artifactsConfig struct {
+	Compress  *bool `json:"compress"`
+	Threshold int   `json:"gzip_threshold"`
+}
+
+// parseCapabilities checks the "schema_version" of a manifest and reads its
+// "capabilities" object and the "input", "artifacts", "streaming",
+// "compression", "on_oversize", "max_output_bytes", "retryable_errors",
+// "side_effects", "idempotent", and "output_template" fields. A nil raw
+// means there is no manifest.
+func parseCapabilities(raw []byte, src string) (capabilities, error) {
+	var m struct {
+		SchemaVersion  int             `json:"schema_version"`
+		Capabilities   capabilities    `json:"capabilities"`
+		Input          string          `json:"input"`
+		Artifacts      artifactsConfig `json:"artifacts"`
+		Name           string          `json:"name"`
+		Streaming      bool            `json:"streaming"`
+		Compression    string          `json:"compression"`
+		OnOversize     string          `json:"on_oversize"`
+		MaxOutput      int             `json:"max_output_bytes"`
+		Retryable      []string        `json:"retryable_errors"`
+		SideEffects    string          `json:"side_effects"`
+		Idempotent     bool            `json:"idempotent"`
+		OutputTemplate string          `json:"output_template"`
+	}
+	m.Capabilities.gzipMin = DefaultArtifactGzipThreshold
+	m.Capabilities.sideEffects = SideEffectsWrite
+	if raw == nil {
+		return m.Capabilities, nil
+	}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return capabilities{}, fmt.Errorf("%s: malformed %s: %w", src, ManifestFile, err)
+	}
+	if m.SchemaVersion < 0 {
+		return capabilities{}, fmt.Errorf("%s: %s: negative schema_version %d", src, ManifestFile, m.SchemaVersion)
+	}
+	if m.SchemaVersion > ManifestSchemaVersion {
+		return capabilities{}, fmt.Errorf("%s: %s: schema_version %d is newer than this executor understands (up to %d)", src, ManifestFile, m.SchemaVersion, ManifestSchemaVersion)
+	}
+	switch m.Input {
+	case "", InputJSON:
+	case InputNDJSON:
+		m.Capabilities.lines = true
+	default:
+		return capabilities{}, fmt.Errorf("%s: %s: unknown input %q (want %q or %q)", src, ManifestFile, m.Input, InputJSON, InputNDJSON)
+	}
+	switch m.Compression {
+	case "":
+	case CompressionGzip:
+		// A gzip stream hands over nothing until it ends, which rules out
+		// answering line by line or streaming partials.
+		if m.Capabilities.lines || m.Streaming {
+			return capabilities{}, fmt.Errorf("%s: %s: compression %q cannot be combined with ndjson input or streaming", src, ManifestFile, m.Compression)
+		}
+		m.Capabilities.gzip = true
+	default:
+		return capabilities{}, fmt.Errorf("%s: %s: unknown compression %q (want %q)", src, ManifestFile, m.Compression, CompressionGzip)
+	}
+	switch m.OnOversize {
+	case "", OnOversizeError:
+	case OnOversizeTruncate:
+		m.Capabilities.truncate = true
+	default:
+		return capabilities{}, fmt.Errorf("%s: %s: unknown on_oversize %q (want %q or %q)", src, ManifestFile, m.OnOversize, OnOversizeError, OnOversizeTruncate)
+	}
+	switch m.SideEffects {
+	case "":
+		m.SideEffects = SideEffectsWrite
+	case SideEffectsNone, SideEffectsRead, SideEffectsWrite:
+	default:
+		return capabilities{}, fmt.Errorf("%s: %s: unknown side_effects %q (want %q, %q, or %q)", src, ManifestFile, m.SideEffects, SideEffectsNone, SideEffectsRead, SideEffectsWrite)
+	}
+	if m.MaxOutput < 0 {
+		return capabilities{}, fmt.Errorf("%s: %s: negative max_output_bytes %d", src, ManifestFile, m.MaxOutput)
+	}
+	if slices.Contains(m.Retryable, neverRetried) {
+		return capabilities{}, fmt.Errorf("%s: %s: retryable_errors cannot include %q", src, ManifestFile, neverRetried)
+	}
+	tmpl, err := parseOutputTemplate(m.OutputTemplate)
+	if err != nil {
+		return capabilities{}, fmt.Errorf("%s: %s: bad output_template: %w", src, ManifestFile, err)
+	}
+	switch a := m.Artifacts; {
+	case a.Threshold < 0:
+		return capabilities{}, fmt.Errorf("%s: %s: negative artifacts.gzip_threshold %d", src, ManifestFile, a.Threshold)
+	case a.Compress != nil && !*a.Compress:
+		m.Capabilities.gzipMin = 0
+	case a.Threshold > 0:
+		m.Capabilities.gzipMin = a.Threshold
+	}
+	m.Capabilities.name = m.Name
+	m.Capabilities.streaming = m.Streaming
+	m.Capabilities.maxOutput = m.MaxOutput
+	m.Capabilities.retryErrors = m.Retryable
+	m.Capabilities.sideEffects = m.SideEffects
+	m.Capabilities.idempotent = m.Idempotent
+	m.Capabilities.outputTemplate = tmpl
+	return m.Capabilities, nil
+}
+
+// withManifest passes a skill what its manifest asks of the host: to read
+// stdin line by line for InputNDJSON, when to compress artifacts, that its
+// streams are gzip-compressed, and to truncate an oversized result.
+func withManifest(cfg wazero.ModuleConfig, caps capabilities) wazero.ModuleConfig {
+	if caps.lines {
+		cfg = cfg.WithEnv(JSONLinesEnv, "1")
+	}
+	if caps.gzipMin > 0 {
+		cfg = cfg.WithEnv(ArtifactGzipEnv, strconv.Itoa(caps.gzipMin))
+	}
+	if caps.gzip {
+		cfg = cfg.WithEnv(CompressionEnv, CompressionGzip)
+	}
+	if caps.truncate {
+		cfg = cfg.WithEnv(OnOversizeEnv, OnOversizeTruncate)
+	}
+	return cfg
+}
+
+// manifestBeside reads the manifest in the directory of a bare module, or
+// returns nil when there is none.
+func manifestBeside(wasmPath string) ([]byte, error) {
+	raw, err := os.ReadFile(filepath.Join(filepath.Dir(wasmPath), ManifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read skill manifest: %w", err)
+	}
+	return raw, nil
+}
@@ -0,0 +1,106 @@
+package runtime
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds, in seconds, of the latency histogram
+// Metrics reports.
+var LatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// HostErrorCode labels, in Metrics, invocations that failed in the host
+// rather than returning a ToolResult: traps, timeouts, oversized output.
+const HostErrorCode = "host_error"
+
+// Metrics counts skill invocations for a Prometheus scraper. Set it as
+// Config.Metrics and serve it, e.g. http.Handle("/metrics", m); it is safe
+// for concurrent use. It reports:
+//
+//	zeroclaw_skill_invocations_total            every invocation
+//	zeroclaw_skill_errors_total{error_code=...} failed ones, by ToolResult.ErrorCode
+//	zeroclaw_skill_duration_seconds             a latency histogram
+//
+// Failures without an error code are labeled "unknown", and host failures
+// HostErrorCode. ExecuteReader with a non-nil w leaves stdout unparsed and
+// counts as a success unless the host fails.
+type Metrics struct {
+	mu          sync.Mutex
+	invocations uint64
+	errors      map[string]uint64
+	buckets     []uint64 // cumulative counts, one per LatencyBuckets bound
+	sum         float64
+}
+
+// NewMetrics returns an empty Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{errors: map[string]uint64{}, buckets: make([]uint64, len(LatencyBuckets))}
+}
+
+// observe records one invocation that took d. res is nil when stdout was
+// not parsed.
+func (m *Metrics) observe(res *ToolResult, err error, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.invocations++
+	switch {
+	case err != nil:
+		m.errors[HostErrorCode]++
+	case res != nil && !res.Success:
+		code := res.ErrorCode
+		if code == "" {
+			code = "unknown"
+		}
+		m.errors[code]++
+	}
+	secs := d.Seconds()
+	m.sum += secs
+	for i, bound := range LatencyBuckets {
+		if secs <= bound {
+			m.buckets[i]++
+		}
+	}
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprint(w, m.String())
+}
+
+// String renders the metrics as ServeHTTP writes them.
+func (m *Metrics) String() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var b strings.Builder
+	b.WriteString("# HELP zeroclaw_skill_invocations_total Skill invocations.\n")
+	b.WriteString("# TYPE zeroclaw_skill_invocations_total counter\n")
+	fmt.Fprintf(&b, "zeroclaw_skill_invocations_total %d\n", m.invocations)
+
+	b.WriteString("# HELP zeroclaw_skill_errors_total Failed skill invocations by error code.\n")
+	b.WriteString("# TYPE zeroclaw_skill_errors_total counter\n")
+	codes := make([]string, 0, len(m.errors))
+	for code := range m.errors {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		fmt.Fprintf(&b, "zeroclaw_skill_errors_total{error_code=%q} %d\n", code, m.errors[code])
+	}
+
+	b.WriteString("# HELP zeroclaw_skill_duration_seconds Skill invocation latency.\n")
+	b.WriteString("# TYPE zeroclaw_skill_duration_seconds histogram\n")
+	for i, bound := range LatencyBuckets {
+		le := strconv.FormatFloat(bound, 'g', -1, 64)
+		fmt.Fprintf(&b, "zeroclaw_skill_duration_seconds_bucket{le=%q} %d\n", le, m.buckets[i])
+	}
+	fmt.Fprintf(&b, "zeroclaw_skill_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.invocations)
+	fmt.Fprintf(&b, "zeroclaw_skill_duration_seconds_sum %g\n", m.sum)
+	fmt.Fprintf(&b, "zeroclaw_skill_duration_seconds_count %d\n", m.invocations)
+	return b.String()
+}
@@ -0,0 +1,43 @@
+package runtime
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsCountServedRequests(t *testing.T) {
+	m := NewMetrics()
+	e := New(Config{Metrics: m})
+	echo, exit := buildSkill(t, "echo"), buildSkill(t, "exitcode")
+	for i := 0; i < 3; i++ {
+		if _, err := e.Execute(context.Background(), echo, []byte(`{}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range []string{`{"code":0}`, `{"code":2}`, `{"code":1}`} {
+		e.Execute(context.Background(), exit, []byte(args))
+	}
+
+	srv := httptest.NewServer(m)
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{
+		"zeroclaw_skill_invocations_total 6\n",
+		`zeroclaw_skill_errors_total{error_code="host_error"} 1` + "\n",
+		`zeroclaw_skill_errors_total{error_code="invalid_input"} 2` + "\n",
+		`zeroclaw_skill_duration_seconds_bucket{le="+Inf"} 6` + "\n",
+		"zeroclaw_skill_duration_seconds_count 6\n",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+}
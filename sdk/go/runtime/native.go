@@ -0,0 +1,170 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// ExecuteNative is (*Executor).ExecuteNative with a zero Config.
+func ExecuteNative(ctx context.Context, binaryPath string, argsJSON []byte) (*Result, error) {
+	return New(Config{}).ExecuteNative(ctx, binaryPath, argsJSON)
+}
+
+// ExecuteNative runs binaryPath, a skill built for the host (plain `go build`,
+// no GOOS=wasip1), as a subprocess over the protocol Execute speaks: argsJSON
+// on stdin, one ToolResult on stdout, parsed the same way. It is for
+// iterating on a skill without rebuilding it for wasm each time.
+//
+// The skill gets what a wasm guest is told in its environment and nothing of
+// the host's: the trace ID, the input and output caps, ctx's deadline, its
+// manifest's settings, its declared secrets, and Config.Seed. Stdout is cut
+// off at the output cap as in Execute, a deadline or cancelled ctx kills the
+// process and fails with ctx's error, and exit statuses are read as for a
+// module. HTTP host functions, OnAsk, OnPartial, ScratchRoot, Retries,
+// Metrics, Recorder, TrustedKeys, and Sandboxed do not apply.
+//
+// There is no sandbox: the binary runs as the host's user, with its files
+// and network. Run only skills you would run by hand.
+func (e *Executor) ExecuteNative(ctx context.Context, binaryPath string, argsJSON []byte) (*Result, error) {
+	if err := checkResultFields(e.cfg.ResultFields); err != nil {
+		return nil, err
+	}
+	raw, err := manifestBeside(binaryPath)
+	if err != nil {
+		return nil, err
+	}
+	caps, err := parseCapabilities(raw, binaryPath)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := e.secretsFor(binaryPath, caps)
+	if err != nil {
+		return nil, err
+	}
+	red := newRedactor(secrets)
+
+	var r io.Reader = bytes.NewReader(argsJSON)
+	if caps.gzip {
+		var stop func()
+		r, stop = gzipStream(r)
+		defer stop()
+	}
+	in := &countingReader{r: r}
+	var stdout, stderr, packed bytes.Buffer
+	out := &stdout
+	if caps.gzip {
+		out = &packed
+	}
+	capped := e.capOutput(out, caps)
+	traceID := newTraceID()
+	cmd := exec.CommandContext(ctx, binaryPath)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = in, capped, &stderr
+	cmd.Env = e.nativeEnv(ctx, caps, secrets, traceID)
+
+	var res Result
+	start := time.Now()
+	err = cmd.Run()
+	res.Timings.Execute = e.span(SpanExecute, start)
+	res.Usage = Usage{ExecTime: res.Timings.Execute, BytesIn: in.n, BytesOut: capped.n}
+	guestStderr := red.redact(stderr.Bytes())
+	if capped.exceeded && ctx.Err() == nil {
+		if res.ToolResult, err = e.oversized(binaryPath, caps, capped); err != nil {
+			return nil, err
+		}
+		res.Stderr = traceLog(guestStderr, traceID)
+		return &res, nil
+	}
+	if res.ExitCode, err = nativeExitCode(ctx, err); err != nil {
+		return nil, fmt.Errorf("run %s: %w\n%s", binaryPath, err, guestStderr)
+	}
+	if caps.gzip {
+		if err := inflateStdout(&stdout, packed.Bytes()); err != nil {
+			return nil, fmt.Errorf("run %s: %w", binaryPath, err)
+		}
+	}
+
+	if err := checkUTF8(binaryPath, stdout.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := decodeResult(red.redact(stdout.Bytes()), &res.ToolResult); err != nil {
+		return nil, fmt.Errorf("%s: stdout is not a JSON ToolResult: %w", binaryPath, err)
+	}
+	if err := checkOutputType(res.OutputType); err != nil {
+		return nil, fmt.Errorf("%s: %w", binaryPath, err)
+	}
+	if err := renderOutput(binaryPath, caps.outputTemplate, &res.ToolResult); err != nil {
+		return nil, err
+	}
+	if res.Meta != nil && res.Meta.TraceID != "" {
+		traceID = res.Meta.TraceID
+	}
+	res.Stderr = traceLog(guestStderr, traceID)
+	res.ToolResult = e.project(res.ToolResult)
+	return &res, nil
+}
+
+// nativeEnv is the whole environment of a native skill: the variables
+// withBudget, withManifest, withSecrets, and withSeed give a wasm guest.
+func (e *Executor) nativeEnv(ctx context.Context, caps capabilities, secrets map[string]string, traceID string) []string {
+	env := []string{TraceIDEnv + "=" + traceID}
+	set := func(key, value string) { env = append(env, key+"="+value) }
+	if limit, _ := e.outputCap(caps); limit > 0 {
+		set(MaxOutputBytesEnv, strconv.Itoa(limit))
+	}
+	switch n := e.cfg.MaxInputBytes; {
+	case n == 0:
+		set(MaxInputBytesEnv, strconv.Itoa(DefaultMaxInputBytes))
+	case n > 0:
+		set(MaxInputBytesEnv, strconv.Itoa(n))
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		set(DeadlineEnv, deadline.UTC().Format(time.RFC3339Nano))
+	}
+	if caps.lines {
+		set(JSONLinesEnv, "1")
+	}
+	if caps.gzipMin > 0 {
+		set(ArtifactGzipEnv, strconv.Itoa(caps.gzipMin))
+	}
+	if caps.gzip {
+		set(CompressionEnv, CompressionGzip)
+	}
+	if caps.truncate {
+		set(OnOversizeEnv, OnOversizeTruncate)
+	}
+	for key, value := range secrets {
+		set(SecretEnvPrefix+key, value)
+	}
+	if e.cfg.Seed != nil {
+		set(SeedEnv, strconv.FormatInt(*e.cfg.Seed, 10))
+	}
+	return env
+}
+
+// nativeExitCode is exitCode for a subprocess: ExitOK, ExitInvalidInput,
+// and ExitPanic come back without an error, a done ctx reports its error,
+// and any other status wraps ErrTrap.
+func nativeExitCode(ctx context.Context, err error) (int, error) {
+	if err == nil {
+		return ExitOK, nil
+	}
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	var exit *exec.ExitError
+	if !errors.As(err, &exit) {
+		return 0, err
+	}
+	switch code := exit.ExitCode(); code {
+	case ExitInvalidInput, ExitPanic:
+		return code, nil
+	default:
+		return code, fmt.Errorf("%w: %v", ErrTrap, exit)
+	}
+}
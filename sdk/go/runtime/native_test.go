@@ -0,0 +1,82 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// buildNative compiles the Go package in dir for the host once per test
+// binary.
+func buildNative(t *testing.T, dir string) string {
+	t.Helper()
+	buildMu.Lock()
+	defer buildMu.Unlock()
+	key := "native/" + dir
+	if path, ok := builtSkill[key]; ok {
+		return path
+	}
+	out := filepath.Join(buildDir, "native_"+filepath.Base(dir))
+	cmd := exec.Command("go", "build", "-o", out, ".")
+	cmd.Dir = dir
+	if msg, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("cannot build native skill %s: %v\n%s", dir, err, msg)
+	}
+	builtSkill[key] = out
+	return out
+}
+
+func TestExecuteNativeMatchesWasm(t *testing.T) {
+	args := []byte(`{"text":"hello wide world\nagain"}`)
+	wasm, err := Execute(context.Background(), buildTemplate(t, "word_count"), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	native, err := ExecuteNative(context.Background(), buildNative(t, filepath.Join("..", "..", "..", "templates", "go", "word_count")), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wasm.Meta, native.Meta = nil, nil
+	if !reflect.DeepEqual(native.ToolResult, wasm.ToolResult) {
+		t.Fatalf("native result %+v, wasm result %+v", native.ToolResult, wasm.ToolResult)
+	}
+}
+
+func TestExecuteNativeStopsAtDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := ExecuteNative(ctx, buildNative(t, filepath.Join("testdata", "spin")), []byte(`{}`))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("spin ran for %v past its deadline", elapsed)
+	}
+}
+
+func TestExecuteNativeCapsOutput(t *testing.T) {
+	ex := New(Config{MaxOutputBytes: 8})
+	_, err := ex.ExecuteNative(context.Background(), buildNative(t, filepath.Join("testdata", "echo")), []byte(`{"text":"far too long"}`))
+	if !errors.Is(err, ErrOutputTooLarge) {
+		t.Fatalf("got %v, want ErrOutputTooLarge", err)
+	}
+}
+
+func TestExecuteNativeMapsExitCodes(t *testing.T) {
+	bin := buildNative(t, filepath.Join("testdata", "exitcode"))
+	res, err := ExecuteNative(context.Background(), bin, []byte(`{"code":2}`))
+	if err != nil {
+		t.Fatalf("exit 2 should still parse stdout: %v", err)
+	}
+	if res.ExitCode != ExitInvalidInput || res.Success || res.ErrorCode != "invalid_input" {
+		t.Fatalf("unexpected result: exit %d, %+v", res.ExitCode, res.ToolResult)
+	}
+	if _, err := ExecuteNative(context.Background(), bin, []byte(`{"code":4}`)); !errors.Is(err, ErrTrap) {
+		t.Fatalf("exit 4: got %v, want ErrTrap", err)
+	}
+}
@@ -0,0 +1,57 @@
+package runtime
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// ErrInvalidOutput is wrapped by the *OutputError returned for a skill whose
+// stdout is not valid UTF-8, which JSON must be. Usually the skill wrote
+// binary to stdout that belonged in an Artifact or a raw result (see
+// RawHeader).
+var ErrInvalidOutput = errors.New("stdout is not valid UTF-8")
+
+// outputContext is how many bytes either side of the first invalid one
+// OutputError.Dump shows.
+const outputContext = 16
+
+// OutputError says where a skill's stdout stops being UTF-8.
+type OutputError struct {
+	Path string
+	// Offset is the index in stdout of the first byte of the first invalid
+	// sequence.
+	Offset int
+	// Start is the index in stdout of the first byte in Dump.
+	Start int
+	// Dump is a hex.Dump of the bytes around Offset; its own offsets count
+	// from Start.
+	Dump string
+}
+
+func (e *OutputError) Error() string {
+	return fmt.Sprintf("%s: %v: invalid byte at offset %d (dump from offset %d):\n%s", e.Path, ErrInvalidOutput, e.Offset, e.Start, e.Dump)
+}
+
+func (e *OutputError) Unwrap() error { return ErrInvalidOutput }
+
+// checkUTF8 returns an *OutputError for stdout that is not valid UTF-8, so
+// a skill writing binary fails with where it went wrong rather than with
+// whatever json.Unmarshal makes of it.
+func checkUTF8(path string, stdout []byte) error {
+	if utf8.Valid(stdout) {
+		return nil
+	}
+	offset := 0
+	for offset < len(stdout) {
+		r, size := utf8.DecodeRune(stdout[offset:])
+		if r == utf8.RuneError && size <= 1 {
+			break
+		}
+		offset += size
+	}
+	start := max(offset-outputContext, 0)
+	end := min(offset+outputContext, len(stdout))
+	return &OutputError{Path: path, Offset: offset, Start: start, Dump: hex.Dump(stdout[start:end])}
+}
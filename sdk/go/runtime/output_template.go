@@ -0,0 +1,50 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/template"
+)
+
+// ErrOutputTemplate is returned for a result the manifest's
+// "output_template" cannot be rendered over, such as one whose Data lacks a
+// field the template names.
+var ErrOutputTemplate = errors.New("output_template does not render over the result's data")
+
+// parseOutputTemplate parses a manifest's "output_template", a Go
+// text/template over the result's Data, e.g. "{{.words}} words". A field
+// the template names but Data lacks is an error at render time rather than
+// "<no value>" in the output; a template reads a field Data may leave out
+// with index, as in {{with index . "top_words"}}. An empty src means no
+// template.
+func parseOutputTemplate(src string) (*template.Template, error) {
+	if src == "" {
+		return nil, nil
+	}
+	return template.New("output_template").Option("missingkey=error").Parse(src)
+}
+
+// renderOutput sets res.Output to tmpl rendered over res.Data, keys as the
+// skill wrote them and numbers as it spelled them. A failed result or one
+// without data keeps the skill's own Output, and so does any result when
+// tmpl is nil. ExecuteReader with a writer hands stdout on unparsed, so
+// its results are never rendered.
+func renderOutput(path string, tmpl *template.Template, res *ToolResult) error {
+	if tmpl == nil || !res.Success || len(res.Data) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(res.Data))
+	dec.UseNumber()
+	var data any
+	if err := dec.Decode(&data); err != nil {
+		return fmt.Errorf("%s: %w: %v", path, ErrOutputTemplate, err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return fmt.Errorf("%s: %w: %v", path, ErrOutputTemplate, err)
+	}
+	res.Output = out.String()
+	return nil
+}
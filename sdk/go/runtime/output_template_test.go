@@ -0,0 +1,53 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestOutputTemplateRendersOverCountResult(t *testing.T) {
+	wasm := skillDir(t, buildTemplate(t, "word_count"),
+		`{"name":"word_count","output_template":"{{.words}} words over {{.lines}} lines{{with index . \"top_words\"}}, mostly {{(index . 0).word}}{{end}}"}`)
+	ctx := context.Background()
+	res, err := New(Config{}).Execute(ctx, wasm, []byte(`{"text":"the cat\nthe hat","top_words":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "4 words over 2 lines, mostly the"; res.Output != want {
+		t.Fatalf("got output %q, want %q", res.Output, want)
+	}
+
+	mod, err := New(Config{}).Compile(ctx, wasm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mod.Close(ctx)
+	in, err := mod.NewInstance(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := in.Call(ctx, []byte(`{"text":"a b"}`)); err != nil || res.Output != "2 words over 1 lines" {
+		t.Fatalf("an Instance should render the template too: %q, %v", res.Output, err)
+	}
+
+	// A failed result has no data to render and keeps its own error.
+	res, err = New(Config{}).Execute(ctx, wasm, []byte(`{"text":"a","count_mode":"lines"}`))
+	if err != nil || res.Success || res.ErrorCode != "invalid_input" {
+		t.Fatalf("a failed result should pass through: %+v, %v", res.ToolResult, err)
+	}
+}
+
+func TestOutputTemplateFailsCleanly(t *testing.T) {
+	wasm := skillDir(t, buildTemplate(t, "word_count"), `{"name":"word_count","output_template":"{{.words}} words, {{.paragraphs}} paragraphs"}`)
+	_, err := New(Config{}).Execute(context.Background(), wasm, []byte(`{"text":"a b"}`))
+	if !errors.Is(err, ErrOutputTemplate) || !strings.Contains(err.Error(), `"paragraphs"`) {
+		t.Fatalf("a template naming a missing field should fail with ErrOutputTemplate, got %v", err)
+	}
+
+	bad := skillDir(t, buildSkill(t, "echo"), `{"name":"echo","output_template":"{{.words"}`)
+	if _, err := New(Config{}).Compile(context.Background(), bad); err == nil || !strings.Contains(err.Error(), "bad output_template") {
+		t.Fatalf("a template that does not parse should fail at load, got %v", err)
+	}
+}
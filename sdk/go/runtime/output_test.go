@@ -0,0 +1,45 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNonUTF8StdoutFailsWithItsOffset(t *testing.T) {
+	wasm := buildSkill(t, "binary")
+	check := func(how string, err error) {
+		t.Helper()
+		var oe *OutputError
+		if !errors.Is(err, ErrInvalidOutput) || !errors.As(err, &oe) {
+			t.Fatalf("%s: got %v, want an *OutputError", how, err)
+		}
+		// The 0xFF follows {"success":true,"output":".
+		if oe.Offset != 26 || oe.Start != 10 || !strings.Contains(oe.Dump, `ff 22 7d`) {
+			t.Fatalf("%s: got offset %d from %d, dump\n%s", how, oe.Offset, oe.Start, oe.Dump)
+		}
+		if !strings.Contains(err.Error(), "offset 26") {
+			t.Fatalf("%s: error %q should name the offset", how, err)
+		}
+	}
+
+	_, err := Execute(context.Background(), wasm, []byte(`{}`))
+	check("execute", err)
+
+	m, err := New(Config{}).Compile(context.Background(), wasm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(context.Background())
+	in, err := m.NewInstance(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = in.Call(context.Background(), []byte(`{}`))
+	check("instance", err)
+
+	if err := checkUTF8("tool.wasm", []byte(`{"output":"héllo �"}`)); err != nil {
+		t.Fatalf("valid UTF-8, U+FFFD included, should pass: %v", err)
+	}
+}
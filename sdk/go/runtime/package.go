@@ -0,0 +1,222 @@
+package runtime
+
+import (
+	"archive/zip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PackageExt is the file extension of skills bundled by `zeroclaw skill package`.
+const PackageExt = ".zcskill"
+
+const (
+	packageHeader    = "zcskill.json"
+	packageSignature = "zcskill.sig"
+	packageFormat    = 1
+)
+
+// ErrUnverified is returned when Config.TrustedKeys is set and a skill is not
+// a package signed by one of those keys.
+var ErrUnverified = errors.New("skill is not signed by a trusted key")
+
+type packageFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+type packageManifest struct {
+	Format        int           `json:"format"`
+	Name          string        `json:"name"`
+	ContentSHA256 string        `json:"content_sha256"`
+	Files         []packageFile `json:"files"`
+}
+
+// LoadTrustedKeys reads every ed25519 PUBLIC KEY block in a PEM file, the same
+// format `zeroclaw skill install --verify` accepts.
+func LoadTrustedKeys(path string) ([]ed25519.PublicKey, error) {
+	rest, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read trusted keys: %w", err)
+	}
+	var keys []ed25519.PublicKey
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "PUBLIC KEY" {
+			continue
+		}
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		key, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: holds a non-ed25519 public key", path)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: no PUBLIC KEY block", path)
+	}
+	return keys, nil
+}
+
+// skillFile is a skill as loadModule read it. Execute reads the skill once
+// and hands it on when it needs the manifest before running the skill, for
+// Config.Before or Config.Retries, so each call reads the file only once
+// and runs the module its hooks were told about.
+type skillFile struct {
+	wasm []byte
+	caps capabilities
+}
+
+// readSkill is loadModule returning a skillFile.
+func (e *Executor) readSkill(path string) (*skillFile, error) {
+	wasm, caps, err := e.loadModule(path)
+	if err != nil {
+		return nil, err
+	}
+	return &skillFile{wasm: wasm, caps: caps}, nil
+}
+
+// loadModule returns the wasm bytes to run for path and the capabilities its
+// manifest grants, enforcing TrustedKeys.
+func (e *Executor) loadModule(path string) ([]byte, capabilities, error) {
+	var wasm, manifest []byte
+	var err error
+	switch {
+	case strings.EqualFold(filepath.Ext(path), PackageExt):
+		wasm, manifest, err = readPackage(path, e.cfg.TrustedKeys)
+	case len(e.cfg.TrustedKeys) > 0:
+		err = fmt.Errorf("%s: %w (only signed %s packages may run)", path, ErrUnverified, PackageExt)
+	default:
+		if wasm, err = os.ReadFile(path); err != nil {
+			err = fmt.Errorf("read skill module: %w", err)
+		} else {
+			manifest, err = manifestBeside(path)
+		}
+	}
+	if err == nil {
+		err = checkKind(path, wasm)
+	}
+	if err != nil {
+		return nil, capabilities{}, err
+	}
+	caps, err := parseCapabilities(manifest, path)
+	if err != nil {
+		return nil, capabilities{}, err
+	}
+	return wasm, caps, nil
+}
+
+// readPackage verifies a .zcskill archive and returns its tool.wasm and, if
+// it has one, its manifest. With trusted keys, the header must also carry a
+// signature from one of them.
+func readPackage(path string, trusted []ed25519.PublicKey) ([]byte, []byte, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open skill package: %w", err)
+	}
+	defer zr.Close()
+
+	entries := map[string][]byte{}
+	for _, f := range zr.File {
+		raw, err := readZipFile(f)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %s: %w", path, f.Name, err)
+		}
+		entries[f.Name] = raw
+	}
+
+	rawHeader, ok := entries[packageHeader]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: missing %s", path, packageHeader)
+	}
+	var header packageManifest
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		return nil, nil, fmt.Errorf("%s: malformed %s: %w", path, packageHeader, err)
+	}
+	if header.Format != packageFormat {
+		return nil, nil, fmt.Errorf("%s: unsupported package format %d", path, header.Format)
+	}
+	if contentHash(header.Files) != header.ContentSHA256 {
+		return nil, nil, fmt.Errorf("%s: content hash does not match its file list", path)
+	}
+
+	listed := map[string]bool{}
+	for _, f := range header.Files {
+		listed[f.Path] = true
+		raw, ok := entries[f.Path]
+		if !ok {
+			return nil, nil, fmt.Errorf("%s: missing listed entry %s", path, f.Path)
+		}
+		sum := sha256.Sum256(raw)
+		if hex.EncodeToString(sum[:]) != f.SHA256 {
+			return nil, nil, fmt.Errorf("%s: checksum mismatch for %s", path, f.Path)
+		}
+	}
+	for name := range entries {
+		if name != packageHeader && name != packageSignature && !listed[name] {
+			return nil, nil, fmt.Errorf("%s: unlisted entry %s", path, name)
+		}
+	}
+
+	if len(trusted) > 0 {
+		sig, err := hex.DecodeString(strings.TrimSpace(string(entries[packageSignature])))
+		if err != nil || len(sig) == 0 {
+			return nil, nil, fmt.Errorf("%s: %w (package is not signed)", path, ErrUnverified)
+		}
+		if !verifiedBy(trusted, rawHeader, sig) {
+			return nil, nil, fmt.Errorf("%s: %w", path, ErrUnverified)
+		}
+	}
+
+	wasm, ok := entries["tool.wasm"]
+	if !ok || !listed["tool.wasm"] {
+		return nil, nil, fmt.Errorf("%s: package has no tool.wasm", path)
+	}
+	if listed[ManifestFile] {
+		return wasm, entries[ManifestFile], nil
+	}
+	return wasm, nil, nil
+}
+
+func verifiedBy(trusted []ed25519.PublicKey, msg, sig []byte) bool {
+	for _, key := range trusted {
+		if ed25519.Verify(key, msg, sig) {
+			return true
+		}
+	}
+	return false
+}
+
+// contentHash mirrors the CLI: SHA-256 over one "<path>\x00<sha256>\n" line per file.
+func contentHash(files []packageFile) string {
+	h := sha256.New()
+	for _, f := range files {
+		io.WriteString(h, f.Path+"\x00"+f.SHA256+"\n")
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
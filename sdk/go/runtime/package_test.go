@@ -0,0 +1,152 @@
+package runtime
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// packageEntries lays out a .zcskill the way `zeroclaw skill package` does,
+// signing the header with key when it is non-nil.
+func packageEntries(t *testing.T, wasmPath string, key ed25519.PrivateKey) [][2]string {
+	t.Helper()
+	wasm, err := os.ReadFile(wasmPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(wasm)
+	files := []packageFile{{Path: "tool.wasm", SHA256: hex.EncodeToString(sum[:])}}
+	header, err := json.Marshal(packageManifest{
+		Format:        packageFormat,
+		Name:          "echo",
+		ContentSHA256: contentHash(files),
+		Files:         files,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := [][2]string{{packageHeader, string(header)}}
+	if key != nil {
+		entries = append(entries, [2]string{packageSignature, hex.EncodeToString(ed25519.Sign(key, header))})
+	}
+	return append(entries, [2]string{"tool.wasm", string(wasm)})
+}
+
+// writeZip stores entries, in order, in a fresh .zcskill file.
+func writeZip(t *testing.T, entries [][2]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "echo"+PackageExt)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		w, err := zw.Create(e[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writePackage(t *testing.T, wasmPath string, key ed25519.PrivateKey) string {
+	t.Helper()
+	return writeZip(t, packageEntries(t, wasmPath, key))
+}
+
+func newKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pub, priv
+}
+
+func TestExecuteRunsUnsignedPackageWithoutTrustedKeys(t *testing.T) {
+	pkg := writePackage(t, buildSkill(t, "echo"), nil)
+	res, err := Execute(context.Background(), pkg, []byte("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.Output != "hi" {
+		t.Fatalf("unexpected result: %+v", res.ToolResult)
+	}
+}
+
+func TestTrustedKeysRequireValidSignature(t *testing.T) {
+	wasm := buildSkill(t, "echo")
+	pub, priv := newKey(t)
+	other, _ := newKey(t)
+	ex := New(Config{TrustedKeys: []ed25519.PublicKey{pub}})
+
+	if _, err := ex.Execute(context.Background(), writePackage(t, wasm, priv), []byte("hi")); err != nil {
+		t.Fatalf("signed package should run: %v", err)
+	}
+
+	untrusted := New(Config{TrustedKeys: []ed25519.PublicKey{other}})
+	cases := map[string]struct {
+		ex   *Executor
+		path string
+	}{
+		"bare module":      {ex, wasm},
+		"unsigned package": {ex, writePackage(t, wasm, nil)},
+		"untrusted signer": {untrusted, writePackage(t, wasm, priv)},
+	}
+	for name, tc := range cases {
+		if _, err := tc.ex.Execute(context.Background(), tc.path, nil); !errors.Is(err, ErrUnverified) {
+			t.Errorf("%s: got %v, want ErrUnverified", name, err)
+		}
+	}
+}
+
+func TestPackageChecksumMismatch(t *testing.T) {
+	entries := packageEntries(t, buildSkill(t, "echo"), nil)
+	last := len(entries) - 1
+	entries[last][1] += "\x00" // tool.wasm no longer matches the header
+	pkg := writeZip(t, entries)
+
+	_, err := Execute(context.Background(), pkg, nil)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch for tool.wasm") {
+		t.Fatalf("got %v, want a checksum mismatch", err)
+	}
+}
+
+func TestLoadTrustedKeys(t *testing.T) {
+	pub, _ := newKey(t)
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	path := filepath.Join(t.TempDir(), "trusted.pem")
+	if err := os.WriteFile(path, append(block, block...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := LoadTrustedKeys(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || !keys[0].Equal(pub) {
+		t.Fatalf("got %d keys, want 2 copies of the generated key", len(keys))
+	}
+}
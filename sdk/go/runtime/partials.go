@@ -0,0 +1,201 @@
+package runtime
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrPartialGap is returned when a streaming skill's result lines cannot be
+// put in order: a Seq is missing, repeated, or follows the Final result.
+var ErrPartialGap = errors.New("streamed results are out of sequence")
+
+// ReadPartials reads a streaming skill's stdout from r and calls fn with each
+// ToolResult line in Seq order, however the lines were reordered on the way
+// (see skill.Emitter). Lines without a Seq, such as progress lines, are
+// skipped. fn's last call is the Final result; if r ends without one, or with
+// a Seq below it missing, ReadPartials returns an error wrapping
+// ErrPartialGap after delivering what it could.
+func ReadPartials(r io.Reader, fn func(ToolResult)) error {
+	seq := newSequencer(fn)
+	in := bufio.NewReader(r)
+	for {
+		line, err := in.ReadBytes('\n')
+		if serr := seq.line(line); serr != nil {
+			return serr
+		}
+		if errors.Is(err, io.EOF) {
+			return seq.close()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// sequencer buffers result lines until every lower Seq has been delivered.
+type sequencer struct {
+	deliver func(ToolResult)
+	next    int // Seq to deliver next
+	final   int // Seq of the Final result, once seen
+	pending map[int]ToolResult
+}
+
+func newSequencer(deliver func(ToolResult)) *sequencer {
+	return &sequencer{deliver: deliver, next: 1, pending: map[int]ToolResult{}}
+}
+
+// line feeds one line of stdout to s.
+func (s *sequencer) line(line []byte) error {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return nil
+	}
+	var res ToolResult
+	if err := json.Unmarshal(line, &res); err != nil || res.Seq <= 0 {
+		return nil // not a numbered result line
+	}
+	if err := inflateArtifacts(&res); err != nil {
+		return fmt.Errorf("seq %d: %w", res.Seq, err)
+	}
+	return s.add(res)
+}
+
+func (s *sequencer) add(res ToolResult) error {
+	if _, dup := s.pending[res.Seq]; dup || res.Seq < s.next {
+		return fmt.Errorf("%w: seq %d repeated", ErrPartialGap, res.Seq)
+	}
+	if s.final != 0 && res.Seq > s.final {
+		return fmt.Errorf("%w: seq %d after final seq %d", ErrPartialGap, res.Seq, s.final)
+	}
+	if res.Final {
+		if s.final != 0 {
+			return fmt.Errorf("%w: second final result at seq %d", ErrPartialGap, res.Seq)
+		}
+		for seq := range s.pending {
+			if seq > res.Seq {
+				return fmt.Errorf("%w: seq %d after final seq %d", ErrPartialGap, seq, res.Seq)
+			}
+		}
+		s.final = res.Seq
+	}
+	s.pending[res.Seq] = res
+	for {
+		next, ok := s.pending[s.next]
+		if !ok {
+			return nil
+		}
+		delete(s.pending, s.next)
+		s.next++
+		s.deliver(next)
+	}
+}
+
+// close reports a gap once stdout has ended.
+func (s *sequencer) close() error {
+	switch {
+	case s.final != 0 && s.next > s.final:
+		return nil
+	case s.final == 0 && len(s.pending) == 0:
+		if s.next > 1 {
+			return fmt.Errorf("%w: no final result after seq %d", ErrPartialGap, s.next-1)
+		}
+		return nil // not a streaming skill
+	default:
+		return fmt.Errorf("%w: seq %d missing", ErrPartialGap, s.next)
+	}
+}
+
+// partialWriter feeds stdout to a sequencer as the guest writes it, keeping
+// the first error for the executor to return once the skill exits.
+type partialWriter struct {
+	seq *sequencer
+	buf []byte
+	err error
+}
+
+func (w *partialWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for w.err == nil {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.err = w.seq.line(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// close flushes an unterminated last line and the sequencer.
+func (w *partialWriter) close() error {
+	if w.err == nil {
+		w.err = w.seq.line(w.buf)
+	}
+	if w.err == nil {
+		w.err = w.seq.close()
+	}
+	return w.err
+}
+
+// CodeDeadlineExceeded is the error code of the Final result the executor
+// writes for a streaming skill that is still running at its deadline, so a
+// consumer of the stream always sees it end.
+const CodeDeadlineExceeded = "deadline_exceeded"
+
+// streamTail watches a streaming skill's stdout for the result lines it has
+// written, so that a stream cut off at the deadline can be terminated.
+type streamTail struct {
+	buf   []byte // unterminated last line
+	seq   int    // highest Seq written
+	final bool
+}
+
+func (t *streamTail) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	for {
+		i := bytes.IndexByte(t.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		t.line(t.buf[:i])
+		t.buf = t.buf[i+1:]
+	}
+}
+
+func (t *streamTail) line(line []byte) {
+	var res ToolResult
+	if json.Unmarshal(line, &res) != nil {
+		return
+	}
+	t.seq = max(t.seq, res.Seq)
+	t.final = t.final || res.Final
+}
+
+// terminate ends the stream on w: it finishes a line the skill left open and,
+// unless the skill got its Final result out, writes one failing with
+// CodeDeadlineExceeded, numbered after the last Seq so ReadPartials accepts it.
+func (t *streamTail) terminate(w io.Writer) error {
+	var end []byte
+	if len(t.buf) > 0 {
+		t.line(t.buf)
+		end = []byte("\n")
+	}
+	if !t.final {
+		msg := "skill did not finish streaming before its deadline"
+		res := ToolResult{Error: &msg, ErrorCode: CodeDeadlineExceeded, Final: true}
+		if t.seq > 0 {
+			res.Seq = t.seq + 1
+		}
+		line, err := json.Marshal(res)
+		if err != nil {
+			return err
+		}
+		end = append(append(end, line...), '\n')
+	}
+	_, err := w.Write(end)
+	return err
+}
@@ -0,0 +1,101 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadPartialsResortsReorderedPipe(t *testing.T) {
+	pr, pw := io.Pipe()
+	go func() {
+		for _, line := range []string{
+			`{"success":true,"output":"c","seq":3}`,
+			`{"progress":{"percent":50}}`,
+			`{"success":true,"output":"a","id":"first","seq":1}`,
+			`{"success":true,"output":"done","seq":4,"final":true}`,
+			`{"success":true,"output":"b","seq":2}`,
+		} {
+			io.WriteString(pw, line+"\n")
+		}
+		pw.Close()
+	}()
+
+	var got []string
+	if err := ReadPartials(pr, func(res ToolResult) { got = append(got, res.Output) }); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "c", "done"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("delivered %v, want %v", got, want)
+	}
+}
+
+func TestReadPartialsDetectsGaps(t *testing.T) {
+	for name, stdout := range map[string]string{
+		"missing":     `{"success":true,"seq":1}` + "\n" + `{"success":true,"seq":3,"final":true}`,
+		"repeated":    `{"success":true,"seq":1}` + "\n" + `{"success":true,"seq":1}`,
+		"after final": `{"success":true,"seq":1,"final":true}` + "\n" + `{"success":true,"seq":2}`,
+		"no final":    `{"success":true,"seq":1}` + "\n" + `{"success":true,"seq":2}`,
+	} {
+		var got []int
+		err := ReadPartials(strings.NewReader(stdout), func(res ToolResult) { got = append(got, res.Seq) })
+		if !errors.Is(err, ErrPartialGap) {
+			t.Errorf("%s: got %v, want ErrPartialGap", name, err)
+		}
+		if len(got) == 0 || got[0] != 1 {
+			t.Errorf("%s: lines before the gap should still be delivered, got %v", name, got)
+		}
+	}
+}
+
+func TestExecutorOnPartialDeliversInSeqOrder(t *testing.T) {
+	wasm := buildSkill(t, "partials")
+	var got []int
+	exec := New(Config{OnPartial: func(res ToolResult) { got = append(got, res.Seq) }})
+	res, err := exec.Execute(context.Background(), wasm, []byte(`{"seq":[2,1,4,3]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("delivered seqs %v, want %v", got, want)
+	}
+	if !res.Final || res.Seq != 4 {
+		t.Fatalf("Execute should parse the final line wherever it was written, got %+v", res.ToolResult)
+	}
+
+	_, err = exec.Execute(context.Background(), wasm, []byte(`{"seq":[1,3]}`))
+	if !errors.Is(err, ErrPartialGap) {
+		t.Fatalf("expected ErrPartialGap for a missing seq, got %v", err)
+	}
+}
+
+func TestStreamCutOffAtDeadlineEndsWithFinalLine(t *testing.T) {
+	wasm := skillDir(t, buildSkill(t, "stream"), `{"name":"stream","streaming":true}`)
+	// The timeout leaves room to compile the module; slowing the consumer
+	// down keeps the endless stream small.
+	exec := New(Config{OnPartial: func(ToolResult) { time.Sleep(time.Millisecond) }})
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+	defer cancel()
+
+	var stdout strings.Builder
+	if _, err := exec.ExecuteReader(ctx, wasm, strings.NewReader(`{}`), &stdout); err != nil {
+		t.Fatalf("a streaming skill should not fail at its deadline: %v", err)
+	}
+	if !strings.HasSuffix(stdout.String(), "\n") {
+		t.Fatal("stream should end with a newline")
+	}
+	var got []ToolResult
+	if err := ReadPartials(strings.NewReader(stdout.String()), func(res ToolResult) { got = append(got, res) }); err != nil {
+		t.Fatalf("terminated stream should read cleanly: %v", err)
+	}
+	if len(got) < 2 {
+		t.Fatalf("expected partials before the final line, got %+v", got)
+	}
+	if last := got[len(got)-1]; !last.Final || last.Success || last.ErrorCode != CodeDeadlineExceeded {
+		t.Fatalf("expected a deadline_exceeded final after %d partials, got %+v", len(got)-1, last)
+	}
+}
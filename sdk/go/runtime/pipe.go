@@ -0,0 +1,81 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// PipeResult is the outcome of Pipe.
+type PipeResult struct {
+	ToolResult
+	// Stage is the index of the stage that returned ToolResult: the last
+	// one, or the first whose result had Success false.
+	Stage int
+}
+
+// Pipe runs the skills at wasmPaths in order, as `zeroclaw skill pipe` does:
+// the first gets argsJSON, and each later one the Data object of the result
+// before it, or {"input": Output} when that result has none. It stops at the
+// first result with Success false and returns it.
+//
+// Each distinct path is compiled once, so a skill named in several stages
+// reuses its Module. Every stage runs on a fresh Instance, so the fetch and
+// output limits in Config and the stage's own manifest apply to each stage
+// alone; the stages share ctx's deadline. Config.Retries retries a failed
+// stage on another fresh Instance before Pipe stops at it.
+func (e *Executor) Pipe(ctx context.Context, wasmPaths []string, argsJSON []byte) (PipeResult, error) {
+	if len(wasmPaths) == 0 {
+		return PipeResult{}, errors.New("pipe needs at least one stage")
+	}
+	modules := map[string]*Module{}
+	defer func() {
+		for _, m := range modules {
+			m.Close(ctx)
+		}
+	}()
+
+	var res ToolResult
+	for i, path := range wasmPaths {
+		args := argsJSON
+		if i > 0 {
+			var err error
+			if args, err = nextStageArgs(res); err != nil {
+				return PipeResult{}, fmt.Errorf("stage %d: %w", i+1, err)
+			}
+		}
+		m, ok := modules[path]
+		if !ok {
+			var err error
+			if m, err = e.Compile(ctx, path); err != nil {
+				return PipeResult{}, fmt.Errorf("stage %d: %w", i+1, err)
+			}
+			modules[path] = m
+		}
+		var err error
+		res, err = e.retry(ctx, m.caps, func() (ToolResult, error) {
+			in, err := m.NewInstance(ctx)
+			if err != nil {
+				return ToolResult{}, err
+			}
+			return in.Call(ctx, args)
+		})
+		if err != nil {
+			return PipeResult{}, fmt.Errorf("stage %d: %w", i+1, err)
+		}
+		if !res.Success {
+			return PipeResult{ToolResult: res, Stage: i}, nil
+		}
+	}
+	return PipeResult{ToolResult: res, Stage: len(wasmPaths) - 1}, nil
+}
+
+// nextStageArgs builds the args of the stage after the one that returned res.
+func nextStageArgs(res ToolResult) ([]byte, error) {
+	if data := bytes.TrimSpace(res.Data); len(data) > 0 && data[0] == '{' {
+		return data, nil
+	}
+	return json.Marshal(map[string]string{"input": res.Output})
+}
@@ -0,0 +1,59 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPipeFeedsDataToTheNextStage(t *testing.T) {
+	wc, echo := buildTemplate(t, "word_count"), buildSkill(t, "echo")
+	compiles := 0
+	ex := New(Config{Tracer: func(span string, _ time.Duration) {
+		if span == SpanCompile {
+			compiles++
+		}
+	}})
+	res, err := ex.Pipe(context.Background(), []string{wc, echo, echo}, []byte(`{"text":"a b c"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// echo has no Data, so the third stage gets the second's output as input.
+	want := `{"input":"{\"words\":3,\"lines\":1,\"characters\":5}"}`
+	if !res.Success || res.Stage != 2 || res.Output != want {
+		t.Fatalf("got stage %d %+v, want %s", res.Stage, res.ToolResult, want)
+	}
+	if compiles != 2 {
+		t.Fatalf("compiled %d modules for 2 distinct skills", compiles)
+	}
+}
+
+func TestPipeStopsAtTheFirstFailure(t *testing.T) {
+	wc, echo := buildTemplate(t, "word_count"), buildSkill(t, "echo")
+	res, err := New(Config{}).Pipe(context.Background(), []string{wc, echo}, []byte(`{"text":"x","count_mode":"words"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || res.Stage != 0 || res.ErrorCode != "invalid_input" {
+		t.Fatalf("want the first stage's failure, got stage %d %+v", res.Stage, res.ToolResult)
+	}
+
+	if _, err := New(Config{}).Pipe(context.Background(), nil, nil); err == nil {
+		t.Fatal("a pipe without stages should fail")
+	}
+}
+
+func TestPipeAppliesLimitsPerStage(t *testing.T) {
+	echo := buildSkill(t, "echo")
+	// echo's result quotes its args, so every stage writes more than the last.
+	ex := New(Config{MaxOutputBytes: 60})
+	_, err := ex.Pipe(context.Background(), []string{echo, echo}, []byte(`{"text":"abc"}`))
+	if !errors.Is(err, ErrOutputTooLarge) || !strings.HasPrefix(err.Error(), "stage 2: ") {
+		t.Fatalf("want the second stage over the cap, got %v", err)
+	}
+	if _, err := ex.Pipe(context.Background(), []string{echo}, []byte(`{"text":"abc"}`)); err != nil {
+		t.Fatalf("the first stage alone fits the cap: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// probeEnvelope asks an SDK-built skill to describe itself instead of running
+// a handler (see skill.ProbeField).
+var probeEnvelope = []byte(`{"__probe":true}`)
+
+// Probe is a skill's own account of the tools it serves and the host
+// capabilities it needs.
+type Probe struct {
+	Tools       []ProbeTool `json:"tools"`
+	Permissions []string    `json:"permissions"`
+}
+
+// ProbeTool is one tool listed by a Probe.
+type ProbeTool struct {
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema"`
+}
+
+// Probe runs the skill at wasmPath with a probe envelope and parses its
+// report, so its permissions can be checked against policy before a real call.
+func (e *Executor) Probe(ctx context.Context, wasmPath string) (*Probe, error) {
+	res, err := e.Execute(ctx, wasmPath, probeEnvelope)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Success || res.Data == nil {
+		return nil, fmt.Errorf("%s: did not answer the probe (is it built with the skill SDK?)", wasmPath)
+	}
+	var p Probe
+	if err := json.Unmarshal(res.Data, &p); err != nil {
+		return nil, fmt.Errorf("%s: malformed probe report: %w", wasmPath, err)
+	}
+	return &p, nil
+}
+
+// Disallowed returns the permissions p requests that are not in allowed.
+func (p *Probe) Disallowed(allowed ...string) []string {
+	var extra []string
+	for _, perm := range p.Permissions {
+		if !slices.Contains(allowed, perm) {
+			extra = append(extra, perm)
+		}
+	}
+	return extra
+}
@@ -0,0 +1,30 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestProbeReportsToolsAndPermissions(t *testing.T) {
+	p, err := New(Config{}).Probe(context.Background(), buildSkill(t, "probe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Tools) != 1 || p.Tools[0].Name != "count" || string(p.Tools[0].Schema) != `{"type":"object"}` {
+		t.Fatalf("unexpected tools: %+v", p.Tools)
+	}
+	if got := fmt.Sprint(p.Disallowed("fs:/data")); got != "[http_fetch]" {
+		t.Fatalf("Disallowed = %s, want [http_fetch]", got)
+	}
+	if extra := p.Disallowed("fs:/data", "http_fetch"); extra != nil {
+		t.Fatalf("Disallowed = %v, want none", extra)
+	}
+}
+
+func TestProbeFailsForSkillsWithoutProbeSupport(t *testing.T) {
+	// echo reports its stdin as output with no data.
+	if _, err := New(Config{}).Probe(context.Background(), buildSkill(t, "echo")); err == nil {
+		t.Fatal("expected an error for a skill that does not answer probes")
+	}
+}
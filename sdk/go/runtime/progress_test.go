@@ -0,0 +1,54 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestProgressDemoStreamsIncreasingProgressThenResult(t *testing.T) {
+	wasm := buildTemplate(t, "progress_demo")
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	if _, err := ExecuteReader(ctx, wasm, strings.NewReader(`{"items":20000,"steps":5}`), &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("got %d lines, want 5 progress lines and a result:\n%s", len(lines), out.String())
+	}
+	last := -1.0
+	for _, line := range lines[:len(lines)-1] {
+		var ev struct {
+			Progress *struct {
+				Percent float64 `json:"percent"`
+			} `json:"progress"`
+		}
+		if err := json.Unmarshal([]byte(line), &ev); err != nil || ev.Progress == nil {
+			t.Fatalf("not a progress line: %s", line)
+		}
+		if ev.Progress.Percent <= last {
+			t.Fatalf("percent went from %v to %v:\n%s", last, ev.Progress.Percent, out.String())
+		}
+		last = ev.Progress.Percent
+	}
+	if last != 100 {
+		t.Fatalf("progress ended at %v, want 100", last)
+	}
+	var res ToolResult
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &res); err != nil || !res.Success {
+		t.Fatalf("last line is not a successful ToolResult: %s", lines[len(lines)-1])
+	}
+
+	// Execute skips the progress lines and parses the result.
+	parsed, err := Execute(ctx, wasm, []byte(`{"items":20000,"steps":5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Success || !bytes.Equal(parsed.Data, res.Data) {
+		t.Fatalf("Execute result %+v differs from the streamed one %s", parsed.ToolResult, lines[len(lines)-1])
+	}
+}
@@ -0,0 +1,92 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrResultField is returned by Compile and Execute when Config.ResultFields
+// names a field ToolResult does not have.
+var ErrResultField = errors.New("unknown result field")
+
+// resultField is one of ToolResult's JSON keys and the func that clears its
+// field.
+type resultField struct {
+	name  string
+	clear func(*ToolResult)
+}
+
+// resultFields lists ToolResult's fields in order.
+var resultFields = []resultField{
+	{"success", func(r *ToolResult) { r.Success = false }},
+	{"output", func(r *ToolResult) { r.Output = "" }},
+	{"output_type", func(r *ToolResult) { r.OutputType = "" }},
+	{"error", func(r *ToolResult) { r.Error = nil }},
+	{"error_code", func(r *ToolResult) { r.ErrorCode = "" }},
+	{"field_errors", func(r *ToolResult) { r.FieldErrors = nil }},
+	{"data", func(r *ToolResult) { r.Data = nil }},
+	{"artifacts", func(r *ToolResult) { r.Artifacts = nil }},
+	{"truncated", func(r *ToolResult) { r.Truncated = false }},
+	{"warnings", func(r *ToolResult) { r.Warnings = nil }},
+	{"seq", func(r *ToolResult) { r.Seq = 0 }},
+	{"id", func(r *ToolResult) { r.ID = "" }},
+	{"final", func(r *ToolResult) { r.Final = false }},
+	{"meta", func(r *ToolResult) { r.Meta = nil }},
+}
+
+// checkResultFields fails for the first of fields that is not a ToolResult
+// JSON key.
+func checkResultFields(fields []string) error {
+	for _, name := range fields {
+		if !slices.ContainsFunc(resultFields, func(f resultField) bool { return f.name == name }) {
+			return fmt.Errorf("%w %q in Config.ResultFields", ErrResultField, name)
+		}
+	}
+	return nil
+}
+
+// project clears every field of res that Config.ResultFields leaves out.
+func (e *Executor) project(res ToolResult) ToolResult {
+	if len(e.cfg.ResultFields) == 0 {
+		return res
+	}
+	for _, f := range resultFields {
+		if !slices.Contains(e.cfg.ResultFields, f.name) {
+			f.clear(&res)
+		}
+	}
+	return res
+}
+
+// MarshalFields encodes r as JSON with only the keys fields names, in
+// ToolResult's field order, so a projected result (see Config.ResultFields)
+// is sent without the zero "success" and "output" json.Marshal would add.
+// Empty fields encodes r whole. A named field r omits when empty is left out.
+func (r ToolResult) MarshalFields(fields []string) ([]byte, error) {
+	whole, err := json.Marshal(r)
+	if err != nil || len(fields) == 0 {
+		return whole, err
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(whole, &values); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	out.WriteByte('{')
+	for _, f := range resultFields {
+		value, ok := values[f.name]
+		if !ok || !slices.Contains(fields, f.name) {
+			continue
+		}
+		if out.Len() > 1 {
+			out.WriteByte(',')
+		}
+		fmt.Fprintf(&out, "%q:", f.name)
+		out.Write(value)
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
@@ -0,0 +1,62 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResultFieldsProjectWordCount(t *testing.T) {
+	ctx := context.Background()
+	exec := New(Config{ResultFields: []string{"data"}})
+	mod, err := exec.Compile(ctx, buildTemplate(t, "word_count"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mod.Close(ctx)
+	in, err := mod.NewInstance(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := in.Call(ctx, []byte(`{"text":"hello world"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := res.MarshalFields([]string{"data"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"data":{"words":2,"lines":1,"characters":11}}`
+	if string(out) != want || res.Output != "" || res.Success {
+		t.Fatalf("projected to data: got %s (%+v), want %s", out, res, want)
+	}
+
+	pool := NewPool(ctx, mod, 1)
+	defer pool.Close(ctx)
+	srv := httptest.NewServer(pool.InvokeHandler(0))
+	defer srv.Close()
+	resp, err := srv.Client().Post(srv.URL+InvokePath, "application/json", strings.NewReader(`{"text":"hello world"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != want {
+		t.Fatalf("invoke: got %d %s, want 200 %s", resp.StatusCode, body, want)
+	}
+}
+
+func TestResultFieldsRejectUnknownNames(t *testing.T) {
+	ctx := context.Background()
+	exec := New(Config{ResultFields: []string{"success", "outputs"}})
+	if _, err := exec.Compile(ctx, "missing.wasm"); !errors.Is(err, ErrResultField) || !strings.Contains(err.Error(), `"outputs"`) {
+		t.Fatalf("Compile: got %v, want ErrResultField naming outputs", err)
+	}
+	if _, err := exec.Execute(ctx, "missing.wasm", nil); !errors.Is(err, ErrResultField) {
+		t.Fatalf("Execute: got %v, want ErrResultField", err)
+	}
+}
@@ -0,0 +1,45 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// RawHeader starts the stderr line a skill writes before a raw result, as
+// skill.Raw does: RawHeader, a space, and the JSON
+// {"content_type":"...","length":N}. Stdout then holds the N-byte body in
+// place of a JSON ToolResult.
+const RawHeader = "ZEROCLAW_RAW=1"
+
+// ErrRawLength is returned for a raw result whose stdout is not as long as
+// its RawHeader line says, e.g. because the skill died writing it.
+var ErrRawLength = errors.New("raw result length does not match its header")
+
+// rawHeader is the JSON after RawHeader.
+type rawHeader struct {
+	ContentType string `json:"content_type"`
+	Length      int    `json:"length"`
+}
+
+// cutRawHeader finds the RawHeader line in a guest's stderr, returning it and
+// stderr without it. ok is false when the guest wrote no raw result.
+func cutRawHeader(stderr []byte) (header rawHeader, rest []byte, ok bool) {
+	prefix := []byte(RawHeader + " ")
+	for start := 0; start < len(stderr); {
+		end := bytes.IndexByte(stderr[start:], '\n')
+		if end < 0 {
+			end = len(stderr)
+		} else {
+			end += start + 1
+		}
+		if line := stderr[start:end]; bytes.HasPrefix(line, prefix) {
+			if json.Unmarshal(bytes.TrimPrefix(line, prefix), &header) == nil {
+				rest = append(append([]byte{}, stderr[:start]...), stderr[end:]...)
+				return header, rest, true
+			}
+		}
+		start = end
+	}
+	return rawHeader{}, stderr, false
+}
@@ -0,0 +1,31 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExecuteReturnsRawResults(t *testing.T) {
+	wasm := buildSkill(t, "raw")
+	res, err := Execute(context.Background(), wasm, []byte("\x00abc\xff"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(res.Raw) != "\xffcba\x00" || res.ContentType != "application/octet-stream" || !res.Success {
+		t.Fatalf("got raw %q (%s), success %v", res.Raw, res.ContentType, res.Success)
+	}
+	if log := string(res.Stderr); !strings.HasSuffix(log, "] reversing\n") || strings.Contains(log, RawHeader) {
+		t.Fatalf("the raw header should be cut from stderr, got %q", log)
+	}
+
+	if _, err := Execute(context.Background(), wasm, []byte("short")); !errors.Is(err, ErrRawLength) {
+		t.Fatalf("want ErrRawLength for a body shorter than its header, got %v", err)
+	}
+
+	res, err = Execute(context.Background(), buildSkill(t, "echo"), []byte(`{}`))
+	if err != nil || res.Raw != nil || res.ContentType != "" {
+		t.Fatalf("a JSON result should leave Raw unset, got %q: %v", res.Raw, err)
+	}
+}
@@ -0,0 +1,135 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RedactedArg stands in a Record for each arg the skill's schema marks
+// secret (see skill.SecretSchemaKey).
+const RedactedArg = "***"
+
+// recordSchemaTimeout bounds the --schema run that finds a skill's secret
+// args for its Record, so a skill that ignores --schema cannot hold up the
+// invocation that is being recorded.
+const recordSchemaTimeout = 5 * time.Second
+
+// Record is one invocation captured by Config.Recorder: the args the skill
+// read, compacted onto one line, and the ToolResult it returned. A file of Record lines
+// replays with `zeroclaw skill replay`, which runs each Args through the
+// current build and reports where its result differs from Result.
+type Record struct {
+	// Name is the skill's manifest "name", or else its file name.
+	Name   string          `json:"name"`
+	Args   json.RawMessage `json:"args"`
+	Result ToolResult      `json:"result"`
+}
+
+// record appends one Record line to the Recorder. Args sent compressed (see
+// ExecuteCompressed) are recorded inflated, and those schema marks secret
+// as RedactedArg. Args that are not JSON cannot be replayed and are
+// skipped, as are write errors, so recording never fails an invocation
+// whose result is already in hand.
+func (e *Executor) record(name string, schema *argsSchema, args []byte, res ToolResult) {
+	if bytes.HasPrefix(args, []byte{0x1f, 0x8b}) {
+		var plain bytes.Buffer
+		if inflateStdout(&plain, args) != nil {
+			return
+		}
+		args = plain.Bytes()
+	}
+	var compact bytes.Buffer
+	if json.Compact(&compact, args) != nil {
+		return
+	}
+	args = compact.Bytes()
+	if schema != nil {
+		args = schema.redact(args)
+	}
+	line, err := json.Marshal(Record{Name: name, Args: args, Result: res})
+	if err != nil {
+		return
+	}
+	e.recMu.Lock()
+	defer e.recMu.Unlock()
+	e.cfg.Recorder.Write(append(line, '\n'))
+}
+
+// recordName labels the records of the skill at wasmPath: the manifest's
+// name, or else the module's file name, or its directory's for the usual
+// tool.wasm.
+func recordName(wasmPath string, caps capabilities) string {
+	if caps.name != "" {
+		return caps.name
+	}
+	name := strings.TrimSuffix(filepath.Base(wasmPath), filepath.Ext(wasmPath))
+	if name == "tool" {
+		return filepath.Base(filepath.Dir(wasmPath))
+	}
+	return name
+}
+
+// redact returns argsJSON with the value of every arg s marks secret
+// replaced by RedactedArg, or argsJSON itself if there is none.
+func (s *argsSchema) redact(argsJSON []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(argsJSON))
+	dec.UseNumber()
+	var args any
+	if dec.Decode(&args) != nil {
+		return argsJSON
+	}
+	var hit bool
+	if s.args != nil {
+		args, hit = s.args.redact(args)
+	} else if env, ok := args.(map[string]any); ok {
+		name, _ := env["tool"].(string)
+		if tool, ok := s.tools[name]; ok && env["args"] != nil {
+			env["args"], hit = tool.redact(env["args"])
+		}
+	}
+	if !hit {
+		return argsJSON
+	}
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	if enc.Encode(args) != nil {
+		return argsJSON
+	}
+	return bytes.TrimSuffix(out.Bytes(), []byte("\n"))
+}
+
+// redact replaces v, or each value within it, that n marks secret, and
+// reports whether it replaced any.
+func (n *schemaNode) redact(v any) (any, bool) {
+	if n.Secret {
+		return RedactedArg, true
+	}
+	hit := false
+	switch v := v.(type) {
+	case map[string]any:
+		for key, val := range v {
+			prop, ok := n.Properties[key]
+			if !ok {
+				prop = n.AdditionalProperties
+			}
+			if prop != nil {
+				var h bool
+				v[key], h = prop.redact(val)
+				hit = hit || h
+			}
+		}
+	case []any:
+		if n.Items != nil {
+			for i, item := range v {
+				var h bool
+				v[i], h = n.Items.redact(item)
+				hit = hit || h
+			}
+		}
+	}
+	return v, hit
+}
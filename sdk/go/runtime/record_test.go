@@ -0,0 +1,73 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRecorderCapturesEachInvocation(t *testing.T) {
+	var rec bytes.Buffer
+	e := New(Config{Recorder: &rec})
+	wasm := skillDir(t, buildSkill(t, "echo"), `{"name":"echo_tool"}`)
+	for _, args := range []string{"{\"text\": \"a\"}\n", `not json`, `{"text":"b"}`} {
+		if _, err := e.Execute(context.Background(), wasm, []byte(args)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(rec.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want a record per JSON invocation, got:\n%s", rec.String())
+	}
+	var first Record
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if first.Name != "echo_tool" || string(first.Args) != `{"text":"a"}` ||
+		!first.Result.Success || first.Result.Output != "{\"text\": \"a\"}\n" {
+		t.Fatalf("unexpected record %s", lines[0])
+	}
+}
+
+func TestRecorderRedactsSecretArgs(t *testing.T) {
+	var rec bytes.Buffer
+	e := New(Config{Recorder: &rec})
+	wasm := buildSkill(t, "schema")
+	args := `{"text":"hi","api_key":"sk-live-123","options":{"wpm":90,"token":"t0k"}}`
+	res, err := e.Execute(context.Background(), wasm, []byte(args))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Output != args {
+		t.Fatalf("the skill should get its secret args unchanged, got %q", res.Output)
+	}
+	var got Record
+	if err := json.Unmarshal(rec.Bytes(), &got); err != nil {
+		t.Fatalf("%v: %s", err, rec.String())
+	}
+	if want := `{"api_key":"***","options":{"token":"***","wpm":90},"text":"hi"}`; string(got.Args) != want {
+		t.Fatalf("recorded args %s, want %s", got.Args, want)
+	}
+
+	rec.Reset()
+	if _, err := e.Execute(context.Background(), wasm, []byte(`{"text":"plain"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(rec.Bytes(), &got); err != nil || string(got.Args) != `{"text":"plain"}` {
+		t.Fatalf("args without secrets should be recorded as sent, got %s", rec.String())
+	}
+}
+
+func TestRecordNameFallsBackToFileName(t *testing.T) {
+	for path, want := range map[string]string{
+		"/skills/word_count/tool.wasm": "word_count",
+		"/tmp/echo.wasm":               "echo",
+	} {
+		if got := recordName(path, capabilities{}); got != want {
+			t.Errorf("recordName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
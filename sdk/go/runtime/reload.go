@@ -0,0 +1,101 @@
+package runtime
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+)
+
+// compiledModule is the code a Module instantiates and the version of its
+// file the code came from.
+type compiledModule struct {
+	code  wazero.CompiledModule
+	stamp fileStamp
+	// schema is read from code by ValidateArgs.
+	schema *lazySchema
+}
+
+// fileStamp identifies one version of a module file. WatchFile hashes the
+// file only once its size or modification time has moved.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+	sum     [sha256.Size]byte
+}
+
+// stampFile reads the file at path for its stamp.
+func stampFile(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{size: info.Size(), modTime: info.ModTime(), sum: sha256.Sum256(raw)}, nil
+}
+
+// sameFile reports whether info describes the file s was taken of, going by
+// its size and modification time.
+func (s fileStamp) sameFile(info os.FileInfo) bool {
+	return info.Size() == s.size && info.ModTime().Equal(s.modTime)
+}
+
+// WatchFile recompiles m if its file has changed since it was compiled, and
+// reports whether it did. Later NewInstance calls use the new code; an
+// Instance made before, including one in the middle of a Call, keeps running
+// the code it was made from. WatchFile does not read a file whose size and
+// modification time are unchanged, and does not recompile one rewritten
+// with the same bytes.
+//
+// The new file must pass the checks Compile made, TrustedKeys and Sandboxed
+// among them. If it fails one or does not compile, as a file caught halfway
+// through a rebuild may not, WatchFile returns the error and m keeps its
+// code. The manifest is not reread: capabilities and secrets stay as Compile
+// found them. With Config.AutoReload set, NewInstance calls WatchFile first.
+func (m *Module) WatchFile(ctx context.Context) (bool, error) {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+	cur := m.code.Load()
+	info, err := os.Stat(m.path)
+	if err != nil {
+		return false, fmt.Errorf("watch %s: %w", m.path, err)
+	}
+	if cur.stamp.sameFile(info) {
+		return false, nil
+	}
+	stamp, err := stampFile(m.path)
+	if err != nil {
+		return false, fmt.Errorf("watch %s: %w", m.path, err)
+	}
+	if stamp.sum == cur.stamp.sum {
+		m.code.Store(&compiledModule{code: cur.code, stamp: stamp, schema: cur.schema})
+		return false, nil
+	}
+
+	wasm, _, err := m.exec.loadModule(m.path)
+	if err != nil {
+		return false, err
+	}
+	start := time.Now()
+	code, err := m.rt.CompileModule(ctx, wasm)
+	m.exec.span(SpanCompile, start)
+	if err != nil {
+		return false, fmt.Errorf("compile %s: %w", m.path, err)
+	}
+	if m.exec.cfg.Sandboxed {
+		if err := checkSandboxImports(m.path, code); err != nil {
+			code.Close(ctx)
+			return false, err
+		}
+	}
+	m.code.Store(&compiledModule{code: code, stamp: stamp, schema: new(lazySchema)})
+	// Instances of the old code hold on to what they need of it.
+	cur.code.Close(ctx)
+	return true, nil
+}
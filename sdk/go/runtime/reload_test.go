@@ -0,0 +1,62 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAutoReloadSwapsRebuiltModule(t *testing.T) {
+	ctx := context.Background()
+	path := skillDir(t, buildSkill(t, "echo"), `{"name":"echo"}`)
+	mod, err := New(Config{AutoReload: true}).Compile(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mod.Close(ctx)
+	if swapped, err := mod.WatchFile(ctx); err != nil || swapped {
+		t.Fatalf("unchanged file: swapped %v, err %v", swapped, err)
+	}
+	before, err := mod.NewInstance(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(buildTemplate(t, "word_count"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := mod.NewInstance(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := after.Call(ctx, []byte(`{"text":"a b"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data struct {
+		Words int `json:"words"`
+	}
+	if !res.Success || json.Unmarshal(res.Data, &data) != nil || data.Words != 2 {
+		t.Fatalf("after reload: got %+v, want word_count's result", res)
+	}
+
+	// The instance made before the swap still runs the old code.
+	res, err = before.Call(ctx, []byte("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.Output != "hi" {
+		t.Fatalf("before reload: got %+v, want the echo", res)
+	}
+}
@@ -0,0 +1,64 @@
+package runtime
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// DefaultRetryableErrors are the error codes a failed result is retried for
+// when Config.Retries is set and the skill's manifest has no
+// "retryable_errors" list: failures a fresh attempt may not repeat.
+var DefaultRetryableErrors = []string{"internal", "timeout"}
+
+// neverRetried is the error code no "retryable_errors" list may name: bad
+// args fail the same way every time.
+const neverRetried = "invalid_input"
+
+// retryable reports whether a call that ended with res and err may be tried
+// again on a fresh instance: err must be nil and res a failure with one of
+// caps' retryable error codes.
+func (caps capabilities) retryable(res ToolResult, err error) bool {
+	if err != nil || res.Success || res.ErrorCode == neverRetried {
+		return false
+	}
+	codes := caps.retryErrors
+	if codes == nil {
+		codes = DefaultRetryableErrors
+	}
+	return slices.Contains(codes, res.ErrorCode)
+}
+
+// retry calls attempt, which must run on a fresh instance each time, until
+// its result is not retryable or Config.Retries retries are spent. It waits
+// Config.RetryBackoff before the first retry and twice as long before each
+// one after; a ctx done while waiting ends it with the last result. With
+// Config.Retries set, the result's Meta.Attempts says how many calls it took.
+func (e *Executor) retry(ctx context.Context, caps capabilities, attempt func() (ToolResult, error)) (ToolResult, error) {
+	backoff := e.cfg.RetryBackoff
+	for n := 1; ; n++ {
+		res, err := attempt()
+		if n > e.cfg.Retries || !caps.retryable(res, err) || !sleep(ctx, backoff) {
+			if err == nil && e.cfg.Retries > 0 {
+				if res.Meta == nil {
+					res.Meta = &ResultMeta{}
+				}
+				res.Meta.Attempts = n
+			}
+			return res, err
+		}
+		backoff *= 2
+	}
+}
+
+// sleep waits d, reporting false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
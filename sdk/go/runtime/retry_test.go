@@ -0,0 +1,92 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer tells the flaky test skill to fail its first failures calls,
+// and counts the calls in *calls.
+func flakyServer(t *testing.T, failures int32, calls *atomic.Int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			fmt.Fprint(w, "fail")
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRetriesTransientFailure(t *testing.T) {
+	var calls atomic.Int32
+	srv := flakyServer(t, 2, &calls)
+	ex := New(Config{HTTPClient: srv.Client(), Retries: 3, RetryBackoff: time.Millisecond})
+	wasm := skillDir(t, buildSkill(t, "flaky"), `{"capabilities":{"net":true}}`)
+
+	res, err := ex.Execute(context.Background(), wasm, []byte(fmt.Sprintf(`{"url":%q,"code":"internal"}`, srv.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.Meta == nil || res.Meta.Attempts != 3 || calls.Load() != 3 {
+		t.Fatalf("got %+v (meta %+v) after %d calls, want success on attempt 3", res.ToolResult, res.Meta, calls.Load())
+	}
+
+	calls.Store(0)
+	m, err := ex.Compile(context.Background(), wasm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(context.Background())
+	p := NewPool(context.Background(), m, 1)
+	defer p.Close(context.Background())
+	got, err := p.Call(context.Background(), []byte(fmt.Sprintf(`{"url":%q,"code":"timeout"}`, srv.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Success || got.Meta.Attempts != 3 {
+		t.Fatalf("pool: got %+v (meta %+v), want success on attempt 3", got, got.Meta)
+	}
+}
+
+func TestRetriesGiveUp(t *testing.T) {
+	for name, tc := range map[string]struct {
+		manifest, code string
+		attempts       int
+	}{
+		"spent retries":      {`{"capabilities":{"net":true}}`, "internal", 3},
+		"invalid_input":      {`{"capabilities":{"net":true}}`, "invalid_input", 1},
+		"code not listed":    {`{"capabilities":{"net":true}}`, "not_found", 1},
+		"manifest lists it":  {`{"capabilities":{"net":true},"retryable_errors":["not_found"]}`, "not_found", 3},
+		"manifest drops one": {`{"capabilities":{"net":true},"retryable_errors":["not_found"]}`, "internal", 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := flakyServer(t, 10, &calls)
+			ex := New(Config{HTTPClient: srv.Client(), Retries: 2, RetryBackoff: time.Millisecond})
+			wasm := skillDir(t, buildSkill(t, "flaky"), tc.manifest)
+			res, err := ex.Execute(context.Background(), wasm, []byte(fmt.Sprintf(`{"url":%q,"code":%q}`, srv.URL, tc.code)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Success || res.ErrorCode != tc.code || res.Meta.Attempts != tc.attempts || int(calls.Load()) != tc.attempts {
+				t.Fatalf("got %+v (meta %+v) after %d calls, want %s after %d", res.ToolResult, res.Meta, calls.Load(), tc.code, tc.attempts)
+			}
+		})
+	}
+}
+
+func TestRetryableErrorsRejectsInvalidInput(t *testing.T) {
+	wasm := skillDir(t, buildSkill(t, "flaky"), `{"retryable_errors":["internal","invalid_input"]}`)
+	_, err := New(Config{Retries: 1}).Execute(context.Background(), wasm, []byte(`{}`))
+	if err == nil || !strings.Contains(err.Error(), "invalid_input") {
+		t.Fatalf("got %v, want an error naming invalid_input", err)
+	}
+}
@@ -0,0 +1,839 @@
+// Package runtime executes ZeroClaw WASI skills in-process on wazero.
+//
+// A skill is a wasip1 command module that reads its JSON args from stdin and
+// writes a single JSON ToolResult to stdout — the same protocol
+// `zeroclaw skill test` drives through the wasmtime CLI. Besides bare .wasm
+// modules, the executor runs .zcskill packages built by `zeroclaw skill package`.
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+	"golang.org/x/time/rate"
+)
+
+// Span names reported to Config.Tracer, one per execution phase.
+const (
+	SpanCompile     = "compile"
+	SpanInstantiate = "instantiate"
+	SpanExecute     = "execute"
+)
+
+// Config tunes an Executor. The zero value is ready to use.
+type Config struct {
+	// Tracer, when set, is called once per phase with the phase's duration.
+	Tracer func(span string, d time.Duration)
+
+	// Metrics, when set, counts every ExecuteReader and Instance.Call, their
+	// failures by error code, and their latency; serve it as /metrics.
+	Metrics *Metrics
+
+	// TrustedKeys, when non-empty, restricts execution to .zcskill packages
+	// signed by one of these keys; anything else fails with ErrUnverified.
+	TrustedKeys []ed25519.PublicKey
+
+	// HTTPClient, when set, serves the zeroclaw_http_fetch host function;
+	// without it every fetch fails with FetchDenied.
+	HTTPClient *http.Client
+	// HTTPRateLimit and HTTPBurst throttle fetches within one invocation.
+	// A zero HTTPRateLimit leaves fetches unthrottled; HTTPBurst defaults to 1.
+	HTTPRateLimit rate.Limit
+	HTTPBurst     int
+	// MaxFetches caps the fetches one invocation may make; zero means no cap.
+	MaxFetches int
+	// MaxResponseBytes caps how much of one response body a fetch reads; the
+	// rest is cut off, and the skill is told the length of what was read.
+	// Zero means 8 MiB.
+	MaxResponseBytes int
+
+	// Retries is how many more times Execute, Pool.Call, and each stage of
+	// Pipe call a skill whose result fails with a retryable error code: one
+	// of the manifest's "retryable_errors", or DefaultRetryableErrors when
+	// it has none. "invalid_input" is never retried. Every attempt runs on a
+	// fresh instance, RetryBackoff after the one before, doubling each time.
+	// ExecuteReader and Instance.Call are not retried, since their input and
+	// instance cannot be reused. Zero means no retries.
+	Retries      int
+	RetryBackoff time.Duration
+
+	// MaxOutputBytes caps what one invocation may write to stdout; more fails
+	// with ErrOutputTooLarge. Zero means no cap. A manifest's
+	// "max_output_bytes" can lower it for one skill, which then ends with a
+	// CodeResourceExhausted result instead. The cap and the context's
+	// deadline are passed to the skill in MaxOutputBytesEnv and DeadlineEnv
+	// so it can trim its result to fit (see skill.Budget).
+	MaxOutputBytes int
+
+	// MaxInputBytes is advertised to the skill in MaxInputBytesEnv as the
+	// most bytes of args it should read; an SDK-built skill fails a larger
+	// request with invalid_input rather than buffer it (see skill.Run). Zero
+	// means DefaultMaxInputBytes and a negative value advertises no cap. The
+	// host does not enforce it: a skill that ignores the variable reads all
+	// it is given.
+	MaxInputBytes int
+
+	// OnPartial, when set, receives each result line of a streaming skill
+	// (see skill.Emitter) in Seq order as ExecuteReader runs it, ending with
+	// the Final result. Out-of-sequence lines fail the call with
+	// ErrPartialGap.
+	OnPartial func(ToolResult)
+
+	// StreamBuffer, when positive, lets ExecuteReader queue up to that many
+	// lines of stdout for w, so a skill need not wait on a slow consumer
+	// until the queue is full. Zero writes each line to w as the guest
+	// does. With DropProgress set, a full queue drops progress lines
+	// instead, oldest first, and counts them in Result.DroppedProgress;
+	// result lines, and so OnPartial, are never dropped.
+	StreamBuffer int
+	DropProgress bool
+
+	// OnAsk, when set, answers the questions a skill puts with skill.Ask
+	// while ExecuteReader runs it; the guest blocks until it returns. An
+	// error is passed back to the skill as the reason. Without OnAsk the
+	// skill is told the host does not answer (skill.ErrAskNotSupported).
+	// Asking needs the args whole, so ExecuteReader reads all of r first,
+	// and skills whose manifest sets "input": "ndjson" cannot ask.
+	OnAsk func(Ask) (json.RawMessage, error)
+
+	// Secrets holds credentials by key. A skill gets only those its
+	// manifest lists under "capabilities.secrets", read with skill.Secret,
+	// and fails with ErrMissingSecret if one is absent. Their values are
+	// replaced with Redacted wherever the skill writes them: stdout, w,
+	// partials, and stderr.
+	Secrets map[string]string
+
+	// StderrSink, when set, receives the guest's stderr as it is written, so
+	// the logs of a long skill can be watched live; Result.Stderr is still
+	// filled in. Lines reach it as the guest wrote them, without the trace
+	// ID prefix, and with Secrets redacted. Executions running concurrently
+	// share it, so it must be safe for concurrent use. Nil keeps stderr
+	// buffered until the skill exits.
+	StderrSink io.Writer
+
+	// ScratchRoot, when set, gives every invocation a fresh, empty directory
+	// under it, mounted writable in the guest at ScratchGuestDir and named
+	// in ScratchEnv (see skill.ScratchDir). It is removed, with everything
+	// the skill wrote there, once the invocation returns, so no state leaks
+	// from one call to the next. An Instance gets its directory when it is
+	// created and loses it when it is called or closed.
+	ScratchRoot string
+
+	// Recorder, when set, gets one Record line per invocation whose stdout
+	// was parsed, for `zeroclaw skill replay` to check later builds against.
+	// See Record.
+	Recorder io.Writer
+
+	// Seed, when set, is passed to every invocation in SeedEnv, so a skill
+	// drawing from skill.Rand gives the same output run after run. Args
+	// carrying SeedField override it. Nil leaves skills seeded from the
+	// clock.
+	Seed *int64
+
+	// Sandboxed runs skills as pure computations from stdin to stdout, for
+	// third-party code. Only WASI is wired: a skill importing any other host
+	// function, such as zeroclaw_http_fetch, fails to compile with
+	// ErrCapabilityDenied naming the import, as does one whose manifest asks
+	// for "net", secrets, NDJSON input, or gzip compression. The guest gets
+	// no environment variables, no directories (ScratchRoot is ignored), no
+	// answers to Ask, and wazero's fake clocks, which start at a fixed
+	// epoch, and deterministic random source. Output limits and ctx's
+	// deadline still apply.
+	Sandboxed bool
+
+	// PreValidate makes Execute check its args against the skill's schema,
+	// as Module.ValidateArgs does, before running it, and fail args that
+	// cannot match with an *ArgsError wrapping ErrInvalidArgs instead of
+	// passing them to the guest. The schema is read by running the skill
+	// once with --schema and is kept until its file changes. A skill that
+	// prints no schema fails every Execute. ExecuteReader, which streams its
+	// args, does not check them.
+	PreValidate bool
+
+	// AutoReload makes every Module.NewInstance first check the Module's
+	// file and recompile it if it changed (see Module.WatchFile), so a dev
+	// host picks up a rebuilt skill without restarting. Each check stats the
+	// file, so production hosts should leave it off.
+	AutoReload bool
+
+	// CompilationCache, when set, keeps the machine code wazero compiles
+	// for each module, so running a module again, a retry included, skips
+	// compiling it. Executors sharing one cache share the work, and
+	// wazero.NewCompilationCacheWithDir keeps it on disk across restarts.
+	// Nil compiles every module each time it is loaded.
+	CompilationCache wazero.CompilationCache
+
+	// ResultFields, when set, projects every ToolResult the executor returns
+	// to the fields named by their JSON keys, such as
+	// []string{"success", "data"}, for hosts that want to save tokens by
+	// dropping the human Output. The other fields are left zero, so a host
+	// that drops "success" must not read Success. InvokeHandler writes only
+	// the named keys, and ToolResult.MarshalFields does the same for other
+	// hosts. Metrics and Recorder still see the whole result. A name that is
+	// not a ToolResult key fails Compile and Execute with ErrResultField
+	// before any skill is loaded.
+	ResultFields []string
+
+	// Before, when set, is called with the skill's name (its manifest
+	// "name", or else its file name) and args once Execute has read the
+	// skill and before it runs it, for policy such as access control; an
+	// error fails the call with that error before any instance is made.
+	// The call runs the module that was read, even if the file changes
+	// while Before runs. After, when set, is called once
+	// Execute is done with each call, whether it succeeded, failed, or was
+	// stopped by Before, for audit logs; its res is zero when err is not
+	// nil. ExecuteReader, Instance.Call, and Pool.Call do not call them.
+	Before func(ctx context.Context, name string, args []byte) error
+	After  func(ctx context.Context, name string, res ToolResult, err error)
+}
+
+// ToolResult is the JSON object a skill writes to stdout.
+type ToolResult struct {
+	Success bool   `json:"success"`
+	Output  string `json:"output"`
+	// OutputType is the media type of Output, such as "text/markdown" or
+	// "text/html", for the host to pick a renderer by; empty means plain
+	// text (see skill.ToolResult.AsMarkdown).
+	OutputType string  `json:"output_type,omitempty"`
+	Error      *string `json:"error,omitempty"`
+	// ErrorCode classifies a failure, e.g. "invalid_input" (see skill.ErrorCode).
+	ErrorCode string `json:"error_code,omitempty"`
+	// FieldErrors locates invalid input within the args (see skill.FieldError).
+	FieldErrors []FieldError    `json:"field_errors,omitempty"`
+	Data        json.RawMessage `json:"data,omitempty"`
+	// Artifacts are the binary files the skill returned, already inflated
+	// when they were compressed on the wire.
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+	// Truncated is set by a skill that cut its result short to fit the
+	// limits it was given (see Config.MaxOutputBytes).
+	Truncated bool `json:"truncated,omitempty"`
+	// Warnings are the skill's notes on a result that still succeeded (see
+	// skill.ToolResult.Warn).
+	Warnings []string `json:"warnings,omitempty"`
+	// Seq, ID, and Final number and name the result lines of a streaming
+	// skill; see ReadPartials.
+	Seq   int    `json:"seq,omitempty"`
+	ID    string `json:"id,omitempty"`
+	Final bool   `json:"final,omitempty"`
+	// ETag names the version of the data behind the result; send it back
+	// in IfNoneMatchField to ask whether it changed. NotModified means it
+	// did not: the result the host holds for ETag is still valid, and this
+	// one carries nothing else worth processing (see skill.NotModified).
+	ETag        string `json:"etag,omitempty"`
+	NotModified bool   `json:"not_modified,omitempty"`
+	// Meta carries the trace ID the skill served the request under and, for
+	// a result the executor parsed, the side effects its manifest declares.
+	Meta *ResultMeta `json:"meta,omitempty"`
+}
+
+// FieldError is one problem in a skill's args: Path is a JSON Pointer such
+// as "/options/wpm".
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Timings breaks an invocation down by phase.
+type Timings struct {
+	Compile     time.Duration
+	Instantiate time.Duration
+	Execute     time.Duration
+}
+
+// Exit statuses with defined meaning; see skill.ExitOK, skill.ExitInvalidInput,
+// and skill.ExitPanic. Any other non-zero status is an internal trap and fails
+// with ErrTrap.
+const (
+	ExitOK           = 0
+	ExitInvalidInput = 2
+	ExitPanic        = 3
+)
+
+// ErrTrap is returned when a skill traps or exits with a status other than
+// ExitOK, ExitInvalidInput, or ExitPanic.
+var ErrTrap = errors.New("skill trapped")
+
+// CodeInternal is the error code of the result the executor returns for a
+// skill that overflowed its call stack, with StackOverflowMessage as its
+// error, in place of the raw trap.
+const CodeInternal = "internal"
+
+// StackOverflowMessage is the error of the result for a stack overflow.
+const StackOverflowMessage = "stack overflow (possible unbounded recursion)"
+
+// ErrOutputType is returned for a ToolResult whose output_type is not a
+// media type such as "text/markdown".
+var ErrOutputType = errors.New("output_type is not a media type")
+
+// Result is the outcome of one skill invocation.
+type Result struct {
+	ToolResult
+	// ExitCode is ExitOK, ExitInvalidInput, or ExitPanic; stdout is parsed
+	// either way. ExitPanic means the SDK recovered a panic in the handler.
+	ExitCode int
+	// Stderr holds whatever the guest logged, each line prefixed with
+	// "[trace-id] ".
+	Stderr []byte
+	// Fetches counts the zeroclaw_http_fetch requests the skill sent.
+	Fetches int
+	Timings Timings
+	Usage   Usage
+	// Raw and ContentType hold the body of a skill that returned a raw
+	// result (see RawHeader) instead of a JSON ToolResult; ToolResult is then
+	// only Success. Raw is nil for every other result.
+	Raw         []byte
+	ContentType string
+	// DroppedProgress counts the progress lines ExecuteReader dropped
+	// rather than keep the skill waiting on w (see Config.DropProgress).
+	DroppedProgress int
+}
+
+// Usage is what one invocation consumed, for cost accounting. A metric the
+// executor could not observe is left zero.
+type Usage struct {
+	// PeakMemoryBytes is the guest's linear memory at its largest. Linear
+	// memory never shrinks, so this is its size when the skill exited.
+	PeakMemoryBytes uint64
+	// ExecTime is Timings.Execute, and Fetches is Result.Fetches.
+	ExecTime time.Duration
+	Fetches  int
+	// BytesIn and BytesOut count what the guest read from stdin and wrote
+	// to stdout, as it crossed the guest boundary: gzipped for a skill whose
+	// manifest sets "compression": "gzip".
+	BytesIn, BytesOut int
+}
+
+// Executor runs skills with a fixed Config.
+type Executor struct {
+	cfg Config
+	// recMu keeps concurrent invocations' Record lines whole.
+	recMu sync.Mutex
+	// schemas caches the args schema of each path Execute validated
+	// under PreValidate.
+	schemaMu sync.Mutex
+	schemas  map[string]*pathSchema
+}
+
+// New returns an Executor using cfg.
+func New(cfg Config) *Executor {
+	return &Executor{cfg: cfg}
+}
+
+// defaultCompilationCache is used by executors whose Config has no
+// CompilationCache. It is nil outside tests, which set it so each test
+// module compiles once per test binary.
+var defaultCompilationCache wazero.CompilationCache
+
+// runtimeConfig is the wazero configuration for the runtimes that run
+// skills. Closing the context given to a call closes its module.
+func (e *Executor) runtimeConfig() wazero.RuntimeConfig {
+	cfg := wazero.NewRuntimeConfig().WithCloseOnContextDone(true)
+	cache := e.cfg.CompilationCache
+	if cache == nil {
+		cache = defaultCompilationCache
+	}
+	if cache != nil {
+		cfg = cfg.WithCompilationCache(cache)
+	}
+	return cfg
+}
+
+// Execute runs the skill at wasmPath with argsJSON on stdin using the default Config.
+func Execute(ctx context.Context, wasmPath string, argsJSON []byte) (*Result, error) {
+	return New(Config{}).Execute(ctx, wasmPath, argsJSON)
+}
+
+// Execute runs the skill at wasmPath with argsJSON on stdin and parses its ToolResult.
+//
+// A ToolResult with Success=false is returned as a Result, not an error; errors
+// are reserved for failures to load, run, or parse the module.
+func (e *Executor) Execute(ctx context.Context, wasmPath string, argsJSON []byte) (*Result, error) {
+	if e.cfg.Before == nil && e.cfg.After == nil {
+		return e.execute(ctx, wasmPath, nil, argsJSON)
+	}
+	// The name comes from the manifest, so the skill is read first, and the
+	// call then runs what was read.
+	var file *skillFile
+	err := checkResultFields(e.cfg.ResultFields)
+	if err == nil {
+		file, err = e.readSkill(wasmPath)
+	}
+	var caps capabilities
+	if file != nil {
+		caps = file.caps
+	}
+	name := recordName(wasmPath, caps)
+	if err == nil && e.cfg.Before != nil {
+		err = e.cfg.Before(ctx, name, argsJSON)
+	}
+	var res *Result
+	if err == nil {
+		res, err = e.execute(ctx, wasmPath, file, argsJSON)
+	}
+	if e.cfg.After != nil {
+		var tr ToolResult
+		if err == nil {
+			tr = res.ToolResult
+		}
+		e.cfg.After(ctx, name, tr, err)
+	}
+	return res, err
+}
+
+// execute is Execute without Config.Before and Config.After. file is the
+// skill as Execute read it, or nil to read it here.
+func (e *Executor) execute(ctx context.Context, wasmPath string, file *skillFile, argsJSON []byte) (*Result, error) {
+	if e.cfg.PreValidate {
+		if err := e.validateArgs(ctx, wasmPath, argsJSON); err != nil {
+			return nil, err
+		}
+	}
+	if e.cfg.Retries == 0 {
+		return e.executeStream(ctx, wasmPath, file, bytes.NewReader(argsJSON), nil)
+	}
+	if file == nil {
+		var err error
+		if file, err = e.readSkill(wasmPath); err != nil {
+			return nil, err
+		}
+	}
+	var res *Result
+	last, err := e.retry(ctx, file.caps, func() (ToolResult, error) {
+		var err error
+		if res, err = e.executeStream(ctx, wasmPath, file, bytes.NewReader(argsJSON), nil); err != nil {
+			return ToolResult{}, err
+		}
+		return res.ToolResult, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.ToolResult = last
+	return res, nil
+}
+
+// ExecuteReader runs the skill at wasmPath using the default Config, streaming r
+// to its stdin. See Executor.ExecuteReader.
+func ExecuteReader(ctx context.Context, wasmPath string, r io.Reader, w io.Writer) (*Result, error) {
+	return New(Config{}).ExecuteReader(ctx, wasmPath, r, w)
+}
+
+// ExecuteReader runs the skill at wasmPath, streaming r to its stdin so large
+// inputs never have to be buffered by the host.
+//
+// When w is nil, stdout is parsed into Result.ToolResult as in Execute.
+// Otherwise stdout is copied to w as the guest writes it and left unparsed.
+// A skill whose manifest sets "input": "ndjson" answers every line of r with
+// a ToolResult line as soon as it reads it, so pass a w to receive them all;
+// with a nil w only the last is parsed.
+//
+// Every invocation runs under a trace ID: the args' TraceField when the
+// caller set one, else a fresh ID passed to the skill in TraceIDEnv. An
+// SDK-built skill echoes it in ToolResult.Meta, and each line of
+// Result.Stderr is prefixed with it.
+//
+// A skill whose manifest sets "streaming": true is not failed at ctx's
+// deadline: its stdout is ended with a Final result carrying
+// CodeDeadlineExceeded, unless the skill wrote its own in time, and that is
+// the Result. Such a skill is told the deadline StreamFlushGrace early.
+//
+// A skill whose manifest sets "compression": "gzip" is sent r gzipped and
+// its stdout is inflated before it reaches w or Result, so it is copied to
+// w only once the skill exits. Config.OnAsk is not offered to such a skill.
+func (e *Executor) ExecuteReader(ctx context.Context, wasmPath string, r io.Reader, w io.Writer) (*Result, error) {
+	return e.executeStream(ctx, wasmPath, nil, r, w)
+}
+
+// executeStream is ExecuteReader for the skill file, reading wasmPath only
+// when file is nil.
+func (e *Executor) executeStream(ctx context.Context, wasmPath string, file *skillFile, r io.Reader, w io.Writer) (*Result, error) {
+	start := time.Now()
+	res, err := e.executeReader(ctx, wasmPath, file, r, w)
+	if e.cfg.Metrics != nil {
+		var tr *ToolResult
+		if res != nil && w == nil {
+			tr = &res.ToolResult
+		}
+		e.cfg.Metrics.observe(tr, err, time.Since(start))
+	}
+	if res != nil && w == nil {
+		res.ToolResult = e.project(res.ToolResult)
+	}
+	return res, err
+}
+
+func (e *Executor) executeReader(ctx context.Context, wasmPath string, file *skillFile, r io.Reader, w io.Writer) (*Result, error) {
+	if err := checkResultFields(e.cfg.ResultFields); err != nil {
+		return nil, err
+	}
+	if file == nil {
+		var err error
+		if file, err = e.readSkill(wasmPath); err != nil {
+			return nil, err
+		}
+	}
+	wasm, caps := file.wasm, file.caps
+	if e.cfg.Sandboxed {
+		if err := checkSandboxCaps(wasmPath, caps); err != nil {
+			return nil, err
+		}
+	}
+	secrets, err := e.secretsFor(wasmPath, caps)
+	if err != nil {
+		return nil, err
+	}
+	red := newRedactor(secrets)
+	// Registered before the runtime is closed, so it runs after.
+	scratch, removeScratch, err := e.newScratch()
+	if err != nil {
+		return nil, fmt.Errorf("scratch dir for %s: %w", wasmPath, err)
+	}
+	defer removeScratch()
+
+	rt := wazero.NewRuntimeWithConfig(ctx, e.runtimeConfig())
+	defer rt.Close(ctx)
+	wasi_snapshot_preview1.MustInstantiate(ctx, rt)
+	if err := e.instantiateHost(ctx, rt); err != nil {
+		return nil, err
+	}
+
+	var args *bytes.Buffer
+	if e.cfg.Recorder != nil && w == nil {
+		args = new(bytes.Buffer)
+		r = io.TeeReader(r, args)
+	}
+	if caps.gzip {
+		var stop func()
+		r, stop = gzipStream(r)
+		defer stop()
+	}
+
+	var res Result
+	start := time.Now()
+	compiled, err := rt.CompileModule(ctx, wasm)
+	res.Timings.Compile = e.span(SpanCompile, start)
+	if err != nil {
+		return nil, fmt.Errorf("compile %s: %w", wasmPath, err)
+	}
+	if e.cfg.Sandboxed {
+		if err := checkSandboxImports(wasmPath, compiled); err != nil {
+			return nil, err
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	out := w
+	if out == nil {
+		out = &stdout
+	}
+	var queue *streamQueue
+	if w != nil && e.cfg.StreamBuffer > 0 {
+		queue = newStreamQueue(w, e.cfg.StreamBuffer, e.cfg.DropProgress)
+		defer queue.close()
+		out = queue
+	}
+	var partials *partialWriter
+	if e.cfg.OnPartial != nil {
+		partials = &partialWriter{seq: newSequencer(e.cfg.OnPartial)}
+		out = io.MultiWriter(out, partials)
+	}
+	var tail *streamTail
+	stream := out
+	if caps.streaming {
+		tail = new(streamTail)
+		out = io.MultiWriter(out, tail)
+	}
+	var ask *asker
+	if e.cfg.OnAsk != nil && !caps.lines && !caps.gzip && !e.cfg.Sandboxed {
+		if ask, err = newAsker(out, r, e.cfg.OnAsk); err != nil {
+			return nil, fmt.Errorf("read args for %s: %w", wasmPath, err)
+		}
+		out, r = ask, ask
+	}
+	var lines *lineWriter
+	if red != nil {
+		lines = &lineWriter{r: red, w: out}
+		out = lines
+	}
+	// A compressed stdout is inflated into out once the guest is done.
+	guestOut := out
+	var packed *bytes.Buffer
+	if caps.gzip {
+		packed = new(bytes.Buffer)
+		guestOut = packed
+	}
+	capped := e.capOutput(guestOut, caps)
+	errOut, flushErr := e.stderrTo(&stderr, red)
+	in := &countingReader{r: r}
+	modCfg := wazero.NewModuleConfig().
+		WithStdin(in).
+		WithStdout(capped).
+		WithStderr(errOut).
+		WithStartFunctions() // run _start ourselves so instantiate and execute time separately
+	traceID := newTraceID()
+	if !e.cfg.Sandboxed {
+		modCfg = withSecrets(withManifest(e.withBudget(ctx, modCfg, caps), caps), secrets)
+		modCfg = withSeed(withScratch(modCfg, scratch), e.cfg.Seed)
+		if ask != nil {
+			modCfg = modCfg.WithEnv(AskEnv, "1")
+		}
+		modCfg = modCfg.WithEnv(TraceIDEnv, traceID)
+	}
+
+	start = time.Now()
+	mod, err := rt.InstantiateModule(ctx, compiled, modCfg)
+	res.Timings.Instantiate = e.span(SpanInstantiate, start)
+	if err != nil {
+		return nil, fmt.Errorf("instantiate %s: %w", wasmPath, err)
+	}
+	defer mod.Close(ctx)
+
+	run := mod.ExportedFunction("_start")
+	if run == nil {
+		return nil, fmt.Errorf("%s: no _start export (build with -target=wasip1)", wasmPath)
+	}
+	fetches := e.newFetchState(caps)
+	start = time.Now()
+	_, err = run.Call(withFetchState(ctx, fetches))
+	res.Timings.Execute = e.span(SpanExecute, start)
+	res.Fetches = fetches.count
+	res.Usage = Usage{
+		ExecTime: res.Timings.Execute,
+		Fetches:  res.Fetches,
+		BytesIn:  in.n,
+		BytesOut: capped.n,
+	}
+	if mem := mod.Memory(); mem != nil {
+		res.Usage.PeakMemoryBytes = uint64(mem.Size())
+	}
+	guestStderr := red.redact(stderr.Bytes())
+	if err := flushErr(); err != nil {
+		return nil, fmt.Errorf("run %s: stderr sink: %w", wasmPath, err)
+	}
+	var inflateErr error
+	if packed != nil {
+		inflateErr = inflateStdout(out, packed.Bytes())
+	}
+	if lines != nil {
+		if err := lines.flush(); err != nil {
+			return nil, fmt.Errorf("run %s: %w", wasmPath, err)
+		}
+	}
+	if capped.exceeded && ctx.Err() == nil {
+		if res.ToolResult, err = e.oversized(wasmPath, caps, capped); err != nil {
+			return nil, err
+		}
+		res.Stderr = traceLog(guestStderr, traceID)
+		return &res, nil
+	}
+	if ctx.Err() == nil && isStackOverflow(err) {
+		res.ToolResult = stackOverflow()
+		res.Stderr = traceLog(guestStderr, traceID)
+		return &res, nil
+	}
+	res.ExitCode, err = exitCode(ctx, err)
+	cutOff := tail != nil && errors.Is(err, context.DeadlineExceeded)
+	if err != nil && !cutOff {
+		return nil, fmt.Errorf("run %s: %w\n%s", wasmPath, err, guestStderr)
+	}
+	if inflateErr != nil {
+		return nil, fmt.Errorf("run %s: %w", wasmPath, inflateErr)
+	}
+	if ask != nil {
+		if err := ask.flush(); err != nil {
+			return nil, fmt.Errorf("run %s: %w", wasmPath, err)
+		}
+	}
+	if cutOff {
+		if err := tail.terminate(stream); err != nil {
+			return nil, fmt.Errorf("run %s: %w", wasmPath, err)
+		}
+	}
+	if partials != nil {
+		if err := partials.close(); err != nil {
+			return nil, fmt.Errorf("%s: %w", wasmPath, err)
+		}
+	}
+	if queue != nil {
+		if err := queue.close(); err != nil {
+			return nil, fmt.Errorf("run %s: %w", wasmPath, err)
+		}
+		res.DroppedProgress = queue.dropped
+	}
+
+	if w != nil {
+		res.Stderr = traceLog(guestStderr, traceID)
+		return &res, nil
+	}
+	if raw, rest, ok := cutRawHeader(guestStderr); ok {
+		if stdout.Len() != raw.Length {
+			return nil, fmt.Errorf("%s: %w: %d bytes, header says %d", wasmPath, ErrRawLength, stdout.Len(), raw.Length)
+		}
+		res.ToolResult = ToolResult{Success: true}
+		res.Raw, res.ContentType = stdout.Bytes(), raw.ContentType
+		res.Stderr = traceLog(rest, traceID)
+		return &res, nil
+	}
+	if err := checkUTF8(wasmPath, stdout.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := decodeResult(stdout.Bytes(), &res.ToolResult); err != nil {
+		return nil, fmt.Errorf("%s: stdout is not a JSON ToolResult: %w", wasmPath, err)
+	}
+	if err := checkOutputType(res.OutputType); err != nil {
+		return nil, fmt.Errorf("%s: %w", wasmPath, err)
+	}
+	if err := renderOutput(wasmPath, caps.outputTemplate, &res.ToolResult); err != nil {
+		return nil, err
+	}
+	if res.Meta != nil && res.Meta.TraceID != "" {
+		traceID = res.Meta.TraceID
+	}
+	res.Stderr = traceLog(guestStderr, traceID)
+	if args != nil {
+		// A skill that prints no schema has no secret args to hide.
+		schemaCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordSchemaTimeout)
+		schema, _ := e.argsSchema(schemaCtx, wasmPath)
+		cancel()
+		e.record(recordName(wasmPath, caps), schema, args.Bytes(), res.ToolResult)
+	}
+	if res.Meta == nil {
+		res.Meta = &ResultMeta{}
+	}
+	res.Meta.SideEffects, res.Meta.Idempotent = caps.sideEffects, caps.idempotent
+	return &res, nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+// stderrTo returns the writer for a guest's stderr: buf, and StderrSink
+// too when one is set, and a func that flushes a last unterminated line to
+// the sink once the guest is done.
+func (e *Executor) stderrTo(buf *bytes.Buffer, red *redactor) (io.Writer, func() error) {
+	if e.cfg.StderrSink == nil {
+		return buf, func() error { return nil }
+	}
+	if red == nil {
+		return io.MultiWriter(buf, e.cfg.StderrSink), func() error { return nil }
+	}
+	sink := &lineWriter{r: red, w: e.cfg.StderrSink}
+	return io.MultiWriter(buf, sink), sink.flush
+}
+
+// decodeResult parses a skill's stdout into res. A streaming skill writes
+// progress and partial lines before its result (see skill.ProgressField and
+// skill.Emitter), so when stdout is not one JSON value the line marked Final
+// is taken as the ToolResult, or else the last line. Compressed artifacts
+// are inflated.
+func decodeResult(stdout []byte, res *ToolResult) error {
+	out := bytes.TrimSpace(stdout)
+	err := json.Unmarshal(out, res)
+	if err == nil {
+		return inflateArtifacts(res)
+	}
+	if bytes.IndexByte(out, '\n') < 0 {
+		return err
+	}
+	lines := bytes.Split(out, []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		var line ToolResult
+		if json.Unmarshal(lines[i], &line) == nil && line.Final {
+			*res = line
+			return inflateArtifacts(res)
+		}
+	}
+	*res = ToolResult{}
+	if err := json.Unmarshal(lines[len(lines)-1], res); err != nil {
+		return err
+	}
+	return inflateArtifacts(res)
+}
+
+// checkOutputType checks that t, when set, parses as type/subtype with
+// optional parameters.
+func checkOutputType(t string) error {
+	if t == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(t)
+	if err != nil {
+		return fmt.Errorf("%w: %q: %v", ErrOutputType, t, err)
+	}
+	if major, minor, ok := strings.Cut(mediaType, "/"); !ok || major == "" || minor == "" {
+		return fmt.Errorf("%w: %q", ErrOutputType, t)
+	}
+	return nil
+}
+
+// span reports the time since start to the tracer, if any, and returns it.
+func (e *Executor) span(name string, start time.Time) time.Duration {
+	d := time.Since(start)
+	if e.cfg.Tracer != nil {
+		e.cfg.Tracer(name, d)
+	}
+	return d
+}
+
+// isStackOverflow reports whether err from running _start is wazero's trap
+// for a guest that called too deep, as unbounded recursion does. wazero
+// keeps that error internal, so it is known by its text, which the
+// interpreter prefixes and follows with a stack trace.
+func isStackOverflow(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg, _, _ := strings.Cut(err.Error(), "\n")
+	return strings.TrimPrefix(msg, "wasm error: ") == "stack overflow"
+}
+
+// stackOverflow is the failed result for a skill that overflowed its stack.
+func stackOverflow() ToolResult {
+	msg := StackOverflowMessage
+	return ToolResult{Error: &msg, ErrorCode: CodeInternal}
+}
+
+// exitCode maps the error from running _start to an exit status.
+//
+// ExitOK, ExitInvalidInput, and ExitPanic come back without an error so the
+// caller reads stdout. Cancellation reports the context's error; anything else wraps ErrTrap.
+func exitCode(ctx context.Context, err error) (int, error) {
+	if err == nil {
+		return ExitOK, nil
+	}
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	var exit *sys.ExitError
+	if errors.As(err, &exit) {
+		switch code := int(exit.ExitCode()); code {
+		case ExitOK, ExitInvalidInput, ExitPanic:
+			return code, nil
+		default:
+			return code, fmt.Errorf("%w: exit status %d", ErrTrap, code)
+		}
+	}
+	return 0, fmt.Errorf("%w: %v", ErrTrap, err)
+}
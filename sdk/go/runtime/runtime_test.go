@@ -0,0 +1,714 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+)
+
+var (
+	buildDir   string
+	buildMu    sync.Mutex
+	builtSkill = map[string]string{}
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "zeroclaw-skills-")
+	if err != nil {
+		panic(err)
+	}
+	buildDir = dir
+	// Every test runs the same few modules, so compile each once.
+	defaultCompilationCache = wazero.NewCompilationCache()
+	code := m.Run()
+	defaultCompilationCache.Close(context.Background())
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// buildSkill compiles testdata/<name> to wasip1 once per test binary.
+func buildSkill(t testing.TB, name string) string {
+	t.Helper()
+	buildMu.Lock()
+	defer buildMu.Unlock()
+	if path, ok := builtSkill[name]; ok {
+		return path
+	}
+	out := filepath.Join(buildDir, name+".wasm")
+	cmd := exec.Command("go", "build", "-o", out, "./testdata/"+name)
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if msg, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("cannot build wasip1 test skill %s: %v\n%s", name, err, msg)
+	}
+	builtSkill[name] = out
+	return out
+}
+
+// buildTemplate compiles templates/go/<name> to wasip1 once per test binary.
+func buildTemplate(t *testing.T, name string) string {
+	t.Helper()
+	buildMu.Lock()
+	defer buildMu.Unlock()
+	key := "template/" + name
+	if path, ok := builtSkill[key]; ok {
+		return path
+	}
+	out := filepath.Join(buildDir, "template_"+name+".wasm")
+	cmd := exec.Command("go", "build", "-o", out, ".")
+	cmd.Dir = filepath.Join("..", "..", "..", "templates", "go", name)
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if msg, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("cannot build wasip1 template %s: %v\n%s", name, err, msg)
+	}
+	builtSkill[key] = out
+	return out
+}
+
+// skillDir lays wasm out as tool.wasm beside a manifest.json holding
+// manifest, the way an installed skill looks, and returns the module path.
+func skillDir(t *testing.T, wasm, manifest string) string {
+	t.Helper()
+	dir := t.TempDir()
+	raw, err := os.ReadFile(wasm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "tool.wasm")
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ManifestFile), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestManifestSchemaVersions(t *testing.T) {
+	echo := buildSkill(t, "echo")
+	for _, manifest := range []string{`{}`, `{"schema_version":1}`, `{"schema_version":2}`} {
+		if _, err := Execute(context.Background(), skillDir(t, echo, manifest), []byte(`{}`)); err != nil {
+			t.Errorf("%s: %v", manifest, err)
+		}
+	}
+	_, err := Execute(context.Background(), skillDir(t, echo, `{"schema_version":3}`), []byte(`{}`))
+	if err == nil || !strings.Contains(err.Error(), "schema_version 3 is newer than this executor understands (up to 2)") {
+		t.Fatalf("got %v, want a future schema_version refused", err)
+	}
+}
+
+func TestExecuteParsesToolResult(t *testing.T) {
+	wasm := buildSkill(t, "echo")
+	res, err := Execute(context.Background(), wasm, []byte(`{"text":"hi"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.Output != `{"text":"hi"}` {
+		t.Fatalf("unexpected result: %+v", res.ToolResult)
+	}
+}
+
+func TestCompilationCacheKeepsCompiledModules(t *testing.T) {
+	wasm := buildSkill(t, "echo")
+	dir := t.TempDir()
+	cache, err := wazero.NewCompilationCacheWithDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close(context.Background())
+	if _, err := New(Config{CompilationCache: cache}).Execute(context.Background(), wasm, []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) == 0 {
+		t.Fatalf("cache dir has %d entries (%v), want the compiled module", len(entries), err)
+	}
+}
+
+func TestExecuteReportsPhaseTimings(t *testing.T) {
+	wasm := buildSkill(t, "echo")
+	spans := map[string]time.Duration{}
+	ex := New(Config{Tracer: func(span string, d time.Duration) { spans[span] = d }})
+	res, err := ex.Execute(context.Background(), wasm, []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]time.Duration{
+		SpanCompile:     res.Timings.Compile,
+		SpanInstantiate: res.Timings.Instantiate,
+		SpanExecute:     res.Timings.Execute,
+	}
+	for span, d := range want {
+		got, ok := spans[span]
+		if !ok {
+			t.Errorf("tracer never saw span %q", span)
+		}
+		if got != d || d <= 0 {
+			t.Errorf("span %q: tracer got %v, Timings has %v", span, got, d)
+		}
+	}
+}
+
+func TestExecuteReportsUsage(t *testing.T) {
+	wasm := buildSkill(t, "echo")
+	args := []byte(`{"text":"hi"}`)
+	res, err := Execute(context.Background(), wasm, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	written := res.ToolResult
+	written.Meta = nil // the executor's, not the skill's
+	out, _ := json.Marshal(written)
+	u := res.Usage
+	if u.BytesIn != len(args) || u.BytesOut != len(out) {
+		t.Errorf("got %d bytes in and %d out, want %d and %d", u.BytesIn, u.BytesOut, len(args), len(out))
+	}
+	if u.PeakMemoryBytes == 0 || u.PeakMemoryBytes%65536 != 0 {
+		t.Errorf("peak memory %d is not a whole number of wasm pages", u.PeakMemoryBytes)
+	}
+	if u.ExecTime != res.Timings.Execute || u.ExecTime <= 0 || u.Fetches != 0 {
+		t.Errorf("usage %+v disagrees with timings %+v", u, res.Timings)
+	}
+}
+
+func TestExecuteReaderStreamsStdinAndStdout(t *testing.T) {
+	wasm := buildSkill(t, "echo")
+	input := strings.Repeat("x", 1<<20)
+	var out bytes.Buffer
+	res, err := ExecuteReader(context.Background(), wasm, strings.NewReader(input), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success {
+		t.Error("ToolResult should be left unparsed when a writer is given")
+	}
+	var got ToolResult
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Output != input {
+		t.Fatalf("echoed %d bytes, want %d", len(got.Output), len(input))
+	}
+}
+
+func TestExecuteReaderParsesWithoutWriter(t *testing.T) {
+	wasm := buildSkill(t, "echo")
+	res, err := ExecuteReader(context.Background(), wasm, strings.NewReader("abc"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.Output != "abc" {
+		t.Fatalf("unexpected result: %+v", res.ToolResult)
+	}
+}
+
+func TestExecuteReaderNDJSONInput(t *testing.T) {
+	wasm := skillDir(t, buildTemplate(t, "word_count"), `{"name":"wc","input":"ndjson"}`)
+	input := "{\"text\":\"a b\"}\n{\"text\":\n\n{\"text\":\"c d e\"}"
+	var out bytes.Buffer
+	if _, err := ExecuteReader(context.Background(), wasm, strings.NewReader(input), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d result lines, want one per non-blank input line:\n%s", len(lines), out.String())
+	}
+	var words []int
+	for i, line := range lines {
+		var res struct {
+			ToolResult
+			Data struct {
+				Words int `json:"words"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(line), &res); err != nil {
+			t.Fatalf("line %d is not a ToolResult: %s", i+1, line)
+		}
+		if i == 1 {
+			if res.Success || res.ErrorCode != "invalid_input" {
+				t.Fatalf("malformed line should get an invalid_input result, got %s", line)
+			}
+			continue
+		}
+		words = append(words, res.Data.Words)
+	}
+	if words[0] != 2 || words[1] != 3 {
+		t.Fatalf("word counts %v, want [2 3]", words)
+	}
+}
+
+func TestWordCountTrimModes(t *testing.T) {
+	wasm := skillDir(t, buildTemplate(t, "word_count"), `{"name":"wc","input":"ndjson"}`)
+	text := `"  a  b \n\n c  \n"`
+	modes := []string{"none", "edges", "collapse", "all"}
+	var input strings.Builder
+	for _, mode := range modes {
+		fmt.Fprintf(&input, "{\"text\":%s,\"trim\":%q}\n", text, mode)
+	}
+	var out bytes.Buffer
+	if _, err := ExecuteReader(context.Background(), wasm, strings.NewReader(input.String()), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	type counts struct{ Words, Lines, Characters int }
+	want := map[string]counts{
+		"none":     {3, 4, 14},
+		"edges":    {3, 3, 9},
+		"collapse": {3, 1, 5},
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != len(modes) {
+		t.Fatalf("got %d result lines, want %d:\n%s", len(lines), len(modes), out.String())
+	}
+	for i, line := range lines {
+		var res struct {
+			ToolResult
+			Data counts `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(line), &res); err != nil {
+			t.Fatalf("trim %q: not a ToolResult: %s", modes[i], line)
+		}
+		w, ok := want[modes[i]]
+		if !ok {
+			if res.Success || res.ErrorCode != "invalid_input" {
+				t.Fatalf("trim %q should be rejected as invalid_input, got %s", modes[i], line)
+			}
+			continue
+		}
+		if res.Data != w {
+			t.Errorf("trim %q: got %+v, want %+v", modes[i], res.Data, w)
+		}
+	}
+}
+
+// TestTinyGoAndGoBuildsRunAlike runs word_count built by each compiler
+// `zeroclaw skill build --compiler` offers and expects the same results.
+func TestTinyGoAndGoBuildsRunAlike(t *testing.T) {
+	if _, err := exec.LookPath("tinygo"); err != nil {
+		t.Skip("tinygo not on PATH")
+	}
+	tinygo := filepath.Join(t.TempDir(), "tool.wasm")
+	cmd := exec.Command("tinygo", "build", "-o", tinygo, "-target=wasip1", ".")
+	cmd.Dir = filepath.Join("..", "..", "..", "templates", "go", "word_count")
+	if msg, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("cannot build word_count with tinygo: %v\n%s", err, msg)
+	}
+	for _, args := range []string{`{"text":"one two\nthree"}`, `{"text":7}`} {
+		var results []ToolResult
+		for _, wasm := range []string{buildTemplate(t, "word_count"), tinygo} {
+			res, err := Execute(context.Background(), wasm, []byte(args))
+			if err != nil {
+				t.Fatalf("%s: %v", wasm, err)
+			}
+			res.Meta = nil // a fresh trace ID each run
+			results = append(results, res.ToolResult)
+		}
+		if !reflect.DeepEqual(results[0], results[1]) {
+			t.Errorf("%s: go build gave %+v, tinygo %+v", args, results[0], results[1])
+		}
+	}
+}
+
+func TestWordCountFields(t *testing.T) {
+	res, err := Execute(context.Background(), buildTemplate(t, "word_count"),
+		[]byte(`{"fields":{"title":"Hello world","body":"one\ntwo three\n"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type counts struct {
+		Name                     string
+		Words, Lines, Characters int
+	}
+	var data struct {
+		counts
+		Fields []counts `json:"fields"`
+	}
+	if err := json.Unmarshal(res.Data, &data); err != nil || !res.Success {
+		t.Fatalf("unexpected result %+v: %v", res.ToolResult, err)
+	}
+	want := []counts{{"body", 3, 3, 14}, {"title", 2, 1, 11}}
+	if !reflect.DeepEqual(data.Fields, want) {
+		t.Fatalf("per-field counts %+v, want %+v sorted by name", data.Fields, want)
+	}
+	if data.counts != (counts{"", 5, 4, 25}) {
+		t.Fatalf("total %+v, want the sum of the fields", data.counts)
+	}
+
+	res, err = Execute(context.Background(), buildTemplate(t, "word_count"), []byte(`{"fields":{"a":"x"},"text":"y"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || res.ErrorCode != "invalid_input" {
+		t.Fatalf("text with fields should fail as invalid_input, got %+v", res.ToolResult)
+	}
+}
+
+func TestWordCountCountModeEnum(t *testing.T) {
+	wasm := buildTemplate(t, "word_count")
+	res, err := Execute(context.Background(), wasm, []byte(`{"__probe":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var probe struct {
+		Tools []struct {
+			Schema struct {
+				Properties map[string]struct {
+					Enum []string `json:"enum"`
+				} `json:"properties"`
+			} `json:"schema"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal(res.Data, &probe); err != nil || len(probe.Tools) != 1 {
+		t.Fatalf("unexpected probe %s: %v", res.Data, err)
+	}
+	if got := probe.Tools[0].Schema.Properties["count_mode"].Enum; !reflect.DeepEqual(got, []string{"bytes", "runes", "graphemes"}) {
+		t.Fatalf("count_mode enum = %v", got)
+	}
+
+	res, err = Execute(context.Background(), wasm, []byte(`{"text":"héllo","count_mode":"bytes"}`))
+	if err != nil || !strings.Contains(string(res.Data), `"characters":6`) {
+		t.Fatalf("bytes mode should count UTF-8 bytes, got %s: %v", res.Data, err)
+	}
+	res, err = Execute(context.Background(), wasm, []byte(`{"text":"x","count_mode":"words"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || len(res.FieldErrors) != 1 || res.FieldErrors[0].Path != "/count_mode" {
+		t.Fatalf("a count_mode outside the enum should fail at /count_mode, got %+v", res.ToolResult)
+	}
+}
+
+func TestWordCountLengthHistogram(t *testing.T) {
+	wasm := buildTemplate(t, "word_count")
+	for _, args := range []string{
+		`{"text":"the quick brown fox jumps over a lazy dog","length_histogram":true}`,
+		`{"text":"a bb a internationalization ccc bb a","count_mode":"bytes","length_histogram":true}`,
+		`{"fields":{"title":"Hello world","body":"one\ntwo three\n","bad":7},"length_histogram":true}`,
+	} {
+		res, err := Execute(context.Background(), wasm, []byte(args))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data struct {
+			Words     int
+			Histogram []struct{ Length, Count int } `json:"length_histogram"`
+		}
+		if err := json.Unmarshal(res.Data, &data); err != nil || !res.Success {
+			t.Fatalf("%s: unexpected result %+v: %v", args, res.ToolResult, err)
+		}
+		sum := 0
+		for i, b := range data.Histogram {
+			if i > 0 && b.Length <= data.Histogram[i-1].Length {
+				t.Fatalf("%s: buckets not ascending by length: %+v", args, data.Histogram)
+			}
+			sum += b.Count
+		}
+		if sum != data.Words || data.Words == 0 {
+			t.Fatalf("%s: bucket counts sum to %d, want %d words", args, sum, data.Words)
+		}
+	}
+
+	res, err := Execute(context.Background(), wasm, []byte(`{"text":"a internationalization a"}`))
+	if err != nil || strings.Contains(string(res.Data), "length_histogram") {
+		t.Fatalf("the histogram should be opt-in, got %s: %v", res.Data, err)
+	}
+	res, err = Execute(context.Background(), wasm, []byte(`{"text":"a internationalization a","length_histogram":true}`))
+	if err != nil || !strings.Contains(string(res.Data), `"length_histogram":[{"length":1,"count":2},{"length":20,"count":1}]`) {
+		t.Fatalf("long words should keep their exact length, got %s: %v", res.Data, err)
+	}
+}
+
+func TestWordCountFoldCase(t *testing.T) {
+	wasm := buildTemplate(t, "word_count")
+	text := `Word word WORD caf\u00e9 cafe\u0301 cafe \ufb01ne fine`
+	for _, tc := range []struct {
+		options, top string
+		unique       int
+	}{
+		{``, `[{"word":"word","count":3},{"word":"cafe","count":1}]`, 6},
+		{`"fold_case":false`, `[{"word":"WORD","count":1},{"word":"Word","count":1}]`, 8},
+		{`"normalize_unicode":"nfc"`, "[{\"word\":\"word\",\"count\":3},{\"word\":\"caf\u00e9\",\"count\":2}]", 5},
+		{`"normalize_unicode":"nfkc"`, "[{\"word\":\"word\",\"count\":3},{\"word\":\"caf\u00e9\",\"count\":2}]", 4}, // and fine twice
+	} {
+		args := `{"text":"` + text + `","top_words":2`
+		if tc.options != "" {
+			args += "," + tc.options
+		}
+		args += "}"
+		res, err := Execute(context.Background(), wasm, []byte(args))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data struct {
+			Words       int
+			UniqueWords int             `json:"unique_words"`
+			TopWords    json.RawMessage `json:"top_words"`
+		}
+		if err := json.Unmarshal(res.Data, &data); err != nil || !res.Success {
+			t.Fatalf("%s: unexpected result %+v: %v", args, res.ToolResult, err)
+		}
+		if string(data.TopWords) != tc.top || data.UniqueWords != tc.unique {
+			t.Fatalf("%s: got %d unique words, top %s; want %d, %s", args, data.UniqueWords, data.TopWords, tc.unique, tc.top)
+		}
+		if data.Words != 8 {
+			t.Fatalf("%s: canonical forms changed the raw total: %d words, want 8", args, data.Words)
+		}
+	}
+}
+
+func TestWordCountWarnsOfAStrippedBOM(t *testing.T) {
+	wasm := buildTemplate(t, "word_count")
+	res, err := Execute(context.Background(), wasm, []byte(`{"text":"\ufeffhi there"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || len(res.Warnings) != 1 || res.Warnings[0] != "stripped a leading byte order mark (U+FEFF)" {
+		t.Fatalf("expected the BOM warning, got %+v", res.ToolResult)
+	}
+	if res, err = Execute(context.Background(), wasm, []byte(`{"text":"hi there"}`)); err != nil || res.Warnings != nil {
+		t.Fatalf("expected no warnings, got %+v: %v", res.ToolResult, err)
+	}
+}
+
+func TestWordCountReportsFieldErrorPath(t *testing.T) {
+	res, err := Execute(context.Background(), buildTemplate(t, "word_count"), []byte(`{"fields":{"title":"t","body":7},"fail_fast":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || len(res.FieldErrors) != 1 || res.FieldErrors[0].Path != "/fields/body" {
+		t.Fatalf("expected a field error at /fields/body, got %+v", res.ToolResult)
+	}
+}
+
+func TestWordCountFieldsPartialFailure(t *testing.T) {
+	wasm := buildTemplate(t, "word_count")
+	batch := `{"fields":{"title":"Hi there","body":7,"notes":null}`
+
+	res, err := Execute(context.Background(), wasm, []byte(batch+`}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data struct {
+		Words   int
+		Warning string
+		Fields  []struct {
+			Name      string
+			Words     int
+			Error     string
+			ErrorCode string `json:"error_code"`
+		}
+	}
+	if err := json.Unmarshal(res.Data, &data); err != nil || !res.Success {
+		t.Fatalf("one good field should keep the call successful, got %+v: %v", res.ToolResult, err)
+	}
+	if data.Words != 2 || data.Warning != "2 of 3 fields failed" || len(data.Fields) != 3 {
+		t.Fatalf("unexpected totals %+v", data)
+	}
+	if f := data.Fields[0]; f.Name != "body" || f.Error != "must be a string, got number" || f.ErrorCode != "invalid_input" {
+		t.Fatalf("bad field reported as %+v", f)
+	}
+	if f := data.Fields[2]; f.Name != "title" || f.Words != 2 || f.Error != "" {
+		t.Fatalf("good field reported as %+v", f)
+	}
+
+	res, err = Execute(context.Background(), wasm, []byte(batch+`,"fail_fast":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || res.ErrorCode != "invalid_input" || len(res.FieldErrors) != 2 {
+		t.Fatalf("fail_fast should fail the call with both field errors, got %+v", res.ToolResult)
+	}
+}
+
+func TestManifestRejectsUnknownInput(t *testing.T) {
+	wasm := skillDir(t, buildSkill(t, "echo"), `{"name":"echo","input":"csv"}`)
+	_, err := Execute(context.Background(), wasm, nil)
+	if err == nil || !strings.Contains(err.Error(), `unknown input "csv"`) {
+		t.Fatalf("expected an unknown input error, got %v", err)
+	}
+}
+
+func TestExecuteMissingModule(t *testing.T) {
+	if _, err := Execute(context.Background(), filepath.Join(t.TempDir(), "missing.wasm"), nil); err == nil {
+		t.Fatal("expected an error for a missing module")
+	}
+}
+
+func TestExecuteMapsExitCodes(t *testing.T) {
+	wasm := buildSkill(t, "exitcode")
+
+	res, err := Execute(context.Background(), wasm, []byte(`{"code":2}`))
+	if err != nil {
+		t.Fatalf("exit 2 should still parse stdout: %v", err)
+	}
+	if res.ExitCode != ExitInvalidInput || res.Success || res.ErrorCode != "invalid_input" {
+		t.Fatalf("unexpected result: exit %d, %+v", res.ExitCode, res.ToolResult)
+	}
+
+	res, err = Execute(context.Background(), wasm, []byte(`{"code":3}`))
+	if err != nil || res.ExitCode != ExitPanic || res.Success {
+		t.Fatalf("exit 3 should parse stdout as a recovered panic: exit %v, %v", res, err)
+	}
+
+	for _, code := range []int{1, 4} {
+		_, err := Execute(context.Background(), wasm, []byte(fmt.Sprintf(`{"code":%d}`, code)))
+		if !errors.Is(err, ErrTrap) {
+			t.Errorf("exit %d: got %v, want ErrTrap", code, err)
+		}
+	}
+}
+
+func TestStackOverflowFailsAsInternal(t *testing.T) {
+	wasm := buildSkill(t, "recurse")
+	check := func(how string, res ToolResult, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("%s: a stack overflow should be a result, not %v", how, err)
+		}
+		if res.Success || res.ErrorCode != CodeInternal || res.Error == nil || *res.Error != StackOverflowMessage {
+			t.Fatalf("%s: got %+v, want an internal %q failure", how, res, StackOverflowMessage)
+		}
+	}
+
+	res, err := Execute(context.Background(), wasm, []byte(`{}`))
+	if err != nil {
+		t.Fatalf("execute: a stack overflow should be a result, not %v", err)
+	}
+	check("execute", res.ToolResult, nil)
+
+	m, err := New(Config{}).Compile(context.Background(), wasm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(context.Background())
+	in, err := m.NewInstance(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := in.Call(context.Background(), []byte(`{}`))
+	check("instance", got, err)
+}
+
+func TestExecutePassesOutputTypeThrough(t *testing.T) {
+	wasm := buildSkill(t, "report")
+
+	res, err := Execute(context.Background(), wasm, []byte(`{"text":"# Report","type":"text/markdown"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Output != "# Report" || res.OutputType != "text/markdown" {
+		t.Fatalf("unexpected result: %+v", res.ToolResult)
+	}
+
+	res, err = Execute(context.Background(), wasm, []byte(`{"text":"2 words"}`))
+	if err != nil || res.OutputType != "" {
+		t.Fatalf("an unset output_type should stay unset: %v, %v", res, err)
+	}
+
+	for _, bad := range []string{"markdown", "text/", "text html"} {
+		_, err := Execute(context.Background(), wasm, []byte(`{"text":"x","type":"`+bad+`"}`))
+		if !errors.Is(err, ErrOutputType) {
+			t.Errorf("output_type %q: got %v, want ErrOutputType", bad, err)
+		}
+	}
+}
+
+func TestDecodeResultReadsIndentedResult(t *testing.T) {
+	// What a skill built on skill.Run writes under skill.PrettyEnv.
+	pretty := "{\n  \"success\": true,\n  \"output\": \"2 words\",\n  \"data\": {\n    \"words\": 2\n  }\n}"
+	var got, want ToolResult
+	if err := decodeResult([]byte(pretty), &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := decodeResult([]byte(`{"success":true,"output":"2 words","data":{"words":2}}`), &want); err != nil {
+		t.Fatal(err)
+	}
+	// Data is kept as the skill wrote it, so only its whitespace differs.
+	var data bytes.Buffer
+	if err := json.Compact(&data, got.Data); err != nil {
+		t.Fatal(err)
+	}
+	got.Data = data.Bytes()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("indented result decoded to %+v, compact to %+v", got, want)
+	}
+}
+
+func TestBeforeDenialSkipsTheSkillAndAfterStillRuns(t *testing.T) {
+	wasm := buildSkill(t, "echo")
+	denied := errors.New("rbac: caller may not run echo")
+	var spans []string
+	type audit struct {
+		name string
+		res  ToolResult
+		err  error
+	}
+	var audits []audit
+	ex := New(Config{
+		Tracer: func(span string, _ time.Duration) { spans = append(spans, span) },
+		Before: func(_ context.Context, name string, args []byte) error {
+			if string(args) == `"deny"` {
+				return denied
+			}
+			return nil
+		},
+		After: func(_ context.Context, name string, res ToolResult, err error) {
+			audits = append(audits, audit{name, res, err})
+		},
+	})
+
+	if _, err := ex.Execute(context.Background(), wasm, []byte(`"deny"`)); !errors.Is(err, denied) {
+		t.Fatalf("denied call: got %v, want the Before error", err)
+	}
+	if len(spans) != 0 {
+		t.Fatalf("a denied call instantiated the skill: spans %v", spans)
+	}
+	res, err := ex.Execute(context.Background(), wasm, []byte(`"allow"`))
+	if err != nil || res.Output != `"allow"` {
+		t.Fatalf("allowed call: got %+v, %v", res, err)
+	}
+	if len(spans) == 0 {
+		t.Fatal("the allowed call was not traced")
+	}
+
+	if len(audits) != 2 {
+		t.Fatalf("After ran %d times, want 2", len(audits))
+	}
+	if a := audits[0]; a.name != "echo" || !errors.Is(a.err, denied) || a.res.Success {
+		t.Fatalf("audit of the denied call: %+v", a)
+	}
+	if a := audits[1]; a.name != "echo" || a.err != nil || a.res.Output != `"allow"` {
+		t.Fatalf("audit of the allowed call: %+v", a)
+	}
+}
+
+func TestExecuteRunsTheModuleBeforeSaw(t *testing.T) {
+	path := skillDir(t, buildSkill(t, "echo"), `{}`)
+	other, err := os.ReadFile(buildTemplate(t, "word_count"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex := New(Config{
+		Before: func(context.Context, string, []byte) error {
+			// A deploy that lands while Before runs is not picked up.
+			return os.WriteFile(path, other, 0o644)
+		},
+		Retries: 1,
+	})
+	res, err := ex.Execute(context.Background(), path, []byte(`"hi"`))
+	if err != nil || res.Output != `"hi"` {
+		t.Fatalf("got %+v, %v; want the echo module Before was called for", res, err)
+	}
+}
@@ -0,0 +1,50 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// ErrCapabilityDenied is returned for a skill that Config.Sandboxed cannot
+// run because it imports a host function other than WASI's, or its
+// manifest asks for something only the host can grant.
+var ErrCapabilityDenied = errors.New("capability denied by the sandbox")
+
+// checkSandboxImports fails with ErrCapabilityDenied, naming the first
+// import, when compiled imports anything but WASI.
+func checkSandboxImports(path string, compiled wazero.CompiledModule) error {
+	for _, def := range compiled.ImportedFunctions() {
+		if module, name, _ := def.Import(); module != wasi_snapshot_preview1.ModuleName {
+			return fmt.Errorf("%s: %w: imports %s.%s", path, ErrCapabilityDenied, module, name)
+		}
+	}
+	if mems := compiled.ImportedMemories(); len(mems) > 0 {
+		module, name, _ := mems[0].Import()
+		return fmt.Errorf("%s: %w: imports memory %s.%s", path, ErrCapabilityDenied, module, name)
+	}
+	return nil
+}
+
+// checkSandboxCaps fails with ErrCapabilityDenied when the manifest asks
+// for network access or secrets, or for an input or compression mode the
+// guest would have to be told of in its environment, which a sandboxed
+// guest does not get.
+func checkSandboxCaps(path string, caps capabilities) error {
+	var denied string
+	switch {
+	case caps.Net:
+		denied = `"net"`
+	case len(caps.Secrets) > 0:
+		denied = fmt.Sprintf("secret %s", caps.Secrets[0])
+	case caps.lines:
+		denied = fmt.Sprintf(`"input": %q`, InputNDJSON)
+	case caps.gzip:
+		denied = fmt.Sprintf(`"compression": %q`, CompressionGzip)
+	default:
+		return nil
+	}
+	return fmt.Errorf("%s: %w: manifest asks for %s", path, ErrCapabilityDenied, denied)
+}
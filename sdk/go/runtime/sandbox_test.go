@@ -0,0 +1,41 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSandboxedRunsPureComputation(t *testing.T) {
+	exec := New(Config{Sandboxed: true, ScratchRoot: t.TempDir()})
+	res, err := exec.Execute(context.Background(), buildSkill(t, "echo"), []byte(`{"text":"hi"}`))
+	if err != nil || res.Output != `{"text":"hi"}` {
+		t.Fatalf("a pure skill should run sandboxed, got %+v: %v", res, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	res, err = exec.Execute(ctx, buildSkill(t, "budget"), []byte(`{"n":1}`))
+	if err != nil || res.Output != "no deadline" {
+		t.Fatalf("a sandboxed skill should see no environment, got %+v: %v", res, err)
+	}
+}
+
+func TestSandboxedDeniesHostImports(t *testing.T) {
+	exec := New(Config{Sandboxed: true})
+	wasm := buildSkill(t, "fetch")
+	_, err := exec.Execute(context.Background(), wasm, []byte(`{"url":"http://example.com","times":1}`))
+	if !errors.Is(err, ErrCapabilityDenied) || !strings.Contains(err.Error(), "imports env.zeroclaw_http_fetch") {
+		t.Fatalf("want ErrCapabilityDenied naming the import, got %v", err)
+	}
+	if _, err := exec.Compile(context.Background(), wasm); !errors.Is(err, ErrCapabilityDenied) {
+		t.Fatalf("Compile should deny it too, got %v", err)
+	}
+
+	netSkill := skillDir(t, buildSkill(t, "echo"), `{"name":"echo","capabilities":{"net":true}}`)
+	if _, err := exec.Execute(context.Background(), netSkill, []byte(`{}`)); !errors.Is(err, ErrCapabilityDenied) || !strings.Contains(err.Error(), `asks for "net"`) {
+		t.Fatalf("want ErrCapabilityDenied for a manifest asking for net, got %v", err)
+	}
+}
@@ -0,0 +1,406 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// schemaFlag makes an SDK-built skill print its args schema instead of
+// handling a request (see skill.SchemaFlag).
+const schemaFlag = "--schema"
+
+// ErrInvalidArgs is wrapped by the *ArgsError that Module.ValidateArgs, and
+// Execute under Config.PreValidate, return for args the skill's schema
+// rejects.
+var ErrInvalidArgs = errors.New("args do not match the skill's schema")
+
+// ArgsError lists what is wrong with args that failed ValidateArgs. The
+// skill was not run with them.
+type ArgsError struct {
+	Path        string
+	FieldErrors []FieldError
+}
+
+func (e *ArgsError) Error() string {
+	msgs := make([]string, len(e.FieldErrors))
+	for i, fe := range e.FieldErrors {
+		msgs[i] = fe.Message
+		if fe.Path != "" {
+			msgs[i] = fe.Path + ": " + fe.Message
+		}
+	}
+	return fmt.Sprintf("%s: %v: %s", e.Path, ErrInvalidArgs, strings.Join(msgs, "; "))
+}
+
+func (e *ArgsError) Unwrap() error { return ErrInvalidArgs }
+
+// ValidateArgs checks argsJSON against the JSON Schema the skill prints for
+// --schema, so args that cannot be right fail before an instance is spent on
+// them. The first call runs the skill once with --schema and caches what it
+// prints until WatchFile swaps in new code. A probe envelope always passes.
+//
+// The checks are the subset of JSON Schema skill.SchemaFor writes: type,
+// properties, required, items, additionalProperties, enum, minimum, and
+// maximum. A skill that prints no schema fails every call.
+func (m *Module) ValidateArgs(argsJSON []byte) error {
+	code := m.code.Load()
+	schema, err := code.schema.get(func() (*argsSchema, error) {
+		return readSchema(context.Background(), m.rt, code.code, m.path)
+	})
+	if err != nil {
+		return err
+	}
+	return schema.validate(m.path, argsJSON)
+}
+
+// validateArgs is ValidateArgs for Execute, which keeps no Module.
+func (e *Executor) validateArgs(ctx context.Context, wasmPath string, argsJSON []byte) error {
+	schema, err := e.argsSchema(ctx, wasmPath)
+	if err != nil {
+		return err
+	}
+	return schema.validate(wasmPath, argsJSON)
+}
+
+// argsSchema reads the schema of the skill at wasmPath, cached on e while
+// the file is unchanged.
+func (e *Executor) argsSchema(ctx context.Context, wasmPath string) (*argsSchema, error) {
+	info, err := os.Stat(wasmPath)
+	if err != nil {
+		return nil, fmt.Errorf("read skill module: %w", err)
+	}
+	e.schemaMu.Lock()
+	cached, ok := e.schemas[wasmPath]
+	if !ok || !cached.stamp.sameFile(info) {
+		cached = &pathSchema{stamp: fileStamp{size: info.Size(), modTime: info.ModTime()}}
+		if e.schemas == nil {
+			e.schemas = map[string]*pathSchema{}
+		}
+		e.schemas[wasmPath] = cached
+	}
+	e.schemaMu.Unlock()
+
+	return cached.schema.get(func() (*argsSchema, error) {
+		wasm, caps, err := e.loadModule(wasmPath)
+		if err != nil {
+			return nil, err
+		}
+		if e.cfg.Sandboxed {
+			if err := checkSandboxCaps(wasmPath, caps); err != nil {
+				return nil, err
+			}
+		}
+		rt := wazero.NewRuntimeWithConfig(ctx, e.runtimeConfig())
+		defer rt.Close(ctx)
+		wasi_snapshot_preview1.MustInstantiate(ctx, rt)
+		if err := e.instantiateHost(ctx, rt); err != nil {
+			return nil, err
+		}
+		code, err := rt.CompileModule(ctx, wasm)
+		if err != nil {
+			return nil, fmt.Errorf("compile %s: %w", wasmPath, err)
+		}
+		if e.cfg.Sandboxed {
+			if err := checkSandboxImports(wasmPath, code); err != nil {
+				return nil, err
+			}
+		}
+		return readSchema(ctx, rt, code, wasmPath)
+	})
+}
+
+// pathSchema is the schema Execute read for one version of a module file.
+type pathSchema struct {
+	stamp  fileStamp
+	schema lazySchema
+}
+
+// lazySchema reads a skill's schema the first time it is needed. A failed
+// read is not kept, so a call whose ctx ran out does not fail later ones.
+type lazySchema struct {
+	mu     sync.Mutex
+	schema *argsSchema
+}
+
+func (l *lazySchema) get(read func() (*argsSchema, error)) (*argsSchema, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.schema == nil {
+		schema, err := read()
+		if err != nil {
+			return nil, err
+		}
+		l.schema = schema
+	}
+	return l.schema, nil
+}
+
+// readSchema runs code once with --schema and parses what it prints.
+func readSchema(ctx context.Context, rt wazero.Runtime, code wazero.CompiledModule, path string) (*argsSchema, error) {
+	var stdout, stderr bytes.Buffer
+	cfg := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(filepath.Base(path), schemaFlag).
+		WithStdout(&stdout).
+		WithStderr(&stderr)
+	mod, err := rt.InstantiateModule(ctx, code, cfg)
+	if mod != nil {
+		mod.Close(ctx)
+	}
+	if _, err := exitCode(ctx, err); err != nil {
+		return nil, fmt.Errorf("run %s %s: %w\n%s", path, schemaFlag, err, stderr.Bytes())
+	}
+	return parseArgsSchema(path, stdout.Bytes())
+}
+
+// argsSchema is what a skill prints for --schema: the schema of its args
+// from skill.Run, or from a skill.Router the schema of each tool's args, by
+// name, taken from a {"tool": name, "args": {...}} envelope.
+type argsSchema struct {
+	args  *schemaNode
+	tools map[string]*schemaNode
+}
+
+func parseArgsSchema(path string, raw []byte) (*argsSchema, error) {
+	bad := fmt.Errorf("%s: did not print a JSON schema for %s (is it built with the skill SDK?)", path, schemaFlag)
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &top); err != nil {
+		return nil, bad
+	}
+	if _, ok := top["type"]; ok {
+		var args schemaNode
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, schemaFlag, err)
+		}
+		return &argsSchema{args: &args}, nil
+	}
+	tools := map[string]*schemaNode{}
+	for name, entry := range top {
+		var tool struct {
+			Parameters *schemaNode `json:"parameters"`
+		}
+		if err := json.Unmarshal(entry, &tool); err != nil || tool.Parameters == nil {
+			return nil, bad
+		}
+		tools[name] = tool.Parameters
+	}
+	if len(tools) == 0 {
+		return nil, bad
+	}
+	return &argsSchema{tools: tools}, nil
+}
+
+func (s *argsSchema) validate(path string, argsJSON []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(argsJSON))
+	dec.UseNumber()
+	var args any
+	if err := dec.Decode(&args); err != nil {
+		return &ArgsError{Path: path, FieldErrors: []FieldError{{Message: "invalid input JSON: " + err.Error()}}}
+	}
+	if obj, ok := args.(map[string]any); ok && obj[probeField] == true {
+		return nil
+	}
+
+	var errs []FieldError
+	if s.args != nil {
+		errs = s.args.check("", args, errs)
+	} else {
+		errs = s.checkEnvelope(args)
+	}
+	if len(errs) > 0 {
+		return &ArgsError{Path: path, FieldErrors: errs}
+	}
+	return nil
+}
+
+// probeField is the key of the probe envelope (see skill.ProbeField).
+const probeField = "__probe"
+
+func (s *argsSchema) checkEnvelope(v any) []FieldError {
+	env, ok := v.(map[string]any)
+	if !ok {
+		return []FieldError{{Message: "expected object, got " + typeOf(v)}}
+	}
+	names := make([]string, 0, len(s.tools))
+	for name := range s.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	name, _ := env["tool"].(string)
+	tool, ok := s.tools[name]
+	if !ok {
+		return []FieldError{{Path: "/tool", Message: fmt.Sprintf("must be one of %s, got %s", strings.Join(names, ", "), jsonText(env["tool"]))}}
+	}
+	args, ok := env["args"]
+	if !ok {
+		args = map[string]any{}
+	}
+	return tool.check("/args", args, nil)
+}
+
+// schemaNode is one JSON Schema. The schema false, which no value matches,
+// has never set; true is the empty schema.
+type schemaNode struct {
+	Type                 string                 `json:"type"`
+	Properties           map[string]*schemaNode `json:"properties"`
+	Required             []string               `json:"required"`
+	Items                *schemaNode            `json:"items"`
+	AdditionalProperties *schemaNode            `json:"additionalProperties"`
+	Enum                 []json.RawMessage      `json:"enum"`
+	Minimum              *json.Number           `json:"minimum"`
+	Maximum              *json.Number           `json:"maximum"`
+	Secret               bool                   `json:"x-zeroclaw-secret"`
+	never                bool
+}
+
+func (n *schemaNode) UnmarshalJSON(raw []byte) error {
+	switch string(bytes.TrimSpace(raw)) {
+	case "true":
+		*n = schemaNode{}
+		return nil
+	case "false":
+		*n = schemaNode{never: true}
+		return nil
+	}
+	type plain schemaNode // schemaNode without this method
+	return json.Unmarshal(raw, (*plain)(n))
+}
+
+// check appends to errs what is wrong with v, found at the JSON Pointer at.
+func (n *schemaNode) check(at string, v any, errs []FieldError) []FieldError {
+	if n.never {
+		return append(errs, FieldError{Path: at, Message: "is not allowed"})
+	}
+	if n.Type != "" && !hasType(v, n.Type) {
+		return append(errs, FieldError{Path: at, Message: "expected " + n.Type + ", got " + typeOf(v)})
+	}
+	if len(n.Enum) > 0 && !slices.ContainsFunc(n.Enum, func(e json.RawMessage) bool { return jsonEqual(e, v) }) {
+		allowed := make([]string, len(n.Enum))
+		for i, e := range n.Enum {
+			allowed[i] = string(e)
+		}
+		errs = append(errs, FieldError{Path: at, Message: fmt.Sprintf("must be one of %s, got %s", strings.Join(allowed, ", "), jsonText(v))})
+	}
+	if num, ok := v.(json.Number); ok {
+		errs = n.checkBounds(at, num, errs)
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for _, key := range n.Required {
+			if _, ok := v[key]; !ok {
+				errs = append(errs, FieldError{Path: at + "/" + escapePointer(key), Message: "is required"})
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			prop, ok := n.Properties[key]
+			if !ok {
+				prop = n.AdditionalProperties
+			}
+			if prop != nil {
+				errs = prop.check(at+"/"+escapePointer(key), v[key], errs)
+			}
+		}
+	case []any:
+		if n.Items != nil {
+			for i, item := range v {
+				errs = n.Items.check(at+"/"+strconv.Itoa(i), item, errs)
+			}
+		}
+	}
+	return errs
+}
+
+func (n *schemaNode) checkBounds(at string, num json.Number, errs []FieldError) []FieldError {
+	f, err := num.Float64()
+	if err != nil {
+		return errs
+	}
+	if n.Minimum != nil {
+		if lo, err := n.Minimum.Float64(); err == nil && f < lo {
+			errs = append(errs, FieldError{Path: at, Message: fmt.Sprintf("must be at least %s, got %s", n.Minimum, num)})
+		}
+	}
+	if n.Maximum != nil {
+		if hi, err := n.Maximum.Float64(); err == nil && f > hi {
+			errs = append(errs, FieldError{Path: at, Message: fmt.Sprintf("must be at most %s, got %s", n.Maximum, num)})
+		}
+	}
+	return errs
+}
+
+// hasType reports whether v, decoded with UseNumber, is of the JSON Schema
+// type typ. An integer is a number written without a fraction or exponent,
+// as encoding/json needs to decode one into an int.
+func hasType(v any, typ string) bool {
+	switch typ {
+	case "integer":
+		num, ok := v.(json.Number)
+		return ok && !strings.ContainsAny(string(num), ".eE")
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	}
+	return typeOf(v) == typ
+}
+
+// typeOf names the JSON Schema type of v; numbers written without a fraction
+// or exponent are integers.
+func typeOf(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if strings.ContainsAny(string(v), ".eE") {
+			return "number"
+		}
+		return "integer"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// jsonEqual reports whether the JSON literal raw holds the value v.
+func jsonEqual(raw json.RawMessage, v any) bool {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var want any
+	if dec.Decode(&want) != nil {
+		return false
+	}
+	return jsonText(want) == jsonText(v)
+}
+
+func jsonText(v any) string {
+	text, _ := json.Marshal(v)
+	return string(text)
+}
+
+// escapePointer escapes key as a JSON Pointer reference token (RFC 6901).
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
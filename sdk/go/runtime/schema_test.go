@@ -0,0 +1,151 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateArgsChecksSchema(t *testing.T) {
+	ctx := context.Background()
+	m, err := Compile(ctx, buildSkill(t, "schema"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(ctx)
+
+	for _, tc := range []struct {
+		args string
+		want string // "" for valid args, else the FieldErrors as path: message
+	}{
+		{`{"text":"hi"}`, ""},
+		{`{"text":"hi","count":3,"mode":"fast","tags":["a"],"options":{"wpm":200},"labels":{"k":"v"},"_seed":1}`, ""},
+		{`{"__probe":true}`, ""},
+		{`{}`, "/text: is required"},
+		{`[1]`, ": expected object, got array"},
+		{`{"text":5}`, "/text: expected string, got integer"},
+		{`{"text":"hi","count":1.5}`, "/count: expected integer, got number"},
+		{`{"text":"hi","count":11}`, "/count: must be at most 10, got 11"},
+		{`{"text":"hi","count":-1}`, "/count: must be at least 0, got -1"},
+		{`{"text":"hi","mode":"medium"}`, `/mode: must be one of "fast", "slow", got "medium"`},
+		{`{"text":"hi","tags":["a",2]}`, "/tags/1: expected string, got integer"},
+		{`{"text":"hi","options":{"wpm":"fast"}}`, "/options/wpm: expected integer, got string"},
+		{`{"text":"hi","labels":{"a/b":true}}`, "/labels/a~1b: expected string, got boolean"},
+		{`{"count":"x","mode":1}`, "/text: is required; /count: expected integer, got string; /mode: expected string, got integer"},
+		{`{"text":`, ": invalid input JSON: unexpected EOF"},
+	} {
+		err := m.ValidateArgs([]byte(tc.args))
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tc.args, err)
+			}
+			continue
+		}
+		var argsErr *ArgsError
+		if !errors.As(err, &argsErr) || !errors.Is(err, ErrInvalidArgs) {
+			t.Errorf("%s: got %v, want an *ArgsError", tc.args, err)
+			continue
+		}
+		got := make([]string, len(argsErr.FieldErrors))
+		for i, fe := range argsErr.FieldErrors {
+			got[i] = fe.Path + ": " + fe.Message
+		}
+		if strings.Join(got, "; ") != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.args, strings.Join(got, "; "), tc.want)
+		}
+	}
+}
+
+func TestValidateArgsReadsSDKSchema(t *testing.T) {
+	ctx := context.Background()
+	m, err := Compile(ctx, buildTemplate(t, "word_count"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(ctx)
+	if err := m.ValidateArgs([]byte(`{"text":"hello world","top_words":3}`)); err != nil {
+		t.Fatal(err)
+	}
+	err = m.ValidateArgs([]byte(`{"text":"hi","count_mode":"letters","top_words":-1}`))
+	if !errors.Is(err, ErrInvalidArgs) {
+		t.Fatalf("got %v, want ErrInvalidArgs", err)
+	}
+	for _, want := range []string{`/count_mode: must be one of "bytes", "runes", "graphemes", got "letters"`, "/top_words: must be at least 0, got -1"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestValidateArgsNeedsASchema(t *testing.T) {
+	ctx := context.Background()
+	// echo answers --schema with a result, not a schema.
+	m, err := Compile(ctx, buildSkill(t, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(ctx)
+	err = m.ValidateArgs([]byte(`{}`))
+	if err == nil || errors.Is(err, ErrInvalidArgs) || !strings.Contains(err.Error(), "did not print a JSON schema") {
+		t.Fatalf("got %v, want a missing-schema error", err)
+	}
+}
+
+func TestPreValidateKeepsBadArgsFromGuest(t *testing.T) {
+	wasm := buildSkill(t, "schema")
+	var runs int
+	e := New(Config{PreValidate: true, Tracer: func(span string, _ time.Duration) {
+		if span == SpanExecute {
+			runs++
+		}
+	}})
+	res, err := e.Execute(context.Background(), wasm, []byte(`{"text":"hi"}`))
+	if err != nil || !res.Success {
+		t.Fatalf("valid args: %+v, %v", res, err)
+	}
+	runs = 0
+	for i := 0; i < 2; i++ {
+		_, err = e.Execute(context.Background(), wasm, []byte(`{"text":5}`))
+		if !errors.Is(err, ErrInvalidArgs) || !strings.Contains(err.Error(), "/text: expected string, got integer") {
+			t.Fatalf("invalid args: got %v, want ErrInvalidArgs", err)
+		}
+	}
+	if runs != 0 {
+		t.Fatalf("guest ran %d times for invalid args", runs)
+	}
+
+	// Without PreValidate the guest gets whatever it is sent.
+	res, err = New(Config{}).Execute(context.Background(), wasm, []byte(`{"text":5}`))
+	if err != nil || res.Output != `{"text":5}` {
+		t.Fatalf("got %+v, %v", res, err)
+	}
+}
+
+func TestRouterSchemaChecksEnvelope(t *testing.T) {
+	s, err := parseArgsSchema("router.wasm", []byte(`{
+		"count":{"description":"Count words","parameters":{"type":"object","properties":{"text":{"type":"string"}},"required":["text"]}},
+		"stats":{"description":"Text stats","parameters":{"type":"object","properties":{},"required":[]}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for args, want := range map[string]string{
+		`{"tool":"count","args":{"text":"a b"}}`: "",
+		`{"tool":"stats"}`:                       "",
+		`{"__probe":true}`:                       "",
+		`{"tool":"count","args":{}}`:             "router.wasm: args do not match the skill's schema: /args/text: is required",
+		`{"tool":"count","args":{"text":1}}`:     "router.wasm: args do not match the skill's schema: /args/text: expected string, got integer",
+		`{"tool":"sum","args":{}}`:               `router.wasm: args do not match the skill's schema: /tool: must be one of count, stats, got "sum"`,
+	} {
+		err := s.validate("router.wasm", []byte(args))
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != want {
+			t.Errorf("%s: got %q, want %q", args, got, want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package runtime
+
+import (
+	"os"
+
+	"github.com/tetratelabs/wazero"
+)
+
+// ScratchEnv names the guest directory a skill may keep scratch files in;
+// it matches skill.ScratchEnv. The executor sets it, to ScratchGuestDir,
+// when Config.ScratchRoot is set.
+const ScratchEnv = "ZEROCLAW_SCRATCH"
+
+// ScratchGuestDir is where an invocation's scratch directory is mounted in
+// the guest.
+const ScratchGuestDir = "/scratch"
+
+// PreopensEnv lists the guest directories the executor mounted; it matches
+// skill.PreopensEnv, so skill.CheckPath accepts paths under ScratchGuestDir.
+const PreopensEnv = "ZEROCLAW_PREOPENS"
+
+// newScratch creates a fresh directory under Config.ScratchRoot for one
+// invocation and returns it with a func that removes it and everything the
+// skill wrote there. Without a ScratchRoot it returns "" and a no-op.
+func (e *Executor) newScratch() (string, func(), error) {
+	if e.cfg.ScratchRoot == "" || e.cfg.Sandboxed {
+		return "", func() {}, nil
+	}
+	dir, err := os.MkdirTemp(e.cfg.ScratchRoot, "scratch-")
+	if err != nil {
+		return "", nil, err
+	}
+	return dir, func() { os.RemoveAll(dir) }, nil
+}
+
+// withScratch mounts dir writable at ScratchGuestDir and tells the skill
+// where it is. An empty dir leaves cfg as it is.
+func withScratch(cfg wazero.ModuleConfig, dir string) wazero.ModuleConfig {
+	if dir == "" {
+		return cfg
+	}
+	return cfg.
+		WithFSConfig(wazero.NewFSConfig().WithDirMount(dir, ScratchGuestDir)).
+		WithEnv(ScratchEnv, ScratchGuestDir).
+		WithEnv(PreopensEnv, ScratchGuestDir)
+}
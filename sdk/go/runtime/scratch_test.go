@@ -0,0 +1,80 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestScratchDirIsFreshAndRemoved(t *testing.T) {
+	wasm := buildSkill(t, "scratch")
+	root := t.TempDir()
+	ctx := context.Background()
+	exec := New(Config{ScratchRoot: root})
+
+	check := func(res ToolResult) {
+		t.Helper()
+		var data struct {
+			Dir   string   `json:"dir"`
+			Found []string `json:"found"`
+		}
+		if err := json.Unmarshal(res.Data, &data); err != nil || !res.Success {
+			t.Fatalf("unexpected result: %+v", res)
+		}
+		if data.Dir != ScratchGuestDir || len(data.Found) != 0 {
+			t.Fatalf("scratch dir %s should start empty, found %v", data.Dir, data.Found)
+		}
+		left, err := os.ReadDir(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(left) != 0 {
+			t.Fatalf("scratch dirs left after the call: %v", left)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		res, err := exec.Execute(ctx, wasm, []byte(`{}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(res.ToolResult)
+	}
+
+	mod, err := exec.Compile(ctx, wasm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mod.Close(ctx)
+	for i := 0; i < 2; i++ {
+		in, err := mod.NewInstance(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := in.Call(ctx, []byte(`{}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(res)
+	}
+
+	in, err := mod.NewInstance(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in.Close(ctx)
+	if left, _ := os.ReadDir(root); len(left) != 0 {
+		t.Fatalf("Close should remove the scratch dir: %v", left)
+	}
+}
+
+func TestNoScratchDirByDefault(t *testing.T) {
+	res, err := Execute(context.Background(), buildSkill(t, "scratch"), []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success {
+		t.Fatalf("a skill without ScratchRoot should have nowhere to write: %+v", res.ToolResult)
+	}
+}
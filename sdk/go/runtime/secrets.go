@@ -0,0 +1,129 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+)
+
+// SecretEnvPrefix prefixes the environment variable that carries each
+// declared secret to the guest; it matches skill.SecretEnvPrefix.
+const SecretEnvPrefix = "ZEROCLAW_SECRET_"
+
+// Redacted replaces every copy of a secret's value in what a skill writes.
+const Redacted = "[REDACTED]"
+
+// ErrMissingSecret is returned for a skill whose manifest declares a secret
+// that Config.Secrets does not hold.
+var ErrMissingSecret = errors.New("skill declares a secret the host does not hold")
+
+// secretsFor picks the secrets caps declares out of Config.Secrets. Secrets
+// the manifest does not name are never passed to the skill.
+func (e *Executor) secretsFor(wasmPath string, caps capabilities) (map[string]string, error) {
+	if len(caps.Secrets) == 0 {
+		return nil, nil
+	}
+	secrets := make(map[string]string, len(caps.Secrets))
+	for _, key := range caps.Secrets {
+		value, ok := e.cfg.Secrets[key]
+		if !ok {
+			return nil, fmt.Errorf("%s: %w: %s", wasmPath, ErrMissingSecret, key)
+		}
+		secrets[key] = value
+	}
+	return secrets, nil
+}
+
+// withSecrets passes each secret to the guest in its SecretEnvPrefix variable.
+func withSecrets(cfg wazero.ModuleConfig, secrets map[string]string) wazero.ModuleConfig {
+	for key, value := range secrets {
+		cfg = cfg.WithEnv(SecretEnvPrefix+key, value)
+	}
+	return cfg
+}
+
+// redactor replaces secret values, as written and as they appear inside a
+// JSON string, with Redacted. A nil redactor leaves everything as is.
+type redactor struct {
+	patterns [][]byte
+}
+
+// newRedactor returns a redactor for the values of secrets, or nil when there
+// is nothing to redact.
+func newRedactor(secrets map[string]string) *redactor {
+	seen := make(map[string]bool)
+	var patterns [][]byte
+	for _, value := range secrets {
+		if value == "" {
+			continue
+		}
+		for _, p := range []string{value, jsonEscape(value, true), jsonEscape(value, false)} {
+			if !seen[p] {
+				seen[p] = true
+				patterns = append(patterns, []byte(p))
+			}
+		}
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+	// Longest first, so a value containing another is redacted whole.
+	sort.Slice(patterns, func(i, j int) bool { return len(patterns[i]) > len(patterns[j]) })
+	return &redactor{patterns: patterns}
+}
+
+// jsonEscape spells s as it appears between the quotes of a JSON string;
+// encoding/json escapes <, >, and & unless html is false.
+func jsonEscape(s string, html bool) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(html)
+	enc.Encode(s)
+	return strings.TrimSuffix(strings.TrimSuffix(b.String(), "\n"), `"`)[1:]
+}
+
+// redact returns b with every secret replaced.
+func (r *redactor) redact(b []byte) []byte {
+	if r == nil {
+		return b
+	}
+	for _, p := range r.patterns {
+		b = bytes.ReplaceAll(b, p, []byte(Redacted))
+	}
+	return b
+}
+
+// lineWriter redacts what is written to it a line at a time, so a secret
+// split across writes is still caught, and forwards the lines to w. Call
+// flush when the guest is done to forward an unterminated last line.
+type lineWriter struct {
+	r   *redactor
+	w   io.Writer
+	buf []byte
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	l.buf = append(l.buf, p...)
+	if i := bytes.LastIndexByte(l.buf, '\n'); i >= 0 {
+		if _, err := l.w.Write(l.r.redact(l.buf[:i+1])); err != nil {
+			return 0, err
+		}
+		l.buf = append(l.buf[:0], l.buf[i+1:]...)
+	}
+	return len(p), nil
+}
+
+func (l *lineWriter) flush() error {
+	if len(l.buf) == 0 {
+		return nil
+	}
+	_, err := l.w.Write(l.r.redact(l.buf))
+	l.buf = nil
+	return err
+}
@@ -0,0 +1,81 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const secretManifest = `{"capabilities":{"secrets":["API_KEY"]}}`
+
+func TestDeclaredSecretReachesGuestAndIsRedacted(t *testing.T) {
+	wasm := skillDir(t, buildSkill(t, "secret"), secretManifest)
+	const key = "tok&en-123"
+	exec := New(Config{Secrets: map[string]string{"API_KEY": key, "OTHER": "x"}})
+
+	res, err := exec.Execute(context.Background(), wasm, []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"key":"[REDACTED]","length":10,"other":false}`; string(res.Data) != want {
+		t.Fatalf("data = %s, want %s", res.Data, want)
+	}
+	if res.Output != "key="+Redacted {
+		t.Fatalf("output = %q", res.Output)
+	}
+	if strings.Contains(string(res.Stderr), key) || !strings.Contains(string(res.Stderr), Redacted) {
+		t.Fatalf("stderr should have the key redacted, got %q", res.Stderr)
+	}
+
+	var w bytes.Buffer
+	if _, err := exec.ExecuteReader(context.Background(), wasm, strings.NewReader(`{}`), &w); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(w.String(), "en-123") {
+		t.Fatalf("streamed stdout leaked the key: %s", w.String())
+	}
+}
+
+func TestInstanceRedactsSecrets(t *testing.T) {
+	wasm := skillDir(t, buildSkill(t, "secret"), secretManifest)
+	mod, err := New(Config{Secrets: map[string]string{"API_KEY": "k-456"}}).Compile(context.Background(), wasm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mod.Close(context.Background())
+	in, err := mod.NewInstance(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := in.Call(context.Background(), []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"key":"[REDACTED]","length":5,"other":false}`; string(res.Data) != want {
+		t.Fatalf("data = %s, want %s", res.Data, want)
+	}
+}
+
+func TestMissingDeclaredSecretFails(t *testing.T) {
+	wasm := skillDir(t, buildSkill(t, "secret"), secretManifest)
+	_, err := Execute(context.Background(), wasm, []byte(`{}`))
+	if !errors.Is(err, ErrMissingSecret) {
+		t.Fatalf("expected ErrMissingSecret, got %v", err)
+	}
+}
+
+func TestLineWriterRedactsAcrossWrites(t *testing.T) {
+	var out bytes.Buffer
+	w := &lineWriter{r: newRedactor(map[string]string{"K": "secret"}), w: &out}
+	w.Write([]byte("a sec"))
+	w.Write([]byte("ret\nb secr"))
+	w.Write([]byte("et"))
+	if err := w.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "a [REDACTED]\nb [REDACTED]"; out.String() != want {
+		t.Fatalf("got %q, want %q", out.String(), want)
+	}
+}
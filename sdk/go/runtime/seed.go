@@ -0,0 +1,23 @@
+package runtime
+
+import (
+	"strconv"
+
+	"github.com/tetratelabs/wazero"
+)
+
+// SeedField is the args field through which a caller pins the randomness of
+// an invocation; it matches skill.SeedField. It takes precedence over
+// Config.Seed.
+const SeedField = "_seed"
+
+// SeedEnv passes Config.Seed to the guest; it matches skill.SeedEnv.
+const SeedEnv = "ZEROCLAW_SEED"
+
+// withSeed passes seed, when set, to the guest in SeedEnv.
+func withSeed(cfg wazero.ModuleConfig, seed *int64) wazero.ModuleConfig {
+	if seed == nil {
+		return cfg
+	}
+	return cfg.WithEnv(SeedEnv, strconv.FormatInt(*seed, 10))
+}
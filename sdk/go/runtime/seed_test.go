@@ -0,0 +1,44 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSeedPinsShuffle(t *testing.T) {
+	wasm := buildSkill(t, "shuffle")
+	const items = `"items":["a","b","c","d","e","f","g","h","i","j","k","l"]`
+	run := func(exec *Executor, args string) string {
+		t.Helper()
+		res, err := exec.Execute(context.Background(), wasm, []byte(args))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Output
+	}
+	seven, eight := int64(7), int64(8)
+
+	first := run(New(Config{Seed: &seven}), `{`+items+`}`)
+	if again := run(New(Config{Seed: &seven}), `{`+items+`}`); again != first {
+		t.Fatalf("Config.Seed should pin the shuffle: %q, then %q", first, again)
+	}
+	if other := run(New(Config{Seed: &eight}), `{`+items+`}`); other == first {
+		t.Fatalf("another seed should shuffle differently, both gave %q", first)
+	}
+	if field := run(New(Config{Seed: &eight}), `{`+items+`,"_seed":7}`); field != first {
+		t.Fatalf("SeedField should override Config.Seed: %q, want %q", field, first)
+	}
+
+	mod, err := New(Config{Seed: &seven}).Compile(context.Background(), wasm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mod.Close(context.Background())
+	in, err := mod.NewInstance(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := in.Call(context.Background(), []byte(`{`+items+`}`)); err != nil || res.Output != first {
+		t.Fatalf("an Instance should get Config.Seed too: %q, want %q: %v", res.Output, first, err)
+	}
+}
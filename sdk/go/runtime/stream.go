@@ -0,0 +1,141 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// streamQueue stands between a skill's stdout and the writer ExecuteReader
+// was given, so a guest can run up to Config.StreamBuffer lines ahead of a
+// slow consumer. With Config.DropProgress set, a full queue makes room by
+// dropping progress lines, oldest first; a result line is never dropped, and
+// the guest blocks until the consumer takes one.
+type streamQueue struct {
+	w    io.Writer
+	size int
+	drop bool
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	lines   [][]byte
+	buf     []byte // unterminated last line
+	closed  bool
+	err     error // first error writing to w
+	dropped int
+	done    chan struct{}
+}
+
+func newStreamQueue(w io.Writer, size int, drop bool) *streamQueue {
+	q := &streamQueue{w: w, size: size, drop: drop, done: make(chan struct{})}
+	q.cond = sync.NewCond(&q.mu)
+	go q.run()
+	return q
+}
+
+func (q *streamQueue) Write(p []byte) (int, error) {
+	q.buf = append(q.buf, p...)
+	for {
+		i := bytes.IndexByte(q.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := q.push(bytes.Clone(q.buf[:i+1])); err != nil {
+			return 0, err
+		}
+		q.buf = q.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// push queues line, waiting for room unless a progress line can be dropped.
+func (q *streamQueue) push(line []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.lines) >= q.size && q.err == nil {
+		if q.drop {
+			if i := q.oldestProgress(); i >= 0 {
+				q.lines = append(q.lines[:i], q.lines[i+1:]...)
+				q.dropped++
+				break
+			}
+			if isProgressLine(line) {
+				q.dropped++
+				return nil
+			}
+		}
+		q.cond.Wait()
+	}
+	if q.err != nil {
+		return q.err
+	}
+	q.lines = append(q.lines, line)
+	q.cond.Broadcast()
+	return nil
+}
+
+func (q *streamQueue) oldestProgress() int {
+	for i, line := range q.lines {
+		if isProgressLine(line) {
+			return i
+		}
+	}
+	return -1
+}
+
+// run copies queued lines to w until the queue is closed and empty.
+func (q *streamQueue) run() {
+	defer close(q.done)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		for len(q.lines) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if len(q.lines) == 0 {
+			return
+		}
+		line := q.lines[0]
+		q.lines = q.lines[1:]
+		q.cond.Broadcast()
+		q.mu.Unlock()
+		_, err := q.w.Write(line)
+		q.mu.Lock()
+		if err != nil && q.err == nil {
+			q.err = err
+			q.lines = nil
+			q.cond.Broadcast()
+		}
+	}
+}
+
+// close queues an unterminated last line, waits for everything queued to
+// reach w, and returns the first error writing to it. It is safe to call
+// more than once.
+func (q *streamQueue) close() error {
+	if len(q.buf) > 0 {
+		line := q.buf
+		q.buf = nil
+		q.push(line)
+	}
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	<-q.done
+	return q.err
+}
+
+// isProgressLine reports whether line is a progress line (see
+// skill.ProgressField) rather than a result.
+func isProgressLine(line []byte) bool {
+	var probe struct {
+		Progress json.RawMessage `json:"progress"`
+		Success  *bool           `json:"success"`
+	}
+	if json.Unmarshal(line, &probe) != nil {
+		return false
+	}
+	return len(probe.Progress) > 0 && probe.Success == nil
+}
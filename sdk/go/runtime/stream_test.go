@@ -0,0 +1,70 @@
+package runtime
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowWriter takes its time over every line, like a consumer forwarding
+// each one over a slow connection.
+type slowWriter struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(2 * time.Millisecond)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestStreamBufferDropsProgressButNotResults(t *testing.T) {
+	wasm := buildSkill(t, "partials")
+	var partials []int
+	exec := New(Config{
+		StreamBuffer: 4,
+		DropProgress: true,
+		OnPartial:    func(res ToolResult) { partials = append(partials, res.Seq) },
+	})
+	var w slowWriter
+	res, err := exec.ExecuteReader(context.Background(), wasm, strings.NewReader(`{"seq":[1,2,3,4,5],"progress":100}`), &w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 2, 3, 4, 5}; !reflect.DeepEqual(partials, want) {
+		t.Fatalf("OnPartial got seqs %v, want %v", partials, want)
+	}
+
+	var streamed []int
+	if err := ReadPartials(strings.NewReader(w.buf.String()), func(res ToolResult) { streamed = append(streamed, res.Seq) }); err != nil {
+		t.Fatalf("w should get every result line: %v", err)
+	}
+	if want := []int{1, 2, 3, 4, 5}; !reflect.DeepEqual(streamed, want) {
+		t.Fatalf("w got seqs %v, want %v", streamed, want)
+	}
+	progress := strings.Count(w.buf.String(), `"progress"`)
+	if res.DroppedProgress == 0 {
+		t.Fatalf("a slow consumer should have cost some progress lines, w got all %d", progress)
+	}
+	if progress+res.DroppedProgress != 500 {
+		t.Fatalf("w got %d progress lines and %d were dropped, want 500 in all", progress, res.DroppedProgress)
+	}
+}
+
+func TestStreamBufferKeepsProgressWithoutDropProgress(t *testing.T) {
+	wasm := buildSkill(t, "partials")
+	exec := New(Config{StreamBuffer: 2})
+	var w slowWriter
+	res, err := exec.ExecuteReader(context.Background(), wasm, strings.NewReader(`{"seq":[1,2],"progress":10}`), &w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(w.buf.String(), `"progress"`); got != 20 || res.DroppedProgress != 0 {
+		t.Fatalf("w got %d progress lines with %d dropped, want all 20", got, res.DroppedProgress)
+	}
+}
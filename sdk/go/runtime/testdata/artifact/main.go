@@ -0,0 +1,40 @@
+// artifact is a test skill that returns an args.size-byte text artifact,
+// gzipped the way skill.Run does when ZEROCLAW_ARTIFACT_GZIP_MIN allows,
+// and a markdown artifact sent as text.
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	var args struct {
+		Size int `json:"size"`
+	}
+	if err := json.NewDecoder(os.Stdin).Decode(&args); err != nil {
+		panic(err)
+	}
+	content := []byte(strings.Repeat("z", args.Size))
+	encoding := ""
+	if min, err := strconv.Atoi(os.Getenv("ZEROCLAW_ARTIFACT_GZIP_MIN")); err == nil && min > 0 && len(content) >= min {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(content)
+		zw.Close()
+		content, encoding = buf.Bytes(), "gzip"
+	}
+	out, _ := json.Marshal(map[string]any{
+		"success": true,
+		"output":  os.Getenv("ZEROCLAW_ARTIFACT_GZIP_MIN"),
+		"artifacts": []map[string]any{
+			{"name": "z.txt", "encoding": encoding, "content": content},
+			{"name": "zz.md", "media_type": "text/markdown", "text": "# " + strconv.Itoa(args.Size)},
+		},
+	})
+	os.Stdout.Write(out)
+}
@@ -0,0 +1,42 @@
+// ask is a test skill that puts args.prompt to the host the way skill.Ask
+// does and reports the answer, or fails with not_supported when the host
+// does not set ZEROCLAW_ASK.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+)
+
+func main() {
+	if os.Getenv("ZEROCLAW_ASK") != "1" {
+		os.Stdout.WriteString(`{"success":false,"output":"","error":"host does not answer questions","error_code":"not_supported"}`)
+		return
+	}
+	in := bufio.NewReader(os.Stdin)
+	line, _ := in.ReadBytes('\n')
+	var args struct {
+		Prompt string `json:"prompt"`
+	}
+	if err := json.Unmarshal(line, &args); err != nil {
+		panic(err)
+	}
+
+	ask, _ := json.Marshal(map[string]any{"type": "ask", "prompt": args.Prompt, "schema": map[string]string{"type": "string"}})
+	os.Stdout.Write(append(ask, '\n'))
+	line, _ = in.ReadBytes('\n')
+	var reply struct {
+		Answer json.RawMessage `json:"answer"`
+		Error  string          `json:"error"`
+	}
+	if err := json.Unmarshal(line, &reply); err != nil {
+		panic(err)
+	}
+	res := map[string]any{"success": reply.Error == "", "output": string(reply.Answer)}
+	if reply.Error != "" {
+		res["error"] = reply.Error
+	}
+	out, _ := json.Marshal(res)
+	os.Stdout.Write(out)
+}
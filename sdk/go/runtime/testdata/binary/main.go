@@ -0,0 +1,11 @@
+// binary is a test skill that writes a byte no UTF-8 text contains into
+// the middle of its result, the way a skill printing binary by mistake does.
+package main
+
+import "os"
+
+func main() {
+	os.Stdout.Write([]byte(`{"success":true,"output":"`))
+	os.Stdout.Write([]byte{0xFF})
+	os.Stdout.Write([]byte(`"}`))
+}
@@ -0,0 +1,39 @@
+// budget is a test skill that lists as many numbers as args.n asks for, or
+// as many as fit in ZEROCLAW_MAX_OUTPUT_BYTES, and reports whether it was
+// told a deadline that has not yet passed.
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"time"
+)
+
+type result struct {
+	Success   bool   `json:"success"`
+	Output    string `json:"output"`
+	Data      []int  `json:"data"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+func main() {
+	var args struct {
+		N int `json:"n"`
+	}
+	json.NewDecoder(os.Stdin).Decode(&args)
+
+	output := "no deadline"
+	if deadline, err := time.Parse(time.RFC3339Nano, os.Getenv("ZEROCLAW_DEADLINE")); err == nil {
+		output = "deadline in future: " + strconv.FormatBool(time.Until(deadline) > 0)
+	}
+	res := result{Success: true, Output: output, Data: make([]int, args.N)}
+	out, _ := json.Marshal(res)
+	if max, err := strconv.Atoi(os.Getenv("ZEROCLAW_MAX_OUTPUT_BYTES")); err == nil {
+		for len(out) > max && len(res.Data) > 0 {
+			res.Data, res.Truncated = res.Data[:len(res.Data)/2], true
+			out, _ = json.Marshal(res)
+		}
+	}
+	os.Stdout.Write(out)
+}
@@ -0,0 +1,24 @@
+// echo is a test skill that reports its stdin back as the output string. It
+// refuses stdin over ZEROCLAW_MAX_INPUT_BYTES, as skill.Run does for
+// SDK-built skills.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+func main() {
+	var res map[string]any
+	in, _ := io.ReadAll(os.Stdin)
+	if max, err := strconv.Atoi(os.Getenv("ZEROCLAW_MAX_INPUT_BYTES")); err == nil && len(in) > max {
+		res = map[string]any{"success": false, "output": "", "error": fmt.Sprintf("input exceeds %d bytes", max), "error_code": "invalid_input"}
+	} else {
+		res = map[string]any{"success": true, "output": string(in)}
+	}
+	out, _ := json.Marshal(res)
+	os.Stdout.Write(out)
+}
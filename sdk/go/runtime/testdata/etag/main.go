@@ -0,0 +1,23 @@
+// etag is a test skill that answers for args.city with the ETag "v1", or
+// with not_modified when args.if_none_match is already "v1".
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+func main() {
+	var args struct {
+		City        string `json:"city"`
+		IfNoneMatch string `json:"if_none_match"`
+	}
+	json.NewDecoder(os.Stdin).Decode(&args)
+
+	res := map[string]any{"success": true, "output": "sunny in " + args.City, "etag": "v1"}
+	if args.IfNoneMatch == "v1" {
+		res = map[string]any{"success": true, "output": "", "etag": "v1", "not_modified": true}
+	}
+	out, _ := json.Marshal(res)
+	os.Stdout.Write(out)
+}
@@ -0,0 +1,18 @@
+// exitcode is a test skill that writes a failed ToolResult and exits with the
+// status given as {"code": N} on stdin.
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+func main() {
+	var args struct {
+		Code int `json:"code"`
+	}
+	json.NewDecoder(os.Stdin).Decode(&args)
+	out, _ := json.Marshal(map[string]any{"success": false, "output": "", "error": "rejected", "error_code": "invalid_input"})
+	os.Stdout.Write(out)
+	os.Exit(args.Code)
+}
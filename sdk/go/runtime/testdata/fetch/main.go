@@ -0,0 +1,40 @@
+// fetch is a test skill that calls zeroclaw_http_fetch {"url","times"} times
+// and reports each return code and the first body.
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"unsafe"
+)
+
+//go:wasmimport env zeroclaw_http_fetch
+func httpFetch(url unsafe.Pointer, urlLen uint32, out unsafe.Pointer, outCap uint32) int32
+
+func main() {
+	in, _ := io.ReadAll(os.Stdin)
+	var args struct {
+		URL   string `json:"url"`
+		Times int    `json:"times"`
+	}
+	json.Unmarshal(in, &args)
+
+	url := []byte(args.URL)
+	buf := make([]byte, 256)
+	var codes []int32
+	body := ""
+	for i := 0; i < args.Times; i++ {
+		n := httpFetch(unsafe.Pointer(&url[0]), uint32(len(url)), unsafe.Pointer(&buf[0]), uint32(len(buf)))
+		codes = append(codes, n)
+		if body == "" && n > 0 {
+			body = string(buf[:min(int(n), len(buf))])
+		}
+	}
+	out, _ := json.Marshal(map[string]any{
+		"success": true,
+		"output":  body,
+		"data":    map[string]any{"codes": codes},
+	})
+	os.Stdout.Write(out)
+}
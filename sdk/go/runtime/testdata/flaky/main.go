@@ -0,0 +1,32 @@
+// flaky is a test skill that asks {"url"} whether to fail: it fails with
+// error code {"code"} while the reply is "fail", and succeeds otherwise.
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"unsafe"
+)
+
+//go:wasmimport env zeroclaw_http_fetch
+func httpFetch(url unsafe.Pointer, urlLen uint32, out unsafe.Pointer, outCap uint32) int32
+
+func main() {
+	in, _ := io.ReadAll(os.Stdin)
+	var args struct {
+		URL  string `json:"url"`
+		Code string `json:"code"`
+	}
+	json.Unmarshal(in, &args)
+
+	url := []byte(args.URL)
+	buf := make([]byte, 64)
+	n := httpFetch(unsafe.Pointer(&url[0]), uint32(len(url)), unsafe.Pointer(&buf[0]), uint32(len(buf)))
+	res := map[string]any{"success": true, "output": "ok"}
+	if n < 0 || string(buf[:n]) == "fail" {
+		res = map[string]any{"success": false, "error": "flaked", "error_code": args.Code}
+	}
+	out, _ := json.Marshal(res)
+	os.Stdout.Write(out)
+}
@@ -0,0 +1,40 @@
+// listing is a test skill that lists args.n file names. When the host sets
+// ZEROCLAW_ON_OVERSIZE=truncate it drops names until the result fits in
+// ZEROCLAW_MAX_OUTPUT_BYTES, as skill.Run does for SDK-built skills;
+// otherwise it writes them all.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+type result struct {
+	Success   bool     `json:"success"`
+	Output    string   `json:"output"`
+	Data      []string `json:"data"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+func main() {
+	var args struct {
+		N int `json:"n"`
+	}
+	json.NewDecoder(os.Stdin).Decode(&args)
+
+	res := result{Success: true, Output: fmt.Sprintf("%d files", args.N)}
+	for i := 0; i < args.N; i++ {
+		res.Data = append(res.Data, fmt.Sprintf("file-%04d.txt", i))
+	}
+	out, _ := json.Marshal(res)
+	max, err := strconv.Atoi(os.Getenv("ZEROCLAW_MAX_OUTPUT_BYTES"))
+	if os.Getenv("ZEROCLAW_ON_OVERSIZE") == "truncate" && err == nil {
+		for len(out) > max && len(res.Data) > 0 {
+			res.Data, res.Truncated = res.Data[:len(res.Data)/2], true
+			out, _ = json.Marshal(res)
+		}
+	}
+	os.Stdout.Write(out)
+}
@@ -0,0 +1,17 @@
+// logs is a test skill that logs a line to stderr before it reads its args,
+// so a host can see the line while the skill is still waiting for input, and
+// ends with an unterminated line once it has them.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	fmt.Fprintln(os.Stderr, "started")
+	in, _ := io.ReadAll(os.Stdin)
+	fmt.Fprintf(os.Stderr, "read %d bytes", len(in))
+	os.Stdout.Write([]byte(`{"success":true,"output":""}`))
+}
@@ -0,0 +1,38 @@
+// partials is a test skill that writes streamed result lines in the order
+// args.seq lists them, as a pipe that reorders writes might deliver them.
+// The highest seq is marked final, and args.progress progress lines go
+// before each one.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+func main() {
+	var args struct {
+		Seq      []int `json:"seq"`
+		Progress int   `json:"progress"`
+	}
+	if err := json.NewDecoder(os.Stdin).Decode(&args); err != nil {
+		panic(err)
+	}
+	last := slices.Max(args.Seq)
+	for _, seq := range args.Seq {
+		for i := range args.Progress {
+			line, _ := json.Marshal(map[string]any{
+				"progress": map[string]any{"message": fmt.Sprintf("step %d of %d", i+1, seq)},
+			})
+			os.Stdout.Write(append(line, '\n'))
+		}
+		line, _ := json.Marshal(map[string]any{
+			"success": true,
+			"output":  fmt.Sprintf("part %d", seq),
+			"seq":     seq,
+			"final":   seq == last,
+		})
+		os.Stdout.Write(append(line, '\n'))
+	}
+}
@@ -0,0 +1,30 @@
+// probe is a test skill that answers {"__probe":true} the way skill.Run does
+// and panics if its handler is ever reached.
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+)
+
+func main() {
+	in, _ := io.ReadAll(os.Stdin)
+	var env struct {
+		Probe bool `json:"__probe"`
+	}
+	if json.Unmarshal(in, &env) != nil || !env.Probe {
+		panic("handler reached")
+	}
+	out, _ := json.Marshal(map[string]any{
+		"success": true,
+		"output":  "probe",
+		"data": map[string]any{
+			"tools": []any{
+				map[string]any{"name": "count", "schema": map[string]any{"type": "object"}},
+			},
+			"permissions": []string{"fs:/data", "http_fetch"},
+		},
+	})
+	os.Stdout.Write(out)
+}
@@ -0,0 +1,26 @@
+// raw is a test skill that returns its stdin reversed as a raw result, the
+// way skill.Raw writes one, or with a header that overstates the length when
+// the args start with "short".
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	in, _ := io.ReadAll(os.Stdin)
+	body := make([]byte, len(in))
+	for i, b := range in {
+		body[len(in)-1-i] = b
+	}
+	length := len(body)
+	if bytes.HasPrefix(in, []byte("short")) {
+		length++
+	}
+	fmt.Fprintln(os.Stderr, "reversing")
+	fmt.Fprintf(os.Stderr, "ZEROCLAW_RAW=1 {\"content_type\":\"application/octet-stream\",\"length\":%d}\n", length)
+	os.Stdout.Write(body)
+}
@@ -0,0 +1,18 @@
+// recurse is a test skill that recurses until it runs out of stack, the
+// way a skill with a missing base case does.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func depth(n int) int {
+	var pad [64]byte
+	pad[n%64] = byte(n)
+	return depth(n+1) + int(pad[0])
+}
+
+func main() {
+	fmt.Fprintln(os.Stdout, depth(0))
+}
@@ -0,0 +1,18 @@
+// report is a test skill that writes {"text": ...} back as its output, typed
+// with the {"type": ...} it was given, without the SDK checking the type.
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+func main() {
+	var args struct {
+		Text string `json:"text"`
+		Type string `json:"type"`
+	}
+	json.NewDecoder(os.Stdin).Decode(&args)
+	out, _ := json.Marshal(map[string]any{"success": true, "output": args.Text, "output_type": args.Type})
+	os.Stdout.Write(out)
+}
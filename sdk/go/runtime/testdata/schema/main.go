@@ -0,0 +1,29 @@
+// schema is a test skill that prints a fixed args schema for --schema, the
+// way skill.Run does, and otherwise reports its stdin back as the output.
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+)
+
+const schema = `{"type":"object","properties":{` +
+	`"text":{"type":"string"},` +
+	`"count":{"type":"integer","minimum":0,"maximum":10},` +
+	`"mode":{"type":"string","enum":["fast","slow"]},` +
+	`"tags":{"type":"array","items":{"type":"string"}},` +
+	`"options":{"type":"object","properties":{"wpm":{"type":"integer"},"token":{"type":"string","x-zeroclaw-secret":true}},"required":[]},` +
+	`"api_key":{"type":"string","x-zeroclaw-secret":true},` +
+	`"labels":{"type":"object","additionalProperties":{"type":"string"}}` +
+	`},"required":["text"]}`
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "--schema" {
+		os.Stdout.WriteString(schema)
+		return
+	}
+	in, _ := io.ReadAll(os.Stdin)
+	out, _ := json.Marshal(map[string]any{"success": true, "output": string(in)})
+	os.Stdout.Write(out)
+}
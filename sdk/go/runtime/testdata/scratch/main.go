@@ -0,0 +1,27 @@
+// scratch is a test skill that reports what its ZEROCLAW_SCRATCH directory
+// already holds, then leaves a file there for a later call to find.
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	dir := os.Getenv("ZEROCLAW_SCRATCH")
+	found := []string{}
+	entries, err := os.ReadDir(dir)
+	if err == nil {
+		for _, e := range entries {
+			found = append(found, e.Name())
+		}
+		err = os.WriteFile(filepath.Join(dir, "left-behind.txt"), []byte("state"), 0o644)
+	}
+	res := map[string]any{"success": err == nil, "output": "", "data": map[string]any{"dir": dir, "found": found}}
+	if err != nil {
+		res["error"] = err.Error()
+	}
+	out, _ := json.Marshal(res)
+	os.Stdout.Write(out)
+}
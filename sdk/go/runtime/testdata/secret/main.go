@@ -0,0 +1,21 @@
+// secret is a test skill that leaks its API_KEY secret into its output,
+// data, and stderr, and reports which secrets it was given.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+func main() {
+	key := os.Getenv("ZEROCLAW_SECRET_API_KEY")
+	_, other := os.LookupEnv("ZEROCLAW_SECRET_OTHER")
+	fmt.Fprintln(os.Stderr, "using key", key)
+	out, _ := json.Marshal(map[string]any{
+		"success": true,
+		"output":  "key=" + key,
+		"data":    map[string]any{"key": key, "length": len(key), "other": other},
+	})
+	os.Stdout.Write(out)
+}
@@ -0,0 +1,31 @@
+// shuffle is a test skill that shuffles args.items, seeded the way
+// skill.Rand seeds: from args._seed, else ZEROCLAW_SEED, else the clock.
+package main
+
+import (
+	"encoding/json"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func main() {
+	var args struct {
+		Items []string `json:"items"`
+		Seed  *int64   `json:"_seed"`
+	}
+	json.NewDecoder(os.Stdin).Decode(&args)
+
+	seed := time.Now().UnixNano()
+	if args.Seed != nil {
+		seed = *args.Seed
+	} else if env, err := strconv.ParseInt(os.Getenv("ZEROCLAW_SEED"), 10, 64); err == nil {
+		seed = env
+	}
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(args.Items), func(i, j int) { args.Items[i], args.Items[j] = args.Items[j], args.Items[i] })
+	out, _ := json.Marshal(map[string]any{"success": true, "output": strings.Join(args.Items, " ")})
+	os.Stdout.Write(out)
+}
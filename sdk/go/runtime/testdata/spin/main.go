@@ -0,0 +1,7 @@
+// spin is a test skill that never returns, for testing timeouts.
+package main
+
+func main() {
+	for n := 0; ; n++ {
+	}
+}
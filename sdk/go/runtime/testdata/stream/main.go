@@ -0,0 +1,20 @@
+// stream is a test skill that writes numbered partial results forever,
+// paying no attention to its deadline.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+func main() {
+	for seq := 1; ; seq++ {
+		line, _ := json.Marshal(map[string]any{
+			"success": true,
+			"output":  fmt.Sprintf("part %d", seq),
+			"seq":     seq,
+		})
+		os.Stdout.Write(append(line, '\n'))
+	}
+}
@@ -0,0 +1,29 @@
+// trace is a test skill that picks its trace ID the way skill.Run does, logs
+// two lines to stderr, and echoes the ID back in the result's meta.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	in, _ := io.ReadAll(os.Stdin)
+	var args struct {
+		TraceID string `json:"_trace_id"`
+	}
+	json.Unmarshal(in, &args)
+	if args.TraceID == "" {
+		args.TraceID = os.Getenv("ZEROCLAW_TRACE_ID")
+	}
+	fmt.Fprintln(os.Stderr, "starting")
+	fmt.Fprint(os.Stderr, "done")
+	out, _ := json.Marshal(map[string]any{
+		"success": true,
+		"output":  "",
+		"meta":    map[string]string{"trace_id": args.TraceID},
+	})
+	os.Stdout.Write(out)
+}
@@ -0,0 +1,62 @@
+package runtime
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// TraceField is the args field through which a caller picks the trace ID of
+// an invocation; it matches skill.TraceField. Without it the executor
+// generates one.
+const TraceField = "_trace_id"
+
+// TraceIDEnv passes the generated trace ID to the guest; it matches
+// skill.TraceIDEnv.
+const TraceIDEnv = "ZEROCLAW_TRACE_ID"
+
+// ResultMeta is what an SDK-built skill reports about a request alongside
+// its result (see skill.ResultMeta).
+type ResultMeta struct {
+	TraceID string `json:"trace_id,omitempty"`
+	// Attempts is set by the executor, not the skill: how many calls a
+	// result took when Config.Retries is set.
+	Attempts int `json:"attempts,omitempty"`
+	// SideEffects and Idempotent are set by the executor from the skill's
+	// manifest, whatever the skill wrote: one of the SideEffects values, and
+	// whether the same args may safely be sent again.
+	SideEffects string `json:"side_effects,omitempty"`
+	Idempotent  bool   `json:"idempotent,omitempty"`
+}
+
+// Cacheable reports whether a result may be cached and served again for
+// the same args: the skill is idempotent and does not write. A cache must
+// not keep results of a SideEffectsWrite skill, idempotent or not.
+func (m *ResultMeta) Cacheable() bool {
+	return m != nil && m.Idempotent && m.SideEffects != "" && m.SideEffects != SideEffectsWrite
+}
+
+// newTraceID returns a random 128-bit trace ID in hex, as W3C Trace Context
+// spells them.
+func newTraceID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// traceLog prefixes every line of a guest's stderr with "[id] ".
+func traceLog(stderr []byte, id string) []byte {
+	if len(stderr) == 0 {
+		return stderr
+	}
+	prefix := []byte("[" + id + "] ")
+	lines := bytes.SplitAfter(stderr, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	out := make([]byte, 0, len(stderr)+len(lines)*len(prefix))
+	for _, line := range lines {
+		out = append(append(out, prefix...), line...)
+	}
+	return out
+}
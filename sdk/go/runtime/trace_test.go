@@ -0,0 +1,68 @@
+package runtime
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTraceIDRoundTripsIntoResultAndLogs(t *testing.T) {
+	wasm := buildSkill(t, "trace")
+	res, err := Execute(context.Background(), wasm, []byte(`{"_trace_id":"req-42"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Meta == nil || res.Meta.TraceID != "req-42" {
+		t.Fatalf("supplied trace ID should come back in meta, got %+v", res.Meta)
+	}
+	if want := "[req-42] starting\n[req-42] done"; string(res.Stderr) != want {
+		t.Fatalf("stderr = %q, want %q", res.Stderr, want)
+	}
+}
+
+func TestTraceIDGeneratedWhenNotSupplied(t *testing.T) {
+	wasm := buildSkill(t, "trace")
+	res, err := Execute(context.Background(), wasm, []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Meta == nil || !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(res.Meta.TraceID) {
+		t.Fatalf("expected a generated trace ID in meta, got %+v", res.Meta)
+	}
+	if want := "[" + res.Meta.TraceID + "] starting\n"; string(res.Stderr[:len(want)]) != want {
+		t.Fatalf("stderr should carry the generated ID, got %q", res.Stderr)
+	}
+}
+
+func TestMetaReportsManifestSideEffects(t *testing.T) {
+	echo := buildSkill(t, "echo")
+	for _, tc := range []struct {
+		manifest  string
+		want      ResultMeta
+		cacheable bool
+	}{
+		{``, ResultMeta{SideEffects: SideEffectsWrite}, false},
+		{`{}`, ResultMeta{SideEffects: SideEffectsWrite}, false},
+		{`{"side_effects":"read","idempotent":true}`, ResultMeta{SideEffects: SideEffectsRead, Idempotent: true}, true},
+		{`{"side_effects":"none"}`, ResultMeta{SideEffects: SideEffectsNone}, false},
+		{`{"side_effects":"write","idempotent":true}`, ResultMeta{SideEffects: SideEffectsWrite, Idempotent: true}, false},
+	} {
+		wasm := echo
+		if tc.manifest != "" {
+			wasm = skillDir(t, echo, tc.manifest)
+		}
+		res, err := Execute(context.Background(), wasm, []byte(`{}`))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.manifest, err)
+		}
+		if *res.Meta != tc.want || res.Meta.Cacheable() != tc.cacheable {
+			t.Errorf("%s: got meta %+v (cacheable %v), want %+v (cacheable %v)", tc.manifest, *res.Meta, res.Meta.Cacheable(), tc.want, tc.cacheable)
+		}
+	}
+
+	_, err := Execute(context.Background(), skillDir(t, echo, `{"side_effects":"delete"}`), []byte(`{}`))
+	if err == nil || !strings.Contains(err.Error(), `unknown side_effects "delete"`) {
+		t.Fatalf("got %v, want an unknown side_effects error", err)
+	}
+}
@@ -0,0 +1,81 @@
+package skill
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ArtifactGzipEnv is set by hosts that accept gzip-compressed artifacts, to
+// the size in bytes from which Run compresses them. The Go runtime sets it
+// from the manifest's "artifacts" object; hosts that leave it unset always
+// get raw content.
+const ArtifactGzipEnv = "ZEROCLAW_ARTIFACT_GZIP_MIN"
+
+// ArtifactGzip is the Artifact.Encoding of gzip-compressed content.
+const ArtifactGzip = "gzip"
+
+// Artifact is a named file a skill returns in ToolResult.Artifacts beside
+// its primary Output, such as an image, an archive, or a cleaned-up copy of
+// the input. Content travels base64-encoded in the JSON; a text artifact can
+// set Text instead, which travels as it is and is never compressed.
+type Artifact struct {
+	Name      string `json:"name"`
+	MediaType string `json:"media_type,omitempty"`
+	// Encoding is empty for raw Content, or ArtifactGzip once Run has
+	// compressed it. Handlers leave it empty; a host that does not know an
+	// encoding can still tell Content is not the file itself.
+	Encoding string `json:"encoding,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Content  []byte `json:"content,omitempty"`
+}
+
+// TextArtifact returns an artifact carrying text of the given media type,
+// e.g. TextArtifact("report.md", OutputMarkdown, report).
+func TextArtifact(name, mediaType, text string) Artifact {
+	return Artifact{Name: name, MediaType: mediaType, Text: text}
+}
+
+// WithArtifact returns r with a added to its Artifacts, replacing any of the
+// same name, and the Artifacts sorted by name, so a skill returning several
+// labeled results emits them in the same order on every run.
+func (r ToolResult) WithArtifact(a Artifact) ToolResult {
+	i, found := slices.BinarySearchFunc(r.Artifacts, a.Name, func(a Artifact, name string) int {
+		return strings.Compare(a.Name, name)
+	})
+	if found {
+		r.Artifacts = slices.Clone(r.Artifacts)
+		r.Artifacts[i] = a
+		return r
+	}
+	r.Artifacts = slices.Insert(slices.Clip(r.Artifacts), i, a)
+	return r
+}
+
+// compressArtifacts gzips res's raw artifacts of at least ArtifactGzipEnv
+// bytes, keeping any that would not shrink. Smaller artifacts are left alone,
+// as compressing them costs more CPU than the bytes it saves.
+func compressArtifacts(res ToolResult) ToolResult {
+	threshold, err := strconv.Atoi(os.Getenv(ArtifactGzipEnv))
+	if err != nil || threshold <= 0 || len(res.Artifacts) == 0 {
+		return res
+	}
+	out := make([]Artifact, len(res.Artifacts))
+	for i, a := range res.Artifacts {
+		out[i] = a
+		if a.Encoding != "" || len(a.Content) < threshold {
+			continue
+		}
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(a.Content)
+		if zw.Close() == nil && buf.Len() < len(a.Content) {
+			out[i].Encoding, out[i].Content = ArtifactGzip, buf.Bytes()
+		}
+	}
+	res.Artifacts = out
+	return res
+}
@@ -0,0 +1,86 @@
+package skill
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestCompressArtifactsAboveThreshold(t *testing.T) {
+	t.Setenv(ArtifactGzipEnv, "1024")
+	big := []byte(strings.Repeat("zeroclaw ", 1000))
+	noise := make([]byte, 4096) // random bytes: gzip would grow them
+	rand.New(rand.NewSource(1)).Read(noise)
+	in := OK("done", nil)
+	in.Artifacts = []Artifact{
+		{Name: "big.txt", Content: big},
+		{Name: "small.txt", Content: []byte("hi")},
+		{Name: "noise.bin", Content: noise},
+	}
+	res := compressArtifacts(in)
+
+	if a := res.Artifacts[0]; a.Encoding != ArtifactGzip || len(a.Content) >= len(big) {
+		t.Fatalf("large artifact should be gzipped, got encoding %q, %d bytes", a.Encoding, len(a.Content))
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(res.Artifacts[0].Content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(zr); !bytes.Equal(got, big) {
+		t.Fatal("gzipped content does not round-trip")
+	}
+	for _, a := range res.Artifacts[1:] {
+		if a.Encoding != "" {
+			t.Errorf("%s should stay raw, got encoding %q", a.Name, a.Encoding)
+		}
+	}
+	if in.Artifacts[0].Encoding != "" {
+		t.Fatal("the handler's artifacts must not be modified")
+	}
+
+	// A client that ignores encoding still finds the field beside the content.
+	out, _ := json.Marshal(res.Artifacts[0])
+	if !strings.Contains(string(out), `"encoding":"gzip","content":"H4sI`) {
+		t.Fatalf("unexpected wire form %.80s", out)
+	}
+}
+
+func TestCompressArtifactsOffWithoutHostSupport(t *testing.T) {
+	in := OK("done", nil)
+	in.Artifacts = []Artifact{{Name: "big.txt", Content: []byte(strings.Repeat("a", 1<<16))}}
+	if res := compressArtifacts(in); res.Artifacts[0].Encoding != "" {
+		t.Fatal("artifacts should stay raw when the host does not set ArtifactGzipEnv")
+	}
+}
+
+func TestWithArtifactRoundTripsNamedResults(t *testing.T) {
+	res := OK("2 words", nil).
+		WithArtifact(TextArtifact("report.md", OutputMarkdown, "# 2 words")).
+		WithArtifact(Artifact{Name: "clean.bin", MediaType: "application/octet-stream", Content: []byte{0, 1}}).
+		WithArtifact(TextArtifact("report.md", OutputMarkdown, "# two words"))
+
+	out, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `"artifacts":[{"name":"clean.bin","media_type":"application/octet-stream","content":"AAE="},` +
+		`{"name":"report.md","media_type":"text/markdown","text":"# two words"}]`
+	if !strings.Contains(string(out), want) {
+		t.Fatalf("artifacts should be sorted by name with the later report.md kept, got %s", out)
+	}
+
+	var back ToolResult
+	if err := json.Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if len(back.Artifacts) != 2 || !bytes.Equal(back.Artifacts[0].Content, []byte{0, 1}) || back.Artifacts[1].Text != "# two words" {
+		t.Fatalf("artifacts did not round-trip: %+v", back.Artifacts)
+	}
+	if back.Output != "2 words" {
+		t.Fatalf("the primary output should stay %q, got %q", "2 words", back.Output)
+	}
+}
@@ -0,0 +1,69 @@
+package skill
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// AskEnv is set to "1" by hosts that answer Ask. Such a host writes the args
+// as a single line on stdin and keeps stdin open for the answers.
+const AskEnv = "ZEROCLAW_ASK"
+
+// AskType is the "type" of the control line Ask writes.
+const AskType = "ask"
+
+// ErrAskNotSupported is returned by Ask when the host does not answer
+// questions: it left AskEnv unset, as `zeroclaw skill test` does. Fail with CodeNotSupported, or fall back to a
+// default, so the skill still works there.
+var ErrAskNotSupported = errors.New("host does not answer questions (not_supported)")
+
+// asking is the runner whose stdin carries answers, set by Run when the host
+// sets AskEnv.
+var asking *runner
+
+// askLine is the control line Ask writes to stdout.
+type askLine struct {
+	Type   string `json:"type"`
+	Prompt string `json:"prompt"`
+	Schema any    `json:"schema,omitempty"`
+}
+
+// answerLine is the host's reply to an askLine, one line on stdin.
+type answerLine struct {
+	Answer json.RawMessage `json:"answer"`
+	Error  string          `json:"error"`
+}
+
+// Ask puts a follow-up question to the user or agent behind the host, e.g.
+// "which file?", and blocks until it is answered:
+//
+//	{"type":"ask","prompt":"which file?","schema":{"type":"string"}}
+//
+// schema, which may be nil, is the JSON Schema the answer should match; a
+// struct type's SchemaFor is a good fit. The answer comes back as raw JSON.
+// Ask returns ErrAskNotSupported when the host does not answer questions,
+// and the host's reason when it declines one.
+func Ask(prompt string, schema any) (json.RawMessage, error) {
+	r := asking
+	if r == nil {
+		return nil, ErrAskNotSupported
+	}
+	r.streamMu.Lock()
+	defer r.streamMu.Unlock()
+	write(r, askLine{Type: AskType, Prompt: prompt, Schema: schema})
+	r.stdout.Write([]byte("\n"))
+
+	line, err := r.answers.ReadBytes('\n')
+	if len(line) == 0 && err != nil {
+		return nil, fmt.Errorf("ask: no answer from host: %w", err)
+	}
+	var ans answerLine
+	if err := json.Unmarshal(line, &ans); err != nil {
+		return nil, fmt.Errorf("ask: invalid answer from host: %w", err)
+	}
+	if ans.Error != "" {
+		return nil, fmt.Errorf("ask: %s", ans.Error)
+	}
+	return ans.Answer, nil
+}
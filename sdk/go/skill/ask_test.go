@@ -0,0 +1,53 @@
+package skill
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// askRunner returns a runner serving stdin the way a host that sets AskEnv
+// writes it, installed for Ask until the test ends.
+func askRunner(t *testing.T, stdin string, out *strings.Builder) *runner {
+	r := &runner{stdin: strings.NewReader(stdin), stdout: out}
+	r.answers = bufio.NewReader(r.stdin)
+	asking = r
+	t.Cleanup(func() { asking = nil })
+	return r
+}
+
+func TestAskWritesPromptAndReadsAnswer(t *testing.T) {
+	var out strings.Builder
+	r := askRunner(t, `{"text":"hi"}`+"\n"+`{"answer":"b.txt"}`+"\n", &out)
+	write(r, handle(r, single(func(args echoArgs) ToolResult {
+		ans, err := Ask("which file?", map[string]string{"type": "string"})
+		if err != nil {
+			return FailCode(CodeInternal, err.Error())
+		}
+		return OK(args.Text+" "+string(ans), nil)
+	})))
+
+	want := `{"type":"ask","prompt":"which file?","schema":{"type":"string"}}` + "\n" +
+		`{"success":true,"output":"hi \"b.txt\""}`
+	if out.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestAskReportsHostRefusal(t *testing.T) {
+	var out strings.Builder
+	askRunner(t, `{"error":"user cancelled"}`+"\n", &out)
+	if _, err := Ask("which file?", nil); err == nil || !strings.Contains(err.Error(), "user cancelled") {
+		t.Fatalf("expected the host's reason, got %v", err)
+	}
+	if _, err := Ask("again?", nil); err == nil {
+		t.Fatal("expected an error once stdin has no more answers")
+	}
+}
+
+func TestAskWithoutHostSupport(t *testing.T) {
+	if _, err := Ask("which file?", nil); !errors.Is(err, ErrAskNotSupported) {
+		t.Fatalf("got %v, want ErrAskNotSupported", err)
+	}
+}
@@ -0,0 +1,74 @@
+package skill
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Hosts that limit an invocation advertise the limits in these environment
+// variables so a skill can fit its result to them instead of being cut off.
+// All are optional; Budget reports an unset or malformed one as no limit.
+const (
+	// MaxOutputBytesEnv holds the most bytes the host accepts on stdout.
+	MaxOutputBytesEnv = "ZEROCLAW_MAX_OUTPUT_BYTES"
+	// DeadlineEnv holds the RFC 3339 time at which the host stops the skill.
+	DeadlineEnv = "ZEROCLAW_DEADLINE"
+	// MaxInputBytesEnv holds the most bytes of args Run reads for one
+	// request; a larger request fails with CodeInvalidInput unread.
+	MaxInputBytesEnv = "ZEROCLAW_MAX_INPUT_BYTES"
+)
+
+// Allowance is the budget the host gave this invocation.
+type Allowance struct {
+	// Deadline is when the host stops the skill; zero when it set none.
+	Deadline time.Time
+	// MaxOutputBytes caps the result Run writes; zero when the host set none.
+	MaxOutputBytes int
+	// MaxInputBytes caps the args Run reads; zero when the host set none.
+	MaxInputBytes int
+}
+
+// Budget reads the host's limits for this invocation from MaxOutputBytesEnv,
+// MaxInputBytesEnv, and DeadlineEnv. A skill building a large result can use
+// it to stop early or drop items, marking the result Truncated:
+//
+//	res := skill.OK(summary, items)
+//	for b := skill.Budget(); !b.Fits(res) && len(items) > 0; {
+//		items = items[:len(items)/2]
+//		res = skill.OK(summary, items)
+//		res.Truncated = true
+//	}
+func Budget() Allowance {
+	var a Allowance
+	if n, err := strconv.Atoi(os.Getenv(MaxOutputBytesEnv)); err == nil && n > 0 {
+		a.MaxOutputBytes = n
+	}
+	if n, err := strconv.Atoi(os.Getenv(MaxInputBytesEnv)); err == nil && n > 0 {
+		a.MaxInputBytes = n
+	}
+	if t, err := time.Parse(time.RFC3339Nano, os.Getenv(DeadlineEnv)); err == nil {
+		a.Deadline = t
+	}
+	return a
+}
+
+// Remaining returns the time left before the deadline, never negative, and
+// false when the host set no deadline.
+func (a Allowance) Remaining() (time.Duration, bool) {
+	if a.Deadline.IsZero() {
+		return 0, false
+	}
+	return max(time.Until(a.Deadline), 0), true
+}
+
+// Fits reports whether res, as Run would write it, stays within
+// MaxOutputBytes. It is always true when the host set no cap, and false when
+// res cannot be marshaled at all.
+func (a Allowance) Fits(res ToolResult) bool {
+	if a.MaxOutputBytes == 0 {
+		return true
+	}
+	out, err := MarshalStable(res)
+	return err == nil && len(out) <= a.MaxOutputBytes
+}
@@ -0,0 +1,53 @@
+package skill
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBudgetUnsetMeansNoLimit(t *testing.T) {
+	t.Setenv(MaxOutputBytesEnv, "")
+	t.Setenv(DeadlineEnv, "")
+	b := Budget()
+	if _, ok := b.Remaining(); ok {
+		t.Fatal("Remaining reported a deadline the host never set")
+	}
+	if !b.Fits(OK(strings.Repeat("x", 1<<20), nil)) {
+		t.Fatal("Fits refused output with no cap set")
+	}
+
+	t.Setenv(MaxOutputBytesEnv, "lots")
+	t.Setenv(DeadlineEnv, "tomorrow")
+	if b := Budget(); b != (Allowance{}) {
+		t.Fatalf("malformed env should read as no limit, got %+v", b)
+	}
+}
+
+func TestBudgetReadsHostLimits(t *testing.T) {
+	deadline := time.Now().Add(time.Minute).UTC()
+	t.Setenv(MaxOutputBytesEnv, "64")
+	t.Setenv(MaxInputBytesEnv, "4096")
+	t.Setenv(DeadlineEnv, deadline.Format(time.RFC3339Nano))
+
+	b := Budget()
+	if b.MaxOutputBytes != 64 || b.MaxInputBytes != 4096 || !b.Deadline.Equal(deadline) {
+		t.Fatalf("got %+v", b)
+	}
+	if left, ok := b.Remaining(); !ok || left <= 0 || left > time.Minute {
+		t.Fatalf("Remaining = %v, %v", left, ok)
+	}
+
+	// {"success":true,"output":""} is 28 bytes of envelope.
+	if !b.Fits(OK(strings.Repeat("x", 64-28), nil)) {
+		t.Fatal("result of exactly MaxOutputBytes should fit")
+	}
+	if b.Fits(OK(strings.Repeat("x", 64-27), nil)) {
+		t.Fatal("result one byte over MaxOutputBytes should not fit")
+	}
+
+	t.Setenv(DeadlineEnv, time.Now().Add(-time.Second).Format(time.RFC3339Nano))
+	if left, ok := Budget().Remaining(); !ok || left != 0 {
+		t.Fatalf("past deadline: Remaining = %v, %v; want 0, true", left, ok)
+	}
+}
@@ -0,0 +1,163 @@
+package skill
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// CompareOpt adjusts how ResultEqual and AssertResult compare results.
+type CompareOpt func(*compareConfig)
+
+type compareConfig struct {
+	tolerance float64
+	ignore    []string
+}
+
+// FloatTolerance treats two numbers anywhere in the results as equal when
+// they differ by at most eps, e.g. a score computed in floating point.
+func FloatTolerance(eps float64) CompareOpt {
+	return func(c *compareConfig) { c.tolerance = eps }
+}
+
+// IgnoreFields leaves the named fields out of the comparison. A name is a
+// JSON key path with dots, such as "meta" or "data.elapsed_ms".
+func IgnoreFields(paths ...string) CompareOpt {
+	return func(c *compareConfig) { c.ignore = append(c.ignore, paths...) }
+}
+
+// ResultEqual reports whether a and b are the same result as a host would
+// read them: both are compared in their JSON form, so Data holding a struct
+// equals Data holding the map it marshals to, and 1 equals 1.0. A field
+// holding its zero value, such as an Error of "" or an empty Output, equals
+// one left unset.
+func ResultEqual(a, b ToolResult, opts ...CompareOpt) bool {
+	return len(resultDiff(a, b, opts)) == 0
+}
+
+// TB is the part of testing.TB that AssertResult uses, so that skills need
+// not link the testing package.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// AssertResult fails t, listing each differing field, unless got and want
+// are ResultEqual:
+//
+//	skill.AssertResult(t, count(args), skill.OK("2 words", map[string]any{"words": 2}))
+func AssertResult(t TB, got, want ToolResult, opts ...CompareOpt) {
+	t.Helper()
+	if diff := resultDiff(got, want, opts); len(diff) > 0 {
+		t.Errorf("result differs from want:\n  %s", strings.Join(diff, "\n  "))
+	}
+}
+
+// resultDiff lists how got departs from want, one "path: ..." line per
+// field.
+func resultDiff(got, want ToolResult, opts []CompareOpt) []string {
+	var cfg compareConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	g, err := resultJSON(got, cfg)
+	if err != nil {
+		return []string{fmt.Sprintf("got does not marshal: %v", err)}
+	}
+	w, err := resultJSON(want, cfg)
+	if err != nil {
+		return []string{fmt.Sprintf("want does not marshal: %v", err)}
+	}
+	var diff []string
+	equalDiff("", w, g, cfg.tolerance, &diff)
+	return diff
+}
+
+// resultJSON is r as decoded JSON, without its zero-valued top-level fields
+// and the fields cfg ignores.
+func resultJSON(r ToolResult, cfg compareConfig) (map[string]any, error) {
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range fields {
+		if value == nil || value == "" || value == false || value == 0.0 {
+			delete(fields, key)
+		}
+	}
+	for _, path := range cfg.ignore {
+		obj := fields
+		keys := strings.Split(path, ".")
+		for _, key := range keys[:len(keys)-1] {
+			obj, _ = obj[key].(map[string]any)
+		}
+		delete(obj, keys[len(keys)-1])
+	}
+	return fields, nil
+}
+
+// equalDiff appends to diff a "path: ..." line for each value that differs
+// between want and got, in either direction. Objects are compared key by
+// key and arrays of equal length element by element; numbers within
+// tolerance are equal.
+func equalDiff(path string, want, got any, tolerance float64, diff *[]string) {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(w)+len(g))
+		for key := range w {
+			keys = append(keys, key)
+		}
+		for key := range g {
+			if _, ok := w[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			field := key
+			if path != "" {
+				field = path + "." + key
+			}
+			wantValue, inWant := w[key]
+			gotValue, inGot := g[key]
+			switch {
+			case !inGot:
+				*diff = append(*diff, fmt.Sprintf("%s: expected %s, missing", field, compact(wantValue)))
+			case !inWant:
+				*diff = append(*diff, fmt.Sprintf("%s: unexpected %s", field, compact(gotValue)))
+			default:
+				equalDiff(field, wantValue, gotValue, tolerance, diff)
+			}
+		}
+		return
+	case []any:
+		g, ok := got.([]any)
+		if !ok || len(g) != len(w) {
+			break
+		}
+		for i := range w {
+			equalDiff(fmt.Sprintf("%s[%d]", path, i), w[i], g[i], tolerance, diff)
+		}
+		return
+	case float64:
+		if g, ok := got.(float64); ok && math.Abs(w-g) <= tolerance {
+			return
+		}
+	}
+	if w, g := compact(want), compact(got); w != g {
+		if path == "" {
+			path = "result"
+		}
+		*diff = append(*diff, fmt.Sprintf("%s: expected %s, got %s", path, w, g))
+	}
+}
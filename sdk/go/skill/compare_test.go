@@ -0,0 +1,63 @@
+package skill
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestResultEqualComparesAsJSON(t *testing.T) {
+	type counts struct {
+		Words int     `json:"words"`
+		Score float64 `json:"score"`
+	}
+	empty := ""
+	got := OK("2 words", counts{Words: 2, Score: 0.30000000000000004})
+	got.Error = &empty
+	want := OK("2 words", map[string]any{"words": 2.0, "score": 0.3})
+	if !ResultEqual(got, want, FloatTolerance(1e-9)) {
+		t.Fatalf("struct and map data, nil and empty error should be equal: %v", resultDiff(got, want, nil))
+	}
+	if ResultEqual(got, want) {
+		t.Fatal("scores differ without a tolerance")
+	}
+
+	got.Meta = &ResultMeta{TraceID: "abc"}
+	if ResultEqual(got, want, FloatTolerance(1e-9)) || !ResultEqual(got, want, FloatTolerance(1e-9), IgnoreFields("meta")) {
+		t.Fatal("meta should count unless ignored")
+	}
+	if !ResultEqual(OK("", map[string]any{"n": 1, "at": "now"}), OK("", map[string]any{"n": 1}), IgnoreFields("data.at", "data.gone.x")) {
+		t.Fatal("a nested ignored field should not count")
+	}
+}
+
+// recorder is a TB that keeps what AssertResult reports.
+type recorder struct{ errs []string }
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func TestAssertResultListsEachDifference(t *testing.T) {
+	var r recorder
+	got := OK("3 words", map[string]any{"words": 3, "tokens": []string{"a", "b", "c"}}).Warn("odd")
+	want := OK("2 words", map[string]any{"words": 2, "tokens": []string{"a", "x", "c"}, "lines": 1})
+	AssertResult(&r, got, want)
+	wantErr := "result differs from want:\n" +
+		"  data.lines: expected 1, missing\n" +
+		"  data.tokens[1]: expected \"x\", got \"b\"\n" +
+		"  data.words: expected 2, got 3\n" +
+		"  output: expected \"2 words\", got \"3 words\"\n" +
+		"  warnings: unexpected [\"odd\"]"
+	if len(r.errs) != 1 || r.errs[0] != wantErr {
+		t.Fatalf("got %q, want %q", r.errs, wantErr)
+	}
+
+	r.errs = nil
+	AssertResult(&r, FailCode(CodeInvalidInput, "bad"), FailCode(CodeInvalidInput, "bad"))
+	if len(r.errs) != 0 {
+		t.Fatalf("equal results reported %q", strings.Join(r.errs, "; "))
+	}
+}
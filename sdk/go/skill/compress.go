@@ -0,0 +1,82 @@
+package skill
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+)
+
+// CompressionEnv is set to CompressionGzip by hosts that exchange gzip
+// streams with the skill: stdin holds the args gzip-compressed, and Run
+// compresses everything it writes to stdout. The handler sees neither.
+// Hosts set it only for skills whose manifest sets "compression": "gzip";
+// without it stdin and stdout are plain JSON.
+const CompressionEnv = "ZEROCLAW_COMPRESSION"
+
+// CompressionGzip is the CompressionEnv value, and manifest "compression",
+// for gzip streams.
+const CompressionGzip = "gzip"
+
+// InputEncodingEnv is set to CompressionGzip by hosts that send large args
+// gzip-compressed but read the result as plain JSON. Run also inflates stdin
+// that starts with gzip's magic bytes without it, which JSON never does, so
+// a host may compress the args of any SDK-built skill (see
+// runtime.ExecuteCompressed).
+const InputEncodingEnv = "ZEROCLAW_INPUT_ENCODING"
+
+// maxInflatedInput caps what compressed args may inflate to when the host
+// set no MaxInputBytesEnv, so a decompression bomb fails as too large
+// instead of exhausting memory.
+const maxInflatedInput = 256 << 20
+
+// gzipMagic starts every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// compressStreams swaps r's stdin and stdout for gzip streams when the host
+// asks for them. The returned func ends the stdout stream; call it once the
+// last result is written and before exiting.
+func compressStreams(r *runner) func() {
+	if os.Getenv(CompressionEnv) != CompressionGzip {
+		return func() {}
+	}
+	zw := gzip.NewWriter(r.stdout)
+	r.stdin, r.stdout = &gunzipReader{src: r.stdin}, zw
+	return func() { zw.Close() }
+}
+
+// gunzipReader inflates src, reading the gzip header on first use so a bad
+// stream surfaces as a read error rather than before the request is served.
+type gunzipReader struct {
+	src io.Reader
+	zr  *gzip.Reader
+}
+
+func (g *gunzipReader) Read(p []byte) (int, error) {
+	if g.zr == nil {
+		zr, err := gzip.NewReader(g.src)
+		if err != nil {
+			return 0, err
+		}
+		g.zr = zr
+	}
+	return g.zr.Read(p)
+}
+
+// inflateInput swaps r's stdin for the stream it inflates to when the host
+// said the args are compressed or they start with gzipMagic. r.maxInput then
+// bounds the inflated bytes, not the compressed ones.
+func inflateInput(r *runner) {
+	in := bufio.NewReader(r.stdin)
+	r.stdin = in
+	if os.Getenv(InputEncodingEnv) != CompressionGzip {
+		if magic, _ := in.Peek(len(gzipMagic)); !bytes.Equal(magic, gzipMagic) {
+			return
+		}
+	}
+	r.stdin = &gunzipReader{src: in}
+	if r.maxInput == 0 {
+		r.maxInput = maxInflatedInput
+	}
+}
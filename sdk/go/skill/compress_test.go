@@ -0,0 +1,122 @@
+package skill
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+)
+
+// serveOnce serves input through compressStreams as serve does and returns
+// what was written to stdout.
+func serveOnce(input []byte) []byte {
+	var out bytes.Buffer
+	r := runner{stdin: bytes.NewReader(input), stdout: &out}
+	closeStdout := compressStreams(&r)
+	write(&r, handle(&r, single(func(args echoArgs) ToolResult {
+		return OK(strings.ToUpper(args.Text), nil)
+	})))
+	closeStdout()
+	return out.Bytes()
+}
+
+func TestCompressedStreamsMatchPlain(t *testing.T) {
+	args := []byte(`{"text":"hello world"}`)
+	plain := serveOnce(args)
+
+	t.Setenv(CompressionEnv, CompressionGzip)
+	var packed bytes.Buffer
+	zw := gzip.NewWriter(&packed)
+	zw.Write(args)
+	zw.Close()
+	zr, err := gzip.NewReader(bytes.NewReader(serveOnce(packed.Bytes())))
+	if err != nil {
+		t.Fatalf("stdout is not gzip: %v", err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Fatalf("compressed result %s, plain %s", got, plain)
+	}
+}
+
+func TestCompressedStdinMustBeGzip(t *testing.T) {
+	t.Setenv(CompressionEnv, CompressionGzip)
+	zr, err := gzip.NewReader(bytes.NewReader(serveOnce([]byte(`{"text":"hi"}`))))
+	if err != nil {
+		t.Fatalf("stdout is not gzip: %v", err)
+	}
+	got, _ := io.ReadAll(zr)
+	if !bytes.Contains(got, []byte(`"error_code":"internal"`)) || !bytes.Contains(got, []byte("gzip")) {
+		t.Fatalf("expected a read failure naming gzip, got %s", got)
+	}
+}
+
+// serveInflated serves input through inflateInput as serve does, with
+// maxInput as the host's MaxInputBytesEnv, and returns the result.
+func serveInflated(input []byte, maxInput int) ToolResult {
+	r := runner{stdin: bytes.NewReader(input), maxInput: maxInput}
+	inflateInput(&r)
+	return handle(&r, single(func(args echoArgs) ToolResult {
+		return OK(strings.ToUpper(args.Text), nil)
+	}))
+}
+
+func TestGzipArgsAreInflated(t *testing.T) {
+	args := []byte(`{"text":"hello world"}`)
+	packed := gzipped(args)
+	if res := serveInflated(args, 0); !res.Success || res.Output != "HELLO WORLD" {
+		t.Fatalf("plain args: %+v", res)
+	}
+	if res := serveInflated(packed, 0); !res.Success || res.Output != "HELLO WORLD" {
+		t.Fatalf("gzip args by magic bytes: %+v", res)
+	}
+
+	t.Setenv(InputEncodingEnv, CompressionGzip)
+	if res := serveInflated(packed, 0); !res.Success || res.Output != "HELLO WORLD" {
+		t.Fatalf("gzip args by %s: %+v", InputEncodingEnv, res)
+	}
+	if res := serveInflated(args, 0); res.ErrorCode != CodeInternal || !strings.Contains(*res.Error, "gzip") {
+		t.Fatalf("plain args declared gzip: %+v", res)
+	}
+}
+
+func TestHandleInflatesGzipArgs(t *testing.T) {
+	upper := func(args echoArgs) ToolResult { return OK(strings.ToUpper(args.Text), nil) }
+	if res := Handle(upper, gzipped([]byte(`{"text":"hello world"}`))); !res.Success || res.Output != "HELLO WORLD" {
+		t.Fatalf("Handle with gzip args: %+v", res)
+	}
+
+	t.Setenv(MaxInputBytesEnv, "1024")
+	bomb := gzipped([]byte(`{"text":"` + strings.Repeat(" ", 1<<20) + `"}`))
+	if res := Handle(upper, bomb); res.ErrorCode != CodeInvalidInput {
+		t.Fatalf("Handle with a bomb: got %+v, want the input limit", res)
+	}
+}
+
+func TestDecompressionBombTripsInputLimit(t *testing.T) {
+	bomb := gzipped([]byte(`{"text":"` + strings.Repeat(" ", 64<<20) + `"}`))
+	if len(bomb) > 1<<20 {
+		t.Fatalf("bomb is %d bytes compressed", len(bomb))
+	}
+	res := serveInflated(bomb, 1<<20)
+	if res.ErrorCode != CodeInvalidInput || *res.Error != "input exceeds 1048576 bytes" {
+		t.Fatalf("got %+v, want the input limit", res)
+	}
+
+	r := runner{stdin: bytes.NewReader(bomb)}
+	if inflateInput(&r); r.maxInput != maxInflatedInput {
+		t.Fatalf("no host limit: maxInput %d, want %d", r.maxInput, maxInflatedInput)
+	}
+}
+
+func gzipped(b []byte) []byte {
+	var packed bytes.Buffer
+	zw := gzip.NewWriter(&packed)
+	zw.Write(b)
+	zw.Close()
+	return packed.Bytes()
+}
@@ -0,0 +1,6 @@
+// Package skill holds the shared guest-side helpers for ZeroClaw WASI skills
+// written in Go.
+//
+// It only depends on the standard library so skills keep building with
+// `tinygo build -target=wasip1`.
+package skill
@@ -0,0 +1,52 @@
+package skill
+
+import "context"
+
+// Emitter writes partial results for a streaming skill ahead of its final
+// one, so a host can show or act on them before the handler returns:
+//
+//	{"success":true,"output":"a.txt: 12 words","id":"a.txt","seq":1}
+//	{"success":true,"output":"b.txt: 30 words","id":"b.txt","seq":2}
+//	{"success":true,"output":"42 words","seq":3,"final":true}
+//
+// Lines get Seq in the order they are written, so handlers may emit from
+// several goroutines. Get one with EmitterFrom.
+type Emitter struct {
+	r *runner
+}
+
+// EmitterFrom returns the Emitter for ctx, which must come from a RunContext
+// skill. Without Meta.Streaming, or for any other context, the Emitter
+// discards everything, so handlers can emit unconditionally.
+func EmitterFrom(ctx context.Context) *Emitter {
+	r, _ := ctx.Value(progressKey{}).(*runner)
+	return &Emitter{r: r}
+}
+
+// Emit writes res as the next partial result and returns the Seq it was
+// given, or 0 when e discards it. res.Seq and res.Final are overwritten.
+func (e *Emitter) Emit(res ToolResult) int {
+	if e == nil || e.r == nil || !e.r.meta.Streaming {
+		return 0
+	}
+	res.Final = false
+	return writeSeq(e.r, compressArtifacts(res))
+}
+
+// writeFinal writes the handler's result as the Final line of a streaming
+// skill, after every partial.
+func writeFinal(r *runner, res ToolResult) {
+	res.Final = true
+	writeSeq(r, res)
+}
+
+// writeSeq numbers res after the last result line r wrote and writes it.
+func writeSeq(r *runner, res ToolResult) int {
+	r.streamMu.Lock()
+	defer r.streamMu.Unlock()
+	r.seq++
+	res.Seq = r.seq
+	write(r, res)
+	r.stdout.Write([]byte("\n"))
+	return res.Seq
+}
@@ -0,0 +1,82 @@
+package skill
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestEmitterNumbersPartialsBeforeFinal(t *testing.T) {
+	var out strings.Builder
+	r := runner{stdin: strings.NewReader(`{"text":"done"}`), stdout: &out}
+	WithMeta(Meta{Streaming: true})(&r)
+	writeFinal(&r, handle(&r, withContext(func(ctx context.Context, args echoArgs) ToolResult {
+		emit := EmitterFrom(ctx)
+		part := OK("first", nil)
+		part.ID = "a"
+		if seq := emit.Emit(part); seq != 1 {
+			t.Errorf("first partial got seq %d, want 1", seq)
+		}
+		Report(ctx, Progress{Percent: 50})
+		emit.Emit(ToolResult{Success: true, Output: "second", Seq: 9, Final: true})
+		return OK(args.Text, nil)
+	})))
+
+	want := `{"success":true,"output":"first","seq":1,"id":"a"}` + "\n" +
+		`{"progress":{"percent":50}}` + "\n" +
+		`{"success":true,"output":"second","seq":2}` + "\n" +
+		`{"success":true,"output":"done","seq":3,"final":true}` + "\n"
+	if out.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestEmitterSeqIsUniqueAcrossGoroutines(t *testing.T) {
+	var out strings.Builder
+	r := runner{stdin: strings.NewReader(`{}`), stdout: &out}
+	WithMeta(Meta{Streaming: true})(&r)
+	writeFinal(&r, handle(&r, withContext(func(ctx context.Context, args echoArgs) ToolResult {
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				EmitterFrom(ctx).Emit(OK("part", nil))
+			}()
+		}
+		wg.Wait()
+		return OK("done", nil)
+	})))
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 21 {
+		t.Fatalf("got %d lines, want 20 partials and a final result", len(lines))
+	}
+	for i, line := range lines {
+		var res ToolResult
+		if err := json.Unmarshal([]byte(line), &res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Seq != i+1 || res.Final != (i == 20) {
+			t.Fatalf("line %d: got seq %d final %v: %s", i+1, res.Seq, res.Final, line)
+		}
+	}
+}
+
+func TestEmitterDiscardsWithoutStreaming(t *testing.T) {
+	var out strings.Builder
+	r := runner{stdin: strings.NewReader(`{}`), stdout: &out}
+	handle(&r, withContext(func(ctx context.Context, args echoArgs) ToolResult {
+		if seq := EmitterFrom(ctx).Emit(OK("part", nil)); seq != 0 {
+			t.Errorf("got seq %d without streaming, want 0", seq)
+		}
+		return OK("done", nil)
+	}))
+	EmitterFrom(context.Background()).Emit(OK("part", nil))
+
+	if out.Len() != 0 {
+		t.Fatalf("expected no partials, got %q", out.String())
+	}
+}
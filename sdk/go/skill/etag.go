@@ -0,0 +1,42 @@
+package skill
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// IfNoneMatchField is the request field through which a host that still
+// holds an earlier result names its ETag, e.g.
+// {"city":"Oslo","if_none_match":"v42"}. It is named for HTTP's
+// If-None-Match, without the underscore of TraceField, but is reserved the
+// same way: it sits beside the args, StrictFields accepts it, and an args
+// struct should not declare it.
+const IfNoneMatchField = "if_none_match"
+
+// IfNoneMatch returns the ETag the host sent in IfNoneMatchField for the
+// request ctx was made for, or "" when it sent none. ctx must come from a
+// RunContext handler. A handler whose data has not changed since that ETag
+// returns NotModified(etag) instead of its full result.
+func IfNoneMatch(ctx context.Context) string {
+	r, _ := ctx.Value(progressKey{}).(*runner)
+	if r == nil {
+		return ""
+	}
+	return r.ifNoneMatch
+}
+
+// NotModified returns a successful result that tells the host its cached
+// copy of the result tagged etag is still valid, so it can reuse that copy
+// instead of processing the call again.
+func NotModified(etag string) ToolResult {
+	return ToolResult{Success: true, NotModified: true, ETag: etag}
+}
+
+// requestIfNoneMatch reads data's IfNoneMatchField.
+func requestIfNoneMatch(data []byte) string {
+	var req struct {
+		IfNoneMatch string `json:"if_none_match"`
+	}
+	json.Unmarshal(data, &req)
+	return req.IfNoneMatch
+}
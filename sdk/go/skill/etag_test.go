@@ -0,0 +1,40 @@
+package skill
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// fetchETag is the ETag of the data the forecast handler serves.
+const fetchETag = `"v42"`
+
+func forecast(ctx context.Context, args echoArgs) ToolResult {
+	if IfNoneMatch(ctx) == fetchETag {
+		return NotModified(fetchETag)
+	}
+	res := OK("sunny in "+args.Text, nil)
+	res.ETag = fetchETag
+	return res
+}
+
+func TestIfNoneMatchShortCircuitsWithNotModified(t *testing.T) {
+	var out strings.Builder
+	r := runner{stdin: strings.NewReader(`{"text":"Oslo"}`), stdout: &out}
+	write(&r, handle(&r, withContext(forecast)))
+	if want := `{"success":true,"output":"sunny in Oslo","etag":"\"v42\""}`; out.String() != want {
+		t.Fatalf("first call: got %s, want %s", out.String(), want)
+	}
+
+	out.Reset()
+	r = runner{stdin: strings.NewReader(`{"text":"Oslo","if_none_match":"\"v42\""}`), stdout: &out, strictFields: true}
+	write(&r, handle(&r, withContext(forecast)))
+	if want := `{"success":true,"output":"","etag":"\"v42\"","not_modified":true}`; out.String() != want {
+		t.Fatalf("matching ETag: got %s, want %s", out.String(), want)
+	}
+
+	r = runner{stdin: strings.NewReader(`{"text":"Oslo","if_none_match":"\"v41\""}`)}
+	if res := handle(&r, withContext(forecast)); res.NotModified || res.Output != "sunny in Oslo" {
+		t.Fatalf("a stale ETag should get the full result, got %+v", res)
+	}
+}
@@ -0,0 +1,29 @@
+package skill
+
+import "os"
+
+// Exit statuses the host runtime understands. Any other non-zero status is
+// treated as an internal trap and the skill's stdout is ignored.
+const (
+	// ExitOK means stdout holds the ToolResult.
+	ExitOK = 0
+	// ExitInvalidInput means the input was rejected; stdout still holds a
+	// failed ToolResult describing why.
+	ExitInvalidInput = 2
+	// ExitPanic means the handler panicked; stdout still holds a failed
+	// ToolResult with CodeInternal describing the panic.
+	ExitPanic = 3
+)
+
+// Exit ends the skill with code. Write the ToolResult to stdout first when
+// using ExitOK or ExitInvalidInput.
+func Exit(code int) {
+	os.Exit(code)
+}
+
+// ExitOnInvalidInput makes Run exit with ExitInvalidInput, after writing the
+// result, whenever it carries CodeInvalidInput. Without it Run always exits
+// with ExitOK once the result is written.
+func ExitOnInvalidInput() Option {
+	return func(r *runner) { r.exitOnInvalid = true }
+}
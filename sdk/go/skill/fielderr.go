@@ -0,0 +1,173 @@
+package skill
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FieldError locates one problem in a request's args.
+type FieldError struct {
+	// Path is the JSON Pointer (RFC 6901) to the offending value, e.g.
+	// "/fields/body" or "/options/wpm"; "" is the args object itself.
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	// Expected is the JSON Schema type the value must have, e.g. "integer",
+	// for a value of the wrong type.
+	Expected string `json:"expected,omitempty"`
+}
+
+// FailFields returns an invalid-input result listing errs, for handlers that
+// validate nested args themselves. The message joins them as "path: message".
+func FailFields(errs ...FieldError) ToolResult {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Path + ": " + e.Message
+		if e.Path == "" {
+			msgs[i] = e.Message
+		}
+	}
+	res := FailCode(CodeInvalidInput, strings.Join(msgs, "; "))
+	res.FieldErrors = errs
+	return res
+}
+
+// decodeFieldError locates err, returned by json.Unmarshal for data, as a
+// FieldError. ok is false for errors that carry no input offset.
+func decodeFieldError(data []byte, err error) (fe FieldError, ok bool) {
+	var syntax *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntax):
+		return FieldError{Path: pointerAt(data, syntax.Offset), Message: syntax.Error()}, true
+	case errors.As(err, &typ) && errors.Unwrap(typ) != nil:
+		// The value had the right JSON type but did not decode, such as
+		// malformed base64 for a []byte; the cause says why.
+		return FieldError{Path: pointerAt(data, typ.Offset), Message: typ.Error()}, true
+	case errors.As(err, &typ):
+		expected, got := jsonType(typ.Type), gotValue(typ.Value)
+		msg := "expected " + expected + ", got " + got
+		if lo, hi, ok := intRange(typ.Type); ok && isInteger(got) {
+			msg = fmt.Sprintf("must be between %s and %s, got %s", lo, hi, got)
+		}
+		return FieldError{Path: pointerAt(data, typ.Offset), Message: msg, Expected: expected}, true
+	}
+	return FieldError{}, false
+}
+
+// jsonType names the JSON Schema type of values t decodes, as SchemaFor
+// describes it, or t's Go type when the schema has none.
+func jsonType(t reflect.Type) string {
+	if name, ok := schemaOf(t)["type"].(string); ok {
+		return name
+	}
+	return t.String()
+}
+
+// intRange returns the smallest and largest values of t, for an integer type.
+func intRange(t reflect.Type) (lo, hi string, ok bool) {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		max := int64(1)<<(t.Bits()-1) - 1
+		return strconv.FormatInt(-max-1, 10), strconv.FormatInt(max, 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "0", strconv.FormatUint(math.MaxUint64>>(64-t.Bits()), 10), true
+	}
+	return "", "", false
+}
+
+// isInteger reports whether s is a whole number, however large.
+func isInteger(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil || errors.Is(err, strconv.ErrRange)
+}
+
+// gotValue names the JSON value an UnmarshalTypeError saw: its type, in JSON
+// Schema terms, or for a number that does not fit, the number itself.
+func gotValue(value string) string {
+	if n, ok := strings.CutPrefix(value, "number "); ok {
+		return n
+	}
+	if value == "bool" {
+		return "boolean"
+	}
+	return value
+}
+
+// jsonFrame is one open object or array while pointerAt walks the input.
+type jsonFrame struct {
+	object  bool
+	key     string // object: the member being read
+	wantKey bool   // object: the next token is a key
+	index   int    // array: the element being read
+}
+
+// pointerAt returns the JSON Pointer of the value that data's decoder was
+// reading when it reached offset, the position json errors report: the end
+// of a mistyped scalar, the start of a mistyped object or array, or wherever
+// the syntax broke.
+func pointerAt(data []byte, offset int64) string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var stack []*jsonFrame
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return pointer(stack)
+		}
+		reached := dec.InputOffset() >= offset
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		if top != nil && top.object && top.wantKey {
+			if key, isKey := tok.(string); isKey {
+				top.key, top.wantKey = key, false
+				if reached {
+					return pointer(stack)
+				}
+				continue
+			}
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			if reached {
+				return pointer(stack)
+			}
+			stack = append(stack, &jsonFrame{object: tok == json.Delim('{'), wantKey: true})
+			continue
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 {
+				top = stack[len(stack)-1]
+			} else {
+				top = nil
+			}
+		}
+		if reached {
+			return pointer(stack)
+		}
+		if top != nil {
+			top.wantKey = true
+			top.index++
+		}
+	}
+}
+
+// pointer renders the path to the value stack is reading.
+func pointer(stack []*jsonFrame) string {
+	var b strings.Builder
+	for _, f := range stack {
+		switch {
+		case !f.object:
+			b.WriteString("/" + strconv.Itoa(f.index))
+		case !f.wantKey:
+			b.WriteString("/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(f.key))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package skill
+
+import (
+	"strings"
+	"testing"
+)
+
+type nestedArgs struct {
+	Fields  map[string]string `json:"fields"`
+	Options struct {
+		WPM   int      `json:"wpm"`
+		Langs []string `json:"langs"`
+	} `json:"options"`
+}
+
+func TestDecodeReportsNestedFieldPath(t *testing.T) {
+	for input, want := range map[string]string{
+		`{"options":{"wpm":"fast"}}`:            "/options/wpm",
+		`{"fields":{"title":"t","body":7}}`:     "/fields/body",
+		`{"fields":{"a/b~c":true}}`:             "/fields/a~1b~0c",
+		`{"options":{"langs":["en",3]}}`:        "/options/langs/1",
+		`{"options":{"wpm":1},"fields":[]}`:     "/fields",
+		`{"options":{"langs":["en"],"wpm":{}}}`: "/options/wpm",
+		`{"options":{"wpm":1,"langs":["en",}}`:  "/options/langs/1",
+		`{"fields":{"body":}}`:                  "/fields/body",
+		`[1]`:                                   "",
+	} {
+		r := runner{}
+		res := decode(&r, []byte(input), func(nestedArgs) ToolResult { return OK("", nil) })
+		if res.ErrorCode != CodeInvalidInput || len(res.FieldErrors) != 1 {
+			t.Errorf("%s: expected one field error, got %+v", input, res)
+			continue
+		}
+		if got := res.FieldErrors[0].Path; got != want {
+			t.Errorf("%s: path %q, want %q", input, got, want)
+		}
+		if want != "" && !strings.Contains(*res.Error, "(at "+want+")") {
+			t.Errorf("%s: message should name the path: %q", input, *res.Error)
+		}
+	}
+}
+
+func TestDecodeNamesExpectedType(t *testing.T) {
+	for _, tc := range []struct{ input, path, expected, msg string }{
+		{`{"options":{"wpm":"fast"}}`, "/options/wpm", "integer", "invalid input JSON: expected integer, got string (at /options/wpm)"},
+		{`{"options":{"wpm":1.5}}`, "/options/wpm", "integer", "invalid input JSON: expected integer, got 1.5 (at /options/wpm)"},
+		{`{"options":{"wpm":9999999999999999999}}`, "/options/wpm", "integer", "invalid input JSON: must be between -9223372036854775808 and 9223372036854775807, got 9999999999999999999 (at /options/wpm)"},
+		{`{"options":{"langs":[true]}}`, "/options/langs/0", "string", "invalid input JSON: expected string, got boolean (at /options/langs/0)"},
+		{`[1]`, "", "object", "invalid input JSON: expected object, got array"},
+	} {
+		r := runner{}
+		res := decode(&r, []byte(tc.input), func(nestedArgs) ToolResult { return OK("", nil) })
+		if res.ErrorCode != CodeInvalidInput || len(res.FieldErrors) != 1 || *res.Error != tc.msg {
+			t.Errorf("%s: unexpected result %+v", tc.input, res)
+			continue
+		}
+		if fe := res.FieldErrors[0]; fe.Path != tc.path || fe.Expected != tc.expected {
+			t.Errorf("%s: field error %+v, want path %q expecting %s", tc.input, fe, tc.path, tc.expected)
+		}
+	}
+}
+
+func TestDecodeKeepsTheCauseOfABadValue(t *testing.T) {
+	type blobArgs struct {
+		Data []byte `json:"data"`
+	}
+	r := runner{}
+	res := decode(&r, []byte(`{"data":"not base64!"}`), func(blobArgs) ToolResult { return OK("", nil) })
+	if res.ErrorCode != CodeInvalidInput || !strings.Contains(*res.Error, "illegal base64") {
+		t.Fatalf("want the base64 error, got %+v", res)
+	}
+	// Go 1.27 reports it as a type error with an offset, older releases bare.
+	for _, fe := range res.FieldErrors {
+		if fe.Path != "/data" || fe.Expected != "" {
+			t.Fatalf("unexpected field error %+v", fe)
+		}
+	}
+}
+
+func TestFailFields(t *testing.T) {
+	res := FailFields(FieldError{Path: "/options/wpm", Message: "must be positive"}, FieldError{Message: "too many fields"})
+	if res.ErrorCode != CodeInvalidInput || *res.Error != "/options/wpm: must be positive; too many fields" || len(res.FieldErrors) != 2 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
@@ -0,0 +1,131 @@
+package skill
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// ExtraTag is the struct tag of an args field that collects the fields the
+// host sent but the struct does not declare:
+//
+//	type Args struct {
+//		Text  string                     `json:"text"`
+//		Extra map[string]json.RawMessage `json:"-,extra"`
+//	}
+//
+// The field must have type map[string]json.RawMessage and sit directly in the
+// args struct; it stays nil when there is nothing extra. It is left out of
+// SchemaFor.
+const ExtraTag = "-,extra"
+
+// StrictFields makes Run reject args with a field A does not declare, with
+// CodeInvalidInput naming the field, instead of dropping it. The reserved
+// request fields, TraceField, SeedField and IfNoneMatchField, are always
+// accepted. An args struct with an ExtraTag field still collects its own
+// unknown fields; nested structs are checked either way.
+func StrictFields() Option {
+	return func(r *runner) { r.strictFields = true }
+}
+
+var rawMessageMap = reflect.TypeOf(map[string]json.RawMessage(nil))
+
+// reservedFields are the request fields a host may add beside any args.
+var reservedFields = []string{TraceField, SeedField, IfNoneMatchField}
+
+// withoutReserved returns the JSON object data without its reservedFields,
+// so StrictFields does not reject them. Anything else is returned as is.
+func withoutReserved(data []byte) []byte {
+	var members map[string]json.RawMessage
+	if json.Unmarshal(data, &members) != nil {
+		return data
+	}
+	found := false
+	for _, name := range reservedFields {
+		if _, ok := members[name]; ok {
+			delete(members, name)
+			found = true
+		}
+	}
+	if !found {
+		return data
+	}
+	out, err := json.Marshal(members)
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// extraField returns the index of t's ExtraTag field, or -1.
+func extraField(t reflect.Type) int {
+	if t.Kind() != reflect.Struct {
+		return -1
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Tag.Get("json") == ExtraTag && f.Type == rawMessageMap && f.IsExported() {
+			return i
+		}
+	}
+	return -1
+}
+
+// collectExtra fills the ExtraTag field of the struct v points to with the
+// members of data that none of its fields take. Names match the way
+// encoding/json matches them: exactly or, failing that, case-insensitively.
+func collectExtra(data []byte, v reflect.Value) {
+	s := v.Elem()
+	i := extraField(s.Type())
+	if i < 0 {
+		return
+	}
+	s.Field(i).Set(reflect.Zero(rawMessageMap))
+	var members map[string]json.RawMessage
+	if json.Unmarshal(data, &members) != nil {
+		return
+	}
+	known := fieldNames(s.Type(), nil)
+	extra := map[string]json.RawMessage{}
+outer:
+	for name, raw := range members {
+		for _, k := range known {
+			if strings.EqualFold(name, k) {
+				continue outer
+			}
+		}
+		extra[name] = raw
+	}
+	if len(extra) > 0 {
+		s.Field(i).Set(reflect.ValueOf(extra))
+	}
+}
+
+// fieldNames appends the JSON names of t's fields to names, including those
+// promoted from embedded structs that SchemaFor lists.
+func fieldNames(t reflect.Type, names []string) []string {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			if !unsettable(f) {
+				names = fieldNames(ft, names)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
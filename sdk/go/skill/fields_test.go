@@ -0,0 +1,68 @@
+package skill
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type Paging struct {
+	Limit int `json:"limit"`
+}
+
+type extraArgs struct {
+	Text string `json:"text"`
+	Paging
+	Extra map[string]json.RawMessage `json:"-,extra"`
+}
+
+func decodeWith[A any](input string, opts ...Option) (A, ToolResult) {
+	r := runner{}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	var got A
+	res := decode(&r, []byte(input), func(args A) ToolResult {
+		got = args
+		return OK("", nil)
+	})
+	return got, res
+}
+
+func TestUnknownFieldsAreDroppedByDefault(t *testing.T) {
+	got, res := decodeWith[echoArgs](`{"text":"hi","locale":"vi"}`)
+	if !res.Success || got.Text != "hi" {
+		t.Fatalf("unexpected result: %+v %+v", got, res)
+	}
+}
+
+func TestStrictFieldsNamesTheUnknownField(t *testing.T) {
+	_, res := decodeWith[echoArgs](`{"text":"hi","locale":"vi"}`, StrictFields())
+	if res.Success || res.ErrorCode != CodeInvalidInput || !strings.Contains(*res.Error, `unknown field "locale"`) {
+		t.Fatalf("expected an invalid_input failure naming locale, got %+v", res)
+	}
+	if _, res := decodeWith[echoArgs](`{"text":"hi"}`, StrictFields(), UseNumber()); !res.Success {
+		t.Fatalf("declared fields should pass strict mode: %+v", res)
+	}
+}
+
+func TestExtraCollectsUndeclaredFields(t *testing.T) {
+	got, res := decodeWith[extraArgs](`{"TEXT":"hi","limit":5,"locale":"vi","since":{"days":2}}`, StrictFields())
+	if !res.Success {
+		t.Fatalf("unexpected failure: %+v", res)
+	}
+	if got.Text != "hi" || got.Limit != 5 {
+		t.Fatalf("declared fields not decoded: %+v", got)
+	}
+	if len(got.Extra) != 2 || string(got.Extra["locale"]) != `"vi"` || string(got.Extra["since"]) != `{"days":2}` {
+		t.Fatalf("unexpected extra fields: %s", got.Extra)
+	}
+
+	got, _ = decodeWith[extraArgs](`{"text":"hi"}`)
+	if got.Extra != nil {
+		t.Fatalf("Extra should stay nil without unknown fields, got %s", got.Extra)
+	}
+	if props := SchemaFor[extraArgs]()["properties"].(map[string]any); props["-"] != nil || props["Extra"] != nil {
+		t.Fatalf("Extra should be left out of the schema: %v", props)
+	}
+}
@@ -0,0 +1,110 @@
+package skill
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// PreopensEnv lists the guest directories the host preopened, separated by
+// ':'. `zeroclaw skill test --preopen` and the Go runtime set it.
+const PreopensEnv = "ZEROCLAW_PREOPENS"
+
+// ScratchEnv names a writable guest directory the host made for this
+// invocation alone and deletes once it returns. The Go runtime sets it when
+// its Config.ScratchRoot is set; it is also listed in PreopensEnv.
+const ScratchEnv = "ZEROCLAW_SCRATCH"
+
+// ScratchDir returns the invocation's scratch directory, and false when the
+// host gave none. WASI offers no /tmp, so write intermediate files here;
+// nothing written survives to the next call.
+func ScratchDir() (string, bool) {
+	dir := os.Getenv(ScratchEnv)
+	if dir == "" {
+		return "", false
+	}
+	return path.Clean(dir), true
+}
+
+// ErrOutsidePreopen is returned for paths outside every preopened directory.
+var ErrOutsidePreopen = errors.New("path is outside the preopened directories")
+
+// Preopens returns the guest directories granted by the host.
+func Preopens() []string {
+	var dirs []string
+	for _, dir := range strings.Split(os.Getenv(PreopensEnv), ":") {
+		if dir != "" {
+			dirs = append(dirs, path.Clean(dir))
+		}
+	}
+	return dirs
+}
+
+// CheckPath cleans p and verifies that it lies under a preopened directory.
+// Relative paths are resolved against the first preopen.
+func CheckPath(p string) (string, error) {
+	dirs := Preopens()
+	if len(dirs) == 0 {
+		return "", fmt.Errorf("%w: no directories were preopened (%s is empty)", ErrOutsidePreopen, PreopensEnv)
+	}
+	if !path.IsAbs(p) {
+		p = path.Join(dirs[0], p)
+	}
+	p = path.Clean(p)
+	for _, dir := range dirs {
+		if p == dir || strings.HasPrefix(p, strings.TrimSuffix(dir, "/")+"/") {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s (granted: %s)", ErrOutsidePreopen, p, strings.Join(dirs, ", "))
+}
+
+// ReadFile reads a file after checking it with CheckPath.
+func ReadFile(p string) ([]byte, error) {
+	p, err := CheckPath(p)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(p)
+}
+
+// ListFiles returns the files under dir, at any depth, whose names match
+// pattern as path.Match does; an empty pattern matches every file. dir is
+// checked with CheckPath. The paths are relative to dir, slash-separated, and
+// sorted. A subdirectory that cannot be listed is returned as if it were a
+// file, so reading it fails like any other unreadable entry.
+func ListFiles(dir, pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	dir, err := CheckPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	var walk func(rel string, entries []os.DirEntry)
+	walk = func(rel string, entries []os.DirEntry) {
+		for _, e := range entries {
+			name := path.Join(rel, e.Name())
+			if e.IsDir() {
+				if sub, err := os.ReadDir(path.Join(dir, name)); err == nil {
+					walk(name, sub)
+					continue
+				}
+			}
+			if ok, _ := path.Match(pattern, e.Name()); ok || pattern == "" {
+				files = append(files, name)
+			}
+		}
+	}
+	walk("", entries)
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,86 @@
+package skill
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadFileUnderPreopen(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("hello world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(PreopensEnv, filepath.ToSlash(dir))
+
+	for _, p := range []string{filepath.ToSlash(filepath.Join(dir, "notes.txt")), "notes.txt"} {
+		data, err := ReadFile(p)
+		if err != nil {
+			t.Fatalf("%s: %v", p, err)
+		}
+		if string(data) != "hello world" {
+			t.Fatalf("%s: read %q", p, data)
+		}
+	}
+}
+
+func TestListFilesWalksAndFilters(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{"b.txt": "two words", "a.md": "one", "sub/c.txt": "three more words"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv(PreopensEnv, filepath.ToSlash(dir))
+
+	for pattern, want := range map[string]string{"": "a.md b.txt sub/c.txt", "*.txt": "b.txt sub/c.txt", "*.go": ""} {
+		got, err := ListFiles(".", pattern)
+		if err != nil || strings.Join(got, " ") != want {
+			t.Errorf("ListFiles(%q) = %q, %v; want %q", pattern, got, err, want)
+		}
+	}
+	if _, err := ListFiles(".", "["); !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("bad pattern: got %v, want path.ErrBadPattern", err)
+	}
+	if _, err := ListFiles("/elsewhere", ""); !errors.Is(err, ErrOutsidePreopen) {
+		t.Errorf("outside the preopen: got %v, want ErrOutsidePreopen", err)
+	}
+	if _, err := ListFiles("missing", ""); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing dir: got %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestCheckPathRejectsOutsideGrant(t *testing.T) {
+	t.Setenv(PreopensEnv, "/data:/cache")
+	for _, p := range []string{"/etc/passwd", "/data/../etc/passwd", "/database/x", "../secret"} {
+		if _, err := CheckPath(p); !errors.Is(err, ErrOutsidePreopen) {
+			t.Errorf("%s: got %v, want ErrOutsidePreopen", p, err)
+		}
+	}
+	if got, err := CheckPath("/cache/a/../b.txt"); err != nil || got != "/cache/b.txt" {
+		t.Errorf("got %q, %v", got, err)
+	}
+
+	t.Setenv(PreopensEnv, "")
+	if _, err := CheckPath("/data/x"); !errors.Is(err, ErrOutsidePreopen) {
+		t.Errorf("no preopens: got %v, want ErrOutsidePreopen", err)
+	}
+}
+
+func TestScratchDir(t *testing.T) {
+	t.Setenv(ScratchEnv, "")
+	if _, ok := ScratchDir(); ok {
+		t.Fatal("no scratch dir was given")
+	}
+	t.Setenv(ScratchEnv, "/scratch/")
+	if dir, ok := ScratchDir(); !ok || dir != "/scratch" {
+		t.Fatalf("got %q, %v", dir, ok)
+	}
+}
@@ -0,0 +1,72 @@
+package skill
+
+import (
+	"errors"
+	"fmt"
+)
+
+// GetBodyLimit is how much of a response body Get keeps; Response.Length
+// still reports the full length.
+const GetBodyLimit = 1 << 20
+
+// Response is the answer to Get.
+type Response struct {
+	Status int
+	// Body holds the first GetBodyLimit bytes of the body.
+	Body []byte
+	// Length is the full body length; it exceeds len(Body) when Body was cut.
+	Length int
+}
+
+// Errors returned by Get, one per failure the host reports.
+var (
+	// ErrNetDenied means the host serves no HTTP or the manifest lacks
+	// capabilities.net.
+	ErrNetDenied = errors.New("network access denied (does manifest.json grant capabilities.net?)")
+	// ErrFetchFailed means the request could not be sent or answered.
+	ErrFetchFailed = errors.New("request failed")
+	// ErrFetchRateLimited means the host's request rate budget ran out
+	// before the deadline.
+	ErrFetchRateLimited = errors.New("request rate limited by host")
+	// ErrFetchLimit means the skill already made as many requests as the host
+	// allows per invocation.
+	ErrFetchLimit = errors.New("request limit reached")
+	// ErrBadURL means the host could not parse the URL.
+	ErrBadURL = errors.New("bad request URL")
+	// ErrFetchDeadline means the host abandoned the request at the
+	// invocation's deadline.
+	ErrFetchDeadline = errors.New("request cancelled at the invocation's deadline")
+)
+
+// Get sends a GET request for url through the host's zeroclaw_http_get
+// import. Any HTTP status is a Response; only a denied, failed, or throttled
+// request is an error. Skills calling it should declare Requires("net").
+func Get(url string) (*Response, error) {
+	if url == "" {
+		return nil, fmt.Errorf("%w: empty URL", ErrBadURL)
+	}
+	body := make([]byte, GetBodyLimit)
+	status, n := httpGet(url, body)
+	if n < 0 {
+		return nil, fmt.Errorf("GET %s: %w", url, fetchError(n))
+	}
+	return &Response{Status: int(status), Body: body[:min(int(n), len(body))], Length: int(n)}, nil
+}
+
+// fetchError maps a negative host result to its error.
+func fetchError(code int32) error {
+	switch code {
+	case -1:
+		return ErrNetDenied
+	case -3:
+		return ErrFetchRateLimited
+	case -4:
+		return ErrFetchLimit
+	case -5:
+		return ErrBadURL
+	case -6:
+		return ErrFetchDeadline
+	default:
+		return ErrFetchFailed
+	}
+}
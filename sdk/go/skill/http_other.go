@@ -0,0 +1,8 @@
+//go:build !wasip1
+
+package skill
+
+// httpGet denies every request outside wasip1, where there is no host to ask.
+func httpGet(string, []byte) (uint32, int32) {
+	return 0, -1
+}
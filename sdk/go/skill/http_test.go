@@ -0,0 +1,31 @@
+package skill
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetOutsideWasip1IsDenied(t *testing.T) {
+	if _, err := Get("https://example.com"); !errors.Is(err, ErrNetDenied) {
+		t.Fatalf("got %v, want ErrNetDenied", err)
+	}
+	if _, err := Get(""); !errors.Is(err, ErrBadURL) {
+		t.Fatalf("got %v, want ErrBadURL", err)
+	}
+}
+
+func TestFetchErrorMatchesHostCodes(t *testing.T) {
+	for code, want := range map[int32]error{
+		-1: ErrNetDenied,
+		-2: ErrFetchFailed,
+		-3: ErrFetchRateLimited,
+		-4: ErrFetchLimit,
+		-5: ErrBadURL,
+		-6: ErrFetchDeadline,
+		-9: ErrFetchFailed,
+	} {
+		if got := fetchError(code); got != want {
+			t.Errorf("fetchError(%d) = %v, want %v", code, got, want)
+		}
+	}
+}
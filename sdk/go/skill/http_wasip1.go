@@ -0,0 +1,14 @@
+//go:build wasip1
+
+package skill
+
+import "unsafe"
+
+//go:wasmimport env zeroclaw_http_get
+func hostHTTPGet(url unsafe.Pointer, urlLen uint32, out unsafe.Pointer, outCap uint32, status unsafe.Pointer) int32
+
+func httpGet(url string, body []byte) (status uint32, n int32) {
+	u := []byte(url)
+	n = hostHTTPGet(unsafe.Pointer(&u[0]), uint32(len(u)), unsafe.Pointer(&body[0]), uint32(len(body)), unsafe.Pointer(&status))
+	return status, n
+}
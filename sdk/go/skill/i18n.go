@@ -0,0 +1,111 @@
+package skill
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// FallbackLocale is the language a Bundle renders in when it has no
+// translation for the one asked for. Every bundle must define it.
+const FallbackLocale = "en"
+
+// Bundle holds a skill's message templates per language, keyed like
+// "word_count.summary". A template names its parameters in braces:
+// "{path}" is replaced by the param's value, and "{words|word|words}" by the
+// plural form for the integer param words, with forms in CLDR order as for
+// PluralIn.
+type Bundle struct {
+	messages map[string]map[string]string
+}
+
+// LoadBundle reads one <language>.json file per language from dir in fsys,
+// usually an embed.FS, each an object of message templates by key.
+func LoadBundle(fsys fs.FS, dir string) (*Bundle, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("i18n: %w", err)
+	}
+	b := &Bundle{messages: map[string]map[string]string{}}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		file := path.Join(dir, entry.Name())
+		raw, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("i18n: %w", err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(raw, &messages); err != nil {
+			return nil, fmt.Errorf("i18n: %s: %w", file, err)
+		}
+		b.messages[Language(name)] = messages
+	}
+	if _, ok := b.messages[FallbackLocale]; !ok {
+		return nil, fmt.Errorf("i18n: %s has no %s.json", dir, FallbackLocale)
+	}
+	return b, nil
+}
+
+// MustLoadBundle is LoadBundle for bundles embedded in the skill, which can
+// only fail if the skill was built wrong; it panics on error.
+func MustLoadBundle(fsys fs.FS, dir string) *Bundle {
+	b, err := LoadBundle(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Message renders key in locale's language with params. When that language
+// lacks the key it renders the FallbackLocale one instead, and warning says
+// so, ready for ToolResult.Warn; warning is "" otherwise.
+func (b *Bundle) Message(locale, key string, params map[string]any) (text, warning string) {
+	lang := Language(locale)
+	tmpl, ok := b.messages[lang][key]
+	if !ok {
+		tmpl, ok = b.messages[FallbackLocale][key]
+		if !ok {
+			return key, fmt.Sprintf("no message %s", key)
+		}
+		warning = fmt.Sprintf("no %q translation of %s; using %s", lang, key, FallbackLocale)
+		lang = FallbackLocale
+	}
+	return render(lang, tmpl, params), warning
+}
+
+// render fills tmpl's placeholders from params. A placeholder naming no
+// param, or plural forms for a param that is not an int, is left as written.
+func render(lang, tmpl string, params map[string]any) string {
+	var out strings.Builder
+	for {
+		open := strings.IndexByte(tmpl, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(tmpl[open:], '}')
+		if end < 0 {
+			break
+		}
+		out.WriteString(tmpl[:open])
+		placeholder := tmpl[open : open+end+1]
+		tmpl = tmpl[open+end+1:]
+
+		name, forms, plural := strings.Cut(placeholder[1:len(placeholder)-1], "|")
+		value, ok := params[name]
+		switch n, isInt := value.(int); {
+		case !ok, plural && !isInt:
+			out.WriteString(placeholder)
+		case plural:
+			out.WriteString(PluralIn(lang, n, strings.Split(forms, "|")...))
+		default:
+			fmt.Fprint(&out, value)
+		}
+	}
+	out.WriteString(tmpl)
+	return out.String()
+}
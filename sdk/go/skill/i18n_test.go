@@ -0,0 +1,65 @@
+package skill
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const summaryKey = "word_count.summary"
+
+var testBundle = fstest.MapFS{
+	"i18n/en.json": {Data: []byte(`{"word_count.summary":"{words} {words|word|words}, {lines} {lines|line|lines}"}`)},
+	"i18n/pl.json": {Data: []byte(`{"word_count.summary":"{words} {words|słowo|słowa|słów}, {lines} {lines|wiersz|wiersze|wierszy}"}`)},
+	"i18n/README":  {Data: []byte("not a bundle")},
+}
+
+func TestBundleRendersPlurals(t *testing.T) {
+	b, err := LoadBundle(testBundle, "i18n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		locale       string
+		words, lines int
+		want         string
+	}{
+		{"en", 1, 1, "1 word, 1 line"},
+		{"en-GB", 2, 0, "2 words, 0 lines"},
+		{"pl", 1, 1, "1 słowo, 1 wiersz"},
+		{"pl_PL.UTF-8", 3, 5, "3 słowa, 5 wierszy"},
+		{"pl", 22, 12, "22 słowa, 12 wierszy"},
+	} {
+		got, warning := b.Message(tc.locale, summaryKey, map[string]any{"words": tc.words, "lines": tc.lines})
+		if got != tc.want || warning != "" {
+			t.Errorf("%s %d/%d: got %q (warning %q), want %q", tc.locale, tc.words, tc.lines, got, warning, tc.want)
+		}
+	}
+}
+
+func TestBundleFallsBackToEnglish(t *testing.T) {
+	b := MustLoadBundle(testBundle, "i18n")
+	got, warning := b.Message("de-AT", summaryKey, map[string]any{"words": 2, "lines": 1})
+	if got != "2 words, 1 line" || warning != `no "de" translation of word_count.summary; using en` {
+		t.Fatalf("got %q, warning %q", got, warning)
+	}
+	if got, warning := b.Message("pl", "missing", nil); got != "missing" || warning != "no message missing" {
+		t.Fatalf("missing key: got %q, warning %q", got, warning)
+	}
+}
+
+func TestRenderLeavesUnknownPlaceholders(t *testing.T) {
+	got := render("en", "{path}: {n|file|files} {gone} {path|x|y} {open", map[string]any{"path": "a.txt", "n": 2})
+	if want := "a.txt: files {gone} {path|x|y} {open"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestLoadBundleNeedsEnglish(t *testing.T) {
+	if _, err := LoadBundle(fstest.MapFS{"i18n/fr.json": {Data: []byte(`{}`)}}, "i18n"); err == nil || !strings.Contains(err.Error(), "en.json") {
+		t.Fatalf("got %v, want an error naming en.json", err)
+	}
+	if _, err := LoadBundle(fstest.MapFS{"i18n/en.json": {Data: []byte(`[]`)}}, "i18n"); err == nil {
+		t.Fatal("a bundle that is not an object loaded")
+	}
+}
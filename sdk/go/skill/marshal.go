@@ -0,0 +1,131 @@
+package skill
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+)
+
+// MarshalStable encodes v as compact JSON with a deterministic byte layout, so
+// results can be hashed, cached, and compared against golden files.
+//
+// Map keys at every depth are emitted in sorted order and struct fields in
+// declaration order; the output never has a trailing newline.
+func MarshalStable(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	// encoding/json sorts map keys; keep the rest identical to json.Marshal.
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// MarshalCanonical encodes v as indented JSON for golden files, so a failing
+// comparison diffs line by line: object keys sorted at every depth, struct
+// fields included, each field on its own two-space-indented line, and a
+// trailing newline. Arrays keep their order.
+//
+// The layout is written here rather than by encoding/json, so it cannot
+// shift between Go versions: numbers keep the digits MarshalStable gave
+// them, and strings escape only quotes, backslashes, and control
+// characters. It matches `zeroclaw skill test --canonical`.
+func MarshalCanonical(v any) ([]byte, error) {
+	raw, err := MarshalStable(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var tree any
+	if err := dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	writeCanonical(&buf, tree, "")
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func writeCanonical(b *bytes.Buffer, v any, indent string) {
+	inner := indent + "  "
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			b.WriteString("{}")
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteString("{\n")
+		for i, k := range keys {
+			b.WriteString(inner)
+			writeCanonicalString(b, k)
+			b.WriteString(": ")
+			writeCanonical(b, v[k], inner)
+			if i < len(keys)-1 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString(indent + "}")
+	case []any:
+		if len(v) == 0 {
+			b.WriteString("[]")
+			return
+		}
+		b.WriteString("[\n")
+		for i, item := range v {
+			b.WriteString(inner)
+			writeCanonical(b, item, inner)
+			if i < len(v)-1 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString(indent + "]")
+	case string:
+		writeCanonicalString(b, v)
+	case json.Number:
+		b.WriteString(v.String())
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case nil:
+		b.WriteString("null")
+	}
+}
+
+func writeCanonicalString(b *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if c < 0x20 {
+				b.WriteString(`\u00`)
+				b.WriteByte(hex[c>>4])
+				b.WriteByte(hex[c&0xf])
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+}
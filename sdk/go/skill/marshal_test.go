@@ -0,0 +1,70 @@
+package skill
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestMarshalStableIsByteIdentical(t *testing.T) {
+	result := OK("3 words", map[string]any{
+		"words": 3,
+		"top": map[string]int{
+			"zeta": 1, "alpha": 2, "mid": 3, "beta": 4, "omega": 5,
+		},
+		"nested": map[string]map[string]int{
+			"b": {"y": 1, "x": 2},
+			"a": {"d": 3, "c": 4},
+		},
+	})
+
+	first, err := MarshalStable(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200; i++ {
+		again, err := MarshalStable(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(first, again) {
+			t.Fatalf("run %d differs:\n%s\n%s", i, first, again)
+		}
+	}
+
+	want := `{"success":true,"output":"3 words","data":{"nested":{"a":{"c":4,"d":3},"b":{"x":2,"y":1}},"top":{"alpha":2,"beta":4,"mid":3,"omega":5,"zeta":1},"words":3}}`
+	if string(first) != want {
+		t.Fatalf("got  %s\nwant %s", first, want)
+	}
+}
+
+func TestMarshalCanonicalIgnoresKeyOrder(t *testing.T) {
+	type line struct {
+		N   int `json:"n"`
+		Len int `json:"len"`
+	}
+	a, err := MarshalCanonical(OK("2 words", map[string]any{"words": 2, "lines": []line{{N: 1, Len: 5}}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := MarshalCanonical(json.RawMessage(`{"data":{"lines":[{"len":5,"n":1}],"words":2},"output":"2 words","success":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same layout `zeroclaw skill test --canonical` prints.
+	want := "{\n  \"data\": {\n    \"lines\": [\n      {\n        \"len\": 5,\n        \"n\": 1\n      }\n    ],\n    \"words\": 2\n  },\n  \"output\": \"2 words\",\n  \"success\": true\n}\n"
+	if string(a) != want || string(b) != want {
+		t.Fatalf("got\n%s\n%s\nwant\n%s", a, b, want)
+	}
+}
+
+func TestMarshalCanonicalKeepsValuesAsWritten(t *testing.T) {
+	got, err := MarshalCanonical(json.RawMessage(`{"id":9007199254740993,"html":"<b>&</b>","ctl":"a\u0001\tb","e":[],"o":{},"n":null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"ctl\": \"a\\u0001\\tb\",\n  \"e\": [],\n  \"html\": \"<b>&</b>\",\n  \"id\": 9007199254740993,\n  \"n\": null,\n  \"o\": {}\n}\n"
+	if string(got) != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
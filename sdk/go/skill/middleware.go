@@ -0,0 +1,165 @@
+package skill
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// middleware is one function registered with Use, adapted to any args type.
+// ok is false when the function takes a different args type.
+type middleware func(args any, res ToolResult) (out ToolResult, ok bool)
+
+// Use registers middleware that Run applies, in registration order, to every
+// result a handler returns, before the result is written. Middleware does not
+// see probe answers or input that failed to decode.
+//
+// A middleware that fails a successful result short-circuits the chain: the
+// failure is written as is and later middleware are skipped. Middleware typed
+// for another args type is skipped too, so with a Router it only wraps the
+// tools it matches; middleware on `any` wraps them all.
+func Use[A any](mw ...func(args A, res ToolResult) ToolResult) Option {
+	return func(r *runner) {
+		for _, f := range mw {
+			f := f // go 1.21 loop variables are shared
+			r.middleware = append(r.middleware, func(args any, res ToolResult) (ToolResult, bool) {
+				a, ok := args.(A)
+				if !ok {
+					return res, false
+				}
+				return f(a, res), true
+			})
+		}
+	}
+}
+
+// applyMiddleware runs r's middleware over a handler's result for args.
+func applyMiddleware(r *runner, args any, res ToolResult) ToolResult {
+	for _, mw := range r.middleware {
+		out, ok := mw(args, res)
+		if !ok {
+			continue
+		}
+		failed := res.Success && !out.Success
+		res = out
+		if failed {
+			break
+		}
+	}
+	return res
+}
+
+// RedactMiddleware blanks every field of Data, at any depth, whose JSON key is
+// one of keys, so secrets a handler copied into its result never reach the
+// host. Other fields keep their values and order. Data that cannot be
+// marshaled fails the result with CodeInternal.
+func RedactMiddleware(keys ...string) func(args any, res ToolResult) ToolResult {
+	redact := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		redact[k] = true
+	}
+	return func(_ any, res ToolResult) ToolResult {
+		if res.Data == nil {
+			return res
+		}
+		raw, err := MarshalStable(res.Data)
+		if err != nil {
+			return FailCode(CodeInternal, fmt.Sprintf("redact: %v", err))
+		}
+		var out bytes.Buffer
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		if err := redactValue(dec, &out, redact); err != nil {
+			return FailCode(CodeInternal, fmt.Sprintf("redact: %v", err))
+		}
+		res.Data = json.RawMessage(out.Bytes())
+		return res
+	}
+}
+
+// redactValue copies one JSON value from dec to out, writing "" in place of
+// the value of any object member named in redact.
+func redactValue(dec *json.Decoder, out *bytes.Buffer, redact map[string]bool) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		out.WriteByte('{')
+		for i := 0; dec.More(); i++ {
+			key, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			writeToken(out, key)
+			out.WriteByte(':')
+			if name, _ := key.(string); redact[name] {
+				if err := skipValue(dec); err != nil {
+					return err
+				}
+				out.WriteString(`""`)
+				continue
+			}
+			if err := redactValue(dec, out, redact); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token() // '}'
+		out.WriteByte('}')
+		return err
+	case json.Delim('['):
+		out.WriteByte('[')
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := redactValue(dec, out, redact); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token() // ']'
+		out.WriteByte(']')
+		return err
+	default:
+		writeToken(out, tok)
+		return nil
+	}
+}
+
+// skipValue discards one JSON value from dec.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// writeToken writes a scalar token; json.Number keeps its original digits.
+func writeToken(out *bytes.Buffer, tok json.Token) {
+	if n, ok := tok.(json.Number); ok {
+		out.WriteString(n.String())
+		return
+	}
+	b, _ := json.Marshal(tok)
+	out.Write(b)
+}
@@ -0,0 +1,88 @@
+package skill
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUseRunsMiddlewareInOrder(t *testing.T) {
+	tag := func(s string) func(echoArgs, ToolResult) ToolResult {
+		return func(args echoArgs, res ToolResult) ToolResult {
+			res.Output += "|" + s + ":" + args.Text
+			return res
+		}
+	}
+	res := runWith(`{"text":"hi"}`, Use(tag("a"), tag("b")), Use(tag("c")))
+	if res.Output != "hi|a:hi|b:hi|c:hi" {
+		t.Fatalf("got output %q", res.Output)
+	}
+}
+
+func TestUseShortCircuitsOnFailure(t *testing.T) {
+	reject := func(args echoArgs, res ToolResult) ToolResult {
+		return FailCode(CodePermissionDenied, "blocked "+args.Text)
+	}
+	reached := false
+	after := func(_ any, res ToolResult) ToolResult {
+		reached = true
+		return res
+	}
+	res := runWith(`{"text":"hi"}`, Use(reject), Use(after))
+	if res.Success || res.ErrorCode != CodePermissionDenied || reached {
+		t.Fatalf("got %+v (later middleware reached: %v)", res, reached)
+	}
+
+	// Input that fails to decode never reaches middleware.
+	res = runWith(`{"text":`, Use(after))
+	if res.Success || reached {
+		t.Fatalf("middleware ran on input that failed to decode: %+v", res)
+	}
+}
+
+func TestUseSkipsMiddlewareForOtherArgs(t *testing.T) {
+	type otherArgs struct{ N int }
+	res := runWith(`{"text":"hi"}`, Use(func(otherArgs, ToolResult) ToolResult {
+		return Fail("wrong type")
+	}))
+	if !res.Success || res.Output != "hi" {
+		t.Fatalf("got %+v", res)
+	}
+}
+
+func TestRedactMiddlewareBlanksMatchingFields(t *testing.T) {
+	type creds struct {
+		User   string `json:"user"`
+		Token  string `json:"token"`
+		Tokens []int  `json:"tokens"`
+	}
+	data := map[string]any{
+		"token": "top",
+		"items": []any{creds{User: "ann", Token: "s3cr3t", Tokens: []int{1, 2}}},
+		"n":     1.5,
+	}
+	out := RedactMiddleware("token", "tokens")(nil, OK("done", data))
+	got, err := MarshalStable(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"success":true,"output":"done","data":{"items":[{"user":"ann","token":"","tokens":""}],"n":1.5,"token":""}}`
+	if string(got) != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+
+	if res := RedactMiddleware("token")(nil, OK("x", nil)); res.Data != nil {
+		t.Fatalf("nil data should stay nil, got %v", res.Data)
+	}
+	if res := RedactMiddleware("token")(nil, OK("x", func() {})); res.Success || !strings.HasPrefix(*res.Error, "redact: ") {
+		t.Fatalf("unmarshalable data should fail, got %+v", res)
+	}
+}
+
+func TestNoMiddlewareLeavesResultUntouched(t *testing.T) {
+	plain, with := runWith(`{"text":"hi"}`), runWith(`{"text":"hi"}`, Use[any]())
+	a, _ := MarshalStable(plain)
+	b, _ := MarshalStable(with)
+	if string(a) != string(b) || string(a) != `{"success":true,"output":"hi"}` {
+		t.Fatalf("got %s and %s", a, b)
+	}
+}
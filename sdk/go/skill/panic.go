@@ -0,0 +1,48 @@
+package skill
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// recoverPanic, deferred around each request, turns a panic into a
+// CodeInternal result in *res and marks r so Run exits with ExitPanic. The
+// message names the panic value and the function that raised it, e.g.
+//
+//	panic: runtime error: index out of range [0] with length 0 (at main.count main.go:42)
+//
+// Panics in goroutines the handler started cannot be recovered and still
+// crash the skill.
+func recoverPanic(r *runner, res *ToolResult) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	r.panicked = true
+	msg := fmt.Sprintf("panic: %v", v)
+	if at := panicSite(); at != "" {
+		msg += " (at " + at + ")"
+	}
+	*res = FailCode(CodeInternal, msg)
+}
+
+// panicSite returns the function, file, and line that panicked: the first
+// frame outside package runtime above runtime.gopanic.
+func panicSite() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	unwinding := false
+	for {
+		f, more := frames.Next()
+		if !unwinding {
+			unwinding = f.Function == "runtime.gopanic"
+		} else if !strings.HasPrefix(f.Function, "runtime.") {
+			return fmt.Sprintf("%s %s:%d", f.Function, filepath.Base(f.File), f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
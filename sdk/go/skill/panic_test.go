@@ -0,0 +1,36 @@
+package skill
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPanicBecomesInternalResult(t *testing.T) {
+	r := runner{stdin: strings.NewReader(`{"text":""}`)}
+	res := handle(&r, single(func(args echoArgs) ToolResult {
+		return OK(string(args.Text[0]), nil)
+	}))
+	if res.Success || res.ErrorCode != CodeInternal || res.Error == nil || !r.panicked {
+		t.Fatalf("expected an internal failure, got %+v", res)
+	}
+	if msg := *res.Error; !strings.HasPrefix(msg, "panic: runtime error: index out of range") ||
+		!strings.Contains(msg, "(at github.com/zeroclaw-labs/zeroclaw/sdk/go/skill.TestPanicBecomesInternalResult.func1 panic_test.go:11)") {
+		t.Fatalf("unexpected panic message %q", msg)
+	}
+}
+
+func TestServeLinesContinuesAfterPanic(t *testing.T) {
+	var out strings.Builder
+	r := runner{stdin: strings.NewReader("{\"text\":\"boom\"}\n{\"text\":\"ok\"}\n"), stdout: &out}
+	serveLines(&r, single(func(args echoArgs) ToolResult {
+		if args.Text == "boom" {
+			panic("boom")
+		}
+		return OK(args.Text, nil)
+	}))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"error":"panic: boom (at `) ||
+		lines[1] != `{"success":true,"output":"ok"}` || !r.panicked {
+		t.Fatalf("unexpected stream:\n%s", out.String())
+	}
+}
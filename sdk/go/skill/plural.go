@@ -0,0 +1,119 @@
+package skill
+
+import (
+	"os"
+	"strings"
+)
+
+// LocaleEnv names the environment variable consulted when a skill is not
+// given an explicit locale.
+const LocaleEnv = "ZEROCLAW_LOCALE"
+
+// pluralRules maps a language to the index of the plural form to use for n.
+// Forms are passed in CLDR order: one, few, many (languages with two forms
+// only use one and other).
+var pluralRules = map[string]func(n int) int{
+	"en": oneOther,
+	"de": oneOther,
+	"es": oneOther,
+	"it": oneOther,
+	"nl": oneOther,
+	"pt": oneOther,
+	"fr": func(n int) int {
+		if n == 0 || n == 1 {
+			return 0
+		}
+		return 1
+	},
+	"cs": func(n int) int {
+		switch {
+		case n == 1:
+			return 0
+		case n >= 2 && n <= 4:
+			return 1
+		default:
+			return 2
+		}
+	},
+	"pl": func(n int) int {
+		switch {
+		case n == 1:
+			return 0
+		case isFew(n):
+			return 1
+		default:
+			return 2
+		}
+	},
+	"ru": slavicEast,
+	"uk": slavicEast,
+}
+
+// Plural returns the form of a word to use for n in the default locale.
+// Forms are given in CLDR order, e.g. Plural(n, "word", "words") for English
+// or Plural(n, "słowo", "słowa", "słów") for Polish.
+func Plural(n int, forms ...string) string {
+	return PluralIn(DefaultLocale(), n, forms...)
+}
+
+// PluralIn is Plural for an explicit locale such as "pl" or "ru-RU".
+// Unknown locales fall back to English rules; if fewer forms are given than
+// the locale distinguishes, the last form is used.
+func PluralIn(locale string, n int, forms ...string) string {
+	if len(forms) == 0 {
+		return ""
+	}
+	rule, ok := pluralRules[Language(locale)]
+	if !ok {
+		rule = oneOther
+	}
+	if n < 0 {
+		n = -n
+	}
+	i := rule(n)
+	if i >= len(forms) {
+		i = len(forms) - 1
+	}
+	return forms[i]
+}
+
+// DefaultLocale returns the locale from ZEROCLAW_LOCALE, or "en" when unset.
+func DefaultLocale() string {
+	if locale := os.Getenv(LocaleEnv); locale != "" {
+		return locale
+	}
+	return "en"
+}
+
+// Language reduces a locale tag such as "pl_PL.UTF-8" or "ru-RU" to its
+// lower-case language subtag ("pl", "ru").
+func Language(locale string) string {
+	lang, _, _ := strings.Cut(locale, ".")
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	return strings.ToLower(lang)
+}
+
+func oneOther(n int) int {
+	if n == 1 {
+		return 0
+	}
+	return 1
+}
+
+func slavicEast(n int) int {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return 0
+	case isFew(n):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// isFew reports whether n ends in 2–4 but not 12–14.
+func isFew(n int) bool {
+	return n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14)
+}
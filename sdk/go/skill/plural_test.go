@@ -0,0 +1,58 @@
+package skill
+
+import "testing"
+
+func TestPluralEnglish(t *testing.T) {
+	cases := map[int]string{0: "words", 1: "word", 2: "words", 11: "words", 21: "words"}
+	for n, want := range cases {
+		if got := PluralIn("en", n, "word", "words"); got != want {
+			t.Errorf("PluralIn(en, %d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestPluralFrenchCountsZeroAsOne(t *testing.T) {
+	cases := map[int]string{0: "mot", 1: "mot", 2: "mots", 11: "mots"}
+	for n, want := range cases {
+		if got := PluralIn("fr-CA", n, "mot", "mots"); got != want {
+			t.Errorf("PluralIn(fr, %d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestPluralPolishHasThreeForms(t *testing.T) {
+	cases := map[int]string{
+		1: "słowo", 2: "słowa", 4: "słowa", 5: "słów",
+		12: "słów", 14: "słów", 22: "słowa", 25: "słów", 0: "słów",
+	}
+	for n, want := range cases {
+		if got := PluralIn("pl-PL", n, "słowo", "słowa", "słów"); got != want {
+			t.Errorf("PluralIn(pl, %d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestPluralRussian(t *testing.T) {
+	cases := map[int]string{1: "слово", 21: "слово", 11: "слов", 3: "слова", 13: "слов", 5: "слов"}
+	for n, want := range cases {
+		if got := PluralIn("ru_RU.UTF-8", n, "слово", "слова", "слов"); got != want {
+			t.Errorf("PluralIn(ru, %d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestPluralFallsBackToLastForm(t *testing.T) {
+	if got := PluralIn("pl", 5, "word", "words"); got != "words" {
+		t.Errorf("got %q, want the last form", got)
+	}
+	if got := PluralIn("xx", 1, "word", "words"); got != "word" {
+		t.Errorf("unknown locale should use English rules, got %q", got)
+	}
+}
+
+func TestPluralUsesLocaleEnv(t *testing.T) {
+	t.Setenv(LocaleEnv, "pl")
+	if got := Plural(5, "słowo", "słowa", "słów"); got != "słów" {
+		t.Errorf("got %q, want słów", got)
+	}
+}
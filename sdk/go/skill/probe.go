@@ -0,0 +1,58 @@
+package skill
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// ProbeField marks a probe envelope, {"__probe":true}. Run and Router.Dispatch
+// answer it with a successful ToolResult whose Data is a ProbeReport, without
+// calling any handler, so a host can check a skill against its policy first.
+const ProbeField = "__probe"
+
+// ProbeReport describes what a skill would do if called.
+type ProbeReport struct {
+	Tools []ProbeTool `json:"tools"`
+	// Permissions lists the host capabilities declared with Requires.
+	Permissions []string `json:"permissions"`
+	// Streaming is set when the skill writes Progress lines (Meta.Streaming).
+	Streaming bool `json:"streaming,omitempty"`
+}
+
+// ProbeTool is one tool a skill serves. Name is empty for a Run skill that
+// did not set ToolName; Description is set for Router tools.
+type ProbeTool struct {
+	Name        string         `json:"name,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Schema      map[string]any `json:"schema"`
+}
+
+// Requires declares the host capabilities the skill needs, spelled as in the
+// manifest, e.g. Requires("fs:/data", "http_fetch"). It is only reported by
+// probes; the host still enforces its own grants.
+func Requires(perms ...string) Option {
+	return func(r *runner) { r.permissions = append(r.permissions, perms...) }
+}
+
+// ToolName names the tool served by Run in probe reports.
+func ToolName(name string) Option {
+	return func(r *runner) { r.name = name }
+}
+
+func isProbe(data []byte) bool {
+	if !bytes.Contains(data, []byte(ProbeField)) {
+		return false
+	}
+	var env struct {
+		Probe bool `json:"__probe"`
+	}
+	return json.Unmarshal(data, &env) == nil && env.Probe
+}
+
+func probeResult(r *runner, tools []ProbeTool) ToolResult {
+	perms := r.permissions
+	if perms == nil {
+		perms = []string{}
+	}
+	return OK("probe", ProbeReport{Tools: tools, Permissions: perms, Streaming: r.meta.Streaming})
+}
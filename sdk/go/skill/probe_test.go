@@ -0,0 +1,49 @@
+package skill
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProbeSkipsHandler(t *testing.T) {
+	called := false
+	r := runner{stdin: strings.NewReader(`{"__probe":true}`)}
+	ToolName("echo")(&r)
+	Requires("fs:/data")(&r)
+	res := handle(&r, single(func(args echoArgs) ToolResult {
+		called = true
+		return OK(args.Text, nil)
+	}))
+
+	if called {
+		t.Fatal("probe must not call the handler")
+	}
+	report, ok := res.Data.(ProbeReport)
+	if !res.Success || !ok {
+		t.Fatalf("expected a ProbeReport, got %+v", res)
+	}
+	if len(report.Tools) != 1 || report.Tools[0].Name != "echo" || report.Tools[0].Schema["type"] != "object" {
+		t.Fatalf("unexpected tools: %+v", report.Tools)
+	}
+	if len(report.Permissions) != 1 || report.Permissions[0] != "fs:/data" {
+		t.Fatalf("unexpected permissions: %v", report.Permissions)
+	}
+}
+
+func TestProbeFieldMustBeTrue(t *testing.T) {
+	for _, input := range []string{`{"__probe":false,"text":"hi"}`, `{"text":"__probe"}`} {
+		if res := runWith(input); res.Output == "probe" {
+			t.Errorf("%s was treated as a probe", input)
+		}
+	}
+}
+
+func TestProbeReportsNoPermissionsAsEmptyList(t *testing.T) {
+	out, err := MarshalStable(runWith(`{"__probe":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `"permissions":[]`) {
+		t.Fatalf("want an empty permissions list, got %s", out)
+	}
+}
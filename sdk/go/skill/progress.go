@@ -0,0 +1,89 @@
+package skill
+
+import "context"
+
+// ProgressField keys a progress line. A streaming skill may write any number
+// of them before its result, one JSON object per line:
+//
+//	{"progress":{"percent":40,"message":"chunk 4 of 10"}}
+//	{"success":true,"output":"..."}
+//
+// Hosts that do not render progress skip these lines and read the last one.
+const ProgressField = "progress"
+
+// Meta describes how a skill talks to its host beyond the one-result
+// protocol. Set it with WithMeta; it is reported in probes.
+type Meta struct {
+	// Streaming makes Report write Progress lines and Emitter write partial
+	// results, and ends the result with a newline, so stdout is NDJSON.
+	// Declare "streaming": true in the manifest as well so `zeroclaw skill
+	// test` renders the lines as a progress bar.
+	Streaming bool
+}
+
+// WithMeta sets the skill's Meta.
+func WithMeta(m Meta) Option {
+	return func(r *runner) { r.meta = m }
+}
+
+// Progress is one progress event.
+type Progress struct {
+	// Percent is how much of the work is done, from 0 to 100.
+	Percent float64 `json:"percent"`
+	// Message optionally says what the skill is doing.
+	Message string `json:"message,omitempty"`
+}
+
+type progressKey struct{}
+
+// Report writes p as a progress line when ctx comes from a RunContext skill
+// with Meta.Streaming set, and does nothing otherwise, so handlers can report
+// unconditionally. Percent is clamped to [0, 100].
+func Report(ctx context.Context, p Progress) {
+	r, _ := ctx.Value(progressKey{}).(*runner)
+	if r == nil || !r.meta.Streaming {
+		return
+	}
+	p.Percent = min(max(p.Percent, 0), 100)
+	r.streamMu.Lock()
+	defer r.streamMu.Unlock()
+	write(r, map[string]Progress{ProgressField: p})
+	r.stdout.Write([]byte("\n"))
+}
+
+// RunContext is Run for handlers that take a context. The context is
+// cancelled at the host's deadline (see DeadlineEnv), so a long handler can
+// check ctx.Err() between steps and return early, and it carries what Report
+// needs to stream progress.
+func RunContext[A any](handler func(ctx context.Context, args A) ToolResult, opts ...Option) {
+	serve(withContext(handler), opts)
+}
+
+// HandleContext is Handle for a RunContext handler.
+func HandleContext[A any](handler func(ctx context.Context, args A) ToolResult, args []byte, opts ...Option) ToolResult {
+	return handleOnce(withContext(handler), args, opts)
+}
+
+// withContext adapts a context handler to a service.
+func withContext[A any](handler func(ctx context.Context, args A) ToolResult) service {
+	return service{
+		schema: func() any { return SchemaFor[A]() },
+		tools: func(r *runner) []ProbeTool {
+			return []ProbeTool{{Name: r.name, Schema: SchemaFor[A]()}}
+		},
+		call: func(r *runner, data []byte) ToolResult {
+			ctx, cancel := requestContext(r)
+			defer cancel()
+			return decode(r, data, func(args A) ToolResult { return handler(ctx, args) })
+		},
+	}
+}
+
+// requestContext returns the context for one request served by r.
+func requestContext(r *runner) (context.Context, context.CancelFunc) {
+	ctx := context.WithValue(context.Background(), progressKey{}, r)
+	if deadline := Budget().Deadline; !deadline.IsZero() {
+		return context.WithDeadline(ctx, deadline)
+	}
+	return context.WithCancel(ctx)
+}
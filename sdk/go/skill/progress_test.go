@@ -0,0 +1,63 @@
+package skill
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportStreamsProgressBeforeResult(t *testing.T) {
+	var out strings.Builder
+	r := runner{stdin: strings.NewReader(`{"text":"done"}`), stdout: &out}
+	WithMeta(Meta{Streaming: true})(&r)
+	write(&r, handle(&r, withContext(func(ctx context.Context, args echoArgs) ToolResult {
+		Report(ctx, Progress{Percent: 50, Message: "half"})
+		Report(ctx, Progress{Percent: 150})
+		return OK(args.Text, nil)
+	})))
+
+	want := `{"progress":{"percent":50,"message":"half"}}` + "\n" +
+		`{"progress":{"percent":100}}` + "\n" +
+		`{"success":true,"output":"done"}`
+	if out.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestReportIsSilentWithoutStreaming(t *testing.T) {
+	var out strings.Builder
+	r := runner{stdin: strings.NewReader(`{"text":"done"}`), stdout: &out}
+	res := handle(&r, withContext(func(ctx context.Context, args echoArgs) ToolResult {
+		Report(ctx, Progress{Percent: 50})
+		return OK(args.Text, nil)
+	}))
+	Report(context.Background(), Progress{Percent: 50})
+
+	if out.Len() != 0 || res.Output != "done" {
+		t.Fatalf("expected no progress lines, got %q and %+v", out.String(), res)
+	}
+}
+
+func TestRunContextDeadlineFromEnv(t *testing.T) {
+	t.Setenv(DeadlineEnv, time.Now().Add(-time.Second).Format(time.RFC3339))
+	r := runner{stdin: strings.NewReader(`{}`)}
+	res := handle(&r, withContext(func(ctx context.Context, args echoArgs) ToolResult {
+		if ctx.Err() != nil {
+			return FailCode(CodeTimeout, ctx.Err().Error())
+		}
+		return OK("", nil)
+	}))
+	if res.ErrorCode != CodeTimeout {
+		t.Fatalf("a past deadline should cancel the context, got %+v", res)
+	}
+}
+
+func TestProbeReportsStreaming(t *testing.T) {
+	r := runner{stdin: strings.NewReader(`{"__probe":true}`)}
+	WithMeta(Meta{Streaming: true})(&r)
+	res := handle(&r, withContext(func(context.Context, echoArgs) ToolResult { return OK("", nil) }))
+	if report, ok := res.Data.(ProbeReport); !ok || !report.Streaming {
+		t.Fatalf("expected a streaming ProbeReport, got %+v", res)
+	}
+}
@@ -0,0 +1,52 @@
+package skill
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// RawHeader starts the stderr line Run writes before a RawResult's body:
+// RawHeader, a space, and the JSON {"content_type":"...","length":N}. A host
+// that sees it takes stdout as the body, N bytes long, instead of parsing a
+// ToolResult from it.
+const RawHeader = "ZEROCLAW_RAW=1"
+
+// RawResult is a binary result, such as a PDF or an image, that Run writes
+// to stdout as is, saving the third that base64 in an Artifact would add.
+// Return it with Raw. Only a single request gets it: in JSON-Lines mode and
+// for a streaming skill, whose stdout carries JSON lines, it is a
+// CodeNotSupported failure.
+type RawResult struct {
+	ContentType string
+	Body        []byte
+}
+
+// Raw returns a successful result that makes Run write raw.Body to stdout,
+// and the RawHeader line to stderr, instead of a JSON ToolResult. The host
+// sees nothing of the result but raw; middleware still runs on it.
+func Raw(raw RawResult) ToolResult {
+	return ToolResult{Success: true, raw: &raw}
+}
+
+// rawHeader is the JSON after RawHeader.
+type rawHeader struct {
+	ContentType string `json:"content_type"`
+	Length      int    `json:"length"`
+}
+
+// writeRaw writes raw's header line to stderr and its body to stdout.
+func writeRaw(stdout, stderr io.Writer, raw RawResult) {
+	header, _ := json.Marshal(rawHeader{ContentType: raw.ContentType, Length: len(raw.Body)})
+	fmt.Fprintf(stderr, "%s %s\n", RawHeader, header)
+	stdout.Write(raw.Body)
+}
+
+// inLine replaces a RawResult, which cannot share stdout with JSON lines,
+// with a CodeNotSupported failure.
+func inLine(res ToolResult) ToolResult {
+	if res.raw == nil {
+		return res
+	}
+	return FailCode(CodeNotSupported, "a raw result needs a single request, not JSON-Lines or streaming mode")
+}
@@ -0,0 +1,34 @@
+package skill
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteRawSkipsJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	body := []byte{0x89, 'P', 'N', 'G', 0, 0xff}
+	writeRaw(&stdout, &stderr, RawResult{ContentType: "image/png", Body: body})
+	if !bytes.Equal(stdout.Bytes(), body) {
+		t.Fatalf("stdout should be the body alone, got %q", stdout.Bytes())
+	}
+	if want := RawHeader + ` {"content_type":"image/png","length":6}` + "\n"; stderr.String() != want {
+		t.Fatalf("got header %q, want %q", stderr.String(), want)
+	}
+}
+
+func TestRawResultFailsInJSONLinesMode(t *testing.T) {
+	var out strings.Builder
+	r := runner{stdin: strings.NewReader("{\"text\":\"a\"}\n"), stdout: &out}
+	serveLines(&r, single(func(args echoArgs) ToolResult {
+		return Raw(RawResult{ContentType: "text/plain", Body: []byte(args.Text)})
+	}))
+	if !strings.Contains(out.String(), `"error_code":"not_supported"`) {
+		t.Fatalf("a raw result cannot be a JSON line, got %s", out.String())
+	}
+
+	if res := inLine(OK("a", nil)); !res.Success {
+		t.Fatalf("a regular result should pass through, got %+v", res)
+	}
+}
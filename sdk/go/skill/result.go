@@ -0,0 +1,178 @@
+package skill
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ToolResult is the JSON object a skill writes to stdout.
+type ToolResult struct {
+	Success bool   `json:"success"`
+	Output  string `json:"output"`
+	// OutputType is the media type of Output, e.g. OutputMarkdown, so the
+	// host can pick a renderer. Empty means plain text.
+	OutputType string  `json:"output_type,omitempty"`
+	Error      *string `json:"error,omitempty"`
+	// ErrorCode classifies a failure; see the Code constants.
+	ErrorCode ErrorCode `json:"error_code,omitempty"`
+	// FieldErrors locates invalid input within the args, one entry per
+	// problem; see FieldError.
+	FieldErrors []FieldError `json:"field_errors,omitempty"`
+	// Data carries structured results; map-backed values must be emitted as
+	// sorted slices (or plain maps, whose keys MarshalStable sorts). A nil
+	// Data leaves "data" out of the result, and so does a nil pointer, map,
+	// or slice; WithNullData writes "data": null instead.
+	Data any `json:"data,omitempty"`
+	// Artifacts carries binary files alongside Output; see Artifact.
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+	// Truncated marks a result the skill cut short to fit its Budget.
+	Truncated bool `json:"truncated,omitempty"`
+	// Warnings flags things worth knowing about a result that still
+	// succeeded, such as input the skill had to repair; see Warn.
+	Warnings []string `json:"warnings,omitempty"`
+	// Seq orders the result lines of a streaming skill: the SDK numbers
+	// partials written by an Emitter from 1, and the result the handler
+	// returns is marked Final with the highest Seq. It is zero otherwise.
+	Seq int `json:"seq,omitempty"`
+	// ID optionally names a partial so consumers can correlate it, e.g. the
+	// file or chunk it describes. The SDK leaves it as the handler set it.
+	ID string `json:"id,omitempty"`
+	// Final marks the last result line of a streaming skill.
+	Final bool `json:"final,omitempty"`
+	// ETag names the version of the data behind the result, for the host
+	// to send back in IfNoneMatchField next time.
+	ETag string `json:"etag,omitempty"`
+	// NotModified says the result tagged ETag that the host already holds
+	// is still valid; see NotModified.
+	NotModified bool `json:"not_modified,omitempty"`
+	// Meta is set by the SDK, not the handler; see ResultMeta.
+	Meta *ResultMeta `json:"meta,omitempty"`
+
+	// raw is set by Raw.
+	raw *RawResult
+	// nullData is set by WithNullData.
+	nullData bool
+}
+
+// WithNullData returns r with no Data, written as "data": null rather than
+// left out, for a host that reads null as "ran, but has no structured data"
+// and a missing field as a skill that never sets it. Data set again
+// afterwards is written as usual.
+func (r ToolResult) WithNullData() ToolResult {
+	r.Data, r.nullData = nil, true
+	return r
+}
+
+// MarshalJSON writes r as its fields say, but for "data": left out when Data
+// is nil, even a nil pointer, map, or slice, and null after WithNullData.
+func (r ToolResult) MarshalJSON() ([]byte, error) {
+	type plain ToolResult // ToolResult without this method
+	switch nilData := isNil(r.Data); {
+	case nilData && r.nullData:
+		r.Data = json.RawMessage("null")
+	case nilData:
+		r.Data = nil
+	}
+	return json.Marshal(plain(r))
+}
+
+// isNil reports whether v is nil or holds a nil pointer, map, or slice.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// OK returns a successful result with a human-readable output and optional data.
+func OK(output string, data any) ToolResult {
+	return ToolResult{Success: true, Output: output, Data: data}
+}
+
+// Fail returns a failed result carrying msg as its error.
+func Fail(msg string) ToolResult {
+	return ToolResult{Success: false, Error: &msg}
+}
+
+// FailCode returns a failed result carrying msg and a machine-readable code.
+func FailCode(code ErrorCode, msg string) ToolResult {
+	return ToolResult{Success: false, Error: &msg, ErrorCode: code}
+}
+
+// Media types for ToolResult.OutputType.
+const (
+	OutputPlain    = "text/plain"
+	OutputMarkdown = "text/markdown"
+	OutputHTML     = "text/html"
+)
+
+// AsMarkdown returns r with Output set to s, typed as OutputMarkdown.
+func (r ToolResult) AsMarkdown(s string) ToolResult {
+	r.Output, r.OutputType = s, OutputMarkdown
+	return r
+}
+
+// AsHTML returns r with Output set to s, typed as OutputHTML.
+func (r ToolResult) AsHTML(s string) ToolResult {
+	r.Output, r.OutputType = s, OutputHTML
+	return r
+}
+
+// Warn returns r with msg appended to its Warnings.
+func (r ToolResult) Warn(msg string) ToolResult {
+	r.Warnings = append(slices.Clip(r.Warnings), msg)
+	return r
+}
+
+// checkOutputType replaces a result whose OutputType is not a media type
+// such as "text/markdown" with a CodeInternal failure, so the host never
+// sees a type it cannot act on.
+func checkOutputType(res ToolResult) ToolResult {
+	if res.OutputType == "" {
+		return res
+	}
+	if err := validMediaType(res.OutputType); err != nil {
+		return FailCode(CodeInternal, fmt.Sprintf("output_type %q: %v", res.OutputType, err))
+	}
+	return res
+}
+
+// validMediaType checks that t parses as type/subtype with optional
+// parameters.
+func validMediaType(t string) error {
+	mediaType, _, err := mime.ParseMediaType(t)
+	if err != nil {
+		return err
+	}
+	major, minor, ok := strings.Cut(mediaType, "/")
+	if !ok || major == "" || minor == "" {
+		return errors.New("expected type/subtype")
+	}
+	return nil
+}
+
+// ErrorCode classifies a failed ToolResult so hosts can branch on it without
+// parsing messages. `zeroclaw skill test` maps each code to a stable exit
+// status (see `zeroclaw skill describe`).
+type ErrorCode string
+
+const (
+	CodeInvalidInput     ErrorCode = "invalid_input"
+	CodeNotFound         ErrorCode = "not_found"
+	CodePermissionDenied ErrorCode = "permission_denied"
+	CodeTimeout          ErrorCode = "timeout"
+	CodeRateLimited      ErrorCode = "rate_limited"
+	CodeInternal         ErrorCode = "internal"
+	// CodeNotSupported reports a feature the host does not offer, such as
+	// answering Ask.
+	CodeNotSupported ErrorCode = "not_supported"
+)
@@ -0,0 +1,47 @@
+package skill
+
+import "testing"
+
+func TestNilDataIsLeftOut(t *testing.T) {
+	type counts struct {
+		Words int `json:"words"`
+	}
+	for name, data := range map[string]any{
+		"nil":         nil,
+		"nil pointer": (*counts)(nil),
+		"nil map":     map[string]int(nil),
+		"nil slice":   []int(nil),
+	} {
+		out, err := MarshalStable(OK("done", data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(out), `{"success":true,"output":"done"}`; got != want {
+			t.Errorf("%s: got %s, want %s", name, got, want)
+		}
+	}
+
+	out, err := MarshalStable(OK("done", []int{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(out), `{"success":true,"output":"done","data":[]}`; got != want {
+		t.Errorf("empty slice: got %s, want %s", got, want)
+	}
+}
+
+func TestWithNullDataWritesNull(t *testing.T) {
+	res := OK("done", map[string]int{"words": 2}).WithNullData().Warn("nothing to count")
+	out, err := MarshalStable(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(out), `{"success":true,"output":"done","data":null,"warnings":["nothing to count"]}`; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	res.Data = map[string]int{"words": 2}
+	if out, _ = MarshalStable(res); string(out) != `{"success":true,"output":"done","data":{"words":2},"warnings":["nothing to count"]}` {
+		t.Fatalf("data set after WithNullData: got %s", out)
+	}
+}
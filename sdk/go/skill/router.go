@@ -0,0 +1,156 @@
+package skill
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Router serves several tools from one module. Each request names its tool in
+// an envelope:
+//
+//	{"tool": "count", "args": {"text": "a b"}}
+//
+// or, for trying a tool by hand, on the command line with its args alone on
+// stdin:
+//
+//	echo '{"text": "a b"}' | wasmtime tool.wasm count
+type Router struct {
+	tools map[string]Tool
+	names []string
+}
+
+// Tool is a handler prepared for a Router; build one with NewTool.
+type Tool struct {
+	desc   string
+	schema func() map[string]any
+	call   func(r *runner, args []byte) ToolResult
+}
+
+// ToolSchema is one tool's entry in a Router's SchemaFlag output: what the
+// tool does and the JSON Schema of its args, the two halves of a function
+// definition in LLM tool-calling APIs.
+type ToolSchema struct {
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+// NewTool wraps handler so a Router can decode its args and report its schema,
+// SchemaFor[A] with its required list sorted, apart from every other tool's.
+// Sorting keeps the function definition a host registers the same from one
+// build to the next.
+func NewTool[A any](handler func(args A) ToolResult) Tool {
+	return Tool{
+		schema: func() map[string]any { return SchemaWith[A](SchemaOpt{SortProperties: true}) },
+		call: func(r *runner, args []byte) ToolResult {
+			return decode(r, args, handler)
+		},
+	}
+}
+
+// NewRouter returns an empty Router.
+func NewRouter() *Router {
+	return &Router{tools: map[string]Tool{}}
+}
+
+// Register adds tool under name, described by desc for schemas and probes.
+// It panics if name is already registered, so a copy-pasted registration
+// fails at startup instead of shadowing a tool.
+func (rt *Router) Register(name, desc string, tool Tool) {
+	if _, ok := rt.tools[name]; ok {
+		panic(fmt.Sprintf("skill: tool %q registered twice", name))
+	}
+	tool.desc = desc
+	rt.names = append(rt.names, name)
+	rt.tools[name] = tool
+}
+
+// ErrNoTools is returned by Validate for a Router with nothing registered.
+var ErrNoTools = errors.New("skill: router has no tools registered")
+
+// Validate reports whether rt can serve requests: it needs at least one tool.
+func (rt *Router) Validate() error {
+	if len(rt.names) == 0 {
+		return ErrNoTools
+	}
+	return nil
+}
+
+// Dispatch is Run for a Router: it reads one envelope from stdin, runs the
+// named tool, and writes its result. Started with SchemaFlag it prints a
+// ToolSchema for each tool, keyed by name, where Run prints one flat schema.
+// Started with a tool name as its first argument, it reads that tool's args
+// from stdin instead; an envelope naming a tool still wins, so a host that
+// sends one is unaffected. A Router that fails Validate reports the error
+// on stderr and exits with status 1 without reading stdin.
+func (rt *Router) Dispatch(opts ...Option) {
+	if err := rt.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	serve(service{
+		schema: rt.schemas,
+		tools:  rt.probeTools,
+		call:   rt.caller(argTool(os.Args)),
+	}, opts)
+}
+
+// argTool is the tool name a module was started with, if its first argument
+// is not a flag such as SchemaFlag.
+func argTool(argv []string) string {
+	if len(argv) < 2 || strings.HasPrefix(argv[1], "-") {
+		return ""
+	}
+	return argv[1]
+}
+
+func (rt *Router) schemas() any {
+	schemas := make(map[string]ToolSchema, len(rt.tools))
+	for name, tool := range rt.tools {
+		schemas[name] = ToolSchema{Description: tool.desc, Parameters: tool.schema()}
+	}
+	return schemas
+}
+
+func (rt *Router) probeTools(*runner) []ProbeTool {
+	tools := make([]ProbeTool, 0, len(rt.names))
+	for _, name := range rt.names {
+		tool := rt.tools[name]
+		tools = append(tools, ProbeTool{Name: name, Description: tool.desc, Schema: tool.schema()})
+	}
+	return tools
+}
+
+// caller serves one request: an envelope, or, when the module was started
+// with tool as its first argument and the request names no tool, that
+// tool's args.
+func (rt *Router) caller(tool string) func(r *runner, data []byte) ToolResult {
+	return func(r *runner, data []byte) ToolResult {
+		var env struct {
+			Tool string          `json:"tool"`
+			Args json.RawMessage `json:"args"`
+		}
+		err := json.Unmarshal(data, &env)
+		if tool != "" && env.Tool == "" {
+			// Bad JSON is then the tool's to report, as bad args.
+			env.Tool, env.Args, err = tool, data, nil
+			if len(bytes.TrimSpace(data)) == 0 {
+				env.Args = nil
+			}
+		}
+		if err != nil {
+			return FailCode(CodeInvalidInput, fmt.Sprintf(`invalid envelope JSON: %v — expected {"tool":"...","args":{...}}`, err))
+		}
+		t, ok := rt.tools[env.Tool]
+		if !ok {
+			return FailCode(CodeNotFound, fmt.Sprintf("unknown tool %q (registered: %s)", env.Tool, strings.Join(rt.names, ", ")))
+		}
+		if env.Args == nil {
+			env.Args = json.RawMessage("{}")
+		}
+		return t.call(r, env.Args)
+	}
+}
@@ -0,0 +1,128 @@
+package skill
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type countArgs struct {
+	Text string `json:"text"`
+}
+
+func testRouter() *Router {
+	rt := NewRouter()
+	rt.Register("echo", "Echo text back", NewTool(func(args echoArgs) ToolResult {
+		return OK(args.Text, nil)
+	}))
+	rt.Register("count", "Count words", NewTool(func(args countArgs) ToolResult {
+		return OK("", len(strings.Fields(args.Text)))
+	}))
+	return rt
+}
+
+func dispatchWith(rt *Router, input string) ToolResult {
+	r := runner{stdin: strings.NewReader(input)}
+	return handle(&r, service{tools: rt.probeTools, call: rt.caller("")})
+}
+
+func TestRouterDispatchesByToolName(t *testing.T) {
+	rt := testRouter()
+	if res := dispatchWith(rt, `{"tool":"echo","args":{"text":"hi"}}`); res.Output != "hi" {
+		t.Fatalf("echo: unexpected result %+v", res)
+	}
+	if res := dispatchWith(rt, `{"tool":"count","args":{"text":"a b c"}}`); res.Data != 3 {
+		t.Fatalf("count: unexpected result %+v", res)
+	}
+}
+
+func TestRouterReadsToolNameFromArgv(t *testing.T) {
+	if got := argTool([]string{"tool.wasm", "count"}); got != "count" {
+		t.Fatalf("argTool = %q, want count", got)
+	}
+	if got := argTool([]string{"tool.wasm", SchemaFlag}); got != "" {
+		t.Fatalf("argTool of a flag = %q, want none", got)
+	}
+
+	rt := testRouter()
+	call := func(tool, input string) ToolResult {
+		r := runner{stdin: strings.NewReader(input)}
+		return handle(&r, service{tools: rt.probeTools, call: rt.caller(tool)})
+	}
+	if res := call("count", `{"text":"a b c"}`); res.Data != 3 {
+		t.Fatalf("count from argv: unexpected result %+v", res)
+	}
+	if res := call("echo", ``); !res.Success || res.Output != "" {
+		t.Fatalf("echo with no stdin: unexpected result %+v", res)
+	}
+	if res := call("count", `{"tool":"echo","args":{"text":"hi"}}`); res.Output != "hi" {
+		t.Fatalf("an envelope should win over argv, got %+v", res)
+	}
+	if res := call("nope", `{}`); res.ErrorCode != CodeNotFound {
+		t.Fatalf("unknown tool from argv: unexpected result %+v", res)
+	}
+	if res := call("count", `{"text":`); res.ErrorCode != CodeInvalidInput || strings.Contains(*res.Error, "envelope") {
+		t.Fatalf("bad args from argv should fail as args, got %+v", res)
+	}
+}
+
+func TestRouterRejectsUnknownToolAndBadEnvelope(t *testing.T) {
+	rt := testRouter()
+	res := dispatchWith(rt, `{"tool":"nope"}`)
+	if res.ErrorCode != CodeNotFound || !strings.Contains(*res.Error, "registered: echo, count") {
+		t.Fatalf("unexpected result for unknown tool: %+v", res)
+	}
+	if res := dispatchWith(rt, `{"tool":`); res.ErrorCode != CodeInvalidInput {
+		t.Fatalf("unexpected result for bad envelope: %+v", res)
+	}
+}
+
+func TestRouterProbeListsToolsInRegistrationOrder(t *testing.T) {
+	res := dispatchWith(testRouter(), `{"__probe":true}`)
+	report := res.Data.(ProbeReport)
+	if len(report.Tools) != 2 || report.Tools[0].Name != "echo" || report.Tools[1].Name != "count" ||
+		report.Tools[1].Description != "Count words" {
+		t.Fatalf("unexpected tools: %+v", report.Tools)
+	}
+}
+
+func TestRouterSchemaDescribesEachTool(t *testing.T) {
+	schemas := testRouter().schemas().(map[string]ToolSchema)
+	if len(schemas) != 2 {
+		t.Fatalf("got %d schemas, want 2: %+v", len(schemas), schemas)
+	}
+	count := schemas["count"]
+	sorted := SchemaOpt{SortProperties: true}
+	if count.Description != "Count words" || !reflect.DeepEqual(count.Parameters, SchemaWith[countArgs](sorted)) {
+		t.Fatalf("count: got %+v", count)
+	}
+	if echo := schemas["echo"]; !reflect.DeepEqual(echo.Parameters, SchemaWith[echoArgs](sorted)) {
+		t.Fatalf("echo: got %+v", echo)
+	}
+
+	// A single-tool skill keeps printing its args schema as is.
+	flat := single(func(args countArgs) ToolResult { return OK("", nil) }).schema()
+	if !reflect.DeepEqual(flat, SchemaFor[countArgs]()) {
+		t.Fatalf("Run schema: got %v, want SchemaFor[countArgs]", flat)
+	}
+}
+
+func TestRouterRegisterPanicsOnDuplicateName(t *testing.T) {
+	rt := testRouter()
+	defer func() {
+		if msg, _ := recover().(string); msg != `skill: tool "echo" registered twice` {
+			t.Fatalf("recovered %q, want a duplicate registration panic", msg)
+		}
+	}()
+	rt.Register("echo", "Echo again", NewTool(func(args countArgs) ToolResult { return OK("", nil) }))
+}
+
+func TestRouterValidateNeedsATool(t *testing.T) {
+	if err := NewRouter().Validate(); !errors.Is(err, ErrNoTools) {
+		t.Fatalf("empty router: got %v, want ErrNoTools", err)
+	}
+	if err := testRouter().Validate(); err != nil {
+		t.Fatalf("router with tools: %v", err)
+	}
+}
@@ -0,0 +1,409 @@
+package skill
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"reflect"
+	"sync"
+)
+
+// JSONLinesEnv is set to "1" by hosts that run the skill in JSON-Lines mode:
+// stdin carries one JSON args object per line and stdout gets one ToolResult
+// line per input, in input order. `zeroclaw skill test --jsonl` sets it, as
+// do hosts running a skill whose manifest sets "input": "ndjson" (or the
+// older "jsonl": true). End of input ends the stream.
+const JSONLinesEnv = "ZEROCLAW_JSONL"
+
+// PrettyEnv set to "1" makes Run indent the ToolResult it writes, for a
+// person reading it. The result is still one JSON object that parses to
+// the same value; only the whitespace differs. `zeroclaw skill test` sets
+// it when its stdout is a terminal, and the Go runtime never does, so hosts
+// get compact JSON. JSON-Lines and streaming results stay one per line.
+const PrettyEnv = "ZEROCLAW_PRETTY"
+
+// Option customizes Run.
+type Option func(*runner)
+
+type runner struct {
+	stdin         io.Reader
+	stdout        io.Writer
+	stderr        io.Writer
+	expect        string
+	exitOnInvalid bool
+	name          string
+	permissions   []string
+	outputSchema  func() map[string]any
+	cleanText     bool
+	strictUTF8    bool
+	useNumber     bool
+	strictFields  bool
+	middleware    []middleware
+	meta          Meta
+	// streamMu serializes the lines a streaming skill writes and guards seq,
+	// the Seq of the last ToolResult line written.
+	streamMu sync.Mutex
+	seq      int
+	// answers reads stdin when the host answers Ask (see AskEnv): args come
+	// as the first line and each answer as one more.
+	answers *bufio.Reader
+	// panicked is set once a request's handler panics; see recoverPanic.
+	panicked bool
+	// traceID is the trace ID of the request being served; see TraceID.
+	traceID string
+	// ifNoneMatch is the ETag the request names; see IfNoneMatch.
+	ifNoneMatch string
+	// seed seeds rand, the request's random source, made on first use; see
+	// Rand.
+	seed int64
+	rand *rand.Rand
+	// maxInput caps the bytes of one request; see MaxInputBytesEnv.
+	maxInput int
+	// selfTest holds the cases SelfTestFlag runs; see SelfTest.
+	selfTest []Case
+}
+
+// service is what Run and Router.Dispatch serve: a schema for SchemaFlag, the
+// tools to list in a probe, and the request handler proper.
+type service struct {
+	schema func() any
+	tools  func(r *runner) []ProbeTool
+	call   func(r *runner, data []byte) ToolResult
+}
+
+// Expect appends an example of valid input to decode error messages, e.g.
+// Expect(`{"text":"..."}`).
+func Expect(example string) Option {
+	return func(r *runner) { r.expect = example }
+}
+
+// UseNumber makes Run decode numbers held in interface values (any,
+// map[string]any, []any) as json.Number instead of float64, so IDs above
+// 2^53 keep every digit. Typed int64 and json.Number fields decode exactly
+// with or without it; declare large IDs as one of those, or as strings.
+func UseNumber() Option {
+	return func(r *runner) { r.useNumber = true }
+}
+
+// Run reads JSON args from stdin, decodes them into A, calls handler, and
+// writes the result to stdout with MarshalStable.
+//
+// Input that cannot be read or decoded produces a failed ToolResult without
+// calling handler. If the result itself cannot be marshaled, Run reports the
+// error on stderr and exits with status 1. Started with SchemaFlag, Run prints
+// SchemaFor[A] instead and reads nothing; OutputSchemaFlag likewise prints the
+// schema registered with OutputFor, and SelfTestFlag runs the cases given to
+// SelfTest. With JSONLinesEnv set, Run serves
+// every line of stdin as its own request; see JSONLinesEnv. With
+// CompressionEnv set, stdin and stdout are gzip streams; args alone may
+// arrive gzip-compressed, too (see InputEncodingEnv). A probe envelope
+// is answered without calling handler; see ProbeField. Middleware registered
+// with Use post-processes each result handler returns. Fields A does not
+// declare are dropped, rejected with StrictFields, or collected into an
+// ExtraTag field. RunContext also gives handler a context for deadlines and
+// progress. With AskEnv set, the handler may put questions to the host with
+// Ask. With OnOversizeEnv set, a result over the host's MaxOutputBytesEnv is
+// cut down to fit; see Allowance.Truncate. A request longer than the host's
+// MaxInputBytesEnv fails with CodeInvalidInput without being buffered whole.
+// A handler that panics gets a CodeInternal result naming the panic, and Run
+// exits with ExitPanic.
+func Run[A any](handler func(args A) ToolResult, opts ...Option) {
+	serve(single(handler), opts)
+}
+
+// single adapts one handler to a service.
+func single[A any](handler func(args A) ToolResult) service {
+	return service{
+		schema: func() any { return SchemaFor[A]() },
+		tools: func(r *runner) []ProbeTool {
+			return []ProbeTool{{Name: r.name, Schema: SchemaFor[A]()}}
+		},
+		call: func(r *runner, data []byte) ToolResult {
+			return decode(r, data, handler)
+		},
+	}
+}
+
+// Handle serves args to handler once, in process, as Run serves a request
+// read from stdin: opts apply, the args are decoded and validated, a probe
+// is answered, middleware runs, and a panic becomes a CodeInternal result.
+// StrictUTF8Env and MaxInputBytesEnv apply as they do to Run, and args that
+// are gzip-compressed are inflated, as with InputEncodingEnv. Handle reads
+// no stdin, writes nothing, and ignores the command line; package skilltest
+// builds on it to test handlers without building a module.
+func Handle[A any](handler func(args A) ToolResult, args []byte, opts ...Option) ToolResult {
+	return handleOnce(single(handler), args, opts)
+}
+
+// handleOnce is the request path of serve for one request held in memory.
+func handleOnce(s service, data []byte, opts []Option) ToolResult {
+	r := runner{stdin: bytes.NewReader(data), stdout: io.Discard, stderr: io.Discard}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	prepareInput(&r)
+	return handle(&r, s)
+}
+
+// prepareInput readies r.stdin the same way for every entry point:
+// StrictUTF8Env and MaxInputBytesEnv apply, and compressed args are
+// inflated.
+func prepareInput(r *runner) {
+	r.strictUTF8 = r.strictUTF8 || strictFromEnv()
+	r.maxInput = Budget().MaxInputBytes
+	inflateInput(r)
+}
+
+func serve(s service, opts []Option) {
+	r := runner{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case SchemaFlag:
+			write(&r, s.schema())
+			return
+		case OutputSchemaFlag:
+			if r.outputSchema == nil {
+				fmt.Fprintln(os.Stderr, "no output schema: pass skill.OutputFor to Run")
+				os.Exit(1)
+			}
+			write(&r, r.outputSchema())
+			return
+		case SelfTestFlag:
+			Exit(runSelfTest(&r, s))
+		}
+	}
+	closeStdout := compressStreams(&r)
+	prepareInput(&r)
+	if os.Getenv(JSONLinesEnv) == "1" {
+		serveLines(&r, s)
+		closeStdout()
+		if r.panicked {
+			Exit(ExitPanic)
+		}
+		return
+	}
+	if os.Getenv(AskEnv) == "1" {
+		r.answers = bufio.NewReader(r.stdin)
+		asking = &r
+	}
+	res := handle(&r, s)
+	switch {
+	case r.meta.Streaming:
+		res = inLine(res)
+		writeFinal(&r, res)
+	case res.raw != nil:
+		writeRaw(r.stdout, r.stderr, *res.raw)
+	default:
+		if truncateFromEnv() {
+			res = Budget().Truncate(res)
+		}
+		if os.Getenv(PrettyEnv) == "1" {
+			writeIndented(&r, res)
+		} else {
+			write(&r, res)
+		}
+	}
+	closeStdout()
+	if r.panicked {
+		Exit(ExitPanic)
+	}
+	if r.exitOnInvalid && res.ErrorCode == CodeInvalidInput {
+		Exit(ExitInvalidInput)
+	}
+}
+
+func write(r *runner, v any) {
+	out, err := MarshalStable(v)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "json marshal error:", err)
+		os.Exit(1)
+	}
+	r.stdout.Write(out)
+}
+
+// writeIndented is write for PrettyEnv: the same JSON, two-space indented.
+func writeIndented(r *runner, v any) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "json marshal error:", err)
+		os.Exit(1)
+	}
+	r.stdout.Write(out)
+}
+
+// handle runs one request through s without touching the process streams
+// beyond r.stdin.
+func handle(r *runner, s service) ToolResult {
+	data, err := readRequest(r)
+	var tooLarge inputTooLarge
+	if errors.As(err, &tooLarge) {
+		return FailCode(CodeInvalidInput, tooLarge.Error())
+	}
+	if err != nil {
+		return FailCode(CodeInternal, fmt.Sprintf("failed to read stdin: %v", err))
+	}
+	return respond(r, s, data)
+}
+
+// readRequest reads the request from r.stdin: all of it, or only the first
+// line when the rest of stdin carries answers to Ask. A request over
+// r.maxInput fails with inputTooLarge once one byte too many is read.
+func readRequest(r *runner) ([]byte, error) {
+	if r.answers == nil {
+		if r.maxInput == 0 {
+			return io.ReadAll(r.stdin)
+		}
+		data, err := io.ReadAll(io.LimitReader(r.stdin, int64(r.maxInput)+1))
+		if err == nil && len(data) > r.maxInput {
+			err = inputTooLarge(r.maxInput)
+		}
+		return data, err
+	}
+	line, tooLong, err := readLine(r.answers, r.maxInput)
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	if tooLong && err == nil {
+		err = inputTooLarge(r.maxInput)
+	}
+	return line, err
+}
+
+// inputTooLarge is the error for a request over MaxInputBytesEnv bytes.
+type inputTooLarge int
+
+func (n inputTooLarge) Error() string {
+	return fmt.Sprintf("input exceeds %d bytes", int(n))
+}
+
+// readLine reads one line from in through its '\n', as in.ReadBytes does,
+// but keeps at most max bytes of it besides the '\n'. A longer line is read
+// to its end and dropped, reporting tooLong, so a line of any length costs
+// at most max bytes of memory. A zero max keeps every line whole.
+func readLine(in *bufio.Reader, max int) (line []byte, tooLong bool, err error) {
+	for {
+		var chunk []byte
+		chunk, err = in.ReadSlice('\n')
+		if !tooLong {
+			line = append(line, chunk...)
+			if max > 0 && len(bytes.TrimSuffix(line, []byte("\n"))) > max {
+				line, tooLong = nil, true
+			}
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return line, tooLong, err
+		}
+	}
+}
+
+// respond answers a probe envelope itself and passes anything else to s,
+// checking the OutputType and compressing the artifacts of its result. A
+// request with a trace ID gets it back in the result's Meta, even when the
+// handler panics.
+func respond(r *runner, s service, data []byte) (res ToolResult) {
+	r.seed, r.rand = requestSeed(data), nil
+	r.ifNoneMatch = requestIfNoneMatch(data)
+	if r.traceID = requestTraceID(data); r.traceID != "" {
+		defer func() { res.Meta = &ResultMeta{TraceID: r.traceID} }()
+	}
+	defer recoverPanic(r, &res)
+	if isProbe(data) {
+		return probeResult(r, s.tools(r))
+	}
+	return compressArtifacts(checkOutputType(s.call(r, data)))
+}
+
+// serveLines answers each non-blank line of r.stdin with one result line as
+// soon as it is read, so a long stream is never buffered whole. A line that
+// fails to decode gets a failed result and the stream continues.
+func serveLines(r *runner, s service) {
+	in := bufio.NewReader(r.stdin)
+	for {
+		line, tooLong, err := readLine(in, r.maxInput)
+		if tooLong {
+			write(r, FailCode(CodeInvalidInput, inputTooLarge(r.maxInput).Error()))
+			r.stdout.Write([]byte("\n"))
+		} else if line = bytes.TrimSpace(line); len(line) > 0 {
+			write(r, inLine(respond(r, s, line)))
+			r.stdout.Write([]byte("\n"))
+		}
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			write(r, FailCode(CodeInternal, fmt.Sprintf("failed to read stdin: %v", err)))
+			r.stdout.Write([]byte("\n"))
+			return
+		}
+	}
+}
+
+// unmarshalArgs is json.Unmarshal, honoring UseNumber and StrictFields.
+// Malformed data always goes through json.Unmarshal so syntax errors read the
+// same either way.
+func unmarshalArgs(r *runner, data []byte, v any) error {
+	strict := r.strictFields && extraField(reflect.TypeOf(v).Elem()) < 0
+	if (!r.useNumber && !strict) || !json.Valid(data) {
+		return json.Unmarshal(data, v)
+	}
+	if strict {
+		data = withoutReserved(data)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if r.useNumber {
+		dec.UseNumber()
+	}
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	return dec.Decode(v)
+}
+
+// decode unmarshals one request and passes it to handler.
+func decode[A any](r *runner, data []byte, handler func(args A) ToolResult) ToolResult {
+	if r.cleanText {
+		data = bytes.TrimPrefix(data, []byte(bom))
+	}
+	if r.strictUTF8 {
+		if at := invalidAt(string(data)); at >= 0 {
+			return FailCode(CodeInvalidInput, fmt.Sprintf("input is not valid UTF-8 at byte %d", at))
+		}
+	}
+	var args A
+	if err := unmarshalArgs(r, data, &args); err != nil {
+		msg := fmt.Sprintf("invalid input JSON: %v", err)
+		fe, located := decodeFieldError(data, err)
+		if fe.Expected != "" {
+			msg = "invalid input JSON: " + fe.Message
+		}
+		if located && fe.Path != "" {
+			msg += fmt.Sprintf(" (at %s)", fe.Path)
+		}
+		if r.expect != "" {
+			msg += " — expected " + r.expect
+		}
+		res := FailCode(CodeInvalidInput, msg)
+		if located {
+			res.FieldErrors = []FieldError{fe}
+		}
+		return res
+	}
+	collectExtra(data, reflect.ValueOf(&args))
+	stripped := r.cleanText && cleanFields(reflect.ValueOf(&args))
+	if errs := validateFields(reflect.ValueOf(args), ""); len(errs) > 0 {
+		return FailFields(errs...)
+	}
+	res := handler(args)
+	if stripped && res.Success {
+		res = res.Warn(BOMWarning)
+	}
+	return applyMiddleware(r, args, res)
+}
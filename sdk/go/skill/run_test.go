@@ -0,0 +1,227 @@
+package skill
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type echoArgs struct {
+	Text string `json:"text"`
+}
+
+func runWith(input string, opts ...Option) ToolResult {
+	r := runner{stdin: strings.NewReader(input)}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	return handle(&r, single(func(args echoArgs) ToolResult {
+		return OK(args.Text, nil)
+	}))
+}
+
+func TestRunDecodesArgs(t *testing.T) {
+	res := runWith(`{"text":"hi"}`)
+	if !res.Success || res.Output != "hi" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestRunReportsInvalidJSON(t *testing.T) {
+	res := runWith(`{"text":`, Expect(`{"text":"..."}`))
+	if res.Success || res.Error == nil || res.ErrorCode != CodeInvalidInput {
+		t.Fatalf("expected an invalid_input failure, got %+v", res)
+	}
+	if !strings.HasPrefix(*res.Error, "invalid input JSON: ") || !strings.HasSuffix(*res.Error, ` — expected {"text":"..."}`) {
+		t.Fatalf("unexpected error message: %q", *res.Error)
+	}
+}
+
+func TestPrettyResultParsesToTheCompactOne(t *testing.T) {
+	res := OK("2 words", map[string]any{"words": 2, "top": []string{"a", "b"}}).Warn("bom stripped")
+	var compact, pretty bytes.Buffer
+	write(&runner{stdout: &compact}, res)
+	writeIndented(&runner{stdout: &pretty}, res)
+	if bytes.Contains(compact.Bytes(), []byte("\n")) || !bytes.Contains(pretty.Bytes(), []byte("\n  \"output\": \"2 words\"")) {
+		t.Fatalf("compact %s, pretty %s", compact.Bytes(), pretty.Bytes())
+	}
+	var a, b any
+	if err := json.Unmarshal(compact.Bytes(), &a); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(&pretty)
+	if err := dec.Decode(&b); err != nil || dec.More() {
+		t.Fatalf("pretty result is not one JSON object: %v", err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("pretty result %v differs from compact %v", b, a)
+	}
+}
+
+func TestUseNumberKeepsLargeIntegers(t *testing.T) {
+	type idArgs struct {
+		ID   int64          `json:"id"`
+		Meta map[string]any `json:"meta"`
+	}
+	const big = "9007199254740993" // 2^53 + 1, which float64 rounds down
+	input := `{"id":` + big + `,"meta":{"parent":` + big + `}}`
+	run := func(opts ...Option) string {
+		var out strings.Builder
+		r := runner{stdin: strings.NewReader(input), stdout: &out}
+		for _, opt := range opts {
+			opt(&r)
+		}
+		write(&r, handle(&r, single(func(args idArgs) ToolResult {
+			return OK("", args)
+		})))
+		return out.String()
+	}
+
+	want := `{"success":true,"output":"","data":{"id":` + big + `,"meta":{"parent":` + big + `}}}`
+	if got := run(UseNumber()); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	if got := run(); !strings.Contains(got, `"id":`+big+`,`) || strings.Contains(got, `"parent":`+big) {
+		t.Fatalf("without UseNumber only the int64 field should stay exact, got %s", got)
+	}
+}
+
+func TestUseNumberKeepsSyntaxErrors(t *testing.T) {
+	res := runWith(`{"text":`, UseNumber())
+	if res.Success || res.ErrorCode != CodeInvalidInput || !strings.Contains(*res.Error, "unexpected end of JSON input") {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestServeLinesAnswersEachLineInOrder(t *testing.T) {
+	var out strings.Builder
+	r := runner{
+		stdin:  strings.NewReader("{\"text\":\"one\"}\n{\"text\":\n\n{\"text\":\"three\"}"),
+		stdout: &out,
+	}
+	serveLines(&r, single(func(args echoArgs) ToolResult {
+		return OK(args.Text, nil)
+	}))
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d result lines, want 3:\n%s", len(lines), out.String())
+	}
+	if lines[0] != `{"success":true,"output":"one"}` || lines[2] != `{"success":true,"output":"three"}` {
+		t.Fatalf("unexpected results:\n%s", out.String())
+	}
+	if !strings.Contains(lines[1], `"success":false`) || !strings.Contains(lines[1], `"error_code":"invalid_input"`) {
+		t.Fatalf("malformed line should yield an invalid_input result, got %s", lines[1])
+	}
+}
+
+func TestServeLinesEndsCleanlyAtEOF(t *testing.T) {
+	var out strings.Builder
+	r := runner{stdin: strings.NewReader("\n  \n"), stdout: &out}
+	serveLines(&r, single(func(args echoArgs) ToolResult {
+		t.Fatal("blank lines must not reach the handler")
+		return ToolResult{}
+	}))
+	if out.Len() != 0 {
+		t.Fatalf("blank input should produce no results, got %q", out.String())
+	}
+}
+
+func TestRunCapsInput(t *testing.T) {
+	input := `{"text":"hello"}` // 16 bytes
+	limit := func(n int) Option { return func(r *runner) { r.maxInput = n } }
+	if res := runWith(input, limit(len(input))); !res.Success || res.Output != "hello" {
+		t.Fatalf("input at the limit should be read whole, got %+v", res)
+	}
+	res := runWith(input, limit(len(input)-1))
+	if res.Success || res.ErrorCode != CodeInvalidInput || *res.Error != "input exceeds 15 bytes" {
+		t.Fatalf("want an invalid_input failure one byte over, got %+v", res)
+	}
+}
+
+func TestServeLinesCapsEachLine(t *testing.T) {
+	var out strings.Builder
+	r := runner{
+		stdin:    strings.NewReader("{\"text\":\"ok\"}\n{\"text\":\"" + strings.Repeat("x", 8192) + "\"}\n{\"text\":\"ok\"}"),
+		stdout:   &out,
+		maxInput: 13,
+	}
+	serveLines(&r, single(func(args echoArgs) ToolResult {
+		return OK(args.Text, nil)
+	}))
+
+	want := `{"success":true,"output":"ok"}` + "\n" +
+		`{"success":false,"output":"","error":"input exceeds 13 bytes","error_code":"invalid_input"}` + "\n" +
+		`{"success":true,"output":"ok"}` + "\n"
+	if out.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestRunChecksOutputType(t *testing.T) {
+	run := func(outputType string) ToolResult {
+		r := runner{stdin: strings.NewReader(`{"text":"# Report"}`)}
+		return handle(&r, single(func(args echoArgs) ToolResult {
+			res := OK("", nil).AsMarkdown(args.Text)
+			if outputType != "" {
+				res.OutputType = outputType
+			}
+			return res
+		}))
+	}
+
+	if res := run(""); !res.Success || res.Output != "# Report" || res.OutputType != OutputMarkdown {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if res := run("text/html; charset=utf-8"); !res.Success {
+		t.Fatalf("a media type with parameters should pass: %+v", res)
+	}
+	for _, bad := range []string{"markdown", "text/", "text html"} {
+		res := run(bad)
+		if res.Success || res.ErrorCode != CodeInternal || !strings.Contains(*res.Error, "output_type") {
+			t.Fatalf("output_type %q: expected an internal failure, got %+v", bad, res)
+		}
+	}
+}
+
+func TestOutputTypeIsOmittedWhenUnset(t *testing.T) {
+	out, err := MarshalStable(OK("2 words", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "output_type") {
+		t.Fatalf("plain results should not carry output_type: %s", out)
+	}
+}
+
+func TestWarnAppendsWithoutSharing(t *testing.T) {
+	base := OK("2 words", nil).Warn("first")
+	a, b := base.Warn("a"), base.Warn("b")
+	if got := strings.Join(a.Warnings, ","); got != "first,a" {
+		t.Fatalf("a.Warnings = %q", a.Warnings)
+	}
+	if got := strings.Join(b.Warnings, ","); got != "first,b" {
+		t.Fatalf("b.Warnings = %q", b.Warnings)
+	}
+	out, err := MarshalStable(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `"warnings":["first","a"]`) {
+		t.Fatalf("warnings not written: %s", out)
+	}
+}
+
+func TestWarningsAreOmittedWhenEmpty(t *testing.T) {
+	res := OK("2 words", nil)
+	res.Warnings = []string{}
+	out, err := MarshalStable(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "warnings") {
+		t.Fatalf("a result without warnings should not carry the key: %s", out)
+	}
+}
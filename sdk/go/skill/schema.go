@@ -0,0 +1,208 @@
+package skill
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaFlag is the command-line flag that makes Run print the args schema
+// instead of handling a request. `zeroclaw skill schema-diff` relies on it.
+const SchemaFlag = "--schema"
+
+// OutputSchemaFlag makes Run print the schema registered with OutputFor, the
+// contract `zeroclaw skill test --check-output` holds Data to when the skill
+// ships no output.schema.json.
+const OutputSchemaFlag = "--output-schema"
+
+// SecretSchemaKey marks a property of the args schema whose value hosts
+// must not log (see SchemaFor).
+const SecretSchemaKey = "x-zeroclaw-secret"
+
+// OutputFor declares D as the type of the result's Data, so the skill can
+// print its schema for OutputSchemaFlag.
+func OutputFor[D any]() Option {
+	return func(r *runner) { r.outputSchema = SchemaFor[D] }
+}
+
+// SchemaFor returns the JSON Schema of A, derived from its json tags.
+//
+// Struct fields become properties, those of an untagged embedded struct
+// among them as encoding/json promotes them; a field is required unless it
+// is a pointer, tagged omitempty, or promoted through an embedded pointer.
+// The fields of an embedded pointer to an unexported struct are left out:
+// encoding/json cannot allocate that struct, so it cannot decode them. A
+// `desc:"..."` tag becomes the property description.
+// A []byte field is a base64 string, as encoding/json decodes it. A
+// `validate:"oneof=a|b|c"` tag on a string or []string field becomes an
+// "enum" of those values, the same set Run enforces before the handler runs;
+// `validate:"min=0"` and `validate:"max=n"` on an integer field likewise
+// become "minimum" and "maximum". A `secret:"true"` tag, for args such as
+// an API key, marks the property "x-zeroclaw-secret": hosts then show and
+// record the value as "***", though the handler still gets it.
+func SchemaFor[A any]() map[string]any {
+	return schemaOf(reflect.TypeOf((*A)(nil)).Elem())
+}
+
+// SchemaOpt adjusts the schema SchemaWith derives.
+type SchemaOpt struct {
+	// SortProperties sorts every "required" list by name instead of
+	// leaving it in field order, so moving a field, or a whole embedded
+	// struct, leaves the schema byte for byte the same. "properties" is a
+	// map and is written in key order either way.
+	SortProperties bool
+}
+
+// SchemaWith is SchemaFor with opt applied. A Router's tools export their
+// parameters with SortProperties set.
+func SchemaWith[A any](opt SchemaOpt) map[string]any {
+	schema := SchemaFor[A]()
+	if opt.SortProperties {
+		sortRequired(schema)
+	}
+	return schema
+}
+
+// sortRequired sorts the "required" list of schema and of every schema
+// nested in it.
+func sortRequired(schema map[string]any) {
+	for key, v := range schema {
+		switch v := v.(type) {
+		case []string:
+			if key == "required" {
+				sort.Strings(v)
+			}
+		case map[string]any:
+			sortRequired(v)
+		}
+	}
+}
+
+func schemaOf(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(json.Number("")) {
+		return map[string]any{"type": "number"}
+	}
+	if t == reflect.TypeOf(json.RawMessage(nil)) {
+		return map[string]any{} // any JSON value
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return map[string]any{"type": "string", "contentEncoding": "base64"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	}
+	return map[string]any{}
+}
+
+func structSchema(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	required := []string{}
+	addFields(t, props, &required, false)
+	return map[string]any{"type": "object", "properties": props, "required": required}
+}
+
+// addFields adds the properties of t's fields to props, and the names of
+// those required to required unless optional is set. A promoted field does
+// not replace one of the same name declared nearer the top, as in
+// encoding/json.
+func addFields(t reflect.Type, props map[string]any, required *[]string, optional bool) {
+	var embedded []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			if !unsettable(f) {
+				embedded = append(embedded, f)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if _, ok := props[name]; ok {
+			continue
+		}
+		props[name] = fieldSchema(f)
+		if !optional && f.Type.Kind() != reflect.Pointer && !hasOpt(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+	for _, f := range embedded {
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			addFields(ft.Elem(), props, required, true)
+		} else {
+			addFields(ft, props, required, optional)
+		}
+	}
+}
+
+// unsettable reports whether f embeds a pointer to an unexported struct
+// type. encoding/json fails with "cannot set embedded pointer to unexported
+// struct" rather than decode into such a field, so its fields are not args.
+func unsettable(f reflect.StructField) bool {
+	return f.Anonymous && !f.IsExported() && f.Type.Kind() == reflect.Pointer
+}
+
+func fieldSchema(f reflect.StructField) map[string]any {
+	prop := schemaOf(f.Type)
+	if values := oneOf(f); values != nil {
+		if items, ok := prop["items"].(map[string]any); ok {
+			items["enum"] = values
+		} else {
+			prop["enum"] = values
+		}
+	}
+	if b, ok := boundsOf(f); ok {
+		if b.hasMin {
+			prop["minimum"] = b.min
+		}
+		if b.hasMax {
+			prop["maximum"] = b.max
+		}
+	}
+	if desc := f.Tag.Get("desc"); desc != "" {
+		prop["description"] = desc
+	}
+	if f.Tag.Get("secret") == "true" {
+		prop[SecretSchemaKey] = true
+	}
+	return prop
+}
+
+func hasOpt(opts, want string) bool {
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == want {
+			return true
+		}
+	}
+	return false
+}
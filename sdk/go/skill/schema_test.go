@@ -0,0 +1,180 @@
+package skill
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type schemaArgs struct {
+	Text   string   `json:"text" desc:"Text to analyze"`
+	Locale string   `json:"locale,omitempty"`
+	Limit  *int     `json:"limit"`
+	Tags   []string `json:"tags,omitempty"`
+	Ratio  float64
+	hidden bool
+	Skip   bool `json:"-"`
+}
+
+func TestSchemaForDerivesPropertiesAndRequired(t *testing.T) {
+	got, err := MarshalStable(SchemaFor[schemaArgs]())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"properties":{"Ratio":{"type":"number"},"limit":{"type":"integer"},"locale":{"type":"string"},` +
+		`"tags":{"items":{"type":"string"},"type":"array"},"text":{"description":"Text to analyze","type":"string"}},` +
+		`"required":["text","Ratio"],"type":"object"}`
+	if string(got) != want {
+		t.Fatalf("schema mismatch\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestSchemaForTypesJSONNumberAsNumber(t *testing.T) {
+	type idArgs struct {
+		ID json.Number `json:"id"`
+	}
+	got, _ := MarshalStable(SchemaFor[idArgs]())
+	if want := `{"properties":{"id":{"type":"number"}},"required":["id"],"type":"object"}`; string(got) != want {
+		t.Fatalf("schema mismatch\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestSchemaForTypesBytesAsBase64(t *testing.T) {
+	type blobArgs struct {
+		Data []byte          `json:"data_base64"`
+		Raw  json.RawMessage `json:"raw"`
+	}
+	got, _ := MarshalStable(SchemaFor[blobArgs]())
+	if want := `{"properties":{"data_base64":{"contentEncoding":"base64","type":"string"},"raw":{}},"required":["data_base64","raw"],"type":"object"}`; string(got) != want {
+		t.Fatalf("schema mismatch\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestSchemaForMarksSecretFields(t *testing.T) {
+	type keyArgs struct {
+		APIKey string `json:"api_key" secret:"true"`
+		Query  string `json:"query"`
+	}
+	got, _ := MarshalStable(SchemaFor[keyArgs]())
+	if want := `{"properties":{"api_key":{"type":"string","x-zeroclaw-secret":true},"query":{"type":"string"}},"required":["api_key","query"],"type":"object"}`; string(got) != want {
+		t.Fatalf("schema mismatch\n got: %s\nwant: %s", got, want)
+	}
+}
+
+// Page is exported so that composedArgs can embed a pointer to it: the
+// fields of an embedded pointer to an unexported struct cannot be decoded.
+type Page struct {
+	Cursor string `json:"cursor,omitempty"`
+	Size   int    `json:"size"`
+}
+
+type filterArgs struct {
+	Labels map[string]string `json:"labels"`
+	Query  string            `json:"query"`
+}
+
+type composedArgs struct {
+	Zone    string                    `json:"zone"`
+	Weights map[string]float64        `json:"weights"`
+	Nested  map[string]map[string]int `json:"nested,omitempty"`
+	filterArgs
+	*Page
+	Query string `json:"query,omitempty"`
+}
+
+func TestSchemaForPromotesEmbeddedFields(t *testing.T) {
+	got, _ := MarshalStable(SchemaFor[composedArgs]())
+	want := `{"properties":{"cursor":{"type":"string"},"labels":{"additionalProperties":{"type":"string"},"type":"object"},` +
+		`"nested":{"additionalProperties":{"additionalProperties":{"type":"integer"},"type":"object"},"type":"object"},` +
+		`"query":{"type":"string"},"size":{"type":"integer"},"weights":{"additionalProperties":{"type":"number"},"type":"object"},` +
+		`"zone":{"type":"string"}},"required":["zone","weights","labels"],"type":"object"}`
+	if string(got) != want {
+		t.Fatalf("schema mismatch\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestSchemaForSkipsUnsettableEmbeds(t *testing.T) {
+	type hiddenPage struct {
+		Cursor string `json:"cursor"`
+	}
+	type hiddenArgs struct {
+		Zone string `json:"zone"`
+		*hiddenPage
+	}
+	got, _ := MarshalStable(SchemaFor[hiddenArgs]())
+	if want := `{"properties":{"zone":{"type":"string"}},"required":["zone"],"type":"object"}`; string(got) != want {
+		t.Fatalf("schema mismatch\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestPromotedFieldsDecode(t *testing.T) {
+	var got composedArgs
+	res := Handle(func(args composedArgs) ToolResult {
+		got = args
+		return OK("", nil)
+	}, []byte(`{"zone":"eu","weights":{"a":1},"labels":{"k":"v"},"query":"q","cursor":"c2","size":20}`))
+	if !res.Success {
+		t.Fatalf("decode failed: %+v", res)
+	}
+	if got.Page == nil || got.Cursor != "c2" || got.Size != 20 || got.Labels["k"] != "v" {
+		t.Fatalf("promoted fields not decoded: %+v", got)
+	}
+	// The outer query shadows filterArgs', as the schema has it.
+	if got.Query != "q" || got.filterArgs.Query != "" {
+		t.Fatalf("query went to %q and %q", got.Query, got.filterArgs.Query)
+	}
+
+	// Without any of its fields the embedded pointer stays nil.
+	res = Handle(func(args composedArgs) ToolResult {
+		got = args
+		return OK("", nil)
+	}, []byte(`{"zone":"eu","weights":{},"labels":{}}`))
+	if !res.Success || got.Page != nil {
+		t.Fatalf("got %+v, %+v", res, got)
+	}
+}
+
+func TestSchemaWithSortPropertiesIsStable(t *testing.T) {
+	sorted := SchemaOpt{SortProperties: true}
+	first, err := MarshalStable(SchemaWith[composedArgs](sorted))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		again, _ := MarshalStable(SchemaWith[composedArgs](sorted))
+		if string(again) != string(first) {
+			t.Fatalf("generation %d differs\n got: %s\nwant: %s", i, again, first)
+		}
+	}
+	if want := `"required":["labels","weights","zone"]`; !strings.Contains(string(first), want) {
+		t.Fatalf("schema %s lacks %s", first, want)
+	}
+
+	type item struct {
+		B string `json:"b"`
+		A string `json:"a"`
+	}
+	type listArgs struct {
+		Items []item          `json:"items"`
+		ByKey map[string]item `json:"by_key"`
+	}
+	got, _ := MarshalStable(SchemaWith[listArgs](sorted))
+	if n := strings.Count(string(got), `"required":["a","b"]`); n != 2 {
+		t.Fatalf("nested required lists not sorted in %s", got)
+	}
+	if !strings.Contains(string(got), `"required":["by_key","items"]`) {
+		t.Fatalf("top-level required not sorted in %s", got)
+	}
+}
+
+func TestOutputForRegistersDataSchema(t *testing.T) {
+	type counts struct {
+		Words int `json:"words"`
+	}
+	var r runner
+	OutputFor[counts]()(&r)
+	props := r.outputSchema()["properties"].(map[string]any)
+	if props["words"].(map[string]any)["type"] != "integer" {
+		t.Fatalf("unexpected output schema: %v", r.outputSchema())
+	}
+}
@@ -0,0 +1,16 @@
+package skill
+
+import "os"
+
+// SecretEnvPrefix prefixes the environment variable through which a host
+// passes each secret a skill declares under "capabilities.secrets" in its
+// manifest: secret API_KEY arrives as ZEROCLAW_SECRET_API_KEY. Hosts keep
+// secrets out of the args and redact their values from anything the skill
+// writes, so a handler need not scrub its own output.
+const SecretEnvPrefix = "ZEROCLAW_SECRET_"
+
+// Secret returns the value of the declared secret key, and false when the
+// host did not provide it. Do not put the value in a result or a log line.
+func Secret(key string) (string, bool) {
+	return os.LookupEnv(SecretEnvPrefix + key)
+}
@@ -0,0 +1,13 @@
+package skill
+
+import "testing"
+
+func TestSecretReadsPrefixedEnv(t *testing.T) {
+	t.Setenv(SecretEnvPrefix+"API_KEY", "k1")
+	if v, ok := Secret("API_KEY"); !ok || v != "k1" {
+		t.Fatalf("Secret(API_KEY) = %q, %v", v, ok)
+	}
+	if _, ok := Secret("MISSING"); ok {
+		t.Fatal("an absent secret should report false")
+	}
+}
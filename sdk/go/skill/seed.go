@@ -0,0 +1,52 @@
+package skill
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+)
+
+// SeedField is the request field through which a host pins the randomness
+// of a request, e.g. {"items":[1,2,3],"_seed":42}, so a golden test gets the
+// same shuffle every run. Like TraceField it is reserved: it sits beside the
+// args, StrictFields accepts it, and handlers decoding into a struct never
+// see it.
+const SeedField = "_seed"
+
+// SeedEnv holds the seed of the invocation, for requests that do not carry
+// SeedField. The Go runtime sets it from Config.Seed.
+const SeedEnv = "ZEROCLAW_SEED"
+
+// Rand returns the random source of the request ctx was made for, seeded
+// from its SeedField or else SeedEnv, or from the clock when the host gave
+// neither. Calls for one request share the source, so a handler draws the
+// same sequence for the same seed however it splits the draws. ctx must come
+// from a RunContext handler; the source is not safe for concurrent use.
+func Rand(ctx context.Context) *rand.Rand {
+	r, _ := ctx.Value(progressKey{}).(*runner)
+	if r == nil {
+		return rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	if r.rand == nil {
+		r.rand = rand.New(rand.NewSource(r.seed))
+	}
+	return r.rand
+}
+
+// requestSeed reads data's SeedField, falling back to SeedEnv and then the
+// clock.
+func requestSeed(data []byte) int64 {
+	var req struct {
+		Seed *int64 `json:"_seed"`
+	}
+	if json.Unmarshal(data, &req) == nil && req.Seed != nil {
+		return *req.Seed
+	}
+	if seed, err := strconv.ParseInt(os.Getenv(SeedEnv), 10, 64); err == nil {
+		return seed
+	}
+	return time.Now().UnixNano()
+}
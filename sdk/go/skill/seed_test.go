@@ -0,0 +1,53 @@
+package skill
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type shuffleArgs struct {
+	Items []string `json:"items"`
+}
+
+// shuffle runs a handler that shuffles its items with Rand on input.
+func shuffle(t *testing.T, input string, opts ...Option) ToolResult {
+	t.Helper()
+	r := runner{stdin: strings.NewReader(input)}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	return handle(&r, withContext(func(ctx context.Context, args shuffleArgs) ToolResult {
+		rng := Rand(ctx)
+		rng.Shuffle(len(args.Items), func(i, j int) { args.Items[i], args.Items[j] = args.Items[j], args.Items[i] })
+		return OK(strings.Join(args.Items, " "), nil)
+	}))
+}
+
+const shuffleItems = `"items":["a","b","c","d","e","f","g","h","i","j","k","l"]`
+
+func TestRandIsPinnedBySeed(t *testing.T) {
+	t.Setenv(SeedEnv, "")
+	first := shuffle(t, `{`+shuffleItems+`,"_seed":7}`)
+	if again := shuffle(t, `{`+shuffleItems+`,"_seed":7}`); again.Output != first.Output {
+		t.Fatalf("the same seed should shuffle the same way: %q, then %q", first.Output, again.Output)
+	}
+	if other := shuffle(t, `{`+shuffleItems+`,"_seed":8}`); other.Output == first.Output {
+		t.Fatalf("another seed should shuffle differently, both gave %q", first.Output)
+	}
+
+	t.Setenv(SeedEnv, "7")
+	if env := shuffle(t, `{`+shuffleItems+`}`); env.Output != first.Output {
+		t.Fatalf("SeedEnv should seed a request without SeedField: %q, want %q", env.Output, first.Output)
+	}
+}
+
+func TestStrictFieldsAcceptsReservedFields(t *testing.T) {
+	res := shuffle(t, `{`+shuffleItems+`,"_seed":7,"_trace_id":"abc"}`, StrictFields())
+	if !res.Success {
+		t.Fatalf("reserved fields should pass StrictFields, got %+v", res)
+	}
+	if res := shuffle(t, `{`+shuffleItems+`,"_sed":7}`, StrictFields()); res.ErrorCode != CodeInvalidInput {
+		t.Fatalf("an unknown field should still fail, got %+v", res)
+	}
+}
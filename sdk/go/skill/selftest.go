@@ -0,0 +1,119 @@
+package skill
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SelfTestFlag makes Run, given SelfTest, run the skill's embedded cases
+// through its handler instead of serving a request, so the skill checks
+// itself with no host and no fixtures file: `wasmtime run tool.wasm
+// --self-test`.
+const SelfTestFlag = "--self-test"
+
+// Case is one embedded self-test. Args are decoded and handled as a request
+// would be, and the result must contain Expect: every key Expect names must
+// hold an equal value, as `zeroclaw skill test --cases` matches its
+// fixtures. A Case with no Expect passes when the result is a success.
+type Case struct {
+	Name   string
+	Args   string
+	Expect string
+}
+
+// SelfTest embeds cases for SelfTestFlag. Run prints a line per case and a
+// summary, and exits with status 1 if any case failed.
+func SelfTest(cases []Case) Option {
+	return func(r *runner) { r.selfTest = cases }
+}
+
+// runSelfTest runs r.selfTest through s, writes the report to r.stdout, and
+// returns the exit status.
+func runSelfTest(r *runner, s service) int {
+	if r.selfTest == nil {
+		fmt.Fprintln(r.stderr, "no self-test cases: pass skill.SelfTest to Run")
+		return 1
+	}
+	failed := 0
+	for _, c := range r.selfTest {
+		if why := checkCase(r, s, c); why != "" {
+			failed++
+			fmt.Fprintf(r.stdout, "  ✗ %s: %s\n", c.Name, why)
+		} else {
+			fmt.Fprintf(r.stdout, "  ✓ %s\n", c.Name)
+		}
+	}
+	fmt.Fprintf(r.stdout, "  %d passed, %d failed\n", len(r.selfTest)-failed, failed)
+	if failed > 0 {
+		return 1
+	}
+	return ExitOK
+}
+
+// checkCase runs c and says how its result departs from c.Expect, or "" when
+// it does not.
+func checkCase(r *runner, s service, c Case) string {
+	expect := c.Expect
+	if expect == "" {
+		expect = `{"success":true}`
+	}
+	var want any
+	if err := json.Unmarshal([]byte(expect), &want); err != nil {
+		return fmt.Sprintf("expect is not valid JSON: %v", err)
+	}
+	args := c.Args
+	if args == "" {
+		args = "{}"
+	}
+	out, err := MarshalStable(respond(r, s, []byte(args)))
+	if err != nil {
+		return fmt.Sprintf("result does not marshal: %v", err)
+	}
+	var got any
+	json.Unmarshal(out, &got)
+	var diff []string
+	subsetDiff("", want, got, &diff)
+	return strings.Join(diff, "; ")
+}
+
+// subsetDiff appends to diff a "path: ..." line for each value in want that
+// got lacks or holds differently. Objects are compared key by key; anything
+// else must be equal.
+func subsetDiff(path string, want, got any, diff *[]string) {
+	if wantObj, ok := want.(map[string]any); ok {
+		if gotObj, ok := got.(map[string]any); ok {
+			keys := make([]string, 0, len(wantObj))
+			for key := range wantObj {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				field := key
+				if path != "" {
+					field = path + "." + key
+				}
+				value, present := gotObj[key]
+				if !present {
+					*diff = append(*diff, fmt.Sprintf("%s: expected %s, missing", field, compact(wantObj[key])))
+					continue
+				}
+				subsetDiff(field, wantObj[key], value, diff)
+			}
+			return
+		}
+	}
+	if w, g := compact(want), compact(got); w != g {
+		if path == "" {
+			path = "result"
+		}
+		*diff = append(*diff, fmt.Sprintf("%s: expected %s, got %s", path, w, g))
+	}
+}
+
+// compact renders v as JSON with sorted keys.
+func compact(v any) string {
+	out, _ := json.Marshal(v)
+	return string(out)
+}
@@ -0,0 +1,46 @@
+package skill
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelfTestReportsEachCase(t *testing.T) {
+	count := single(func(args countArgs) ToolResult {
+		return OK("", map[string]any{"words": len(strings.Fields(args.Text))})
+	})
+	var out, errs strings.Builder
+	r := runner{stdout: &out, stderr: &errs}
+	SelfTest([]Case{
+		{Name: "two words", Args: `{"text":"a b"}`, Expect: `{"data":{"words":2}}`},
+		{Name: "no expect", Args: `{"text":""}`},
+		{Name: "wrong count", Args: `{"text":"a b c"}`, Expect: `{"data":{"words":2,"lines":1}}`},
+		{Name: "bad args", Args: `{"text":1}`},
+	})(&r)
+
+	if code := runSelfTest(&r, count); code != 1 {
+		t.Fatalf("exit status %d, want 1", code)
+	}
+	want := "  ✓ two words\n" +
+		"  ✓ no expect\n" +
+		"  ✗ wrong count: data.lines: expected 1, missing; data.words: expected 2, got 3\n" +
+		"  ✗ bad args: success: expected true, got false\n" +
+		"  2 passed, 2 failed\n"
+	if out.String() != want {
+		t.Fatalf("report:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	r.selfTest = r.selfTest[:2]
+	out.Reset()
+	if code := runSelfTest(&r, count); code != ExitOK || !strings.HasSuffix(out.String(), "  2 passed, 0 failed\n") {
+		t.Fatalf("passing cases: exit %d, report %q", code, out.String())
+	}
+}
+
+func TestSelfTestNeedsCases(t *testing.T) {
+	var out, errs strings.Builder
+	r := runner{stdout: &out, stderr: &errs}
+	if code := runSelfTest(&r, service{}); code != 1 || !strings.Contains(errs.String(), "skill.SelfTest") {
+		t.Fatalf("exit %d, stderr %q", code, errs.String())
+	}
+}
@@ -0,0 +1,219 @@
+package skill
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// StrictUTF8Env set to "1" turns on StrictUTF8 without rebuilding the skill;
+// `zeroclaw skill test --strict-utf8` sets it.
+const StrictUTF8Env = "ZEROCLAW_STRICT_UTF8"
+
+// ErrInvalidUTF8 is returned by DecodeText in strict mode.
+var ErrInvalidUTF8 = errors.New("invalid UTF-8")
+
+const bom = "\ufeff"
+
+// BOMWarning is the warning CleanText adds when it stripped a field's BOM.
+const BOMWarning = "stripped a leading byte order mark (U+FEFF)"
+
+// CleanText makes Run normalize fields tagged `text:"clean"` (strings, and the
+// strings of slices and maps) before the handler sees them: a leading UTF-8 BOM is stripped and invalid byte
+// sequences become U+FFFD, so counts do not depend on where the text came
+// from. A BOM in front of the JSON input itself is skipped as well. A result
+// the handler returns successfully for args whose fields had a BOM carries
+// BOMWarning.
+func CleanText() Option {
+	return func(r *runner) { r.cleanText = true }
+}
+
+// StrictUTF8 makes Run reject input that is not valid UTF-8 with
+// CodeInvalidInput instead of repairing it.
+func StrictUTF8() Option {
+	return func(r *runner) { r.strictUTF8 = true }
+}
+
+// Encodings InspectText reads, as reported in DecodedText.Encoding.
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+)
+
+// DecodedText is the result of InspectText.
+type DecodedText struct {
+	Text string
+	// Encoding is the encoding declared by a byte order mark, or EncodingUTF8
+	// when there is none.
+	Encoding string
+	// InvalidBytes counts the bytes that were not valid in Encoding. Each run
+	// of them became one U+FFFD in Text, so a non-zero count means counts
+	// over Text include replacement characters rather than what was meant.
+	InvalidBytes int
+}
+
+// DecodeText turns raw bytes, such as a file's contents, into text the way
+// CleanText treats tagged fields; see InspectText.
+func DecodeText(b []byte) (string, error) {
+	d, err := InspectText(b)
+	return d.Text, err
+}
+
+// InspectText decodes raw bytes as the encoding their byte order mark
+// declares (UTF-8, UTF-16LE or UTF-16BE), defaulting to UTF-8, and strips the
+// mark. Invalid sequences are replaced with U+FFFD and counted, so a caller
+// can warn about Latin-1 or corrupt input instead of counting garbage. With
+// StrictUTF8Env set it returns ErrInvalidUTF8 instead of repairing them.
+func InspectText(b []byte) (DecodedText, error) {
+	var d DecodedText
+	var at int
+	switch {
+	case len(b) >= 2 && b[0] == 0xff && b[1] == 0xfe:
+		d.Encoding = EncodingUTF16LE
+		d.Text, d.InvalidBytes, at = decodeUTF16(b[2:], binary.LittleEndian)
+	case len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff:
+		d.Encoding = EncodingUTF16BE
+		d.Text, d.InvalidBytes, at = decodeUTF16(b[2:], binary.BigEndian)
+	default:
+		d.Encoding = EncodingUTF8
+		d.Text = strings.TrimPrefix(string(b), bom)
+		if at = invalidAt(d.Text); at >= 0 {
+			d.InvalidBytes = countInvalid(d.Text)
+			d.Text = strings.ToValidUTF8(d.Text, string(utf8.RuneError))
+		}
+	}
+	if d.Encoding != EncodingUTF8 && at >= 0 {
+		at += 2 // past the BOM
+	}
+	if d.InvalidBytes > 0 && strictFromEnv() {
+		return DecodedText{}, fmt.Errorf("%w at byte %d", ErrInvalidUTF8, at)
+	}
+	return d, nil
+}
+
+// decodeUTF16 decodes UTF-16 code units. Unpaired surrogates and a trailing
+// odd byte are invalid; as with ToValidUTF8, each run of them becomes one
+// U+FFFD. at is the offset of the first invalid byte in b, or -1.
+func decodeUTF16(b []byte, order binary.ByteOrder) (text string, invalid, at int) {
+	var sb strings.Builder
+	at = -1
+	inRun := false
+	bad := func(i, n int) {
+		if at < 0 {
+			at = i
+		}
+		if !inRun {
+			sb.WriteRune(utf8.RuneError)
+		}
+		inRun = true
+		invalid += n
+	}
+	i := 0
+	for ; i+1 < len(b); i += 2 {
+		u := rune(order.Uint16(b[i:]))
+		if !utf16.IsSurrogate(u) {
+			sb.WriteRune(u)
+			inRun = false
+			continue
+		}
+		if i+3 < len(b) {
+			if r := utf16.DecodeRune(u, rune(order.Uint16(b[i+2:]))); r != utf8.RuneError {
+				sb.WriteRune(r)
+				inRun = false
+				i += 2
+				continue
+			}
+		}
+		bad(i, 2)
+	}
+	if i < len(b) {
+		bad(i, 1)
+	}
+	return sb.String(), invalid, at
+}
+
+// countInvalid returns how many bytes of s are not part of valid UTF-8.
+func countInvalid(s string) int {
+	n := 0
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			n++
+		}
+		i += size
+	}
+	return n
+}
+
+func strictFromEnv() bool {
+	return os.Getenv(StrictUTF8Env) == "1"
+}
+
+// cleanString strips a leading BOM from s and repairs invalid UTF-8,
+// reporting whether there was a BOM.
+func cleanString(s string) (string, bool) {
+	trimmed, stripped := strings.CutPrefix(s, bom)
+	return strings.ToValidUTF8(trimmed, string(utf8.RuneError)), stripped
+}
+
+// invalidAt returns the offset of the first invalid UTF-8 byte in s, or -1.
+func invalidAt(s string) int {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
+
+// cleanFields applies cleanString to the tagged string and []string fields of
+// v, descending into nested structs. It reports whether any had a BOM.
+func cleanFields(v reflect.Value) (stripped bool) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+	clean := func(s string) string {
+		s, had := cleanString(s)
+		stripped = stripped || had
+		return s
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f, fv := t.Field(i), v.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if f.Tag.Get("text") != "clean" {
+			stripped = cleanFields(fv) || stripped
+			continue
+		}
+		switch {
+		case fv.Kind() == reflect.String:
+			fv.SetString(clean(fv.String()))
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.String:
+			for j := 0; j < fv.Len(); j++ {
+				fv.Index(j).SetString(clean(fv.Index(j).String()))
+			}
+		case fv.Kind() == reflect.Map && fv.Type().Elem().Kind() == reflect.String:
+			iter := fv.MapRange()
+			for iter.Next() {
+				fv.SetMapIndex(iter.Key(), reflect.ValueOf(clean(iter.Value().String())).Convert(fv.Type().Elem()))
+			}
+		}
+	}
+	return stripped
+}
@@ -0,0 +1,132 @@
+package skill
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type textArgs struct {
+	Text   string            `json:"text" text:"clean"`
+	Lines  []string          `json:"lines" text:"clean"`
+	Fields map[string]string `json:"fields" text:"clean"`
+	Raw    string            `json:"raw"`
+}
+
+func decodeText(input string, opts ...Option) (textArgs, ToolResult) {
+	r := runner{}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	var got textArgs
+	res := decode(&r, []byte(input), func(args textArgs) ToolResult {
+		got = args
+		return OK("", nil)
+	})
+	return got, res
+}
+
+func TestCleanTextStripsBOMAndRepairsTaggedFields(t *testing.T) {
+	input := "\ufeff{\"text\":\"\ufeffhello \xffworld\",\"lines\":[\"\ufeffa\"],\"fields\":{\"t\":\"\ufeffc\"},\"raw\":\"\ufeffb\"}"
+	got, res := decodeText(input, CleanText())
+	if !res.Success {
+		t.Fatalf("unexpected failure: %+v", res)
+	}
+	if got.Text != "hello \uFFFDworld" || got.Lines[0] != "a" || got.Fields["t"] != "c" {
+		t.Fatalf("tagged fields not cleaned: %q %q %q", got.Text, got.Lines, got.Fields)
+	}
+	if got.Raw != "\ufeffb" {
+		t.Fatalf("untagged field should be left alone, got %q", got.Raw)
+	}
+	if len(strings.Fields(got.Text)) != 2 {
+		t.Fatalf("word count skewed: %q", got.Text)
+	}
+}
+
+func TestCleanTextWarnsOfAStrippedBOM(t *testing.T) {
+	for input, want := range map[string]bool{
+		`{"text":"\ufeffhi"}`:           true,
+		`{"lines":["a","\ufeffb"]}`:     true,
+		`{"fields":{"t":"\ufeffc"}}`:    true,
+		"\ufeff{\"text\":\"hi\"}":       false,
+		`{"raw":"\ufeffb","text":"hi"}`: false,
+	} {
+		_, res := decodeText(input, CleanText())
+		if got := len(res.Warnings) == 1 && res.Warnings[0] == BOMWarning; got != want || len(res.Warnings) > 1 {
+			t.Errorf("%s: warnings %q, want BOMWarning %v", input, res.Warnings, want)
+		}
+	}
+	r := runner{cleanText: true}
+	res := decode(&r, []byte(`{"text":"\ufeffhi"}`), func(textArgs) ToolResult { return Fail("no") })
+	if len(res.Warnings) != 0 {
+		t.Fatalf("a failed result should not be warned about the BOM: %+v", res)
+	}
+}
+
+func TestStrictUTF8RejectsInvalidInput(t *testing.T) {
+	_, res := decodeText("{\"text\":\"ok \xff\"}", CleanText(), StrictUTF8())
+	if res.ErrorCode != CodeInvalidInput || !strings.Contains(*res.Error, "not valid UTF-8 at byte 12") {
+		t.Fatalf("expected an invalid_input failure, got %+v", res)
+	}
+	if _, res := decodeText("\ufeff{\"text\":\"ok\"}", CleanText(), StrictUTF8()); !res.Success {
+		t.Fatalf("a BOM is valid UTF-8 and should pass strict mode: %+v", res)
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	got, err := DecodeText([]byte("\ufeffab\xfe"))
+	if err != nil || got != "ab\uFFFD" {
+		t.Fatalf("DecodeText = %q, %v", got, err)
+	}
+	t.Setenv(StrictUTF8Env, "1")
+	if _, err := DecodeText([]byte("ab\xfe")); !errors.Is(err, ErrInvalidUTF8) {
+		t.Fatalf("strict DecodeText: got %v, want ErrInvalidUTF8", err)
+	}
+}
+
+func TestInspectTextCountsInvalidBytesFromFile(t *testing.T) {
+	dir := t.TempDir()
+	// "café" in Latin-1, then a truncated UTF-8 sequence: three invalid bytes.
+	if err := os.WriteFile(filepath.Join(dir, "latin1.txt"), []byte("caf\xe9 ok \xe2\x82"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(PreopensEnv, filepath.ToSlash(dir))
+
+	raw, err := ReadFile("latin1.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := InspectText(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DecodedText{Text: "caf\uFFFD ok \uFFFD", Encoding: EncodingUTF8, InvalidBytes: 3}
+	if got != want {
+		t.Fatalf("InspectText = %+v, want %+v", got, want)
+	}
+
+	t.Setenv(StrictUTF8Env, "1")
+	if _, err := InspectText(raw); !errors.Is(err, ErrInvalidUTF8) || !strings.Contains(err.Error(), "at byte 3") {
+		t.Fatalf("strict InspectText: got %v, want ErrInvalidUTF8 at byte 3", err)
+	}
+}
+
+func TestInspectTextSniffsBOMEncoding(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		raw  string
+		want DecodedText
+	}{
+		{"utf-8 BOM", "\xef\xbb\xbfhi", DecodedText{Text: "hi", Encoding: EncodingUTF8}},
+		{"utf-16le", "\xff\xfeh\x00\xe9\x00=\xd8\x00\xde", DecodedText{Text: "h\u00e9\U0001f600", Encoding: EncodingUTF16LE}},
+		{"utf-16be", "\xfe\xff\x00h\x00i", DecodedText{Text: "hi", Encoding: EncodingUTF16BE}},
+		{"unpaired surrogate and odd byte", "\xff\xfea\x00\x00\xd8b", DecodedText{Text: "a\uFFFD", Encoding: EncodingUTF16LE, InvalidBytes: 3}},
+	} {
+		got, err := InspectText([]byte(tc.raw))
+		if err != nil || got != tc.want {
+			t.Errorf("%s: InspectText = %+v, %v; want %+v", tc.name, got, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package skill
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+)
+
+// TraceField is the request field through which a host names the trace a
+// request belongs to, e.g. {"text":"a b","_trace_id":"4bf92f35"}. It sits
+// beside the args, or beside "tool" in a Router envelope; handlers decoding
+// into a struct never see it.
+const TraceField = "_trace_id"
+
+// TraceIDEnv holds the trace ID of the invocation, for requests that do not
+// carry TraceField. The Go runtime sets a fresh one for every invocation.
+const TraceIDEnv = "ZEROCLAW_TRACE_ID"
+
+// ResultMeta is what the SDK reports about a request alongside its result.
+type ResultMeta struct {
+	// TraceID echoes the request's trace ID so the host can match the
+	// result to the request and its logs.
+	TraceID string `json:"trace_id"`
+}
+
+// TraceID returns the trace ID of the request ctx was made for, from its
+// TraceField or else TraceIDEnv, or "" when the host gave none. ctx must
+// come from a RunContext handler; include the ID in anything the handler
+// logs or sends on.
+func TraceID(ctx context.Context) string {
+	r, _ := ctx.Value(progressKey{}).(*runner)
+	if r == nil {
+		return ""
+	}
+	return r.traceID
+}
+
+// requestTraceID reads data's TraceField, falling back to TraceIDEnv.
+func requestTraceID(data []byte) string {
+	var req struct {
+		TraceID string `json:"_trace_id"`
+	}
+	if json.Unmarshal(data, &req) == nil && req.TraceID != "" {
+		return req.TraceID
+	}
+	return os.Getenv(TraceIDEnv)
+}
@@ -0,0 +1,39 @@
+package skill
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTraceIDRoundTripsIntoResult(t *testing.T) {
+	t.Setenv(TraceIDEnv, "from-env")
+	r := runner{stdin: strings.NewReader(`{"text":"hi","_trace_id":"abc123"}`)}
+	var seen string
+	res := handle(&r, withContext(func(ctx context.Context, args echoArgs) ToolResult {
+		seen = TraceID(ctx)
+		return OK(args.Text, nil)
+	}))
+	if seen != "abc123" || res.Meta == nil || res.Meta.TraceID != "abc123" || res.Output != "hi" {
+		t.Fatalf("handler saw %q, result %+v", seen, res)
+	}
+}
+
+func TestTraceIDFallsBackToEnv(t *testing.T) {
+	t.Setenv(TraceIDEnv, "from-env")
+	r := runner{stdin: strings.NewReader(`{}`)}
+	res := handle(&r, single(func(echoArgs) ToolResult { panic("boom") }))
+	if res.ErrorCode != CodeInternal || res.Meta == nil || res.Meta.TraceID != "from-env" {
+		t.Fatalf("a panicking handler's result should still carry the trace ID, got %+v", res)
+	}
+}
+
+func TestNoTraceIDLeavesResultUnchanged(t *testing.T) {
+	t.Setenv(TraceIDEnv, "")
+	var out strings.Builder
+	r := runner{stdin: strings.NewReader(`{"text":"hi"}`), stdout: &out}
+	write(&r, handle(&r, single(func(args echoArgs) ToolResult { return OK(args.Text, nil) })))
+	if want := `{"success":true,"output":"hi"}`; out.String() != want {
+		t.Fatalf("got %s, want %s", out.String(), want)
+	}
+}
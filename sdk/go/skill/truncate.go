@@ -0,0 +1,156 @@
+package skill
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+)
+
+// OnOversizeEnv is set to OnOversizeTruncate by hosts running a skill whose
+// manifest sets "on_oversize": "truncate". Run then passes its result
+// through Budget().Truncate before writing it, so a result over
+// MaxOutputBytesEnv is cut down instead of failing the call.
+const OnOversizeEnv = "ZEROCLAW_ON_OVERSIZE"
+
+// Values of the manifest's "on_oversize" field. OnOversizeError, the
+// default, leaves an oversized result to fail on the host.
+const (
+	OnOversizeError    = "error"
+	OnOversizeTruncate = "truncate"
+)
+
+// truncateFromEnv reports whether the host asked for OnOversizeTruncate.
+func truncateFromEnv() bool {
+	return os.Getenv(OnOversizeEnv) == OnOversizeTruncate
+}
+
+// Truncate returns res cut down to fit MaxOutputBytes, with Truncated set. It
+// halves the longest array in Data, wherever it is nested, until the result
+// fits or every array is empty, then shortens Output. Data is rewritten as
+// the JSON it marshals to, keeping its field order. A result that fits is
+// returned unchanged; one that cannot be made to fit, say for a long string
+// in Data, is returned as close as Truncate got it.
+func (a Allowance) Truncate(res ToolResult) ToolResult {
+	if a.Fits(res) {
+		return res
+	}
+	res.Truncated = true
+	if tree, ok := dataTree(res.Data); ok {
+		for !a.Fits(res) {
+			longest := tree.longestArray()
+			if longest == nil {
+				break
+			}
+			longest.items = longest.items[:len(longest.items)/2]
+			res.Data = json.RawMessage(tree.encode(nil))
+		}
+	}
+	if a.Fits(res) {
+		return res
+	}
+	// Keep the longest prefix of Output, in whole runes, that fits.
+	runes := []rune(res.Output)
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		res.Output = string(runes[:mid])
+		if a.Fits(res) {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	res.Output = string(runes[:lo])
+	return res
+}
+
+// jsonNode is a JSON value decoded so Truncate can shorten its arrays and
+// write it back with objects in their original member order.
+type jsonNode struct {
+	kind   json.Delim      // '{' or '[', or 0 for a scalar
+	scalar json.RawMessage // a scalar's encoding
+	keys   []string        // an object's member names
+	items  []*jsonNode     // an object's member values, or an array's elements
+}
+
+// dataTree decodes the JSON that data marshals to. ok is false for nil Data
+// or Data that does not marshal.
+func dataTree(data any) (*jsonNode, bool) {
+	if data == nil {
+		return nil, false
+	}
+	raw, err := MarshalStable(data)
+	if err != nil {
+		return nil, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	node, err := decodeNode(dec)
+	return node, err == nil
+}
+
+func decodeNode(dec *json.Decoder) (*jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		raw, err := MarshalStable(tok)
+		return &jsonNode{scalar: raw}, err
+	}
+	n := &jsonNode{kind: delim}
+	for dec.More() {
+		if delim == '{' {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			n.keys = append(n.keys, key.(string))
+		}
+		item, err := decodeNode(dec)
+		if err != nil {
+			return nil, err
+		}
+		n.items = append(n.items, item)
+	}
+	_, err = dec.Token() // the closing delimiter
+	return n, err
+}
+
+// longestArray returns the non-empty array under n, n included, with the
+// most elements, or nil when there is none.
+func (n *jsonNode) longestArray() *jsonNode {
+	var longest *jsonNode
+	if n.kind == '[' && len(n.items) > 0 {
+		longest = n
+	}
+	for _, item := range n.items {
+		if a := item.longestArray(); a != nil && (longest == nil || len(a.items) > len(longest.items)) {
+			longest = a
+		}
+	}
+	return longest
+}
+
+// encode appends n's compact JSON to b.
+func (n *jsonNode) encode(b []byte) []byte {
+	if n.kind == 0 {
+		return append(b, n.scalar...)
+	}
+	b = append(b, byte(n.kind))
+	for i, item := range n.items {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		if n.kind == '{' {
+			key, _ := MarshalStable(n.keys[i])
+			b = append(append(b, key...), ':')
+		}
+		b = item.encode(b)
+	}
+	if n.kind == '{' {
+		return append(b, '}')
+	}
+	return append(b, ']')
+}
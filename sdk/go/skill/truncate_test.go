@@ -0,0 +1,55 @@
+package skill
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type listing struct {
+	Dir   string   `json:"dir"`
+	Files []string `json:"files"`
+	Tags  []string `json:"tags"`
+}
+
+func TestTruncateHalvesTheLongestArray(t *testing.T) {
+	files := make([]string, 500)
+	for i := range files {
+		files[i] = "file.txt"
+	}
+	res := OK("500 files", listing{Dir: "/data", Files: files, Tags: []string{"a", "b"}})
+	b := Allowance{MaxOutputBytes: 512}
+	got := b.Truncate(res)
+	if !got.Truncated || !b.Fits(got) {
+		t.Fatalf("want a truncated result within budget, got %+v", got)
+	}
+	var data listing
+	if err := json.Unmarshal(got.Data.(json.RawMessage), &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Files) == 0 || len(data.Files) >= 500 || len(data.Tags) != 2 || data.Dir != "/data" {
+		t.Fatalf("only the long array should shrink: %d files, tags %v, dir %q", len(data.Files), data.Tags, data.Dir)
+	}
+	if out, _ := MarshalStable(got.Data); !strings.HasPrefix(string(out), `{"dir":"/data","files":[`) {
+		t.Fatalf("field order lost: %s", out)
+	}
+	if got.Output != "500 files" || len(res.Data.(listing).Files) != 500 {
+		t.Fatalf("output cut needlessly or the handler's data changed: %q", got.Output)
+	}
+}
+
+func TestTruncateShortensOutputLast(t *testing.T) {
+	res := OK(strings.Repeat("é", 100), nil)
+	b := Allowance{MaxOutputBytes: 64}
+	got := b.Truncate(res)
+	if !got.Truncated || !b.Fits(got) || got.Output == "" || !strings.HasPrefix(res.Output, got.Output) {
+		t.Fatalf("want a shortened output within budget, got %+v", got)
+	}
+	if out, _ := MarshalStable(got); len(out) < 62 {
+		t.Fatalf("output cut further than needed: %s", out)
+	}
+
+	if got := (Allowance{}).Truncate(res); got.Truncated || got.Output != res.Output {
+		t.Fatalf("no cap should leave the result alone, got %+v", got)
+	}
+}
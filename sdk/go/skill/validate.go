@@ -0,0 +1,134 @@
+package skill
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// oneOf returns the values a `validate:"oneof=a|b|c"` tag allows, or nil
+// when the field has no such rule. Other comma-separated rules are ignored.
+func oneOf(f reflect.StructField) []string {
+	for _, rule := range strings.Split(f.Tag.Get("validate"), ",") {
+		if values, ok := strings.CutPrefix(rule, "oneof="); ok {
+			return strings.Split(values, "|")
+		}
+	}
+	return nil
+}
+
+// intBounds are the limits `validate:"min=n"` and `validate:"max=n"` rules
+// set on an integer field.
+type intBounds struct {
+	min, max       int64
+	hasMin, hasMax bool
+}
+
+// boundsOf returns the min and max rules of f, and false when it has
+// neither.
+func boundsOf(f reflect.StructField) (b intBounds, ok bool) {
+	for _, rule := range strings.Split(f.Tag.Get("validate"), ",") {
+		if n, found := strings.CutPrefix(rule, "min="); found {
+			b.min, _ = strconv.ParseInt(n, 10, 64)
+			b.hasMin = true
+		}
+		if n, found := strings.CutPrefix(rule, "max="); found {
+			b.max, _ = strconv.ParseInt(n, 10, 64)
+			b.hasMax = true
+		}
+	}
+	return b, b.hasMin || b.hasMax
+}
+
+// check returns the message for n outside b, or "" when it is within.
+func (b intBounds) check(n int64) string {
+	if (!b.hasMin || n >= b.min) && (!b.hasMax || n <= b.max) {
+		return ""
+	}
+	switch {
+	case b.hasMin && b.hasMax:
+		return fmt.Sprintf("must be between %d and %d, got %d", b.min, b.max, n)
+	case b.hasMin:
+		return fmt.Sprintf("must be at least %d, got %d", b.min, n)
+	}
+	return fmt.Sprintf("must be at most %d, got %d", b.max, n)
+}
+
+// validateFields checks the oneof rules of v's string, *string, and []string
+// fields and the min and max rules of its signed integer fields, descending
+// into nested structs, and returns a FieldError for each value outside its
+// set or range. An omitempty field left empty is not checked. path is the
+// JSON Pointer of v.
+func validateFields(v reflect.Value, path string) []FieldError {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	var errs []FieldError
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f, fv := t.Field(i), v.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		at := path + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+		if b, ok := boundsOf(f); ok {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if !fv.CanInt() || (fv.IsZero() && hasOpt(opts, "omitempty")) {
+				continue
+			}
+			if msg := b.check(fv.Int()); msg != "" {
+				errs = append(errs, FieldError{Path: at, Message: msg})
+			}
+			continue
+		}
+		allowed := oneOf(f)
+		if allowed == nil {
+			errs = append(errs, validateFields(fv, at)...)
+			continue
+		}
+		check := func(s, at string) {
+			for _, a := range allowed {
+				if s == a {
+					return
+				}
+			}
+			errs = append(errs, FieldError{Path: at, Message: fmt.Sprintf("must be one of %s, got %q", strings.Join(allowed, ", "), s)})
+		}
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		switch {
+		case fv.Kind() == reflect.String:
+			if fv.String() != "" || !hasOpt(opts, "omitempty") {
+				check(fv.String(), at)
+			}
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.String:
+			for j := 0; j < fv.Len(); j++ {
+				check(fv.Index(j).String(), at+"/"+strconv.Itoa(j))
+			}
+		}
+	}
+	return errs
+}
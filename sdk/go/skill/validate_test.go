@@ -0,0 +1,88 @@
+package skill
+
+import (
+	"reflect"
+	"testing"
+)
+
+type modeArgs struct {
+	Mode  string   `json:"mode,omitempty" validate:"oneof=bytes|runes|graphemes"`
+	Units []string `json:"units" validate:"oneof=words|lines"`
+	Inner struct {
+		Level *string `json:"level" validate:"required,oneof=low|high"`
+	} `json:"inner"`
+}
+
+func TestSchemaForEmitsOneOfAsEnum(t *testing.T) {
+	props := SchemaFor[modeArgs]()["properties"].(map[string]any)
+	if got := props["mode"].(map[string]any)["enum"]; !reflect.DeepEqual(got, []string{"bytes", "runes", "graphemes"}) {
+		t.Fatalf("mode enum = %v", got)
+	}
+	items := props["units"].(map[string]any)["items"].(map[string]any)
+	if got := items["enum"]; !reflect.DeepEqual(got, []string{"words", "lines"}) {
+		t.Fatalf("units items enum = %v", got)
+	}
+	inner := props["inner"].(map[string]any)["properties"].(map[string]any)
+	if got := inner["level"].(map[string]any)["enum"]; !reflect.DeepEqual(got, []string{"low", "high"}) {
+		t.Fatalf("inner.level enum = %v", got)
+	}
+}
+
+func TestDecodeRejectsValuesOutsideOneOf(t *testing.T) {
+	ok := func(modeArgs) ToolResult { return OK("ran", nil) }
+	r := runner{}
+	if res := decode(&r, []byte(`{"units":["words"]}`), ok); !res.Success {
+		t.Fatalf("an omitted omitempty field should pass, got %+v", res)
+	}
+
+	res := decode(&r, []byte(`{"mode":"words","units":["lines","pages"],"inner":{"level":"mid"}}`), ok)
+	if res.Success || res.ErrorCode != CodeInvalidInput {
+		t.Fatalf("expected invalid_input, got %+v", res)
+	}
+	want := []FieldError{
+		{Path: "/mode", Message: `must be one of bytes, runes, graphemes, got "words"`},
+		{Path: "/units/1", Message: `must be one of words, lines, got "pages"`},
+		{Path: "/inner/level", Message: `must be one of low, high, got "mid"`},
+	}
+	if !reflect.DeepEqual(res.FieldErrors, want) {
+		t.Fatalf("field errors = %+v", res.FieldErrors)
+	}
+}
+
+type limitArgs struct {
+	TopWords int   `json:"top_words,omitempty" validate:"min=0"`
+	WPM      *int  `json:"wpm" validate:"min=1,max=1000"`
+	Small    uint8 `json:"small,omitempty"`
+	Depth    int   `json:"depth,omitempty" validate:"max=3"`
+}
+
+func TestSchemaForEmitsMinAndMax(t *testing.T) {
+	props := SchemaFor[limitArgs]()["properties"].(map[string]any)
+	if got := props["top_words"].(map[string]any); got["minimum"] != int64(0) || got["maximum"] != nil {
+		t.Fatalf("top_words schema = %v", got)
+	}
+	if got := props["wpm"].(map[string]any); got["minimum"] != int64(1) || got["maximum"] != int64(1000) {
+		t.Fatalf("wpm schema = %v", got)
+	}
+}
+
+func TestDecodeRejectsIntegersOutOfRange(t *testing.T) {
+	ok := func(limitArgs) ToolResult { return OK("ran", nil) }
+	r := runner{}
+	if res := decode(&r, []byte(`{"wpm":200}`), ok); !res.Success {
+		t.Fatalf("values in range should pass, got %+v", res)
+	}
+	for _, tc := range []struct{ input, msg string }{
+		{`{"top_words":-5}`, "/top_words: must be at least 0, got -5"},
+		{`{"wpm":0}`, "/wpm: must be between 1 and 1000, got 0"},
+		{`{"wpm":5,"depth":4}`, "/depth: must be at most 3, got 4"},
+		{`{"top_words":9999999999999999999}`, "invalid input JSON: must be between -9223372036854775808 and 9223372036854775807, got 9999999999999999999 (at /top_words)"},
+		{`{"small":-5}`, "invalid input JSON: must be between 0 and 255, got -5 (at /small)"},
+		{`{"small":256}`, "invalid input JSON: must be between 0 and 255, got 256 (at /small)"},
+	} {
+		res := decode(&r, []byte(tc.input), ok)
+		if res.Success || res.ErrorCode != CodeInvalidInput || *res.Error != tc.msg {
+			t.Errorf("%s: got %+v, want %q", tc.input, res, tc.msg)
+		}
+	}
+}
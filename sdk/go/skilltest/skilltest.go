@@ -0,0 +1,87 @@
+// Package skilltest runs skill handlers from Go tests, in process, so a
+// skill's logic can be tested without building a module or starting a
+// host.
+//
+// Run sends args through the request path skill.Run uses: the same
+// decoding, validation, options, and middleware, and the same encoding of
+// the result. A test then checks the result as a host would see it:
+//
+//	func TestCountsWords(t *testing.T) {
+//		res := skilltest.Run(t, count, `{"text":"a b"}`)
+//		if res.Data.(*CountResult).Words != 2 {
+//			t.Fatalf("got %+v", res)
+//		}
+//	}
+package skilltest
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/zeroclaw-labs/zeroclaw/sdk/go/skill"
+)
+
+// Run serves argsJSON to handler as skill.Run would serve it from stdin,
+// with opts applied, and returns the result. It fails t if the result does
+// not encode, where skill.Run would exit with status 1.
+func Run[A any](t testing.TB, handler func(args A) skill.ToolResult, argsJSON string, opts ...skill.Option) skill.ToolResult {
+	t.Helper()
+	return encodes(t, skill.Handle(handler, []byte(argsJSON), opts...))
+}
+
+// RunContext is Run for a skill.RunContext handler.
+func RunContext[A any](t testing.TB, handler func(ctx context.Context, args A) skill.ToolResult, argsJSON string, opts ...skill.Option) skill.ToolResult {
+	t.Helper()
+	return encodes(t, skill.HandleContext(handler, []byte(argsJSON), opts...))
+}
+
+func encodes(t testing.TB, res skill.ToolResult) skill.ToolResult {
+	t.Helper()
+	if _, err := skill.MarshalStable(res); err != nil {
+		t.Fatalf("result does not encode: %v\n%+v", err, res)
+	}
+	return res
+}
+
+// ExpectOK fails t unless res succeeded.
+func ExpectOK(t testing.TB, res skill.ToolResult) {
+	t.Helper()
+	if !res.Success {
+		t.Fatalf("expected success, got error %q (%s)", message(res), res.ErrorCode)
+	}
+}
+
+// ExpectError fails t unless res failed with code, and returns its error
+// message.
+func ExpectError(t testing.TB, res skill.ToolResult, code skill.ErrorCode) string {
+	t.Helper()
+	if res.Success {
+		t.Fatalf("expected a %s error, got success: %q", code, res.Output)
+	}
+	if res.ErrorCode != code {
+		t.Fatalf("expected a %s error, got %s: %q", code, res.ErrorCode, message(res))
+	}
+	return message(res)
+}
+
+// ExpectFieldErrors fails t unless res failed with CodeInvalidInput and
+// field errors at exactly paths, in order, such as "/count_mode".
+func ExpectFieldErrors(t testing.TB, res skill.ToolResult, paths ...string) {
+	t.Helper()
+	ExpectError(t, res, skill.CodeInvalidInput)
+	got := make([]string, len(res.FieldErrors))
+	for i, fe := range res.FieldErrors {
+		got[i] = fe.Path
+	}
+	if !slices.Equal(got, paths) {
+		t.Fatalf("expected field errors at %q, got %+v", paths, res.FieldErrors)
+	}
+}
+
+func message(res skill.ToolResult) string {
+	if res.Error == nil {
+		return ""
+	}
+	return *res.Error
+}
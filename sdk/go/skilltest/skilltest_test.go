@@ -0,0 +1,95 @@
+package skilltest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/zeroclaw-labs/zeroclaw/sdk/go/skill"
+)
+
+type greetArgs struct {
+	Name  string `json:"name"`
+	Style string `json:"style,omitempty" validate:"oneof=plain|loud"`
+}
+
+func greet(args greetArgs) skill.ToolResult {
+	if args.Name == "" {
+		return skill.FailCode(skill.CodeInvalidInput, "name is empty")
+	}
+	out := "hello, " + args.Name
+	if args.Style == "loud" {
+		out = strings.ToUpper(out)
+	}
+	return skill.OK(out, map[string]int{"length": len(out)})
+}
+
+func TestRunDecodesValidatesAndHandles(t *testing.T) {
+	res := Run(t, greet, `{"name":"Ada","style":"loud"}`)
+	ExpectOK(t, res)
+	if res.Output != "HELLO, ADA" || res.Data.(map[string]int)["length"] != 10 {
+		t.Fatalf("got %+v", res)
+	}
+
+	ExpectFieldErrors(t, Run(t, greet, `{"name":"Ada","style":"quiet"}`), "/style")
+	ExpectFieldErrors(t, Run(t, greet, `{"name":7}`), "/name")
+	if msg := ExpectError(t, Run(t, greet, `{}`), skill.CodeInvalidInput); msg != "name is empty" {
+		t.Fatalf("got message %q", msg)
+	}
+}
+
+func TestRunAppliesOptions(t *testing.T) {
+	ExpectOK(t, Run(t, greet, `{"name":"Ada","nickname":"A"}`))
+	res := Run(t, greet, `{"name":"Ada","nickname":"A"}`, skill.StrictFields())
+	if msg := ExpectError(t, res, skill.CodeInvalidInput); !strings.Contains(msg, "nickname") {
+		t.Fatalf("StrictFields should name the unknown field, got %q", msg)
+	}
+}
+
+func TestRunInflatesGzipArgs(t *testing.T) {
+	var packed bytes.Buffer
+	zw := gzip.NewWriter(&packed)
+	zw.Write([]byte(`{"name":"Ada"}`))
+	zw.Close()
+	if res := Run(t, greet, packed.String()); res.Output != "hello, Ada" {
+		t.Fatalf("gzip args should decode as Run would, got %+v", res)
+	}
+}
+
+func TestRunContextRecoversPanics(t *testing.T) {
+	res := RunContext(t, func(ctx context.Context, args greetArgs) skill.ToolResult {
+		if skill.TraceID(ctx) != "t1" {
+			return skill.Fail("no trace ID")
+		}
+		panic("boom")
+	}, `{"name":"Ada","_trace_id":"t1"}`)
+	if msg := ExpectError(t, res, skill.CodeInternal); !strings.HasPrefix(msg, "panic: boom") {
+		t.Fatalf("got %q", msg)
+	}
+	if res.Meta == nil || res.Meta.TraceID != "t1" {
+		t.Fatalf("the trace ID should come back in Meta, got %+v", res.Meta)
+	}
+}
+
+func TestRunFailsOnAResultThatDoesNotEncode(t *testing.T) {
+	var ft fakeT
+	encodes(&ft, skill.OK("", func() {}))
+	if !strings.Contains(ft.failed, "does not encode") {
+		t.Fatalf("got %q", ft.failed)
+	}
+}
+
+// fakeT records the failure of a helper under test instead of failing.
+type fakeT struct {
+	testing.TB
+	failed string
+}
+
+func (ft *fakeT) Helper() {}
+
+func (ft *fakeT) Fatalf(format string, args ...any) {
+	ft.failed = fmt.Sprintf(format, args...)
+}
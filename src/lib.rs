@@ -155,30 +155,317 @@ pub enum SkillCommands {
     New {
         /// Skill name (snake_case recommended, e.g. my_weather_tool)
         name: String,
-        /// Template language: typescript, rust, go, python
+        /// Template name or language: typescript, rust, go, python, js
         #[arg(long, short, default_value = "typescript")]
         template: String,
+        /// Use the first template for this language (typescript, rust, go, python, js)
+        #[arg(long, conflicts_with = "template")]
+        lang: Option<String>,
+    },
+    /// Compile a Go, Rust, or JavaScript skill directory to tool.wasm and seal its checksum
+    Build {
+        /// Skill directory containing go.mod, Cargo.toml, or package.json
+        #[arg(default_value = ".")]
+        path: std::path::PathBuf,
+        /// Go toolchain: tinygo (default, smallest modules) or go (GOOS=wasip1, full stdlib)
+        #[arg(long)]
+        compiler: Option<String>,
+        /// Optimization level passed to tinygo -opt or Cargo's release opt-level (e.g. z, s, 2)
+        #[arg(long)]
+        opt: Option<String>,
+        /// Version stamped into a Go skill's main.version with -ldflags -X
+        #[arg(long)]
+        version: Option<String>,
+        /// WASI target: wasip1 (default, a core module every executor runs) or
+        /// wasip2 (a component, with tinygo or cargo; the Go runtime cannot run it yet)
+        #[arg(long, default_value = "wasip1")]
+        target: String,
+        /// Remove tool.wasm and earlier build output before building
+        #[arg(long)]
+        clean: bool,
     },
     /// Run a skill tool locally for testing (reads args from --args or stdin)
     Test {
+        /// Path to the skill directory or installed skill name; `dir/...` tests
+        /// every skill under dir with its cases.json
+        path: String,
+        /// Optional tool name inside the skill (defaults to first tool found);
+        /// a single tool.wasm, such as a Go router, gets it as its first argument
+        #[arg(long)]
+        tool: Option<String>,
+        /// JSON arguments to pass to the tool, e.g. '{"city":"Hanoi"}'
+        #[arg(long, short)]
+        args: Option<String>,
+        /// Re-indent the tool's JSON result for reading
+        #[arg(long, conflicts_with = "field")]
+        pretty: bool,
+        /// Print only the value at a dotted path in the result, e.g. 'data.words'
+        #[arg(long)]
+        field: Option<String>,
+        /// Run every case in a JSON fixtures file instead of a single call
+        #[arg(long, conflicts_with_all = ["args", "field"])]
+        cases: Option<std::path::PathBuf>,
+        /// Number of cases to run concurrently with --cases
+        #[arg(long, default_value_t = 1, requires = "cases")]
+        parallel: usize,
+        /// Print the --cases or 'dir/...' report as JSON instead of one line
+        /// per case; with --explain-timing, put the timings in the result
+        #[arg(long)]
+        json: bool,
+        /// Format of the --cases or 'dir/...' report: text (default), json
+        /// (same as --json), or, for --cases, csv, one
+        /// name,status,duration_ms,error_code,message row per case
+        #[arg(long, value_parser = ["text", "json", "csv"], conflicts_with = "json")]
+        format: Option<String>,
+        /// Never color the --cases report, even on a terminal
+        #[arg(long)]
+        no_color: bool,
+        /// Map a host directory into the skill as 'host:guest' (repeatable); the guest
+        /// dir must be declared under capabilities.fs in manifest.json
+        #[arg(long)]
+        preopen: Vec<String>,
+        /// Stream newline-delimited JSON args from stdin through one skill process,
+        /// printing one result line per input (default when the manifest sets "jsonl")
+        #[arg(long, conflicts_with_all = ["args", "cases", "field", "pretty"])]
+        jsonl: bool,
+        /// Fail if the result's data does not match output.schema.json (or the
+        /// schema the module prints for --output-schema)
+        #[arg(long, conflicts_with_all = ["cases", "jsonl"])]
+        check_output: bool,
+        /// Make SDK-built skills reject invalid UTF-8 input with invalid_input
+        /// instead of repairing it
+        #[arg(long)]
+        strict_utf8: bool,
+        /// Give the skill a secret as 'KEY=VALUE' (repeatable); the key must be
+        /// declared under capabilities.secrets in manifest.json
+        #[arg(long)]
+        secret: Vec<String>,
+        /// Read secrets from a file of 'KEY=VALUE' lines
+        #[arg(long)]
+        secret_file: Option<std::path::PathBuf>,
+        /// Keep the module compiled and run each JSON args line typed on stdin
+        /// in a fresh instance, with timings, until an empty line or EOF
+        #[arg(
+            long,
+            alias = "repeat-until-empty",
+            conflicts_with_all = ["args", "cases", "jsonl", "field", "check_output", "preopen", "secret", "secret_file"]
+        )]
+        interactive: bool,
+        /// With --verbose, print the tool's stderr as it is written instead of
+        /// after it exits
+        #[arg(long)]
+        follow: bool,
+        /// Send the args and read the result as gzip streams; the manifest must
+        /// set "compression": "gzip"
+        #[arg(long, conflicts_with_all = ["cases", "jsonl", "interactive"])]
+        compress: bool,
+        /// Print only the result, with sorted keys and one field per line, for
+        /// writing and diffing golden files
+        #[arg(long, conflicts_with_all = ["pretty", "field", "cases", "jsonl", "interactive"])]
+        canonical: bool,
+        /// With --canonical, drop these dotted paths (comma-separated) from the
+        /// result first, e.g. 'meta.trace_id,data.elapsed_ms'
+        #[arg(long, value_delimiter = ',', requires = "canonical")]
+        ignore_fields: Vec<String>,
+        /// Replace the result's output with its data as sorted key=value
+        /// pairs, e.g. 'characters=11;lines=1;words=2', so a snapshot does
+        /// not change with the locale
+        #[arg(long, conflicts_with_all = ["cases", "jsonl", "interactive"])]
+        stable_output: bool,
+        /// With a 'dir/...' path, test only the skills whose manifest name
+        /// matches this glob
+        #[arg(long)]
+        filter: Option<String>,
+        /// Report how long each phase of the call took (load, instantiate,
+        /// input, execute, decode) in a table, or with --json under the
+        /// result's meta.timing
+        #[arg(long, conflicts_with_all = ["cases", "jsonl", "interactive"])]
+        explain_timing: bool,
+        /// Stop a call that runs longer than this (e.g. '10s', '2m') with a
+        /// deadline_exceeded failure; applies to each case of --cases and
+        /// 'dir/...', and '0s' turns it off
+        #[arg(long, default_value = "60s", value_parser = crate::skills::deadline::parse_duration)]
+        timeout: std::time::Duration,
+        /// Build a Go skill with `go build` for this machine and run the
+        /// binary instead of tool.wasm; quicker to rebuild, but not sandboxed
+        #[arg(
+            long,
+            conflicts_with_all = ["tool", "cases", "jsonl", "interactive", "check_output", "preopen", "secret", "secret_file", "compress", "follow", "explain_timing"]
+        )]
+        native: bool,
+        /// Write the result's artifact of this name to the --out file
+        #[arg(long, requires = "out", conflicts_with_all = ["cases", "jsonl", "interactive"])]
+        extract: Option<String>,
+        /// File to write the --extract artifact to
+        #[arg(long, requires = "extract")]
+        out: Option<std::path::PathBuf>,
+    },
+    /// Keep a skill compiled and answer requests until stopped, each in a fresh
+    /// instance
+    Serve {
         /// Path to the skill directory or installed skill name
+        #[arg(default_value = ".")]
         path: String,
         /// Optional tool name inside the skill (defaults to first tool found)
         #[arg(long)]
         tool: Option<String>,
-        /// JSON arguments to pass to the tool, e.g. '{"city":"Hanoi"}'
+        /// Unix socket to answer on: one JSON args line in, one result line out
+        #[arg(long)]
+        socket: Option<std::path::PathBuf>,
+        /// Address to serve Prometheus metrics on at /metrics, e.g. ':9090'
+        #[arg(long)]
+        metrics_addr: Option<String>,
+        /// Address to serve liveness and readiness probes on at /healthz and
+        /// /readyz, e.g. ':8081'
+        #[arg(long)]
+        health_addr: Option<String>,
+        /// Address to answer POST /invoke on, with the args as the body and
+        /// the result as the response, e.g. ':8080'
+        #[arg(long)]
+        http_addr: Option<String>,
+        /// Copies of the module to keep, each running one call at a time
+        #[arg(long, default_value_t = 1)]
+        pool_size: usize,
+        /// Requests that may wait for a busy pool before more are answered
+        /// rate_limited
+        #[arg(long, default_value_t = 16)]
+        queue_size: usize,
+    },
+    /// Check that a `skill serve --socket` loop answers, without running the skill
+    Ping {
+        /// Unix socket `skill serve` listens on
+        socket: std::path::PathBuf,
+        /// Fail if no answer arrives within this long (e.g. '500ms', '5s')
+        #[arg(long, default_value = "5s", value_parser = crate::skills::deadline::parse_duration)]
+        timeout: std::time::Duration,
+    },
+    /// Chain skills: run each in order, feeding a stage's `data` into the next
+    Pipe {
+        /// Skill directories or installed skill names, in pipeline order
+        #[arg(required = true)]
+        stages: Vec<String>,
+        /// JSON arguments for the first stage
         #[arg(long, short)]
         args: Option<String>,
+        /// Rename fields between stages, e.g. 'data.words -> count' (repeatable)
+        #[arg(long)]
+        map: Vec<String>,
+        /// Rerun a stage up to N more times when it fails with an error code
+        /// its manifest's retryable_errors lists (default: internal, timeout)
+        #[arg(long, default_value_t = 0)]
+        retries: u32,
+        /// Wait before the first rerun, doubled before each one after (e.g. '250ms', '2s')
+        #[arg(long, default_value = "100ms", value_parser = crate::skills::deadline::parse_duration)]
+        retry_backoff: std::time::Duration,
+    },
+    /// Rerun recorded invocations and report results that changed since recording
+    Replay {
+        /// JSON-Lines file of {"name","args","result"} records (see the Go
+        /// runtime's Config.Recorder)
+        recording: std::path::PathBuf,
+        /// Skill directory or installed name to replay against, instead of
+        /// the installed skill each record names
+        #[arg(long)]
+        skill: Option<String>,
+    },
+    /// Package a built skill directory into a single verifiable .zcskill archive
+    Package {
+        /// Skill directory to package (must contain tool.wasm)
+        #[arg(default_value = ".")]
+        path: String,
+        /// Output file (defaults to <name>.zcskill in the current directory)
+        #[arg(long, short)]
+        output: Option<std::path::PathBuf>,
+        /// Sign the package with an ed25519 private key (PKCS#8 PEM)
+        #[arg(long)]
+        sign: Option<std::path::PathBuf>,
+    },
+    /// Catalog every skill under a directory: name, version, description,
+    /// capabilities, and tool.wasm checksum
+    Index {
+        /// Directory to search, as `dir` or `dir/...`
+        #[arg(default_value = ".")]
+        path: String,
+        /// Output file (defaults to stdout)
+        #[arg(long, short)]
+        output: Option<std::path::PathBuf>,
+        /// Format of the index: json (default) or markdown, a table for people
+        #[arg(long, value_parser = ["json", "markdown"])]
+        format: Option<String>,
+    },
+    /// Compare the args schemas (`--schema`) of two builds; fails on breaking changes
+    SchemaDiff {
+        /// Old skill: a .wasm file, skill directory, or installed skill name
+        old: String,
+        /// New skill: a .wasm file, skill directory, or installed skill name
+        new: String,
+        /// Accept purely additive changes (new optional fields, relaxed requirements)
+        #[arg(long)]
+        allow_additive: bool,
+    },
+    /// Run two builds over the same fixtures and report cases whose `output`
+    /// or `data` differ; fails if any do
+    Diff {
+        /// Old skill: a .wasm file, skill directory, or installed skill name
+        old: String,
+        /// New skill: a .wasm file, skill directory, or installed skill name
+        new: String,
+        /// JSON fixtures file whose cases' args both builds are run with (see
+        /// `skill test --cases`)
+        #[arg(long)]
+        cases: std::path::PathBuf,
+        /// Volatile dotted paths (comma-separated) to leave out of the
+        /// comparison, e.g. 'data.elapsed_ms'
+        #[arg(long, value_delimiter = ',')]
+        ignore_fields: Vec<String>,
+    },
+    /// Check args against a skill's manifest and print them with defaults
+    /// applied, without running the module
+    Validate {
+        /// Path to the skill directory or installed skill name
+        #[arg(default_value = ".")]
+        path: String,
+        /// JSON arguments to check, e.g. '{"text":"hello"}'
+        #[arg(long, short)]
+        args: String,
+        /// Also warn about parameters that have no description
+        #[arg(long)]
+        warn_undocumented: bool,
+    },
+    /// Show a skill's manifest, parameters, and `skill test` exit codes
+    Describe {
+        /// Path to the skill directory or installed skill name
+        #[arg(default_value = ".")]
+        path: String,
+    },
+    /// Probe a skill's built module and list the tools it registers
+    Inspect {
+        /// Path to the skill directory or installed skill name
+        #[arg(default_value = ".")]
+        path: String,
+        /// Print the zeroclaw host functions the module imports as JSON,
+        /// without running it, and fail on any the manifest does not declare
+        #[arg(long)]
+        capabilities: bool,
+    },
+    /// Rewrite a skill's manifest.json in place at the current schema_version
+    Migrate {
+        /// Path to the skill directory or installed skill name
+        #[arg(default_value = ".")]
+        path: String,
     },
     /// Audit a skill source directory or installed skill name
     Audit {
         /// Skill path or installed skill name
         source: String,
     },
-    /// Install a new skill from a local path, git URL, or registry (namespace/name)
+    /// Install a new skill from a local path, .zcskill package, git URL, or registry (namespace/name)
     Install {
-        /// Source: local path, git URL, or registry package (e.g. acme/my-tool)
+        /// Source: local path, .zcskill package, git URL, or registry package (e.g. acme/my-tool)
         source: String,
+        /// Require a .zcskill package signed by a key in this PEM file (one or more public keys)
+        #[arg(long)]
+        verify: Option<std::path::PathBuf>,
     },
     /// Remove an installed skill
     Remove {
@@ -187,6 +474,8 @@ pub enum SkillCommands {
     },
     /// List all available skill templates
     Templates,
+    /// Check the skill toolchain (tinygo, wasip1 target, executor) and suggest fixes
+    Doctor,
 }
 
 /// Migration subcommands
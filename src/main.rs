@@ -417,6 +417,14 @@ Examples:
     Skills {
         #[command(subcommand)]
         skill_command: SkillCommands,
+
+        /// Print only the result; failures still exit non-zero
+        #[arg(long, global = true, conflicts_with = "verbose")]
+        quiet: bool,
+
+        /// Also print phase timings, capability grants, and the tool's stderr
+        #[arg(long, global = true)]
+        verbose: bool,
     },
 
     /// Migrate data from other agent runtimes
@@ -1123,7 +1131,21 @@ async fn main() -> Result<()> {
             integration_command,
         } => integrations::handle_command(integration_command, &config),
 
-        Commands::Skills { skill_command } => skills::handle_command(skill_command, &config),
+        Commands::Skills {
+            skill_command,
+            quiet,
+            verbose,
+        } => {
+            let verbosity = skills::Verbosity::from_flags(quiet, verbose);
+            match skills::handle_command(skill_command, &config, verbosity) {
+                // Tool-level failures get their own exit status so scripts can branch.
+                Err(err) if skills::exit_code(&err) != skills::EXIT_HARNESS_ERROR => {
+                    eprintln!("Error: {err:?}");
+                    std::process::exit(skills::exit_code(&err));
+                }
+                result => result,
+            }
+        }
 
         Commands::Migrate { migrate_command } => {
             migration::handle_command(migrate_command, &config).await
@@ -2142,4 +2164,34 @@ mod tests {
             other => panic!("expected estop resume command, got {other:?}"),
         }
     }
+
+    #[test]
+    fn skill_verbosity_flags_are_global_and_exclusive() {
+        for argv in [
+            ["zeroclaw", "skill", "--quiet", "test", "."],
+            ["zeroclaw", "skill", "test", ".", "--quiet"],
+        ] {
+            let cli = Cli::try_parse_from(argv).expect("--quiet should parse on either side");
+            match cli.command {
+                Commands::Skills { quiet, verbose, .. } => assert!(quiet && !verbose),
+                other => panic!("expected skill command, got {other:?}"),
+            }
+        }
+
+        let cli = Cli::try_parse_from(["zeroclaw", "skill", "test", ".", "--verbose", "--follow"])
+            .expect("--verbose --follow should parse");
+        assert!(matches!(
+            cli.command,
+            Commands::Skills { verbose: true, .. }
+        ));
+
+        let err = Cli::try_parse_from(["zeroclaw", "skill", "test", ".", "--quiet", "--verbose"])
+            .expect_err("--quiet and --verbose should conflict");
+        assert_eq!(err.kind(), clap::error::ErrorKind::ArgumentConflict);
+        let msg = err.to_string();
+        assert!(
+            msg.contains("'--quiet' cannot be used with '--verbose'"),
+            "{msg}"
+        );
+    }
 }
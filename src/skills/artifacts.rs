@@ -0,0 +1,165 @@
+//! `skill test --extract NAME --out FILE` — save one of a result's artifacts.
+//!
+//! Besides its primary `output` and `data`, a skill can return named files in
+//! `artifacts` (the Go SDK's `skill.Artifact`): each has a `name`, an optional
+//! `media_type`, and either `text`, sent as it is, or base64 `content`, which
+//! `"encoding": "gzip"` says is compressed. `skill test` lists them after the
+//! result, and `--extract` writes one to a file as the skill meant it, text
+//! or bytes, inflated if need be.
+
+use anyhow::{bail, Context, Result};
+use base64::Engine;
+use serde_json::Value;
+use std::io::Read;
+use std::path::PathBuf;
+
+/// The `encoding` of gzip-compressed `content`.
+const GZIP: &str = "gzip";
+
+/// An artifact `skill test --extract` should write to a file.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Extract {
+    pub name: String,
+    pub out: PathBuf,
+}
+
+impl Extract {
+    /// Write the artifact of this name in the result on `stdout` to the
+    /// file, returning its size in bytes.
+    pub fn write(&self, stdout: &str) -> Result<usize> {
+        let body = extract(stdout, &self.name)?;
+        std::fs::write(&self.out, &body)
+            .with_context(|| format!("failed to write {}", self.out.display()))?;
+        Ok(body.len())
+    }
+}
+
+/// One line per artifact in the result on `stdout`, e.g.
+/// `report.md (text/markdown, 12 bytes)`, in the order the skill sent them.
+/// Output that is not a `ToolResult` has none.
+pub fn list(stdout: &str) -> Vec<String> {
+    artifacts(stdout)
+        .iter()
+        .map(|artifact| {
+            let name = artifact.get("name").and_then(Value::as_str).unwrap_or("");
+            let media_type = artifact
+                .get("media_type")
+                .and_then(Value::as_str)
+                .unwrap_or("application/octet-stream");
+            match body(artifact) {
+                Ok(body) => format!("{name} ({media_type}, {} bytes)", body.len()),
+                Err(err) => format!("{name} ({media_type}, unreadable: {err:#})"),
+            }
+        })
+        .collect()
+}
+
+/// The bytes of the artifact called `name` in the result on `stdout`.
+pub fn extract(stdout: &str, name: &str) -> Result<Vec<u8>> {
+    let artifacts = artifacts(stdout);
+    let Some(artifact) = artifacts
+        .iter()
+        .find(|artifact| artifact.get("name").and_then(Value::as_str) == Some(name))
+    else {
+        let names: Vec<&str> = artifacts
+            .iter()
+            .filter_map(|artifact| artifact.get("name").and_then(Value::as_str))
+            .collect();
+        if names.is_empty() {
+            bail!("--extract {name}: the result has no artifacts");
+        }
+        bail!(
+            "--extract {name}: the result has no such artifact (it has {})",
+            names.join(", ")
+        );
+    };
+    body(artifact).with_context(|| format!("--extract {name}"))
+}
+
+fn artifacts(stdout: &str) -> Vec<Value> {
+    serde_json::from_str::<Value>(stdout.trim())
+        .ok()
+        .and_then(|mut result| result.get_mut("artifacts").map(Value::take))
+        .and_then(|artifacts| match artifacts {
+            Value::Array(artifacts) => Some(artifacts),
+            _ => None,
+        })
+        .unwrap_or_default()
+}
+
+/// The file an artifact stands for: its `text`, or its `content` decoded and,
+/// for `"encoding": "gzip"`, inflated.
+fn body(artifact: &Value) -> Result<Vec<u8>> {
+    if let Some(text) = artifact.get("text").and_then(Value::as_str) {
+        return Ok(text.as_bytes().to_vec());
+    }
+    let content = artifact
+        .get("content")
+        .and_then(Value::as_str)
+        .unwrap_or("");
+    let bytes = base64::engine::general_purpose::STANDARD
+        .decode(content)
+        .context("content is not base64")?;
+    match artifact.get("encoding").and_then(Value::as_str) {
+        None | Some("") => Ok(bytes),
+        Some(GZIP) => {
+            let mut out = Vec::new();
+            flate2::read::GzDecoder::new(bytes.as_slice())
+                .read_to_end(&mut out)
+                .context("gzip content does not inflate")?;
+            Ok(out)
+        }
+        Some(other) => bail!("unknown encoding {other:?}"),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn two_artifacts() -> String {
+        let packed = base64::engine::general_purpose::STANDARD
+            .encode(crate::skills::compress::gzip(b"cleaned text"));
+        serde_json::json!({
+            "success": true,
+            "output": "2 words",
+            "artifacts": [
+                {"name": "clean.txt", "media_type": "text/plain", "encoding": "gzip", "content": packed},
+                {"name": "report.md", "media_type": "text/markdown", "text": "# 2 words"},
+            ],
+        })
+        .to_string()
+    }
+
+    #[test]
+    fn list_names_each_artifact_with_its_size() {
+        assert_eq!(
+            list(&two_artifacts()),
+            [
+                "clean.txt (text/plain, 12 bytes)",
+                "report.md (text/markdown, 9 bytes)"
+            ]
+        );
+        assert!(list(r#"{"success":true,"output":"x"}"#).is_empty());
+        assert!(list("plain text").is_empty());
+    }
+
+    #[test]
+    fn extract_writes_one_artifact_to_a_file() {
+        let dir = tempfile::tempdir().unwrap();
+        let stdout = two_artifacts();
+        for (name, want) in [("report.md", "# 2 words"), ("clean.txt", "cleaned text")] {
+            let extract = Extract {
+                name: name.to_string(),
+                out: dir.path().join(name),
+            };
+            assert_eq!(extract.write(&stdout).unwrap(), want.len());
+            assert_eq!(std::fs::read_to_string(&extract.out).unwrap(), want);
+        }
+
+        let err = extract(&stdout, "missing.txt").unwrap_err().to_string();
+        assert!(err.contains("it has clean.txt, report.md"), "{err}");
+        let err = extract(r#"{"success":true,"output":"x"}"#, "a").unwrap_err();
+        assert!(err.to_string().contains("no artifacts"), "{err}");
+    }
+}
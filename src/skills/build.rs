@@ -0,0 +1,535 @@
+//! `zeroclaw skill build` — compile a skill to `tool.wasm`.
+//!
+//! The language comes from the project file beside `manifest.json`: `go.mod`,
+//! `Cargo.toml`, or `package.json`. Go skills build with TinyGo, the default,
+//! which produces the smallest modules, or the standard Go toolchain
+//! (`GOOS=wasip1 GOARCH=wasm`, Go 1.21 or later), which builds packages TinyGo
+//! cannot, at several times the size. Rust skills build with
+//! `cargo build --target wasm32-wasip1 --release`, and JavaScript skills with
+//! `javy build src/index.js`. Every toolchain produces a wasip1 command module
+//! that the executors run the same way, and every build seals it with a
+//! [`CHECKSUM`] file. `--target wasip2` builds a WASI Preview 2 component
+//! instead, with TinyGo or Cargo only: `wasmtime run`, and so `skill test`,
+//! runs it over the same stdin/stdout protocol, but the Go runtime does not
+//! yet and fails it with `runtime.ErrComponent`.
+
+use super::doctor;
+use anyhow::{bail, Context, Result};
+use sha2::{Digest, Sha256};
+use std::path::{Path, PathBuf};
+use std::process::Command;
+
+/// The module every build writes, beside the project file.
+pub const OUTPUT: &str = "tool.wasm";
+
+/// The file every build writes beside [`OUTPUT`] with its SHA-256, in the
+/// format `sha256sum -c` checks.
+pub const CHECKSUM: &str = "tool.wasm.sha256";
+
+/// The WASI target a skill is built for.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub enum Target {
+    /// A core module for WASI Preview 1, which every executor runs.
+    #[default]
+    Wasip1,
+    /// A component for WASI Preview 2.
+    Wasip2,
+}
+
+impl Target {
+    /// Parse a `--target` value.
+    pub fn parse(name: &str) -> Result<Self> {
+        match name {
+            "wasip1" => Ok(Self::Wasip1),
+            "wasip2" => Ok(Self::Wasip2),
+            other => bail!("unknown target '{other}' (expected wasip1 or wasip2)"),
+        }
+    }
+
+    pub fn name(self) -> &'static str {
+        match self {
+            Self::Wasip1 => "wasip1",
+            Self::Wasip2 => "wasip2",
+        }
+    }
+
+    /// The Cargo target Rust skills are built for.
+    fn rust(self) -> &'static str {
+        match self {
+            Self::Wasip1 => "wasm32-wasip1",
+            Self::Wasip2 => "wasm32-wasip2",
+        }
+    }
+}
+
+/// The language of a skill, as told by its project file.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum Language {
+    Go,
+    Rust,
+    JavaScript,
+}
+
+impl Language {
+    /// Detect the language of the skill in `dir`.
+    pub fn detect(dir: &Path) -> Result<Self> {
+        [Self::Go, Self::Rust, Self::JavaScript]
+            .into_iter()
+            .find(|language| dir.join(language.project_file()).is_file())
+            .with_context(|| {
+                format!(
+                    "{} has no go.mod, Cargo.toml, or package.json; `skill build` compiles Go, Rust, and JavaScript skills (see the template README for other languages)",
+                    dir.display()
+                )
+            })
+    }
+
+    pub fn name(self) -> &'static str {
+        match self {
+            Self::Go => "Go",
+            Self::Rust => "Rust",
+            Self::JavaScript => "JavaScript",
+        }
+    }
+
+    /// The file that marks a project in this language.
+    pub fn project_file(self) -> &'static str {
+        match self {
+            Self::Go => "go.mod",
+            Self::Rust => "Cargo.toml",
+            Self::JavaScript => "package.json",
+        }
+    }
+}
+
+/// A toolchain that can build a Go skill for wasip1.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum Compiler {
+    TinyGo,
+    Go,
+}
+
+impl Compiler {
+    /// Parse a `--compiler` value.
+    pub fn parse(name: &str) -> Result<Self> {
+        match name {
+            "tinygo" => Ok(Self::TinyGo),
+            "go" => Ok(Self::Go),
+            other => bail!("unknown compiler '{other}' (expected tinygo or go)"),
+        }
+    }
+
+    pub fn name(self) -> &'static str {
+        match self {
+            Self::TinyGo => "tinygo",
+            Self::Go => "go",
+        }
+    }
+
+    /// The command that builds the package in the current directory into
+    /// [`OUTPUT`].
+    pub fn command(self, options: &Options) -> Result<Command> {
+        let mut cmd = Command::new(self.name());
+        cmd.args(["build", "-o", OUTPUT]);
+        match self {
+            Self::TinyGo => {
+                cmd.arg(format!("-target={}", options.target.name()));
+                if let Some(opt) = &options.opt {
+                    cmd.arg(format!("-opt={opt}"));
+                }
+            }
+            Self::Go => {
+                if options.opt.is_some() {
+                    bail!("--opt sets TinyGo's optimization level; --compiler go has none");
+                }
+                if options.target == Target::Wasip2 {
+                    bail!("--target wasip2 needs TinyGo; --compiler go builds only GOOS=wasip1");
+                }
+                cmd.env("GOOS", "wasip1").env("GOARCH", "wasm");
+            }
+        }
+        if let Some(version) = &options.version {
+            cmd.arg(format!("-ldflags=-X main.version={version}"));
+        }
+        cmd.arg(".");
+        Ok(cmd)
+    }
+
+    /// The `skill doctor` check for this toolchain.
+    fn check(self) -> doctor::Check {
+        match self {
+            Self::TinyGo => {
+                doctor::check_tinygo(doctor::command_stdout("tinygo", &["version"]).as_deref()).1
+            }
+            Self::Go => doctor::check_go(doctor::command_stdout("go", &["version"]).as_deref()),
+        }
+    }
+}
+
+/// How to build a skill; the default builds a Go skill with TinyGo.
+#[derive(Debug, Clone, Default)]
+pub struct Options {
+    /// The Go toolchain; `None` means TinyGo. Other languages reject it.
+    pub compiler: Option<Compiler>,
+    /// TinyGo's `-opt` level, or Cargo's release `opt-level`, e.g. `z`.
+    pub opt: Option<String>,
+    /// Stamped into a Go skill's `main.version` with `-ldflags -X`.
+    pub version: Option<String>,
+    /// What to build: a wasip1 module, the default, or a wasip2 component.
+    pub target: Target,
+    /// Remove the previous module, its checksum, and for Rust the target's
+    /// build directory first, so nothing stale survives a failed build.
+    pub clean: bool,
+}
+
+/// The outcome of a successful [`build`].
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Built {
+    pub language: Language,
+    /// The program that compiled the module, e.g. `tinygo`.
+    pub toolchain: &'static str,
+    /// The size of [`OUTPUT`] in bytes.
+    pub size: u64,
+    /// The hex SHA-256 of [`OUTPUT`], as written to [`CHECKSUM`].
+    pub sha256: String,
+}
+
+/// Fail unless `check` passed, naming the fix.
+fn require(what: &str, check: &doctor::Check) -> Result<()> {
+    if check.passed {
+        return Ok(());
+    }
+    let hint = check
+        .hint
+        .as_deref()
+        .unwrap_or("run `zeroclaw skill doctor`");
+    bail!("{what}: {} {}; {hint}", check.name, check.detail)
+}
+
+/// Build the skill in `dir` into [`OUTPUT`] and seal it with [`CHECKSUM`].
+pub fn build(dir: &Path, options: &Options) -> Result<Built> {
+    let language = Language::detect(dir)?;
+    if language != Language::Go {
+        if options.compiler.is_some() {
+            bail!(
+                "--compiler picks a Go toolchain; this is a {} skill",
+                language.name()
+            );
+        }
+        if options.version.is_some() {
+            bail!(
+                "--version sets main.version in Go skills; set a {} skill's version in its {}",
+                language.name(),
+                language.project_file()
+            );
+        }
+    }
+    let (mut cmd, toolchain) = match language {
+        Language::Go => {
+            let compiler = options.compiler.unwrap_or(Compiler::TinyGo);
+            let cmd = compiler.command(options)?;
+            require(
+                &format!("--compiler {}", compiler.name()),
+                &compiler.check(),
+            )?;
+            (cmd, compiler.name())
+        }
+        Language::Rust => {
+            require(
+                "Rust skill",
+                &doctor::check_cargo(doctor::command_stdout("cargo", &["--version"]).as_deref()),
+            )?;
+            let mut cmd = Command::new("cargo");
+            cmd.args(["build", "--release", "--target", options.target.rust()]);
+            if let Some(opt) = &options.opt {
+                cmd.env("CARGO_PROFILE_RELEASE_OPT_LEVEL", opt);
+            }
+            (cmd, "cargo")
+        }
+        Language::JavaScript => {
+            if options.opt.is_some() {
+                bail!("--opt sets TinyGo's or Cargo's optimization level; javy has none");
+            }
+            if options.target == Target::Wasip2 {
+                bail!("--target wasip2 needs TinyGo or Cargo; javy builds only wasip1 modules");
+            }
+            require(
+                "JavaScript skill",
+                &doctor::check_javy(doctor::command_stdout("javy", &["--version"]).as_deref()),
+            )?;
+            let mut cmd = Command::new("javy");
+            cmd.args(["build", "src/index.js", "-o", OUTPUT]);
+            (cmd, "javy")
+        }
+    };
+
+    if options.clean {
+        clean(dir, language, options.target)?;
+    }
+    let status = cmd
+        .current_dir(dir)
+        .status()
+        .with_context(|| format!("failed to run {toolchain}"))?;
+    if !status.success() {
+        bail!("{toolchain} build failed ({status})");
+    }
+    let output = dir.join(OUTPUT);
+    if language == Language::Rust {
+        let built = rust_module(dir, options.target)?;
+        std::fs::copy(&built, &output).with_context(|| {
+            format!("failed to copy {} to {}", built.display(), output.display())
+        })?;
+    }
+    let wasm = std::fs::read(&output)
+        .with_context(|| format!("{toolchain} wrote no {}", output.display()))?;
+    let sha256 = hex::encode(Sha256::digest(&wasm));
+    std::fs::write(dir.join(CHECKSUM), format!("{sha256}  {OUTPUT}\n"))
+        .with_context(|| format!("failed to write {}", dir.join(CHECKSUM).display()))?;
+    Ok(Built {
+        language,
+        toolchain,
+        size: wasm.len() as u64,
+        sha256,
+    })
+}
+
+/// Remove what an earlier build of the skill in `dir` for `target` left
+/// behind.
+fn clean(dir: &Path, language: Language, target: Target) -> Result<()> {
+    for file in [OUTPUT, CHECKSUM] {
+        match std::fs::remove_file(dir.join(file)) {
+            Err(err) if err.kind() != std::io::ErrorKind::NotFound => {
+                return Err(err).with_context(|| format!("failed to remove {file}"));
+            }
+            _ => {}
+        }
+    }
+    if language == Language::Rust {
+        let built = rust_target_dir(dir).join(target.rust());
+        if built.is_dir() {
+            std::fs::remove_dir_all(&built)
+                .with_context(|| format!("failed to remove {}", built.display()))?;
+        }
+    }
+    Ok(())
+}
+
+/// Cargo's target directory for the crate in `dir`.
+fn rust_target_dir(dir: &Path) -> PathBuf {
+    match std::env::var_os("CARGO_TARGET_DIR") {
+        Some(target) => dir.join(target),
+        None => dir.join("target"),
+    }
+}
+
+/// The module Cargo built for the crate in `dir` for `target`: its first
+/// `[[bin]]`, or else its package, by name.
+fn rust_module(dir: &Path, target: Target) -> Result<PathBuf> {
+    let manifest = std::fs::read_to_string(dir.join("Cargo.toml"))
+        .with_context(|| format!("failed to read {}", dir.join("Cargo.toml").display()))?;
+    let manifest: toml::Table = toml::from_str(&manifest).context("failed to parse Cargo.toml")?;
+    let bin = manifest
+        .get("bin")
+        .and_then(|bins| bins.as_array()?.first()?.get("name")?.as_str())
+        .or_else(|| manifest.get("package")?.get("name")?.as_str())
+        .context("Cargo.toml names no [[bin]] or [package]")?;
+    Ok(rust_target_dir(dir)
+        .join(target.rust())
+        .join("release")
+        .join(format!("{bin}.wasm")))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn compilers_parse_by_name() {
+        assert_eq!(Compiler::parse("tinygo").unwrap(), Compiler::TinyGo);
+        assert_eq!(Compiler::parse("go").unwrap(), Compiler::Go);
+        let err = Compiler::parse("gccgo").unwrap_err().to_string();
+        assert!(err.contains("expected tinygo or go"), "{err}");
+    }
+
+    fn args(cmd: &Command) -> Vec<String> {
+        cmd.get_args()
+            .map(|a| a.to_str().unwrap().to_owned())
+            .collect()
+    }
+
+    #[test]
+    fn go_builds_for_wasip1() {
+        let cmd = Compiler::Go.command(&Options::default()).unwrap();
+        let env: Vec<_> = cmd
+            .get_envs()
+            .map(|(k, v)| (k.to_str().unwrap(), v.and_then(|v| v.to_str())))
+            .collect();
+        assert!(env.contains(&("GOOS", Some("wasip1"))), "{env:?}");
+        assert!(env.contains(&("GOARCH", Some("wasm"))), "{env:?}");
+        let args = args(&Compiler::TinyGo.command(&Options::default()).unwrap());
+        assert_eq!(args, ["build", "-o", OUTPUT, "-target=wasip1", "."]);
+    }
+
+    #[test]
+    fn opt_and_version_reach_the_go_toolchains() {
+        let options = Options {
+            opt: Some("z".into()),
+            version: Some("1.2.3".into()),
+            ..Options::default()
+        };
+        let args = args(&Compiler::TinyGo.command(&options).unwrap());
+        assert!(args.contains(&"-opt=z".to_owned()), "{args:?}");
+        assert!(
+            args.contains(&"-ldflags=-X main.version=1.2.3".to_owned()),
+            "{args:?}"
+        );
+        let err = Compiler::Go.command(&options).unwrap_err().to_string();
+        assert!(err.contains("--compiler go has none"), "{err}");
+    }
+
+    #[test]
+    fn missing_toolchain_is_reported_with_its_hint() {
+        let err = require("--compiler go", &doctor::check_go(None))
+            .unwrap_err()
+            .to_string();
+        assert!(
+            err.starts_with("--compiler go: go not found on PATH"),
+            "{err}"
+        );
+        assert!(err.contains("https://go.dev/dl/"), "{err}");
+
+        let err = require("JavaScript skill", &doctor::check_javy(None))
+            .unwrap_err()
+            .to_string();
+        assert!(
+            err.starts_with("JavaScript skill: javy not found on PATH; install Javy"),
+            "{err}"
+        );
+    }
+
+    #[test]
+    fn non_go_directories_are_rejected() {
+        let dir = tempfile::tempdir().unwrap();
+        let err = build(dir.path(), &Options::default())
+            .unwrap_err()
+            .to_string();
+        assert!(err.contains("has no go.mod"), "{err}");
+    }
+
+    #[test]
+    fn language_is_detected_from_the_project_file() {
+        let dir = tempfile::tempdir().unwrap();
+        std::fs::write(dir.path().join("package.json"), "{}").unwrap();
+        assert_eq!(Language::detect(dir.path()).unwrap(), Language::JavaScript);
+        std::fs::write(dir.path().join("Cargo.toml"), "").unwrap();
+        assert_eq!(Language::detect(dir.path()).unwrap(), Language::Rust);
+
+        let options = Options {
+            version: Some("1.2.3".into()),
+            ..Options::default()
+        };
+        let err = build(dir.path(), &options).unwrap_err().to_string();
+        assert!(
+            err.contains("set a Rust skill's version in its Cargo.toml"),
+            "{err}"
+        );
+    }
+
+    #[test]
+    fn rust_module_is_named_after_the_bin() {
+        let dir = tempfile::tempdir().unwrap();
+        std::fs::write(
+            dir.path().join("Cargo.toml"),
+            "[package]\nname = \"calc\"\n\n[[bin]]\nname = \"calc_tool\"\npath = \"src/main.rs\"\n",
+        )
+        .unwrap();
+        let module = rust_module(dir.path(), Target::Wasip1).unwrap();
+        assert!(
+            module.ends_with("wasm32-wasip1/release/calc_tool.wasm"),
+            "{}",
+            module.display()
+        );
+        let component = rust_module(dir.path(), Target::Wasip2).unwrap();
+        assert!(
+            component.ends_with("wasm32-wasip2/release/calc_tool.wasm"),
+            "{}",
+            component.display()
+        );
+    }
+
+    #[test]
+    fn wasip2_builds_with_tinygo_and_cargo_only() {
+        assert_eq!(Target::parse("wasip2").unwrap(), Target::Wasip2);
+        let err = Target::parse("wasip3").unwrap_err().to_string();
+        assert!(err.contains("expected wasip1 or wasip2"), "{err}");
+
+        let options = Options {
+            target: Target::Wasip2,
+            ..Options::default()
+        };
+        let args = args(&Compiler::TinyGo.command(&options).unwrap());
+        assert_eq!(args, ["build", "-o", OUTPUT, "-target=wasip2", "."]);
+        let err = Compiler::Go.command(&options).unwrap_err().to_string();
+        assert!(err.contains("--target wasip2 needs TinyGo"), "{err}");
+
+        let dir = tempfile::tempdir().unwrap();
+        std::fs::write(dir.path().join("package.json"), "{}").unwrap();
+        let err = build(dir.path(), &options).unwrap_err().to_string();
+        assert!(err.contains("javy builds only wasip1 modules"), "{err}");
+    }
+
+    /// Builds the Go `word_count` template with the standard Go toolchain, so
+    /// it runs wherever `go` is installed; skipped where it is not.
+    #[test]
+    fn go_template_builds_to_a_runnable_module() {
+        if doctor::command_stdout("go", &["version"]).is_none() {
+            eprintln!("skipping: go is not installed");
+            return;
+        }
+        let repo = Path::new(env!("CARGO_MANIFEST_DIR"));
+        let template = repo.join("templates/go/word_count");
+        let dir = tempfile::tempdir().unwrap();
+        std::fs::create_dir(dir.path().join("i18n")).unwrap();
+        for file in [
+            "go.sum",
+            "i18n/en.json",
+            "i18n/fr.json",
+            "i18n/pl.json",
+            "i18n/ru.json",
+            "main.go",
+            "manifest.json",
+        ] {
+            std::fs::copy(template.join(file), dir.path().join(file)).unwrap();
+        }
+        let go_mod = std::fs::read_to_string(template.join("go.mod"))
+            .unwrap()
+            .replace("../../../sdk/go", repo.join("sdk/go").to_str().unwrap());
+        std::fs::write(dir.path().join("go.mod"), go_mod).unwrap();
+        std::fs::write(dir.path().join(OUTPUT), "stale").unwrap();
+
+        let options = Options {
+            compiler: Some(Compiler::Go),
+            clean: true,
+            ..Options::default()
+        };
+        let built = build(dir.path(), &options).unwrap();
+        let wasm = std::fs::read(dir.path().join(OUTPUT)).unwrap();
+        assert!(wasm.starts_with(b"\0asm"), "not a WASM module");
+        assert_eq!(built.language, Language::Go);
+        assert_eq!(built.size, wasm.len() as u64);
+        assert_eq!(
+            std::fs::read_to_string(dir.path().join(CHECKSUM)).unwrap(),
+            format!("{}  {OUTPUT}\n", hex::encode(Sha256::digest(&wasm)))
+        );
+
+        if cfg!(feature = "wasm-tools") {
+            let res = crate::tools::wasm_tool::WasmTool::run_bytes(
+                &wasm,
+                &serde_json::json!({"text": "hello world"}),
+            )
+            .unwrap();
+            assert!(res.success, "{res:?}");
+            assert!(res.output.starts_with("2 words"), "{}", res.output);
+        }
+    }
+}
@@ -0,0 +1,186 @@
+//! `skill test --canonical` — print a result laid out for golden files.
+//!
+//! Object keys are sorted at every depth and each field gets its own line, so
+//! a diff between two goldens shows only the fields that changed. Arrays keep
+//! their order; SDKs emit map-backed data as sorted slices already.
+//! `--ignore-fields` drops volatile fields such as `meta.trace_id` first. The
+//! layout matches the Go SDK's `skill.MarshalCanonical`.
+//!
+//! `--stable-output` swaps the free-form `output`, which a skill may word
+//! differently per locale, for a rendering of `data` that never changes with
+//! it, e.g. `characters=11;lines=1;words=2`.
+
+use anyhow::Result;
+use serde_json::Value;
+
+/// Render `result` canonically, without the dotted paths in `ignore`. A path
+/// that runs through an array applies to each of its elements, so
+/// `data.items.id` drops every item's `id`.
+pub fn canonical(result: &Value, ignore: &[String]) -> Result<String> {
+    let mut result = result.clone();
+    strip_paths(&mut result, ignore);
+    Ok(serde_json::to_string_pretty(&sorted(result))?)
+}
+
+/// Remove the dotted paths in `ignore` from `value`, as [`canonical`] does.
+pub fn strip_paths(value: &mut Value, ignore: &[String]) {
+    for path in ignore {
+        let segments: Vec<&str> = path.split('.').filter(|s| !s.is_empty()).collect();
+        strip(value, &segments);
+    }
+}
+
+fn strip(value: &mut Value, path: &[&str]) {
+    let Some((first, rest)) = path.split_first() else {
+        return;
+    };
+    match value {
+        Value::Object(fields) if rest.is_empty() => {
+            fields.remove(*first);
+        }
+        Value::Object(fields) => {
+            if let Some(inner) = fields.get_mut(*first) {
+                strip(inner, rest);
+            }
+        }
+        Value::Array(items) => {
+            for item in items {
+                strip(item, path);
+            }
+        }
+        _ => {}
+    }
+}
+
+/// Replace `result`'s `output` with its `data` as `key=value` pairs in key
+/// order, joined by `;`. Nested objects give dotted keys (`counts.words=2`),
+/// strings are written bare, and other values as JSON. A result whose `data`
+/// is not an object keeps its `output`.
+pub fn stable_output(result: &mut Value) {
+    let Some(Value::Object(data)) = result.get("data") else {
+        return;
+    };
+    let mut pairs = Vec::new();
+    flatten("", data, &mut pairs);
+    pairs.sort();
+    let rendered = pairs
+        .iter()
+        .map(|(key, value)| format!("{key}={value}"))
+        .collect::<Vec<_>>()
+        .join(";");
+    result["output"] = Value::String(rendered);
+}
+
+fn flatten(
+    prefix: &str,
+    fields: &serde_json::Map<String, Value>,
+    pairs: &mut Vec<(String, String)>,
+) {
+    for (key, value) in fields {
+        let key = if prefix.is_empty() {
+            key.clone()
+        } else {
+            format!("{prefix}.{key}")
+        };
+        match value {
+            Value::Object(inner) => flatten(&key, inner, pairs),
+            Value::String(s) => pairs.push((key, s.clone())),
+            other => pairs.push((key, other.to_string())),
+        }
+    }
+}
+
+/// `value` with the keys of every object in byte order, whatever order the
+/// map type keeps them in.
+fn sorted(value: Value) -> Value {
+    match value {
+        Value::Object(fields) => {
+            let mut fields: Vec<(String, Value)> = fields.into_iter().collect();
+            fields.sort_by(|a, b| a.0.cmp(&b.0));
+            Value::Object(fields.into_iter().map(|(k, v)| (k, sorted(v))).collect())
+        }
+        Value::Array(items) => Value::Array(items.into_iter().map(sorted).collect()),
+        other => other,
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn key_order_does_not_change_the_output() {
+        let a: Value = serde_json::from_str(
+            r#"{"success":true,"output":"2 words","data":{"words":2,"lines":[{"n":1,"len":5}]}}"#,
+        )
+        .unwrap();
+        let b: Value = serde_json::from_str(
+            r#"{"data":{"lines":[{"len":5,"n":1}],"words":2},"output":"2 words","success":true}"#,
+        )
+        .unwrap();
+        let out = canonical(&a, &[]).unwrap();
+        assert_eq!(out, canonical(&b, &[]).unwrap());
+        assert_eq!(
+            out,
+            "{\n  \"data\": {\n    \"lines\": [\n      {\n        \"len\": 5,\n        \"n\": 1\n      }\n    ],\n    \"words\": 2\n  },\n  \"output\": \"2 words\",\n  \"success\": true\n}"
+        );
+    }
+
+    #[test]
+    fn stable_output_is_the_same_in_every_locale() {
+        // What the Go word_count template answers for "hello wide world".
+        let localized = [
+            r#"{"success":true,"output":"3 words, 1 line, 16 characters","data":{"words":3,"lines":1,"characters":16}}"#,
+            r#"{"success":true,"output":"3 słowa, 1 wiersz, 16 znaków","data":{"words":3,"lines":1,"characters":16}}"#,
+            r#"{"success":true,"output":"3 слова, 1 строка, 16 символов","data":{"words":3,"lines":1,"characters":16}}"#,
+        ];
+        let outputs: Vec<String> = localized
+            .iter()
+            .map(|raw| {
+                let mut result: Value = serde_json::from_str(raw).unwrap();
+                stable_output(&mut result);
+                canonical(&result, &[]).unwrap()
+            })
+            .collect();
+        assert!(outputs.iter().all(|out| *out == outputs[0]));
+        assert!(outputs[0].contains(r#""output": "characters=16;lines=1;words=3""#));
+    }
+
+    #[test]
+    fn stable_output_flattens_nested_data() {
+        let mut result: Value = serde_json::from_str(
+            r#"{"success":true,"output":"x","data":{"name":"a b","counts":{"words":2},"tags":["t"],"ok":true}}"#,
+        )
+        .unwrap();
+        stable_output(&mut result);
+        assert_eq!(
+            result["output"],
+            r#"counts.words=2;name=a b;ok=true;tags=["t"]"#
+        );
+
+        let mut plain: Value =
+            serde_json::from_str(r#"{"success":true,"output":"HELLO"}"#).unwrap();
+        stable_output(&mut plain);
+        assert_eq!(plain["output"], "HELLO");
+    }
+
+    #[test]
+    fn ignored_fields_are_dropped_first() {
+        let result: Value = serde_json::from_str(
+            r#"{"success":true,"output":"","meta":{"trace_id":"a1"},"data":{"items":[{"id":1,"at":"t1"},{"id":2,"at":"t2"}]}}"#,
+        )
+        .unwrap();
+        let ignore = ["meta.trace_id".to_string(), "data.items.at".to_string()];
+        assert_eq!(
+            canonical(&result, &ignore).unwrap(),
+            canonical(
+                &serde_json::from_str(
+                    r#"{"success":true,"output":"","meta":{},"data":{"items":[{"id":1},{"id":2}]}}"#
+                )
+                .unwrap(),
+                &[]
+            )
+            .unwrap()
+        );
+    }
+}
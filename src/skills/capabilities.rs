@@ -0,0 +1,360 @@
+//! `zeroclaw skill inspect --capabilities` — which host functions a built
+//! skill imports, read from its wasm without running it.
+//!
+//! A module's import section names every function it needs from its host.
+//! The `zeroclaw_*` ones are the host functions a runtime only links for a
+//! skill granted the capability they need, such as `net` for
+//! `zeroclaw_http_fetch`; an import the manifest does not declare is
+//! flagged, so a policy can reject a skill that reaches the network without
+//! saying so. WASI imports are left out: every Go or Rust module imports
+//! `path_open` and friends, and what they reach is decided by the
+//! directories the host preopens, not by the import.
+
+use anyhow::{bail, Context, Result};
+use serde_json::Value;
+
+/// The prefix of every host function a zeroclaw runtime provides.
+pub const HOST_FUNCTION_PREFIX: &str = "zeroclaw_";
+
+/// Each host function a runtime provides and the capability a skill must
+/// declare to get it.
+const HOST_FUNCTIONS: [(&str, &str); 2] =
+    [("zeroclaw_http_fetch", "net"), ("zeroclaw_http_get", "net")];
+
+/// One `zeroclaw_*` host function a module imports.
+#[derive(Debug, Clone, PartialEq, Eq, serde::Serialize)]
+pub struct HostImport {
+    pub module: String,
+    pub name: String,
+    /// The capability that grants it, or `None` for a function no runtime
+    /// provides, which no manifest can declare.
+    pub capability: Option<&'static str>,
+}
+
+/// The host functions a module imports, checked against its manifest.
+#[derive(Debug, Clone, PartialEq, Eq, serde::Serialize)]
+pub struct Report {
+    pub imports: Vec<HostImport>,
+    /// The capabilities the manifest declares, e.g. `net` or `fs:/data`.
+    pub declared: Vec<String>,
+    /// The imports whose capability the manifest does not declare.
+    pub undeclared: Vec<String>,
+    #[serde(flatten)]
+    pub safety: Safety,
+}
+
+/// What a manifest says calling the tool does. A manifest that does not say
+/// is taken to write and not be idempotent.
+#[derive(Debug, Clone, PartialEq, Eq, serde::Serialize)]
+pub struct Safety {
+    /// The `side_effects` class: `none`, `read`, or `write`.
+    pub side_effects: String,
+    pub idempotent: bool,
+}
+
+impl Safety {
+    pub fn of(manifest: &Value) -> Self {
+        Self {
+            side_effects: manifest
+                .get("side_effects")
+                .and_then(Value::as_str)
+                .unwrap_or("write")
+                .to_string(),
+            idempotent: manifest
+                .get("idempotent")
+                .and_then(Value::as_bool)
+                .unwrap_or(false),
+        }
+    }
+}
+
+impl std::fmt::Display for Safety {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        let idempotent = if self.idempotent {
+            "idempotent"
+        } else {
+            "not idempotent"
+        };
+        write!(f, "{}, {idempotent}", self.side_effects)
+    }
+}
+
+/// Read the host functions `wasm` imports and check each against the
+/// capabilities `manifest` declares.
+pub fn report(wasm: &[u8], manifest: &Value) -> Result<Report> {
+    let declared = declared(manifest);
+    let imports: Vec<HostImport> = imports(wasm)?
+        .into_iter()
+        .filter(|(_, name)| name.starts_with(HOST_FUNCTION_PREFIX))
+        .map(|(module, name)| HostImport {
+            capability: HOST_FUNCTIONS
+                .iter()
+                .find(|(function, _)| *function == name)
+                .map(|&(_, capability)| capability),
+            module,
+            name,
+        })
+        .collect();
+    let undeclared = imports
+        .iter()
+        .filter(|import| {
+            !import
+                .capability
+                .is_some_and(|capability| declared.iter().any(|d| d == capability))
+        })
+        .map(|import| import.name.clone())
+        .collect();
+    Ok(Report {
+        imports,
+        declared,
+        undeclared,
+        safety: Safety::of(manifest),
+    })
+}
+
+/// The capabilities a manifest declares: `net` when `capabilities.net` is
+/// true, then `fs:<dir>` and `secret:<KEY>` for each listed.
+pub fn declared(manifest: &Value) -> Vec<String> {
+    let mut declared = Vec::new();
+    if manifest.pointer("/capabilities/net") == Some(&Value::Bool(true)) {
+        declared.push("net".to_string());
+    }
+    for (key, prefix) in [("fs", "fs:"), ("secrets", "secret:")] {
+        let items = manifest
+            .get("capabilities")
+            .and_then(|caps| caps.get(key))
+            .and_then(Value::as_array);
+        for item in items.into_iter().flatten().filter_map(Value::as_str) {
+            declared.push(format!("{prefix}{item}"));
+        }
+    }
+    declared
+}
+
+/// The `(module, name)` of every import in a wasm binary, in the order its
+/// import section lists them.
+pub fn imports(wasm: &[u8]) -> Result<Vec<(String, String)>> {
+    let mut reader = Reader { bytes: wasm, at: 0 };
+    if reader.take(4)? != b"\0asm" {
+        bail!("not a wasm module: missing the \\0asm header");
+    }
+    if reader.take(4)? != [1, 0, 0, 0] {
+        bail!("unsupported wasm binary version; only version 1 modules can be inspected");
+    }
+    while reader.at < wasm.len() {
+        let id = reader.byte()?;
+        let size = reader.u32()? as usize;
+        let body = reader.take(size)?;
+        if id == IMPORT_SECTION {
+            return import_entries(body).context("malformed import section");
+        }
+    }
+    Ok(Vec::new())
+}
+
+/// The id of the import section.
+const IMPORT_SECTION: u8 = 2;
+
+fn import_entries(section: &[u8]) -> Result<Vec<(String, String)>> {
+    let mut reader = Reader {
+        bytes: section,
+        at: 0,
+    };
+    let count = reader.u32()?;
+    let mut entries = Vec::new();
+    for _ in 0..count {
+        let module = reader.name()?;
+        let name = reader.name()?;
+        // Skip the import's description; only its names matter here.
+        match reader.byte()? {
+            // A function, or an exception tag: a type index.
+            0x00 => {
+                reader.u32()?;
+            }
+            0x04 => {
+                reader.byte()?;
+                reader.u32()?;
+            }
+            // A table: its element type, then its limits.
+            0x01 => {
+                reader.byte()?;
+                reader.limits()?;
+            }
+            0x02 => reader.limits()?,
+            // A global: its value type and mutability.
+            0x03 => {
+                reader.take(2)?;
+            }
+            kind => bail!("unknown kind {kind:#04x} for import {module}.{name}"),
+        }
+        entries.push((module, name));
+    }
+    Ok(entries)
+}
+
+/// A cursor over the bytes of a wasm binary.
+struct Reader<'a> {
+    bytes: &'a [u8],
+    at: usize,
+}
+
+impl<'a> Reader<'a> {
+    fn take(&mut self, n: usize) -> Result<&'a [u8]> {
+        let end = self
+            .at
+            .checked_add(n)
+            .filter(|&end| end <= self.bytes.len())
+            .context("unexpected end of wasm binary")?;
+        let taken = &self.bytes[self.at..end];
+        self.at = end;
+        Ok(taken)
+    }
+
+    fn byte(&mut self) -> Result<u8> {
+        Ok(self.take(1)?[0])
+    }
+
+    /// An unsigned LEB128 number of at most 32 bits.
+    fn u32(&mut self) -> Result<u32> {
+        let mut value = 0u32;
+        for shift in (0..35).step_by(7) {
+            let byte = self.byte()?;
+            value |= u32::from(byte & 0x7f)
+                .checked_shl(shift)
+                .filter(|_| shift < 28 || byte & 0x7f < 0x10)
+                .context("LEB128 number is over 32 bits")?;
+            if byte & 0x80 == 0 {
+                return Ok(value);
+            }
+        }
+        bail!("LEB128 number is over 32 bits")
+    }
+
+    fn name(&mut self) -> Result<String> {
+        let len = self.u32()? as usize;
+        let bytes = self.take(len)?;
+        String::from_utf8(bytes.to_vec()).context("import name is not UTF-8")
+    }
+
+    /// The limits of a table or memory: a flags byte, a minimum, and a
+    /// maximum when bit 0 of the flags is set.
+    fn limits(&mut self) -> Result<()> {
+        let flags = self.byte()?;
+        self.u32()?;
+        if flags & 0x01 != 0 {
+            self.u32()?;
+        }
+        Ok(())
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    /// A module of one `() -> ()` function type and the given function
+    /// imports, plus a memory import.
+    fn module_importing(functions: &[(&str, &str)]) -> Vec<u8> {
+        fn name(out: &mut Vec<u8>, name: &str) {
+            out.push(name.len() as u8);
+            out.extend_from_slice(name.as_bytes());
+        }
+        let mut imports = vec![functions.len() as u8 + 1];
+        for (module, field) in functions {
+            name(&mut imports, module);
+            name(&mut imports, field);
+            imports.extend_from_slice(&[0x00, 0x00]);
+        }
+        name(&mut imports, "env");
+        name(&mut imports, "memory");
+        imports.extend_from_slice(&[0x02, 0x01, 0x01, 0x02]);
+
+        let mut wasm = b"\0asm\x01\0\0\0".to_vec();
+        wasm.extend_from_slice(&[0x01, 0x04, 0x01, 0x60, 0x00, 0x00]);
+        wasm.push(IMPORT_SECTION);
+        wasm.push(imports.len() as u8);
+        wasm.extend(imports);
+        wasm
+    }
+
+    #[test]
+    fn imports_lists_each_import_in_order() {
+        let wasm = module_importing(&[
+            ("wasi_snapshot_preview1", "fd_write"),
+            ("env", "zeroclaw_http_get"),
+        ]);
+        assert_eq!(
+            imports(&wasm).unwrap(),
+            [
+                ("wasi_snapshot_preview1".to_string(), "fd_write".to_string()),
+                ("env".to_string(), "zeroclaw_http_get".to_string()),
+                ("env".to_string(), "memory".to_string()),
+            ]
+        );
+        assert!(imports(b"\0asm\x01\0\0\0").unwrap().is_empty());
+    }
+
+    #[test]
+    fn imports_rejects_what_is_not_a_whole_module() {
+        assert!(imports(b"not wasm").is_err());
+        assert!(imports(b"\0asm\x02\0\0\0").is_err());
+        let wasm = module_importing(&[("env", "zeroclaw_http_get")]);
+        assert!(imports(&wasm[..wasm.len() - 3]).is_err());
+        let mut overlong = b"\0asm\x01\0\0\0\x02".to_vec();
+        overlong.extend_from_slice(&[0xff, 0xff, 0xff, 0xff, 0x7f]);
+        assert!(imports(&overlong).is_err());
+    }
+
+    #[test]
+    fn report_flags_host_functions_the_manifest_does_not_declare() {
+        let wasm = module_importing(&[
+            ("wasi_snapshot_preview1", "fd_write"),
+            ("env", "zeroclaw_http_fetch"),
+            ("env", "zeroclaw_ask"),
+        ]);
+        let report = report(&wasm, &json!({"capabilities": {"fs": ["/data"]}})).unwrap();
+        assert_eq!(
+            report.imports,
+            [
+                HostImport {
+                    module: "env".to_string(),
+                    name: "zeroclaw_http_fetch".to_string(),
+                    capability: Some("net"),
+                },
+                HostImport {
+                    module: "env".to_string(),
+                    name: "zeroclaw_ask".to_string(),
+                    capability: None,
+                },
+            ]
+        );
+        assert_eq!(report.declared, ["fs:/data"]);
+        assert_eq!(report.undeclared, ["zeroclaw_http_fetch", "zeroclaw_ask"]);
+        assert_eq!(report.safety.to_string(), "write, not idempotent");
+    }
+
+    #[test]
+    fn report_passes_an_import_the_manifest_declares() {
+        let wasm = module_importing(&[("env", "zeroclaw_http_get")]);
+        let manifest = json!({
+            "capabilities": {"net": true, "secrets": ["API_KEY"]},
+            "side_effects": "read",
+            "idempotent": true,
+        });
+        let report = report(&wasm, &manifest).unwrap();
+        assert_eq!(report.declared, ["net", "secret:API_KEY"]);
+        assert!(report.undeclared.is_empty(), "{report:?}");
+        assert_eq!(report.safety.to_string(), "read, idempotent");
+        assert_eq!(
+            serde_json::to_value(&report).unwrap(),
+            json!({
+                "imports": [{"module": "env", "name": "zeroclaw_http_get", "capability": "net"}],
+                "declared": ["net", "secret:API_KEY"],
+                "undeclared": [],
+                "side_effects": "read",
+                "idempotent": true,
+            })
+        );
+    }
+}
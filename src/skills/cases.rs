@@ -0,0 +1,550 @@
+//! `zeroclaw skill test --cases` — run a fixtures file against one skill.
+//!
+//! A fixtures file is a JSON array of cases:
+//!
+//! ```json
+//! [{"name": "two words", "args": {"text": "a b"}, "expect": {"data": {"words": 2}}}]
+//! ```
+//!
+//! `expect` is matched as a subset of the tool's `ToolResult`: every key it
+//! names must be present with an equal value; other keys are ignored. A case
+//! with no `expect` passes when the tool returns `success: true`, and one with
+//! `"skip": true` is reported without being run.
+
+use anyhow::{Context, Result};
+use serde::Deserialize;
+use serde_json::{json, Value};
+use std::fmt;
+use std::panic::{catch_unwind, AssertUnwindSafe};
+use std::path::Path;
+use std::sync::atomic::{AtomicUsize, Ordering};
+use std::sync::Mutex;
+use std::time::{Duration, Instant};
+
+/// One fixture: args to send and the expected (partial) result.
+#[derive(Debug, Clone, Deserialize)]
+pub struct Case {
+    pub name: String,
+    #[serde(default = "default_args")]
+    pub args: Value,
+    #[serde(default)]
+    pub expect: Option<Value>,
+    #[serde(default)]
+    pub skip: bool,
+}
+
+fn default_args() -> Value {
+    Value::Object(serde_json::Map::new())
+}
+
+/// Result of running one case.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct CaseOutcome {
+    pub name: String,
+    /// `None` when the case passed or was skipped, otherwise why it failed.
+    pub failure: Option<String>,
+    /// Where the result departs from `expect`, one `<path>: ...` line each.
+    pub diff: Vec<String>,
+    pub skipped: bool,
+    pub elapsed: Duration,
+    /// The `error_code` of the tool's result, when it returned one.
+    pub error_code: Option<String>,
+}
+
+impl CaseOutcome {
+    pub fn passed(&self) -> bool {
+        self.failure.is_none() && !self.skipped
+    }
+
+    /// `"passed"`, `"failed"`, or `"skipped"`, as the machine-readable
+    /// reports name it.
+    pub fn status(&self) -> &'static str {
+        if self.skipped {
+            "skipped"
+        } else if self.passed() {
+            "passed"
+        } else {
+            "failed"
+        }
+    }
+
+    /// The failure followed by its diff, a line each; empty when the case
+    /// did not fail.
+    pub fn message(&self) -> String {
+        self.failure
+            .iter()
+            .chain(&self.diff)
+            .map(String::as_str)
+            .collect::<Vec<_>>()
+            .join("\n")
+    }
+}
+
+/// How many cases passed, failed, and were skipped.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub struct Summary {
+    pub passed: usize,
+    pub failed: usize,
+    pub skipped: usize,
+}
+
+impl Summary {
+    pub fn of(outcomes: &[CaseOutcome]) -> Self {
+        let mut summary = Self::default();
+        for outcome in outcomes {
+            if outcome.skipped {
+                summary.skipped += 1;
+            } else if outcome.passed() {
+                summary.passed += 1;
+            } else {
+                summary.failed += 1;
+            }
+        }
+        summary
+    }
+}
+
+impl fmt::Display for Summary {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(
+            f,
+            "{} passed, {} failed, {} skipped",
+            self.passed, self.failed, self.skipped
+        )
+    }
+}
+
+/// Render `outcomes` for a terminal: a line per case with its time, the diff
+/// under each failure, and the summary last. `color` adds ANSI styling.
+pub fn render_report(outcomes: &[CaseOutcome], color: bool) -> String {
+    let mut out = String::new();
+    for outcome in outcomes {
+        let ms = outcome.elapsed.as_millis();
+        let line = match &outcome.failure {
+            _ if outcome.skipped => format!(
+                "  {} {} (skipped)",
+                console::style("-").yellow().force_styling(color),
+                outcome.name
+            ),
+            None => format!(
+                "  {} {} ({ms}ms)",
+                console::style("✓").green().bold().force_styling(color),
+                outcome.name
+            ),
+            Some(why) => format!(
+                "  {} {} ({ms}ms): {why}",
+                console::style("✗").red().bold().force_styling(color),
+                outcome.name
+            ),
+        };
+        out.push_str(&line);
+        out.push('\n');
+        for change in &outcome.diff {
+            out.push_str(&format!(
+                "      {}\n",
+                console::style(change).dim().force_styling(color)
+            ));
+        }
+    }
+    let summary = Summary::of(outcomes);
+    let summary_style = if summary.failed > 0 {
+        console::Style::new().red().bold()
+    } else {
+        console::Style::new().green().bold()
+    };
+    out.push('\n');
+    out.push_str(&format!(
+        "  {}\n",
+        summary_style.force_styling(color).apply_to(summary)
+    ));
+    out
+}
+
+/// `--json` report: each case with its status and time, and the totals.
+pub fn json_report(outcomes: &[CaseOutcome]) -> Value {
+    let cases: Vec<Value> = outcomes
+        .iter()
+        .map(|outcome| {
+            let mut case = json!({
+                "name": outcome.name,
+                "status": outcome.status(),
+                "elapsed_ms": outcome.elapsed.as_millis(),
+            });
+            if let Some(failure) = &outcome.failure {
+                case["failure"] = json!(failure);
+            }
+            if !outcome.diff.is_empty() {
+                case["diff"] = json!(outcome.diff);
+            }
+            case
+        })
+        .collect();
+    let summary = Summary::of(outcomes);
+    json!({
+        "cases": cases,
+        "passed": summary.passed,
+        "failed": summary.failed,
+        "skipped": summary.skipped,
+    })
+}
+
+/// `--format csv` report: a `name,status,duration_ms,error_code,message`
+/// header, then a row per case. `message` is the failure followed by its
+/// diff, a line each, and fields are quoted as RFC 4180 asks.
+pub fn csv_report(outcomes: &[CaseOutcome]) -> String {
+    let mut out = String::from("name,status,duration_ms,error_code,message\n");
+    for outcome in outcomes {
+        let row = [
+            csv_field(&outcome.name),
+            csv_field(outcome.status()),
+            csv_field(&outcome.elapsed.as_millis().to_string()),
+            csv_field(outcome.error_code.as_deref().unwrap_or_default()),
+            csv_field(&outcome.message()),
+        ];
+        out.push_str(&row.join(","));
+        out.push('\n');
+    }
+    out
+}
+
+/// `field` as a CSV field: quoted, with quotes doubled, when it holds a
+/// comma, quote, or line break.
+fn csv_field(field: &str) -> String {
+    if field.contains([',', '"', '\n', '\r']) {
+        format!("\"{}\"", field.replace('"', "\"\""))
+    } else {
+        field.to_string()
+    }
+}
+
+/// Read a fixtures file.
+pub fn load_cases(path: &Path) -> Result<Vec<Case>> {
+    let raw = std::fs::read_to_string(path)
+        .with_context(|| format!("failed to read cases file: {}", path.display()))?;
+    serde_json::from_str(&raw)
+        .with_context(|| format!("{} is not a JSON array of cases", path.display()))
+}
+
+/// Run `cases` across `parallel` workers and return outcomes in case order.
+///
+/// `run(args_json)` executes the skill once and returns its stdout; it must
+/// not share mutable state between calls, since workers call it concurrently.
+/// A runner error or panic fails only the case that caused it.
+pub fn run_cases<F>(cases: &[Case], parallel: usize, run: F) -> Vec<CaseOutcome>
+where
+    F: Fn(&str) -> Result<String> + Sync,
+{
+    let slots: Vec<Mutex<Option<CaseOutcome>>> = cases.iter().map(|_| Mutex::new(None)).collect();
+    let next = AtomicUsize::new(0);
+    let workers = parallel.clamp(1, cases.len().max(1));
+
+    std::thread::scope(|scope| {
+        for _ in 0..workers {
+            scope.spawn(|| loop {
+                let index = next.fetch_add(1, Ordering::Relaxed);
+                let Some(case) = cases.get(index) else {
+                    break;
+                };
+                let start = Instant::now();
+                let (failure, diff, error_code) = if case.skip {
+                    (None, Vec::new(), None)
+                } else {
+                    match catch_unwind(AssertUnwindSafe(|| run_case(case, &run))) {
+                        Ok((error_code, Ok(()))) => (None, Vec::new(), error_code),
+                        Ok((error_code, Err((why, diff)))) => (Some(why), diff, error_code),
+                        Err(panic) => (
+                            Some(format!("panicked: {}", panic_message(&*panic))),
+                            Vec::new(),
+                            None,
+                        ),
+                    }
+                };
+                *slots[index].lock().unwrap_or_else(|e| e.into_inner()) = Some(CaseOutcome {
+                    name: case.name.clone(),
+                    failure,
+                    diff,
+                    skipped: case.skip,
+                    elapsed: start.elapsed(),
+                    error_code,
+                });
+            });
+        }
+    });
+
+    slots
+        .into_iter()
+        .map(|slot| {
+            slot.into_inner()
+                .unwrap_or_else(|e| e.into_inner())
+                .expect("every case is run exactly once")
+        })
+        .collect()
+}
+
+/// Run one case, returning the result's `error_code`, if it has one, and the
+/// verdict. A failure comes with the diff against `expect`, if any.
+fn run_case<F>(case: &Case, run: &F) -> (Option<String>, Result<(), (String, Vec<String>)>)
+where
+    F: Fn(&str) -> Result<String>,
+{
+    let stdout = match run(&case.args.to_string()) {
+        Ok(stdout) => stdout,
+        Err(err) => return (None, Err((format!("{err:#}"), Vec::new()))),
+    };
+    let Ok(result) = serde_json::from_str::<Value>(stdout.trim()) else {
+        let why = format!("stdout is not a JSON ToolResult: {}", stdout.trim());
+        return (None, Err((why, Vec::new())));
+    };
+    let error_code = result
+        .get("error_code")
+        .and_then(Value::as_str)
+        .map(str::to_string);
+    (error_code, check_result(case, &result))
+}
+
+/// Check a case's parsed result against its `expect`, or for success.
+fn check_result(case: &Case, result: &Value) -> Result<(), (String, Vec<String>)> {
+    match &case.expect {
+        Some(expect) => {
+            let diff = subset_diff(expect, result);
+            if diff.is_empty() {
+                Ok(())
+            } else {
+                Err(("result does not match expect".to_string(), diff))
+            }
+        }
+        None => match result.get("success").and_then(Value::as_bool) {
+            Some(true) => Ok(()),
+            _ => Err((format!("tool returned failure: {result}"), Vec::new())),
+        },
+    }
+}
+
+/// Whether every key in `expect` appears in `actual` with an equal value.
+/// Objects match recursively; everything else must be equal.
+fn is_subset(expect: &Value, actual: &Value) -> bool {
+    subset_diff(expect, actual).is_empty()
+}
+
+/// List each place `actual` breaks the subset match with `expect`, by dotted
+/// path, e.g. `data.words: expected 3, got 2`.
+fn subset_diff(expect: &Value, actual: &Value) -> Vec<String> {
+    let mut diff = Vec::new();
+    collect_diff("", expect, actual, &mut diff);
+    diff
+}
+
+fn collect_diff(path: &str, expect: &Value, actual: &Value, diff: &mut Vec<String>) {
+    match (expect, actual) {
+        (Value::Object(expect), Value::Object(actual)) => {
+            for (key, value) in expect {
+                let field = if path.is_empty() {
+                    key.clone()
+                } else {
+                    format!("{path}.{key}")
+                };
+                match actual.get(key) {
+                    Some(a) => collect_diff(&field, value, a, diff),
+                    None => diff.push(format!("{field}: expected {value}, missing")),
+                }
+            }
+        }
+        _ if expect != actual => {
+            let field = if path.is_empty() { "result" } else { path };
+            diff.push(format!("{field}: expected {expect}, got {actual}"));
+        }
+        _ => {}
+    }
+}
+
+fn panic_message(panic: &(dyn std::any::Any + Send)) -> String {
+    panic
+        .downcast_ref::<&str>()
+        .map(|s| (*s).to_string())
+        .or_else(|| panic.downcast_ref::<String>().cloned())
+        .unwrap_or_else(|| "unknown panic".to_string())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    /// Fake word counter: reports the number of words in `text`.
+    fn count_words(args: &str) -> Result<String> {
+        let args: Value = serde_json::from_str(args)?;
+        let text = args["text"].as_str().unwrap_or_default();
+        let words = text.split_whitespace().count();
+        Ok(
+            json!({"success": true, "output": format!("{words} words"), "data": {"words": words}})
+                .to_string(),
+        )
+    }
+
+    fn many_cases(n: usize) -> Vec<Case> {
+        (0..n)
+            .map(|i| Case {
+                name: format!("case-{i}"),
+                args: json!({"text": "w ".repeat(i)}),
+                // Every seventh case expects the wrong count.
+                expect: Some(json!({"data": {"words": if i % 7 == 3 { i + 1 } else { i }}})),
+                skip: false,
+            })
+            .collect()
+    }
+
+    /// Outcomes without their timings, which vary from run to run.
+    fn verdicts(outcomes: &[CaseOutcome]) -> Vec<(String, Option<String>, Vec<String>)> {
+        outcomes
+            .iter()
+            .map(|o| (o.name.clone(), o.failure.clone(), o.diff.clone()))
+            .collect()
+    }
+
+    #[test]
+    fn results_are_correct_and_ordered_for_any_worker_count() {
+        let cases = many_cases(200);
+        let serial = run_cases(&cases, 1, count_words);
+
+        assert_eq!(serial.len(), 200);
+        for (i, outcome) in serial.iter().enumerate() {
+            assert_eq!(outcome.name, format!("case-{i}"));
+            assert_eq!(outcome.passed(), i % 7 != 3, "{outcome:?}");
+        }
+        for workers in [2, 8, 64] {
+            assert_eq!(
+                verdicts(&run_cases(&cases, workers, count_words)),
+                verdicts(&serial)
+            );
+        }
+    }
+
+    #[test]
+    fn panicking_case_fails_without_aborting_the_run() {
+        let cases = many_cases(20);
+        let outcomes = run_cases(&cases, 4, |args| {
+            if args.contains(&"w ".repeat(5)) && !args.contains(&"w ".repeat(6)) {
+                panic!("boom");
+            }
+            count_words(args)
+        });
+
+        assert_eq!(outcomes.len(), 20);
+        assert_eq!(outcomes[5].failure.as_deref(), Some("panicked: boom"));
+        assert!(outcomes[4].passed() && outcomes[6].passed());
+    }
+
+    #[test]
+    fn expect_matches_as_subset() {
+        let actual =
+            json!({"success": true, "output": "2 words", "data": {"words": 2, "lines": 1}});
+        assert!(is_subset(&json!({"data": {"words": 2}}), &actual));
+        assert!(is_subset(&json!({}), &actual));
+        assert!(!is_subset(&json!({"data": {"words": 3}}), &actual));
+        assert!(!is_subset(&json!({"error": "x"}), &actual));
+    }
+
+    #[test]
+    fn case_without_expect_requires_success() {
+        let case = Case {
+            name: "fails".into(),
+            args: json!({}),
+            expect: None,
+            skip: false,
+        };
+        let (_, failure) = run_case(&case, &|_: &str| {
+            Ok(r#"{"success":false,"output":"","error":"nope"}"#.to_string())
+        });
+        assert!(failure.unwrap_err().0.starts_with("tool returned failure"));
+    }
+
+    #[test]
+    fn mismatch_reports_a_focused_diff() {
+        let mut cases = many_cases(4);
+        cases[3].expect = Some(json!({"success": true, "data": {"words": 4, "lines": 1}}));
+        let outcome = &run_cases(&cases, 1, count_words)[3];
+        assert_eq!(
+            outcome.diff,
+            vec![
+                "data.lines: expected 1, missing",
+                "data.words: expected 4, got 3",
+            ]
+        );
+    }
+
+    #[test]
+    fn summary_counts_passed_failed_and_skipped() {
+        let mut cases = many_cases(10);
+        cases[0].skip = true;
+        cases[1].skip = true;
+        let outcomes = run_cases(&cases, 2, count_words);
+
+        // Case 3 expects the wrong count; cases 0 and 1 never run.
+        let summary = Summary::of(&outcomes);
+        assert_eq!(
+            summary,
+            Summary {
+                passed: 7,
+                failed: 1,
+                skipped: 2
+            }
+        );
+        assert!(render_report(&outcomes, false).ends_with("  7 passed, 1 failed, 2 skipped\n"));
+        let report = json_report(&outcomes);
+        assert_eq!(
+            (&report["passed"], &report["failed"], &report["skipped"]),
+            (&json!(7), &json!(1), &json!(2))
+        );
+        assert_eq!(report["cases"][0]["status"], "skipped");
+    }
+
+    #[test]
+    fn plain_report_has_no_color_codes() {
+        let outcomes = run_cases(&many_cases(5), 1, count_words);
+        let plain = render_report(&outcomes, false);
+        assert!(!plain.contains('\x1b'), "{plain:?}");
+        assert!(plain.contains("✗ case-3 ("));
+        assert!(render_report(&outcomes, true).contains('\x1b'));
+    }
+
+    #[test]
+    fn csv_report_has_a_header_and_a_row_per_case() {
+        let outcome =
+            |name: &str, failure: Option<&str>, diff: &[&str], code: Option<&str>| CaseOutcome {
+                name: name.to_string(),
+                failure: failure.map(str::to_string),
+                diff: diff.iter().map(|d| d.to_string()).collect(),
+                skipped: false,
+                elapsed: Duration::from_millis(12),
+                error_code: code.map(str::to_string),
+            };
+        let outcomes = [
+            outcome("two words", None, &[], None),
+            outcome(
+                "bad, \"text\"",
+                Some("result does not match expect"),
+                &[r#"data.words: expected 1, got "x""#],
+                Some("invalid_input"),
+            ),
+        ];
+        assert_eq!(
+            csv_report(&outcomes),
+            concat!(
+                "name,status,duration_ms,error_code,message\n",
+                "two words,passed,12,,\n",
+                r#""bad, ""text""",failed,12,invalid_input,"result does not match expect"#,
+                "\n",
+                r#"data.words: expected 1, got ""x""""#,
+                "\n",
+            )
+        );
+    }
+
+    #[test]
+    fn csv_fields_are_quoted_when_needed() {
+        assert_eq!(csv_field("plain"), "plain");
+        assert_eq!(csv_field("a, b"), "\"a, b\"");
+        assert_eq!(csv_field(r#"said "hi""#), r#""said ""hi""""#);
+        assert_eq!(csv_field("one\ntwo"), "\"one\ntwo\"");
+    }
+}
@@ -0,0 +1,90 @@
+//! `skill test --compress` — exchange gzip streams with a skill.
+//!
+//! A skill whose manifest sets `"compression": "gzip"` accepts its args as a
+//! gzip stream and writes its `ToolResult` as one when
+//! `ZEROCLAW_COMPRESSION=gzip` is set (the Go SDK's `skill.CompressionEnv`).
+//! Hosts that pass large texts over the wire use it to save bandwidth; the
+//! handler sees plain JSON either way.
+
+use anyhow::{bail, Context, Result};
+use std::io::{Read, Write};
+use std::path::Path;
+
+/// Environment variable that switches an SDK-built skill to gzip streams.
+pub const COMPRESSION_ENV: &str = "ZEROCLAW_COMPRESSION";
+
+/// The only `compression` a manifest may declare.
+pub const GZIP: &str = "gzip";
+
+/// Refuse `--compress` for a skill whose manifest does not declare
+/// `"compression": "gzip"`, and return the `wasmtime run` flags that turn it
+/// on.
+pub fn wasmtime_args(manifest_path: &Path) -> Result<Vec<String>> {
+    let declared = if manifest_path.exists() {
+        let raw = std::fs::read_to_string(manifest_path)
+            .with_context(|| format!("failed to read {}", manifest_path.display()))?;
+        let manifest: serde_json::Value = serde_json::from_str(&raw)
+            .with_context(|| format!("{} is not valid JSON", manifest_path.display()))?;
+        manifest
+            .get("compression")
+            .and_then(serde_json::Value::as_str)
+            .map(str::to_string)
+    } else {
+        None
+    };
+    if declared.as_deref() != Some(GZIP) {
+        bail!("--compress needs \"compression\": \"{GZIP}\" in the skill's manifest.json");
+    }
+    Ok(vec![
+        "--env".to_string(),
+        format!("{COMPRESSION_ENV}={GZIP}"),
+    ])
+}
+
+/// Gzip `data`.
+pub fn gzip(data: &[u8]) -> Vec<u8> {
+    let mut encoder = flate2::write::GzEncoder::new(Vec::new(), flate2::Compression::default());
+    // Writing to a Vec cannot fail.
+    encoder.write_all(data).expect("gzip into memory");
+    encoder.finish().expect("gzip into memory")
+}
+
+/// Inflate a gzip stream a skill wrote to stdout.
+pub fn gunzip(data: &[u8]) -> Result<Vec<u8>> {
+    let mut out = Vec::new();
+    flate2::read::GzDecoder::new(data)
+        .read_to_end(&mut out)
+        .context("tool stdout is not a gzip stream")?;
+    Ok(out)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn gzip_round_trips() {
+        let args = format!(r#"{{"text":"{}"}}"#, "the quick brown fox ".repeat(500));
+        let packed = gzip(args.as_bytes());
+        assert!(packed.len() < args.len() / 10, "{} bytes", packed.len());
+        assert_eq!(gunzip(&packed).unwrap(), args.as_bytes());
+        assert!(gunzip(args.as_bytes()).is_err());
+    }
+
+    #[test]
+    fn wasmtime_args_require_a_declared_compression() {
+        let dir = tempfile::tempdir().unwrap();
+        let manifest = dir.path().join("manifest.json");
+        assert!(wasmtime_args(&manifest).is_err());
+
+        std::fs::write(&manifest, r#"{"name":"word_count"}"#).unwrap();
+        let err = wasmtime_args(&manifest).unwrap_err();
+        assert!(err.to_string().contains("\"compression\""), "{err}");
+
+        std::fs::write(&manifest, r#"{"name":"word_count","compression":"gzip"}"#).unwrap();
+        assert_eq!(
+            wasmtime_args(&manifest).unwrap(),
+            ["--env", "ZEROCLAW_COMPRESSION=gzip"]
+        );
+    }
+}
@@ -0,0 +1,240 @@
+//! `skill test --timeout`: stop a tool that runs past its deadline.
+//!
+//! The wasmtime CLI has no deadline that works the same across its versions,
+//! so the host kills the process itself and answers in the tool's place with a
+//! `deadline_exceeded` result, as the Go runtime's `CodeDeadlineExceeded` does.
+
+use anyhow::Result;
+use std::io::{BufRead, Read};
+use std::process::{Child, ChildStdout, Output};
+use std::sync::mpsc::{self, Receiver, RecvTimeoutError};
+use std::thread::JoinHandle;
+use std::time::{Duration, Instant};
+
+/// The error code of the result written for a tool stopped at its deadline.
+pub const DEADLINE_EXCEEDED: &str = "deadline_exceeded";
+
+/// How often [`wait_with_output`] checks whether the tool has exited.
+const POLL: Duration = Duration::from_millis(10);
+
+/// Parse a duration flag such as `--timeout 10s` or `--retry-backoff 250ms`:
+/// a whole number of `ms`, `s` or `m`.
+pub fn parse_duration(spec: &str) -> std::result::Result<Duration, String> {
+    let spec = spec.trim();
+    let invalid = || format!("invalid duration '{spec}': expected e.g. '250ms', '10s' or '2m'");
+    let (digits, unit) = spec
+        .find(|c: char| !c.is_ascii_digit())
+        .map(|at| spec.split_at(at))
+        .ok_or_else(invalid)?;
+    let n: u64 = digits.parse().map_err(|_| invalid())?;
+    match unit {
+        "ms" => Ok(Duration::from_millis(n)),
+        "s" => Ok(Duration::from_secs(n)),
+        "m" => Ok(Duration::from_secs(n * 60)),
+        _ => Err(invalid()),
+    }
+}
+
+/// A tool ran past its deadline and was killed.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct DeadlineExceeded(pub Duration);
+
+impl DeadlineExceeded {
+    /// The `ToolResult` the host writes on the stopped tool's behalf.
+    pub fn result(&self) -> String {
+        serde_json::json!({
+            "success": false,
+            "output": "",
+            "error": self.to_string(),
+            "error_code": DEADLINE_EXCEEDED,
+        })
+        .to_string()
+    }
+}
+
+impl std::fmt::Display for DeadlineExceeded {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        write!(f, "tool did not finish within {:?}", self.0)
+    }
+}
+
+impl std::error::Error for DeadlineExceeded {}
+
+/// The point in time a call must finish by.
+#[derive(Debug, Clone, Copy)]
+pub struct Deadline {
+    timeout: Duration,
+    at: Instant,
+}
+
+impl Deadline {
+    /// A deadline `timeout` from now, or none for `None`.
+    pub fn start(timeout: Option<Duration>) -> Option<Self> {
+        timeout.map(|timeout| Self {
+            timeout,
+            at: Instant::now() + timeout,
+        })
+    }
+
+    /// Time left before the deadline, or `None` once it has passed.
+    fn remaining(&self) -> Option<Duration> {
+        self.at
+            .checked_duration_since(Instant::now())
+            .filter(|left| !left.is_zero())
+    }
+
+    fn exceeded(&self) -> anyhow::Error {
+        DeadlineExceeded(self.timeout).into()
+    }
+}
+
+/// Like [`Child::wait_with_output`], but kill `child` and fail with
+/// [`DeadlineExceeded`] if it is still running at `deadline`. Whatever stdout
+/// and stderr the caller has not taken are read as the tool writes them, so a
+/// full pipe never stalls it.
+pub fn wait_with_output(mut child: Child, deadline: Option<Deadline>) -> Result<Output> {
+    let Some(deadline) = deadline else {
+        return Ok(child.wait_with_output()?);
+    };
+    let stdout = child.stdout.take().map(drain);
+    let stderr = child.stderr.take().map(drain);
+    let status = loop {
+        if let Some(status) = child.try_wait()? {
+            break status;
+        }
+        if deadline.remaining().is_none() {
+            // Killing closes the pipes, which ends both drain threads.
+            let _ = child.kill();
+            let _ = child.wait();
+            return Err(deadline.exceeded());
+        }
+        std::thread::sleep(POLL);
+    };
+    let collect = |reader: Option<JoinHandle<Vec<u8>>>| {
+        reader
+            .map(|reader| reader.join().unwrap_or_default())
+            .unwrap_or_default()
+    };
+    Ok(Output {
+        status,
+        stdout: collect(stdout),
+        stderr: collect(stderr),
+    })
+}
+
+/// Read everything from `pipe` on a thread of its own.
+fn drain(mut pipe: impl Read + Send + 'static) -> JoinHandle<Vec<u8>> {
+    std::thread::spawn(move || {
+        let mut bytes = Vec::new();
+        let _ = pipe.read_to_end(&mut bytes);
+        bytes
+    })
+}
+
+/// Send each line of `stdout` over a channel as the tool writes it, for
+/// [`next_line`].
+pub fn read_lines(stdout: ChildStdout) -> Receiver<std::io::Result<String>> {
+    let (lines, rx) = mpsc::channel();
+    std::thread::spawn(move || {
+        for line in std::io::BufReader::new(stdout).lines() {
+            if lines.send(line).is_err() {
+                break;
+            }
+        }
+    });
+    rx
+}
+
+/// The next line from [`read_lines`], or `None` once the tool closes stdout;
+/// fails with [`DeadlineExceeded`] when `deadline` passes first.
+pub fn next_line(
+    lines: &Receiver<std::io::Result<String>>,
+    deadline: Option<&Deadline>,
+) -> Result<Option<String>> {
+    let received = match deadline {
+        None => lines.recv().map_err(|_| RecvTimeoutError::Disconnected),
+        Some(deadline) => match deadline.remaining() {
+            Some(left) => lines.recv_timeout(left),
+            None => Err(RecvTimeoutError::Timeout),
+        },
+    };
+    match received {
+        Ok(line) => Ok(Some(line?)),
+        Err(RecvTimeoutError::Disconnected) => Ok(None),
+        Err(RecvTimeoutError::Timeout) => Err(deadline.map_or_else(
+            || anyhow::anyhow!("tool stdout stalled"),
+            Deadline::exceeded,
+        )),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::process::{Command, Stdio};
+
+    fn shell(script: &str) -> Child {
+        Command::new("sh")
+            .args(["-c", script])
+            .stdout(Stdio::piped())
+            .stderr(Stdio::piped())
+            .spawn()
+            .unwrap()
+    }
+
+    #[test]
+    fn parse_duration_needs_a_unit() {
+        assert_eq!(parse_duration("250ms"), Ok(Duration::from_millis(250)));
+        assert_eq!(parse_duration("10s"), Ok(Duration::from_secs(10)));
+        assert_eq!(parse_duration("2m"), Ok(Duration::from_secs(120)));
+        assert_eq!(parse_duration("0s"), Ok(Duration::ZERO));
+        for bad in ["10", "s", "1.5s", "10h", "soon"] {
+            assert!(parse_duration(bad).is_err(), "{bad}");
+        }
+    }
+
+    #[test]
+    fn hung_tool_is_killed_at_the_deadline() {
+        let started = Instant::now();
+        let err = wait_with_output(
+            shell("sleep 10"),
+            Deadline::start(Some(Duration::from_millis(100))),
+        )
+        .unwrap_err();
+
+        assert!(started.elapsed() < Duration::from_secs(5));
+        let exceeded = err.downcast_ref::<DeadlineExceeded>().unwrap();
+        let result: serde_json::Value = serde_json::from_str(&exceeded.result()).unwrap();
+        assert_eq!(result["success"], false);
+        assert_eq!(result["error_code"], DEADLINE_EXCEEDED);
+        assert_eq!(result["error"], "tool did not finish within 100ms");
+    }
+
+    #[test]
+    fn tool_that_finishes_in_time_keeps_its_output() {
+        let output = wait_with_output(
+            shell("echo out; echo err >&2"),
+            Deadline::start(Some(Duration::from_secs(10))),
+        )
+        .unwrap();
+        assert!(output.status.success());
+        assert_eq!(output.stdout, b"out\n");
+        assert_eq!(output.stderr, b"err\n");
+    }
+
+    #[test]
+    fn streamed_lines_stop_at_the_deadline() {
+        let mut child = shell("echo first; sleep 10");
+        let lines = read_lines(child.stdout.take().unwrap());
+        let deadline = Deadline::start(Some(Duration::from_millis(200)));
+
+        assert_eq!(
+            next_line(&lines, deadline.as_ref()).unwrap().as_deref(),
+            Some("first")
+        );
+        let err = next_line(&lines, deadline.as_ref()).unwrap_err();
+        assert!(err.downcast_ref::<DeadlineExceeded>().is_some());
+        let _ = child.kill();
+        let _ = child.wait();
+    }
+}
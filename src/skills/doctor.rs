@@ -0,0 +1,427 @@
+//! `zeroclaw skill doctor` — check the toolchain a skill author needs.
+//!
+//! Each check prints pass or fail with a hint for fixing it. A failed
+//! critical check makes the command exit non-zero, so it can gate a setup
+//! script; other failures only limit what `skill test` and `skill build`
+//! can do.
+
+use anyhow::{bail, Result};
+use std::process::Command;
+
+/// Oldest TinyGo that ships the `wasip1` target the Go templates build for.
+pub const TINYGO_MIN_VERSION: (u32, u32, u32) = (0, 30, 0);
+
+/// Oldest Go with the `GOOS=wasip1` port, for `skill build --compiler go`.
+pub const GO_MIN_VERSION: (u32, u32, u32) = (1, 21, 0);
+
+/// A WASI command that writes `{"success":true,"output":"noop"}` and exits,
+/// run through the built-in executor to prove it works end to end:
+///
+/// ```wat
+/// (module
+///   (import "wasi_snapshot_preview1" "fd_write"
+///     (func $fd_write (param i32 i32 i32 i32) (result i32)))
+///   (memory (export "memory") 1)
+///   (data (i32.const 0) "\10\00\00\00\20\00\00\00")  ;; iovec: 32 bytes at 16
+///   (data (i32.const 16) "{\"success\":true,\"output\":\"noop\"}")
+///   (func (export "_start")
+///     (drop (call $fd_write (i32.const 1) (i32.const 0) (i32.const 1) (i32.const 8)))))
+/// ```
+const NOOP_WASM: &[u8] = &[
+    0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, 0x01, 0x0c, 0x02, 0x60, 0x04, 0x7f, 0x7f, 0x7f,
+    0x7f, 0x01, 0x7f, 0x60, 0x00, 0x00, 0x02, 0x23, 0x01, 0x16, 0x77, 0x61, 0x73, 0x69, 0x5f, 0x73,
+    0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x5f, 0x70, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x31,
+    0x08, 0x66, 0x64, 0x5f, 0x77, 0x72, 0x69, 0x74, 0x65, 0x00, 0x00, 0x03, 0x02, 0x01, 0x01, 0x05,
+    0x03, 0x01, 0x00, 0x01, 0x07, 0x13, 0x02, 0x06, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x02, 0x00,
+    0x06, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x00, 0x01, 0x0a, 0x0f, 0x01, 0x0d, 0x00, 0x41, 0x01,
+    0x41, 0x00, 0x41, 0x01, 0x41, 0x08, 0x10, 0x00, 0x1a, 0x0b, 0x0b, 0x33, 0x02, 0x00, 0x41, 0x00,
+    0x0b, 0x08, 0x10, 0x00, 0x00, 0x00, 0x20, 0x00, 0x00, 0x00, 0x00, 0x41, 0x10, 0x0b, 0x20, 0x7b,
+    0x22, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x22, 0x3a, 0x74, 0x72, 0x75, 0x65, 0x2c, 0x22,
+    0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x22, 0x3a, 0x22, 0x6e, 0x6f, 0x6f, 0x70, 0x22, 0x7d,
+];
+
+const TINYGO_INSTALL_HINT: &str =
+    "install TinyGo: https://tinygo.org/getting-started/install/ (macOS: brew install tinygo)";
+
+const GO_INSTALL_HINT: &str = "install Go 1.21 or later: https://go.dev/dl/";
+
+const CARGO_INSTALL_HINT: &str =
+    "install Rust: https://rustup.rs, then `rustup target add wasm32-wasip1`";
+
+const JAVY_INSTALL_HINT: &str =
+    "install Javy 3 or later: https://github.com/bytecodealliance/javy/releases";
+
+/// The outcome of one check.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Check {
+    pub name: &'static str,
+    pub passed: bool,
+    /// Whether a failure makes `skill doctor` exit non-zero.
+    pub critical: bool,
+    /// What was found, e.g. a version.
+    pub detail: String,
+    /// How to fix a failure.
+    pub hint: Option<String>,
+}
+
+impl Check {
+    fn pass(name: &'static str, detail: impl Into<String>) -> Self {
+        Self {
+            name,
+            passed: true,
+            critical: true,
+            detail: detail.into(),
+            hint: None,
+        }
+    }
+
+    fn fail(name: &'static str, detail: impl Into<String>, hint: impl Into<String>) -> Self {
+        Self {
+            name,
+            passed: false,
+            critical: true,
+            detail: detail.into(),
+            hint: Some(hint.into()),
+        }
+    }
+
+    fn optional(mut self) -> Self {
+        self.critical = false;
+        self
+    }
+}
+
+/// Run every check and print a report. Fails if a critical check failed.
+pub fn run() -> Result<()> {
+    let (tinygo_version, tinygo) = check_tinygo(command_stdout("tinygo", &["version"]).as_deref());
+    let wasip1 = match tinygo_version {
+        Some(_) => check_wasip1_target(command_stdout("tinygo", &["targets"]).as_deref()),
+        None => Check::fail(
+            "wasip1 target",
+            "not checked: tinygo is missing",
+            TINYGO_INSTALL_HINT,
+        ),
+    };
+    let checks = [
+        tinygo,
+        wasip1,
+        check_go(command_stdout("go", &["version"]).as_deref()).optional(),
+        check_wasmtime_cli(command_stdout("wasmtime", &["--version"]).as_deref()),
+        check_executor(crate::tools::wasm_tool::WasmTool::run_bytes(
+            NOOP_WASM,
+            &serde_json::json!({}),
+        )),
+    ];
+
+    println!("  Checking the skill toolchain:\n");
+    for check in &checks {
+        println!("{}", render(check));
+    }
+    println!();
+    summarize(&checks)
+}
+
+/// Render one check as a report line, with its hint below when it failed.
+fn render(check: &Check) -> String {
+    let mark = match (check.passed, check.critical) {
+        (true, _) => console::style("✓").green().bold(),
+        (false, true) => console::style("✗").red().bold(),
+        (false, false) => console::style("!").yellow().bold(),
+    };
+    let mut line = format!("  {mark} {:<16} {}", check.name, check.detail);
+    if let Some(hint) = check.hint.as_deref().filter(|_| !check.passed) {
+        line.push_str(&format!("\n    {} {hint}", console::style("→").dim()));
+    }
+    line
+}
+
+/// Fail when any critical check failed; print the outcome otherwise.
+fn summarize(checks: &[Check]) -> Result<()> {
+    let critical: Vec<&str> = checks
+        .iter()
+        .filter(|c| c.critical && !c.passed)
+        .map(|c| c.name)
+        .collect();
+    if !critical.is_empty() {
+        bail!(
+            "skill doctor: {} critical check(s) failed: {}",
+            critical.len(),
+            critical.join(", ")
+        );
+    }
+    if checks.iter().all(|c| c.passed) {
+        println!(
+            "  {} Ready to build skills",
+            console::style("✓").green().bold()
+        );
+    } else {
+        println!(
+            "  {} Ready to build skills; see the warnings above",
+            console::style("✓").green().bold()
+        );
+    }
+    Ok(())
+}
+
+/// Run `program args` and return its stdout, or `None` if it could not be
+/// started or exited non-zero.
+pub(super) fn command_stdout(program: &str, args: &[&str]) -> Option<String> {
+    let output = Command::new(program).args(args).output().ok()?;
+    output
+        .status
+        .success()
+        .then(|| String::from_utf8_lossy(&output.stdout).into_owned())
+}
+
+/// Parse `tinygo version` output such as
+/// `tinygo version 0.33.0 linux/amd64 (using go version go1.22.5 ...)`.
+fn parse_tinygo_version(stdout: &str) -> Option<(u32, u32, u32)> {
+    let version = stdout.split_whitespace().nth(2)?;
+    let mut parts = version.trim_start_matches('v').splitn(3, '.').map(|p| {
+        // Drop suffixes such as "-dev" or "-rc1".
+        p.split(|c: char| !c.is_ascii_digit()).next()?.parse().ok()
+    });
+    Some((
+        parts.next()??,
+        parts.next()??,
+        parts.next().flatten().unwrap_or(0),
+    ))
+}
+
+fn format_version((major, minor, patch): (u32, u32, u32)) -> String {
+    format!("{major}.{minor}.{patch}")
+}
+
+/// Check `tinygo version` output against [`TINYGO_MIN_VERSION`]. Also returns
+/// the version found, so later checks can skip a missing toolchain.
+pub(super) fn check_tinygo(stdout: Option<&str>) -> (Option<(u32, u32, u32)>, Check) {
+    let Some(stdout) = stdout else {
+        return (
+            None,
+            Check::fail("tinygo", "not found on PATH", TINYGO_INSTALL_HINT),
+        );
+    };
+    let Some(version) = parse_tinygo_version(stdout) else {
+        return (
+            None,
+            Check::fail(
+                "tinygo",
+                format!("unrecognized version output: {}", stdout.trim()),
+                TINYGO_INSTALL_HINT,
+            ),
+        );
+    };
+    let min = format_version(TINYGO_MIN_VERSION);
+    let check = if version >= TINYGO_MIN_VERSION {
+        Check::pass("tinygo", format!("{} (>= {min})", format_version(version)))
+    } else {
+        Check::fail(
+            "tinygo",
+            format!("{} is older than {min}", format_version(version)),
+            format!("upgrade TinyGo to {min} or later for -target=wasip1: https://tinygo.org/getting-started/install/"),
+        )
+    };
+    (Some(version), check)
+}
+
+/// Parse `go version` output such as `go version go1.22.5 linux/amd64`.
+fn parse_go_version(stdout: &str) -> Option<(u32, u32, u32)> {
+    let version = stdout.split_whitespace().nth(2)?.strip_prefix("go")?;
+    let mut parts = version.splitn(3, '.').map(|p| {
+        // Drop suffixes such as "rc1" in "go1.23rc1".
+        p.split(|c: char| !c.is_ascii_digit()).next()?.parse().ok()
+    });
+    Some((
+        parts.next()??,
+        parts.next()??,
+        parts.next().flatten().unwrap_or(0),
+    ))
+}
+
+/// Check `go version` output against [`GO_MIN_VERSION`]. Only
+/// `skill build --compiler go` needs Go, so `skill doctor` reports a failure
+/// as a warning.
+pub(super) fn check_go(stdout: Option<&str>) -> Check {
+    let Some(stdout) = stdout else {
+        return Check::fail("go", "not found on PATH", GO_INSTALL_HINT);
+    };
+    let Some(version) = parse_go_version(stdout) else {
+        return Check::fail(
+            "go",
+            format!("unrecognized version output: {}", stdout.trim()),
+            GO_INSTALL_HINT,
+        );
+    };
+    let min = format_version(GO_MIN_VERSION);
+    if version >= GO_MIN_VERSION {
+        Check::pass("go", format!("{} (>= {min})", format_version(version)))
+    } else {
+        Check::fail(
+            "go",
+            format!("{} is older than {min}", format_version(version)),
+            GO_INSTALL_HINT,
+        )
+    }
+}
+
+/// Check that `cargo --version` ran, for `skill build` of a Rust skill.
+pub(super) fn check_cargo(stdout: Option<&str>) -> Check {
+    check_present("cargo", stdout, CARGO_INSTALL_HINT)
+}
+
+/// Check that `javy --version` ran, for `skill build` of a JavaScript skill.
+pub(super) fn check_javy(stdout: Option<&str>) -> Check {
+    check_present("javy", stdout, JAVY_INSTALL_HINT)
+}
+
+/// Pass with the first line of a version query's `stdout`, or fail when the
+/// program could not be run.
+fn check_present(name: &'static str, stdout: Option<&str>, hint: &str) -> Check {
+    match stdout {
+        Some(stdout) => Check::pass(name, stdout.lines().next().unwrap_or_default().trim()),
+        None => Check::fail(name, "not found on PATH", hint),
+    }
+}
+
+/// Check that `tinygo targets` lists `wasip1`.
+fn check_wasip1_target(stdout: Option<&str>) -> Check {
+    match stdout {
+        Some(targets) if targets.lines().any(|t| t.trim() == "wasip1") => {
+            Check::pass("wasip1 target", "available")
+        }
+        Some(_) => Check::fail(
+            "wasip1 target",
+            "not listed by `tinygo targets`",
+            "reinstall TinyGo from an official release; distro packages may omit WASI targets",
+        ),
+        None => Check::fail(
+            "wasip1 target",
+            "`tinygo targets` failed",
+            "run `tinygo targets` to see why",
+        ),
+    }
+}
+
+/// Check for the `wasmtime` CLI, which `skill test` runs skills with. Skills
+/// still run in the agent without it, so a failure is not critical.
+fn check_wasmtime_cli(stdout: Option<&str>) -> Check {
+    match stdout {
+        Some(version) => Check::pass("wasmtime CLI", version.trim()),
+        None => Check::fail(
+            "wasmtime CLI",
+            "not found; `zeroclaw skill test` needs it",
+            "install wasmtime: curl https://wasmtime.dev/install.sh -sSf | bash",
+        )
+        .optional(),
+    }
+}
+
+/// Check the result of running [`NOOP_WASM`] through the built-in executor.
+fn check_executor(result: Result<crate::tools::ToolResult>) -> Check {
+    match result {
+        Ok(res) if res.success && res.output == "noop" => {
+            Check::pass("executor", "ran a built-in no-op skill")
+        }
+        Ok(res) => Check::fail(
+            "executor",
+            format!("no-op skill returned an unexpected result: {res:?}"),
+            "report this: the built-in executor mis-handled a trivial module",
+        ),
+        Err(e) => Check::fail(
+            "executor",
+            format!("{e:#}"),
+            "build zeroclaw with the wasm-tools feature (on by default)",
+        ),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn tinygo_version_is_parsed_and_compared() {
+        let out =
+            "tinygo version 0.33.0 linux/amd64 (using go version go1.22.5 and LLVM version 18.1.2)";
+        assert_eq!(parse_tinygo_version(out), Some((0, 33, 0)));
+        assert_eq!(
+            parse_tinygo_version("tinygo version 0.34.0-dev-1a2b3c darwin/arm64"),
+            Some((0, 34, 0))
+        );
+        assert_eq!(parse_tinygo_version("command not found"), None);
+
+        let (version, check) = check_tinygo(Some(out));
+        assert_eq!(version, Some((0, 33, 0)));
+        assert!(check.passed, "{check:?}");
+
+        let (_, old) = check_tinygo(Some("tinygo version 0.28.1 linux/amd64"));
+        assert!(!old.passed && old.critical);
+        assert!(old.detail.contains("older than 0.30.0"), "{old:?}");
+
+        let (version, missing) = check_tinygo(None);
+        assert!(version.is_none() && !missing.passed && missing.hint.is_some());
+    }
+
+    #[test]
+    fn go_version_is_parsed_and_compared() {
+        assert_eq!(
+            parse_go_version("go version go1.22.5 linux/amd64"),
+            Some((1, 22, 5))
+        );
+        assert_eq!(
+            parse_go_version("go version go1.23rc1 darwin/arm64"),
+            Some((1, 23, 0))
+        );
+        assert!(check_go(Some("go version go1.21.0 linux/amd64")).passed);
+
+        let old = check_go(Some("go version go1.20.14 linux/amd64"));
+        assert!(
+            !old.passed && old.detail.contains("older than 1.21.0"),
+            "{old:?}"
+        );
+        assert!(!check_go(None).passed);
+    }
+
+    #[test]
+    fn wasip1_target_must_be_listed() {
+        assert!(check_wasip1_target(Some("wasi\nwasip1\nwasip2\n")).passed);
+        assert!(!check_wasip1_target(Some("wasi\nwasip2\n")).passed);
+        assert!(!check_wasip1_target(None).passed);
+    }
+
+    #[test]
+    fn missing_wasmtime_cli_is_not_critical() {
+        let check = check_wasmtime_cli(None);
+        assert!(!check.passed && !check.critical);
+        assert!(summarize(&[check]).is_ok());
+    }
+
+    #[test]
+    fn failed_critical_check_fails_the_command() {
+        let checks = [
+            Check::pass("tinygo", "0.33.0"),
+            check_wasip1_target(Some("wasi\n")),
+        ];
+        let err = summarize(&checks).unwrap_err().to_string();
+        assert!(
+            err.contains("1 critical check(s) failed: wasip1 target"),
+            "{err}"
+        );
+        assert!(render(&checks[1]).contains("reinstall TinyGo"));
+    }
+
+    #[test]
+    fn executor_check_reports_the_error() {
+        let check = check_executor(Err(anyhow::anyhow!("WASM tools are not enabled")));
+        assert!(!check.passed && check.detail.contains("not enabled"));
+    }
+
+    #[cfg(feature = "wasm-tools")]
+    #[test]
+    #[ignore = "slow: initializes wasmtime Cranelift compiler; run with --include-ignored"]
+    fn noop_skill_runs_through_executor() {
+        let result =
+            crate::tools::wasm_tool::WasmTool::run_bytes(NOOP_WASM, &serde_json::json!({}));
+        assert!(check_executor(result).passed);
+    }
+}
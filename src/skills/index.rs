@@ -0,0 +1,250 @@
+//! `zeroclaw skill index <dir>/...` — a catalog of every skill under a
+//! directory.
+//!
+//! Skills are found as `skill test <dir>/...` finds them (see
+//! [`suite::discover`]). Each entry takes its name, version, description, and
+//! capabilities from the skill's `manifest.json`, and its checksum from the
+//! SHA-256 of `tool.wasm`, null until the skill is built. Entries are sorted
+//! by name. Two skills with one name fail the index: a registry could not
+//! tell them apart, least of all when they also share a version.
+
+use super::suite;
+use anyhow::{bail, Context, Result};
+use serde::Serialize;
+use serde_json::Value;
+use sha2::{Digest, Sha256};
+use std::path::Path;
+
+/// Current index document format.
+const INDEX_FORMAT: u32 = 1;
+
+/// The registry document `skill index` writes.
+#[derive(Debug, Clone, PartialEq, Serialize)]
+pub struct Index {
+    pub format: u32,
+    pub skills: Vec<IndexEntry>,
+}
+
+/// One skill in an [`Index`].
+#[derive(Debug, Clone, PartialEq, Serialize)]
+pub struct IndexEntry {
+    pub name: String,
+    pub version: String,
+    pub description: String,
+    /// The manifest's `capabilities`, as written; `{}` when it has none.
+    pub capabilities: Value,
+    /// SHA-256 of `tool.wasm`, hex-encoded.
+    pub sha256: Option<String>,
+    /// The skill's directory, relative to the indexed root.
+    pub path: String,
+}
+
+/// Index every skill under `root`.
+pub fn build_index(root: &Path) -> Result<Index> {
+    let mut skills = Vec::new();
+    for skill in suite::discover(root, None)? {
+        let manifest_path = skill.dir.join("manifest.json");
+        let raw = std::fs::read_to_string(&manifest_path)
+            .with_context(|| format!("failed to read {}", manifest_path.display()))?;
+        let manifest: Value = serde_json::from_str(&raw)
+            .with_context(|| format!("{} is not valid JSON", manifest_path.display()))?;
+        let field = |key: &str| {
+            manifest
+                .get(key)
+                .and_then(Value::as_str)
+                .unwrap_or_default()
+                .to_string()
+        };
+        let wasm = skill.dir.join("tool.wasm");
+        let sha256 = match std::fs::read(&wasm) {
+            Ok(bytes) => Some(hex::encode(Sha256::digest(bytes))),
+            Err(e) if e.kind() == std::io::ErrorKind::NotFound => None,
+            Err(e) => return Err(e).with_context(|| format!("failed to read {}", wasm.display())),
+        };
+        skills.push(IndexEntry {
+            name: skill.name,
+            version: field("version"),
+            description: field("description"),
+            capabilities: manifest
+                .get("capabilities")
+                .cloned()
+                .unwrap_or_else(|| Value::Object(serde_json::Map::new())),
+            sha256,
+            path: skill
+                .dir
+                .strip_prefix(root)
+                .unwrap_or(&skill.dir)
+                .to_string_lossy()
+                .replace('\\', "/"),
+        });
+    }
+    // Stable, so a duplicate is reported against the first of its paths.
+    skills.sort_by(|a, b| a.name.cmp(&b.name));
+    for pair in skills.windows(2) {
+        let [a, b] = pair else { continue };
+        if a.name != b.name {
+            continue;
+        }
+        if a.version == b.version {
+            bail!(
+                "skill {} v{} is defined twice, in {} and {}",
+                a.name,
+                a.version,
+                a.path,
+                b.path
+            );
+        }
+        bail!(
+            "skill name {} is used twice: v{} in {} and v{} in {}",
+            a.name,
+            a.version,
+            a.path,
+            b.version,
+            b.path
+        );
+    }
+    Ok(Index {
+        format: INDEX_FORMAT,
+        skills,
+    })
+}
+
+/// `--format markdown`: a table with a row per skill.
+pub fn render_markdown(index: &Index) -> String {
+    let mut out = String::from("| Skill | Version | Description | Capabilities | SHA-256 |\n");
+    out.push_str("| --- | --- | --- | --- | --- |\n");
+    for skill in &index.skills {
+        let row = [
+            format!("`{}`", skill.name),
+            skill.version.clone(),
+            skill.description.clone(),
+            capabilities(&skill.capabilities),
+            skill
+                .sha256
+                .as_ref()
+                .map_or_else(|| "not built".to_string(), |sha| format!("`{sha}`")),
+        ];
+        let row: Vec<String> = row.iter().map(|cell| cell.replace('|', "\\|")).collect();
+        out.push_str(&format!("| {} |\n", row.join(" | ")));
+    }
+    out
+}
+
+/// Capabilities as one table cell, e.g. `fs: /data; net`.
+fn capabilities(caps: &Value) -> String {
+    let Some(caps) = caps.as_object() else {
+        return String::new();
+    };
+    caps.iter()
+        .filter(|(_, value)| !matches!(value, Value::Bool(false) | Value::Null))
+        .map(|(key, value)| match value {
+            Value::Bool(true) => key.clone(),
+            Value::Array(items) => {
+                let items: Vec<String> = items
+                    .iter()
+                    .map(|item| {
+                        item.as_str()
+                            .map_or_else(|| item.to_string(), str::to_string)
+                    })
+                    .collect();
+                format!("{key}: {}", items.join(", "))
+            }
+            Value::String(s) => format!("{key}: {s}"),
+            other => format!("{key}: {other}"),
+        })
+        .collect::<Vec<_>>()
+        .join("; ")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    /// Write a skill directory under `root`.
+    fn skill(root: &Path, dir: &str, manifest: &str, wasm: Option<&[u8]>) {
+        let dir = root.join(dir);
+        std::fs::create_dir_all(&dir).unwrap();
+        std::fs::write(dir.join("manifest.json"), manifest).unwrap();
+        if let Some(wasm) = wasm {
+            std::fs::write(dir.join("tool.wasm"), wasm).unwrap();
+        }
+    }
+
+    #[test]
+    fn index_lists_every_skill_by_name() {
+        let root = tempfile::tempdir().unwrap();
+        skill(
+            root.path(),
+            "text/word_count",
+            r#"{"name":"word_count","version":"1.2.0","description":"Count words","capabilities":{"fs":["/data"]}}"#,
+            Some(b"\0asm"),
+        );
+        skill(
+            root.path(),
+            "net/http_fetch",
+            r#"{"name":"http_fetch","version":"0.3.0","description":"Fetch a URL | status","capabilities":{"net":true}}"#,
+            None,
+        );
+
+        let index = build_index(root.path()).unwrap();
+        assert_eq!(
+            serde_json::to_value(&index).unwrap(),
+            json!({
+                "format": 1,
+                "skills": [
+                    {
+                        "name": "http_fetch",
+                        "version": "0.3.0",
+                        "description": "Fetch a URL | status",
+                        "capabilities": {"net": true},
+                        "sha256": null,
+                        "path": "net/http_fetch",
+                    },
+                    {
+                        "name": "word_count",
+                        "version": "1.2.0",
+                        "description": "Count words",
+                        "capabilities": {"fs": ["/data"]},
+                        "sha256": hex::encode(Sha256::digest(b"\0asm")),
+                        "path": "text/word_count",
+                    },
+                ],
+            })
+        );
+
+        let markdown = render_markdown(&index);
+        let rows: Vec<&str> = markdown.lines().collect();
+        assert_eq!(rows.len(), 4);
+        assert_eq!(
+            rows[2],
+            "| `http_fetch` | 0.3.0 | Fetch a URL \\| status | net | not built |"
+        );
+        assert!(rows[3].starts_with("| `word_count` | 1.2.0 | Count words | fs: /data | `"));
+    }
+
+    #[test]
+    fn duplicate_names_fail_the_index() {
+        let root = tempfile::tempdir().unwrap();
+        let manifest = r#"{"name":"word_count","version":"1.0.0"}"#;
+        skill(root.path(), "a", manifest, None);
+        skill(root.path(), "b", manifest, None);
+        let err = build_index(root.path()).unwrap_err();
+        assert_eq!(
+            err.to_string(),
+            "skill word_count v1.0.0 is defined twice, in a and b"
+        );
+
+        skill(
+            root.path(),
+            "b",
+            r#"{"name":"word_count","version":"2.0.0"}"#,
+            None,
+        );
+        let err = build_index(root.path()).unwrap_err();
+        assert_eq!(
+            err.to_string(),
+            "skill name word_count is used twice: v1.0.0 in a and v2.0.0 in b"
+        );
+    }
+}
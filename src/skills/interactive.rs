@@ -0,0 +1,140 @@
+//! `skill test --interactive` — try many inputs against one warm module.
+//!
+//! The module is compiled once; each line read from the terminal is a JSON
+//! args object run in a fresh instance, so no guest state leaks from one call
+//! to the next. Each `ToolResult` is printed on its own stdout line and its
+//! timing on stderr. An empty line or end of input ends the session.
+
+use anyhow::Result;
+use std::io::{BufRead, Write};
+use std::time::{Duration, Instant};
+
+/// Prompt shown before each line when reading from a terminal.
+pub const PROMPT: &str = "> ";
+
+/// Read args lines from `input` until an empty line or EOF, run each through
+/// `call`, and write its stdout to `out` and a status line to `log`. Lines
+/// that are not JSON and calls that fail are reported on `log` and the
+/// session goes on. Returns the number of calls made.
+pub fn run_session(
+    input: impl BufRead,
+    out: &mut impl Write,
+    log: &mut impl Write,
+    prompt: bool,
+    mut call: impl FnMut(&serde_json::Value) -> Result<String>,
+) -> Result<usize> {
+    let mut calls = 0;
+    let mut lines = input.lines();
+    loop {
+        if prompt {
+            write!(log, "{PROMPT}")?;
+            log.flush()?;
+        }
+        let Some(line) = lines.next().transpose()? else {
+            break;
+        };
+        let line = line.trim();
+        if line.is_empty() {
+            break;
+        }
+        let args: serde_json::Value = match serde_json::from_str(line) {
+            Ok(args) => args,
+            Err(e) => {
+                writeln!(log, "  ✗ not valid JSON: {e}")?;
+                continue;
+            }
+        };
+        calls += 1;
+        let start = Instant::now();
+        match call(&args) {
+            Ok(stdout) => {
+                let elapsed = start.elapsed();
+                writeln!(out, "{}", stdout.trim_end())?;
+                out.flush()?;
+                writeln!(
+                    log,
+                    "  {} {}",
+                    status_mark(&stdout),
+                    format_elapsed(elapsed)
+                )?;
+            }
+            Err(e) => writeln!(log, "  ✗ {} — {e:#}", format_elapsed(start.elapsed()))?,
+        }
+    }
+    Ok(calls)
+}
+
+/// `✓` for a `ToolResult` with `success: true`, `✗` otherwise.
+fn status_mark(stdout: &str) -> &'static str {
+    let success = serde_json::from_str::<serde_json::Value>(stdout.trim())
+        .ok()
+        .and_then(|result| result.get("success")?.as_bool())
+        .unwrap_or(false);
+    if success {
+        "✓"
+    } else {
+        "✗"
+    }
+}
+
+/// Render a call's duration in milliseconds, e.g. `12.3 ms`.
+fn format_elapsed(elapsed: Duration) -> String {
+    format!("{:.1} ms", elapsed.as_secs_f64() * 1000.0)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn echo(args: &serde_json::Value) -> Result<String> {
+        let success = args.get("fail").is_none();
+        Ok(serde_json::json!({"success": success, "output": args.to_string()}).to_string())
+    }
+
+    #[test]
+    fn each_line_gets_one_result_in_order() {
+        let input = "{\"n\":1}\n{\"n\":2}\n{\"n\":3,\"fail\":true}\n";
+        let (mut out, mut log) = (Vec::new(), Vec::new());
+        let calls = run_session(input.as_bytes(), &mut out, &mut log, false, echo).unwrap();
+        assert_eq!(calls, 3);
+
+        let out = String::from_utf8(out).unwrap();
+        let outputs: Vec<String> = out
+            .lines()
+            .map(|line| {
+                let result: serde_json::Value = serde_json::from_str(line).unwrap();
+                result["output"].as_str().unwrap().to_string()
+            })
+            .collect();
+        assert_eq!(
+            outputs,
+            [r#"{"n":1}"#, r#"{"n":2}"#, r#"{"fail":true,"n":3}"#]
+        );
+
+        let log = String::from_utf8(log).unwrap();
+        let marks: Vec<&str> = log.lines().map(|l| &l.trim_start()[..3]).collect();
+        assert_eq!(marks, ["✓", "✓", "✗"]);
+        assert!(log.lines().all(|l| l.ends_with(" ms")), "{log}");
+    }
+
+    #[test]
+    fn empty_line_ends_and_bad_lines_do_not() {
+        let input = "not json\n{\"n\":1}\n\n{\"n\":2}\n";
+        let (mut out, mut log) = (Vec::new(), Vec::new());
+        let mut failing = true;
+        let calls = run_session(input.as_bytes(), &mut out, &mut log, true, |args| {
+            if std::mem::take(&mut failing) {
+                anyhow::bail!("trapped");
+            }
+            echo(args)
+        })
+        .unwrap();
+        assert_eq!(calls, 1, "the empty line should end the session");
+        assert!(out.is_empty(), "a failed call prints no result");
+
+        let log = String::from_utf8(log).unwrap();
+        assert!(log.contains("not valid JSON"), "{log}");
+        assert!(log.contains("trapped"), "{log}");
+        assert_eq!(log.matches(PROMPT).count(), 3);
+    }
+}
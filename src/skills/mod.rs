@@ -6,8 +6,32 @@ use std::path::{Path, PathBuf};
 use std::process::Command;
 use std::time::{Duration, SystemTime};
 
+mod artifacts;
 mod audit;
+mod build;
+mod canonical;
+mod capabilities;
+mod cases;
+mod compress;
+pub(crate) mod deadline;
+mod doctor;
+mod index;
+mod interactive;
+mod native;
+mod output_check;
+mod output_diff;
+mod package;
+pub(crate) mod pipe;
+mod preopen;
+mod replay;
+mod schema_diff;
+mod secrets;
+mod serve;
+mod suite;
 mod templates;
+mod timing;
+mod trap;
+mod validate;
 
 const OPEN_SKILLS_REPO_URL: &str = "https://github.com/besoeasy/open-skills";
 const OPEN_SKILLS_SYNC_MARKER: &str = ".zeroclaw-open-skills-sync";
@@ -991,8 +1015,12 @@ fn write_readme(dir: &std::path::Path, name: &str, language: &str, test_args: &s
             "cargo build --target wasm32-wasip1 --release\ncp target/wasm32-wasip1/release/*.wasm tool.wasm",
             "Requires: rustup target add wasm32-wasip1  # one-time setup",
         ),
+        "javascript" => (
+            "npm run build",
+            "Requires: javy (https://github.com/bytecodealliance/javy)",
+        ),
         "go" => (
-            "tinygo build -o tool.wasm -target wasi .",
+            "zeroclaw skill build",
             "Requires: tinygo (https://tinygo.org)",
         ),
         "python" => (
@@ -1033,91 +1061,1687 @@ fn write_readme(dir: &std::path::Path, name: &str, language: &str, test_args: &s
     Ok(())
 }
 
-// ─── Local test (zeroclaw skill test) ────────────────────────────────────────
+// ─── Local test (zeroclaw skill test) ────────────────────────────────────────
+
+/// What `skill test` grants or asks of the guest beyond stdin and stdout.
+#[derive(Debug, Clone, Default)]
+pub struct GuestOptions {
+    /// Host directories mounted with `--preopen`.
+    pub preopens: Vec<preopen::Preopen>,
+    /// Reject invalid UTF-8 input instead of repairing it (`--strict-utf8`).
+    pub strict_utf8: bool,
+    /// Secrets given with `--secret` or `--secret-file`.
+    pub secrets: Vec<secrets::Secret>,
+    /// Exchange gzip streams with the tool (`--compress`).
+    pub compress: bool,
+    /// How long one call may run before it is killed (`--timeout`); `None`
+    /// waits for ever.
+    pub timeout: Option<Duration>,
+    /// Ask SDK-built skills to indent their result, as `skill test` does
+    /// for a single call when stdout is a terminal.
+    pub pretty: bool,
+}
+
+/// How much the `skill` commands print besides their result, set by the
+/// global `--quiet` and `--verbose` flags.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub enum Verbosity {
+    /// The result alone; a failure still exits non-zero with its error.
+    Quiet,
+    #[default]
+    Normal,
+    /// Also phase timings, capability grants, usage, and the tool's stderr.
+    Verbose,
+}
+
+impl Verbosity {
+    /// The level the flags ask for; clap keeps them from both being set.
+    pub fn from_flags(quiet: bool, verbose: bool) -> Self {
+        match (quiet, verbose) {
+            (true, _) => Self::Quiet,
+            (_, true) => Self::Verbose,
+            _ => Self::Normal,
+        }
+    }
+}
+
+/// What `skill test` shows of the tool's stderr, which is otherwise only
+/// printed when the tool fails.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub enum GuestLog {
+    /// Nothing.
+    #[default]
+    Hidden,
+    /// All of it once the tool exits (`--verbose`).
+    After,
+    /// Each line as the tool writes it (`--verbose --follow`).
+    Follow,
+}
+
+/// How `skill test --explain-timing` reports the phases of the call.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum TimingReport {
+    /// A table after the result.
+    Table,
+    /// Under the result's `meta.timing` (`--json`), printed alone.
+    Meta,
+}
+
+/// How `skill test` prints the tool's `ToolResult`.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum TestOutput {
+    /// The tool's stdout, unchanged.
+    Raw,
+    /// The `ToolResult` re-indented for reading.
+    Pretty,
+    /// A single value at a dotted path (e.g. `data.words`), for scripting.
+    Field(String),
+    /// The `ToolResult` laid out for golden files, without the listed dotted
+    /// paths (`--canonical --ignore-fields`).
+    Canonical(Vec<String>),
+}
+
+/// Run a WASM tool locally using the system `wasmtime` CLI binary.
+///
+/// Looks for `tool.wasm` inside `skill_path/tools/<tool_name>/` (installed layout)
+/// OR directly as `skill_path/tool.wasm` (dev layout — right after build).
+///
+/// A result with `success: false` is returned as an error so the command exits non-zero.
+/// With `stable_output`, the printed result's `output` is replaced as by
+/// [`canonical::stable_output`].
+#[allow(clippy::too_many_arguments)]
+pub fn test_skill_locally(
+    skill_path: &std::path::Path,
+    tool_name: Option<&str>,
+    args_json: &str,
+    output: &TestOutput,
+    guest: &GuestOptions,
+    check_output: bool,
+    stable_output: bool,
+    verbosity: Verbosity,
+    follow: bool,
+    explain: Option<TimingReport>,
+    extract: Option<&artifacts::Extract>,
+) -> Result<()> {
+    // --verbose times the phases too, and shows them as --explain-timing does.
+    let verbose = verbosity == Verbosity::Verbose;
+    let mut timer = (explain.is_some() || verbose).then(timing::PhaseTimer::start);
+    let log = match (verbose, follow) {
+        (true, true) => GuestLog::Follow,
+        (true, false) => GuestLog::After,
+        _ => GuestLog::Hidden,
+    };
+    // Resolve .wasm path
+    let wasm_path = resolve_wasm_path(skill_path, tool_name)?;
+    let wasmtime_args = guest_wasmtime_args(&wasm_path, guest)?;
+    let argv = tool_argv(skill_path, &wasm_path, tool_name);
+
+    // Validate JSON args
+    let _: serde_json::Value = serde_json::from_str(args_json)
+        .with_context(|| format!("--args is not valid JSON: {args_json}"))?;
+
+    // --field and --canonical output is meant to be captured, so it prints
+    // the result alone, as do --quiet and --explain-timing --json.
+    let quiet = verbosity == Verbosity::Quiet
+        || matches!(output, TestOutput::Field(_) | TestOutput::Canonical(_))
+        || explain == Some(TimingReport::Meta);
+    let shown = if quiet { Verbosity::Quiet } else { verbosity };
+    let command = format!(
+        "{} {}",
+        console::style("wasmtime").cyan(),
+        wasm_path.display()
+    );
+    let header = run_header(shown, &command, &shown_args(&wasm_path, args_json), guest);
+    if !header.is_empty() {
+        println!("{}", header.join("\n"));
+        println!();
+    }
+
+    let streaming = manifest_flag(&wasm_path, "streaming");
+    if streaming && explain.is_none() {
+        timer = None;
+    }
+    if let Some(timer) = timer.as_mut() {
+        timer.mark("load");
+    }
+    let started = std::time::Instant::now();
+    // Bytes on the wire, which --compress makes differ from the text.
+    let mut wire = None;
+    // A streaming tool writes progress lines before its result; draw them as
+    // a bar on stderr and keep only the result for the checks below.
+    let mut run = || -> Result<(String, String)> {
+        if streaming {
+            if guest.compress {
+                anyhow::bail!("--compress does not apply to a streaming tool");
+            }
+            if explain.is_some() {
+                anyhow::bail!("--explain-timing does not apply to a streaming tool");
+            }
+            return run_wasm_streaming(
+                &wasm_path,
+                &wasmtime_args,
+                args_json,
+                !quiet,
+                log,
+                guest.timeout,
+            );
+        }
+        if !guest.compress {
+            let (stdout, stderr) = run_wasm_logged(
+                &wasm_path,
+                &wasmtime_args,
+                &argv,
+                args_json.as_bytes(),
+                log,
+                timer.as_mut(),
+                guest.timeout,
+            )?;
+            return Ok((guest_text(&stdout), stderr));
+        }
+        let packed = compress::gzip(args_json.as_bytes());
+        let (stdout, stderr) = run_wasm_logged(
+            &wasm_path,
+            &wasmtime_args,
+            &argv,
+            &packed,
+            log,
+            timer.as_mut(),
+            guest.timeout,
+        )?;
+        wire = Some((packed.len(), stdout.len()));
+        let plain = compress::gunzip(&stdout)?;
+        if !quiet {
+            println!(
+                "  gzip:    args {} → {} bytes, result {} → {} bytes",
+                args_json.len(),
+                packed.len(),
+                plain.len(),
+                stdout.len()
+            );
+            println!();
+        }
+        Ok((guest_text(&plain), stderr))
+    };
+    let (stdout, stderr) =
+        run().or_else(|err| Ok::<_, anyhow::Error>((host_result(err)?, String::new())))?;
+    let elapsed = started.elapsed();
+    let stdout = if stable_output {
+        let mut result: serde_json::Value = serde_json::from_str(stdout.trim())
+            .context("--stable-output needs a JSON ToolResult on stdout")?;
+        canonical::stable_output(&mut result);
+        result.to_string()
+    } else {
+        stdout
+    };
+    let printed = format_tool_output(&stdout, output)?;
+    let phases = timer.map(|mut timer| {
+        timer.mark("decode");
+        timer.finish()
+    });
+    match &phases {
+        Some(phases) if explain == Some(TimingReport::Meta) => {
+            println!("{}", timing::with_meta(&stdout, phases)?)
+        }
+        _ => println!("{printed}"),
+    }
+    if let Some(phases) = phases
+        .as_ref()
+        .filter(|_| explain != Some(TimingReport::Meta))
+    {
+        // Keep captured --field and --canonical output to the result alone.
+        if quiet {
+            eprint!("{}", phases.render_table());
+        } else {
+            println!();
+            print!("{}", phases.render_table());
+        }
+    }
+    let (bytes_in, bytes_out) = wire.unwrap_or((args_json.len(), stdout.len()));
+    let footer = run_footer(shown, &render_usage(elapsed, bytes_in, bytes_out), &stdout);
+    if !footer.is_empty() {
+        println!();
+        println!("{}", footer.join("\n"));
+    }
+    if log == GuestLog::After && !stderr.is_empty() {
+        eprintln!();
+        eprintln!("  {}", console::style("stderr:").dim());
+        eprint!("{stderr}");
+    }
+
+    show_artifacts(&stdout, extract, quiet)?;
+    if check_output {
+        check_output_contract(&wasm_path, &stdout)?;
+    }
+    check_tool_result(&stdout)?;
+    if !quiet {
+        println!();
+        println!(
+            "  {} Tool returned success",
+            console::style("✓").green().bold()
+        );
+    }
+
+    Ok(())
+}
+
+/// `skill test --native`: build the Go skill at `skill_path` for the host
+/// and run it once, checking and printing its result as
+/// [`test_skill_locally`] does for `tool.wasm`.
+#[allow(clippy::too_many_arguments)]
+fn test_native_locally(
+    skill_path: &Path,
+    args_json: &str,
+    output: &TestOutput,
+    guest: &GuestOptions,
+    stable_output: bool,
+    verbosity: Verbosity,
+    extract: Option<&artifacts::Extract>,
+) -> Result<()> {
+    let _: serde_json::Value = serde_json::from_str(args_json)
+        .with_context(|| format!("--args is not valid JSON: {args_json}"))?;
+    let quiet = verbosity == Verbosity::Quiet
+        || matches!(output, TestOutput::Field(_) | TestOutput::Canonical(_));
+    let shown = if quiet { Verbosity::Quiet } else { verbosity };
+    let binary = native::build(skill_path)?;
+    let command = format!("{} {}", console::style("native").cyan(), binary.display());
+    let header = run_header(
+        shown,
+        &command,
+        &shown_args(&skill_path.join("manifest.json"), args_json),
+        guest,
+    );
+    if !header.is_empty() {
+        println!("{}", header.join("\n"));
+        println!(
+            "  {} not sandboxed: the skill has this machine's files and network",
+            console::style("Warning:").yellow()
+        );
+        println!();
+    }
+
+    let started = std::time::Instant::now();
+    let (stdout, stderr) = match native::run(
+        &binary,
+        args_json.as_bytes(),
+        guest.strict_utf8,
+        guest.pretty,
+        guest.timeout,
+    ) {
+        Ok((stdout, stderr)) => (
+            guest_text(&stdout),
+            secrets::redact(&String::from_utf8_lossy(&stderr)),
+        ),
+        Err(err) => (host_result(err)?, String::new()),
+    };
+    let elapsed = started.elapsed();
+    let stdout = if stable_output {
+        let mut result: serde_json::Value = serde_json::from_str(stdout.trim())
+            .context("--stable-output needs a JSON ToolResult on stdout")?;
+        canonical::stable_output(&mut result);
+        result.to_string()
+    } else {
+        stdout
+    };
+    println!("{}", format_tool_output(&stdout, output)?);
+    let footer = run_footer(
+        shown,
+        &render_usage(elapsed, args_json.len(), stdout.len()),
+        &stdout,
+    );
+    if !footer.is_empty() {
+        println!();
+        println!("{}", footer.join("\n"));
+    }
+    if verbosity == Verbosity::Verbose && !stderr.is_empty() {
+        eprintln!();
+        eprintln!("  {}", console::style("stderr:").dim());
+        eprint!("{stderr}");
+    }
+
+    show_artifacts(&stdout, extract, quiet)?;
+    check_tool_result(&stdout)?;
+    if !quiet {
+        println!();
+        println!(
+            "  {} Tool returned success",
+            console::style("✓").green().bold()
+        );
+    }
+
+    Ok(())
+}
+
+/// List the artifacts of the result on `stdout` after it, unless `quiet`,
+/// and write the one `--extract` names to its file.
+fn show_artifacts(stdout: &str, extract: Option<&artifacts::Extract>, quiet: bool) -> Result<()> {
+    let listed = artifacts::list(stdout);
+    if !quiet && !listed.is_empty() {
+        println!();
+        for artifact in &listed {
+            println!("  Artifact: {artifact}");
+        }
+    }
+    if let Some(extract) = extract {
+        let bytes = extract.write(stdout)?;
+        if !quiet {
+            println!(
+                "  Wrote {} ({bytes} bytes) to {}",
+                extract.name,
+                extract.out.display()
+            );
+        }
+    }
+    Ok(())
+}
+
+/// Describe what one `skill test` run consumed. The wasmtime CLI does not
+/// report the guest's peak memory, so that is marked unavailable; the Go
+/// runtime's `Result.Usage` has it.
+fn render_usage(elapsed: std::time::Duration, bytes_in: usize, bytes_out: usize) -> String {
+    format!(
+        "{} ms, {bytes_in} bytes in, {bytes_out} bytes out, peak memory n/a",
+        elapsed.as_millis()
+    )
+}
+
+/// What `skill test --verbose` says a tool is given before it runs: each
+/// `--preopen` directory, the names (never the values) of its secrets, and
+/// its deadline.
+fn grant_lines(guest: &GuestOptions) -> Vec<String> {
+    let mut lines: Vec<String> = guest
+        .preopens
+        .iter()
+        .map(|p| format!("fs {} → {}", p.host.display(), p.guest))
+        .collect();
+    if !guest.secrets.is_empty() {
+        let keys: Vec<&str> = guest.secrets.iter().map(|s| s.key.as_str()).collect();
+        lines.push(format!("secrets {}", keys.join(", ")));
+    }
+    lines.push(match guest.timeout {
+        Some(timeout) => format!("deadline {timeout:?}"),
+        None => "no deadline".to_string(),
+    });
+    lines
+}
+
+/// The lines `skill test` prints before running a tool: the command, its
+/// input, and with `--verbose` its grants. `--quiet` prints none.
+fn run_header(
+    verbosity: Verbosity,
+    command: &str,
+    input: &str,
+    guest: &GuestOptions,
+) -> Vec<String> {
+    if verbosity == Verbosity::Quiet {
+        return Vec::new();
+    }
+    let mut lines = vec![
+        format!("  Running: {command}"),
+        format!("  Input:   {input}"),
+    ];
+    if verbosity == Verbosity::Verbose {
+        lines.extend(
+            grant_lines(guest)
+                .iter()
+                .map(|grant| format!("  Grant:   {grant}")),
+        );
+    }
+    lines
+}
+
+/// The lines `skill test --verbose` prints after the result: what the call
+/// consumed and each of the result's warnings. Other levels print none.
+fn run_footer(verbosity: Verbosity, usage: &str, stdout: &str) -> Vec<String> {
+    if verbosity != Verbosity::Verbose {
+        return Vec::new();
+    }
+    let mut lines = vec![format!("  Usage:   {usage}")];
+    for warning in result_warnings(stdout) {
+        lines.push(format!(
+            "  {} {warning}",
+            console::style("Warning:").yellow()
+        ));
+    }
+    lines
+}
+
+/// The lines `skill replay` and `skill diff` print for one record or case:
+/// a ✗ and each change when it changed, otherwise a ✓ that `--quiet` leaves
+/// out.
+fn verdict_lines(verbosity: Verbosity, label: &str, changes: &[String]) -> Vec<String> {
+    if changes.is_empty() {
+        if verbosity == Verbosity::Quiet {
+            return Vec::new();
+        }
+        return vec![format!("  {} {label}", console::style("✓").green().bold())];
+    }
+    let mut lines = vec![format!("  {} {label}", console::style("✗").red().bold())];
+    lines.extend(changes.iter().map(|change| format!("      {change}")));
+    lines
+}
+
+/// The lines `skill diff` and `skill schema-diff --verbose` print before
+/// comparing: the two modules they run. Other levels print none.
+fn comparison_header(verbosity: Verbosity, old: &Path, new: &Path) -> Vec<String> {
+    if verbosity != Verbosity::Verbose {
+        return Vec::new();
+    }
+    vec![
+        format!("  Old:     {}", old.display()),
+        format!("  New:     {}", new.display()),
+    ]
+}
+
+/// The lines `skill package` prints: the package written, then unless
+/// `--quiet` its digest, signature, and how to install it, and with
+/// `--verbose` each file in it.
+fn package_lines(
+    verbosity: Verbosity,
+    header: &package::PackageHeader,
+    output: &Path,
+    size: usize,
+    signed: bool,
+) -> Vec<String> {
+    let mut lines = vec![format!(
+        "  {} Packaged {} v{}: {} ({} files, {size} bytes)",
+        console::style("✓").green().bold(),
+        header.name,
+        header.version,
+        output.display(),
+        header.files.len(),
+    )];
+    if verbosity == Verbosity::Quiet {
+        return lines;
+    }
+    lines.push(format!("    sha256: {}", header.content_sha256));
+    if signed {
+        lines.push("    signed: ed25519".to_string());
+    }
+    if verbosity == Verbosity::Verbose {
+        lines.extend(
+            header
+                .files
+                .iter()
+                .map(|file| format!("    file:   {} {}", file.path, file.sha256)),
+        );
+    }
+    lines.push(format!(
+        "  Install with: zeroclaw skill install {}",
+        output.display()
+    ));
+    lines
+}
+
+/// The lines `skill index --output` prints: the index written, and with
+/// `--verbose` each skill in it.
+fn index_lines(verbosity: Verbosity, index: &index::Index, output: &Path) -> Vec<String> {
+    let mut lines = vec![format!(
+        "  {} Indexed {} skills: {}",
+        console::style("✓").green().bold(),
+        index.skills.len(),
+        output.display()
+    )];
+    if verbosity == Verbosity::Verbose {
+        lines.extend(
+            index
+                .skills
+                .iter()
+                .map(|skill| format!("    {} v{} ({})", skill.name, skill.version, skill.path)),
+        );
+    }
+    lines
+}
+
+/// The line `skill migrate` prints for one manifest, migrated `from` an
+/// older schema_version or, when `None`, already current, which `--quiet`
+/// leaves out.
+fn migrate_line(verbosity: Verbosity, manifest_path: &Path, from: Option<u64>) -> Option<String> {
+    let to = crate::tools::wasm_tool::MANIFEST_SCHEMA_VERSION;
+    match from {
+        Some(from) => Some(format!(
+            "  {} Migrated {} from schema_version {from} to {to}",
+            console::style("✓").green().bold(),
+            manifest_path.display(),
+        )),
+        None if verbosity == Verbosity::Quiet => None,
+        None => Some(format!(
+            "  {} {} is already at schema_version {to}",
+            console::style("✓").green().bold(),
+            manifest_path.display(),
+        )),
+    }
+}
+
+/// The lines `skill install` prints before installing: the source, and with
+/// `--verbose` the directory it installs into. `--quiet` prints none.
+fn install_header(verbosity: Verbosity, source: &str, skills_path: &Path) -> Vec<String> {
+    if verbosity == Verbosity::Quiet {
+        return Vec::new();
+    }
+    let mut lines = vec![format!("Installing skill from: {source}")];
+    if verbosity == Verbosity::Verbose {
+        lines.push(format!("  Into:    {}", skills_path.display()));
+    }
+    lines
+}
+
+/// What `skill install` suggests after installing, unless `--quiet`.
+const INSTALL_HINT: &str = "  Run 'zeroclaw skill list' to verify the new tools are available.";
+
+/// Environment variable that puts an SDK-built skill in JSON-Lines mode
+/// (see the Go SDK's `skill.JSONLinesEnv`).
+const JSONL_ENV: &str = "ZEROCLAW_JSONL";
+
+/// Manifest `input` value for tools that read one JSON object per line.
+const INPUT_NDJSON: &str = "ndjson";
+
+/// Whether the tool's manifest sets `"input": "ndjson"` or `"jsonl": true`.
+fn declares_jsonl(skill_path: &Path, tool_name: Option<&str>) -> bool {
+    resolve_wasm_path(skill_path, tool_name)
+        .ok()
+        .and_then(|wasm| read_manifest_value(&wasm))
+        .is_some_and(|manifest| {
+            manifest.get("input").and_then(serde_json::Value::as_str) == Some(INPUT_NDJSON)
+                || manifest.get("jsonl").and_then(serde_json::Value::as_bool) == Some(true)
+        })
+}
+
+/// Whether the manifest next to `wasm_path` sets `key` to `true`.
+fn manifest_flag(wasm_path: &Path, key: &str) -> bool {
+    read_manifest_value(wasm_path)
+        .and_then(|manifest| manifest.get(key).and_then(serde_json::Value::as_bool))
+        .unwrap_or(false)
+}
+
+/// `args_json` as `skill test` echoes it: args the `parameters` of the
+/// manifest next to `path` mark secret are shown as
+/// [`secrets::REDACTED_ARG`].
+fn shown_args(path: &Path, args_json: &str) -> String {
+    let manifest = read_manifest_value(path);
+    secrets::redact_args(
+        manifest.as_ref().and_then(|m| m.get("parameters")),
+        args_json,
+    )
+}
+
+/// The manifest next to `wasm_path` as untyped JSON, if it exists and parses.
+fn read_manifest_value(wasm_path: &Path) -> Option<serde_json::Value> {
+    let raw = std::fs::read_to_string(wasm_path.with_file_name("manifest.json")).ok()?;
+    serde_json::from_str(&raw).ok()
+}
+
+/// Run one skill process in JSON-Lines mode with this process's stdin and
+/// stdout attached, so each input line is answered as soon as it is read.
+///
+/// Results are written by the skill itself, one line each, in input order;
+/// status messages go to stderr to keep stdout machine-readable.
+fn test_jsonl_locally(
+    skill_path: &Path,
+    tool_name: Option<&str>,
+    guest: &GuestOptions,
+    verbosity: Verbosity,
+) -> Result<()> {
+    let wasm_path = resolve_wasm_path(skill_path, tool_name)?;
+    let mut wasmtime_args = guest_wasmtime_args(&wasm_path, guest)?;
+    wasmtime_args.extend(["--env".to_string(), format!("{JSONL_ENV}=1")]);
+
+    if verbosity != Verbosity::Quiet {
+        eprintln!(
+            "  Streaming JSON lines from stdin through {} {}",
+            console::style("wasmtime").cyan(),
+            wasm_path.display()
+        );
+    }
+    // Results pass through here line by line so leaked secrets are redacted.
+    let mut child = std::process::Command::new("wasmtime")
+        .arg("run")
+        .args(&wasmtime_args)
+        .arg(&wasm_path)
+        .stdout(std::process::Stdio::piped())
+        .spawn()
+        .context(WASMTIME_NOT_FOUND)?;
+    let stdout = child
+        .stdout
+        .take()
+        .context("wasmtime stdout was not captured")?;
+    for line in std::io::BufRead::lines(std::io::BufReader::new(stdout)) {
+        println!("{}", secrets::redact(&line?));
+    }
+    let status = child.wait()?;
+    if !status.success() {
+        anyhow::bail!("wasmtime exited with {status} while streaming JSON lines");
+    }
+    Ok(())
+}
+
+/// Compile a skill once and run each args line typed on stdin in a fresh
+/// instance of it (`zeroclaw skill test --interactive`).
+fn test_interactive_locally(
+    skill_path: &Path,
+    tool_name: Option<&str>,
+    pretty: bool,
+) -> Result<()> {
+    use std::io::IsTerminal;
+
+    let wasm_path = resolve_wasm_path(skill_path, tool_name)?;
+    let tool = crate::tools::wasm_tool::WasmTool::load(
+        &wasm_path,
+        tool_name.unwrap_or("skill").to_string(),
+        String::new(),
+        serde_json::Value::Null,
+    )?;
+    let stdin = std::io::stdin();
+    let prompt = stdin.is_terminal();
+    if prompt {
+        eprintln!(
+            "  Loaded {} — type one JSON args object per line; an empty line or Ctrl-D ends",
+            wasm_path.display()
+        );
+    }
+    let output = if pretty {
+        TestOutput::Pretty
+    } else {
+        TestOutput::Raw
+    };
+    let calls = interactive::run_session(
+        stdin.lock(),
+        &mut std::io::stdout(),
+        &mut std::io::stderr(),
+        prompt,
+        |args| format_tool_output(&tool.call_stdout(args)?, &output),
+    )?;
+    if prompt {
+        eprintln!();
+        eprintln!("  {calls} calls");
+    }
+    Ok(())
+}
+
+/// Compile a skill once and answer requests on every address in `addrs`
+/// until the process is stopped (`zeroclaw skill serve`).
+fn serve_skill(
+    skill_path: &Path,
+    tool_name: Option<&str>,
+    addrs: &serve::Addrs,
+    pool_size: usize,
+    queue_size: usize,
+    verbosity: Verbosity,
+) -> Result<()> {
+    let wasm_path = resolve_wasm_path(skill_path, tool_name)?;
+    // Each copy has its own engine, so its deadline ticker times only the
+    // call running on it.
+    let copies = (0..pool_size.max(1))
+        .map(|_| {
+            crate::tools::wasm_tool::WasmTool::load(
+                &wasm_path,
+                tool_name.unwrap_or("skill").to_string(),
+                String::new(),
+                serde_json::Value::Null,
+            )
+        })
+        .collect::<Result<Vec<_>>>()?;
+    let listeners = serve::Listeners::bind(addrs)?;
+    if verbosity != Verbosity::Quiet {
+        eprintln!(
+            "  Serving {} ({} {})",
+            wasm_path.display(),
+            copies.len(),
+            if copies.len() == 1 { "copy" } else { "copies" }
+        );
+        for line in listeners.describe() {
+            eprintln!("    {line}");
+        }
+    }
+    let server = std::sync::Arc::new(serve::Server::pooled(copies, queue_size));
+    // Readiness follows the first instance; requests are answered meanwhile.
+    let warm = std::sync::Arc::clone(&server);
+    std::thread::spawn(move || {
+        if let Err(e) = warm.warm_up() {
+            if verbosity != Verbosity::Quiet {
+                eprintln!("  {} {e:#}", console::style("✗").red().bold());
+            }
+        }
+    });
+    for thread in serve::serve(server, listeners) {
+        let _ = thread.join();
+    }
+    Ok(())
+}
+
+impl serve::Module for crate::tools::wasm_tool::WasmTool {
+    fn call(&self, args: &serde_json::Value) -> Result<String> {
+        use crate::tools::wasm_tool::{WasmTool, WASM_TIMEOUT_SECS};
+
+        self.call_stdout(args).map_err(|e| {
+            if WasmTool::timed_out(&e) {
+                serve::TimedOut(std::time::Duration::from_secs(WASM_TIMEOUT_SECS)).into()
+            } else {
+                e
+            }
+        })
+    }
+
+    fn instantiate(&self) -> Result<()> {
+        crate::tools::wasm_tool::WasmTool::instantiate(self)
+    }
+}
+
+/// Fail if a successful result's `data` breaks the skill's output schema.
+///
+/// Failed results carry no contract for `data` and are left to
+/// [`check_tool_result`].
+fn check_output_contract(wasm_path: &Path, stdout: &str) -> Result<()> {
+    let Ok(result) = serde_json::from_str::<serde_json::Value>(stdout.trim()) else {
+        anyhow::bail!("--check-output: stdout is not a JSON ToolResult");
+    };
+    if result.get("success").and_then(serde_json::Value::as_bool) != Some(true) {
+        return Ok(());
+    }
+
+    let schema_path = wasm_path.with_file_name(output_check::OUTPUT_SCHEMA_FILE);
+    let schema: serde_json::Value = if schema_path.exists() {
+        let raw = std::fs::read_to_string(&schema_path)
+            .with_context(|| format!("failed to read {}", schema_path.display()))?;
+        serde_json::from_str(&raw)
+            .with_context(|| format!("{} is not valid JSON", schema_path.display()))?
+    } else {
+        let printed = run_wasm_command(
+            wasm_path,
+            &[],
+            &[output_check::OUTPUT_SCHEMA_FLAG],
+            "",
+            None,
+        )
+        .with_context(|| {
+            format!(
+                "--check-output: no {} next to {} and the module printed no schema for {}",
+                output_check::OUTPUT_SCHEMA_FILE,
+                wasm_path.display(),
+                output_check::OUTPUT_SCHEMA_FLAG
+            )
+        })?;
+        serde_json::from_str(printed.trim()).with_context(|| {
+            format!(
+                "{} did not print a JSON schema for {}",
+                wasm_path.display(),
+                output_check::OUTPUT_SCHEMA_FLAG
+            )
+        })?
+    };
+
+    let data = result.get("data").unwrap_or(&serde_json::Value::Null);
+    let violations = output_check::check_output(&schema, data);
+    if !violations.is_empty() {
+        anyhow::bail!(
+            "result data does not match the output schema:\n  {}",
+            violations.join("\n  ")
+        );
+    }
+    Ok(())
+}
+
+/// Check `args_json` against the `parameters` schema in a skill's manifest,
+/// returning the args with defaults applied as compact JSON.
+///
+/// Rejected args fail with an `invalid_input` [`ToolFailure`], so the exit
+/// status matches what `skill test` would report for them.
+fn validate_skill_args(
+    skill_path: &Path,
+    args_json: &str,
+    warn_undocumented: bool,
+) -> Result<String> {
+    let manifest_path = skill_path.join("manifest.json");
+    let raw = std::fs::read_to_string(&manifest_path)
+        .with_context(|| format!("failed to read {}", manifest_path.display()))?;
+    let manifest: serde_json::Value = serde_json::from_str(&raw)
+        .with_context(|| format!("{} is not valid JSON", manifest_path.display()))?;
+    let Some(schema) = manifest.get("parameters") else {
+        anyhow::bail!("{} declares no parameters", manifest_path.display());
+    };
+    let args: serde_json::Value =
+        serde_json::from_str(args_json).context("--args is not valid JSON")?;
+    if warn_undocumented {
+        for field in validate::undocumented_fields(schema) {
+            eprintln!(
+                "  {} {field} has no description",
+                console::style("!").yellow().bold()
+            );
+        }
+    }
+
+    match validate::validate_args(schema, args) {
+        Ok(args) => Ok(args.to_string()),
+        Err(violations) => Err(ToolFailure {
+            error: format!(
+                "args do not match the manifest parameters:\n  {}",
+                violations.join("\n  ")
+            ),
+            error_code: Some("invalid_input".to_string()),
+        }
+        .into()),
+    }
+}
+
+/// Upgrade every manifest of a skill, the one at its root or, in the
+/// installed layout, each `tools/<name>/manifest.json`, to the current
+/// schema in place. Returns each manifest with the version it was at, or
+/// `None` for one that was current and is left untouched.
+fn migrate_skill_manifests(skill_path: &Path) -> Result<Vec<(PathBuf, Option<u64>)>> {
+    let mut paths = vec![skill_path.join("manifest.json")];
+    if !paths[0].exists() {
+        paths = std::fs::read_dir(skill_path.join("tools"))
+            .into_iter()
+            .flatten()
+            .flatten()
+            .map(|entry| entry.path().join("manifest.json"))
+            .filter(|path| path.exists())
+            .collect();
+        paths.sort();
+    }
+    if paths.is_empty() {
+        anyhow::bail!("no manifest.json found in {}", skill_path.display());
+    }
+    let mut migrated = Vec::new();
+    for path in paths {
+        let raw = std::fs::read_to_string(&path)
+            .with_context(|| format!("failed to read {}", path.display()))?;
+        let mut manifest: serde_json::Value = serde_json::from_str(&raw)
+            .with_context(|| format!("{} is not valid JSON", path.display()))?;
+        let from = crate::tools::wasm_tool::migrate_manifest(&mut manifest)
+            .with_context(|| format!("cannot migrate {}", path.display()))?;
+        if from.is_some() {
+            std::fs::write(
+                &path,
+                format!("{}\n", serde_json::to_string_pretty(&manifest)?),
+            )
+            .with_context(|| format!("failed to write {}", path.display()))?;
+        }
+        migrated.push((path, from));
+    }
+    Ok(migrated)
+}
+
+/// Print a skill's manifest summary and the `skill test` exit codes.
+fn describe_skill(skill_path: &Path) -> Result<()> {
+    let manifest_path = skill_path.join("manifest.json");
+    if manifest_path.exists() {
+        let raw = std::fs::read_to_string(&manifest_path)
+            .with_context(|| format!("failed to read {}", manifest_path.display()))?;
+        let manifest: serde_json::Value = serde_json::from_str(&raw)
+            .with_context(|| format!("{} is not valid JSON", manifest_path.display()))?;
+        let field = |key: &str| manifest.get(key).and_then(serde_json::Value::as_str);
+        println!(
+            "{} {}",
+            console::style(field("name").unwrap_or("(unnamed)"))
+                .white()
+                .bold(),
+            console::style(format!("v{}", field("version").unwrap_or("0.0.0"))).dim()
+        );
+        if let Some(description) = field("description") {
+            println!("  {description}");
+        }
+        // Manifests that do not say are taken to write and not be idempotent.
+        let idempotent = manifest
+            .get("idempotent")
+            .and_then(serde_json::Value::as_bool)
+            .unwrap_or(false);
+        println!(
+            "  Side effects: {}, {}",
+            field("side_effects").unwrap_or("write"),
+            if idempotent {
+                "idempotent"
+            } else {
+                "not idempotent"
+            }
+        );
+        let params = manifest.get("parameters");
+        let required: Vec<&str> = params
+            .and_then(|p| p.get("required"))
+            .and_then(serde_json::Value::as_array)
+            .map(|r| r.iter().filter_map(serde_json::Value::as_str).collect())
+            .unwrap_or_default();
+        if let Some(properties) = params
+            .and_then(|p| p.get("properties"))
+            .and_then(serde_json::Value::as_object)
+        {
+            println!();
+            println!("  Parameters:");
+            for (name, schema) in properties {
+                let ty = schema
+                    .get("type")
+                    .and_then(serde_json::Value::as_str)
+                    .unwrap_or("any");
+                let marker = if required.contains(&name.as_str()) {
+                    " (required)"
+                } else {
+                    ""
+                };
+                println!("    {name}: {ty}{marker}");
+            }
+        }
+    } else {
+        println!("{}", skill_path.display());
+        println!("  No manifest.json found.");
+    }
+
+    println!();
+    println!("  Exit codes (zeroclaw skill test):");
+    println!("    0   tool returned success");
+    println!(
+        "    {EXIT_HARNESS_ERROR}   harness error (module not found, wasmtime failed, bad --args)"
+    );
+    println!("    {EXIT_TOOL_FAILURE}  tool returned failure without a known error_code");
+    for (code, exit) in ERROR_CODE_EXITS {
+        println!("    {exit}  tool returned failure with error_code \"{code}\"");
+    }
+    Ok(())
+}
+
+/// Probe a skill's built module and print the tools it registers, with the
+/// side effects and idempotency its manifest declares. A module that fails
+/// to start, such as a Go router with no tools, or one whose probe answer
+/// lists none fails the command.
+fn inspect_skill(skill_path: &Path) -> Result<()> {
+    let wasm_path = resolve_wasm_path(skill_path, None)?;
+    let manifest = manifest_beside(&wasm_path)?;
+    let stdout = run_wasm_tool(&wasm_path, PROBE_ARGS)
+        .with_context(|| format!("probe of {} failed", wasm_path.display()))?;
+    println!("{}", console::style(wasm_path.display()).white().bold());
+    println!("  Side effects: {}", capabilities::Safety::of(&manifest));
+    let Some(tools) = probe_tool_names(&stdout) else {
+        println!("  Tools: not reported (the module is not built on a zeroclaw SDK)");
+        return Ok(());
+    };
+    if tools.is_empty() {
+        anyhow::bail!("{} registers no tools", wasm_path.display());
+    }
+    println!("  Tools:");
+    for name in tools {
+        println!("    {name}");
+    }
+    Ok(())
+}
+
+/// Print, as JSON, the host functions a skill's built module imports and
+/// the capabilities its manifest declares, failing if any import is not
+/// declared. The module is read, not run.
+fn inspect_capabilities(skill_path: &Path) -> Result<()> {
+    let wasm_path = resolve_wasm_path(skill_path, None)?;
+    let wasm = std::fs::read(&wasm_path)
+        .with_context(|| format!("failed to read {}", wasm_path.display()))?;
+    let manifest = manifest_beside(&wasm_path)?;
+    let report = capabilities::report(&wasm, &manifest)
+        .with_context(|| format!("cannot read the imports of {}", wasm_path.display()))?;
+    println!("{}", serde_json::to_string_pretty(&report)?);
+    if !report.undeclared.is_empty() {
+        anyhow::bail!(
+            "{} imports host functions its manifest does not declare: {}",
+            wasm_path.display(),
+            report
+                .imports
+                .iter()
+                .filter(|import| report.undeclared.contains(&import.name))
+                .map(|import| match import.capability {
+                    Some(capability) => format!("{} (needs {capability})", import.name),
+                    None => format!("{} (not a zeroclaw host function)", import.name),
+                })
+                .collect::<Vec<_>>()
+                .join(", ")
+        );
+    }
+    Ok(())
+}
+
+/// The manifest next to a built module, where both the dev and installed
+/// layouts keep it, or `null` for a skill without one, which declares
+/// nothing.
+fn manifest_beside(wasm_path: &Path) -> Result<serde_json::Value> {
+    let manifest_path = wasm_path.with_file_name("manifest.json");
+    if !manifest_path.exists() {
+        return Ok(serde_json::Value::Null);
+    }
+    let raw = std::fs::read_to_string(&manifest_path)
+        .with_context(|| format!("failed to read {}", manifest_path.display()))?;
+    serde_json::from_str(&raw)
+        .with_context(|| format!("{} is not valid JSON", manifest_path.display()))
+}
+
+/// The probe envelope SDK-built skills answer without running a handler.
+const PROBE_ARGS: &str = r#"{"__probe":true}"#;
+
+/// The tool names in a probe answer, in the order the skill registered them,
+/// or `None` when `stdout` is not one: a module not built on an SDK
+/// treats the envelope as ordinary args.
+fn probe_tool_names(stdout: &str) -> Option<Vec<String>> {
+    let result: serde_json::Value = serde_json::from_str(stdout.trim()).ok()?;
+    if result.get("output").and_then(serde_json::Value::as_str) != Some("probe") {
+        return None;
+    }
+    let tools = result.pointer("/data/tools")?.as_array()?;
+    Some(
+        tools
+            .iter()
+            .filter_map(|tool| tool.get("name").and_then(serde_json::Value::as_str))
+            .map(str::to_string)
+            .collect(),
+    )
+}
+
+// ─── Exit codes (zeroclaw skill test) ───────────────────────────────────────
+
+/// Exit status for harness errors: missing module, wasmtime failure, bad args.
+pub const EXIT_HARNESS_ERROR: i32 = 1;
+
+/// Guest exit status meaning "input rejected"; the module still writes a result.
+const EXIT_GUEST_INVALID_INPUT: i32 = 2;
+
+/// Guest exit status meaning the SDK recovered a panic; the module still
+/// writes an `internal` result.
+const EXIT_GUEST_PANIC: i32 = 3;
+
+/// Whether a guest exit status leaves a ToolResult on stdout.
+fn guest_wrote_result(status: std::process::ExitStatus) -> bool {
+    status.success()
+        || matches!(
+            status.code(),
+            Some(EXIT_GUEST_INVALID_INPUT | EXIT_GUEST_PANIC)
+        )
+}
+
+/// Exit status for a failed `ToolResult` without a recognised `error_code`.
+pub const EXIT_TOOL_FAILURE: i32 = 10;
+
+/// Stable exit statuses for the SDK's `error_code` values.
+const ERROR_CODE_EXITS: &[(&str, i32)] = &[
+    ("invalid_input", 11),
+    ("not_found", 12),
+    ("permission_denied", 13),
+    ("timeout", 14),
+    ("rate_limited", 15),
+    ("internal", 16),
+    ("not_supported", 17),
+    (deadline::DEADLINE_EXCEEDED, 18),
+];
+
+/// A tool ran to completion but returned `success: false`.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct ToolFailure {
+    pub error: String,
+    pub error_code: Option<String>,
+}
+
+impl ToolFailure {
+    /// Exit status for this failure; unknown codes share [`EXIT_TOOL_FAILURE`].
+    pub fn exit_code(&self) -> i32 {
+        self.error_code
+            .as_deref()
+            .and_then(|code| ERROR_CODE_EXITS.iter().find(|(c, _)| *c == code))
+            .map_or(EXIT_TOOL_FAILURE, |(_, exit)| *exit)
+    }
+}
+
+impl std::fmt::Display for ToolFailure {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        write!(f, "tool returned failure: {}", self.error)?;
+        if let Some(code) = &self.error_code {
+            write!(f, " [{code}]")?;
+        }
+        Ok(())
+    }
+}
+
+impl std::error::Error for ToolFailure {}
+
+/// Process exit status for an error returned by [`handle_command`].
+///
+/// [`ToolFailure`]s map to their own statuses; anything else is a harness error.
+pub fn exit_code(err: &anyhow::Error) -> i32 {
+    err.downcast_ref::<ToolFailure>()
+        .map_or(EXIT_HARNESS_ERROR, ToolFailure::exit_code)
+}
+
+/// How `skill test --cases` prints its report.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum CasesReport {
+    /// A line per case and a summary; `color` is off when stdout is not a
+    /// terminal or with `--no-color`.
+    Text { color: bool },
+    /// One JSON object (`--json` or `--format json`).
+    Json,
+    /// A header and a row per case (`--format csv`).
+    Csv,
+}
+
+/// Run a fixtures file against a skill (`zeroclaw skill test --cases`).
+///
+/// Cases run on `parallel` workers, each invoking its own wasmtime process, and
+/// are reported in file order.
+fn test_cases_locally(
+    skill_path: &Path,
+    tool_name: Option<&str>,
+    cases_path: &Path,
+    parallel: usize,
+    guest: &GuestOptions,
+    report: CasesReport,
+    verbosity: Verbosity,
+) -> Result<()> {
+    let wasm_path = resolve_wasm_path(skill_path, tool_name)?;
+    let wasmtime_args = guest_wasmtime_args(&wasm_path, guest)?;
+    let argv = tool_argv(skill_path, &wasm_path, tool_name);
+    let fixtures = cases::load_cases(cases_path)?;
+
+    // --quiet keeps the report but not the line announcing it.
+    let heading = match report {
+        CasesReport::Text { color } if verbosity != Verbosity::Quiet => Some(color),
+        _ => None,
+    };
+    if let Some(color) = heading {
+        println!(
+            "  Running {} cases: {} {} ({} workers)",
+            fixtures.len(),
+            console::style("wasmtime").cyan().force_styling(color),
+            wasm_path.display(),
+            parallel.max(1)
+        );
+        println!();
+    }
+
+    let outcomes = cases::run_cases(&fixtures, parallel, |args| {
+        run_wasm_command(&wasm_path, &wasmtime_args, &argv, args, guest.timeout)
+            .or_else(host_result)
+    });
+    match report {
+        CasesReport::Text { color } => print!("{}", cases::render_report(&outcomes, color)),
+        CasesReport::Json => println!("{}", cases::json_report(&outcomes)),
+        CasesReport::Csv => print!("{}", cases::csv_report(&outcomes)),
+    }
+
+    let summary = cases::Summary::of(&outcomes);
+    if summary.failed > 0 {
+        anyhow::bail!("{} of {} cases failed", summary.failed, outcomes.len());
+    }
+    Ok(())
+}
+
+/// Run the `cases.json` of every skill under `root`, printing each skill's
+/// report and then the rollup, or with `--quiet` only the rollup, or with
+/// [`CasesReport::Json`] only the JSON report.
+fn test_suite_locally(
+    root: &Path,
+    filter: Option<&str>,
+    guest: &GuestOptions,
+    report: CasesReport,
+    verbosity: Verbosity,
+) -> Result<()> {
+    let color = match report {
+        CasesReport::Text { color } => Some(color),
+        CasesReport::Json => None,
+        CasesReport::Csv => anyhow::bail!("--format csv does not apply to a 'dir/...' run"),
+    };
+    let each = color.filter(|_| verbosity != Verbosity::Quiet);
+    let skills = suite::discover(root, filter)?;
+    if skills.is_empty() {
+        anyhow::bail!("no skills found under {}", root.display());
+    }
+
+    let outcomes = suite::run_suite(skills, |skill, fixtures| {
+        if let Some(color) = each {
+            println!(
+                "  {} {} ({} cases)",
+                console::style("▸").cyan().force_styling(color),
+                skill.name,
+                fixtures.len()
+            );
+        }
+        let wasm_path = resolve_wasm_path(&skill.dir, None)?;
+        let wasmtime_args = guest_wasmtime_args(&wasm_path, guest)?;
+        let outcomes = cases::run_cases(fixtures, 1, |args| {
+            run_wasm_command(&wasm_path, &wasmtime_args, &[], args, guest.timeout)
+                .or_else(host_result)
+        });
+        if let Some(color) = each {
+            print!("{}", cases::render_report(&outcomes, color));
+            println!();
+        }
+        Ok(outcomes)
+    });
+    match color {
+        Some(color) => print!("{}", suite::render_rollup(&outcomes, color)),
+        None => println!("{}", suite::json_report(&outcomes)),
+    }
+    suite::check_suite(&outcomes)
+}
+
+/// Fail with a [`ToolFailure`] when a tool's stdout is a `ToolResult` with
+/// `success: false`.
+///
+/// Non-JSON stdout passes: plain-text tools have no status to inspect.
+fn check_tool_result(stdout: &str) -> Result<()> {
+    let Ok(result) = serde_json::from_str::<serde_json::Value>(stdout.trim()) else {
+        return Ok(());
+    };
+    let success = result
+        .get("success")
+        .and_then(serde_json::Value::as_bool)
+        .unwrap_or(false);
+    if !success {
+        let field = |key: &str| result.get(key).and_then(serde_json::Value::as_str);
+        return Err(ToolFailure {
+            error: field("error").unwrap_or("unknown").to_string(),
+            error_code: field("error_code").map(str::to_string),
+        }
+        .into());
+    }
+    Ok(())
+}
+
+/// The `warnings` a tool's `ToolResult` carries alongside its result; none
+/// when stdout is not JSON.
+fn result_warnings(stdout: &str) -> Vec<String> {
+    serde_json::from_str::<serde_json::Value>(stdout.trim())
+        .ok()
+        .as_ref()
+        .and_then(|result| result.get("warnings"))
+        .and_then(serde_json::Value::as_array)
+        .map(|warnings| {
+            warnings
+                .iter()
+                .filter_map(serde_json::Value::as_str)
+                .map(str::to_string)
+                .collect()
+        })
+        .unwrap_or_default()
+}
+
+/// Render a tool's stdout for `skill test` according to `output`.
+fn format_tool_output(stdout: &str, output: &TestOutput) -> Result<String> {
+    let parse = || {
+        serde_json::from_str::<serde_json::Value>(stdout.trim())
+            .context("tool stdout is not a JSON ToolResult")
+    };
+    match output {
+        TestOutput::Raw => Ok(stdout.trim_end().to_string()),
+        TestOutput::Pretty => Ok(serde_json::to_string_pretty(&parse()?)?),
+        TestOutput::Canonical(ignore) => canonical::canonical(&parse()?, ignore),
+        TestOutput::Field(path) => {
+            let result = parse()?;
+            let value = path
+                .split('.')
+                .try_fold(&result, |value, key| value.get(key))
+                .with_context(|| format!("field '{path}' not found in tool result"))?;
+            Ok(match value {
+                serde_json::Value::String(s) => s.clone(),
+                other => other.to_string(),
+            })
+        }
+    }
+}
+
+/// Run a WASM tool once via the `wasmtime` CLI, piping `args_json` to stdin.
+///
+/// Returns the tool's stdout. A trap fails with [`trap::GuestTrap`]; an exit
+/// status other than 0, 2 or 3 is an error carrying stderr.
+fn run_wasm_tool(wasm_path: &std::path::Path, args_json: &str) -> Result<String> {
+    run_wasm_command(wasm_path, &[], &[], args_json, None)
+}
+
+/// The result the host writes in the tool's place, `deadline_exceeded` for a
+/// tool `skill test --timeout` stopped and `internal` for one that trapped, or
+/// `err` itself for any other failure to run it.
+fn host_result(err: anyhow::Error) -> Result<String> {
+    if let Some(exceeded) = err.downcast_ref::<deadline::DeadlineExceeded>() {
+        return Ok(exceeded.result());
+    }
+    match err.downcast_ref::<trap::GuestTrap>() {
+        Some(trap) => Ok(trap.result()),
+        None => Err(err),
+    }
+}
+
+/// Environment variable that makes an SDK-built skill reject invalid UTF-8
+/// input (see the Go SDK's `skill.StrictUTF8Env`).
+const STRICT_UTF8_ENV: &str = "ZEROCLAW_STRICT_UTF8";
+
+/// Environment variable that makes an SDK-built skill indent its result
+/// (see the Go SDK's `skill.PrettyEnv`).
+const PRETTY_ENV: &str = "ZEROCLAW_PRETTY";
+
+/// Check `guest` against the manifest next to `wasm_path` and return the
+/// wasmtime flags that apply it.
+fn guest_wasmtime_args(wasm_path: &Path, guest: &GuestOptions) -> Result<Vec<String>> {
+    let mut args = Vec::new();
+    if !guest.preopens.is_empty() {
+        let declared = preopen::declared_fs(&wasm_path.with_file_name("manifest.json"))?;
+        preopen::check_preopens(&guest.preopens, &declared)?;
+        args = preopen::wasmtime_args(&guest.preopens);
+    }
+    if guest.strict_utf8 {
+        args.extend(["--env".to_string(), format!("{STRICT_UTF8_ENV}=1")]);
+    }
+    if guest.pretty {
+        args.extend(["--env".to_string(), format!("{PRETTY_ENV}=1")]);
+    }
+    if guest.compress {
+        args.extend(compress::wasmtime_args(
+            &wasm_path.with_file_name("manifest.json"),
+        )?);
+    }
+    let declared = secrets::declared_secrets(&wasm_path.with_file_name("manifest.json"))?;
+    secrets::check_secrets(&guest.secrets, &declared)?;
+    args.extend(secrets::export(&guest.secrets));
+    Ok(args)
+}
+
+/// Collect `--secret KEY=VALUE` flags and the lines of `--secret-file`; a
+/// flag overrides a file entry with the same key.
+fn read_secret_flags(flags: &[String], file: Option<&Path>) -> Result<Vec<secrets::Secret>> {
+    let mut all = match file {
+        Some(path) => secrets::read_file(path)?,
+        None => Vec::new(),
+    };
+    for flag in flags {
+        let secret = secrets::Secret::parse(flag).context("--secret")?;
+        all.retain(|s| s.key != secret.key);
+        all.push(secret);
+    }
+    Ok(all)
+}
+
+/// Print a module's args schema by running it with `--schema`.
+fn wasm_args_schema(wasm_path: &Path) -> Result<serde_json::Value> {
+    let stdout = run_wasm_command(wasm_path, &[], &["--schema"], "", None)?;
+    serde_json::from_str(stdout.trim()).with_context(|| {
+        format!(
+            "{} did not print a JSON schema for --schema (is it built with the skill SDK?)",
+            wasm_path.display()
+        )
+    })
+}
+
+const WASMTIME_NOT_FOUND: &str = "wasmtime not found — install it first:\n\n\
+     \x20 macOS (Homebrew):  brew install wasmtime\n\
+     \x20 macOS/Linux:       curl https://wasmtime.dev/install.sh -sSf | bash\n\
+     \x20 Cargo (slow):      cargo install wasmtime-cli\n\n\
+     After installing, restart your terminal and run this command again.\n\
+     Docs: https://wasmtime.dev";
+
+/// Like [`run_wasm_tool`], passing `wasmtime_args` to `wasmtime run` and
+/// `guest_args` on the module's command line, and stopping the tool after
+/// `timeout`.
+fn run_wasm_command(
+    wasm_path: &std::path::Path,
+    wasmtime_args: &[String],
+    guest_args: &[&str],
+    stdin_data: &str,
+    timeout: Option<Duration>,
+) -> Result<String> {
+    let (stdout, _) = run_wasm_logged(
+        wasm_path,
+        wasmtime_args,
+        guest_args,
+        stdin_data.as_bytes(),
+        GuestLog::Hidden,
+        None,
+        timeout,
+    )?;
+    Ok(guest_text(&stdout))
+}
+
+/// A tool's stdout as text, with secrets redacted.
+fn guest_text(stdout: &[u8]) -> String {
+    secrets::redact(&String::from_utf8_lossy(stdout))
+}
+
+/// Like [`run_wasm_command`], returning the tool's stdout as written (it is a
+/// gzip stream under `--compress`) and its redacted stderr, and printing each
+/// line of stderr as it is written when `log` is [`GuestLog::Follow`]. A
+/// `timer` is marked as wasmtime starts, takes its input, and exits. A tool
+/// still running after `timeout` is killed and fails the call with
+/// [`deadline::DeadlineExceeded`].
+fn run_wasm_logged(
+    wasm_path: &std::path::Path,
+    wasmtime_args: &[String],
+    guest_args: &[&str],
+    stdin_data: &[u8],
+    log: GuestLog,
+    mut timer: Option<&mut timing::PhaseTimer>,
+    timeout: Option<Duration>,
+) -> Result<(Vec<u8>, String)> {
+    let mut child = std::process::Command::new("wasmtime")
+        .arg("run")
+        .args(wasmtime_args)
+        .arg(wasm_path)
+        .args(guest_args)
+        .stdin(std::process::Stdio::piped())
+        .stdout(std::process::Stdio::piped())
+        .stderr(std::process::Stdio::piped())
+        .spawn()
+        .context(WASMTIME_NOT_FOUND)?;
+    let deadline = deadline::Deadline::start(timeout);
+    if let Some(timer) = timer.as_deref_mut() {
+        timer.mark("instantiate");
+    }
+    let follower = follow_stderr(&mut child, log);
+    // take() moves stdin out so it is dropped (closed) at end of block,
+    // sending EOF to the child process — required for read_to_string to return.
+    if let Some(mut stdin) = child.stdin.take() {
+        use std::io::Write;
+        stdin.write_all(stdin_data)?;
+        // stdin dropped here → EOF sent
+    }
+    if let Some(timer) = timer.as_deref_mut() {
+        timer.mark("input");
+    }
+    let output = deadline::wait_with_output(child, deadline)?;
+    if let Some(timer) = timer {
+        timer.mark("execute");
+    }
+    let stderr = match follower {
+        Some(follower) => follower.join().unwrap_or_default(),
+        None => secrets::redact(&String::from_utf8_lossy(&output.stderr)),
+    };
+
+    // Exits 2 and 3 are the SDK's invalid-input and panic statuses: stdout
+    // still carries a ToolResult.
+    if !guest_wrote_result(output.status) {
+        return Err(wasmtime_failure(&stderr));
+    }
+
+    Ok((output.stdout, stderr))
+}
+
+/// The error for a `wasmtime run` that left no result: a [`trap::GuestTrap`]
+/// when the tool trapped, or wasmtime's stderr.
+fn wasmtime_failure(stderr: &str) -> anyhow::Error {
+    match trap::GuestTrap::parse(stderr) {
+        Some(trap) => trap.into(),
+        None => anyhow::anyhow!("wasmtime exited with error:\n{stderr}"),
+    }
+}
 
-/// Run a WASM tool locally using the system `wasmtime` CLI binary.
-///
-/// Looks for `tool.wasm` inside `skill_path/tools/<tool_name>/` (installed layout)
-/// OR directly as `skill_path/tool.wasm` (dev layout — right after build).
-pub fn test_skill_locally(
-    skill_path: &std::path::Path,
-    tool_name: Option<&str>,
-    args_json: &str,
-) -> Result<()> {
-    // Resolve .wasm path
-    let wasm_path = resolve_wasm_path(skill_path, tool_name)?;
+/// For [`GuestLog::Follow`], take the child's stderr and print each line on
+/// this process's stderr as the tool writes it, redacted. The thread returns
+/// everything it read once the tool closes stderr.
+fn follow_stderr(
+    child: &mut std::process::Child,
+    log: GuestLog,
+) -> Option<std::thread::JoinHandle<String>> {
+    use std::io::BufRead;
 
-    // Validate JSON args
-    let _: serde_json::Value = serde_json::from_str(args_json)
-        .with_context(|| format!("--args is not valid JSON: {args_json}"))?;
+    if log != GuestLog::Follow {
+        return None;
+    }
+    let stderr = child.stderr.take()?;
+    Some(std::thread::spawn(move || {
+        let mut seen = String::new();
+        for line in std::io::BufReader::new(stderr).lines() {
+            let Ok(line) = line else { break };
+            let line = secrets::redact(&line);
+            eprintln!("\r\x1b[2K  {} {line}", console::style("│").dim());
+            seen.push_str(&line);
+            seen.push('\n');
+        }
+        seen
+    }))
+}
 
-    println!(
-        "  Running: {} {}",
-        console::style("wasmtime").cyan(),
-        wasm_path.display()
+/// Key of a progress line written by a streaming tool (see the Go SDK's
+/// `skill.ProgressField`).
+const PROGRESS_FIELD: &str = "progress";
+
+/// One progress event from a streaming tool.
+#[derive(Debug, Clone, PartialEq, Deserialize)]
+struct ProgressEvent {
+    percent: f64,
+    #[serde(default)]
+    message: Option<String>,
+}
+
+/// Parse `line` as a progress line, or `None` if it is anything else.
+fn progress_event(line: &str) -> Option<ProgressEvent> {
+    let mut value: serde_json::Value = serde_json::from_str(line).ok()?;
+    serde_json::from_value(value.get_mut(PROGRESS_FIELD)?.take()).ok()
+}
+
+/// Render `event` as a one-line progress bar, e.g. `[######--------------]  30% step 3`.
+fn render_progress(event: &ProgressEvent) -> String {
+    const WIDTH: usize = 20;
+    let percent = event.percent.clamp(0.0, 100.0);
+    // Truncation is intended: a cell fills only once its share is complete.
+    #[allow(clippy::cast_possible_truncation, clippy::cast_sign_loss)]
+    let filled = (percent / 100.0 * WIDTH as f64) as usize;
+    let mut bar = format!(
+        "[{}{}] {:>3.0}%",
+        "#".repeat(filled),
+        "-".repeat(WIDTH - filled),
+        percent
     );
-    println!("  Input:   {args_json}");
-    println!();
+    if let Some(message) = event.message.as_deref().filter(|m| !m.is_empty()) {
+        bar.push(' ');
+        bar.push_str(message);
+    }
+    bar
+}
 
-    // Run via wasmtime CLI (captures stdout as tool output)
-    let output = std::process::Command::new("wasmtime")
+/// Like [`run_wasm_logged`] for a tool whose manifest sets
+/// `"streaming": true`: stdout is read line by line as the tool writes it,
+/// progress lines are drawn as a bar on stderr when `show` is set, and the
+/// remaining lines — the `ToolResult` — are returned with stderr. A tool
+/// still writing after `timeout` is killed, as by [`run_wasm_logged`].
+fn run_wasm_streaming(
+    wasm_path: &Path,
+    wasmtime_args: &[String],
+    stdin_data: &str,
+    show: bool,
+    log: GuestLog,
+    timeout: Option<Duration>,
+) -> Result<(String, String)> {
+    use std::io::Write;
+
+    let mut child = std::process::Command::new("wasmtime")
         .arg("run")
-        .arg(&wasm_path)
+        .args(wasmtime_args)
+        .arg(wasm_path)
         .stdin(std::process::Stdio::piped())
         .stdout(std::process::Stdio::piped())
         .stderr(std::process::Stdio::piped())
         .spawn()
-        .context(
-            "wasmtime not found — install it first:\n\n\
-             \x20 macOS (Homebrew):  brew install wasmtime\n\
-             \x20 macOS/Linux:       curl https://wasmtime.dev/install.sh -sSf | bash\n\
-             \x20 Cargo (slow):      cargo install wasmtime-cli\n\n\
-             After installing, restart your terminal and run this command again.\n\
-             Docs: https://wasmtime.dev",
-        )
-        .and_then(|mut child| {
-            use std::io::Write;
-            // take() moves stdin out so it is dropped (closed) at end of block,
-            // sending EOF to the child process — required for read_to_string to return.
-            if let Some(mut stdin) = child.stdin.take() {
-                stdin.write_all(args_json.as_bytes())?;
-                // stdin dropped here → EOF sent
-            }
-            child.wait_with_output().map_err(anyhow::Error::from)
-        })?;
+        .context(WASMTIME_NOT_FOUND)?;
+    let deadline = deadline::Deadline::start(timeout);
+    let follower = follow_stderr(&mut child, log);
+    if let Some(mut stdin) = child.stdin.take() {
+        stdin.write_all(stdin_data.as_bytes())?;
+    }
+
+    let mut result = String::new();
+    let mut drawn = false;
+    let stdout = child
+        .stdout
+        .take()
+        .context("wasmtime stdout was not captured")?;
+    let lines = deadline::read_lines(stdout);
+    loop {
+        let line = match deadline::next_line(&lines, deadline.as_ref()) {
+            Ok(Some(line)) => secrets::redact(&line),
+            Ok(None) => break,
+            Err(err) => {
+                let _ = child.kill();
+                let _ = child.wait();
+                if drawn {
+                    eprintln!();
+                }
+                return Err(err);
+            }
+        };
+        match progress_event(&line) {
+            Some(event) => {
+                if show {
+                    eprint!("\r\x1b[2K  {}", render_progress(&event));
+                    drawn = true;
+                }
+            }
+            None => {
+                result.push_str(&line);
+                result.push('\n');
+            }
+        }
+    }
+    if drawn {
+        eprintln!();
+    }
 
-    if !output.status.success() {
-        let stderr = String::from_utf8_lossy(&output.stderr);
-        anyhow::bail!("wasmtime exited with error:\n{stderr}");
+    let output = child.wait_with_output()?;
+    let stderr = match follower {
+        Some(follower) => follower.join().unwrap_or_default(),
+        None => secrets::redact(&String::from_utf8_lossy(&output.stderr)),
+    };
+    if !guest_wrote_result(output.status) {
+        return Err(wasmtime_failure(&stderr));
     }
+    Ok((result, stderr))
+}
 
-    let stdout = String::from_utf8_lossy(&output.stdout);
-    println!("{}", stdout);
+/// Resolve a `skill test`-style path argument to a skill directory.
+///
+/// Relative paths resolve against the current directory; a bare name that does
+/// not exist locally falls back to the installed skills directory.
+fn resolve_skill_path(path: &str, workspace_dir: &Path) -> Result<PathBuf> {
+    let skill_path = Path::new(path);
+    let skill_path = if skill_path.is_absolute() {
+        skill_path.to_path_buf()
+    } else {
+        std::env::current_dir()
+            .unwrap_or_else(|_| workspace_dir.to_path_buf())
+            .join(skill_path)
+    };
 
-    // Pretty-print if valid JSON
-    match serde_json::from_str::<serde_json::Value>(&stdout) {
-        Ok(v) => {
-            println!();
-            let success = v.get("success").and_then(|s| s.as_bool()).unwrap_or(false);
-            if success {
-                println!(
-                    "  {} Tool returned success",
-                    console::style("✓").green().bold()
-                );
-            } else {
-                let err = v.get("error").and_then(|e| e.as_str()).unwrap_or("unknown");
-                println!(
-                    "  {} Tool returned failure: {err}",
-                    console::style("✗").red().bold()
-                );
-            }
-        }
-        Err(_) => {
-            // stdout is not JSON — show as-is (maybe the tool printed plain text)
-        }
+    // If `path` is just a skill name, resolve from installed skills dir
+    let skill_path = if !skill_path.exists() && !path.contains('/') && !path.contains('\\') {
+        skills_dir(workspace_dir).join(path)
+    } else {
+        skill_path
+    };
+
+    if !skill_path.exists() {
+        anyhow::bail!(
+            "Skill path not found: {}\n\
+             Tip: run from the skill directory or pass an absolute path.",
+            skill_path.display()
+        );
     }
 
-    Ok(())
+    Ok(skill_path)
+}
+
+/// The guest argv for `skill test --tool NAME` when the skill is one
+/// `tool.wasm`: a Go router (`skill.Router.Dispatch`) takes its tool from
+/// there when stdin holds only the args. A tool installed in its own
+/// `tools/NAME/` module is given none.
+fn tool_argv<'a>(skill_path: &Path, wasm_path: &Path, tool_name: Option<&'a str>) -> Vec<&'a str> {
+    match tool_name {
+        Some(name) if wasm_path == skill_path.join("tool.wasm") => vec![name],
+        _ => Vec::new(),
+    }
 }
 
 /// Find the `.wasm` file for a skill directory.
@@ -1571,6 +3195,14 @@ fn clawhub_download_url(source: &str) -> Result<String> {
 // the name, version, and author fields are read from it. Otherwise the skill
 // name is derived from the URL's last path segment.
 
+/// Returns true if `source` is a local `.zcskill` package file.
+fn is_package_source(source: &str) -> bool {
+    let path = Path::new(source);
+    path.extension().map_or(false, |e| {
+        e.eq_ignore_ascii_case(package::PACKAGE_EXTENSION)
+    }) && path.is_file()
+}
+
 /// Returns true if `source` should be handled as a zip-URL download.
 fn is_zip_url_source(source: &str) -> bool {
     // Explicit `zip:https://...` prefix
@@ -1841,13 +3473,32 @@ fn fetch_url_blocking(url: &str, auth_token: Option<&str>) -> Result<Vec<u8>> {
 
 // ─── Handle command ───────────────────────────────────────────────────────────
 
-/// Handle the `skills` CLI command
+/// Handle the `skills` CLI command, printing as much as `verbosity` asks
 #[allow(clippy::too_many_lines)]
-pub fn handle_command(command: crate::SkillCommands, config: &crate::config::Config) -> Result<()> {
+pub fn handle_command(
+    command: crate::SkillCommands,
+    config: &crate::config::Config,
+    verbosity: Verbosity,
+) -> Result<()> {
     let workspace_dir = &config.workspace_dir;
     match command {
-        crate::SkillCommands::New { name, template } => {
+        crate::SkillCommands::New {
+            name,
+            template,
+            lang,
+        } => {
             let dest = std::env::current_dir().unwrap_or_else(|_| workspace_dir.clone());
+            let template = match lang {
+                Some(lang) => templates::find_language(&lang)
+                    .ok_or_else(|| {
+                        anyhow::anyhow!(
+                            "Unknown language '{lang}'. Supported: typescript, rust, go, python, js"
+                        )
+                    })?
+                    .name
+                    .to_string(),
+                None => template,
+            };
 
             scaffold_skill(&name, &template, &dest)
                 .with_context(|| format!("failed to scaffold skill '{name}'"))?;
@@ -1885,8 +3536,11 @@ pub fn handle_command(command: crate::SkillCommands, config: &crate::config::Con
                     println!("    cargo build --target wasm32-wasip1 --release");
                     println!("    cp target/wasm32-wasip1/release/*.wasm tool.wasm");
                 }
+                "javascript" => {
+                    println!("    npm run build   # → tool.wasm (requires javy)");
+                }
                 "go" => {
-                    println!("    tinygo build -o tool.wasm -target wasi .");
+                    println!("    zeroclaw skill build   # → tool.wasm (tinygo -target=wasip1)");
                 }
                 "python" => {
                     println!("    pip install componentize-py");
@@ -1918,37 +3572,377 @@ pub fn handle_command(command: crate::SkillCommands, config: &crate::config::Con
             Ok(())
         }
 
-        crate::SkillCommands::Test { path, tool, args } => {
-            let skill_path = std::path::Path::new(&path);
-            let skill_path = if skill_path.is_absolute() {
-                skill_path.to_path_buf()
-            } else {
-                std::env::current_dir()
-                    .unwrap_or_else(|_| workspace_dir.clone())
-                    .join(skill_path)
+        crate::SkillCommands::Test {
+            path,
+            tool,
+            args,
+            pretty,
+            field,
+            cases,
+            parallel,
+            json,
+            format,
+            no_color,
+            preopen,
+            jsonl,
+            check_output,
+            strict_utf8,
+            secret,
+            secret_file,
+            interactive,
+            follow,
+            compress,
+            canonical,
+            ignore_fields,
+            stable_output,
+            filter,
+            explain_timing,
+            timeout,
+            native,
+            extract,
+            out,
+        } => {
+            if follow && verbosity != Verbosity::Verbose {
+                anyhow::bail!("--follow needs --verbose");
+            }
+            if verbosity == Verbosity::Verbose && (cases.is_some() || jsonl || interactive) {
+                anyhow::bail!("--verbose does not apply to --cases, --jsonl, or --interactive");
+            }
+            let extract = extract
+                .zip(out)
+                .map(|(name, out)| artifacts::Extract { name, out });
+            let guest = GuestOptions {
+                preopens: preopen
+                    .iter()
+                    .map(|spec| preopen::Preopen::parse(spec))
+                    .collect::<Result<Vec<_>>>()?,
+                strict_utf8,
+                secrets: read_secret_flags(&secret, secret_file.as_deref())?,
+                compress,
+                timeout: (!timeout.is_zero()).then_some(timeout),
+                pretty: false,
             };
-
-            // If `path` is just a skill name, resolve from installed skills dir
-            let skill_path = if !skill_path.exists() && !path.contains('/') && !path.contains('\\')
-            {
-                skills_dir(workspace_dir).join(&path)
-            } else {
-                skill_path
+            let report = match format.as_deref() {
+                Some("csv") => CasesReport::Csv,
+                Some("json") => CasesReport::Json,
+                _ if json => CasesReport::Json,
+                _ => CasesReport::Text {
+                    color: !no_color && console::colors_enabled(),
+                },
             };
+            if let Some(root) = suite::recursive_root(&path) {
+                if native {
+                    anyhow::bail!("--native runs one skill; drop it for a 'dir/...' path");
+                }
+                let root = resolve_skill_path(root, workspace_dir)?;
+                return test_suite_locally(&root, filter.as_deref(), &guest, report, verbosity);
+            }
+            let skill_path = resolve_skill_path(&path, workspace_dir)?;
+            if let Some(cases) = cases {
+                return test_cases_locally(
+                    &skill_path,
+                    tool.as_deref(),
+                    &cases,
+                    parallel,
+                    &guest,
+                    report,
+                    verbosity,
+                );
+            }
+            let timing = match format.as_deref() {
+                _ if !explain_timing => None,
+                Some("csv") => anyhow::bail!("--format csv does not apply to --explain-timing"),
+                Some("json") => Some(TimingReport::Meta),
+                _ if json => Some(TimingReport::Meta),
+                _ => Some(TimingReport::Table),
+            };
+            if (json || format.is_some()) && timing.is_none() {
+                anyhow::bail!(
+                    "--json and --format need --cases, a 'dir/...' path, or --explain-timing"
+                );
+            }
+            if timing == Some(TimingReport::Meta) && (field.is_some() || pretty || canonical) {
+                anyhow::bail!(
+                    "--explain-timing --json prints the whole result; drop --field, --pretty, and --canonical"
+                );
+            }
+            if interactive {
+                return test_interactive_locally(&skill_path, tool.as_deref(), pretty);
+            }
+            if jsonl || (args.is_none() && declares_jsonl(&skill_path, tool.as_deref())) {
+                return test_jsonl_locally(&skill_path, tool.as_deref(), &guest, verbosity);
+            }
+            let args_json = args.as_deref().unwrap_or("{\"input\":\"test\"}");
+            // One result printed as it is, to a person, may as well be indented.
+            let guest = GuestOptions {
+                pretty: std::io::IsTerminal::is_terminal(&std::io::stdout()),
+                ..guest
+            };
+            let output = match field {
+                Some(field) => TestOutput::Field(field),
+                None if canonical => TestOutput::Canonical(ignore_fields),
+                None if pretty => TestOutput::Pretty,
+                None => TestOutput::Raw,
+            };
+            if native {
+                return test_native_locally(
+                    &skill_path,
+                    args_json,
+                    &output,
+                    &guest,
+                    stable_output,
+                    verbosity,
+                    extract.as_ref(),
+                )
+                .with_context(|| format!("skill test failed for {}", skill_path.display()));
+            }
 
-            if !skill_path.exists() {
+            test_skill_locally(
+                &skill_path,
+                tool.as_deref(),
+                args_json,
+                &output,
+                &guest,
+                check_output,
+                stable_output,
+                verbosity,
+                follow,
+                timing,
+                extract.as_ref(),
+            )
+            .with_context(|| format!("skill test failed for {}", skill_path.display()))?;
+
+            Ok(())
+        }
+
+        crate::SkillCommands::Serve {
+            path,
+            tool,
+            socket,
+            metrics_addr,
+            health_addr,
+            http_addr,
+            pool_size,
+            queue_size,
+        } => {
+            let addrs = serve::Addrs {
+                socket,
+                metrics: metrics_addr,
+                health: health_addr,
+                http: http_addr,
+            };
+            if addrs.socket.is_none()
+                && addrs.metrics.is_none()
+                && addrs.health.is_none()
+                && addrs.http.is_none()
+            {
                 anyhow::bail!(
-                    "Skill path not found: {}\n\
-                     Tip: run from the skill directory or pass an absolute path.",
-                    skill_path.display()
+                    "nothing to serve on: pass --socket, --http-addr, --metrics-addr, or --health-addr"
                 );
             }
+            let skill_path = resolve_skill_path(&path, workspace_dir)?;
+            serve_skill(
+                &skill_path,
+                tool.as_deref(),
+                &addrs,
+                pool_size,
+                queue_size,
+                verbosity,
+            )
+        }
+
+        #[cfg(unix)]
+        crate::SkillCommands::Ping { socket, timeout } => {
+            let elapsed = serve::ping(&socket, timeout)?;
+            println!(
+                "  {} {} answered in {} ms",
+                console::style("✓").green().bold(),
+                socket.display(),
+                elapsed.as_millis()
+            );
+            Ok(())
+        }
+
+        #[cfg(not(unix))]
+        crate::SkillCommands::Ping { .. } => anyhow::bail!("skill ping needs a Unix platform"),
+
+        crate::SkillCommands::Pipe {
+            stages,
+            args,
+            map,
+            retries,
+            retry_backoff,
+        } => {
+            let maps = map
+                .iter()
+                .map(|spec| pipe::FieldMap::parse(spec))
+                .collect::<Result<Vec<_>>>()?;
+            let wasm_paths = stages
+                .iter()
+                .map(|stage| resolve_wasm_path(&resolve_skill_path(stage, workspace_dir)?, None))
+                .collect::<Result<Vec<_>>>()?;
+            let policies = wasm_paths
+                .iter()
+                .map(|path| {
+                    let manifest = read_manifest_value(path);
+                    pipe::RetryPolicy::for_manifest(retries, retry_backoff, manifest.as_ref())
+                        .with_context(|| format!("{}", path.display()))
+                })
+                .collect::<Result<Vec<_>>>()?;
 
             let args_json = args.as_deref().unwrap_or("{\"input\":\"test\"}");
+            let initial: serde_json::Value = serde_json::from_str(args_json)
+                .with_context(|| format!("--args is not valid JSON: {args_json}"))?;
+
+            let quiet = verbosity == Verbosity::Quiet;
+            let outcome = pipe::run_pipeline(wasm_paths.len(), initial, &maps, |stage, input| {
+                if !quiet {
+                    println!(
+                        "  Stage {}: {} {}",
+                        stage + 1,
+                        console::style("wasmtime").cyan(),
+                        wasm_paths[stage].display()
+                    );
+                    println!(
+                        "  Input:   {}",
+                        shown_args(&wasm_paths[stage], &input.to_string())
+                    );
+                }
+                let started = std::time::Instant::now();
+                let result = pipe::run_with_retries(
+                    &policies[stage],
+                    || run_wasm_tool(&wasm_paths[stage], input),
+                    |wait| {
+                        if !quiet {
+                            println!("  Retrying stage {} in {wait:?}", stage + 1);
+                        }
+                        std::thread::sleep(wait);
+                    },
+                );
+                if verbosity == Verbosity::Verbose {
+                    println!("  Time:    {} ms", started.elapsed().as_millis());
+                }
+                result
+            })?;
+
+            match outcome {
+                pipe::PipeOutcome::Completed(result) if quiet => {
+                    println!("{result}");
+                    Ok(())
+                }
+                pipe::PipeOutcome::Completed(result) => {
+                    println!();
+                    println!("{result}");
+                    println!();
+                    println!(
+                        "  {} Pipeline completed ({} stages)",
+                        console::style("✓").green().bold(),
+                        wasm_paths.len()
+                    );
+                    Ok(())
+                }
+                pipe::PipeOutcome::Failed { stage, error } => {
+                    anyhow::bail!(
+                        "pipeline stopped at stage {} ({}): {error}",
+                        stage + 1,
+                        stages[stage]
+                    )
+                }
+            }
+        }
+
+        crate::SkillCommands::Replay { recording, skill } => {
+            let raw = std::fs::read_to_string(&recording)
+                .with_context(|| format!("failed to read {}", recording.display()))?;
+            let records = replay::parse_recording(&raw)
+                .with_context(|| format!("{} is not a recording", recording.display()))?;
+
+            let mut changed = 0;
+            for (i, record) in records.iter().enumerate() {
+                let source = skill.as_deref().unwrap_or(&record.name);
+                let wasm_path =
+                    resolve_wasm_path(&resolve_skill_path(source, workspace_dir)?, None)?;
+                let args_json = record.args.to_string();
+                let stdout = if manifest_flag(&wasm_path, "streaming") {
+                    run_wasm_streaming(&wasm_path, &[], &args_json, false, GuestLog::Hidden, None)?
+                        .0
+                } else {
+                    run_wasm_tool(&wasm_path, &args_json)?
+                };
+                let result: serde_json::Value =
+                    serde_json::from_str(stdout.trim()).with_context(|| {
+                        format!(
+                            "record {}: {} did not print a JSON ToolResult",
+                            i + 1,
+                            wasm_path.display()
+                        )
+                    })?;
+
+                let changes = replay::diff_results(&record.result, &result);
+                if !changes.is_empty() {
+                    changed += 1;
+                }
+                let label = format!("#{} {} {args_json}", i + 1, record.name);
+                for line in verdict_lines(verbosity, &label, &changes) {
+                    println!("{line}");
+                }
+                if verbosity == Verbosity::Verbose {
+                    println!("      ran:    {}", wasm_path.display());
+                }
+            }
+            if verbosity != Verbosity::Quiet {
+                println!();
+            }
+
+            if changed > 0 {
+                anyhow::bail!("{changed} of {} recorded result(s) changed", records.len());
+            }
+            println!(
+                "  {} All {} recorded result(s) unchanged",
+                console::style("✓").green().bold(),
+                records.len()
+            );
+            Ok(())
+        }
 
-            test_skill_locally(&skill_path, tool.as_deref(), args_json)
-                .with_context(|| format!("skill test failed for {}", skill_path.display()))?;
+        crate::SkillCommands::Package { path, output, sign } => {
+            let skill_path = resolve_skill_path(&path, workspace_dir)?;
+            let signer = sign.as_deref().map(package::load_signing_key).transpose()?;
+            let (header, bytes) = package::build_package(&skill_path, signer.as_ref())
+                .with_context(|| format!("failed to package {}", skill_path.display()))?;
+            let output = output.unwrap_or_else(|| {
+                PathBuf::from(format!("{}.{}", header.name, package::PACKAGE_EXTENSION))
+            });
+            std::fs::write(&output, &bytes)
+                .with_context(|| format!("failed to write {}", output.display()))?;
+
+            for line in package_lines(verbosity, &header, &output, bytes.len(), signer.is_some()) {
+                println!("{line}");
+            }
+            Ok(())
+        }
 
+        crate::SkillCommands::Index {
+            path,
+            output,
+            format,
+        } => {
+            let root = suite::recursive_root(&path).unwrap_or(&path);
+            let root = resolve_skill_path(root, workspace_dir)?;
+            let index = index::build_index(&root)?;
+            let text = match format.as_deref() {
+                Some("markdown") => index::render_markdown(&index),
+                _ => format!("{}\n", serde_json::to_string_pretty(&index)?),
+            };
+            let Some(output) = output else {
+                print!("{text}");
+                return Ok(());
+            };
+            std::fs::write(&output, text)
+                .with_context(|| format!("failed to write {}", output.display()))?;
+            for line in index_lines(verbosity, &index, &output) {
+                println!("{line}");
+            }
             Ok(())
         }
 
@@ -1962,34 +3956,235 @@ pub fn handle_command(command: crate::SkillCommands, config: &crate::config::Con
                 println!();
                 println!("  Or install: zeroclaw skills install <source>");
             } else {
-                println!("Installed skills ({}):", skills.len());
-                println!();
-                for skill in &skills {
-                    println!(
-                        "  {} {} — {}",
-                        console::style(&skill.name).white().bold(),
-                        console::style(format!("v{}", skill.version)).dim(),
-                        skill.description
-                    );
-                    if !skill.tools.is_empty() {
-                        println!(
-                            "    Tools: {}",
-                            skill
-                                .tools
-                                .iter()
-                                .map(|t| t.name.as_str())
-                                .collect::<Vec<_>>()
-                                .join(", ")
-                        );
-                    }
-                    if !skill.tags.is_empty() {
-                        println!("    Tags:  {}", skill.tags.join(", "));
-                    }
+                println!("Installed skills ({}):", skills.len());
+                println!();
+                for skill in &skills {
+                    println!(
+                        "  {} {} — {}",
+                        console::style(&skill.name).white().bold(),
+                        console::style(format!("v{}", skill.version)).dim(),
+                        skill.description
+                    );
+                    if !skill.tools.is_empty() {
+                        println!(
+                            "    Tools: {}",
+                            skill
+                                .tools
+                                .iter()
+                                .map(|t| t.name.as_str())
+                                .collect::<Vec<_>>()
+                                .join(", ")
+                        );
+                    }
+                    if !skill.tags.is_empty() {
+                        println!("    Tags:  {}", skill.tags.join(", "));
+                    }
+                }
+            }
+            println!();
+            Ok(())
+        }
+        crate::SkillCommands::Diff {
+            old,
+            new,
+            cases: cases_path,
+            ignore_fields,
+        } => {
+            let resolve = |source: &str| -> Result<PathBuf> {
+                let path = Path::new(source);
+                if path.is_file() {
+                    return Ok(path.to_path_buf());
+                }
+                resolve_wasm_path(&resolve_skill_path(source, workspace_dir)?, None)
+            };
+            let old_path = resolve(&old)?;
+            let new_path = resolve(&new)?;
+            for line in comparison_header(verbosity, &old_path, &new_path) {
+                println!("{line}");
+            }
+            let fixtures = cases::load_cases(&cases_path)?;
+            let result = |wasm_path: &Path, args_json: &str| -> Result<serde_json::Value> {
+                let stdout = if manifest_flag(wasm_path, "streaming") {
+                    run_wasm_streaming(wasm_path, &[], args_json, false, GuestLog::Hidden, None)?.0
+                } else {
+                    run_wasm_tool(wasm_path, args_json)?
+                };
+                serde_json::from_str(stdout.trim()).with_context(|| {
+                    format!("{} did not print a JSON ToolResult", wasm_path.display())
+                })
+            };
+
+            let (mut compared, mut differ) = (0, 0);
+            for case in &fixtures {
+                if case.skip {
+                    if verbosity != Verbosity::Quiet {
+                        println!("  {} {} (skipped)", console::style("-").dim(), case.name);
+                    }
+                    continue;
+                }
+                compared += 1;
+                let args_json = case.args.to_string();
+                let changes = output_diff::diff_outputs(
+                    &result(&old_path, &args_json)
+                        .with_context(|| format!("case {:?}", case.name))?,
+                    &result(&new_path, &args_json)
+                        .with_context(|| format!("case {:?}", case.name))?,
+                    &ignore_fields,
+                );
+                if !changes.is_empty() {
+                    differ += 1;
+                }
+                for line in verdict_lines(verbosity, &case.name, &changes) {
+                    println!("{line}");
+                }
+            }
+            if verbosity != Verbosity::Quiet {
+                println!();
+            }
+
+            if differ > 0 {
+                anyhow::bail!("{differ} of {compared} case(s) differ from {old} to {new}");
+            }
+            println!(
+                "  {} All {compared} case(s) agree",
+                console::style("✓").green().bold()
+            );
+            Ok(())
+        }
+        crate::SkillCommands::SchemaDiff {
+            old,
+            new,
+            allow_additive,
+        } => {
+            let resolve = |source: &str| -> Result<PathBuf> {
+                let path = Path::new(source);
+                if path.is_file() {
+                    return Ok(path.to_path_buf());
+                }
+                resolve_wasm_path(&resolve_skill_path(source, workspace_dir)?, None)
+            };
+            let (old_path, new_path) = (resolve(&old)?, resolve(&new)?);
+            for line in comparison_header(verbosity, &old_path, &new_path) {
+                println!("{line}");
+            }
+            let old_schema = wasm_args_schema(&old_path)?;
+            let new_schema = wasm_args_schema(&new_path)?;
+
+            let changes = schema_diff::diff_schemas(&old_schema, &new_schema);
+            if changes.is_empty() {
+                println!(
+                    "  {} Schemas are identical",
+                    console::style("✓").green().bold()
+                );
+                return Ok(());
+            }
+            for change in &changes {
+                let marker = if change.is_breaking() {
+                    console::style("breaking").red().bold()
+                } else {
+                    console::style("additive").yellow()
+                };
+                println!("  {change}  [{marker}]");
+            }
+            if verbosity != Verbosity::Quiet {
+                println!();
+            }
+
+            let breaking = changes.iter().filter(|c| c.is_breaking()).count();
+            if breaking > 0 {
+                anyhow::bail!("{breaking} breaking schema change(s) from {old} to {new}");
+            }
+            if !allow_additive {
+                anyhow::bail!(
+                    "schema changed ({} additive change(s)); pass --allow-additive to accept",
+                    changes.len()
+                );
+            }
+            println!(
+                "  {} Only additive changes",
+                console::style("✓").green().bold()
+            );
+            Ok(())
+        }
+
+        crate::SkillCommands::Build {
+            path,
+            compiler,
+            opt,
+            version,
+            target,
+            clean,
+        } => {
+            let options = build::Options {
+                compiler: compiler
+                    .as_deref()
+                    .map(build::Compiler::parse)
+                    .transpose()?,
+                opt,
+                version,
+                target: build::Target::parse(&target)?,
+                clean,
+            };
+            let built = build::build(&path, &options)
+                .with_context(|| format!("failed to build {}", path.display()))?;
+            println!(
+                "  {} Built {} ({}) with {}: {} bytes",
+                console::style("✓").green().bold(),
+                path.join(build::OUTPUT).display(),
+                built.language.name(),
+                built.toolchain,
+                built.size
+            );
+            if verbosity != Verbosity::Quiet {
+                println!(
+                    "    sha256: {} (sealed in {})",
+                    built.sha256,
+                    build::CHECKSUM
+                );
+            }
+            Ok(())
+        }
+
+        crate::SkillCommands::Validate {
+            path,
+            args,
+            warn_undocumented,
+        } => {
+            let skill_path = resolve_skill_path(&path, workspace_dir)?;
+            let normalized = validate_skill_args(&skill_path, &args, warn_undocumented)?;
+            eprintln!(
+                "  {} Args accepted by {}",
+                console::style("✓").green().bold(),
+                skill_path.display()
+            );
+            println!("{normalized}");
+            Ok(())
+        }
+
+        crate::SkillCommands::Describe { path } => {
+            let skill_path = resolve_skill_path(&path, workspace_dir)?;
+            describe_skill(&skill_path)
+        }
+
+        crate::SkillCommands::Inspect { path, capabilities } => {
+            let skill_path = resolve_skill_path(&path, workspace_dir)?;
+            if capabilities {
+                inspect_capabilities(&skill_path)
+            } else {
+                inspect_skill(&skill_path)
+            }
+        }
+
+        crate::SkillCommands::Migrate { path } => {
+            let skill_path = resolve_skill_path(&path, workspace_dir)?;
+            for (manifest_path, from) in migrate_skill_manifests(&skill_path)? {
+                if let Some(line) = migrate_line(verbosity, &manifest_path, from) {
+                    println!("{line}");
                 }
             }
-            println!();
             Ok(())
         }
+
         crate::SkillCommands::Audit { source } => {
             let source_path = PathBuf::from(&source);
             let target = if source_path.exists() {
@@ -2028,10 +4223,23 @@ pub fn handle_command(command: crate::SkillCommands, config: &crate::config::Con
             }
             anyhow::bail!("Skill audit failed.");
         }
-        crate::SkillCommands::Install { source } => {
-            println!("Installing skill from: {source}");
+        crate::SkillCommands::Install { source, verify } => {
+            let trusted = verify
+                .as_deref()
+                .map(package::load_trusted_keys)
+                .transpose()?;
+            if trusted.is_some() && !is_package_source(&source) {
+                anyhow::bail!(
+                    "--verify only applies to .{} packages; {source} is not one",
+                    package::PACKAGE_EXTENSION
+                );
+            }
 
             let skills_path = skills_dir(workspace_dir);
+            for line in install_header(verbosity, &source, &skills_path) {
+                println!("{line}");
+            }
+            let quiet = verbosity == Verbosity::Quiet;
             std::fs::create_dir_all(&skills_path)?;
 
             if is_clawhub_source(&source) {
@@ -2047,7 +4255,9 @@ pub fn handle_command(command: crate::SkillCommands, config: &crate::config::Con
                     installed_dir.display(),
                     files_written
                 );
-                println!("  Run 'zeroclaw skill list' to verify the new tools are available.");
+                if !quiet {
+                    println!("{INSTALL_HINT}");
+                }
             } else if is_zip_url_source(&source) {
                 // Generic zip-URL install: supports `zip:https://...` prefix and
                 // direct `.zip` URLs.  No system `unzip` binary required.
@@ -2061,7 +4271,9 @@ pub fn handle_command(command: crate::SkillCommands, config: &crate::config::Con
                     installed_dir.display(),
                     files_written
                 );
-                println!("  Run 'zeroclaw skill list' to verify the new tools are available.");
+                if !quiet {
+                    println!("{INSTALL_HINT}");
+                }
             } else if is_git_source(&source) {
                 let (installed_dir, files_scanned) =
                     install_git_skill_source(&source, &skills_path, config.skills.allow_scripts)
@@ -2072,7 +4284,9 @@ pub fn handle_command(command: crate::SkillCommands, config: &crate::config::Con
                     installed_dir.display(),
                     files_scanned
                 );
-                println!("  Security audit completed successfully.");
+                if !quiet {
+                    println!("  Security audit completed successfully.");
+                }
             } else if is_registry_source(&source) {
                 // ZeroMarket (or compatible) registry: `namespace/name[@version]`
                 let registry_url = &config.wasm.registry_url;
@@ -2085,16 +4299,39 @@ pub fn handle_command(command: crate::SkillCommands, config: &crate::config::Con
                     installed_dir.display(),
                     files_written
                 );
-                println!("  Run 'zeroclaw skill list' to verify the new tools are available.");
+                if !quiet {
+                    println!("{INSTALL_HINT}");
+                }
             } else {
-                // Check if source is a local .zip file before falling back to directory install
+                // Check for a local .zcskill package or .zip file before falling back to directory install
                 let source_path = std::path::Path::new(&source);
                 let is_local_zip = source_path
                     .extension()
                     .map_or(false, |e| e.eq_ignore_ascii_case("zip"))
                     && source_path.is_file();
 
-                if is_local_zip {
+                if is_package_source(&source) {
+                    let (dest, files_written) =
+                        package::install_package(source_path, &skills_path, trusted.as_deref())
+                            .with_context(|| {
+                                format!("failed to install skill package: {source}")
+                            })?;
+                    println!(
+                        "  {} Skill package verified and installed: {} ({} files written)",
+                        console::style("✓").green().bold(),
+                        dest.display(),
+                        files_written
+                    );
+                    if trusted.is_some() {
+                        println!(
+                            "  {} Signature matches a trusted key",
+                            console::style("✓").green().bold()
+                        );
+                    }
+                    if !quiet {
+                        println!("{INSTALL_HINT}");
+                    }
+                } else if is_local_zip {
                     let (dest, files_written) = install_local_zip_source(source_path, &skills_path)
                         .with_context(|| format!("failed to install zip skill from: {source}"))?;
                     println!(
@@ -2103,7 +4340,9 @@ pub fn handle_command(command: crate::SkillCommands, config: &crate::config::Con
                         dest.display(),
                         files_written
                     );
-                    println!("  Run 'zeroclaw skill list' to verify the new tools are available.");
+                    if !quiet {
+                        println!("{INSTALL_HINT}");
+                    }
                 } else {
                     let (dest, files_scanned) = install_local_skill_source(
                         &source,
@@ -2117,7 +4356,9 @@ pub fn handle_command(command: crate::SkillCommands, config: &crate::config::Con
                         dest.display(),
                         files_scanned
                     );
-                    println!("  Security audit completed successfully.");
+                    if !quiet {
+                        println!("  Security audit completed successfully.");
+                    }
                 }
             }
 
@@ -2154,6 +4395,8 @@ pub fn handle_command(command: crate::SkillCommands, config: &crate::config::Con
             Ok(())
         }
 
+        crate::SkillCommands::Doctor => doctor::run(),
+
         crate::SkillCommands::Templates => {
             println!("  Available skill templates:\n");
             println!(
@@ -2832,6 +5075,52 @@ description = "Bare minimum"
         assert!(skill_dir.join("SKILL.md").exists(), "SKILL.md missing");
     }
 
+    #[test]
+    fn scaffold_skill_rust_word_count_matches_go_manifest() {
+        let dir = tempfile::tempdir().unwrap();
+        scaffold_skill("zeroclaw_wc_rs", "word_count_rust", dir.path()).unwrap();
+        let skill_dir = dir.path().join("zeroclaw_wc_rs");
+        let main_rs = fs::read_to_string(skill_dir.join("src").join("main.rs")).unwrap();
+        assert!(main_rs.contains(r#"name: "zeroclaw_wc_rs""#));
+
+        // Same parameters and capabilities as the Go template, so either
+        // language can back the same tool.
+        let manifest = |path: &std::path::Path| -> serde_json::Value {
+            let mut m: serde_json::Value =
+                serde_json::from_str(&fs::read_to_string(path).unwrap()).unwrap();
+            m.as_object_mut().unwrap().remove("name");
+            m
+        };
+        assert_eq!(
+            manifest(&skill_dir.join("manifest.json")),
+            manifest(
+                &std::path::Path::new(env!("CARGO_MANIFEST_DIR"))
+                    .join("templates/go/word_count/manifest.json")
+            )
+        );
+    }
+
+    #[test]
+    fn scaffold_skill_js_word_count_via_language_alias() {
+        assert_eq!(
+            templates::find_language("js").map(|t| t.name),
+            Some("word_count_js")
+        );
+        assert!(templates::find_language("cobol").is_none());
+
+        let dir = tempfile::tempdir().unwrap();
+        scaffold_skill("zeroclaw_wc_js", "js", dir.path()).unwrap();
+        let skill_dir = dir.path().join("zeroclaw_wc_js");
+        let index_js = fs::read_to_string(skill_dir.join("src").join("index.js")).unwrap();
+        assert!(index_js.contains("name: 'zeroclaw_wc_js'"));
+        assert!(
+            skill_dir.join("package.json").exists(),
+            "package.json missing"
+        );
+        let readme = fs::read_to_string(skill_dir.join("README.md")).unwrap();
+        assert!(readme.contains("javy"), "README should name the toolchain");
+    }
+
     #[test]
     fn scaffold_skill_substitutes_name_placeholder() {
         let dir = tempfile::tempdir().unwrap();
@@ -2858,6 +5147,53 @@ description = "Bare minimum"
             "manifest.json missing"
         );
         assert!(skill_dir.join("SKILL.md").exists(), "SKILL.md missing");
+
+        // The in-repo SDK replace directive must not leak into scaffolded projects.
+        let go_mod = fs::read_to_string(skill_dir.join("go.mod")).unwrap();
+        assert!(go_mod.contains("module zeroclaw_test_go"));
+        assert!(go_mod.contains("require github.com/zeroclaw-labs/zeroclaw/sdk/go"));
+        assert!(
+            !go_mod.contains("replace"),
+            "dev-only replace leaked: {go_mod}"
+        );
+    }
+
+    #[test]
+    fn scaffold_skill_go_http_fetch_declares_net() {
+        let dir = tempfile::tempdir().unwrap();
+        scaffold_skill("zeroclaw_fetch", "http_fetch", dir.path()).unwrap();
+        let skill_dir = dir.path().join("zeroclaw_fetch");
+        let manifest: serde_json::Value =
+            serde_json::from_str(&fs::read_to_string(skill_dir.join("manifest.json")).unwrap())
+                .unwrap();
+        assert_eq!(manifest["capabilities"]["net"], serde_json::json!(true));
+        let main_go = fs::read_to_string(skill_dir.join("main.go")).unwrap();
+        assert!(main_go.contains(r#"skill.Requires("net")"#));
+    }
+
+    #[test]
+    fn scaffold_skill_go_progress_demo_declares_streaming() {
+        let dir = tempfile::tempdir().unwrap();
+        scaffold_skill("zeroclaw_progress", "progress_demo", dir.path()).unwrap();
+        let skill_dir = dir.path().join("zeroclaw_progress");
+        assert!(manifest_flag(&skill_dir.join("tool.wasm"), "streaming"));
+        let main_go = fs::read_to_string(skill_dir.join("main.go")).unwrap();
+        assert!(main_go.contains("skill.Meta{Streaming: true}"));
+        assert!(main_go.contains("ctx.Err()"));
+    }
+
+    #[test]
+    fn scaffold_skill_go_image_info_takes_base64() {
+        let dir = tempfile::tempdir().unwrap();
+        scaffold_skill("zeroclaw_image", "image_info", dir.path()).unwrap();
+        let skill_dir = dir.path().join("zeroclaw_image");
+        let manifest: serde_json::Value =
+            serde_json::from_str(&fs::read_to_string(skill_dir.join("manifest.json")).unwrap())
+                .unwrap();
+        let data = &manifest["parameters"]["properties"]["data_base64"];
+        assert_eq!(data["contentEncoding"], "base64");
+        let main_go = fs::read_to_string(skill_dir.join("main.go")).unwrap();
+        assert!(main_go.contains("Data []byte `json:\"data_base64\""));
     }
 
     #[test]
@@ -2987,6 +5323,444 @@ description = "Bare minimum"
         assert_eq!(normalize_skill_name("skill.v1"), "skillv1");
         assert_eq!(normalize_skill_name("skill@1.0.0"), "skill100");
     }
+
+    // ── skill test output ─────────────────────────────────────────────────────
+
+    const WORD_COUNT_RESULT: &str =
+        "{\"success\":true,\"output\":\"2 words\",\"data\":{\"words\":2,\"unit\":\"word\"}}\n";
+
+    #[test]
+    fn render_usage_marks_peak_memory_unavailable() {
+        let usage = render_usage(std::time::Duration::from_millis(12), 14, 52);
+        assert_eq!(usage, "12 ms, 14 bytes in, 52 bytes out, peak memory n/a");
+    }
+
+    #[test]
+    fn tool_argv_names_the_tool_of_a_router_module() {
+        let skill = Path::new("skills/text");
+        let router = skill.join("tool.wasm");
+        assert_eq!(tool_argv(skill, &router, Some("count")), ["count"]);
+        assert!(tool_argv(skill, &router, None).is_empty());
+        let installed = skill.join("tools").join("count").join("tool.wasm");
+        assert!(tool_argv(skill, &installed, Some("count")).is_empty());
+    }
+
+    #[test]
+    fn quiet_prints_only_the_result_and_verbose_adds_diagnostics() {
+        assert_eq!(Verbosity::from_flags(false, false), Verbosity::Normal);
+        assert_eq!(Verbosity::from_flags(true, false), Verbosity::Quiet);
+        assert_eq!(Verbosity::from_flags(false, true), Verbosity::Verbose);
+
+        let guest = GuestOptions {
+            preopens: vec![preopen::Preopen {
+                host: PathBuf::from("./docs"),
+                guest: "/data".to_string(),
+            }],
+            secrets: vec![secrets::Secret::parse("API_KEY=sk-123").unwrap()],
+            timeout: Some(Duration::from_secs(60)),
+            ..GuestOptions::default()
+        };
+        let warned = "{\"success\":true,\"output\":\"2 words\",\"warnings\":[\"bom stripped\"]}";
+        let usage = "12 ms, 14 bytes in, 52 bytes out, peak memory n/a";
+
+        assert!(run_header(Verbosity::Quiet, "wasmtime tool.wasm", "{}", &guest).is_empty());
+        assert!(run_footer(Verbosity::Quiet, usage, warned).is_empty());
+
+        assert_eq!(
+            run_header(Verbosity::Normal, "wasmtime tool.wasm", "{}", &guest),
+            ["  Running: wasmtime tool.wasm", "  Input:   {}"]
+        );
+        assert!(run_footer(Verbosity::Normal, usage, warned).is_empty());
+
+        let header = run_header(Verbosity::Verbose, "wasmtime tool.wasm", "{}", &guest);
+        assert_eq!(
+            header[2..],
+            [
+                format!("  Grant:   fs {} → /data", Path::new("./docs").display()),
+                "  Grant:   secrets API_KEY".to_string(),
+                "  Grant:   deadline 60s".to_string(),
+            ]
+        );
+        assert!(!header.join("\n").contains("sk-123"));
+        let footer = run_footer(Verbosity::Verbose, usage, warned);
+        assert_eq!(footer[0], format!("  Usage:   {usage}"));
+        assert!(footer[1].contains("bom stripped"), "{footer:?}");
+
+        // The other commands keep their result line under --quiet and drop
+        // the rest.
+        let changes = ["/output: \"2 words\" → \"3 words\"".to_string()];
+        assert!(verdict_lines(Verbosity::Quiet, "#1 word_count", &[]).is_empty());
+        assert_eq!(
+            verdict_lines(Verbosity::Quiet, "#1 word_count", &changes).len(),
+            2
+        );
+        assert_eq!(
+            verdict_lines(Verbosity::Normal, "#1 word_count", &[]).len(),
+            1
+        );
+        let (old, new) = (Path::new("old/tool.wasm"), Path::new("new/tool.wasm"));
+        assert!(comparison_header(Verbosity::Normal, old, new).is_empty());
+        assert_eq!(
+            comparison_header(Verbosity::Verbose, old, new),
+            [
+                format!("  Old:     {}", old.display()),
+                format!("  New:     {}", new.display()),
+            ]
+        );
+
+        let header = package::PackageHeader {
+            format: 1,
+            name: "word_count".to_string(),
+            version: "1.0.0".to_string(),
+            content_sha256: "abc123".to_string(),
+            files: vec![package::PackageFile {
+                path: "tool.wasm".to_string(),
+                sha256: "def456".to_string(),
+            }],
+        };
+        let output = Path::new("word_count.zcskill");
+        let quiet = package_lines(Verbosity::Quiet, &header, output, 42, true);
+        assert_eq!(quiet.len(), 1);
+        assert!(quiet[0].contains("Packaged word_count v1.0.0"), "{quiet:?}");
+        let normal = package_lines(Verbosity::Normal, &header, output, 42, true);
+        assert_eq!(normal[1..3], ["    sha256: abc123", "    signed: ed25519"]);
+        assert!(normal.last().unwrap().contains("zeroclaw skill install"));
+        let verbose = package_lines(Verbosity::Verbose, &header, output, 42, true);
+        assert!(verbose.contains(&"    file:   tool.wasm def456".to_string()));
+
+        let index = index::Index {
+            format: 1,
+            skills: vec![index::IndexEntry {
+                name: "word_count".to_string(),
+                version: "1.0.0".to_string(),
+                description: String::new(),
+                capabilities: serde_json::json!({}),
+                sha256: None,
+                path: "word_count".to_string(),
+            }],
+        };
+        let output = Path::new("index.json");
+        assert_eq!(index_lines(Verbosity::Quiet, &index, output).len(), 1);
+        assert_eq!(
+            index_lines(Verbosity::Verbose, &index, output)[1],
+            "    word_count v1.0.0 (word_count)"
+        );
+
+        let manifest = Path::new("manifest.json");
+        assert!(migrate_line(Verbosity::Quiet, manifest, None).is_none());
+        assert!(migrate_line(Verbosity::Quiet, manifest, Some(1)).is_some());
+        assert!(migrate_line(Verbosity::Normal, manifest, None).is_some());
+
+        let skills = Path::new("skills");
+        assert!(install_header(Verbosity::Quiet, "./word_count", skills).is_empty());
+        assert_eq!(
+            install_header(Verbosity::Normal, "./word_count", skills),
+            ["Installing skill from: ./word_count"]
+        );
+        assert_eq!(
+            install_header(Verbosity::Verbose, "./word_count", skills)[1],
+            format!("  Into:    {}", skills.display())
+        );
+    }
+
+    #[test]
+    fn format_tool_output_raw_is_unchanged() {
+        assert_eq!(
+            format_tool_output(WORD_COUNT_RESULT, &TestOutput::Raw).unwrap(),
+            WORD_COUNT_RESULT.trim_end()
+        );
+    }
+
+    #[test]
+    fn format_tool_output_pretty_reindents_json() {
+        let pretty = format_tool_output(WORD_COUNT_RESULT, &TestOutput::Pretty).unwrap();
+        assert_eq!(
+            pretty,
+            "{\n  \"data\": {\n    \"unit\": \"word\",\n    \"words\": 2\n  },\n  \"output\": \"2 words\",\n  \"success\": true\n}"
+        );
+        assert!(format_tool_output("plain text", &TestOutput::Pretty).is_err());
+    }
+
+    #[test]
+    fn format_tool_output_canonical_drops_ignored_fields() {
+        let result = r#"{"success":true,"output":"2 words","meta":{"trace_id":"a1"}}"#;
+        let output = TestOutput::Canonical(vec!["meta".to_string()]);
+        assert_eq!(
+            format_tool_output(result, &output).unwrap(),
+            "{\n  \"output\": \"2 words\",\n  \"success\": true\n}"
+        );
+    }
+
+    #[test]
+    fn format_tool_output_extracts_single_field() {
+        let field = |path: &str| TestOutput::Field(path.to_string());
+        assert_eq!(
+            format_tool_output(WORD_COUNT_RESULT, &field("data.words")).unwrap(),
+            "2"
+        );
+        // Strings print unquoted so shell scripts can use them directly.
+        assert_eq!(
+            format_tool_output(WORD_COUNT_RESULT, &field("data.unit")).unwrap(),
+            "word"
+        );
+        assert_eq!(
+            format_tool_output(WORD_COUNT_RESULT, &field("data")).unwrap(),
+            "{\"unit\":\"word\",\"words\":2}"
+        );
+        let err = format_tool_output(WORD_COUNT_RESULT, &field("data.lines")).unwrap_err();
+        assert!(err.to_string().contains("data.lines"));
+    }
+
+    #[test]
+    fn exit_codes_distinguish_success_failure_and_harness_errors() {
+        assert!(check_tool_result(WORD_COUNT_RESULT).is_ok());
+
+        let failed = check_tool_result(r#"{"success":false,"output":"","error":"boom"}"#)
+            .unwrap_err()
+            .context("skill test failed for ./word_count");
+        assert_eq!(exit_code(&failed), EXIT_TOOL_FAILURE);
+
+        let coded = check_tool_result(
+            r#"{"success":false,"output":"","error":"bad","error_code":"invalid_input"}"#,
+        )
+        .unwrap_err();
+        assert_eq!(exit_code(&coded), 11);
+        assert_eq!(
+            coded.to_string(),
+            "tool returned failure: bad [invalid_input]"
+        );
+
+        let unknown = check_tool_result(
+            r#"{"success":false,"output":"","error":"x","error_code":"made_up"}"#,
+        )
+        .unwrap_err();
+        assert_eq!(exit_code(&unknown), EXIT_TOOL_FAILURE);
+
+        let dir = tempfile::tempdir().unwrap();
+        let missing = test_skill_locally(
+            dir.path(),
+            None,
+            "{}",
+            &TestOutput::Raw,
+            &GuestOptions::default(),
+            false,
+            false,
+            Verbosity::Normal,
+            false,
+            None,
+            None,
+        )
+        .unwrap_err();
+        assert_eq!(exit_code(&missing), EXIT_HARNESS_ERROR);
+    }
+
+    #[cfg(unix)]
+    #[test]
+    fn follow_stderr_collects_lines_as_the_child_writes_them() {
+        let mut child = std::process::Command::new("sh")
+            .args(["-c", "echo started >&2; echo done >&2"])
+            .stderr(std::process::Stdio::piped())
+            .spawn()
+            .unwrap();
+        assert!(follow_stderr(&mut child, GuestLog::After).is_none());
+        let follower = follow_stderr(&mut child, GuestLog::Follow).unwrap();
+        child.wait().unwrap();
+        assert_eq!(follower.join().unwrap(), "started\ndone\n");
+    }
+
+    #[test]
+    fn validate_skill_args_applies_defaults_without_a_module() {
+        let dir = tempfile::tempdir().unwrap();
+        std::fs::write(
+            dir.path().join("manifest.json"),
+            r#"{"name":"word_count","parameters":{"type":"object","required":["text"],"properties":{"text":{"type":"string"},"trim":{"type":"string","enum":["none","edges"],"default":"none"}}}}"#,
+        )
+        .unwrap();
+
+        let normalized = validate_skill_args(dir.path(), r#"{"text":"hi"}"#, false).unwrap();
+        assert_eq!(normalized, r#"{"text":"hi","trim":"none"}"#);
+
+        let err = validate_skill_args(dir.path(), r#"{"trim":"all"}"#, false).unwrap_err();
+        assert_eq!(exit_code(&err), 11);
+        let message = err.to_string();
+        assert!(message.contains("args.text: missing required field"));
+        assert!(message.contains("args.trim: \"all\" is not one of the allowed values"));
+
+        let bad_json = validate_skill_args(dir.path(), "{", false).unwrap_err();
+        assert_eq!(exit_code(&bad_json), EXIT_HARNESS_ERROR);
+    }
+
+    #[test]
+    fn check_tool_result_fails_on_unsuccessful_result() {
+        assert!(check_tool_result(WORD_COUNT_RESULT).is_ok());
+        assert!(check_tool_result("plain text").is_ok());
+        let err =
+            check_tool_result("{\"success\":false,\"output\":\"\",\"error\":\"missing text\"}")
+                .unwrap_err();
+        assert_eq!(err.to_string(), "tool returned failure: missing text");
+    }
+
+    #[test]
+    fn probe_tool_names_reads_a_probe_answer() {
+        let probe = r#"{"success":true,"output":"probe","data":{"tools":[{"name":"echo","schema":{}},{"name":"count","schema":{}}],"permissions":[]}}"#;
+        assert_eq!(probe_tool_names(probe).unwrap(), ["echo", "count"]);
+        assert_eq!(
+            probe_tool_names(r#"{"success":true,"output":"probe","data":{"tools":[]}}"#).unwrap(),
+            Vec::<String>::new()
+        );
+        assert!(probe_tool_names(WORD_COUNT_RESULT).is_none());
+        assert!(probe_tool_names("plain text").is_none());
+    }
+
+    #[test]
+    fn result_warnings_lists_the_warnings_of_a_result() {
+        assert!(result_warnings(WORD_COUNT_RESULT).is_empty());
+        assert!(result_warnings("plain text").is_empty());
+        let warned = "{\"success\":true,\"output\":\"2 words\",\"warnings\":[\"bom stripped\"]}";
+        assert_eq!(result_warnings(warned), ["bom stripped"]);
+    }
+
+    #[test]
+    fn check_output_contract_reads_output_schema_file() {
+        let dir = tempfile::tempdir().unwrap();
+        let wasm = dir.path().join("tool.wasm");
+        std::fs::write(
+            dir.path().join(output_check::OUTPUT_SCHEMA_FILE),
+            r#"{"type":"object","required":["words"],"properties":{"words":{"type":"integer"}}}"#,
+        )
+        .unwrap();
+
+        assert!(check_output_contract(&wasm, WORD_COUNT_RESULT).is_ok());
+        let err = check_output_contract(
+            &wasm,
+            r#"{"success":true,"output":"","data":{"words":"two"}}"#,
+        )
+        .unwrap_err();
+        assert!(err
+            .to_string()
+            .contains("data.words: expected integer, got string"));
+        assert_eq!(exit_code(&err), EXIT_HARNESS_ERROR);
+
+        // A failed result is judged by check_tool_result, not the contract.
+        assert!(
+            check_output_contract(&wasm, r#"{"success":false,"output":"","error":"x"}"#).is_ok()
+        );
+    }
+
+    #[test]
+    fn migrate_rewrites_old_manifests_in_place() {
+        let dir = tempfile::tempdir().unwrap();
+        let manifest = dir.path().join("manifest.json");
+        fs::write(&manifest, r#"{"name":"wc","jsonl":true}"#).unwrap();
+
+        let migrated = migrate_skill_manifests(dir.path()).unwrap();
+        assert_eq!(migrated, vec![(manifest.clone(), Some(1))]);
+        let rewritten: serde_json::Value =
+            serde_json::from_str(&fs::read_to_string(&manifest).unwrap()).unwrap();
+        assert_eq!(
+            rewritten,
+            serde_json::json!({"input": "ndjson", "name": "wc", "schema_version": 2})
+        );
+        assert!(declares_jsonl(dir.path(), None));
+
+        // A current manifest is left as it was written.
+        let current = r#"{"schema_version":2,"name":"wc"}"#;
+        fs::write(&manifest, current).unwrap();
+        assert_eq!(
+            migrate_skill_manifests(dir.path()).unwrap(),
+            vec![(manifest.clone(), None)]
+        );
+        assert_eq!(fs::read_to_string(&manifest).unwrap(), current);
+
+        fs::write(&manifest, r#"{"schema_version":9,"name":"wc"}"#).unwrap();
+        let err = migrate_skill_manifests(dir.path()).unwrap_err();
+        assert!(
+            format!("{err:#}").contains("newer than this zeroclaw"),
+            "{err:#}"
+        );
+    }
+
+    #[test]
+    fn skill_test_input_hides_secret_args() {
+        let dir = tempfile::tempdir().unwrap();
+        let wasm = dir.path().join("tool.wasm");
+        let args = r#"{"city":"Oslo","api_key":"sk-live-123"}"#;
+        assert_eq!(shown_args(&wasm, args), args);
+
+        std::fs::write(
+            dir.path().join("manifest.json"),
+            r#"{"name":"weather","parameters":{"type":"object","properties":{"city":{"type":"string"},"api_key":{"type":"string","x-zeroclaw-secret":true}}}}"#,
+        )
+        .unwrap();
+        assert_eq!(
+            shown_args(&wasm, args),
+            r#"{"api_key":"***","city":"Oslo"}"#
+        );
+    }
+
+    #[test]
+    fn manifest_jsonl_flag_selects_jsonl_mode() {
+        let dir = tempfile::tempdir().unwrap();
+        assert!(!declares_jsonl(dir.path(), None));
+
+        std::fs::write(dir.path().join("tool.wasm"), b"\0asm").unwrap();
+        std::fs::write(dir.path().join("manifest.json"), r#"{"name":"wc"}"#).unwrap();
+        assert!(!declares_jsonl(dir.path(), None));
+
+        std::fs::write(
+            dir.path().join("manifest.json"),
+            r#"{"name":"wc","jsonl":true}"#,
+        )
+        .unwrap();
+        assert!(declares_jsonl(dir.path(), None));
+
+        std::fs::write(
+            dir.path().join("manifest.json"),
+            r#"{"name":"wc","input":"ndjson"}"#,
+        )
+        .unwrap();
+        assert!(declares_jsonl(dir.path(), None));
+
+        std::fs::write(
+            dir.path().join("manifest.json"),
+            r#"{"name":"wc","input":"json"}"#,
+        )
+        .unwrap();
+        assert!(!declares_jsonl(dir.path(), None));
+    }
+
+    #[test]
+    fn progress_lines_are_told_apart_from_results() {
+        assert_eq!(
+            progress_event(r#"{"progress":{"percent":40,"message":"4 of 10"}}"#),
+            Some(ProgressEvent {
+                percent: 40.0,
+                message: Some("4 of 10".into())
+            })
+        );
+        assert_eq!(
+            progress_event(r#"{"progress":{"percent":100}}"#).map(|e| e.percent),
+            Some(100.0)
+        );
+        assert_eq!(progress_event(r#"{"success":true,"output":"done"}"#), None);
+        assert_eq!(progress_event("not json"), None);
+    }
+
+    #[test]
+    fn progress_bar_fills_with_percent() {
+        let bar = |percent, message: Option<&str>| {
+            render_progress(&ProgressEvent {
+                percent,
+                message: message.map(Into::into),
+            })
+        };
+        assert_eq!(bar(0.0, None), "[--------------------]   0%");
+        assert_eq!(
+            bar(45.0, Some("step 9")),
+            "[#########-----------]  45% step 9"
+        );
+        assert_eq!(bar(250.0, None), "[####################] 100%");
+    }
 }
 
 #[cfg(test)]
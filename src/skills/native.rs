@@ -0,0 +1,161 @@
+//! `skill test --native`: run a Go skill as a host binary instead of wasm.
+//!
+//! A plain `go build` for the host is much quicker than a TinyGo build, which
+//! makes it the faster loop while working on a skill's logic. The binary
+//! speaks the same protocol as `tool.wasm`, args on stdin and one ToolResult
+//! on stdout, but nothing sandboxes it: it reads and writes the host's files
+//! and network as the user who runs it. Check a skill as `tool.wasm` before
+//! shipping it.
+
+use super::build::Language;
+use super::{deadline, doctor, PRETTY_ENV, STRICT_UTF8_ENV};
+use anyhow::{bail, Context, Result};
+use std::hash::{Hash, Hasher};
+use std::path::{Path, PathBuf};
+use std::process::{Command, Stdio};
+use std::time::Duration;
+
+/// Compile the Go skill in `dir` for the host, into the temp directory, and
+/// return the binary's path.
+pub fn build(dir: &Path) -> Result<PathBuf> {
+    let language = Language::detect(dir)?;
+    if language != Language::Go {
+        bail!(
+            "--native runs Go skills built with `go build`; this is a {} skill",
+            language.name()
+        );
+    }
+    if doctor::command_stdout("go", &["version"]).is_none() {
+        bail!("--native builds with the standard Go toolchain, and `go` is not installed");
+    }
+    let binary = std::env::temp_dir().join(binary_name(dir));
+    let output = Command::new("go")
+        .args(["build", "-o"])
+        .arg(&binary)
+        .arg(".")
+        .current_dir(dir)
+        .env_remove("GOOS")
+        .env_remove("GOARCH")
+        .output()
+        .context("failed to run go build")?;
+    if !output.status.success() {
+        bail!(
+            "go build failed in {}:\n{}",
+            dir.display(),
+            String::from_utf8_lossy(&output.stderr)
+        );
+    }
+    Ok(binary)
+}
+
+/// `zeroclaw-native-<dir>-<hash>`, so skills in different directories with
+/// the same name do not overwrite each other's binary.
+fn binary_name(dir: &Path) -> String {
+    let dir = dir.canonicalize().unwrap_or_else(|_| dir.to_path_buf());
+    let mut hasher = std::collections::hash_map::DefaultHasher::new();
+    dir.hash(&mut hasher);
+    let name = dir.file_name().and_then(|n| n.to_str()).unwrap_or("skill");
+    format!(
+        "zeroclaw-native-{name}-{:08x}{}",
+        hasher.finish() as u32,
+        std::env::consts::EXE_SUFFIX
+    )
+}
+
+/// Run `binary` once with `stdin_data` on stdin and return its stdout and
+/// stderr. The binary gets none of this process's environment, only what
+/// `wasmtime run` would pass a module. A run still going after `timeout` is
+/// killed and fails with [`deadline::DeadlineExceeded`].
+pub fn run(
+    binary: &Path,
+    stdin_data: &[u8],
+    strict_utf8: bool,
+    pretty: bool,
+    timeout: Option<Duration>,
+) -> Result<(Vec<u8>, Vec<u8>)> {
+    let mut cmd = Command::new(binary);
+    cmd.env_clear();
+    if strict_utf8 {
+        cmd.env(STRICT_UTF8_ENV, "1");
+    }
+    if pretty {
+        cmd.env(PRETTY_ENV, "1");
+    }
+    let mut child = cmd
+        .stdin(Stdio::piped())
+        .stdout(Stdio::piped())
+        .stderr(Stdio::piped())
+        .spawn()
+        .with_context(|| format!("failed to run {}", binary.display()))?;
+    let deadline = deadline::Deadline::start(timeout);
+    if let Some(mut stdin) = child.stdin.take() {
+        use std::io::Write;
+        stdin.write_all(stdin_data)?;
+    }
+    let output = deadline::wait_with_output(child, deadline)?;
+    if !super::guest_wrote_result(output.status) {
+        bail!(
+            "{} exited with {}:\n{}",
+            binary.display(),
+            output.status,
+            String::from_utf8_lossy(&output.stderr)
+        );
+    }
+    Ok((output.stdout, output.stderr))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn only_go_skills_run_natively() {
+        let dir = tempfile::tempdir().unwrap();
+        std::fs::write(
+            dir.path().join("Cargo.toml"),
+            "[package]\nname = \"calc\"\n",
+        )
+        .unwrap();
+        let err = build(dir.path()).unwrap_err().to_string();
+        assert!(err.contains("this is a Rust skill"), "{err}");
+    }
+
+    #[test]
+    fn binary_name_tells_same_named_dirs_apart() {
+        let a = binary_name(Path::new("/skills/a/word_count"));
+        let b = binary_name(Path::new("/skills/b/word_count"));
+        assert!(a.starts_with("zeroclaw-native-word_count-"), "{a}");
+        assert_ne!(a, b);
+    }
+
+    /// Builds the Go `word_count` template for the host and runs it; skipped
+    /// where `go` is not installed.
+    #[test]
+    fn go_template_runs_natively() {
+        if doctor::command_stdout("go", &["version"]).is_none() {
+            eprintln!("skipping: go is not installed");
+            return;
+        }
+        let template = Path::new(env!("CARGO_MANIFEST_DIR")).join("templates/go/word_count");
+        let binary = build(&template).unwrap();
+
+        let args = br#"{"text":"hello wide world"}"#;
+        let (stdout, _) = run(&binary, args, false, false, None).unwrap();
+        let result: serde_json::Value = serde_json::from_slice(&stdout).unwrap();
+        assert_eq!(result["success"], true, "{result}");
+        assert_eq!(result["data"]["words"], 3);
+
+        // ZEROCLAW_PRETTY changes the layout, not the result.
+        let (pretty, _) = run(&binary, args, false, true, None).unwrap();
+        assert!(!stdout.contains(&b'\n') && pretty.contains(&b'\n'));
+        assert_eq!(
+            serde_json::from_slice::<serde_json::Value>(&pretty).unwrap(),
+            result
+        );
+
+        // Rejected input exits 2, which still leaves a result.
+        let (stdout, _) = run(&binary, b"not json", false, false, None).unwrap();
+        let result: serde_json::Value = serde_json::from_slice(&stdout).unwrap();
+        assert_eq!(result["error_code"], "invalid_input", "{result}");
+    }
+}
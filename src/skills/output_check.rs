@@ -0,0 +1,189 @@
+//! `zeroclaw skill test --check-output` — hold a skill's `data` to its output
+//! contract.
+//!
+//! The contract is `output.schema.json` next to `tool.wasm`, or, failing that,
+//! the schema the module prints for `--output-schema` (see the Go SDK's
+//! `skill.OutputFor`). Only the JSON Schema keywords the SDK emits are checked:
+//! `type`, `properties`, `required`, `items`, `additionalProperties` (as a
+//! schema), `enum`, `minimum`, and `maximum`.
+
+use serde_json::Value;
+
+/// File name of a skill's declared output schema.
+pub const OUTPUT_SCHEMA_FILE: &str = "output.schema.json";
+
+/// Guest flag that makes an SDK-built module print its output schema.
+pub const OUTPUT_SCHEMA_FLAG: &str = "--output-schema";
+
+/// List every way `data` breaks `schema`, as `<path>: <problem>` lines.
+/// An empty list means the data conforms.
+pub fn check_output(schema: &Value, data: &Value) -> Vec<String> {
+    check_value("data", schema, data)
+}
+
+/// Like [`check_output`], with paths rooted at `root` instead of `data`.
+pub fn check_value(root: &str, schema: &Value, value: &Value) -> Vec<String> {
+    let mut violations = Vec::new();
+    check(root, schema, value, &mut violations);
+    violations
+}
+
+fn check(path: &str, schema: &Value, value: &Value, violations: &mut Vec<String>) {
+    if let Some(expected) = schema.get("type") {
+        let allowed: Vec<&str> = match expected {
+            Value::String(t) => vec![t.as_str()],
+            Value::Array(ts) => ts.iter().filter_map(Value::as_str).collect(),
+            _ => Vec::new(),
+        };
+        if !allowed.is_empty() && !allowed.iter().any(|t| has_type(value, t)) {
+            violations.push(format!(
+                "{path}: expected {}, got {}",
+                allowed.join(" or "),
+                type_of(value)
+            ));
+            return;
+        }
+    }
+
+    if let Some(options) = schema.get("enum").and_then(Value::as_array) {
+        if !options.contains(value) {
+            violations.push(format!("{path}: {value} is not one of the allowed values"));
+        }
+    }
+
+    if let Some(n) = value.as_f64() {
+        let bound = |key: &str| schema.get(key).and_then(Value::as_f64);
+        if let Some(min) = bound("minimum").filter(|&min| n < min) {
+            violations.push(format!("{path}: must be at least {min}, got {value}"));
+        }
+        if let Some(max) = bound("maximum").filter(|&max| n > max) {
+            violations.push(format!("{path}: must be at most {max}, got {value}"));
+        }
+    }
+
+    match value {
+        Value::Object(fields) => {
+            if let Some(required) = schema.get("required").and_then(Value::as_array) {
+                for name in required.iter().filter_map(Value::as_str) {
+                    if !fields.contains_key(name) {
+                        violations.push(format!("{path}.{name}: missing required field"));
+                    }
+                }
+            }
+            let props = schema.get("properties").and_then(Value::as_object);
+            let extra = schema.get("additionalProperties").filter(|s| s.is_object());
+            for (name, field) in fields {
+                let field_schema = props.and_then(|p| p.get(name)).or(extra);
+                if let Some(field_schema) = field_schema {
+                    check(&format!("{path}.{name}"), field_schema, field, violations);
+                }
+            }
+        }
+        Value::Array(items) => {
+            if let Some(item_schema) = schema.get("items") {
+                for (i, item) in items.iter().enumerate() {
+                    check(&format!("{path}[{i}]"), item_schema, item, violations);
+                }
+            }
+        }
+        _ => {}
+    }
+}
+
+fn has_type(value: &Value, ty: &str) -> bool {
+    match ty {
+        "integer" => value.as_i64().is_some() || value.as_u64().is_some(),
+        "number" => value.is_number(),
+        other => type_of(value) == other,
+    }
+}
+
+fn type_of(value: &Value) -> &'static str {
+    match value {
+        Value::Null => "null",
+        Value::Bool(_) => "boolean",
+        Value::Number(n) if n.is_f64() => "number",
+        Value::Number(_) => "integer",
+        Value::String(_) => "string",
+        Value::Array(_) => "array",
+        Value::Object(_) => "object",
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    fn word_count_output() -> Value {
+        json!({
+            "type": "object",
+            "required": ["words", "lines", "characters"],
+            "properties": {
+                "words": {"type": "integer"},
+                "lines": {"type": "integer"},
+                "characters": {"type": "integer"}
+            }
+        })
+    }
+
+    #[test]
+    fn conforming_data_has_no_violations() {
+        let data = json!({"words": 2, "lines": 1, "characters": 11});
+        assert!(check_output(&word_count_output(), &data).is_empty());
+    }
+
+    #[test]
+    fn missing_and_mistyped_fields_are_reported() {
+        let data = json!({"words": "2", "lines": 1});
+        assert_eq!(
+            check_output(&word_count_output(), &data),
+            vec![
+                "data.characters: missing required field",
+                "data.words: expected integer, got string",
+            ]
+        );
+    }
+
+    #[test]
+    fn minimum_and_maximum_are_checked() {
+        let schema = json!({
+            "type": "object",
+            "properties": {
+                "top_words": {"type": "integer", "minimum": 0},
+                "wpm": {"type": "integer", "minimum": 1, "maximum": 1000}
+            }
+        });
+        assert!(check_output(&schema, &json!({"top_words": 0, "wpm": 1000})).is_empty());
+        assert_eq!(
+            check_output(&schema, &json!({"top_words": -5, "wpm": 1001})),
+            vec![
+                "data.top_words: must be at least 0, got -5",
+                "data.wpm: must be at most 1000, got 1001",
+            ]
+        );
+    }
+
+    #[test]
+    fn nested_arrays_and_enums_are_checked() {
+        let schema = json!({
+            "type": "object",
+            "properties": {
+                "tags": {"type": "array", "items": {"type": "string", "enum": ["a", "b"]}},
+                "ratio": {"type": "number"}
+            }
+        });
+        assert!(check_output(&schema, &json!({"tags": ["a"], "ratio": 1})).is_empty());
+        assert_eq!(
+            check_output(&schema, &json!({"tags": ["a", "c", 3]})),
+            vec![
+                "data.tags[1]: \"c\" is not one of the allowed values",
+                "data.tags[2]: expected string, got integer",
+            ]
+        );
+        assert_eq!(
+            check_output(&schema, &Value::Null),
+            vec!["data: expected object, got null"]
+        );
+    }
+}
@@ -0,0 +1,85 @@
+//! `zeroclaw skill diff` — check that a refactored build still answers as the
+//! old one did.
+//!
+//! Both modules get every case of a `--cases` fixtures file, and their results
+//! are compared on `output` and `data` only, field by field as `skill replay`
+//! compares a result with its recording. The rest of the envelope, such as
+//! `meta` with its trace ID, differs from run to run and is not compared.
+//! Volatile fields inside `data`, such as timings, are declared with
+//! `--ignore-fields data.elapsed_ms` and dropped from both results first.
+
+use super::{canonical, replay};
+use serde_json::{Map, Value};
+
+/// The result fields `skill diff` compares.
+const COMPARED: [&str; 2] = ["output", "data"];
+
+/// List how `new` departs from `old` in [`COMPARED`], without the dotted
+/// paths in `ignore`, e.g. `~ data.words: 2 -> 3`. Empty when they agree.
+pub fn diff_outputs(old: &Value, new: &Value, ignore: &[String]) -> Vec<String> {
+    replay::diff_results(&compared(old, ignore), &compared(new, ignore))
+}
+
+/// The [`COMPARED`] fields of `result`, with `ignore` stripped.
+fn compared(result: &Value, ignore: &[String]) -> Value {
+    let mut fields = Map::new();
+    for name in COMPARED {
+        if let Some(value) = result.get(name) {
+            fields.insert(name.to_string(), value.clone());
+        }
+    }
+    let mut fields = Value::Object(fields);
+    canonical::strip_paths(&mut fields, ignore);
+    fields
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    #[test]
+    fn identical_builds_have_no_diff() {
+        let old = json!({
+            "success": true,
+            "output": "2 words",
+            "data": {"words": 2, "elapsed_ms": 0.4},
+            "meta": {"trace_id": "a1"}
+        });
+        let new = json!({
+            "success": true,
+            "output": "2 words",
+            "data": {"words": 2, "elapsed_ms": 1.7},
+            "meta": {"trace_id": "b2"}
+        });
+        assert!(diff_outputs(&old, &new, &["data.elapsed_ms".to_string()]).is_empty());
+        assert_eq!(
+            diff_outputs(&old, &new, &[]),
+            vec!["~ data.elapsed_ms: 0.4 -> 1.7"]
+        );
+    }
+
+    #[test]
+    fn changed_builds_report_each_field() {
+        let old = json!({
+            "success": true,
+            "output": "3 words",
+            "data": {"words": 3, "lines": 1, "items": [{"id": "x", "n": 1}]}
+        });
+        let new = json!({
+            "success": true,
+            "output": "2 words",
+            "warnings": ["new"],
+            "data": {"words": 2, "items": [{"id": "y", "n": 2}]}
+        });
+        assert_eq!(
+            diff_outputs(&old, &new, &["data.items.id".to_string()]),
+            vec![
+                "~ data.items: [{\"n\":1}] -> [{\"n\":2}]",
+                "- data.lines removed (was 1)",
+                "~ data.words: 3 -> 2",
+                "~ output: \"3 words\" -> \"2 words\"",
+            ]
+        );
+    }
+}
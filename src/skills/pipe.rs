@@ -0,0 +1,379 @@
+//! `zeroclaw skill pipe` — chain WASM skills so each stage's result feeds the next.
+//!
+//! Stage N+1 receives stage N's `data` object as its args (or `{"input": output}`
+//! when a stage returns no structured data). `--map` expressions replace that
+//! default with an explicit set of renamed fields. The pipeline stops at the
+//! first stage that returns `success: false`. `--retries` reruns a stage that
+//! fails with a retryable error code before the pipeline gives up on it.
+
+use anyhow::{anyhow, bail, Context, Result};
+use serde_json::{Map, Value};
+use std::time::Duration;
+
+/// Error codes a stage is retried for when its manifest has no
+/// `retryable_errors` list, as in the Go runtime's `DefaultRetryableErrors`.
+pub const DEFAULT_RETRYABLE_ERRORS: &[&str] = &["internal", "timeout"];
+
+/// The error code that is never retried: bad args fail the same way every time.
+const NEVER_RETRIED: &str = "invalid_input";
+
+/// How a stage that fails with a transient error is rerun.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct RetryPolicy {
+    /// Reruns after the first attempt; zero turns retrying off.
+    pub retries: u32,
+    /// Wait before the first rerun, doubled before each one after.
+    pub backoff: Duration,
+    /// Error codes worth a rerun; never includes `invalid_input`.
+    pub retryable: Vec<String>,
+}
+
+impl RetryPolicy {
+    /// The policy for a stage whose manifest is `manifest`: its
+    /// `retryable_errors`, or [`DEFAULT_RETRYABLE_ERRORS`] when it lists none.
+    pub fn for_manifest(retries: u32, backoff: Duration, manifest: Option<&Value>) -> Result<Self> {
+        let listed = manifest.and_then(|m| m.get("retryable_errors"));
+        let retryable = match listed {
+            None => DEFAULT_RETRYABLE_ERRORS
+                .iter()
+                .map(|c| c.to_string())
+                .collect(),
+            Some(list) => serde_json::from_value::<Vec<String>>(list.clone())
+                .context("manifest retryable_errors must be a list of error codes")?,
+        };
+        if retryable.iter().any(|c| c == NEVER_RETRIED) {
+            bail!("manifest retryable_errors cannot include '{NEVER_RETRIED}'");
+        }
+        Ok(Self {
+            retries,
+            backoff,
+            retryable,
+        })
+    }
+
+    /// Whether `result` failed with one of the retryable error codes.
+    fn retryable(&self, result: &Value) -> bool {
+        if result.get("success").and_then(Value::as_bool) == Some(true) {
+            return false;
+        }
+        let code = result.get("error_code").and_then(Value::as_str);
+        code.is_some_and(|code| code != NEVER_RETRIED && self.retryable.iter().any(|c| c == code))
+    }
+}
+
+/// Run one stage under `policy`: call `run` until its result is not
+/// retryable or the retries are spent, calling `sleep` with the backoff
+/// before each rerun. With retries on, the returned stdout has the attempt
+/// count in `meta.attempts`; stdout that is not a JSON object is returned as is.
+pub fn run_with_retries<F, S>(policy: &RetryPolicy, mut run: F, mut sleep: S) -> Result<String>
+where
+    F: FnMut() -> Result<String>,
+    S: FnMut(Duration),
+{
+    let mut backoff = policy.backoff;
+    let mut attempt = 1;
+    loop {
+        let stdout = run()?;
+        let Ok(Value::Object(mut result)) = serde_json::from_str::<Value>(stdout.trim()) else {
+            return Ok(stdout);
+        };
+        if attempt <= policy.retries && policy.retryable(&Value::Object(result.clone())) {
+            sleep(backoff);
+            backoff *= 2;
+            attempt += 1;
+            continue;
+        }
+        if policy.retries == 0 {
+            return Ok(stdout);
+        }
+        let meta = result
+            .entry("meta")
+            .or_insert_with(|| Value::Object(Map::new()));
+        if let Value::Object(meta) = meta {
+            meta.insert("attempts".into(), attempt.into());
+        }
+        return Ok(Value::Object(result).to_string());
+    }
+}
+
+/// A `--map` rename applied between stages, e.g. `data.words -> count`.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct FieldMap {
+    /// Dotted path into the previous stage's `ToolResult` (e.g. `data.words`).
+    source: Vec<String>,
+    /// Top-level field name in the next stage's args.
+    target: String,
+}
+
+impl FieldMap {
+    /// Parse a `<path> -> <field>` expression.
+    pub fn parse(spec: &str) -> Result<Self> {
+        let Some((source, target)) = spec.split_once("->") else {
+            bail!(
+                "invalid --map '{spec}': expected '<path> -> <field>' (e.g. 'data.words -> count')"
+            );
+        };
+        let source: Vec<String> = source.trim().split('.').map(str::to_string).collect();
+        let target = target.trim();
+        if source.iter().any(String::is_empty) || target.is_empty() || target.contains('.') {
+            bail!(
+                "invalid --map '{spec}': expected '<path> -> <field>' (e.g. 'data.words -> count')"
+            );
+        }
+        Ok(Self {
+            source,
+            target: target.to_string(),
+        })
+    }
+}
+
+/// Outcome of a pipeline run.
+#[derive(Debug, Clone, PartialEq)]
+pub enum PipeOutcome {
+    /// Every stage succeeded; holds the last stage's `ToolResult`.
+    Completed(Value),
+    /// Stage `stage` (0-based) returned `success: false`; later stages did not run.
+    Failed { stage: usize, error: String },
+}
+
+/// Run `stages` skills in order, starting from `args`.
+///
+/// `run_stage(index, args_json)` executes one stage and returns its raw stdout.
+/// Errors from the runner itself (missing wasmtime, trap) abort the pipeline;
+/// a well-formed failure result is reported as [`PipeOutcome::Failed`].
+pub fn run_pipeline<F>(
+    stages: usize,
+    args: Value,
+    maps: &[FieldMap],
+    mut run_stage: F,
+) -> Result<PipeOutcome>
+where
+    F: FnMut(usize, &str) -> Result<String>,
+{
+    if stages == 0 {
+        bail!("skill pipe needs at least one stage");
+    }
+
+    let mut input = args;
+    let mut last = Value::Null;
+    for stage in 0..stages {
+        let stdout = run_stage(stage, &input.to_string())
+            .with_context(|| format!("stage {} failed to run", stage + 1))?;
+        let result: Value = serde_json::from_str(stdout.trim())
+            .with_context(|| format!("stage {} did not return a JSON ToolResult", stage + 1))?;
+
+        if !result
+            .get("success")
+            .and_then(Value::as_bool)
+            .unwrap_or(false)
+        {
+            let error = result
+                .get("error")
+                .and_then(Value::as_str)
+                .unwrap_or("unknown")
+                .to_string();
+            return Ok(PipeOutcome::Failed { stage, error });
+        }
+
+        if stage + 1 < stages {
+            input = next_stage_args(&result, maps)
+                .with_context(|| format!("cannot build args for stage {}", stage + 2))?;
+        }
+        last = result;
+    }
+
+    Ok(PipeOutcome::Completed(last))
+}
+
+/// Build the next stage's args from a successful `ToolResult`.
+fn next_stage_args(result: &Value, maps: &[FieldMap]) -> Result<Value> {
+    if maps.is_empty() {
+        return Ok(match result.get("data") {
+            Some(data) if data.is_object() => data.clone(),
+            _ => {
+                let output = result.get("output").and_then(Value::as_str).unwrap_or("");
+                serde_json::json!({ "input": output })
+            }
+        });
+    }
+
+    let mut args = Map::new();
+    for map in maps {
+        let value = map
+            .source
+            .iter()
+            .try_fold(result, |value, key| value.get(key))
+            .ok_or_else(|| {
+                anyhow!(
+                    "--map source '{}' not found in stage result",
+                    map.source.join(".")
+                )
+            })?;
+        args.insert(map.target.clone(), value.clone());
+    }
+    Ok(Value::Object(args))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    #[test]
+    fn field_map_parses_arrow_expression() {
+        let map = FieldMap::parse(" data.words -> count ").unwrap();
+        assert_eq!(map.source, vec!["data", "words"]);
+        assert_eq!(map.target, "count");
+    }
+
+    #[test]
+    fn field_map_rejects_malformed_expressions() {
+        assert!(FieldMap::parse("data.words").is_err());
+        assert!(FieldMap::parse("data..words -> count").is_err());
+        assert!(FieldMap::parse("data.words -> ").is_err());
+        assert!(FieldMap::parse("data.words -> a.b").is_err());
+    }
+
+    #[test]
+    fn two_stage_pipeline_feeds_data_into_next_stage() {
+        let mut seen = Vec::new();
+        let outcome = run_pipeline(2, json!({"text": "hello world"}), &[], |stage, args| {
+            seen.push(args.to_string());
+            Ok(match stage {
+                0 => r#"{"success":true,"output":"2 words","data":{"words":2}}"#.to_string(),
+                _ => r#"{"success":true,"output":"done"}"#.to_string(),
+            })
+        })
+        .unwrap();
+
+        assert_eq!(seen, vec![r#"{"text":"hello world"}"#, r#"{"words":2}"#]);
+        assert_eq!(
+            outcome,
+            PipeOutcome::Completed(json!({"success": true, "output": "done"}))
+        );
+    }
+
+    #[test]
+    fn first_stage_failure_short_circuits() {
+        let mut calls = 0;
+        let outcome = run_pipeline(3, json!({}), &[], |_, _| {
+            calls += 1;
+            Ok(r#"{"success":false,"output":"","error":"bad input"}"#.to_string())
+        })
+        .unwrap();
+
+        assert_eq!(calls, 1);
+        assert_eq!(
+            outcome,
+            PipeOutcome::Failed {
+                stage: 0,
+                error: "bad input".into()
+            }
+        );
+    }
+
+    #[test]
+    fn field_map_renames_fields_between_stages() {
+        let maps = vec![FieldMap::parse("data.words -> count").unwrap()];
+        let mut second_args = String::new();
+        run_pipeline(2, json!({"text": "a b c"}), &maps, |stage, args| {
+            if stage == 1 {
+                second_args = args.to_string();
+            }
+            Ok(r#"{"success":true,"output":"3 words","data":{"words":3,"lines":1}}"#.to_string())
+        })
+        .unwrap();
+
+        assert_eq!(second_args, r#"{"count":3}"#);
+    }
+
+    #[test]
+    fn stage_without_data_passes_output_as_input() {
+        let result = json!({"success": true, "output": "HELLO"});
+        assert_eq!(
+            next_stage_args(&result, &[]).unwrap(),
+            json!({"input": "HELLO"})
+        );
+    }
+
+    fn policy(retryable: &[&str]) -> RetryPolicy {
+        RetryPolicy {
+            retries: 2,
+            backoff: Duration::from_millis(10),
+            retryable: retryable.iter().map(|c| c.to_string()).collect(),
+        }
+    }
+
+    #[test]
+    fn flaky_stage_is_retried_until_it_succeeds() {
+        let mut calls = 0;
+        let mut waits = Vec::new();
+        let stdout = run_with_retries(
+            &policy(DEFAULT_RETRYABLE_ERRORS),
+            || {
+                calls += 1;
+                Ok(if calls < 3 {
+                    r#"{"success":false,"error":"flaked","error_code":"internal"}"#.to_string()
+                } else {
+                    r#"{"success":true,"output":"ok"}"#.to_string()
+                })
+            },
+            |wait| waits.push(wait),
+        )
+        .unwrap();
+
+        assert_eq!(calls, 3);
+        assert_eq!(
+            waits,
+            [Duration::from_millis(10), Duration::from_millis(20)]
+        );
+        let result: Value = serde_json::from_str(&stdout).unwrap();
+        assert_eq!(result["success"], json!(true));
+        assert_eq!(result["meta"]["attempts"], json!(3));
+    }
+
+    #[test]
+    fn validation_errors_and_unlisted_codes_are_not_retried() {
+        for (code, listed) in [
+            ("invalid_input", &["invalid_input"][..]),
+            ("not_found", &["internal"]),
+        ] {
+            let mut calls = 0;
+            let stdout = run_with_retries(
+                &policy(listed),
+                || {
+                    calls += 1;
+                    Ok(format!(
+                        r#"{{"success":false,"error":"no","error_code":"{code}"}}"#
+                    ))
+                },
+                |_| {},
+            )
+            .unwrap();
+            assert_eq!(calls, 1, "{code}");
+            let result: Value = serde_json::from_str(&stdout).unwrap();
+            assert_eq!(result["meta"]["attempts"], json!(1));
+        }
+    }
+
+    #[test]
+    fn manifest_sets_retryable_errors() {
+        let manifest = json!({"retryable_errors": ["rate_limited"]});
+        let policy = RetryPolicy::for_manifest(1, Duration::ZERO, Some(&manifest)).unwrap();
+        assert_eq!(policy.retryable, ["rate_limited"]);
+        let policy = RetryPolicy::for_manifest(1, Duration::ZERO, None).unwrap();
+        assert_eq!(policy.retryable, DEFAULT_RETRYABLE_ERRORS);
+        let manifest = json!({"retryable_errors": ["invalid_input"]});
+        assert!(RetryPolicy::for_manifest(1, Duration::ZERO, Some(&manifest)).is_err());
+    }
+
+    #[test]
+    fn missing_map_source_is_an_error() {
+        let maps = vec![FieldMap::parse("data.missing -> count").unwrap()];
+        let err = run_pipeline(2, json!({}), &maps, |_, _| {
+            Ok(r#"{"success":true,"output":"","data":{}}"#.to_string())
+        })
+        .unwrap_err();
+        assert!(format!("{err:#}").contains("data.missing"));
+    }
+}
@@ -0,0 +1,173 @@
+//! `skill test --preopen host:guest` — map host directories into a skill's
+//! WASI filesystem.
+//!
+//! A skill declares the guest directories it may be given under
+//! `capabilities.fs` in `manifest.json`; a preopen whose guest path is not at
+//! or beneath one of them is refused. The granted guest directories are passed
+//! to the module in `ZEROCLAW_PREOPENS` so the SDK can reject other paths with
+//! a clear error before WASI does.
+
+use anyhow::{bail, Context, Result};
+use std::path::{Path, PathBuf};
+
+/// Environment variable listing granted guest directories, `:`-separated.
+pub const PREOPENS_ENV: &str = "ZEROCLAW_PREOPENS";
+
+/// A host directory mounted at a guest path.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Preopen {
+    pub host: PathBuf,
+    pub guest: String,
+}
+
+impl Preopen {
+    /// Parse a `<host dir>:<guest dir>` flag value.
+    pub fn parse(spec: &str) -> Result<Self> {
+        let Some((host, guest)) = spec.rsplit_once(':') else {
+            bail!(
+                "invalid --preopen '{spec}': expected '<host dir>:<guest dir>' (e.g. ./docs:/data)"
+            );
+        };
+        if host.is_empty() || !guest.starts_with('/') {
+            bail!("invalid --preopen '{spec}': expected '<host dir>:<guest dir>' with an absolute guest dir");
+        }
+        let host = PathBuf::from(host);
+        if !host.is_dir() {
+            bail!("--preopen host directory not found: {}", host.display());
+        }
+        Ok(Self {
+            host,
+            guest: normalize_guest(guest),
+        })
+    }
+}
+
+/// Guest directories a skill declares under `capabilities.fs`.
+pub fn declared_fs(manifest_path: &Path) -> Result<Vec<String>> {
+    if !manifest_path.exists() {
+        return Ok(Vec::new());
+    }
+    let raw = std::fs::read_to_string(manifest_path)
+        .with_context(|| format!("failed to read {}", manifest_path.display()))?;
+    let manifest: serde_json::Value = serde_json::from_str(&raw)
+        .with_context(|| format!("{} is not valid JSON", manifest_path.display()))?;
+    Ok(manifest
+        .pointer("/capabilities/fs")
+        .and_then(serde_json::Value::as_array)
+        .map(|dirs| {
+            dirs.iter()
+                .filter_map(serde_json::Value::as_str)
+                .map(normalize_guest)
+                .collect()
+        })
+        .unwrap_or_default())
+}
+
+/// Refuse any preopen that is not at or beneath a declared directory.
+pub fn check_preopens(preopens: &[Preopen], declared: &[String]) -> Result<()> {
+    for preopen in preopens {
+        if !declared.iter().any(|dir| is_within(&preopen.guest, dir)) {
+            bail!(
+                "--preopen guest dir {} is not declared in the manifest's capabilities.fs (declared: {})",
+                preopen.guest,
+                if declared.is_empty() {
+                    "none".to_string()
+                } else {
+                    declared.join(", ")
+                }
+            );
+        }
+    }
+    Ok(())
+}
+
+/// `wasmtime run` flags that mount `preopens` and advertise them to the guest.
+pub fn wasmtime_args(preopens: &[Preopen]) -> Vec<String> {
+    if preopens.is_empty() {
+        return Vec::new();
+    }
+    let mut args = Vec::new();
+    for preopen in preopens {
+        args.push("--dir".to_string());
+        args.push(format!("{}::{}", preopen.host.display(), preopen.guest));
+    }
+    let guests: Vec<&str> = preopens.iter().map(|p| p.guest.as_str()).collect();
+    args.push("--env".to_string());
+    args.push(format!("{PREOPENS_ENV}={}", guests.join(":")));
+    args
+}
+
+fn is_within(guest: &str, dir: &str) -> bool {
+    dir == "/" || guest == dir || guest.starts_with(&format!("{dir}/"))
+}
+
+fn normalize_guest(dir: &str) -> String {
+    let trimmed = dir.trim_end_matches('/');
+    if trimmed.is_empty() {
+        "/".to_string()
+    } else {
+        trimmed.to_string()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn parse_splits_on_last_colon() {
+        let dir = tempfile::tempdir().unwrap();
+        let spec = format!("{}:/data/", dir.path().display());
+        let preopen = Preopen::parse(&spec).unwrap();
+        assert_eq!(preopen.host, dir.path());
+        assert_eq!(preopen.guest, "/data");
+
+        assert!(Preopen::parse("/no/such/dir:/data").is_err());
+        assert!(Preopen::parse(&format!("{}:data", dir.path().display())).is_err());
+        assert!(Preopen::parse("/data").is_err());
+    }
+
+    #[test]
+    fn preopens_must_fall_under_declared_fs() {
+        let dir = tempfile::tempdir().unwrap();
+        let manifest = dir.path().join("manifest.json");
+        std::fs::write(&manifest, r#"{"capabilities":{"fs":["/data/"]}}"#).unwrap();
+        let declared = declared_fs(&manifest).unwrap();
+        assert_eq!(declared, vec!["/data"]);
+
+        let grant = |guest: &str| Preopen {
+            host: dir.path().to_path_buf(),
+            guest: guest.to_string(),
+        };
+        assert!(check_preopens(&[grant("/data"), grant("/data/in")], &declared).is_ok());
+        let err = check_preopens(&[grant("/database")], &declared).unwrap_err();
+        assert!(err.to_string().contains("/database is not declared"));
+        assert!(check_preopens(&[grant("/data")], &[]).is_err());
+    }
+
+    #[test]
+    fn wasmtime_args_mount_and_advertise_preopens() {
+        let preopens = [
+            Preopen {
+                host: PathBuf::from("/tmp/docs"),
+                guest: "/data".into(),
+            },
+            Preopen {
+                host: PathBuf::from("/tmp/cache"),
+                guest: "/cache".into(),
+            },
+        ];
+        assert_eq!(
+            wasmtime_args(&preopens),
+            [
+                "--dir",
+                "/tmp/docs::/data",
+                "--dir",
+                "/tmp/cache::/cache",
+                "--env",
+                "ZEROCLAW_PREOPENS=/data:/cache",
+            ]
+        );
+        assert!(wasmtime_args(&[]).is_empty());
+    }
+}
@@ -0,0 +1,137 @@
+//! `zeroclaw skill replay` — rerun recorded invocations against the current
+//! build.
+//!
+//! A recording is a JSON-Lines file of `{"name","args","result"}` records, as
+//! written by the Go runtime's `Config.Recorder`. Each record's `args` is sent
+//! to the skill again and the new result is compared with `result`, field by
+//! field. A field that is `null` on one side and missing on the other is not
+//! a change: SDKs differ in whether they write empty optional fields. The
+//! top-level `meta` object is ignored too; it carries the request's trace ID,
+//! which differs on every run.
+
+use anyhow::{Context, Result};
+use serde::Deserialize;
+use serde_json::Value;
+
+/// One recorded invocation.
+#[derive(Debug, Clone, PartialEq, Deserialize)]
+pub struct Record {
+    /// The skill's manifest name; replay resolves it as an installed skill.
+    pub name: String,
+    pub args: Value,
+    pub result: Value,
+}
+
+/// Parse a recording, skipping blank lines. Errors name the line.
+pub fn parse_recording(raw: &str) -> Result<Vec<Record>> {
+    raw.lines()
+        .enumerate()
+        .filter(|(_, line)| !line.trim().is_empty())
+        .map(|(i, line)| {
+            serde_json::from_str(line)
+                .with_context(|| format!("line {}: not a {{name, args, result}} record", i + 1))
+        })
+        .collect()
+}
+
+/// List how `new` differs from the recorded `old`, sorted by dotted path,
+/// e.g. `~ data.words: 2 -> 3`.
+pub fn diff_results(old: &Value, new: &Value) -> Vec<String> {
+    let mut changes = Vec::new();
+    diff("", &without_meta(old), &without_meta(new), &mut changes);
+    changes
+}
+
+/// `result` without its top-level `meta` object.
+fn without_meta(result: &Value) -> Value {
+    let mut result = result.clone();
+    if let Value::Object(fields) = &mut result {
+        fields.remove("meta");
+    }
+    result
+}
+
+fn diff(path: &str, old: &Value, new: &Value, changes: &mut Vec<String>) {
+    match (old, new) {
+        (Value::Object(old_fields), Value::Object(new_fields)) => {
+            let mut names: Vec<&String> = old_fields.keys().chain(new_fields.keys()).collect();
+            names.sort();
+            names.dedup();
+            for name in names {
+                let field = if path.is_empty() {
+                    name.clone()
+                } else {
+                    format!("{path}.{name}")
+                };
+                match (
+                    old_fields.get(name).filter(|v| !v.is_null()),
+                    new_fields.get(name).filter(|v| !v.is_null()),
+                ) {
+                    (Some(o), Some(n)) => diff(&field, o, n, changes),
+                    (Some(o), None) => changes.push(format!("- {field} removed (was {o})")),
+                    (None, Some(n)) => changes.push(format!("+ {field} added: {n}")),
+                    (None, None) => {}
+                }
+            }
+        }
+        _ if old != new => {
+            let field = if path.is_empty() { "result" } else { path };
+            changes.push(format!("~ {field}: {old} -> {new}"));
+        }
+        _ => {}
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    #[test]
+    fn recordings_parse_line_by_line() {
+        let raw = concat!(
+            r#"{"name":"word_count","args":{"text":"a"},"result":{"success":true,"output":"1 word"}}"#,
+            "\n\n",
+            r#"{"name":"word_count","args":{},"result":{"success":true,"output":"0 words"}}"#,
+            "\n"
+        );
+        let records = parse_recording(raw).unwrap();
+        assert_eq!(records.len(), 2);
+        assert_eq!(records[0].args, json!({"text": "a"}));
+
+        let err = parse_recording("{\"name\":\"x\"}\n").unwrap_err();
+        assert!(err.to_string().starts_with("line 1:"));
+    }
+
+    #[test]
+    fn unchanged_results_have_no_diff() {
+        let old = json!({"success": true, "output": "2 words", "data": {"words": 2}});
+        let new =
+            json!({"success": true, "output": "2 words", "error": null, "data": {"words": 2}});
+        assert!(diff_results(&old, &new).is_empty());
+    }
+
+    #[test]
+    fn trace_ids_are_not_a_change() {
+        let old = json!({"success": true, "output": "", "meta": {"trace_id": "a1"}});
+        let new = json!({"success": true, "output": "", "meta": {"trace_id": "b2"}});
+        assert!(diff_results(&old, &new).is_empty());
+        assert!(diff_results(&old, &json!({"success": true, "output": ""})).is_empty());
+    }
+
+    #[test]
+    fn changed_added_and_removed_fields_are_listed() {
+        let old = json!({"success": true, "output": "2 words", "data": {"words": 2, "lines": 1}});
+        let new =
+            json!({"success": true, "output": "3 words", "data": {"words": 3, "characters": 5}});
+        assert_eq!(
+            diff_results(&old, &new),
+            vec![
+                "+ data.characters added: 5",
+                "- data.lines removed (was 1)",
+                "~ data.words: 2 -> 3",
+                "~ output: \"2 words\" -> \"3 words\"",
+            ]
+        );
+    }
+}
@@ -0,0 +1,224 @@
+//! `zeroclaw skill schema-diff` — compare the args schemas of two skill builds.
+//!
+//! Schemas come from running each module with `--schema` (see the Go SDK's
+//! `skill.SchemaFlag`). Object properties are compared recursively and
+//! reported by dotted path.
+
+use serde_json::Value;
+use std::fmt;
+
+/// One difference between an old and a new schema.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum SchemaChange {
+    Added {
+        field: String,
+        required: bool,
+    },
+    Removed {
+        field: String,
+    },
+    Retyped {
+        field: String,
+        from: String,
+        to: String,
+    },
+    NowRequired {
+        field: String,
+    },
+    NoLongerRequired {
+        field: String,
+    },
+}
+
+impl SchemaChange {
+    /// Whether callers valid against the old schema can break on the new one.
+    pub fn is_breaking(&self) -> bool {
+        match self {
+            Self::Added { required, .. } => *required,
+            Self::Removed { .. } | Self::Retyped { .. } | Self::NowRequired { .. } => true,
+            Self::NoLongerRequired { .. } => false,
+        }
+    }
+}
+
+impl fmt::Display for SchemaChange {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            Self::Added { field, required } => {
+                write!(f, "+ {field} added")?;
+                if *required {
+                    write!(f, " (required)")?;
+                }
+                Ok(())
+            }
+            Self::Removed { field } => write!(f, "- {field} removed"),
+            Self::Retyped { field, from, to } => write!(f, "~ {field} retyped: {from} -> {to}"),
+            Self::NowRequired { field } => write!(f, "~ {field} is now required"),
+            Self::NoLongerRequired { field } => write!(f, "~ {field} is no longer required"),
+        }
+    }
+}
+
+/// List the changes from `old` to `new`, sorted by field path.
+pub fn diff_schemas(old: &Value, new: &Value) -> Vec<SchemaChange> {
+    let mut changes = Vec::new();
+    diff_object("", old, new, &mut changes);
+    changes
+}
+
+fn diff_object(prefix: &str, old: &Value, new: &Value, changes: &mut Vec<SchemaChange>) {
+    let props = |schema: &Value| {
+        schema
+            .get("properties")
+            .and_then(Value::as_object)
+            .cloned()
+            .unwrap_or_default()
+    };
+    let required = |schema: &Value, name: &str| {
+        schema
+            .get("required")
+            .and_then(Value::as_array)
+            .is_some_and(|r| r.iter().any(|v| v.as_str() == Some(name)))
+    };
+    let (old_props, new_props) = (props(old), props(new));
+
+    let mut names: Vec<&String> = old_props.keys().chain(new_props.keys()).collect();
+    names.sort();
+    names.dedup();
+    for name in names {
+        let field = format!("{prefix}{name}");
+        match (old_props.get(name), new_props.get(name)) {
+            (Some(_), None) => changes.push(SchemaChange::Removed { field }),
+            (None, Some(_)) => changes.push(SchemaChange::Added {
+                required: required(new, name),
+                field,
+            }),
+            (Some(old_prop), Some(new_prop)) => {
+                let (from, to) = (type_name(old_prop), type_name(new_prop));
+                if from != to {
+                    changes.push(SchemaChange::Retyped {
+                        field: field.clone(),
+                        from,
+                        to,
+                    });
+                } else if from == "object" {
+                    diff_object(&format!("{field}."), old_prop, new_prop, changes);
+                }
+                match (required(old, name), required(new, name)) {
+                    (false, true) => changes.push(SchemaChange::NowRequired { field }),
+                    (true, false) => changes.push(SchemaChange::NoLongerRequired { field }),
+                    _ => {}
+                }
+            }
+            (None, None) => unreachable!("name came from one of the maps"),
+        }
+    }
+}
+
+/// A property's type, including the item type for arrays (e.g. `array<string>`).
+fn type_name(schema: &Value) -> String {
+    let base = schema
+        .get("type")
+        .and_then(Value::as_str)
+        .unwrap_or("any")
+        .to_string();
+    match schema.get("items") {
+        Some(items) if base == "array" => format!("array<{}>", type_name(items)),
+        _ => base,
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    fn word_count_v1() -> Value {
+        json!({
+            "type": "object",
+            "required": ["text"],
+            "properties": {
+                "text": {"type": "string"},
+                "locale": {"type": "string"},
+                "opts": {"type": "object", "properties": {"trim": {"type": "boolean"}}}
+            }
+        })
+    }
+
+    #[test]
+    fn identical_schemas_have_no_changes() {
+        assert!(diff_schemas(&word_count_v1(), &word_count_v1()).is_empty());
+    }
+
+    #[test]
+    fn additive_changes_are_not_breaking() {
+        let mut new = word_count_v1();
+        new["properties"]["max_words"] = json!({"type": "integer"});
+        new["properties"]["opts"]["properties"]["lower"] = json!({"type": "boolean"});
+
+        let changes = diff_schemas(&word_count_v1(), &new);
+        assert_eq!(
+            changes,
+            vec![
+                SchemaChange::Added {
+                    field: "max_words".into(),
+                    required: false
+                },
+                SchemaChange::Added {
+                    field: "opts.lower".into(),
+                    required: false
+                },
+            ]
+        );
+        assert!(!changes.iter().any(SchemaChange::is_breaking));
+    }
+
+    #[test]
+    fn removed_retyped_and_newly_required_fields_are_breaking() {
+        let new = json!({
+            "type": "object",
+            "required": ["text", "locale"],
+            "properties": {
+                "text": {"type": "array", "items": {"type": "string"}},
+                "locale": {"type": "string"}
+            }
+        });
+
+        let changes = diff_schemas(&word_count_v1(), &new);
+        assert_eq!(
+            changes,
+            vec![
+                SchemaChange::NowRequired {
+                    field: "locale".into()
+                },
+                SchemaChange::Removed {
+                    field: "opts".into()
+                },
+                SchemaChange::Retyped {
+                    field: "text".into(),
+                    from: "string".into(),
+                    to: "array<string>".into()
+                },
+            ]
+        );
+        assert!(changes.iter().all(SchemaChange::is_breaking));
+        assert_eq!(
+            changes[2].to_string(),
+            "~ text retyped: string -> array<string>"
+        );
+    }
+
+    #[test]
+    fn relaxing_required_is_additive() {
+        let mut new = word_count_v1();
+        new["required"] = json!([]);
+        let changes = diff_schemas(&word_count_v1(), &new);
+        assert_eq!(
+            changes,
+            vec![SchemaChange::NoLongerRequired {
+                field: "text".into()
+            }]
+        );
+        assert!(!changes[0].is_breaking());
+    }
+}
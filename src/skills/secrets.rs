@@ -0,0 +1,323 @@
+//! `skill test --secret KEY=VALUE` — hand credentials to a skill without
+//! putting them in its args.
+//!
+//! A skill names the secrets it needs under `capabilities.secrets` in
+//! `manifest.json`; a secret it does not declare is refused, and a declared
+//! one that is not given is an error. Each value reaches the guest as
+//! `ZEROCLAW_SECRET_<KEY>` (read with the Go SDK's `skill.Secret`). The value
+//! is exported into this process's environment and wasmtime is told to
+//! inherit the variable by name, so it never appears on a command line, and
+//! any copy the skill writes to stdout or stderr is replaced with
+//! [`REDACTED`] before it is printed.
+//!
+//! A secret can also travel in the args, such as an API key a tool takes as
+//! a field. The Go SDK marks such fields `"x-zeroclaw-secret": true` in the
+//! schema (a `secret:"true"` struct tag), and `skill test` echoes their
+//! values as [`REDACTED_ARG`]; the skill still gets them.
+
+use anyhow::{bail, Context, Result};
+use std::path::Path;
+
+/// Prefix of the environment variable that carries each secret.
+pub const SECRET_ENV_PREFIX: &str = "ZEROCLAW_SECRET_";
+
+/// What a leaked secret value is replaced with.
+pub const REDACTED: &str = "[REDACTED]";
+
+/// What an arg the schema marks secret is shown as.
+pub const REDACTED_ARG: &str = "***";
+
+/// The schema keyword that marks an arg secret.
+pub const SECRET_SCHEMA_KEY: &str = "x-zeroclaw-secret";
+
+/// One secret given on the command line or in a secrets file.
+#[derive(Clone, PartialEq, Eq)]
+pub struct Secret {
+    pub key: String,
+    pub value: String,
+}
+
+// Secrets are never printed, even in debug output.
+impl std::fmt::Debug for Secret {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("Secret")
+            .field("key", &self.key)
+            .field("value", &REDACTED)
+            .finish()
+    }
+}
+
+impl Secret {
+    /// Parse a `KEY=VALUE` flag value or secrets-file line.
+    pub fn parse(spec: &str) -> Result<Self> {
+        let Some((key, value)) = spec.split_once('=') else {
+            bail!("invalid secret: expected 'KEY=VALUE' (e.g. API_KEY=...)");
+        };
+        let key = key.trim();
+        if key.is_empty() || !key.chars().all(|c| c.is_ascii_alphanumeric() || c == '_') {
+            bail!("invalid secret key '{key}': use letters, digits and '_' only");
+        }
+        Ok(Self {
+            key: key.to_string(),
+            value: value.to_string(),
+        })
+    }
+
+    /// The environment variable the guest reads this secret from.
+    pub fn env_name(&self) -> String {
+        format!("{SECRET_ENV_PREFIX}{}", self.key)
+    }
+}
+
+/// Read a `--secret-file`: one `KEY=VALUE` per line; blank lines and lines
+/// starting with `#` are skipped.
+pub fn read_file(path: &Path) -> Result<Vec<Secret>> {
+    let raw = std::fs::read_to_string(path)
+        .with_context(|| format!("failed to read {}", path.display()))?;
+    raw.lines()
+        .enumerate()
+        .filter(|(_, line)| {
+            let line = line.trim();
+            !line.is_empty() && !line.starts_with('#')
+        })
+        .map(|(i, line)| {
+            Secret::parse(line).with_context(|| format!("{}:{}", path.display(), i + 1))
+        })
+        .collect()
+}
+
+/// Secret keys a skill declares under `capabilities.secrets`.
+pub fn declared_secrets(manifest_path: &Path) -> Result<Vec<String>> {
+    if !manifest_path.exists() {
+        return Ok(Vec::new());
+    }
+    let raw = std::fs::read_to_string(manifest_path)
+        .with_context(|| format!("failed to read {}", manifest_path.display()))?;
+    let manifest: serde_json::Value = serde_json::from_str(&raw)
+        .with_context(|| format!("{} is not valid JSON", manifest_path.display()))?;
+    Ok(manifest
+        .pointer("/capabilities/secrets")
+        .and_then(serde_json::Value::as_array)
+        .map(|keys| {
+            keys.iter()
+                .filter_map(serde_json::Value::as_str)
+                .map(str::to_string)
+                .collect()
+        })
+        .unwrap_or_default())
+}
+
+/// Refuse secrets the manifest does not declare and require every one it does.
+pub fn check_secrets(secrets: &[Secret], declared: &[String]) -> Result<()> {
+    for secret in secrets {
+        if !declared.contains(&secret.key) {
+            bail!(
+                "secret {} is not declared in the manifest's capabilities.secrets (declared: {})",
+                secret.key,
+                if declared.is_empty() {
+                    "none".to_string()
+                } else {
+                    declared.join(", ")
+                }
+            );
+        }
+    }
+    for key in declared {
+        if !secrets.iter().any(|s| &s.key == key) {
+            bail!("the manifest requires secret {key}: pass --secret {key}=... or --secret-file");
+        }
+    }
+    Ok(())
+}
+
+/// Export `secrets` into this process's environment and return the
+/// `wasmtime run` flags that pass them on by name.
+///
+/// Call this before starting any worker threads.
+pub fn export(secrets: &[Secret]) -> Vec<String> {
+    let mut args = Vec::new();
+    for secret in secrets {
+        let name = secret.env_name();
+        std::env::set_var(&name, &secret.value);
+        args.push("--env".to_string());
+        args.push(name);
+    }
+    args
+}
+
+/// Replace every exported secret value in `text` with [`REDACTED`], both as
+/// written and as it appears inside a JSON string.
+pub fn redact(text: &str) -> String {
+    let values: Vec<String> = std::env::vars()
+        .filter(|(name, value)| name.starts_with(SECRET_ENV_PREFIX) && !value.is_empty())
+        .map(|(_, value)| value)
+        .collect();
+    redact_values(text, &values)
+}
+
+/// `args_json` with the value of every arg `schema` marks secret replaced by
+/// [`REDACTED_ARG`], for printing. Args that do not parse, or hold no secret,
+/// come back as given; otherwise keys come back sorted.
+pub fn redact_args(schema: Option<&serde_json::Value>, args_json: &str) -> String {
+    let Some(schema) = schema else {
+        return args_json.to_string();
+    };
+    let Ok(mut args) = serde_json::from_str::<serde_json::Value>(args_json) else {
+        return args_json.to_string();
+    };
+    if redact_value(schema, &mut args) {
+        args.to_string()
+    } else {
+        args_json.to_string()
+    }
+}
+
+/// Replace what `schema` marks secret within `value`, reporting whether any
+/// was.
+fn redact_value(schema: &serde_json::Value, value: &mut serde_json::Value) -> bool {
+    if schema
+        .get(SECRET_SCHEMA_KEY)
+        .and_then(serde_json::Value::as_bool)
+        == Some(true)
+    {
+        *value = serde_json::Value::String(REDACTED_ARG.to_string());
+        return true;
+    }
+    let mut hit = false;
+    match value {
+        serde_json::Value::Object(fields) => {
+            for (name, field) in fields.iter_mut() {
+                let field_schema = schema
+                    .get("properties")
+                    .and_then(|props| props.get(name.as_str()))
+                    .or_else(|| schema.get("additionalProperties"));
+                if let Some(field_schema) = field_schema {
+                    hit |= redact_value(field_schema, field);
+                }
+            }
+        }
+        serde_json::Value::Array(items) => {
+            if let Some(item_schema) = schema.get("items") {
+                for item in items {
+                    hit |= redact_value(item_schema, item);
+                }
+            }
+        }
+        _ => {}
+    }
+    hit
+}
+
+fn redact_values(text: &str, values: &[String]) -> String {
+    let mut out = text.to_string();
+    for value in values {
+        out = out.replace(value.as_str(), REDACTED);
+        let escaped = serde_json::to_string(value).unwrap_or_default();
+        let escaped = escaped.trim_matches('"');
+        if escaped != value {
+            out = out.replace(escaped, REDACTED);
+        }
+    }
+    out
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn parse_splits_on_first_equals() {
+        let secret = Secret::parse("API_KEY=a=b").unwrap();
+        assert_eq!(secret.key, "API_KEY");
+        assert_eq!(secret.value, "a=b");
+        assert_eq!(secret.env_name(), "ZEROCLAW_SECRET_API_KEY");
+        assert!(Secret::parse("API_KEY").is_err());
+        assert!(Secret::parse("BAD-KEY=x").is_err());
+        assert!(!format!("{secret:?}").contains("a=b"));
+    }
+
+    #[test]
+    fn read_file_skips_comments_and_blank_lines() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("secrets.env");
+        std::fs::write(&path, "# dev keys\n\nAPI_KEY=k1\nTOKEN=t2\n").unwrap();
+        let secrets = read_file(&path).unwrap();
+        let keys: Vec<&str> = secrets.iter().map(|s| s.key.as_str()).collect();
+        assert_eq!(keys, ["API_KEY", "TOKEN"]);
+
+        std::fs::write(&path, "API_KEY=k1\nnot a secret\n").unwrap();
+        let err = format!("{:#}", read_file(&path).unwrap_err());
+        assert!(err.contains("secrets.env:2"), "{err}");
+    }
+
+    #[test]
+    fn declared_secrets_reads_capabilities() {
+        let dir = tempfile::tempdir().unwrap();
+        let manifest = dir.path().join("manifest.json");
+        std::fs::write(&manifest, r#"{"capabilities":{"secrets":["API_KEY"]}}"#).unwrap();
+        assert_eq!(declared_secrets(&manifest).unwrap(), ["API_KEY"]);
+        assert!(declared_secrets(&dir.path().join("missing.json"))
+            .unwrap()
+            .is_empty());
+    }
+
+    #[test]
+    fn check_secrets_refuses_undeclared_and_requires_declared() {
+        let declared = vec!["API_KEY".to_string()];
+        let given = [Secret::parse("API_KEY=k").unwrap()];
+        assert!(check_secrets(&given, &declared).is_ok());
+
+        let err = check_secrets(&[Secret::parse("OTHER=k").unwrap()], &declared).unwrap_err();
+        assert!(err.to_string().contains("not declared"), "{err}");
+
+        let err = check_secrets(&[], &declared).unwrap_err();
+        assert!(err.to_string().contains("requires secret API_KEY"), "{err}");
+    }
+
+    #[test]
+    fn redact_values_replaces_raw_and_json_escaped_copies() {
+        let values = vec!["s3cr\"et".to_string()];
+        let text = "log: s3cr\"et\n{\"output\":\"s3cr\\\"et\"}";
+        assert_eq!(
+            redact_values(text, &values),
+            "log: [REDACTED]\n{\"output\":\"[REDACTED]\"}"
+        );
+    }
+
+    #[test]
+    fn redact_args_hides_fields_the_schema_marks_secret() {
+        let schema = serde_json::json!({
+            "type": "object",
+            "properties": {
+                "query": {"type": "string"},
+                "api_key": {"type": "string", "x-zeroclaw-secret": true},
+                "auth": {
+                    "type": "object",
+                    "properties": {"token": {"type": "string", "x-zeroclaw-secret": true}}
+                },
+                "keys": {"type": "array", "items": {"type": "string", "x-zeroclaw-secret": true}}
+            }
+        });
+        let args = r#"{"query":"weather","api_key":"sk-1","auth":{"token":"t0k","user":"me"},"keys":["a","b"]}"#;
+        assert_eq!(
+            redact_args(Some(&schema), args),
+            r#"{"api_key":"***","auth":{"token":"***","user":"me"},"keys":["***","***"],"query":"weather"}"#
+        );
+
+        let plain = r#"{"query":"weather", "n":1}"#;
+        assert_eq!(redact_args(Some(&schema), plain), plain);
+        assert_eq!(redact_args(None, args), args);
+        assert_eq!(redact_args(Some(&schema), "not json"), "not json");
+    }
+
+    #[test]
+    fn export_passes_names_not_values() {
+        let args = export(&[Secret::parse("EXPORT_TEST=hunter2").unwrap()]);
+        assert_eq!(args, ["--env", "ZEROCLAW_SECRET_EXPORT_TEST"]);
+        assert_eq!(
+            std::env::var("ZEROCLAW_SECRET_EXPORT_TEST").unwrap(),
+            "hunter2"
+        );
+        assert_eq!(redact("got hunter2"), "got [REDACTED]");
+    }
+}
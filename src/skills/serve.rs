@@ -0,0 +1,1191 @@
+//! `zeroclaw skill serve` — keep one skill compiled and answer requests
+//! until stopped.
+//!
+//! Requests arrive on a Unix socket (`--socket`) as JSON-Lines: each line is
+//! an args object, answered by one `ToolResult` line, and a connection may
+//! send as many as it likes. Every request runs in a fresh instance of the
+//! warm module, so no guest state carries from one to the next.
+//! `--metrics-addr` serves Prometheus counters for the requests at
+//! `/metrics`, named as the Go runtime's `runtime.Metrics` names them.
+//! `--health-addr` serves `/healthz` and `/readyz` for a supervisor's probes,
+//! and `zeroclaw skill ping` checks that a socket's serve loop answers.
+//! `--http-addr` takes the args as the body of `POST /invoke` and answers
+//! with the `ToolResult`, under the status the Go runtime's `InvokeHandler`
+//! gives its `error_code`.
+//!
+//! `--pool-size N` keeps N copies of the module and runs up to N calls at
+//! once. A request that finds every copy busy waits in a queue of at most
+//! `--queue-size` requests; one that finds the queue full is answered
+//! `rate_limited` at once.
+
+use anyhow::{Context, Result};
+use serde_json::{json, Value};
+use std::collections::BTreeMap;
+use std::io::{self, BufRead, BufReader, Write};
+use std::net::TcpListener;
+use std::path::{Path, PathBuf};
+use std::sync::{Arc, Condvar, Mutex, PoisonError};
+use std::thread::JoinHandle;
+use std::time::{Duration, Instant};
+
+/// The warm module `skill serve` hands requests to.
+pub trait Module: Send {
+    /// Run one call with `args` in a fresh instance and return its stdout.
+    fn call(&self, args: &Value) -> Result<String>;
+
+    /// Create an instance without running it, to show the module can be
+    /// instantiated before the first request.
+    fn instantiate(&self) -> Result<()>;
+}
+
+/// The error a [`Module`] returns for a call stopped at the deadline it
+/// gives each instance; the call is answered as a `timeout` failure.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct TimedOut(pub Duration);
+
+impl std::fmt::Display for TimedOut {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        write!(f, "call stopped at its {:?} deadline", self.0)
+    }
+}
+
+impl std::error::Error for TimedOut {}
+
+/// The error a call gets when every instance in the pool is busy and the
+/// queue of requests waiting for one is full; the call is answered as a
+/// `rate_limited` failure without reaching the skill.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct Busy {
+    pub pool_size: usize,
+    pub queue_size: usize,
+}
+
+impl std::fmt::Display for Busy {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        write!(
+            f,
+            "all {} instances are busy and {} requests are already waiting",
+            self.pool_size, self.queue_size
+        )
+    }
+}
+
+impl std::error::Error for Busy {}
+
+/// The `error_code` a call the host could not complete is counted and
+/// answered under.
+fn host_failure_code(err: &anyhow::Error) -> &'static str {
+    if err.downcast_ref::<TimedOut>().is_some() {
+        "timeout"
+    } else if err.downcast_ref::<Busy>().is_some() {
+        "rate_limited"
+    } else {
+        HOST_ERROR_CODE
+    }
+}
+
+/// The field of the request line `zeroclaw skill ping` sends,
+/// `{"__ping":true}`. The serve loop answers it with [`PONG`] itself; the
+/// skill never sees it.
+pub const PING_FIELD: &str = "__ping";
+
+/// The `output` of the answer to a ping.
+pub const PONG: &str = "pong";
+
+/// Upper bounds, in seconds, of the latency histogram: the Go runtime's
+/// `LatencyBuckets`, so dashboards work for either host.
+pub const LATENCY_BUCKETS: [f64; 11] = [
+    0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5.0, 10.0,
+];
+
+/// The `error_code` counted, and answered, for calls that failed in the host
+/// rather than returning a `ToolResult`, such as traps and oversized output.
+/// A call stopped at its deadline is a `timeout` instead.
+pub const HOST_ERROR_CODE: &str = "host_error";
+
+/// Counts of the calls served so far.
+#[derive(Debug, Default)]
+pub struct Metrics {
+    invocations: u64,
+    errors: BTreeMap<String, u64>,
+    /// Cumulative counts, one per [`LATENCY_BUCKETS`] bound.
+    buckets: [u64; LATENCY_BUCKETS.len()],
+    sum: f64,
+}
+
+impl Metrics {
+    /// Count one call that took `elapsed`. A failed `ToolResult` is counted
+    /// under its `error_code`, or `unknown` without one; stdout that is not
+    /// a `ToolResult` counts as a success, as a plain-text tool has no
+    /// status to inspect.
+    pub fn observe(&mut self, result: &Result<String>, elapsed: Duration) {
+        self.invocations += 1;
+        let code = match result {
+            Err(e) => Some(host_failure_code(e).to_string()),
+            Ok(stdout) => serde_json::from_str::<Value>(stdout.trim())
+                .ok()
+                .filter(|result| result.get("success") == Some(&Value::Bool(false)))
+                .map(|result| {
+                    result
+                        .get("error_code")
+                        .and_then(Value::as_str)
+                        .unwrap_or("unknown")
+                        .to_string()
+                }),
+        };
+        if let Some(code) = code {
+            *self.errors.entry(code).or_default() += 1;
+        }
+        let secs = elapsed.as_secs_f64();
+        self.sum += secs;
+        for (count, bound) in self.buckets.iter_mut().zip(LATENCY_BUCKETS) {
+            if secs <= bound {
+                *count += 1;
+            }
+        }
+    }
+
+    /// The counts in the Prometheus text exposition format.
+    pub fn render(&self) -> String {
+        let mut out = String::new();
+        out.push_str("# HELP zeroclaw_skill_invocations_total Skill invocations.\n");
+        out.push_str("# TYPE zeroclaw_skill_invocations_total counter\n");
+        out.push_str(&format!(
+            "zeroclaw_skill_invocations_total {}\n",
+            self.invocations
+        ));
+
+        out.push_str(
+            "# HELP zeroclaw_skill_errors_total Failed skill invocations by error code.\n",
+        );
+        out.push_str("# TYPE zeroclaw_skill_errors_total counter\n");
+        for (code, count) in &self.errors {
+            out.push_str(&format!(
+                "zeroclaw_skill_errors_total{{error_code={code:?}}} {count}\n"
+            ));
+        }
+
+        out.push_str("# HELP zeroclaw_skill_duration_seconds Skill invocation latency.\n");
+        out.push_str("# TYPE zeroclaw_skill_duration_seconds histogram\n");
+        for (count, bound) in self.buckets.iter().zip(LATENCY_BUCKETS) {
+            out.push_str(&format!(
+                "zeroclaw_skill_duration_seconds_bucket{{le=\"{bound}\"}} {count}\n"
+            ));
+        }
+        out.push_str(&format!(
+            "zeroclaw_skill_duration_seconds_bucket{{le=\"+Inf\"}} {}\n",
+            self.invocations
+        ));
+        out.push_str(&format!(
+            "zeroclaw_skill_duration_seconds_sum {}\n",
+            self.sum
+        ));
+        out.push_str(&format!(
+            "zeroclaw_skill_duration_seconds_count {}\n",
+            self.invocations
+        ));
+        out
+    }
+}
+
+/// How many requests may wait for an instance unless `--queue-size` says
+/// otherwise.
+pub const DEFAULT_QUEUE_SIZE: usize = 16;
+
+/// Copies of the warm module, each running one call at a time: concurrent
+/// calls on one copy would share its deadline ticker and time out early.
+/// Every call runs in a fresh instance of its copy, so handing a copy to the
+/// next request leaks no guest state.
+struct Pool<M> {
+    state: Mutex<PoolState<M>>,
+    /// Signalled each time a copy is handed back.
+    returned: Condvar,
+    size: usize,
+    queue_size: usize,
+}
+
+struct PoolState<M> {
+    idle: Vec<M>,
+    waiting: usize,
+}
+
+impl<M> Pool<M> {
+    fn new(modules: Vec<M>, queue_size: usize) -> Self {
+        Self {
+            size: modules.len(),
+            state: Mutex::new(PoolState {
+                idle: modules,
+                waiting: 0,
+            }),
+            returned: Condvar::new(),
+            queue_size,
+        }
+    }
+
+    /// Take an idle copy, waiting for one if the queue has room, or fail
+    /// with [`Busy`] if it does not.
+    fn checkout(&self) -> Result<Checkout<'_, M>, Busy> {
+        let mut state = lock(&self.state);
+        if state.idle.is_empty() {
+            if state.waiting >= self.queue_size {
+                return Err(Busy {
+                    pool_size: self.size,
+                    queue_size: self.queue_size,
+                });
+            }
+            state.waiting += 1;
+            while state.idle.is_empty() {
+                state = self
+                    .returned
+                    .wait(state)
+                    .unwrap_or_else(PoisonError::into_inner);
+            }
+            state.waiting -= 1;
+        }
+        let module = state.idle.pop();
+        Ok(Checkout { pool: self, module })
+    }
+}
+
+/// A copy taken from a [`Pool`], handed back when dropped, also when the
+/// call on it panics.
+struct Checkout<'a, M> {
+    pool: &'a Pool<M>,
+    module: Option<M>,
+}
+
+impl<M> std::ops::Deref for Checkout<'_, M> {
+    type Target = M;
+
+    fn deref(&self) -> &M {
+        self.module
+            .as_ref()
+            .expect("a checkout holds its module until dropped")
+    }
+}
+
+impl<M> Drop for Checkout<'_, M> {
+    fn drop(&mut self) {
+        if let Some(module) = self.module.take() {
+            lock(&self.pool.state).idle.push(module);
+            self.pool.returned.notify_one();
+        }
+    }
+}
+
+/// A skill being served: its pool of warm modules and the counts of its
+/// calls.
+pub struct Server<M> {
+    pool: Pool<M>,
+    metrics: Mutex<Metrics>,
+    /// Why `/readyz` fails, or `None` once an instance has been created.
+    not_ready: Mutex<Option<String>>,
+}
+
+impl<M: Module> Server<M> {
+    /// Serve one copy of a module, with up to [`DEFAULT_QUEUE_SIZE`]
+    /// requests waiting for it.
+    pub fn new(module: M) -> Self {
+        Self::pooled(vec![module], DEFAULT_QUEUE_SIZE)
+    }
+
+    /// Serve `modules`, copies of one module, running a call on each at once
+    /// with up to `queue_size` requests waiting for a free one.
+    pub fn pooled(modules: Vec<M>, queue_size: usize) -> Self {
+        Self {
+            pool: Pool::new(modules, queue_size),
+            metrics: Mutex::new(Metrics::default()),
+            not_ready: Mutex::new(Some("module has not been instantiated yet".to_string())),
+        }
+    }
+
+    /// Instantiate the module once, so `/readyz` passes before the first
+    /// request; a failure is what `/readyz` reports until a call succeeds.
+    pub fn warm_up(&self) -> Result<()> {
+        let result = self
+            .pool
+            .checkout()
+            .map_err(anyhow::Error::from)
+            .and_then(|module| module.instantiate());
+        *lock(&self.not_ready) = result
+            .as_ref()
+            .err()
+            .map(|e| format!("cannot instantiate module: {e:#}"));
+        result
+    }
+
+    /// Answer one request line with one result line, or `None` for a blank
+    /// line. A line that is not JSON fails as `invalid_input`, and a ping is
+    /// answered with [`PONG`], neither reaching the skill.
+    pub fn answer_line(&self, line: &str) -> Option<String> {
+        let line = line.trim();
+        if line.is_empty() {
+            return None;
+        }
+        Some(match serde_json::from_str::<Value>(line) {
+            Ok(args) if args.get(PING_FIELD) == Some(&Value::Bool(true)) => {
+                json!({"success": true, "output": PONG}).to_string()
+            }
+            Ok(args) => self.invoke(&args),
+            Err(e) => {
+                failure("invalid_input", &format!("args are not valid JSON: {e}")).to_string()
+            }
+        })
+    }
+
+    /// Run one call, count it, and return its result on one line. A call
+    /// the host could not complete is answered as [`HOST_ERROR_CODE`],
+    /// `timeout` for one stopped at its deadline, or `rate_limited` for one
+    /// turned away by a full queue.
+    fn invoke(&self, args: &Value) -> String {
+        let started = Instant::now();
+        let result = self
+            .pool
+            .checkout()
+            .map_err(anyhow::Error::from)
+            .and_then(|module| module.call(args));
+        lock(&self.metrics).observe(&result, started.elapsed());
+        if result.is_ok() {
+            *lock(&self.not_ready) = None;
+        }
+        match result {
+            Ok(stdout) => stdout.trim().to_string(),
+            Err(e) => failure(host_failure_code(&e), &format!("{e:#}")).to_string(),
+        }
+    }
+
+    /// Answer each line read from `input` on `output` until `input` ends.
+    pub fn serve_lines(&self, input: impl BufRead, mut output: impl Write) -> io::Result<()> {
+        for line in input.lines() {
+            if let Some(answer) = self.answer_line(&line?) {
+                writeln!(output, "{answer}")?;
+                output.flush()?;
+            }
+        }
+        Ok(())
+    }
+
+    /// Answer one HTTP request on a listener that serves `endpoints`.
+    fn route(&self, endpoints: &[Endpoint], request: &Request) -> Response {
+        let Some(endpoint) = endpoints
+            .iter()
+            .find(|endpoint| endpoint.paths().contains(&request.path.as_str()))
+        else {
+            return Response::text(404, "not found\n");
+        };
+        let method = if *endpoint == Endpoint::Invoke {
+            "POST"
+        } else {
+            "GET"
+        };
+        if request.method != method {
+            return Response::text(405, "method not allowed\n");
+        }
+        match endpoint {
+            Endpoint::Invoke => self.answer_invoke(&request.body),
+            Endpoint::Metrics => Response {
+                status: 200,
+                content_type: "text/plain; version=0.0.4",
+                body: lock(&self.metrics).render(),
+            },
+            // The module compiled before anything was served, so the process
+            // is healthy for as long as it answers.
+            Endpoint::Health if request.path == "/healthz" => {
+                Response::json(200, &json!({"status": "ok"}))
+            }
+            Endpoint::Health => match lock(&self.not_ready).as_deref() {
+                None => Response::json(200, &json!({"status": "ok"})),
+                Some(reason) => {
+                    Response::json(503, &json!({"status": "unavailable", "reason": reason}))
+                }
+            },
+        }
+    }
+
+    /// Run the args in `body` and answer with the result. A body that is not
+    /// JSON is answered as `invalid_input` without reaching the skill, and
+    /// stdout that is not JSON as [`HOST_ERROR_CODE`].
+    fn answer_invoke(&self, body: &[u8]) -> Response {
+        let answer = match serde_json::from_slice::<Value>(body) {
+            Ok(args) => self.invoke(&args),
+            Err(e) => {
+                failure("invalid_input", &format!("args are not valid JSON: {e}")).to_string()
+            }
+        };
+        match serde_json::from_str::<Value>(&answer) {
+            Ok(result) => Response::json(invoke_status(&result), &result),
+            Err(_) => Response::json(
+                500,
+                &failure(HOST_ERROR_CODE, "skill output is not a ToolResult"),
+            ),
+        }
+    }
+}
+
+/// The HTTP status `POST /invoke` answers `result` with: 200 unless it
+/// failed, then one for its `error_code`, as the Go runtime's
+/// `InvokeHandler` maps them.
+fn invoke_status(result: &Value) -> u16 {
+    if result.get("success") != Some(&Value::Bool(false)) {
+        return 200;
+    }
+    match result.get("error_code").and_then(Value::as_str) {
+        Some("invalid_input") => 400,
+        Some("permission_denied") => 403,
+        Some("not_found") => 404,
+        Some("rate_limited") => 429,
+        Some("not_supported") => 501,
+        Some("timeout") => 504,
+        _ => 500,
+    }
+}
+
+/// Lock `mutex`, carrying on past a panic in another request: a call that
+/// panicked leaves the module and the counts usable.
+fn lock<T>(mutex: &Mutex<T>) -> std::sync::MutexGuard<'_, T> {
+    mutex.lock().unwrap_or_else(PoisonError::into_inner)
+}
+
+/// A failed `ToolResult` the host writes on the skill's behalf.
+fn failure(code: &str, message: &str) -> Value {
+    json!({"success": false, "output": "", "error": message, "error_code": code})
+}
+
+/// What an HTTP listener of `skill serve` answers.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum Endpoint {
+    /// `GET /metrics` (`--metrics-addr`).
+    Metrics,
+    /// `GET /healthz` and `GET /readyz` (`--health-addr`).
+    Health,
+    /// `POST /invoke` (`--http-addr`).
+    Invoke,
+}
+
+impl Endpoint {
+    fn paths(self) -> &'static [&'static str] {
+        match self {
+            Self::Metrics => &["/metrics"],
+            Self::Health => &["/healthz", "/readyz"],
+            Self::Invoke => &["/invoke"],
+        }
+    }
+}
+
+/// Where `skill serve` listens. HTTP endpoints given the same address share
+/// one listener.
+#[derive(Debug, Default)]
+pub struct Addrs {
+    /// Unix socket answering JSON-Lines requests.
+    pub socket: Option<PathBuf>,
+    pub metrics: Option<String>,
+    pub health: Option<String>,
+    pub http: Option<String>,
+}
+
+/// The listeners of [`Addrs`], bound but not yet answered.
+pub struct Listeners {
+    #[cfg(unix)]
+    socket: Option<(PathBuf, std::os::unix::net::UnixListener)>,
+    http: Vec<(TcpListener, Vec<Endpoint>)>,
+}
+
+impl Listeners {
+    /// Bind every address in `addrs`, so a taken port fails before anything
+    /// is served. A stale socket file left by an earlier server is replaced.
+    pub fn bind(addrs: &Addrs) -> Result<Self> {
+        let mut groups: BTreeMap<String, Vec<Endpoint>> = BTreeMap::new();
+        for (addr, endpoint) in [
+            (&addrs.metrics, Endpoint::Metrics),
+            (&addrs.health, Endpoint::Health),
+            (&addrs.http, Endpoint::Invoke),
+        ] {
+            if let Some(addr) = addr {
+                groups.entry(listen_addr(addr)).or_default().push(endpoint);
+            }
+        }
+        let http = groups
+            .into_iter()
+            .map(|(addr, endpoints)| {
+                let listener =
+                    TcpListener::bind(&addr).with_context(|| format!("cannot listen on {addr}"))?;
+                Ok((listener, endpoints))
+            })
+            .collect::<Result<Vec<_>>>()?;
+        Ok(Self {
+            #[cfg(unix)]
+            socket: addrs.socket.as_deref().map(bind_socket).transpose()?,
+            #[cfg(not(unix))]
+            socket: addrs
+                .socket
+                .as_ref()
+                .map(|_| anyhow::bail!("--socket needs a Unix platform"))
+                .transpose()?,
+            http,
+        })
+    }
+
+    /// One line per listener saying what it serves where, e.g.
+    /// `http://127.0.0.1:9090/metrics`.
+    pub fn describe(&self) -> Vec<String> {
+        let mut lines = Vec::new();
+        #[cfg(unix)]
+        if let Some((path, _)) = &self.socket {
+            lines.push(format!("socket {}", path.display()));
+        }
+        for (listener, endpoints) in &self.http {
+            let addr = listener
+                .local_addr()
+                .map_or_else(|_| "?".to_string(), |addr| addr.to_string());
+            for path in endpoints.iter().flat_map(|endpoint| endpoint.paths()) {
+                lines.push(format!("http://{addr}{path}"));
+            }
+        }
+        lines
+    }
+
+    /// Each bound HTTP address, in the order [`describe`](Self::describe)
+    /// lists them.
+    pub fn http_addrs(&self) -> Vec<std::net::SocketAddr> {
+        self.http
+            .iter()
+            .filter_map(|(listener, _)| listener.local_addr().ok())
+            .collect()
+    }
+}
+
+/// Bind the Unix socket at `path`, first removing a socket file an earlier
+/// server left behind. Any other file there is an error.
+#[cfg(unix)]
+fn bind_socket(path: &Path) -> Result<(PathBuf, std::os::unix::net::UnixListener)> {
+    use std::os::unix::fs::FileTypeExt;
+
+    if let Ok(meta) = std::fs::symlink_metadata(path) {
+        if !meta.file_type().is_socket() {
+            anyhow::bail!("{} exists and is not a socket", path.display());
+        }
+        std::fs::remove_file(path)
+            .with_context(|| format!("cannot remove stale socket {}", path.display()))?;
+    }
+    let listener = std::os::unix::net::UnixListener::bind(path)
+        .with_context(|| format!("cannot listen on {}", path.display()))?;
+    Ok((path.to_path_buf(), listener))
+}
+
+/// `addr` as `TcpListener::bind` takes it: a bare `:port` listens on every
+/// interface, as Go's `net.Listen` does.
+fn listen_addr(addr: &str) -> String {
+    match addr.strip_prefix(':') {
+        Some(port) => format!("0.0.0.0:{port}"),
+        None => addr.to_string(),
+    }
+}
+
+/// How long a connection may take to send one HTTP request.
+const HTTP_READ_TIMEOUT: Duration = Duration::from_secs(10);
+
+/// Answer every listener, each connection on its own thread, and return the
+/// accepting threads. They run until the process ends.
+pub fn serve<M: Module + 'static>(
+    server: Arc<Server<M>>,
+    listeners: Listeners,
+) -> Vec<JoinHandle<()>> {
+    let mut threads = Vec::new();
+    #[cfg(unix)]
+    if let Some((_, listener)) = listeners.socket {
+        let server = Arc::clone(&server);
+        threads.push(std::thread::spawn(move || {
+            for stream in listener.incoming().flatten() {
+                let server = Arc::clone(&server);
+                std::thread::spawn(move || {
+                    if let Ok(reader) = stream.try_clone() {
+                        let _ = server.serve_lines(BufReader::new(reader), stream);
+                    }
+                });
+            }
+        }));
+    }
+    for (listener, endpoints) in listeners.http {
+        let server = Arc::clone(&server);
+        threads.push(std::thread::spawn(move || {
+            for stream in listener.incoming().flatten() {
+                let server = Arc::clone(&server);
+                let endpoints = endpoints.clone();
+                std::thread::spawn(move || {
+                    let _ = stream.set_read_timeout(Some(HTTP_READ_TIMEOUT));
+                    let Ok(reader) = stream.try_clone() else {
+                        return;
+                    };
+                    let response = match read_request(&mut BufReader::new(reader)) {
+                        Ok(request) => server.route(&endpoints, &request),
+                        Err(e) => Response::text(400, &format!("{e:#}\n")),
+                    };
+                    let _ = response.write_to(&stream);
+                });
+            }
+        }));
+    }
+    threads
+}
+
+/// Send a ping to the serve loop on the Unix socket at `path` and return how
+/// long it took to answer `pong`, failing if it does not within `timeout`.
+#[cfg(unix)]
+pub fn ping(path: &Path, timeout: Duration) -> Result<Duration> {
+    let started = Instant::now();
+    let stream = std::os::unix::net::UnixStream::connect(path)
+        .with_context(|| format!("cannot connect to {}", path.display()))?;
+    stream.set_read_timeout(Some(timeout))?;
+    stream.set_write_timeout(Some(timeout))?;
+    writeln!(&stream, "{}", json!({ PING_FIELD: true }))?;
+    let mut line = String::new();
+    BufReader::new(&stream)
+        .read_line(&mut line)
+        .with_context(|| format!("{} did not answer within {timeout:?}", path.display()))?;
+    let answer: Option<Value> = serde_json::from_str(line.trim()).ok();
+    if answer != Some(json!({"success": true, "output": PONG})) {
+        anyhow::bail!(
+            "{} answered {:?} instead of a pong",
+            path.display(),
+            line.trim()
+        );
+    }
+    Ok(started.elapsed())
+}
+
+/// The most bytes of body `skill serve` reads from one HTTP request.
+const MAX_BODY_BYTES: usize = 16 << 20;
+
+/// One HTTP request, as much of it as `skill serve` needs.
+#[derive(Debug)]
+struct Request {
+    method: String,
+    /// The request target without its query string.
+    path: String,
+    body: Vec<u8>,
+}
+
+/// Read one HTTP/1.1 request: its request line, its headers, and a body of
+/// `Content-Length` bytes.
+fn read_request(reader: &mut impl BufRead) -> Result<Request> {
+    let mut line = String::new();
+    reader.read_line(&mut line).context("cannot read request")?;
+    let mut parts = line.split_whitespace();
+    let (Some(method), Some(target)) = (parts.next(), parts.next()) else {
+        anyhow::bail!("malformed request line {:?}", line.trim_end());
+    };
+    let path = target.split('?').next().unwrap_or(target).to_string();
+    let method = method.to_string();
+
+    let mut length = 0;
+    loop {
+        let mut header = String::new();
+        if reader
+            .read_line(&mut header)
+            .context("cannot read headers")?
+            == 0
+        {
+            break;
+        }
+        let header = header.trim_end();
+        if header.is_empty() {
+            break;
+        }
+        if let Some((name, value)) = header.split_once(':') {
+            if name.trim().eq_ignore_ascii_case("content-length") {
+                length = value
+                    .trim()
+                    .parse()
+                    .with_context(|| format!("bad Content-Length {:?}", value.trim()))?;
+            }
+        }
+    }
+    if length > MAX_BODY_BYTES {
+        anyhow::bail!("body of {length} bytes is over the {MAX_BODY_BYTES}-byte limit");
+    }
+    let mut body = vec![0; length];
+    reader.read_exact(&mut body).context("cannot read body")?;
+    Ok(Request { method, path, body })
+}
+
+/// One HTTP response; the connection closes after it.
+#[derive(Debug)]
+struct Response {
+    status: u16,
+    content_type: &'static str,
+    body: String,
+}
+
+impl Response {
+    fn text(status: u16, body: &str) -> Self {
+        Self {
+            status,
+            content_type: "text/plain; charset=utf-8",
+            body: body.to_string(),
+        }
+    }
+
+    fn json(status: u16, body: &Value) -> Self {
+        Self {
+            status,
+            content_type: "application/json",
+            body: body.to_string(),
+        }
+    }
+
+    fn write_to(&self, mut out: impl Write) -> io::Result<()> {
+        write!(
+            out,
+            "HTTP/1.1 {} {}\r\nContent-Type: {}\r\nContent-Length: {}\r\nConnection: close\r\n\r\n{}",
+            self.status,
+            reason(self.status),
+            self.content_type,
+            self.body.len(),
+            self.body
+        )?;
+        out.flush()
+    }
+}
+
+/// The reason phrase for each status `skill serve` answers with.
+fn reason(status: u16) -> &'static str {
+    match status {
+        200 => "OK",
+        400 => "Bad Request",
+        403 => "Forbidden",
+        404 => "Not Found",
+        405 => "Method Not Allowed",
+        429 => "Too Many Requests",
+        501 => "Not Implemented",
+        503 => "Service Unavailable",
+        504 => "Gateway Timeout",
+        _ => "Internal Server Error",
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::io::Read;
+
+    /// A module that echoes its args, failing with the code in `"fail"`,
+    /// trapping on `"trap"`, and overrunning its deadline on `"spin"`.
+    struct Echo;
+
+    impl Module for Echo {
+        fn instantiate(&self) -> Result<()> {
+            Ok(())
+        }
+
+        fn call(&self, args: &Value) -> Result<String> {
+            if args.get("trap").is_some() {
+                anyhow::bail!("wasm `unreachable` instruction executed");
+            }
+            if args.get("spin").is_some() {
+                return Err(TimedOut(Duration::from_secs(30)).into());
+            }
+            Ok(match args.get("fail").and_then(Value::as_str) {
+                Some(code) => json!({"success": false, "output": "", "error_code": code}),
+                None => json!({"success": true, "output": args.to_string()}),
+            }
+            .to_string())
+        }
+    }
+
+    /// Send `request` to `addr` and return the status and body of the answer.
+    fn http(addr: std::net::SocketAddr, request: &str) -> (u16, String) {
+        let mut stream = std::net::TcpStream::connect(addr).unwrap();
+        stream.write_all(request.as_bytes()).unwrap();
+        let mut answer = String::new();
+        stream.read_to_string(&mut answer).unwrap();
+        let (head, body) = answer.split_once("\r\n\r\n").unwrap();
+        let status = head.split_whitespace().nth(1).unwrap().parse().unwrap();
+        (status, body.to_string())
+    }
+
+    #[test]
+    fn each_line_gets_one_result_line() {
+        let server = Server::new(Echo);
+        let input = "{\"n\":1}\n\n not json\n{\"trap\":true}\n{\"n\":2}\n";
+        let mut out = Vec::new();
+        server.serve_lines(input.as_bytes(), &mut out).unwrap();
+
+        let results: Vec<Value> = String::from_utf8(out)
+            .unwrap()
+            .lines()
+            .map(|line| serde_json::from_str(line).unwrap())
+            .collect();
+        assert_eq!(results.len(), 4, "{results:?}");
+        assert_eq!(results[0]["output"], r#"{"n":1}"#);
+        assert_eq!(results[1]["error_code"], "invalid_input");
+        assert_eq!(results[2]["error_code"], HOST_ERROR_CODE);
+        assert!(results[2]["error"]
+            .as_str()
+            .unwrap()
+            .contains("unreachable"));
+        assert_eq!(results[3]["output"], r#"{"n":2}"#);
+    }
+
+    #[test]
+    fn metrics_count_invocations_and_errors_by_code() {
+        let mut metrics = Metrics::default();
+        let ok = Ok(r#"{"success":true,"output":"2 words"}"#.to_string());
+        metrics.observe(&ok, Duration::from_millis(3));
+        metrics.observe(&Ok("plain text".to_string()), Duration::from_millis(30));
+        metrics.observe(
+            &Ok(r#"{"success":false,"output":"","error_code":"invalid_input"}"#.to_string()),
+            Duration::from_millis(3),
+        );
+        metrics.observe(
+            &Ok(r#"{"success":false,"output":""}"#.to_string()),
+            Duration::from_secs(20),
+        );
+        metrics.observe(&Err(anyhow::anyhow!("trapped")), Duration::from_millis(3));
+
+        let text = metrics.render();
+        for line in [
+            "zeroclaw_skill_invocations_total 5\n",
+            "zeroclaw_skill_errors_total{error_code=\"host_error\"} 1\n",
+            "zeroclaw_skill_errors_total{error_code=\"invalid_input\"} 1\n",
+            "zeroclaw_skill_errors_total{error_code=\"unknown\"} 1\n",
+            "zeroclaw_skill_duration_seconds_bucket{le=\"0.005\"} 3\n",
+            "zeroclaw_skill_duration_seconds_bucket{le=\"0.05\"} 4\n",
+            "zeroclaw_skill_duration_seconds_bucket{le=\"10\"} 4\n",
+            "zeroclaw_skill_duration_seconds_bucket{le=\"+Inf\"} 5\n",
+            "zeroclaw_skill_duration_seconds_count 5\n",
+        ] {
+            assert!(text.contains(line), "missing {line:?} in:\n{text}");
+        }
+    }
+
+    #[test]
+    fn metrics_endpoint_reports_served_requests() {
+        let server = Arc::new(Server::new(Echo));
+        let input = "{\"n\":1}\n{\"n\":2}\n{\"fail\":\"not_found\"}\n{\"trap\":true}\n";
+        server.serve_lines(input.as_bytes(), io::sink()).unwrap();
+
+        let listeners = Listeners::bind(&Addrs {
+            metrics: Some("127.0.0.1:0".to_string()),
+            ..Addrs::default()
+        })
+        .unwrap();
+        let addr = listeners.http_addrs()[0];
+        assert_eq!(listeners.describe(), [format!("http://{addr}/metrics")]);
+        serve(server, listeners);
+
+        let (status, body) = http(addr, "GET /metrics HTTP/1.1\r\nHost: x\r\n\r\n");
+        assert_eq!(status, 200);
+        assert!(
+            body.contains("zeroclaw_skill_invocations_total 4\n"),
+            "{body}"
+        );
+        assert!(
+            body.contains("zeroclaw_skill_errors_total{error_code=\"not_found\"} 1\n"),
+            "{body}"
+        );
+        assert!(
+            body.contains("zeroclaw_skill_errors_total{error_code=\"host_error\"} 1\n"),
+            "{body}"
+        );
+
+        assert_eq!(http(addr, "GET /other HTTP/1.1\r\n\r\n").0, 404);
+        assert_eq!(http(addr, "POST /metrics HTTP/1.1\r\n\r\n").0, 405);
+    }
+
+    /// A module that cannot be instantiated without a call, as when its
+    /// first instance needs the request's stdin.
+    struct Unready;
+
+    impl Module for Unready {
+        fn instantiate(&self) -> Result<()> {
+            anyhow::bail!("unknown import: env::missing")
+        }
+
+        fn call(&self, _args: &Value) -> Result<String> {
+            Ok(r#"{"success":true,"output":"ok"}"#.to_string())
+        }
+    }
+
+    /// Serve `server`'s health endpoints on a free port and return it.
+    fn serve_health<M: Module + 'static>(server: &Arc<Server<M>>) -> std::net::SocketAddr {
+        let listeners = Listeners::bind(&Addrs {
+            health: Some("127.0.0.1:0".to_string()),
+            ..Addrs::default()
+        })
+        .unwrap();
+        let addr = listeners.http_addrs()[0];
+        assert_eq!(
+            listeners.describe(),
+            [
+                format!("http://{addr}/healthz"),
+                format!("http://{addr}/readyz")
+            ]
+        );
+        serve(Arc::clone(server), listeners);
+        addr
+    }
+
+    #[test]
+    fn readyz_fails_until_the_module_is_instantiated() {
+        let server = Arc::new(Server::new(Echo));
+        let addr = serve_health(&server);
+
+        assert_eq!(
+            http(addr, "GET /healthz HTTP/1.1\r\n\r\n"),
+            (200, r#"{"status":"ok"}"#.to_string())
+        );
+        let (status, body) = http(addr, "GET /readyz HTTP/1.1\r\n\r\n");
+        assert_eq!(status, 503);
+        let body: Value = serde_json::from_str(&body).unwrap();
+        assert_eq!(body["status"], "unavailable");
+        assert!(body["reason"]
+            .as_str()
+            .unwrap()
+            .contains("not been instantiated"));
+
+        server.warm_up().unwrap();
+        assert_eq!(
+            http(addr, "GET /readyz HTTP/1.1\r\n\r\n"),
+            (200, r#"{"status":"ok"}"#.to_string())
+        );
+    }
+
+    #[test]
+    fn readyz_reports_a_failed_warm_up_until_a_call_succeeds() {
+        let server = Arc::new(Server::new(Unready));
+        let addr = serve_health(&server);
+
+        assert!(server.warm_up().is_err());
+        assert_eq!(http(addr, "GET /healthz HTTP/1.1\r\n\r\n").0, 200);
+        let (status, body) = http(addr, "GET /readyz HTTP/1.1\r\n\r\n");
+        assert_eq!(status, 503);
+        assert!(body.contains("env::missing"), "{body}");
+
+        server.answer_line("{}").unwrap();
+        assert_eq!(http(addr, "GET /readyz HTTP/1.1\r\n\r\n").0, 200);
+    }
+
+    #[test]
+    fn a_ping_is_answered_without_reaching_the_skill() {
+        let server = Server::new(Unready);
+        let answer: Value =
+            serde_json::from_str(&server.answer_line(r#"{"__ping":true}"#).unwrap()).unwrap();
+        assert_eq!(answer, json!({"success": true, "output": PONG}));
+        assert!(lock(&server.metrics)
+            .render()
+            .contains("zeroclaw_skill_invocations_total 0\n"));
+        // A ping counts neither as a call nor as the module being ready.
+        assert!(lock(&server.not_ready).is_some());
+    }
+
+    #[cfg(unix)]
+    #[test]
+    fn ping_checks_the_serve_loop_answers() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("skill.sock");
+        assert!(ping(&path, Duration::from_secs(1)).is_err());
+
+        let listeners = Listeners::bind(&Addrs {
+            socket: Some(path.clone()),
+            ..Addrs::default()
+        })
+        .unwrap();
+        serve(Arc::new(Server::new(Echo)), listeners);
+        ping(&path, Duration::from_secs(5)).unwrap();
+
+        // A listener that never answers times out.
+        let silent = dir.path().join("silent.sock");
+        let _listener = std::os::unix::net::UnixListener::bind(&silent).unwrap();
+        let err = ping(&silent, Duration::from_millis(50)).unwrap_err();
+        assert!(format!("{err:#}").contains("did not answer"), "{err:#}");
+    }
+
+    /// POST `body` to `/invoke` at `addr` and return the status and result.
+    fn post(addr: std::net::SocketAddr, body: &str) -> (u16, Value) {
+        let (status, answer) = http(
+            addr,
+            &format!(
+                "POST /invoke HTTP/1.1\r\nContent-Type: application/json\r\nContent-Length: {}\r\n\r\n{body}",
+                body.len()
+            ),
+        );
+        (status, serde_json::from_str(&answer).unwrap())
+    }
+
+    #[test]
+    fn invoke_answers_with_the_result_and_a_status_for_its_code() {
+        let server = Arc::new(Server::new(Echo));
+        let listeners = Listeners::bind(&Addrs {
+            http: Some("127.0.0.1:0".to_string()),
+            ..Addrs::default()
+        })
+        .unwrap();
+        let addr = listeners.http_addrs()[0];
+        assert_eq!(listeners.describe(), [format!("http://{addr}/invoke")]);
+        serve(Arc::clone(&server), listeners);
+
+        let (status, result) = post(addr, r#"{"text":"hello world"}"#);
+        assert_eq!(status, 200);
+        assert_eq!(result["success"], true);
+        assert_eq!(result["output"], r#"{"text":"hello world"}"#);
+
+        let (status, result) = post(addr, "{not json");
+        assert_eq!(status, 400);
+        assert_eq!(result["error_code"], "invalid_input");
+
+        for (args, status, code) in [
+            (r#"{"fail":"not_found"}"#, 404, "not_found"),
+            (r#"{"fail":"rate_limited"}"#, 429, "rate_limited"),
+            (r#"{"fail":"internal"}"#, 500, "internal"),
+            (r#"{"spin":true}"#, 504, "timeout"),
+            (r#"{"trap":true}"#, 500, HOST_ERROR_CODE),
+        ] {
+            let (got, result) = post(addr, args);
+            assert_eq!((got, result["error_code"].as_str()), (status, Some(code)));
+        }
+        assert_eq!(http(addr, "GET /invoke HTTP/1.1\r\n\r\n").0, 405);
+
+        // The unparsed body never reached the skill.
+        let text = lock(&server.metrics).render();
+        assert!(
+            text.contains("zeroclaw_skill_invocations_total 6\n"),
+            "{text}"
+        );
+        assert!(
+            text.contains("zeroclaw_skill_errors_total{error_code=\"timeout\"} 1\n"),
+            "{text}"
+        );
+    }
+
+    #[cfg(unix)]
+    #[test]
+    fn socket_answers_each_connection() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("skill.sock");
+        std::fs::write(dir.path().join("taken"), "").unwrap();
+        assert!(Listeners::bind(&Addrs {
+            socket: Some(dir.path().join("taken")),
+            ..Addrs::default()
+        })
+        .is_err());
+
+        // A socket file left behind by an earlier server is replaced.
+        drop(std::os::unix::net::UnixListener::bind(&path).unwrap());
+        let listeners = Listeners::bind(&Addrs {
+            socket: Some(path.clone()),
+            ..Addrs::default()
+        })
+        .unwrap();
+        serve(Arc::new(Server::new(Echo)), listeners);
+
+        for n in 1..=2 {
+            let mut stream = std::os::unix::net::UnixStream::connect(&path).unwrap();
+            writeln!(stream, "{{\"n\":{n}}}").unwrap();
+            let mut line = String::new();
+            BufReader::new(&stream).read_line(&mut line).unwrap();
+            let result: Value = serde_json::from_str(&line).unwrap();
+            assert_eq!(result["output"], format!("{{\"n\":{n}}}"));
+        }
+    }
+
+    /// A module that counts how many of its copies are in a call at once.
+    struct Gauge {
+        active: Arc<std::sync::atomic::AtomicUsize>,
+        widest: Arc<std::sync::atomic::AtomicUsize>,
+    }
+
+    impl Module for Gauge {
+        fn instantiate(&self) -> Result<()> {
+            Ok(())
+        }
+
+        fn call(&self, args: &Value) -> Result<String> {
+            use std::sync::atomic::Ordering;
+
+            let now = self.active.fetch_add(1, Ordering::SeqCst) + 1;
+            self.widest.fetch_max(now, Ordering::SeqCst);
+            std::thread::sleep(Duration::from_millis(50));
+            self.active.fetch_sub(1, Ordering::SeqCst);
+            Ok(json!({"success": true, "output": args.to_string()}).to_string())
+        }
+    }
+
+    /// A module whose calls each wait for a message on its channel.
+    struct Gate(Mutex<std::sync::mpsc::Receiver<()>>);
+
+    impl Module for Gate {
+        fn instantiate(&self) -> Result<()> {
+            Ok(())
+        }
+
+        fn call(&self, _args: &Value) -> Result<String> {
+            lock(&self.0).recv()?;
+            Ok(json!({"success": true, "output": "done"}).to_string())
+        }
+    }
+
+    #[test]
+    fn the_pool_runs_no_more_calls_at_once_than_it_has_copies() {
+        let active = Arc::new(std::sync::atomic::AtomicUsize::new(0));
+        let widest = Arc::new(std::sync::atomic::AtomicUsize::new(0));
+        let copies = (0..3)
+            .map(|_| Gauge {
+                active: Arc::clone(&active),
+                widest: Arc::clone(&widest),
+            })
+            .collect();
+        let server = Arc::new(Server::pooled(copies, 16));
+
+        let calls: Vec<_> = (0..12)
+            .map(|n| {
+                let server = Arc::clone(&server);
+                std::thread::spawn(move || (n, server.answer_line(&format!("{{\"n\":{n}}}"))))
+            })
+            .collect();
+        for call in calls {
+            let (n, answer) = call.join().unwrap();
+            let result: Value = serde_json::from_str(&answer.unwrap()).unwrap();
+            assert_eq!(result["output"], format!("{{\"n\":{n}}}"));
+        }
+        assert_eq!(widest.load(std::sync::atomic::Ordering::SeqCst), 3);
+        assert_eq!(lock(&server.pool.state).idle.len(), 3);
+    }
+
+    #[test]
+    fn a_request_that_finds_the_queue_full_is_rate_limited() {
+        let (release, calls) = std::sync::mpsc::channel();
+        let server = Arc::new(Server::pooled(vec![Gate(Mutex::new(calls))], 1));
+        let wait_for = |ready: &dyn Fn(&PoolState<Gate>) -> bool| {
+            while !ready(&lock(&server.pool.state)) {
+                std::thread::sleep(Duration::from_millis(1));
+            }
+        };
+
+        let running = {
+            let server = Arc::clone(&server);
+            std::thread::spawn(move || server.answer_line("{}"))
+        };
+        wait_for(&|state| state.idle.is_empty());
+        let queued = {
+            let server = Arc::clone(&server);
+            std::thread::spawn(move || server.answer_line("{}"))
+        };
+        wait_for(&|state| state.waiting == 1);
+
+        let result: Value = serde_json::from_str(&server.answer_line("{}").unwrap()).unwrap();
+        assert_eq!(result["error_code"], "rate_limited");
+        assert_eq!(invoke_status(&result), 429);
+
+        release.send(()).unwrap();
+        release.send(()).unwrap();
+        for call in [running, queued] {
+            let result: Value = serde_json::from_str(&call.join().unwrap().unwrap()).unwrap();
+            assert_eq!(result["output"], "done");
+        }
+        let text = lock(&server.metrics).render();
+        assert!(
+            text.contains("zeroclaw_skill_errors_total{error_code=\"rate_limited\"} 1\n"),
+            "{text}"
+        );
+    }
+}
@@ -0,0 +1,386 @@
+//! `zeroclaw skill test <dir>/...` — run the fixtures of every skill under a
+//! directory.
+//!
+//! Each directory beneath `<dir>` holding a `manifest.json` is a skill, and
+//! its `cases.json` runs as it would with `--cases`. A skill passes when all
+//! of its cases do; one without a `cases.json` is listed but not counted.
+//! The search does not descend into a skill, into hidden directories, or into
+//! `target` and `node_modules`. `--filter` keeps the skills whose manifest
+//! name matches a glob. The report ends with a line per skill and the totals,
+//! or with `--format json` is one object for CI to collect (see
+//! [`json_report`]).
+
+use super::cases::{self, Case, CaseOutcome, Summary};
+use anyhow::{Context, Result};
+use serde_json::{json, Value};
+use std::path::{Path, PathBuf};
+use std::time::Duration;
+
+/// Fixtures file each skill keeps beside its manifest.
+pub const CASES_FILE: &str = "cases.json";
+
+/// Directories never searched for skills.
+const SKIPPED_DIRS: &[&str] = &["target", "node_modules"];
+
+/// The directory to search when `path` asks for a recursive run, as
+/// `skills/...` or `...` does.
+pub fn recursive_root(path: &str) -> Option<&str> {
+    if path == "..." {
+        return Some(".");
+    }
+    path.strip_suffix("/...")
+        .or_else(|| path.strip_suffix("\\..."))
+        .map(|root| if root.is_empty() { "/" } else { root })
+}
+
+/// A skill found by [`discover`].
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct SkillDir {
+    /// The manifest's `name`, or the directory's when it has none.
+    pub name: String,
+    pub dir: PathBuf,
+}
+
+/// Every skill under `root` whose name matches the glob `filter`, in path
+/// order.
+pub fn discover(root: &Path, filter: Option<&str>) -> Result<Vec<SkillDir>> {
+    let pattern = filter
+        .map(glob::Pattern::new)
+        .transpose()
+        .context("--filter is not a valid glob")?;
+    let mut found = Vec::new();
+    walk(root, &mut found)?;
+    found.sort_by(|a, b| a.dir.cmp(&b.dir));
+    found.retain(|skill| pattern.as_ref().map_or(true, |p| p.matches(&skill.name)));
+    Ok(found)
+}
+
+fn walk(dir: &Path, found: &mut Vec<SkillDir>) -> Result<()> {
+    let manifest = dir.join("manifest.json");
+    if manifest.is_file() {
+        let name = manifest_name(&manifest).unwrap_or_else(|| {
+            dir.file_name()
+                .map_or_else(|| dir.display().to_string(), |n| n.to_string_lossy().into())
+        });
+        found.push(SkillDir {
+            name,
+            dir: dir.to_path_buf(),
+        });
+        return Ok(());
+    }
+    let entries =
+        std::fs::read_dir(dir).with_context(|| format!("failed to read {}", dir.display()))?;
+    for entry in entries {
+        let entry = entry?;
+        let name = entry.file_name();
+        let name = name.to_string_lossy();
+        if name.starts_with('.') || SKIPPED_DIRS.contains(&name.as_ref()) {
+            continue;
+        }
+        // file_type does not follow symlinks, so a link cannot loop the walk.
+        if entry.file_type()?.is_dir() {
+            walk(&entry.path(), found)?;
+        }
+    }
+    Ok(())
+}
+
+fn manifest_name(manifest: &Path) -> Option<String> {
+    let raw = std::fs::read_to_string(manifest).ok()?;
+    let manifest: serde_json::Value = serde_json::from_str(&raw).ok()?;
+    Some(manifest.get("name")?.as_str()?.to_string())
+}
+
+/// How one skill's fixtures went.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum SkillStatus {
+    Ran(Vec<CaseOutcome>),
+    /// The skill has no `cases.json`.
+    NoCases,
+    /// The fixtures could not be read or the skill could not be run.
+    Error(String),
+}
+
+/// A skill and how its fixtures went.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct SkillOutcome {
+    pub skill: SkillDir,
+    pub status: SkillStatus,
+}
+
+impl SkillOutcome {
+    pub fn failed(&self) -> bool {
+        match &self.status {
+            SkillStatus::Ran(outcomes) => Summary::of(outcomes).failed > 0,
+            SkillStatus::NoCases => false,
+            SkillStatus::Error(_) => true,
+        }
+    }
+}
+
+/// Load each skill's `cases.json` and pass it to `run`, in order. An error
+/// from `run` fails that skill alone.
+pub fn run_suite<F>(skills: Vec<SkillDir>, mut run: F) -> Vec<SkillOutcome>
+where
+    F: FnMut(&SkillDir, &[Case]) -> Result<Vec<CaseOutcome>>,
+{
+    skills
+        .into_iter()
+        .map(|skill| {
+            let path = skill.dir.join(CASES_FILE);
+            let status = if !path.exists() {
+                SkillStatus::NoCases
+            } else {
+                match cases::load_cases(&path).and_then(|fixtures| run(&skill, &fixtures)) {
+                    Ok(outcomes) => SkillStatus::Ran(outcomes),
+                    Err(e) => SkillStatus::Error(format!("{e:#}")),
+                }
+            };
+            SkillOutcome { skill, status }
+        })
+        .collect()
+}
+
+/// The rollup: a line per skill with its case totals, then how many skills
+/// and cases passed overall. `color` adds ANSI styling.
+pub fn render_rollup(skills: &[SkillOutcome], color: bool) -> String {
+    let width = skills.iter().map(|s| s.skill.name.len()).max().unwrap_or(0);
+    let mut out = String::new();
+    let mut total = Summary::default();
+    for outcome in skills {
+        let (mark, detail) = match &outcome.status {
+            SkillStatus::Ran(outcomes) => {
+                let summary = Summary::of(outcomes);
+                total.passed += summary.passed;
+                total.failed += summary.failed;
+                total.skipped += summary.skipped;
+                let mark = if summary.failed > 0 {
+                    console::style("✗").red().bold()
+                } else {
+                    console::style("✓").green().bold()
+                };
+                (mark, summary.to_string())
+            }
+            SkillStatus::NoCases => (console::style("-").yellow(), format!("no {CASES_FILE}")),
+            SkillStatus::Error(why) => (console::style("✗").red().bold(), why.clone()),
+        };
+        out.push_str(&format!(
+            "  {} {:<width$}  {detail}\n",
+            mark.force_styling(color),
+            outcome.skill.name
+        ));
+    }
+    let failed = skills.iter().filter(|s| s.failed()).count();
+    let passed = skills
+        .iter()
+        .filter(|s| matches!(s.status, SkillStatus::Ran(_)) && !s.failed())
+        .count();
+    let style = if failed > 0 {
+        console::Style::new().red().bold()
+    } else {
+        console::Style::new().green().bold()
+    };
+    out.push('\n');
+    out.push_str(&format!(
+        "  {}\n",
+        style.force_styling(color).apply_to(format!(
+            "{} skills: {passed} passed, {failed} failed ({total} cases)",
+            skills.len()
+        ))
+    ));
+    out
+}
+
+/// `--format json` report: `results`, an entry per case named
+/// `<skill>/<case>` and sorted by name, and `summary`, the totals. Every
+/// entry has the same four keys, `error` being null unless it failed, so two
+/// runs diff cleanly. Skipped cases and skills without fixtures are left
+/// out; a skill whose fixtures could not be run is one failed entry under
+/// its own name.
+pub fn json_report(skills: &[SkillOutcome]) -> Value {
+    let mut results = Vec::new();
+    let mut total = Duration::ZERO;
+    for outcome in skills {
+        match &outcome.status {
+            SkillStatus::Ran(cases) => {
+                for case in cases.iter().filter(|c| !c.skipped) {
+                    total += case.elapsed;
+                    results.push((
+                        format!("{}/{}", outcome.skill.name, case.name),
+                        case.passed(),
+                        case.elapsed,
+                        (!case.passed()).then(|| case.message()),
+                    ));
+                }
+            }
+            SkillStatus::NoCases => {}
+            SkillStatus::Error(why) => results.push((
+                outcome.skill.name.clone(),
+                false,
+                Duration::ZERO,
+                Some(why.clone()),
+            )),
+        }
+    }
+    results.sort_by(|a, b| a.0.cmp(&b.0));
+    let passed = results.iter().filter(|r| r.1).count();
+    json!({
+        "results": results
+            .iter()
+            .map(|(name, passed, elapsed, error)| json!({
+                "name": name,
+                "passed": passed,
+                "duration_ms": elapsed.as_millis(),
+                "error": error,
+            }))
+            .collect::<Vec<_>>(),
+        "summary": {
+            "total": results.len(),
+            "passed": passed,
+            "failed": results.len() - passed,
+            "duration_ms": total.as_millis(),
+        },
+    })
+}
+
+/// Fail when any skill failed, so the command exits non-zero.
+pub fn check_suite(skills: &[SkillOutcome]) -> Result<()> {
+    let failed = skills.iter().filter(|s| s.failed()).count();
+    if failed > 0 {
+        anyhow::bail!("{failed} of {} skills failed", skills.len());
+    }
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    /// A tree with a passing and a failing skill, one without fixtures, and
+    /// a manifest in a hidden directory that must not be found.
+    fn tree() -> tempfile::TempDir {
+        let root = tempfile::tempdir().unwrap();
+        let skill = |dir: &str, manifest: &str, cases: Option<&str>| {
+            let dir = root.path().join(dir);
+            std::fs::create_dir_all(&dir).unwrap();
+            std::fs::write(dir.join("manifest.json"), manifest).unwrap();
+            if let Some(cases) = cases {
+                std::fs::write(dir.join(CASES_FILE), cases).unwrap();
+            }
+        };
+        let cases = r#"[{"name":"two words","args":{"text":"a b"},"expect":{"data":{"words":2}}}]"#;
+        skill("word_count", r#"{"name":"word_count"}"#, Some(cases));
+        skill("nested/broken", r#"{"name":"broken_count"}"#, Some(cases));
+        skill("nested/draft", r#"{}"#, None);
+        skill(".git/hooks", r#"{"name":"hidden"}"#, Some(cases));
+        root
+    }
+
+    /// Run each skill's cases against a stand-in that counts right only for
+    /// `word_count`.
+    fn run(root: &Path, filter: Option<&str>) -> Vec<SkillOutcome> {
+        let skills = discover(root, filter).unwrap();
+        run_suite(skills, |skill, fixtures| {
+            let words = if skill.name == "word_count" { 2 } else { 3 };
+            Ok(cases::run_cases(fixtures, 1, |_| {
+                Ok(format!(
+                    r#"{{"success":true,"output":"","data":{{"words":{words}}}}}"#
+                ))
+            }))
+        })
+    }
+
+    #[test]
+    fn recursive_root_needs_the_dots_suffix() {
+        assert_eq!(recursive_root("./skills/..."), Some("./skills"));
+        assert_eq!(recursive_root("..."), Some("."));
+        assert_eq!(recursive_root("/..."), Some("/"));
+        assert_eq!(recursive_root("./skills"), None);
+        assert_eq!(recursive_root("skills..."), None);
+    }
+
+    #[test]
+    fn discover_finds_skills_by_manifest() {
+        let root = tree();
+        let names: Vec<String> = discover(root.path(), None)
+            .unwrap()
+            .into_iter()
+            .map(|s| s.name)
+            .collect();
+        assert_eq!(names, ["broken_count", "draft", "word_count"]);
+
+        let names: Vec<String> = discover(root.path(), Some("*_count"))
+            .unwrap()
+            .into_iter()
+            .map(|s| s.name)
+            .collect();
+        assert_eq!(names, ["broken_count", "word_count"]);
+        assert!(discover(root.path(), Some("[")).is_err());
+    }
+
+    #[test]
+    fn rollup_reports_each_skill_and_fails_on_any() {
+        let root = tree();
+        let outcomes = run(root.path(), None);
+        let rollup = render_rollup(&outcomes, false);
+        assert_eq!(
+            rollup,
+            concat!(
+                "  ✗ broken_count  0 passed, 1 failed, 0 skipped\n",
+                "  - draft         no cases.json\n",
+                "  ✓ word_count    1 passed, 0 failed, 0 skipped\n",
+                "\n",
+                "  3 skills: 1 passed, 1 failed (1 passed, 1 failed, 0 skipped cases)\n",
+            )
+        );
+        let err = check_suite(&outcomes).unwrap_err();
+        assert_eq!(err.to_string(), "1 of 3 skills failed");
+
+        let outcomes = run(root.path(), Some("word_*"));
+        assert!(check_suite(&outcomes).is_ok());
+    }
+
+    #[test]
+    fn json_report_sorts_cases_by_name() {
+        let root = tree();
+        std::fs::write(root.path().join("nested/draft").join(CASES_FILE), "{").unwrap();
+        let mut outcomes = run(root.path(), None);
+        for outcome in &mut outcomes {
+            if let SkillStatus::Ran(cases) = &mut outcome.status {
+                cases[0].elapsed = Duration::from_millis(7);
+            }
+        }
+        let report = json_report(&outcomes);
+        let results = report["results"].as_array().unwrap();
+        let names: Vec<&str> = results
+            .iter()
+            .map(|r| r["name"].as_str().unwrap())
+            .collect();
+        assert_eq!(
+            names,
+            ["broken_count/two words", "draft", "word_count/two words"]
+        );
+        assert_eq!(
+            results[2],
+            json!({"name": "word_count/two words", "passed": true, "duration_ms": 7, "error": null})
+        );
+        assert_eq!(
+            results[0]["error"],
+            "result does not match expect\ndata.words: expected 2, got 3"
+        );
+        assert!(results[1]["error"].as_str().unwrap().contains("cases"));
+        assert_eq!(
+            report["summary"],
+            json!({"total": 3, "passed": 1, "failed": 2, "duration_ms": 14})
+        );
+    }
+
+    #[test]
+    fn unreadable_fixtures_fail_only_their_skill() {
+        let root = tree();
+        std::fs::write(root.path().join("word_count").join(CASES_FILE), "{").unwrap();
+        let outcomes = run(root.path(), Some("word_count"));
+        assert!(matches!(&outcomes[0].status, SkillStatus::Error(why) if why.contains("cases")));
+        assert!(check_suite(&outcomes).is_err());
+    }
+}
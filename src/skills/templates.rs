@@ -55,6 +55,25 @@ const RUST_CALCULATOR_FILES: &[TemplateFile] = &[
     },
 ];
 
+const RUST_WORD_COUNT_FILES: &[TemplateFile] = &[
+    TemplateFile {
+        path: "Cargo.toml",
+        content: include_str!("../../templates/rust/word_count/Cargo.toml"),
+    },
+    TemplateFile {
+        path: "src/main.rs",
+        content: include_str!("../../templates/rust/word_count/src/main.rs"),
+    },
+    TemplateFile {
+        path: "manifest.json",
+        content: include_str!("../../templates/rust/word_count/manifest.json"),
+    },
+    TemplateFile {
+        path: ".cargo/config.toml",
+        content: include_str!("../../templates/rust/word_count/.cargo/config.toml"),
+    },
+];
+
 // ── TypeScript templates ──────────────────────────────────────────────────────
 
 const TS_HELLO_FILES: &[TemplateFile] = &[
@@ -76,6 +95,23 @@ const TS_HELLO_FILES: &[TemplateFile] = &[
     },
 ];
 
+// ── JavaScript templates ──────────────────────────────────────────────────────
+
+const JS_WORD_COUNT_FILES: &[TemplateFile] = &[
+    TemplateFile {
+        path: "package.json",
+        content: include_str!("../../templates/js/word_count/package.json"),
+    },
+    TemplateFile {
+        path: "src/index.js",
+        content: include_str!("../../templates/js/word_count/src/index.js"),
+    },
+    TemplateFile {
+        path: "manifest.json",
+        content: include_str!("../../templates/js/word_count/manifest.json"),
+    },
+];
+
 // ── Go templates ─────────────────────────────────────────────────────────────
 
 const GO_WORD_COUNT_FILES: &[TemplateFile] = &[
@@ -83,16 +119,85 @@ const GO_WORD_COUNT_FILES: &[TemplateFile] = &[
         path: "go.mod",
         content: include_str!("../../templates/go/word_count/go.mod"),
     },
+    TemplateFile {
+        path: "go.sum",
+        content: include_str!("../../templates/go/word_count/go.sum"),
+    },
+    TemplateFile {
+        path: "i18n/en.json",
+        content: include_str!("../../templates/go/word_count/i18n/en.json"),
+    },
+    TemplateFile {
+        path: "i18n/fr.json",
+        content: include_str!("../../templates/go/word_count/i18n/fr.json"),
+    },
+    TemplateFile {
+        path: "i18n/pl.json",
+        content: include_str!("../../templates/go/word_count/i18n/pl.json"),
+    },
+    TemplateFile {
+        path: "i18n/ru.json",
+        content: include_str!("../../templates/go/word_count/i18n/ru.json"),
+    },
     TemplateFile {
         path: "main.go",
         content: include_str!("../../templates/go/word_count/main.go"),
     },
+    TemplateFile {
+        path: "main_test.go",
+        content: include_str!("../../templates/go/word_count/main_test.go"),
+    },
     TemplateFile {
         path: "manifest.json",
         content: include_str!("../../templates/go/word_count/manifest.json"),
     },
 ];
 
+const GO_HTTP_FETCH_FILES: &[TemplateFile] = &[
+    TemplateFile {
+        path: "go.mod",
+        content: include_str!("../../templates/go/http_fetch/go.mod"),
+    },
+    TemplateFile {
+        path: "main.go",
+        content: include_str!("../../templates/go/http_fetch/main.go"),
+    },
+    TemplateFile {
+        path: "manifest.json",
+        content: include_str!("../../templates/go/http_fetch/manifest.json"),
+    },
+];
+
+const GO_PROGRESS_DEMO_FILES: &[TemplateFile] = &[
+    TemplateFile {
+        path: "go.mod",
+        content: include_str!("../../templates/go/progress_demo/go.mod"),
+    },
+    TemplateFile {
+        path: "main.go",
+        content: include_str!("../../templates/go/progress_demo/main.go"),
+    },
+    TemplateFile {
+        path: "manifest.json",
+        content: include_str!("../../templates/go/progress_demo/manifest.json"),
+    },
+];
+
+const GO_IMAGE_INFO_FILES: &[TemplateFile] = &[
+    TemplateFile {
+        path: "go.mod",
+        content: include_str!("../../templates/go/image_info/go.mod"),
+    },
+    TemplateFile {
+        path: "main.go",
+        content: include_str!("../../templates/go/image_info/main.go"),
+    },
+    TemplateFile {
+        path: "manifest.json",
+        content: include_str!("../../templates/go/image_info/manifest.json"),
+    },
+];
+
 // ── Python templates ──────────────────────────────────────────────────────────
 
 const PY_TEXT_TRANSFORM_FILES: &[TemplateFile] = &[
@@ -123,6 +228,13 @@ pub const ALL: &[SkillTemplate] = &[
         test_args: r#"{"op":"add","a":3,"b":7}"#,
         files: RUST_CALCULATOR_FILES,
     },
+    SkillTemplate {
+        name: "word_count_rust",
+        language: "rust",
+        description: "Count words, lines, and characters — same protocol as the Go word_count",
+        test_args: r#"{"text":"hello world foo bar"}"#,
+        files: RUST_WORD_COUNT_FILES,
+    },
     SkillTemplate {
         name: "hello_world",
         language: "typescript",
@@ -130,6 +242,13 @@ pub const ALL: &[SkillTemplate] = &[
         test_args: r#"{"name":"ZeroClaw"}"#,
         files: TS_HELLO_FILES,
     },
+    SkillTemplate {
+        name: "word_count_js",
+        language: "javascript",
+        description: "Count words, lines, and characters — same protocol as the Go word_count (JavaScript + Javy)",
+        test_args: r#"{"text":"hello world foo bar"}"#,
+        files: JS_WORD_COUNT_FILES,
+    },
     SkillTemplate {
         name: "word_count",
         language: "go",
@@ -137,6 +256,27 @@ pub const ALL: &[SkillTemplate] = &[
         test_args: r#"{"text":"hello world foo bar"}"#,
         files: GO_WORD_COUNT_FILES,
     },
+    SkillTemplate {
+        name: "http_fetch",
+        language: "go",
+        description: "GET a URL and report its status and body length (needs capabilities.net)",
+        test_args: r#"{"url":"https://example.com"}"#,
+        files: GO_HTTP_FETCH_FILES,
+    },
+    SkillTemplate {
+        name: "progress_demo",
+        language: "go",
+        description: "Process a long synthetic workload, streaming progress before the result",
+        test_args: r#"{"items":5000000}"#,
+        files: GO_PROGRESS_DEMO_FILES,
+    },
+    SkillTemplate {
+        name: "image_info",
+        language: "go",
+        description: "Report the size, format, and dimensions of a base64-encoded image",
+        test_args: r#"{"data_base64":"iVBORw0KGgoAAAANSUhEUgAAAAMAAAACCAIAAAASFvFNAAAAEElEQVR4nGP4z8AAQQxwFgBB0gX7h/C5SAAAAABJRU5ErkJggg=="}"#,
+        files: GO_IMAGE_INFO_FILES,
+    },
     SkillTemplate {
         name: "text_transform",
         language: "python",
@@ -146,16 +286,21 @@ pub const ALL: &[SkillTemplate] = &[
     },
 ];
 
-/// Find a template by name. Also accepts language aliases ("rust", "typescript", "go", "python").
+/// Find a template by name. Also accepts language aliases ("rust", "typescript", "js", "go", "python").
 pub fn find(name: &str) -> Option<&'static SkillTemplate> {
     // Exact name match first
     if let Some(t) = ALL.iter().find(|t| t.name == name) {
         return Some(t);
     }
-    // Language alias → first template for that language
-    let lang = match name {
+    find_language(name)
+}
+
+/// The first template for a language or one of its aliases ("ts", "js", "py").
+pub fn find_language(lang: &str) -> Option<&'static SkillTemplate> {
+    let lang = match lang {
         "rust" => "rust",
         "typescript" | "ts" => "typescript",
+        "javascript" | "js" => "javascript",
         "go" => "go",
         "python" | "py" => "python",
         _ => return None,
@@ -163,8 +308,22 @@ pub fn find(name: &str) -> Option<&'static SkillTemplate> {
     ALL.iter().find(|t| t.language == lang)
 }
 
-/// Apply `__SKILL_NAME__` / `__BIN_NAME__` substitutions to template content.
+/// Marks template lines that only make sense inside the ZeroClaw repo, such as
+/// the Go `replace` directive pointing templates at the in-tree SDK.
+const DEV_ONLY_MARKER: &str = "zeroclaw:dev-only";
+
+/// Apply `__SKILL_NAME__` / `__BIN_NAME__` substitutions to template content,
+/// dropping lines tagged with [`DEV_ONLY_MARKER`].
 pub fn apply(content: &str, name: &str, bin_name: &str) -> String {
+    let content = if content.contains(DEV_ONLY_MARKER) {
+        content
+            .lines()
+            .filter(|line| !line.contains(DEV_ONLY_MARKER))
+            .map(|line| format!("{line}\n"))
+            .collect()
+    } else {
+        content.to_string()
+    };
     content
         .replace("__SKILL_NAME__", name)
         .replace("__BIN_NAME__", bin_name)
@@ -0,0 +1,162 @@
+//! `zeroclaw skill test --explain-timing` — where one call's time went.
+//!
+//! The phases are those the CLI can see from outside the `wasmtime` process:
+//! `load` finds the module and prepares its arguments, `instantiate` starts
+//! wasmtime, `input` writes the args to its stdin, `execute` waits for it to
+//! exit, and `decode` reads the result. wasmtime compiles and instantiates
+//! the module inside its own process, so that cost lands in `execute`; the
+//! Go runtime's `Result.Timings` splits it out.
+
+use anyhow::{bail, Context, Result};
+use serde_json::{Map, Value};
+use std::time::{Duration, Instant};
+
+/// Times consecutive phases of one call, so they add up to its wall time.
+#[derive(Debug)]
+pub struct PhaseTimer {
+    started: Instant,
+    last: Instant,
+    phases: Vec<(&'static str, Duration)>,
+}
+
+impl PhaseTimer {
+    /// Start timing; the first phase starts now.
+    pub fn start() -> Self {
+        let now = Instant::now();
+        Self {
+            started: now,
+            last: now,
+            phases: Vec::new(),
+        }
+    }
+
+    /// End the running phase, named `phase`, and start the next.
+    pub fn mark(&mut self, phase: &'static str) {
+        let now = Instant::now();
+        self.phases.push((phase, now - self.last));
+        self.last = now;
+    }
+
+    /// Stop timing.
+    pub fn finish(self) -> Timing {
+        Timing {
+            phases: self.phases,
+            total: self.started.elapsed(),
+        }
+    }
+}
+
+/// The phases a [`PhaseTimer`] recorded and the wall time around them.
+#[derive(Debug, Clone, PartialEq)]
+pub struct Timing {
+    pub phases: Vec<(&'static str, Duration)>,
+    pub total: Duration,
+}
+
+impl Timing {
+    /// A table with a row per phase and its share of the total.
+    pub fn render_table(&self) -> String {
+        let mut out = format!("  {:<11} {:>9} {:>7}\n", "Phase", "Time", "Share");
+        for (phase, took) in &self.phases {
+            let share = if self.total.is_zero() {
+                0.0
+            } else {
+                took.as_secs_f64() / self.total.as_secs_f64() * 100.0
+            };
+            out.push_str(&format!(
+                "  {phase:<11} {:>9} {share:>6.1}%\n",
+                format_ms(*took)
+            ));
+        }
+        out.push_str(&format!("  {:<11} {:>9}\n", "total", format_ms(self.total)));
+        out
+    }
+
+    /// The phases as `{"load_ms": ..., ..., "total_ms": ...}`, for a result's
+    /// `meta.timing`.
+    pub fn to_json(&self) -> Value {
+        let mut timing = Map::new();
+        for (phase, took) in &self.phases {
+            timing.insert(format!("{phase}_ms"), ms(*took));
+        }
+        timing.insert("total_ms".to_string(), ms(self.total));
+        Value::Object(timing)
+    }
+}
+
+/// Insert `timing` as `meta.timing` of the `ToolResult` in `result`.
+pub fn with_meta(result: &str, timing: &Timing) -> Result<String> {
+    let mut value: Value =
+        serde_json::from_str(result.trim()).context("tool output is not JSON")?;
+    let Some(object) = value.as_object_mut() else {
+        bail!("tool output is not a JSON object");
+    };
+    let meta = object
+        .entry("meta")
+        .or_insert_with(|| Value::Object(Map::new()));
+    let Some(meta) = meta.as_object_mut() else {
+        bail!("the tool's meta is not a JSON object");
+    };
+    meta.insert("timing".to_string(), timing.to_json());
+    Ok(serde_json::to_string(&value)?)
+}
+
+fn ms(took: Duration) -> Value {
+    Value::from(took.as_secs_f64() * 1000.0)
+}
+
+fn format_ms(took: Duration) -> String {
+    format!("{:.1} ms", took.as_secs_f64() * 1000.0)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    /// The phases `skill test` marks, in the order they run.
+    const PHASES: [&str; 5] = ["load", "instantiate", "input", "execute", "decode"];
+
+    #[test]
+    fn phases_add_up_to_the_wall_time() {
+        let outer = Instant::now();
+        let mut timer = PhaseTimer::start();
+        for phase in PHASES {
+            std::thread::sleep(Duration::from_millis(2));
+            timer.mark(phase);
+        }
+        let timing = timer.finish();
+        let wall = outer.elapsed();
+
+        let names: Vec<&str> = timing.phases.iter().map(|(phase, _)| *phase).collect();
+        assert_eq!(names, PHASES);
+        let sum: Duration = timing.phases.iter().map(|(_, took)| *took).sum();
+        assert!(timing
+            .phases
+            .iter()
+            .all(|(_, took)| *took >= Duration::from_millis(2)));
+        assert!(sum <= timing.total && timing.total <= wall);
+        assert!(
+            wall - sum < Duration::from_millis(50),
+            "{sum:?} of {wall:?}"
+        );
+
+        let json = timing.to_json();
+        for phase in PHASES {
+            assert!(json[format!("{phase}_ms")].as_f64().unwrap() >= 0.0);
+        }
+        assert_eq!(timing.render_table().lines().count(), PHASES.len() + 2);
+    }
+
+    #[test]
+    fn timing_goes_under_meta() {
+        let timing = Timing {
+            phases: vec![("load", Duration::from_millis(1))],
+            total: Duration::from_millis(2),
+        };
+        let out = with_meta(r#"{"success":true,"meta":{"trace_id":"t"}}"#, &timing).unwrap();
+        let value: Value = serde_json::from_str(&out).unwrap();
+        assert_eq!(value["meta"]["trace_id"], "t");
+        assert_eq!(value["meta"]["timing"]["load_ms"], 1.0);
+        assert_eq!(value["meta"]["timing"]["total_ms"], 2.0);
+    }
+}
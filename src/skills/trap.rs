@@ -0,0 +1,178 @@
+//! Guest traps: a module that hits `unreachable`, reads out of bounds, or
+//! otherwise aborts leaves no result on stdout, only wasmtime's report on
+//! stderr. The host reads the trap's reason and, when the module carries
+//! debug info, where it happened out of that report, and answers in the
+//! tool's place with an `internal` result.
+
+/// The error code of the result written for a trapped tool.
+pub const INTERNAL: &str = "internal";
+
+/// A tool trapped: what wasmtime says went wrong and, best effort, the
+/// innermost frame that has a source location, e.g. `main.crash main.go:12:5`.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct GuestTrap {
+    pub reason: String,
+    pub location: Option<String>,
+}
+
+impl GuestTrap {
+    /// Read a trap out of wasmtime's stderr, or `None` when the tool failed
+    /// some other way, such as an exit status or a module that would not load.
+    pub fn parse(stderr: &str) -> Option<Self> {
+        let reason = stderr.lines().find_map(|line| {
+            let (_, reason) = line.split_once("wasm trap: ")?;
+            Some(reason.trim().to_string())
+        })?;
+        Some(Self {
+            reason,
+            location: location(stderr),
+        })
+    }
+
+    /// The `ToolResult` the host writes on the trapped tool's behalf.
+    pub fn result(&self) -> String {
+        serde_json::json!({
+            "success": false,
+            "output": "",
+            "error": self.to_string(),
+            "error_code": INTERNAL,
+        })
+        .to_string()
+    }
+}
+
+/// The first frame of a wasm backtrace followed by an `at file:line` line.
+/// Modules built without debug info have none.
+fn location(stderr: &str) -> Option<String> {
+    let mut function = None;
+    for line in stderr.lines().map(str::trim) {
+        if let Some(at) = line.strip_prefix("at ") {
+            return Some(match function {
+                Some(function) => format!("{function} {}", at.trim()),
+                None => at.trim().to_string(),
+            });
+        }
+        // A frame reads `0: 0x1f2 - main.crash`.
+        function = line
+            .split_once(" - ")
+            .filter(|(frame, _)| {
+                let mut frame = frame.split_whitespace();
+                frame.next().is_some_and(|n| n.ends_with(':'))
+                    && frame.next().is_some_and(|addr| addr.starts_with("0x"))
+            })
+            .map(|(_, function)| function.trim())
+            .filter(|function| !function.contains("<unknown>"));
+    }
+    None
+}
+
+impl std::fmt::Display for GuestTrap {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        write!(f, "tool trapped: {}", self.reason)?;
+        if let Some(location) = &self.location {
+            write!(f, " (at {location})")?;
+        }
+        Ok(())
+    }
+}
+
+impl std::error::Error for GuestTrap {}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const WITH_DEBUG_INFO: &str = "Error: failed to run main module `tool.wasm`
+
+Caused by:
+    0: failed to invoke command default
+    1: error while executing at wasm backtrace:
+           0:  0x1a3f - main.crash
+                           at /skills/trap/main.go:12:5
+           1:  0x2b10 - main.main
+                           at /skills/trap/main.go:20:7
+           2:  0x9c01 - <unknown>!_start
+    2: wasm trap: wasm `unreachable` instruction executed
+";
+
+    const WITHOUT_DEBUG_INFO: &str = "Error: failed to run main module `tool.wasm`
+
+Caused by:
+    0: failed to invoke command default
+    1: error while executing at wasm backtrace:
+           0:   0x2d - <unknown>!<wasm function 0>
+    2: wasm trap: out of bounds memory access
+";
+
+    #[test]
+    fn reads_reason_and_location() {
+        let trap = GuestTrap::parse(WITH_DEBUG_INFO).unwrap();
+        assert_eq!(trap.reason, "wasm `unreachable` instruction executed");
+        assert_eq!(
+            trap.location.as_deref(),
+            Some("main.crash /skills/trap/main.go:12:5")
+        );
+        assert_eq!(
+            trap.to_string(),
+            "tool trapped: wasm `unreachable` instruction executed \
+             (at main.crash /skills/trap/main.go:12:5)"
+        );
+    }
+
+    #[test]
+    fn location_needs_debug_info() {
+        let trap = GuestTrap::parse(WITHOUT_DEBUG_INFO).unwrap();
+        assert_eq!(trap.reason, "out of bounds memory access");
+        assert_eq!(trap.location, None);
+        assert_eq!(
+            trap.to_string(),
+            "tool trapped: out of bounds memory access"
+        );
+    }
+
+    #[test]
+    fn other_failures_are_not_traps() {
+        let exited = "Error: failed to run main module `tool.wasm`
+
+Caused by:
+    Exited with i32 exit status 4
+";
+        assert_eq!(GuestTrap::parse(exited), None);
+    }
+
+    #[test]
+    fn result_is_internal() {
+        let trap = GuestTrap::parse(WITHOUT_DEBUG_INFO).unwrap();
+        let result: serde_json::Value = serde_json::from_str(&trap.result()).unwrap();
+        assert_eq!(result["success"], false);
+        assert_eq!(result["error_code"], INTERNAL);
+        assert_eq!(result["error"], "tool trapped: out of bounds memory access");
+    }
+
+    /// A skill that traps as soon as it starts; wasmtime runs the text
+    /// format as it is. Skipped where wasmtime is not installed.
+    #[test]
+    fn trapping_skill_fails_as_internal() {
+        use super::super::{check_tool_result, doctor, exit_code, host_result, run_wasm_tool};
+
+        if doctor::command_stdout("wasmtime", &["--version"]).is_none() {
+            eprintln!("skipping: wasmtime is not installed");
+            return;
+        }
+        let dir = tempfile::tempdir().unwrap();
+        let module = dir.path().join("tool.wat");
+        std::fs::write(
+            &module,
+            r#"(module (memory (export "memory") 1) (func (export "_start") unreachable))"#,
+        )
+        .unwrap();
+
+        let err = run_wasm_tool(&module, "{}").unwrap_err();
+        let trap = err.downcast_ref::<GuestTrap>().expect("a GuestTrap");
+        assert!(trap.reason.contains("unreachable"), "{trap}");
+
+        let stdout = host_result(err).unwrap();
+        let failure = check_tool_result(&stdout).unwrap_err();
+        assert_eq!(exit_code(&failure), 16, "{failure}");
+    }
+}
@@ -0,0 +1,163 @@
+//! `zeroclaw skill validate` — check args against a skill's manifest without
+//! running it.
+//!
+//! The manifest's `parameters` schema supplies `default` values for missing
+//! object properties, which are filled in first, and is then checked with the
+//! same JSON Schema subset as `skill test --check-output`. Checks a handler
+//! makes after decoding are not run: args that pass here can still fail in
+//! the skill itself.
+//!
+//! With `--warn-undocumented`, properties the schema gives no `description`
+//! are listed too: LLM tool registrations show the descriptions, so an
+//! undocumented field is one the model has to guess at. Go skills set them
+//! with a `desc:"..."` struct tag.
+
+use super::output_check;
+use serde_json::Value;
+
+/// What a validated call would send: `args` with every default applied.
+/// Keys are sorted, as the Go SDK writes them.
+pub fn validate_args(schema: &Value, mut args: Value) -> Result<Value, Vec<String>> {
+    apply_defaults(schema, &mut args);
+    let violations = output_check::check_value("args", schema, &args);
+    if violations.is_empty() {
+        Ok(args)
+    } else {
+        Err(violations)
+    }
+}
+
+/// The dotted paths, starting at `args`, of every property at any depth that
+/// has no non-empty `description`. Array items are written `name[]`.
+pub fn undocumented_fields(schema: &Value) -> Vec<String> {
+    let mut paths = Vec::new();
+    collect_undocumented("args", schema, &mut paths);
+    paths
+}
+
+fn collect_undocumented(path: &str, schema: &Value, paths: &mut Vec<String>) {
+    if let Some(props) = schema.get("properties").and_then(Value::as_object) {
+        for (name, field_schema) in props {
+            let field = format!("{path}.{name}");
+            let documented = field_schema
+                .get("description")
+                .and_then(Value::as_str)
+                .is_some_and(|d| !d.trim().is_empty());
+            if !documented {
+                paths.push(field.clone());
+            }
+            collect_undocumented(&field, field_schema, paths);
+        }
+    }
+    if let Some(items) = schema.get("items") {
+        collect_undocumented(&format!("{path}[]"), items, paths);
+    }
+}
+
+/// Fill in the `default` of each property `value` lacks, at any depth.
+fn apply_defaults(schema: &Value, value: &mut Value) {
+    match value {
+        Value::Object(fields) => {
+            let Some(props) = schema.get("properties").and_then(Value::as_object) else {
+                return;
+            };
+            for (name, field_schema) in props {
+                match fields.get_mut(name) {
+                    Some(field) => apply_defaults(field_schema, field),
+                    None => {
+                        if let Some(default) = field_schema.get("default") {
+                            fields.insert(name.clone(), default.clone());
+                        }
+                    }
+                }
+            }
+        }
+        Value::Array(items) => {
+            if let Some(item_schema) = schema.get("items") {
+                for item in items {
+                    apply_defaults(item_schema, item);
+                }
+            }
+        }
+        _ => {}
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    fn word_count_params() -> Value {
+        json!({
+            "type": "object",
+            "required": ["text"],
+            "properties": {
+                "text": {"type": "string"},
+                "trim": {"type": "string", "enum": ["none", "edges", "collapse"], "default": "none"},
+                "options": {
+                    "type": "object",
+                    "properties": {"wpm": {"type": "integer", "default": 200}}
+                }
+            }
+        })
+    }
+
+    #[test]
+    fn valid_args_get_their_defaults() {
+        let args = json!({"text": "hi", "options": {}});
+        assert_eq!(
+            validate_args(&word_count_params(), args)
+                .unwrap()
+                .to_string(),
+            r#"{"options":{"wpm":200},"text":"hi","trim":"none"}"#
+        );
+    }
+
+    #[test]
+    fn given_values_are_kept() {
+        let args = json!({"text": "hi", "trim": "edges"});
+        assert_eq!(
+            validate_args(&word_count_params(), args).unwrap()["trim"],
+            "edges"
+        );
+    }
+
+    #[test]
+    fn undocumented_fields_are_listed_at_any_depth() {
+        let schema = json!({
+            "type": "object",
+            "properties": {
+                "text": {"type": "string", "description": "Text to analyze"},
+                "trim": {"type": "string", "description": " "},
+                "options": {
+                    "type": "object",
+                    "description": "Tuning",
+                    "properties": {"wpm": {"type": "integer"}}
+                },
+                "tags": {
+                    "type": "array",
+                    "description": "Labels",
+                    "items": {"type": "object", "properties": {"name": {"type": "string"}}}
+                }
+            }
+        });
+        assert_eq!(
+            undocumented_fields(&schema),
+            vec!["args.options.wpm", "args.tags[].name", "args.trim"]
+        );
+    }
+
+    #[test]
+    fn invalid_args_are_reported() {
+        let args = json!({"trim": "all", "options": {"wpm": "fast"}});
+        assert_eq!(
+            validate_args(&word_count_params(), args).unwrap_err(),
+            vec![
+                "args.text: missing required field",
+                "args.options.wpm: expected integer, got string",
+                "args.trim: \"all\" is not one of the allowed values",
+            ]
+        );
+    }
+}
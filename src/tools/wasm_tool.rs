@@ -30,6 +30,12 @@
 //!   [`WASM_TIMEOUT_SECS`] epochs so runaway modules are preempted without
 //!   relying on OS-level process signals.
 //! - Output capped at 1 MiB (enforced by [`MemoryOutputPipe`] capacity).
+//!
+//! Both limits are advertised to the module in `ZEROCLAW_MAX_OUTPUT_BYTES` and
+//! `ZEROCLAW_DEADLINE` (RFC 3339, UTC) so it can trim its result to fit
+//! rather than be cut off; see `skill.Budget` in the Go SDK. The module is
+//! also told `ZEROCLAW_MAX_INPUT_BYTES`, past which SDK-built skills refuse
+//! their args rather than buffer them.
 
 use super::traits::{Tool, ToolResult};
 use anyhow::{bail, Context};
@@ -40,15 +46,41 @@ use std::path::Path;
 /// Maximum tool output size (1 MiB).
 const MAX_OUTPUT_BYTES: usize = 1_048_576;
 
+/// Most bytes of args a guest is told to read (16 MiB).
+const MAX_INPUT_BYTES: usize = 16 << 20;
+
 /// Wall-clock timeout for a single WASM invocation.
-const WASM_TIMEOUT_SECS: u64 = 30;
+pub(crate) const WASM_TIMEOUT_SECS: u64 = 30;
+
+/// Environment variable carrying [`MAX_OUTPUT_BYTES`] to the guest.
+const MAX_OUTPUT_BYTES_ENV: &str = "ZEROCLAW_MAX_OUTPUT_BYTES";
+
+/// Environment variable carrying [`MAX_INPUT_BYTES`] to the guest.
+const MAX_INPUT_BYTES_ENV: &str = "ZEROCLAW_MAX_INPUT_BYTES";
+
+/// Environment variable carrying the invocation's deadline to the guest.
+const DEADLINE_ENV: &str = "ZEROCLAW_DEADLINE";
+
+/// The `(name, value)` pairs that tell a guest starting now about its limits.
+#[cfg_attr(not(feature = "wasm-tools"), allow(dead_code))]
+fn budget_env(now: chrono::DateTime<chrono::Utc>) -> [(&'static str, String); 3] {
+    let deadline = now + chrono::Duration::seconds(WASM_TIMEOUT_SECS as i64);
+    [
+        (MAX_OUTPUT_BYTES_ENV, MAX_OUTPUT_BYTES.to_string()),
+        (MAX_INPUT_BYTES_ENV, MAX_INPUT_BYTES.to_string()),
+        (
+            DEADLINE_ENV,
+            deadline.to_rfc3339_opts(chrono::SecondsFormat::Millis, true),
+        ),
+    ]
+}
 
 // ─── Feature-gated implementation ─────────────────────────────────────────────
 
 #[cfg(feature = "wasm-tools")]
 mod inner {
     use super::{
-        async_trait, bail, Context, Path, Tool, ToolResult, Value, MAX_OUTPUT_BYTES,
+        async_trait, bail, budget_env, Context, Path, Tool, ToolResult, Value, MAX_OUTPUT_BYTES,
         WASM_TIMEOUT_SECS,
     };
     use wasmtime::{Config as WtConfig, Engine, Linker, Module, Store};
@@ -86,27 +118,112 @@ mod inner {
                 .with_context(|| format!("cannot read WASM file: {}", path.display()))?;
             let module = Module::new(&engine, &bytes)
                 .with_context(|| format!("cannot compile WASM module: {}", path.display()))?;
+            Ok(Self::from_module(
+                name,
+                description,
+                parameters_schema,
+                engine,
+                module,
+            ))
+        }
 
-            Ok(Self {
+        fn from_module(
+            name: String,
+            description: String,
+            parameters_schema: Value,
+            engine: Engine,
+            module: Module,
+        ) -> Self {
+            Self {
                 name,
                 description,
                 parameters_schema,
                 engine,
                 module,
                 is_running: std::sync::Arc::new(std::sync::atomic::AtomicBool::new(false)),
-            })
+            }
+        }
+
+        /// Compile `wasm` and run it once with `args`, as an installed tool
+        /// would be run.
+        pub fn run_bytes(wasm: &[u8], args: &Value) -> anyhow::Result<ToolResult> {
+            let mut cfg = WtConfig::new();
+            cfg.epoch_interruption(true);
+            let engine = Engine::new(&cfg).context("failed to create WASM engine")?;
+            let module = Module::new(&engine, wasm).context("cannot compile WASM module")?;
+            Self::from_module(String::new(), String::new(), Value::Null, engine, module)
+                .invoke_sync(args)
+        }
+
+        /// Run the compiled module once, in a fresh store and instance so no
+        /// state carries over from an earlier call, and return its stdout.
+        ///
+        /// Exits 2 and 3 are the SDK's invalid-input and panic statuses:
+        /// stdout still carries a `ToolResult`, so they are not errors.
+        pub fn call_stdout(&self, args: &Value) -> anyhow::Result<String> {
+            let (call_result, raw) = self.run_once(args)?;
+            if let Err(e) = call_result {
+                match e.downcast_ref::<wasmtime_wasi::I32Exit>() {
+                    Some(exit) if matches!(exit.0, 0 | 2 | 3) => {}
+                    _ => return Err(e),
+                }
+            }
+            Ok(String::from_utf8_lossy(&raw).into_owned())
+        }
+
+        /// Whether `err`, from [`call_stdout`](Self::call_stdout), is a call
+        /// stopped at the [`WASM_TIMEOUT_SECS`] deadline.
+        pub fn timed_out(err: &anyhow::Error) -> bool {
+            matches!(
+                err.downcast_ref::<wasmtime::Trap>(),
+                Some(wasmtime::Trap::Interrupt)
+            )
+        }
+
+        /// Create an instance with empty stdio and drop it without running
+        /// `_start`, showing the module links and instantiates.
+        pub fn instantiate(&self) -> anyhow::Result<()> {
+            let mut store = Store::new(&self.engine, WasiCtxBuilder::new().build_p1());
+            store.set_epoch_deadline(WASM_TIMEOUT_SECS);
+            let mut linker: Linker<WasiP1Ctx> = Linker::new(&self.engine);
+            preview1::add_to_linker_sync(&mut linker, |ctx| ctx)
+                .context("failed to add WASI to linker")?;
+            linker
+                .instantiate(&mut store, &self.module)
+                .context("cannot instantiate WASM module")?;
+            Ok(())
         }
 
         fn invoke_sync(&self, args: &Value) -> anyhow::Result<ToolResult> {
+            let (call_result, raw) = self.run_once(args)?;
+            call_result?;
+
+            if raw.is_empty() {
+                bail!("WASM tool wrote nothing to stdout");
+            }
+            // Note: MemoryOutputPipe::new(MAX_OUTPUT_BYTES) already caps writes
+            // at construction time, so no separate size check is needed here.
+
+            serde_json::from_slice::<ToolResult>(&raw)
+                .context("WASM tool stdout is not valid ToolResult JSON")
+        }
+
+        /// Instantiate the module with `args` on stdin and run `_start`,
+        /// returning how the call ended and everything written to stdout.
+        fn run_once(&self, args: &Value) -> anyhow::Result<(anyhow::Result<()>, Vec<u8>)> {
             let input_bytes = serde_json::to_vec(args)?;
 
             let stdout_pipe = MemoryOutputPipe::new(MAX_OUTPUT_BYTES);
             let stdout_for_read = stdout_pipe.clone();
 
-            let wasi_ctx: WasiP1Ctx = WasiCtxBuilder::new()
+            let mut builder = WasiCtxBuilder::new();
+            builder
                 .stdin(MemoryInputPipe::new(input_bytes))
-                .stdout(stdout_pipe)
-                .build_p1();
+                .stdout(stdout_pipe);
+            for (key, value) in budget_env(chrono::Utc::now()) {
+                builder.env(key, value);
+            }
+            let wasi_ctx: WasiP1Ctx = builder.build_p1();
 
             let mut store = Store::new(&self.engine, wasi_ctx);
             // epoch_deadline is in ticks; the incrementer thread below fires at 1 Hz.
@@ -145,17 +262,7 @@ mod inner {
             let _ = stop_tx.send(());
             let _ = ticker.join();
 
-            call_result?;
-
-            let raw = stdout_for_read.contents().to_vec();
-            if raw.is_empty() {
-                bail!("WASM tool wrote nothing to stdout");
-            }
-            // Note: MemoryOutputPipe::new(MAX_OUTPUT_BYTES) already caps writes
-            // at construction time, so no separate size check is needed here.
-
-            serde_json::from_slice::<ToolResult>(&raw)
-                .context("WASM tool stdout is not valid ToolResult JSON")
+            Ok((call_result, stdout_for_read.contents().to_vec()))
         }
     }
 
@@ -246,6 +353,31 @@ mod inner {
                 parameters_schema,
             })
         }
+
+        pub fn run_bytes(_wasm: &[u8], _args: &Value) -> anyhow::Result<ToolResult> {
+            bail!(
+                "WASM tools are not enabled in this build. \
+                 Recompile with '--features wasm-tools'."
+            )
+        }
+
+        pub fn timed_out(_err: &anyhow::Error) -> bool {
+            false
+        }
+
+        pub fn instantiate(&self) -> anyhow::Result<()> {
+            bail!(
+                "WASM tools are not enabled in this build. \
+                 Recompile with '--features wasm-tools'."
+            )
+        }
+
+        pub fn call_stdout(&self, _args: &Value) -> anyhow::Result<String> {
+            bail!(
+                "WASM tools are not enabled in this build. \
+                 Recompile with '--features wasm-tools'."
+            )
+        }
     }
 
     #[async_trait]
@@ -289,6 +421,9 @@ pub use inner::WasmToolImpl as WasmTool;
 /// - Installed layout: `<skill-dir>/tools/<tool-name>/manifest.json`
 #[derive(Debug, Clone, serde::Serialize, serde::Deserialize)]
 pub struct WasmManifest {
+    /// The manifest schema this file follows; [`load_from`](Self::load_from)
+    /// upgrades older manifests to [`MANIFEST_SCHEMA_VERSION`] first.
+    pub schema_version: u64,
     /// Tool name exposed to the LLM (snake_case, e.g. `my_weather_tool`).
     pub name: String,
     /// Human-readable description shown to the LLM.
@@ -301,17 +436,119 @@ pub struct WasmManifest {
     /// Optional homepage / source URL (shown in `zeroclaw skill list`).
     #[serde(default)]
     pub homepage: Option<String>,
+    /// Whether calling the tool again with the same args is safe.
+    #[serde(default)]
+    pub idempotent: bool,
+    /// What calling the tool does to state outside it; `write` unless the
+    /// manifest says otherwise.
+    #[serde(default)]
+    pub side_effects: SideEffects,
+}
+
+/// The manifest's `side_effects` class.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq, serde::Serialize, serde::Deserialize)]
+#[serde(rename_all = "lowercase")]
+pub enum SideEffects {
+    /// The tool only computes on its args.
+    None,
+    /// The tool reads files or the network but changes nothing.
+    Read,
+    /// The tool may change state; assumed for manifests that do not say.
+    #[default]
+    Write,
+}
+
+impl std::fmt::Display for SideEffects {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.write_str(match self {
+            Self::None => "none",
+            Self::Read => "read",
+            Self::Write => "write",
+        })
+    }
 }
 
 fn default_manifest_version() -> String {
     "1".to_string()
 }
 
+/// The manifest schema this build reads and writes. Version 1 is every
+/// manifest written before `schema_version` existed.
+pub const MANIFEST_SCHEMA_VERSION: u64 = 2;
+
+/// Upgrade a parsed manifest to [`MANIFEST_SCHEMA_VERSION`] in place,
+/// returning the version it was at, or `None` when it already was current.
+/// A manifest from a newer schema fails: its fields may mean something this
+/// build does not know.
+///
+/// Version 2 spells `"jsonl": true` as `"input": "ndjson"`.
+pub fn migrate_manifest(manifest: &mut Value) -> anyhow::Result<Option<u64>> {
+    let Some(fields) = manifest.as_object_mut() else {
+        bail!("manifest is not a JSON object");
+    };
+    let from = match fields.get("schema_version") {
+        None => 1,
+        Some(v) => match v.as_u64() {
+            Some(n) if n >= 1 => n,
+            _ => bail!("schema_version must be a positive integer, got {v}"),
+        },
+    };
+    if from > MANIFEST_SCHEMA_VERSION {
+        bail!(
+            "manifest schema_version {from} is newer than this zeroclaw understands \
+             (up to {MANIFEST_SCHEMA_VERSION}); upgrade zeroclaw to use this skill"
+        );
+    }
+    if from == MANIFEST_SCHEMA_VERSION {
+        return Ok(None);
+    }
+    if from < 2 && fields.remove("jsonl") == Some(Value::Bool(true)) {
+        fields
+            .entry("input")
+            .or_insert_with(|| Value::String("ndjson".into()));
+    }
+    fields.insert("schema_version".into(), MANIFEST_SCHEMA_VERSION.into());
+    Ok(Some(from))
+}
+
 impl WasmManifest {
+    /// The description given to the LLM: the manifest's, followed by its
+    /// side effects and idempotency when they are safer than the default,
+    /// e.g. `Count words [side effects: read; idempotent]`, so that an
+    /// agent can tell read-only tools apart.
+    pub fn tool_description(&self) -> String {
+        let mut notes = Vec::new();
+        if self.side_effects != SideEffects::Write {
+            notes.push(format!("side effects: {}", self.side_effects));
+        }
+        if self.idempotent {
+            notes.push("idempotent".to_string());
+        }
+        if notes.is_empty() {
+            return self.description.clone();
+        }
+        format!("{} [{}]", self.description, notes.join("; "))
+    }
+
+    /// Read the manifest at `path`, upgrading it from an older schema with a
+    /// warning; `zeroclaw skill migrate` rewrites the file itself.
     pub fn load_from(path: &Path) -> anyhow::Result<Self> {
         let bytes = std::fs::read(path)
             .with_context(|| format!("cannot read manifest: {}", path.display()))?;
-        serde_json::from_slice(&bytes)
+        let mut manifest: Value = serde_json::from_slice(&bytes)
+            .with_context(|| format!("invalid manifest JSON: {}", path.display()))?;
+        // The reason goes in the message: the loader logs only its top line.
+        if let Some(from) = migrate_manifest(&mut manifest)
+            .map_err(|e| anyhow::anyhow!("unsupported manifest {}: {e}", path.display()))?
+        {
+            tracing::warn!(
+                path = %path.display(),
+                from,
+                to = MANIFEST_SCHEMA_VERSION,
+                "upgraded an old manifest schema; run `zeroclaw skill migrate` to rewrite it"
+            );
+        }
+        serde_json::from_value(manifest)
             .with_context(|| format!("invalid manifest JSON: {}", path.display()))
     }
 }
@@ -448,7 +685,7 @@ fn load_single_tool(
     match WasmTool::load(
         wasm,
         manifest.name.clone(),
-        manifest.description.clone(),
+        manifest.tool_description(),
         manifest.parameters.clone(),
     ) {
         Ok(t) => {
@@ -477,6 +714,7 @@ mod tests {
     #[test]
     fn manifest_round_trips() {
         let json = serde_json::json!({
+            "schema_version": MANIFEST_SCHEMA_VERSION,
             "name": "zeroclaw_test_tool",
             "description": "Test tool",
             "parameters": { "type": "object", "properties": {} }
@@ -485,6 +723,51 @@ mod tests {
         assert_eq!(m.name, "zeroclaw_test_tool");
         assert_eq!(m.version, "1");
         assert!(m.homepage.is_none());
+        assert!(!m.idempotent);
+        assert_eq!(m.side_effects, SideEffects::Write);
+        assert_eq!(m.tool_description(), "Test tool");
+    }
+
+    #[test]
+    fn manifest_side_effects_reach_description() {
+        let json = serde_json::json!({
+            "schema_version": MANIFEST_SCHEMA_VERSION,
+            "name": "zeroclaw_test_tool",
+            "description": "Count words",
+            "parameters": { "type": "object", "properties": {} },
+            "side_effects": "read",
+            "idempotent": true
+        });
+        let m: WasmManifest = serde_json::from_value(json).unwrap();
+        assert_eq!(m.side_effects, SideEffects::Read);
+        assert_eq!(
+            m.tool_description(),
+            "Count words [side effects: read; idempotent]"
+        );
+
+        let json = serde_json::json!({
+            "schema_version": MANIFEST_SCHEMA_VERSION,
+            "name": "zeroclaw_test_tool",
+            "description": "Delete files",
+            "parameters": {},
+            "side_effects": "destroy"
+        });
+        assert!(serde_json::from_value::<WasmManifest>(json).is_err());
+    }
+
+    #[test]
+    fn budget_env_advertises_caps_and_deadline() {
+        let now = chrono::DateTime::parse_from_rfc3339("2026-01-02T03:04:05.678Z")
+            .unwrap()
+            .with_timezone(&chrono::Utc);
+        assert_eq!(
+            budget_env(now),
+            [
+                ("ZEROCLAW_MAX_OUTPUT_BYTES", "1048576".to_string()),
+                ("ZEROCLAW_MAX_INPUT_BYTES", "16777216".to_string()),
+                ("ZEROCLAW_DEADLINE", "2026-01-02T03:04:35.678Z".to_string()),
+            ]
+        );
     }
 
     #[test]
@@ -542,6 +825,7 @@ mod tests {
     #[test]
     fn manifest_with_optional_fields_parsed() {
         let json = serde_json::json!({
+            "schema_version": MANIFEST_SCHEMA_VERSION,
             "name": "zeroclaw_optional_test",
             "description": "Tool with all optional fields",
             "parameters": { "type": "object", "properties": {} },
@@ -556,6 +840,54 @@ mod tests {
         );
     }
 
+    // ── Manifest schema versions ──────────────────────────────────────────────
+
+    #[test]
+    fn v1_manifest_loads_into_current_struct() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("manifest.json");
+        let v1 = serde_json::json!({
+            "name": "zeroclaw_v1_tool",
+            "version": "1",
+            "description": "Written before schema_version",
+            "parameters": { "type": "object", "properties": {} },
+            "jsonl": true
+        });
+        std::fs::write(&path, v1.to_string()).unwrap();
+        let m = WasmManifest::load_from(&path).unwrap();
+        assert_eq!(m.schema_version, MANIFEST_SCHEMA_VERSION);
+        assert_eq!(m.name, "zeroclaw_v1_tool");
+
+        let mut migrated = v1;
+        assert_eq!(migrate_manifest(&mut migrated).unwrap(), Some(1));
+        assert_eq!(migrated["input"], "ndjson");
+        assert!(migrated.get("jsonl").is_none());
+        assert_eq!(migrate_manifest(&mut migrated).unwrap(), None);
+    }
+
+    #[test]
+    fn future_manifest_schema_is_rejected() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("manifest.json");
+        let future = serde_json::json!({
+            "schema_version": MANIFEST_SCHEMA_VERSION + 1,
+            "name": "zeroclaw_future_tool",
+            "description": "From a newer zeroclaw",
+            "parameters": {}
+        });
+        std::fs::write(&path, future.to_string()).unwrap();
+        let msg = format!("{:#}", WasmManifest::load_from(&path).unwrap_err());
+        assert!(
+            msg.contains("schema_version 3 is newer than this zeroclaw understands (up to 2)"),
+            "unexpected error: {msg}"
+        );
+
+        for bad in [serde_json::json!(0), serde_json::json!("2")] {
+            let mut manifest = serde_json::json!({ "schema_version": bad });
+            assert!(migrate_manifest(&mut manifest).is_err(), "{bad}");
+        }
+    }
+
     // ── load_wasm_tools_from_skills: skip / layout detection ─────────────────
 
     #[test]
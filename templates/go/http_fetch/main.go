@@ -0,0 +1,83 @@
+// __SKILL_NAME__ — ZeroClaw Skill (Go / WASI)
+//
+// Fetches a URL and reports its HTTP status and body length.
+// Protocol: read JSON from stdin, write JSON result to stdout.
+// Build:    tinygo build -target=wasip1 -o tool.wasm .
+// Network:  wasip1 has no outbound sockets, so requests go through the host's
+//           zeroclaw_http_get import (skill.Get). The host only serves it to
+//           skills whose manifest sets capabilities.net.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zeroclaw-labs/zeroclaw/sdk/go/skill"
+)
+
+// Args is printed as JSON Schema by `tool.wasm --schema`; keep the desc tags
+// in sync with manifest.json.
+type Args struct {
+	URL string `json:"url" desc:"http:// or https:// URL to GET"`
+}
+
+type FetchResult struct {
+	Status int `json:"status"`
+	Length int `json:"length"`
+}
+
+func main() {
+	skill.Run(fetch,
+		skill.Expect(`{"url":"https://example.com"}`),
+		skill.ToolName("__SKILL_NAME__"),
+		skill.OutputFor[FetchResult](),
+		skill.Requires("net"), // keep in sync with manifest capabilities.net
+	)
+}
+
+func fetch(args Args) skill.ToolResult {
+	if !strings.HasPrefix(args.URL, "http://") && !strings.HasPrefix(args.URL, "https://") {
+		return skill.FailCode(skill.CodeInvalidInput, fmt.Sprintf("url must start with http:// or https://, got %q", args.URL))
+	}
+	resp, err := skill.Get(args.URL)
+	switch {
+	case errors.Is(err, skill.ErrNetDenied):
+		return skill.FailCode(skill.CodePermissionDenied, err.Error())
+	case errors.Is(err, skill.ErrFetchRateLimited), errors.Is(err, skill.ErrFetchLimit):
+		return skill.FailCode(skill.CodeRateLimited, err.Error())
+	case errors.Is(err, skill.ErrBadURL):
+		return skill.FailCode(skill.CodeInvalidInput, err.Error())
+	case errors.Is(err, skill.ErrFetchDeadline):
+		return skill.FailCode(skill.CodeTimeout, err.Error())
+	case err != nil:
+		return skill.FailCode(skill.CodeInternal, err.Error())
+	}
+
+	data := &FetchResult{Status: resp.Status, Length: resp.Length}
+	summary := fmt.Sprintf("GET %s: status %d, %d bytes", args.URL, resp.Status, resp.Length)
+	if resp.Status >= 200 && resp.Status <= 299 {
+		return skill.OK(summary, data)
+	}
+	res := skill.FailCode(statusCode(resp.Status), summary)
+	res.Data = data
+	return res
+}
+
+// statusCode classifies an HTTP error status for ToolResult.ErrorCode. It
+// avoids net/http, which would bloat a TinyGo build for a few constants.
+func statusCode(status int) skill.ErrorCode {
+	switch status {
+	case 404, 410:
+		return skill.CodeNotFound
+	case 401, 403:
+		return skill.CodePermissionDenied
+	case 429:
+		return skill.CodeRateLimited
+	case 408, 504:
+		return skill.CodeTimeout
+	default:
+		return skill.CodeInternal
+	}
+}
@@ -0,0 +1,7 @@
+module __SKILL_NAME__
+
+go 1.21
+
+require github.com/zeroclaw-labs/zeroclaw/sdk/go v0.1.0
+
+replace github.com/zeroclaw-labs/zeroclaw/sdk/go => ../../../sdk/go // zeroclaw:dev-only
@@ -0,0 +1,130 @@
+// __SKILL_NAME__ — ZeroClaw Skill (Go / WASI)
+//
+// Reports the size, format, and dimensions of a base64-encoded image.
+// Protocol: read JSON from stdin, write JSON result to stdout.
+// Build:    tinygo build -target=wasip1 -o tool.wasm .
+// Binary:   JSON has no bytes type, so binary input travels as base64. A
+//           []byte field decodes it, and its schema says so.
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/zeroclaw-labs/zeroclaw/sdk/go/skill"
+)
+
+// Args is printed as JSON Schema by `tool.wasm --schema`; keep the desc tags
+// in sync with manifest.json.
+type Args struct {
+	// Data holds the image bytes; encoding/json decodes the base64 string,
+	// and malformed base64 fails as invalid_input before info is called.
+	Data []byte `json:"data_base64" desc:"The image, base64-encoded (PNG, JPEG, or GIF)"`
+	// Mime optionally declares the image's media type; a mismatch with the
+	// detected format is rejected.
+	Mime string `json:"mime,omitempty" desc:"Declared media type, e.g. image/png; must match the data"`
+}
+
+type Info struct {
+	Bytes  int    `json:"bytes"`
+	Format string `json:"format"`
+	Mime   string `json:"mime"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+func main() {
+	skill.Run(info,
+		skill.Expect(`{"data_base64":"iVBORw0KGgo...","mime":"image/png"}`),
+		skill.ToolName("__SKILL_NAME__"),
+		skill.OutputFor[Info](),
+	)
+}
+
+func info(args Args) skill.ToolResult {
+	if len(args.Data) == 0 {
+		return skill.FailCode(skill.CodeInvalidInput, "data_base64 is empty")
+	}
+	img, err := inspect(args.Data)
+	if err != nil {
+		return skill.FailCode(skill.CodeInvalidInput, err.Error())
+	}
+	if args.Mime != "" && args.Mime != img.Mime {
+		return skill.FailCode(skill.CodeInvalidInput, fmt.Sprintf("mime is %s but the data is %s", args.Mime, img.Mime))
+	}
+	summary := fmt.Sprintf("%s image, %dx%d, %d bytes", img.Format, img.Width, img.Height, img.Bytes)
+	return skill.OK(summary, img)
+}
+
+// inspect reads the format and dimensions from an image's header. It avoids
+// the image packages, which would have to decode the whole image and would
+// bloat a TinyGo build.
+func inspect(data []byte) (*Info, error) {
+	img := &Info{Bytes: len(data)}
+	var err error
+	switch {
+	case hasPrefix(data, "\x89PNG\r\n\x1a\n"):
+		img.Format, img.Mime = "PNG", "image/png"
+		img.Width, img.Height, err = pngSize(data)
+	case hasPrefix(data, "\xff\xd8\xff"):
+		img.Format, img.Mime = "JPEG", "image/jpeg"
+		img.Width, img.Height, err = jpegSize(data)
+	case hasPrefix(data, "GIF87a"), hasPrefix(data, "GIF89a"):
+		img.Format, img.Mime = "GIF", "image/gif"
+		img.Width, img.Height, err = gifSize(data)
+	default:
+		return nil, fmt.Errorf("data is not a PNG, JPEG, or GIF image")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("corrupt %s: %v", img.Format, err)
+	}
+	return img, nil
+}
+
+func hasPrefix(data []byte, magic string) bool {
+	return len(data) >= len(magic) && string(data[:len(magic)]) == magic
+}
+
+// pngSize reads the IHDR chunk, which must come first.
+func pngSize(data []byte) (int, int, error) {
+	if len(data) < 24 || string(data[12:16]) != "IHDR" {
+		return 0, 0, fmt.Errorf("missing IHDR chunk")
+	}
+	w, h := binary.BigEndian.Uint32(data[16:20]), binary.BigEndian.Uint32(data[20:24])
+	if w == 0 || h == 0 {
+		return 0, 0, fmt.Errorf("zero width or height")
+	}
+	return int(w), int(h), nil
+}
+
+// jpegSize walks the marker segments to the first start-of-frame.
+func jpegSize(data []byte) (int, int, error) {
+	for i := 2; ; {
+		if i+4 > len(data) || data[i] != 0xff {
+			return 0, 0, fmt.Errorf("no frame header before byte %d", i)
+		}
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		// SOF0-SOF15, except DHT (C4), JPG (C8), and DAC (CC).
+		if marker >= 0xc0 && marker <= 0xcf && marker != 0xc4 && marker != 0xc8 && marker != 0xcc {
+			if length < 7 || i+9 > len(data) {
+				return 0, 0, fmt.Errorf("truncated frame header")
+			}
+			h, w := binary.BigEndian.Uint16(data[i+5:i+7]), binary.BigEndian.Uint16(data[i+7:i+9])
+			return int(w), int(h), nil
+		}
+		if length < 2 {
+			return 0, 0, fmt.Errorf("bad segment length at byte %d", i)
+		}
+		i += 2 + length
+	}
+}
+
+// gifSize reads the logical screen descriptor after the signature.
+func gifSize(data []byte) (int, int, error) {
+	if len(data) < 10 {
+		return 0, 0, fmt.Errorf("truncated screen descriptor")
+	}
+	return int(binary.LittleEndian.Uint16(data[6:8])), int(binary.LittleEndian.Uint16(data[8:10])), nil
+}
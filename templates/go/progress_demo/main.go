@@ -0,0 +1,91 @@
+// __SKILL_NAME__ — ZeroClaw Skill (Go / WASI)
+//
+// Checksums a large synthetic workload in steps, reporting progress after each.
+// Protocol: read JSON from stdin, write NDJSON to stdout: progress lines, then
+//           the ToolResult on the last line.
+// Build:    tinygo build -target=wasip1 -o tool.wasm .
+// Test:     zeroclaw skill test . --args '{"items":5000000}'
+// Streaming: manifest.json sets "streaming": true so `zeroclaw skill test`
+//            draws the progress lines as a bar; keep it in sync with WithMeta.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zeroclaw-labs/zeroclaw/sdk/go/skill"
+)
+
+// Args is printed as JSON Schema by `tool.wasm --schema`; keep the desc tags
+// in sync with manifest.json.
+type Args struct {
+	Items int `json:"items,omitempty" desc:"Number of synthetic items to process; defaults to 1000000"`
+	Steps int `json:"steps,omitempty" desc:"Number of progress updates to report (1-100); defaults to 10"`
+}
+
+type ChecksumResult struct {
+	Items int `json:"items"`
+	// Checksum is hex: a uint64 does not survive JSON number parsing in hosts
+	// that read numbers as doubles.
+	Checksum string `json:"checksum"`
+}
+
+func main() {
+	skill.RunContext(checksum,
+		skill.Expect(`{"items":1000000,"steps":10}`),
+		skill.ToolName("__SKILL_NAME__"),
+		skill.OutputFor[ChecksumResult](),
+		skill.WithMeta(skill.Meta{Streaming: true}),
+	)
+}
+
+func checksum(ctx context.Context, args Args) skill.ToolResult {
+	if args.Items == 0 {
+		args.Items = 1_000_000
+	}
+	if args.Steps == 0 {
+		args.Steps = 10
+	}
+	if args.Items < 0 || args.Steps < 1 || args.Steps > 100 {
+		return skill.FailCode(skill.CodeInvalidInput, fmt.Sprintf("need items >= 0 and 1 <= steps <= 100, got items=%d steps=%d", args.Items, args.Steps))
+	}
+
+	sum := uint64(fnvOffset)
+	done := 0
+	for step := 1; step <= args.Steps; step++ {
+		// The host's deadline cancels ctx; stop between steps and say how far
+		// the work got rather than being killed mid-step with no result.
+		if err := ctx.Err(); err != nil {
+			res := skill.FailCode(skill.CodeTimeout, fmt.Sprintf("stopped after %d of %d items: %v", done, args.Items, err))
+			res.Data = &ChecksumResult{Items: done, Checksum: fmt.Sprintf("%016x", sum)}
+			return res
+		}
+		end := args.Items * step / args.Steps
+		for ; done < end; done++ {
+			sum = mix(sum, uint64(done))
+		}
+		skill.Report(ctx, skill.Progress{
+			Percent: float64(100 * step / args.Steps),
+			Message: fmt.Sprintf("%d of %d items", done, args.Items),
+		})
+	}
+	data := &ChecksumResult{Items: done, Checksum: fmt.Sprintf("%016x", sum)}
+	return skill.OK(fmt.Sprintf("checksum of %d items: %s", done, data.Checksum), data)
+}
+
+// FNV-1a parameters for the checksum.
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// mix folds one item into the running checksum (FNV-1a over its 8 bytes), so
+// the work cannot be skipped and the result depends on every item.
+func mix(sum, item uint64) uint64 {
+	for i := 0; i < 8; i++ {
+		sum ^= (item >> (8 * i)) & 0xff
+		sum *= fnvPrime
+	}
+	return sum
+}
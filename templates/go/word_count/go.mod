@@ -1,3 +1,9 @@
 module __SKILL_NAME__
 
 go 1.21
+
+require github.com/zeroclaw-labs/zeroclaw/sdk/go v0.1.0
+
+require golang.org/x/text v0.14.0
+
+replace github.com/zeroclaw-labs/zeroclaw/sdk/go => ../../../sdk/go // zeroclaw:dev-only
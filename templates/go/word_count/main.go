@@ -4,88 +4,1044 @@
 // Protocol: read JSON from stdin, write JSON result to stdout.
 // Build:    tinygo build -target=wasip1 -o tool.wasm .
 // Test:     zeroclaw skill test . --args '{"text":"hello world"}'
+// Files:    zeroclaw skill test . --preopen ./docs:/data --args '{"path":"/data/notes.txt"}'
+// Folders:  zeroclaw skill test . --preopen ./docs:/data --args '{"dir":"/data","glob":"*.txt"}'
 
 package main
 
 import (
+	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"os"
+	"io/fs"
+	"math"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/zeroclaw-labs/zeroclaw/sdk/go/skill"
+	"golang.org/x/text/unicode/norm"
 )
 
+// Args is printed as JSON Schema by `tool.wasm --schema`; keep the desc tags
+// in sync with manifest.json.
 type Args struct {
-	Text string `json:"text"`
+	Text string `json:"text,omitempty" desc:"Text to analyze" text:"clean"`
+	// Path names a file to analyze instead of Text. It must lie under a
+	// directory the host preopened (manifest capabilities.fs).
+	Path string `json:"path,omitempty" desc:"File to analyze instead of text; must be under a preopened directory"`
+	// Locale selects the language of the output summary (e.g. "fr", "pl", "ru").
+	// Empty falls back to ZEROCLAW_LOCALE, then English.
+	Locale string `json:"locale,omitempty" desc:"Language of the summary (e.g. en, pl, ru); defaults to English"`
+	// Trim normalizes whitespace before counting: "edges" trims the ends,
+	// which can lower Lines and Characters; "collapse" also turns every
+	// internal run of whitespace, newlines included, into one space, so
+	// non-empty text counts as one line. Words never change.
+	Trim string `json:"trim,omitempty" desc:"Whitespace to normalize before counting: none (default), edges, or collapse"`
+	// CountMode picks what Characters counts: "runes" (the default) counts
+	// Unicode code points, "bytes" UTF-8 bytes, and "graphemes" what a
+	// reader sees as one character, so "é" written as e + U+0301 or a
+	// family emoji joined with U+200D counts once. The validate tag makes
+	// the SDK reject any other value and list the three in the schema.
+	CountMode string `json:"count_mode,omitempty" desc:"What characters counts: runes (default), bytes, or graphemes" validate:"oneof=bytes|runes|graphemes"`
+	// Tokenizer picks how text splits into words: "whitespace" (the
+	// default) at every run of Unicode whitespace, so "hello,world" is one
+	// word; "smart" at everything but letters and digits, keeping an
+	// apostrophe or hyphen between letters ("don't", "well-known") and a
+	// point or comma between digits ("3.14", "1,000") inside the word.
+	// "uax29" at Unicode word boundaries (UAX #29), so "e.g." is the word
+	// "e.g" and "well-known" two words, while each Chinese or Japanese
+	// ideograph is a word of its own. Under "smart" and "uax29",
+	// punctuation and symbols alone, e.g. "—" or "👍", are not words.
+	// "uax29" approximates Unicode's Word_Break property, as wordBreakOf
+	// says, rather than reading its tables, so a character outside the
+	// classes it knows can break where a full UAX #29 segmenter would not.
+	Tokenizer string `json:"tokenizer,omitempty" desc:"How text splits into words: whitespace (default); smart, which also splits at punctuation but keeps words like don't and well-known whole; or uax29, at Unicode word boundaries" validate:"oneof=whitespace|smart|uax29"`
+	// Segmentation is another name for Tokenizer, for callers asking for
+	// Unicode segmentation by that name: {"segmentation":"uax29"} counts as
+	// {"tokenizer":"uax29"} does. Setting both to different values fails.
+	Segmentation string `json:"segmentation,omitempty" desc:"Another name for tokenizer, limited to the Unicode segmentation choices: whitespace (default) or uax29" validate:"oneof=whitespace|uax29"`
+	// WordRegex, instead of Tokenizer, makes each match of a regular
+	// expression a word, e.g. `\p{L}+` for runs of letters; a match of no
+	// characters is not one. Go reads it as RE2, and the JavaScript and
+	// Rust templates with their own engines, where \w, \d, and \b are ASCII
+	// in Go and JavaScript but Unicode in Rust: spell classes out, as
+	// [A-Za-z0-9_] or \p{L}, for the same words everywhere.
+	WordRegex string `json:"word_regex,omitempty" desc:"Regular expression each word matches, instead of a tokenizer, e.g. \\p{L}+"`
+	// Fields counts several named texts in one call, e.g. the title, body,
+	// and footnotes of a document, instead of Text or Path. An entry that is
+	// not a string fails on its own unless FailFast is set.
+	Fields map[string]string `json:"fields,omitempty" desc:"Named texts to count separately and in total, instead of text or path" text:"clean"`
+	// FailFast fails the whole call when any entry of Fields is bad.
+	FailFast bool `json:"fail_fast,omitempty" desc:"Fail the whole call if any field is bad, instead of reporting it per field"`
+	// Dir counts each file under a preopened directory, at any depth,
+	// instead of Text, Path, or Fields; Glob keeps only the files whose
+	// names match it (see path.Match). A file that cannot be read is
+	// skipped with a warning.
+	Dir  string `json:"dir,omitempty" desc:"Directory whose files to count separately and in total, instead of text, path, or fields; must be under a preopened directory"`
+	Glob string `json:"glob,omitempty" desc:"Only count the files under dir whose names match this pattern, e.g. *.txt"`
+	// LengthHistogram adds CountResult.LengthHistogram.
+	LengthHistogram bool `json:"length_histogram,omitempty" desc:"Add how many words there are of each length to the result"`
+	// TopWords adds CountResult.UniqueWords and the TopWords most frequent
+	// words. Zero leaves both out.
+	TopWords int `json:"top_words,omitempty" desc:"Add this many of the most frequent words, and how many distinct words there are, to the result" validate:"min=0"`
+	// Top is another name for TopWords, for {"stats":true,"top":5}.
+	// Setting both to different values fails.
+	Top int `json:"top,omitempty" desc:"Another name for top_words" validate:"min=0"`
+	// FoldCase and NormalizeUnicode pick the canonical form in which
+	// TopWords compares words; Words still counts them as written. FoldCase,
+	// on unless set to false, lowercases each character, so "Word" and
+	// "word" are one word. NormalizeUnicode "nfc" composes characters, so
+	// "café" with a precomposed é and with e + U+0301 are one word, and
+	// "nfkc" also folds compatibility forms such as "ﬁ" into "fi". Neither
+	// strips accents: "café" and "cafe" stay two words.
+	FoldCase         *bool  `json:"fold_case,omitempty" desc:"Treat words that differ only in case as one word for top_words; defaults to true"`
+	NormalizeUnicode string `json:"normalize_unicode,omitempty" desc:"Unicode normalization form to compare words in for top_words: none (default), nfc, or nfkc" validate:"oneof=none|nfc|nfkc"`
+	// CaseSensitive is FoldCase the other way round: {"case_sensitive":true}
+	// counts as {"fold_case":false} does. Setting both so they disagree
+	// fails.
+	CaseSensitive *bool `json:"case_sensitive,omitempty" desc:"Keep words that differ only in case apart for top_words; the opposite of fold_case, defaults to false"`
+	// Stats adds CountResult.LongestWord and AverageWordLength, and
+	// TopWords at statsTopWords unless that is set too.
+	Stats bool `json:"stats,omitempty" desc:"Add the longest word, the average word length, and the top_words most frequent words (5 unless set) to the result"`
+	// Explain adds CountResult.Explain.
+	Explain bool `json:"explain,omitempty" desc:"Add how the counts were derived, and the first words found, to the result"`
+	// Tokens adds CountResult.EstimatedTokens, a guess at how many tokens an
+	// LLM would read the text as. The one mode, "gpt-bpe-approx", charges
+	// each whitespace-separated word a token per four characters, rounded
+	// half up and at least one, whatever Tokenizer says: close to what GPT's
+	// BPE tokenizers give English prose, where a common word and the space
+	// before it are one token. Code, numbers, and non-Latin scripts take
+	// more tokens than it says.
+	Tokens string `json:"tokens,omitempty" desc:"Add an estimate of the LLM tokens in the text: gpt-bpe-approx, about a token per four characters of each word" validate:"oneof=gpt-bpe-approx"`
+	// Offset and Length count only a window of Text or Path: Length
+	// characters from Offset on, in runes, or in bytes with CountMode
+	// "bytes", where a boundary inside a character moves back to its start.
+	// Nil Length runs to the end. A window that runs past the end of the text
+	// is cut short there, with a warning.
+	Offset int  `json:"offset,omitempty" desc:"Count only from this many characters into the text: runes, or bytes with count_mode bytes" validate:"min=0"`
+	Length *int `json:"length,omitempty" desc:"Count only this many characters from offset on, in the same unit; defaults to the rest of the text" validate:"min=0"`
+
+	// badFields maps each entry of "fields" that is not a string to why.
+	badFields map[string]string
+	// wordRe is WordRegex compiled, or nil.
+	wordRe *regexp.Regexp
+}
+
+// UnmarshalJSON decodes Args, setting aside "fields" entries that are not
+// strings so one bad entry does not fail the others.
+func (a *Args) UnmarshalJSON(b []byte) error {
+	type plain Args // Args without this method
+	// Named Args too, so decode errors still read "Go struct field Args.text".
+	type Args struct {
+		plain
+		Fields map[string]json.RawMessage `json:"fields"`
+	}
+	var raw Args
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*(*plain)(a) = raw.plain
+	if raw.Fields == nil {
+		return nil
+	}
+	a.Fields = make(map[string]string, len(raw.Fields))
+	for name, v := range raw.Fields {
+		var text string
+		if err := json.Unmarshal(v, &text); err != nil || v[0] != '"' {
+			if a.badFields == nil {
+				a.badFields = map[string]string{}
+			}
+			a.badFields[name] = "must be a string, got " + jsonKind(v)
+			continue
+		}
+		a.Fields[name] = text
+	}
+	return nil
+}
+
+// jsonKind names the type of the JSON value v.
+func jsonKind(v json.RawMessage) string {
+	switch v[0] {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	}
+	return "number"
 }
 
 type CountResult struct {
 	Words      int `json:"words"`
 	Lines      int `json:"lines"`
 	Characters int `json:"characters"`
+	// Bytes is only set with Args.Fields or Args.Dir, as the total of their
+	// entries' Bytes.
+	Bytes int `json:"bytes,omitempty"`
+	// Encoding and InvalidBytes are only set for Path: inline text arrives as
+	// JSON, which is already valid UTF-8 by the time it is decoded. Invalid
+	// bytes each count as part of a U+FFFD character, and Warning says so.
+	// Warning also flags text that is only whitespace: it has lines and
+	// characters but no words.
+	Encoding     string `json:"encoding,omitempty"`
+	InvalidBytes int    `json:"invalid_bytes,omitempty"`
+	Warning      string `json:"warning,omitempty"`
+	// Fields holds the counts of each Args.Fields entry, sorted by name; the
+	// counts above are then their totals. HeaviestField names the good entry
+	// with the most Bytes, the first by name on a tie; it is a pointer so
+	// that an entry named "" is still reported.
+	Fields        []FieldCount `json:"fields,omitempty"`
+	HeaviestField *string      `json:"heaviest_field,omitempty"`
+	// Files likewise holds the counts of each file read under Args.Dir,
+	// sorted by path.
+	Files []FileResult `json:"files,omitempty"`
+	// LengthHistogram counts the words of each length, measured in the unit
+	// Args.CountMode gives Characters, shortest first; with Args.Fields it
+	// covers the words of every good entry. Every length gets its own bucket,
+	// however long, so the buckets are exact and their counts add up to Words.
+	LengthHistogram []LengthBucket `json:"length_histogram,omitempty"`
+	// UniqueWords and TopWords are set by Args.TopWords: the number of
+	// distinct words and the most frequent of them, in their canonical form,
+	// most frequent first and ties in byte order. With Args.Fields they cover
+	// the words of every good entry.
+	UniqueWords int        `json:"unique_words,omitempty"`
+	TopWords    []WordFreq `json:"top_words,omitempty"`
+	// LongestWord and AverageWordLength are set by Args.Stats, in the unit
+	// Args.CountMode gives Characters: the longest word as written, the first
+	// in byte order on a tie, and the mean length of a word, rounded to two
+	// decimals. With Args.Fields they cover the words of every good entry.
+	LongestWord       string  `json:"longest_word,omitempty"`
+	AverageWordLength float64 `json:"average_word_length,omitempty"`
+	// EstimatedTokens is set by Args.Tokens; with Args.Fields or Args.Dir it
+	// covers every text counted.
+	EstimatedTokens int `json:"estimated_tokens,omitempty"`
+	// Explain is set by Args.Explain.
+	Explain *ExplainInfo `json:"explain,omitempty"`
+}
+
+// ExplainInfo records how the counts were derived, so a surprising number
+// can be traced to the setting behind it. Trim and CountMode are the modes
+// in effect, defaults filled in.
+type ExplainInfo struct {
+	// Tokenizer is how text split into words: Args.Tokenizer, or
+	// "whitespace", at every run of Unicode whitespace, or "regex" with
+	// Args.WordRegex, which WordRegex repeats. TokenizerRule says what that
+	// means, with examples, as tokenizerRules has it.
+	Tokenizer     string `json:"tokenizer"`
+	WordRegex     string `json:"word_regex,omitempty"`
+	TokenizerRule string `json:"tokenizer_rule"`
+	// Strip is what was removed before counting: "bom" when some text
+	// counted started with a byte order mark, or "none".
+	Strip     string `json:"strip"`
+	Trim      string `json:"trim"`
+	CountMode string `json:"count_mode"`
+	// Tokens holds the first explainTokens words as counted, after Trim;
+	// with Args.Fields, those of every good entry in name order.
+	Tokens []string `json:"tokens,omitempty"`
+}
+
+// blankWarning is CountResult.Warning for text that is only whitespace.
+const blankWarning = "input has no words, only whitespace"
+
+// tokenizerRules holds ExplainInfo.TokenizerRule for each Args.Tokenizer.
+var tokenizerRules = map[string]string{
+	"whitespace": "splits at whitespace only, so e.g., and hello,world are one word each",
+	"smart":      "splits at anything but letters and digits, keeping don't, well-known, and 1,000 whole, so e.g., is two words (e, g)",
+	"uax29":      "splits at Unicode word boundaries (UAX #29) and drops punctuation, so e.g., is one word (e.g), don't and 1,000 one each, and hello,world and well-known two each",
+	"regex":      "takes each non-empty match of word_regex as a word, skipping the text between matches",
+}
+
+// explainTokens is how many words ExplainInfo.Tokens lists.
+const explainTokens = 10
+
+// charsPerToken is how many characters of a word Args.Tokens counts as one
+// token.
+const charsPerToken = 4
+
+// statsTopWords is how many top words Args.Stats adds when Args.TopWords
+// is not set.
+const statsTopWords = 5
+
+// WordFreq is how often one word occurs.
+type WordFreq struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// LengthBucket is the number of words of one length.
+type LengthBucket struct {
+	Length int `json:"length"`
+	Count  int `json:"count"`
+}
+
+// FieldCount is the count of one named text in Args.Fields.
+type FieldCount struct {
+	Name       string `json:"name"`
+	Words      int    `json:"words"`
+	Lines      int    `json:"lines"`
+	Characters int    `json:"characters"`
+	// Bytes is the UTF-8 length of the text as counted, whatever
+	// Args.CountMode gives Characters.
+	Bytes int `json:"bytes"`
+	// Error and ErrorCode are set, and the counts zero, for a bad entry.
+	Error     *string         `json:"error,omitempty"`
+	ErrorCode skill.ErrorCode `json:"error_code,omitempty"`
 }
 
-type ToolResult struct {
-	Success bool         `json:"success"`
-	Output  string       `json:"output"`
-	Error   *string      `json:"error,omitempty"`
-	Data    *CountResult `json:"data,omitempty"`
+// FileResult is the count of one file under Args.Dir.
+type FileResult struct {
+	// Path is relative to Args.Dir and slash-separated.
+	Path       string `json:"path"`
+	Words      int    `json:"words"`
+	Lines      int    `json:"lines"`
+	Characters int    `json:"characters"`
+	// Bytes is the UTF-8 length of the text as counted, after decoding, as
+	// for FieldCount.
+	Bytes        int    `json:"bytes"`
+	Encoding     string `json:"encoding"`
+	InvalidBytes int    `json:"invalid_bytes,omitempty"`
+}
+
+// selfTests are the cases `tool.wasm --self-test` runs through count; add one
+// whenever you fix a bug.
+var selfTests = []skill.Case{
+	{Name: "counts text", Args: `{"text":"hello world"}`, Expect: `{"data":{"words":2,"lines":1,"characters":11}}`},
+	{Name: "smart tokenizer keeps contractions", Args: `{"text":"don't stop,go","tokenizer":"smart"}`, Expect: `{"data":{"words":3}}`},
+	{Name: "uax29 tokenizer keeps abbreviations", Args: `{"text":"e.g., 1,000 items","tokenizer":"uax29","explain":true}`, Expect: `{"data":{"words":3,"explain":{"tokens":["e.g","1,000","items"]}}}`},
+	{Name: "dir excludes text", Args: `{"dir":"/data","text":"a"}`, Expect: `{"success":false,"error_code":"invalid_input"}`},
+}
+
+// options are what main passes to skill.Run; main_test.go passes them to
+// skilltest.Run too, so the tests see requests exactly as the host does.
+var options = []skill.Option{
+	skill.Expect(`{"text":"..."} or {"path":"..."}`),
+	skill.ToolName("__SKILL_NAME__"),
+	skill.OutputFor[CountResult](),
+	skill.CleanText(),          // strip BOMs and repair invalid UTF-8 so counts are stable
+	skill.Requires("fs:/data"), // keep in sync with manifest capabilities.fs
+	skill.SelfTest(selfTests),
+	skill.Use(explainStrip),
 }
 
 func main() {
-	data, err := io.ReadAll(os.Stdin)
+	skill.Run(count, options...)
+}
+
+func count(args Args) skill.ToolResult {
+	normalize, err := trimmer(args.Trim)
 	if err != nil {
-		writeError(fmt.Sprintf("failed to read stdin: %v", err))
-		return
+		return skill.FailCode(skill.CodeInvalidInput, err.Error())
+	}
+	if args.Top != 0 {
+		if args.TopWords != 0 && args.TopWords != args.Top {
+			return skill.FailCode(skill.CodeInvalidInput, "top and top_words disagree; set only one")
+		}
+		args.TopWords = args.Top
+	}
+	if args.CaseSensitive != nil {
+		if args.FoldCase != nil && *args.FoldCase == *args.CaseSensitive {
+			return skill.FailCode(skill.CodeInvalidInput, "case_sensitive and fold_case disagree; set only one")
+		}
+		foldCase := !*args.CaseSensitive
+		args.FoldCase = &foldCase
+	}
+	if args.Stats && args.TopWords == 0 {
+		args.TopWords = statsTopWords
+	}
+	if args.Segmentation != "" {
+		if args.Tokenizer != "" && args.Tokenizer != args.Segmentation {
+			return skill.FailCode(skill.CodeInvalidInput, "segmentation and tokenizer disagree; set only one")
+		}
+		args.Tokenizer = args.Segmentation
+	}
+	if args.WordRegex != "" {
+		if args.Tokenizer != "" {
+			return skill.FailCode(skill.CodeInvalidInput, "word_regex cannot be combined with tokenizer")
+		}
+		if args.wordRe, err = regexp.Compile(args.WordRegex); err != nil {
+			return skill.FailCode(skill.CodeInvalidInput, fmt.Sprintf("invalid word_regex: %v", err))
+		}
+	}
+	if (args.Offset != 0 || args.Length != nil) && (args.Dir != "" || args.Fields != nil) {
+		return skill.FailCode(skill.CodeInvalidInput, "offset and length apply only to text or path")
+	}
+	if args.Dir != "" {
+		if args.Text != "" || args.Path != "" || args.Fields != nil {
+			return skill.FailCode(skill.CodeInvalidInput, "dir cannot be combined with text, path, or fields")
+		}
+		return countDir(args, normalize)
+	}
+	if args.Glob != "" {
+		return skill.FailCode(skill.CodeInvalidInput, "glob needs dir")
+	}
+	if args.Fields != nil {
+		if args.Text != "" || args.Path != "" {
+			return skill.FailCode(skill.CodeInvalidInput, "fields cannot be combined with text or path")
+		}
+		return countFields(args, normalize)
+	}
+	var decoded skill.DecodedText
+	bom := false
+	if args.Path != "" {
+		data, err := skill.ReadFile(args.Path)
+		switch {
+		case errors.Is(err, skill.ErrOutsidePreopen):
+			return skill.FailCode(skill.CodePermissionDenied, err.Error())
+		case err != nil:
+			return skill.FailCode(skill.CodeNotFound, err.Error())
+		}
+		if decoded, err = skill.InspectText(data); err != nil {
+			return skill.FailCode(skill.CodeInvalidInput, fmt.Sprintf("%s: %v", args.Path, err))
+		}
+		args.Text, bom = decoded.Text, startsWithBOM(data)
+	}
+	text, clamped := window(args.Text, args)
+	text = normalize(text)
+	counts := tally(text, args)
+	counts.Encoding = decoded.Encoding
+	words := splitWords(text, args)
+	if args.LengthHistogram {
+		counts.LengthHistogram = histogram(words, args.CountMode)
+	}
+	if args.TopWords > 0 {
+		counts.UniqueWords, counts.TopWords = frequencies(words, args)
+	}
+	if args.Stats {
+		counts.LongestWord, counts.AverageWordLength = wordStats(words, args.CountMode)
+	}
+	if args.Explain {
+		counts.Explain = explain(words, args, bom)
+	}
+	out, fallback := summary(counts, args.Locale)
+	if args.Tokens != "" {
+		counts.EstimatedTokens = estimateTokens(text)
+		out += tokensNote(counts.EstimatedTokens)
+	}
+	if text != "" && strings.TrimSpace(text) == "" {
+		counts.Warning = blankWarning
+		out += "; warning: " + counts.Warning
+	}
+	if decoded.InvalidBytes > 0 {
+		counts.InvalidBytes = decoded.InvalidBytes
+		counts.Warning = fmt.Sprintf("%s is not valid %s: %d %s replaced with U+FFFD (Latin-1 or corrupt?)",
+			args.Path, decoded.Encoding, decoded.InvalidBytes, skill.PluralIn("en", decoded.InvalidBytes, "byte", "bytes"))
+		out += "; warning: " + counts.Warning
+	}
+	res := skill.OK(out, &counts)
+	if fallback != "" {
+		res = res.Warn(fallback)
+	}
+	if clamped != "" {
+		res = res.Warn(clamped)
+	}
+	return res
+}
+
+// window cuts text to the characters args.Offset and args.Length select,
+// and says how it clamped a window that ran past the end of the text.
+func window(text string, args Args) (string, string) {
+	if args.Offset == 0 && args.Length == nil {
+		return text, ""
+	}
+	unit := "rune"
+	// at is the byte index where character i of text starts.
+	at := func(i int) int {
+		for b := range text {
+			if i == 0 {
+				return b
+			}
+			i--
+		}
+		return len(text)
+	}
+	size := utf8.RuneCountInString(text)
+	if args.CountMode == "bytes" {
+		unit, size = "byte", len(text)
+		at = func(i int) int {
+			for i < len(text) && !utf8.RuneStart(text[i]) {
+				i--
+			}
+			return i
+		}
+	}
+	units := func(n int) string { return fmt.Sprintf("%d %s", n, skill.PluralIn("en", n, unit, unit+"s")) }
+	if args.Offset > size {
+		return "", fmt.Sprintf("offset %d is past the end of the text (%s); nothing counted", args.Offset, units(size))
+	}
+	end, warning := size, ""
+	if args.Length != nil && *args.Length <= size-args.Offset {
+		end = args.Offset + *args.Length
+	} else if args.Length != nil {
+		warning = fmt.Sprintf("length %d runs past the end of the text; counted the %s from offset %d", *args.Length, units(size-args.Offset), args.Offset)
+	}
+	return text[at(args.Offset):at(end)], warning
+}
+
+// countFields counts each of args.Fields and their total. Bad entries are
+// listed with their error, and the call only fails when all of them are
+// bad, or any is with FailFast.
+func countFields(args Args, normalize func(string) string) skill.ToolResult {
+	names := make([]string, 0, len(args.Fields)+len(args.badFields))
+	for name := range args.Fields {
+		names = append(names, name)
+	}
+	for name := range args.badFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var total CountResult
+	var bad []skill.FieldError
+	var words []string
+	tokens, heaviest := 0, -1
+	for _, name := range names {
+		if msg, ok := args.badFields[name]; ok {
+			bad = append(bad, skill.FieldError{Path: "/fields/" + pointerEscape(name), Message: msg})
+			total.Fields = append(total.Fields, FieldCount{Name: name, Error: &msg, ErrorCode: skill.CodeInvalidInput})
+			continue
+		}
+		text := normalize(args.Fields[name])
+		c := tally(text, args)
+		words = append(words, splitWords(text, args)...)
+		tokens += estimateTokens(text)
+		total.Words += c.Words
+		total.Lines += c.Lines
+		total.Characters += c.Characters
+		total.Bytes += len(text)
+		if len(text) > heaviest {
+			heaviestName := name
+			total.HeaviestField, heaviest = &heaviestName, len(text)
+		}
+		total.Fields = append(total.Fields, FieldCount{Name: name, Words: c.Words, Lines: c.Lines, Characters: c.Characters, Bytes: len(text)})
+	}
+	if len(bad) > 0 && (args.FailFast || len(bad) == len(names)) {
+		return skill.FailFields(bad...)
+	}
+	if args.LengthHistogram {
+		total.LengthHistogram = histogram(words, args.CountMode)
+	}
+	if args.TopWords > 0 {
+		total.UniqueWords, total.TopWords = frequencies(words, args)
+	}
+	if args.Stats {
+		total.LongestWord, total.AverageWordLength = wordStats(words, args.CountMode)
+	}
+	if args.Explain {
+		total.Explain = explain(words, args, false)
+	}
+	out, fallback := summary(total, args.Locale)
+	if args.Tokens != "" {
+		total.EstimatedTokens = tokens
+		out += tokensNote(tokens)
+	}
+	if len(bad) > 0 {
+		total.Warning = fmt.Sprintf("%d of %d fields failed", len(bad), len(names))
+		out += "; warning: " + total.Warning
+	}
+	res := skill.OK(out, &total)
+	if fallback != "" {
+		res = res.Warn(fallback)
+	}
+	return res
+}
+
+// countDir counts each file under args.Dir that args.Glob matches, and their
+// total. A file that cannot be read or decoded is left out of both, with a
+// warning naming it.
+func countDir(args Args, normalize func(string) string) skill.ToolResult {
+	names, err := skill.ListFiles(args.Dir, args.Glob)
+	switch {
+	case errors.Is(err, path.ErrBadPattern):
+		return skill.FailCode(skill.CodeInvalidInput, fmt.Sprintf("invalid glob %q: %v", args.Glob, err))
+	case errors.Is(err, skill.ErrOutsidePreopen):
+		return skill.FailCode(skill.CodePermissionDenied, err.Error())
+	case err != nil:
+		return skill.FailCode(skill.CodeNotFound, err.Error())
+	}
+
+	var total CountResult
+	var skipped []string
+	var words []string
+	tokens, bom := 0, false
+	for _, name := range names {
+		data, err := skill.ReadFile(path.Join(args.Dir, name))
+		var decoded skill.DecodedText
+		if err == nil {
+			decoded, err = skill.InspectText(data)
+		}
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("skipped %s: %s", name, unreadable(err)))
+			continue
+		}
+		bom = bom || startsWithBOM(data)
+		text := normalize(decoded.Text)
+		c := tally(text, args)
+		words = append(words, splitWords(text, args)...)
+		tokens += estimateTokens(text)
+		total.Words += c.Words
+		total.Lines += c.Lines
+		total.Characters += c.Characters
+		total.Bytes += len(text)
+		total.Files = append(total.Files, FileResult{
+			Path: name, Words: c.Words, Lines: c.Lines, Characters: c.Characters, Bytes: len(text),
+			Encoding: decoded.Encoding, InvalidBytes: decoded.InvalidBytes,
+		})
+	}
+	if args.LengthHistogram {
+		total.LengthHistogram = histogram(words, args.CountMode)
+	}
+	if args.TopWords > 0 {
+		total.UniqueWords, total.TopWords = frequencies(words, args)
+	}
+	if args.Stats {
+		total.LongestWord, total.AverageWordLength = wordStats(words, args.CountMode)
+	}
+	if args.Explain {
+		total.Explain = explain(words, args, bom)
 	}
+	out, fallback := summary(total, args.Locale)
+	if args.Tokens != "" {
+		total.EstimatedTokens = tokens
+		out += tokensNote(tokens)
+	}
+	if len(skipped) > 0 {
+		total.Warning = fmt.Sprintf("%d of %d files skipped", len(skipped), len(names))
+		out += "; warning: " + total.Warning
+	}
+	res := skill.OK(out, &total)
+	if fallback != "" {
+		res = res.Warn(fallback)
+	}
+	for _, w := range skipped {
+		res = res.Warn(w)
+	}
+	return res
+}
 
-	var args Args
-	if err := json.Unmarshal(data, &args); err != nil {
-		writeError(fmt.Sprintf("invalid input JSON: %v — expected {\"text\":\"...\"}", err))
-		return
+// unreadable says why a file under Args.Dir was skipped, in the words every
+// template uses rather than the platform's error text.
+func unreadable(err error) string {
+	switch {
+	case errors.Is(err, skill.ErrInvalidUTF8):
+		return err.Error()
+	case errors.Is(err, fs.ErrNotExist):
+		return "not found"
+	case errors.Is(err, fs.ErrPermission):
+		return "permission denied"
 	}
+	return "cannot be read"
+}
+
+// pointerEscape escapes name as a JSON Pointer segment.
+func pointerEscape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
 
+// tally counts the words, lines, and characters of text, the words as
+// Args.Tokenizer or Args.WordRegex and the characters as Args.CountMode says.
+func tally(text string, args Args) CountResult {
 	lines := 0
-	if args.Text != "" {
-		lines = strings.Count(args.Text, "\n") + 1
+	if text != "" {
+		lines = strings.Count(text, "\n") + 1
 	}
-	counts := CountResult{
-		Words:      len(strings.Fields(args.Text)),
+	return CountResult{
+		Words:      len(splitWords(text, args)),
 		Lines:      lines,
-		Characters: len([]rune(args.Text)),
+		Characters: characters(text, args.CountMode),
 	}
+}
 
-	result := ToolResult{
-		Success: true,
-		Output: fmt.Sprintf("%d %s, %d %s, %d %s",
-			counts.Words, plural(counts.Words, "word", "words"),
-			counts.Lines, plural(counts.Lines, "line", "lines"),
-			counts.Characters, plural(counts.Characters, "character", "characters"),
-		),
-		Data: &counts,
+// splitWords splits text into words as Args.WordRegex or Args.Tokenizer
+// says.
+func splitWords(text string, args Args) []string {
+	if args.wordRe != nil {
+		return slices.DeleteFunc(args.wordRe.FindAllString(text, -1), func(w string) bool { return w == "" })
+	}
+	switch args.Tokenizer {
+	case "smart":
+		return smartWords(text)
+	case "uax29":
+		return segmentWords(text)
+	default:
+		return strings.Fields(text)
 	}
+}
 
-	out, err := json.Marshal(result)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "json marshal error:", err)
-		os.Exit(1)
+// smartWords splits text at anything that is not a letter or digit, keeping
+// the joiners joins allows inside a word.
+func smartWords(text string) []string {
+	runes := []rune(text)
+	var words []string
+	start := -1 // where the word being read begins, or -1 between words
+	for i, r := range runes {
+		inWord := wordRune(r) ||
+			start >= 0 && (extends(r) || i+1 < len(runes) && joins(runes[i-1], r, runes[i+1]))
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			words = append(words, string(runes[start:i]))
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
 	}
-	os.Stdout.Write(out)
+	return words
+}
+
+// wordRune reports whether r is a letter or digit: Unicode's Alphabetic or
+// Number, as Rust's char::is_alphabetic and char::is_numeric see them.
+func wordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Other_Alphabetic, r)
 }
 
-func plural(n int, singular, pluralForm string) string {
-	if n == 1 {
-		return singular
+// joins reports whether r, inside a word after prev, keeps the word going
+// on to next under the smart tokenizer.
+func joins(prev, r, next rune) bool {
+	switch r {
+	case '\'', '’', '-', '‐':
+		return wordRune(next)
+	case '.', ',':
+		return unicode.IsNumber(prev) && unicode.IsNumber(next)
 	}
-	return pluralForm
+	return false
 }
 
-func writeError(msg string) {
-	result := ToolResult{Success: false, Error: &msg}
-	out, err := json.Marshal(result)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "json marshal error:", err)
-		os.Exit(1)
+// wordBreak is a rune's Unicode Word_Break property, as far as the uax29
+// tokenizer tells them apart.
+type wordBreak int
+
+const (
+	wbOther wordBreak = iota
+	wbLetter
+	wbNumeric
+	wbKatakana
+	wbIdeograph // Han and Hiragana, which UAX #29 leaves a word each
+	wbExtendNumLet
+	wbMidLetter
+	wbMidNum
+	wbMidNumLet // a MidLetter and a MidNum both
+	wbExtend
+)
+
+// wordBreakOf approximates the Word_Break property of r without Unicode
+// tables, the same way in every template: letters and digits as wordRune
+// sees them, the joiners UAX #29 lists by code point, and the marks extends
+// attaches.
+func wordBreakOf(r rune) wordBreak {
+	switch {
+	case extends(r) || r == '\u200d':
+		return wbExtend
+	case r >= 0x30a0 && r <= 0x30ff, r >= 0x31f0 && r <= 0x31ff, r >= 0xff66 && r <= 0xff9f:
+		return wbKatakana
+	case r >= 0x3040 && r <= 0x309f, r >= 0x3400 && r <= 0x4dbf, r >= 0x4e00 && r <= 0x9fff,
+		r >= 0xf900 && r <= 0xfaff, r >= 0x20000 && r <= 0x3ffff:
+		return wbIdeograph
+	case unicode.IsNumber(r):
+		return wbNumeric
+	case wordRune(r):
+		return wbLetter
+	}
+	switch r {
+	case '_', '\u202f', '\u203f', '\u2040', '\u2054', '\ufe33', '\ufe34', '\ufe4d', '\ufe4e', '\ufe4f', '\uff3f':
+		return wbExtendNumLet
+	case ':', '\u00b7', '\u0387', '\u05f4', '\u2027', '\ufe13', '\ufe55', '\uff1a':
+		return wbMidLetter
+	case ',', ';', '\u037e', '\u0589', '\u060c', '\u060d', '\u066c', '\u07f8', '\u2044', '\ufe10', '\ufe14', '\ufe50', '\ufe54', '\uff0c', '\uff1b':
+		return wbMidNum
+	case '.', '\'', '\u2018', '\u2019', '\u2024', '\ufe52', '\uff07', '\uff0e':
+		return wbMidNumLet
+	}
+	return wbOther
+}
+
+// segmentWords splits text at its Unicode word boundaries (UAX #29 rules
+// WB4 to WB13b) and keeps the segments with a letter, digit, or ideograph
+// in them, dropping those of punctuation, symbols, or whitespace alone.
+func segmentWords(text string) []string {
+	type unit struct {
+		start int
+		wb    wordBreak
+	}
+	// WB4: a mark or joiner belongs to the rune before it.
+	var units []unit
+	for i, r := range text {
+		wb := wordBreakOf(r)
+		if wb == wbExtend {
+			if len(units) > 0 {
+				continue
+			}
+			wb = wbOther
+		}
+		units = append(units, unit{i, wb})
+	}
+	class := func(i int) wordBreak {
+		if i < 0 || i >= len(units) {
+			return wbOther
+		}
+		return units[i].wb
+	}
+	alnum := func(wb wordBreak) bool { return wb == wbLetter || wb == wbNumeric }
+	midLetter := func(wb wordBreak) bool { return wb == wbMidLetter || wb == wbMidNumLet }
+	midNum := func(wb wordBreak) bool { return wb == wbMidNum || wb == wbMidNumLet }
+	// joined reports whether no boundary falls before unit i.
+	joined := func(i int) bool {
+		a, b := class(i-1), class(i)
+		switch {
+		case alnum(a) && alnum(b): // WB5, WB8 to WB10
+		case a == wbLetter && midLetter(b) && class(i+1) == wbLetter: // WB6
+		case midLetter(a) && class(i-2) == wbLetter && b == wbLetter: // WB7
+		case a == wbNumeric && midNum(b) && class(i+1) == wbNumeric: // WB12
+		case midNum(a) && class(i-2) == wbNumeric && b == wbNumeric: // WB11
+		case a == wbKatakana && b == wbKatakana: // WB13
+		case (alnum(a) || a == wbKatakana || a == wbExtendNumLet) && b == wbExtendNumLet: // WB13a
+		case a == wbExtendNumLet && (alnum(b) || b == wbKatakana): // WB13b
+		default:
+			return false
+		}
+		return true
+	}
+	var words []string
+	start, wordLike := 0, false
+	for i := range units {
+		if i > 0 && !joined(i) {
+			if wordLike {
+				words = append(words, text[units[start].start:units[i].start])
+			}
+			start, wordLike = i, false
+		}
+		switch units[i].wb {
+		case wbLetter, wbNumeric, wbKatakana, wbIdeograph:
+			wordLike = true
+		}
+	}
+	if wordLike {
+		words = append(words, text[units[start].start:])
+	}
+	return words
+}
+
+// characters counts the characters of text as Args.CountMode says.
+func characters(text, mode string) int {
+	switch mode {
+	case "bytes":
+		return len(text)
+	case "graphemes":
+		return graphemes(text)
+	}
+	return len([]rune(text))
+}
+
+// histogram buckets words by their length in characters, shortest first.
+func histogram(words []string, mode string) []LengthBucket {
+	counts := map[int]int{}
+	for _, w := range words {
+		counts[characters(w, mode)]++
+	}
+	buckets := make([]LengthBucket, 0, len(counts))
+	for length, n := range counts {
+		buckets = append(buckets, LengthBucket{Length: length, Count: n})
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Length < buckets[j].Length })
+	return buckets
+}
+
+// frequencies counts words in their canonical form, returning how many
+// distinct words there are and the args.TopWords most frequent.
+func frequencies(words []string, args Args) (int, []WordFreq) {
+	counts := map[string]int{}
+	for _, w := range words {
+		counts[canonical(w, args)]++
+	}
+	top := make([]WordFreq, 0, len(counts))
+	for word, n := range counts {
+		top = append(top, WordFreq{Word: word, Count: n})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Word < top[j].Word
+	})
+	if len(top) > args.TopWords {
+		top = top[:args.TopWords]
+	}
+	return len(counts), top
+}
+
+// wordStats returns the longest of words, the first in byte order on a tie,
+// and their mean length rounded to two decimals, both in characters.
+func wordStats(words []string, mode string) (string, float64) {
+	longest, most, total := "", 0, 0
+	for _, w := range words {
+		n := characters(w, mode)
+		total += n
+		if n > most || n == most && w < longest {
+			longest, most = w, n
+		}
+	}
+	if len(words) == 0 {
+		return "", 0
+	}
+	return longest, math.Round(float64(total)*100/float64(len(words))) / 100
+}
+
+// explain describes how args turned text into words, the first of which
+// are words; bom says a file counted started with a byte order mark.
+func explain(words []string, args Args, bom bool) *ExplainInfo {
+	info := &ExplainInfo{Tokenizer: args.Tokenizer, WordRegex: args.WordRegex, Strip: "none", Trim: args.Trim, CountMode: args.CountMode}
+	switch {
+	case args.WordRegex != "":
+		info.Tokenizer = "regex"
+	case info.Tokenizer == "":
+		info.Tokenizer = "whitespace"
+	}
+	if bom {
+		info.Strip = "bom"
+	}
+	info.TokenizerRule = tokenizerRules[info.Tokenizer]
+	if info.Trim == "" {
+		info.Trim = "none"
+	}
+	if info.CountMode == "" {
+		info.CountMode = "runes"
+	}
+	if len(words) > explainTokens {
+		words = words[:explainTokens]
+	}
+	info.Tokens = words
+	return info
+}
+
+// startsWithBOM reports whether data, a file's contents, starts with a
+// UTF-8 or UTF-16 byte order mark, which InspectText strips.
+func startsWithBOM(data []byte) bool {
+	d := string(data)
+	return strings.HasPrefix(d, "\ufeff") || strings.HasPrefix(d, "\xff\xfe") || strings.HasPrefix(d, "\xfe\xff")
+}
+
+// explainStrip sets ExplainInfo.Strip for a BOM that CleanText stripped
+// from Args.Text or Args.Fields: count never sees it, as Run only says so in
+// a warning once count returns.
+func explainStrip(_ Args, res skill.ToolResult) skill.ToolResult {
+	if c, ok := res.Data.(*CountResult); ok && c.Explain != nil && slices.Contains(res.Warnings, skill.BOMWarning) {
+		c.Explain.Strip = "bom"
+	}
+	return res
+}
+
+// estimateTokens is the "gpt-bpe-approx" estimate of the tokens in text:
+// each whitespace-separated word costs len/charsPerToken tokens, rounded
+// half up, and at least one.
+func estimateTokens(text string) int {
+	n := 0
+	for _, w := range strings.Fields(text) {
+		n += max(1, (len([]rune(w))+charsPerToken/2)/charsPerToken)
+	}
+	return n
+}
+
+// tokensNote is what Args.Tokens adds to the summary, saying the count is
+// an estimate.
+func tokensNote(tokens int) string {
+	return fmt.Sprintf("; ~%d %s (gpt-bpe-approx estimate, not an exact tokenizer count)",
+		tokens, skill.PluralIn("en", tokens, "token", "tokens"))
+}
+
+// canonical returns word as Args.NormalizeUnicode and Args.FoldCase say
+// TopWords compares it.
+func canonical(word string, args Args) string {
+	switch args.NormalizeUnicode {
+	case "nfc":
+		word = norm.NFC.String(word)
+	case "nfkc":
+		word = norm.NFKC.String(word)
+	}
+	if args.FoldCase == nil || *args.FoldCase {
+		word = strings.ToLower(word)
+	}
+	return word
+}
+
+// graphemes approximates the number of user-perceived characters in text
+// without Unicode segmentation tables, the same way in every template:
+// combining diacritical marks, variation selectors, and skin-tone modifiers
+// join the rune before them, U+200D joins the runes on either side, a pair
+// of regional indicators is one flag, and CR LF is one line break.
+func graphemes(text string) int {
+	n := 0
+	var prev rune
+	flag := false // prev is the first regional indicator of a pair
+	for i, r := range text {
+		joins := i > 0 && (extends(r) || r == '\u200d' || prev == '\u200d' || (prev == '\r' && r == '\n'))
+		regional := r >= 0x1f1e6 && r <= 0x1f1ff
+		if regional && flag {
+			joins = true
+		}
+		flag = regional && !flag
+		if !joins {
+			n++
+		}
+		prev = r
+	}
+	return n
+}
+
+// extends reports whether r attaches to the rune before it.
+func extends(r rune) bool {
+	for _, block := range [][2]rune{
+		{0x0300, 0x036f}, {0x1ab0, 0x1aff}, {0x1dc0, 0x1dff}, {0x20d0, 0x20ff}, {0xfe20, 0xfe2f}, // combining marks
+		{0xfe00, 0xfe0f},   // variation selectors
+		{0x1f3fb, 0x1f3ff}, // skin-tone modifiers
+	} {
+		if r >= block[0] && r <= block[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// trimmer returns the normalization for an Args.Trim mode.
+func trimmer(mode string) (func(string) string, error) {
+	switch mode {
+	case "", "none":
+		return func(s string) string { return s }, nil
+	case "edges":
+		return strings.TrimSpace, nil
+	case "collapse":
+		return func(s string) string { return strings.Join(strings.Fields(s), " ") }, nil
+	}
+	return nil, fmt.Errorf("invalid trim %q: want none, edges, or collapse", mode)
+}
+
+// messages holds the summary in each language the template is translated to,
+// one i18n/<language>.json file each; add a file to add a language.
+//
+//go:embed i18n/*.json
+var messages embed.FS
+
+var bundle = skill.MustLoadBundle(messages, "i18n")
+
+// summary renders counts as "2 words, 1 line, 11 characters" in the requested
+// locale. For languages without translations it renders English, and the
+// warning says so.
+func summary(counts CountResult, locale string) (out, warning string) {
+	if locale == "" {
+		locale = skill.DefaultLocale()
 	}
-	os.Stdout.Write(out)
+	return bundle.Message(locale, "word_count.summary", map[string]any{
+		"words":      counts.Words,
+		"lines":      counts.Lines,
+		"characters": counts.Characters,
+	})
 }
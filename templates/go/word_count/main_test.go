@@ -0,0 +1,122 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zeroclaw-labs/zeroclaw/sdk/go/skill"
+	"github.com/zeroclaw-labs/zeroclaw/sdk/go/skilltest"
+)
+
+// These tests run count in process with `go test`, through the same
+// decoding and validation as tool.wasm; no build or host is needed.
+
+func TestEmptyTextCountsNothing(t *testing.T) {
+	for _, args := range []string{`{"text":""}`, `{}`} {
+		res := skilltest.Run(t, count, args, options...)
+		skilltest.ExpectOK(t, res)
+		got := *res.Data.(*CountResult)
+		if got.Words != 0 || got.Lines != 0 || got.Characters != 0 || got.Warning != "" {
+			t.Fatalf("%s: got %+v", args, got)
+		}
+	}
+}
+
+func TestMultiLineText(t *testing.T) {
+	const args = `{"text":"roses are red\nviolets are blue"}`
+	res := skilltest.Run(t, count, args, options...)
+	skilltest.ExpectOK(t, res)
+	if got := *res.Data.(*CountResult); got.Words != 6 || got.Lines != 2 || got.Characters != 30 {
+		t.Fatalf("got %+v, want 6 words, 2 lines, 30 characters", got)
+	}
+
+	// "collapse" turns the blank line into one space: the same words and
+	// characters, on one line.
+	res = skilltest.Run(t, count, `{"text":"roses are red\n\nviolets are blue","trim":"collapse"}`, options...)
+	if got := *res.Data.(*CountResult); got.Words != 6 || got.Lines != 1 || got.Characters != 30 {
+		t.Fatalf("collapsed: got %+v, want 6 words, 1 line, 30 characters", got)
+	}
+}
+
+func TestStats(t *testing.T) {
+	res := skilltest.Run(t, count, `{"text":"The cat saw the CAT and a dog","stats":true}`, options...)
+	skilltest.ExpectOK(t, res)
+	got := *res.Data.(*CountResult)
+	if got.LongestWord != "CAT" || got.AverageWordLength != 2.75 || got.UniqueWords != 6 {
+		t.Fatalf("got %+v, want longest CAT, average 2.75, 6 unique words", got)
+	}
+	// stats lists the top 5 words, folded, most frequent first and ties in
+	// byte order.
+	want := []WordFreq{{"cat", 2}, {"the", 2}, {"a", 1}, {"and", 1}, {"dog", 1}}
+	if !reflect.DeepEqual(got.TopWords, want) {
+		t.Fatalf("top words %v, want %v", got.TopWords, want)
+	}
+
+	// top and case_sensitive are top_words and fold_case by other names.
+	for _, args := range []string{
+		`{"text":"The cat saw the CAT","stats":true,"top_words":2,"fold_case":false}`,
+		`{"text":"The cat saw the CAT","stats":true,"top":2,"case_sensitive":true}`,
+	} {
+		res := skilltest.Run(t, count, args, options...)
+		if got := res.Data.(*CountResult).TopWords; !reflect.DeepEqual(got, []WordFreq{{"CAT", 1}, {"The", 1}}) {
+			t.Fatalf("%s: top words %v", args, got)
+		}
+	}
+	skilltest.ExpectError(t, skilltest.Run(t, count, `{"text":"a","top":2,"top_words":3}`, options...), skill.CodeInvalidInput)
+	skilltest.ExpectError(t, skilltest.Run(t, count, `{"text":"a","case_sensitive":true,"fold_case":true}`, options...), skill.CodeInvalidInput)
+
+	// Without stats, or without words, none of it is reported.
+	for _, args := range []string{`{"text":"The cat saw the CAT"}`, `{"text":"","stats":true}`} {
+		got := *skilltest.Run(t, count, args, options...).Data.(*CountResult)
+		if got.LongestWord != "" || got.AverageWordLength != 0 || len(got.TopWords) != 0 {
+			t.Fatalf("%s: got %+v", args, got)
+		}
+	}
+}
+
+func TestBadArgsAreRejected(t *testing.T) {
+	skilltest.ExpectFieldErrors(t, skilltest.Run(t, count, `{"text":"a","count_mode":"lines"}`, options...), "/count_mode")
+	skilltest.ExpectFieldErrors(t, skilltest.Run(t, count, `{"text":"a","top_words":-1}`, options...), "/top_words")
+	skilltest.ExpectFieldErrors(t, skilltest.Run(t, count, `{"text":"a","top":-1}`, options...), "/top")
+	skilltest.ExpectError(t, skilltest.Run(t, count, `{"dir":"/data","text":"a"}`, options...), skill.CodeInvalidInput)
+}
+
+func TestExplain(t *testing.T) {
+	res := skilltest.Run(t, count, `{"text":"don't stop-me now, 42!","word_regex":"[a-z]+","explain":true}`, options...)
+	skilltest.ExpectOK(t, res)
+	got := res.Data.(*CountResult)
+	want := ExplainInfo{Tokenizer: "regex", WordRegex: "[a-z]+", TokenizerRule: tokenizerRules["regex"],
+		Strip: "none", Trim: "none", CountMode: "runes", Tokens: []string{"don", "t", "stop", "me", "now"}}
+	if got.Words != 5 || got.Explain == nil || !reflect.DeepEqual(*got.Explain, want) {
+		t.Fatalf("got %d words, explain %+v; want 5 and %+v", got.Words, got.Explain, want)
+	}
+
+	// The BOM CleanText strips is reported, though count never sees it.
+	res = skilltest.Run(t, count, "{\"text\":\"\ufeffhello\",\"explain\":true}", options...)
+	if got := res.Data.(*CountResult).Explain; got.Strip != "bom" || got.Tokenizer != "whitespace" {
+		t.Fatalf("with a BOM: got %+v, want strip bom and the whitespace tokenizer", got)
+	}
+
+	skilltest.ExpectError(t, skilltest.Run(t, count, `{"text":"a","word_regex":"("}`, options...), skill.CodeInvalidInput)
+	skilltest.ExpectError(t, skilltest.Run(t, count, `{"text":"a","word_regex":"a","tokenizer":"smart"}`, options...), skill.CodeInvalidInput)
+}
+
+func TestSegmentation(t *testing.T) {
+	for _, tc := range []struct {
+		args string
+		want []string
+	}{
+		{`{"text":"e.g., 1,000 items","explain":true}`, []string{"e.g.,", "1,000", "items"}},
+		{`{"text":"e.g., 1,000 items","segmentation":"uax29","explain":true}`, []string{"e.g", "1,000", "items"}},
+		{`{"text":"e.g., 1,000 items","segmentation":"uax29","tokenizer":"uax29","explain":true}`, []string{"e.g", "1,000", "items"}},
+	} {
+		res := skilltest.Run(t, count, tc.args, options...)
+		skilltest.ExpectOK(t, res)
+		if got := res.Data.(*CountResult).Explain.Tokens; !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got words %q, want %q", tc.args, got, tc.want)
+		}
+	}
+
+	skilltest.ExpectError(t, skilltest.Run(t, count, `{"text":"a","segmentation":"smart"}`, options...), skill.CodeInvalidInput)
+	skilltest.ExpectError(t, skilltest.Run(t, count, `{"text":"a","segmentation":"uax29","tokenizer":"smart"}`, options...), skill.CodeInvalidInput)
+}
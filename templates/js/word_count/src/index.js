@@ -0,0 +1,931 @@
+/**
+ * __SKILL_NAME__ — ZeroClaw Skill (JavaScript / WASI via Javy)
+ *
+ * Counts words, lines, and characters in text, byte-for-byte compatible with
+ * the Go `word_count` template for the same args: same `ToolResult` fields,
+ * error codes, and probe envelope.
+ * Protocol: read JSON from stdin, write JSON result to stdout.
+ * Build:    npm run build  →  tool.wasm
+ * Requires: javy CLI ≥ 3  →  https://github.com/bytecodealliance/javy
+ * Test:     zeroclaw skill test . --args '{"text":"hello world"}'
+ *
+ * Javy modules see only stdin and stdout — no argv, environment, or
+ * filesystem — so this template has no `path` argument, `--schema` flag, or
+ * JSON-Lines mode, and the summary defaults to English rather than
+ * ZEROCLAW_LOCALE.
+ */
+
+const EXPECT = '{"text":"..."}';
+
+// Keys are listed in sorted order, as the Go SDK writes them.
+const ARGS_SCHEMA = {
+  properties: {
+    case_sensitive: {
+      description:
+        'Keep words that differ only in case apart for top_words; the opposite of fold_case, defaults to false',
+      type: 'boolean',
+    },
+    count_mode: {
+      description: 'What characters counts: runes (default), bytes, or graphemes',
+      enum: ['bytes', 'runes', 'graphemes'],
+      type: 'string',
+    },
+    explain: {
+      description: 'Add how the counts were derived, and the first words found, to the result',
+      type: 'boolean',
+    },
+    fail_fast: {
+      description: 'Fail the whole call if any field is bad, instead of reporting it per field',
+      type: 'boolean',
+    },
+    fields: {
+      additionalProperties: { type: 'string' },
+      description: 'Named texts to count separately and in total, instead of text or path',
+      type: 'object',
+    },
+    fold_case: {
+      description: 'Treat words that differ only in case as one word for top_words; defaults to true',
+      type: 'boolean',
+    },
+    length: {
+      description:
+        'Count only this many characters from offset on, in the same unit; defaults to the rest of the text',
+      minimum: 0,
+      type: 'integer',
+    },
+    length_histogram: {
+      description: 'Add how many words there are of each length to the result',
+      type: 'boolean',
+    },
+    locale: {
+      description: 'Language of the summary (e.g. en, pl, ru); defaults to English',
+      type: 'string',
+    },
+    normalize_unicode: {
+      description:
+        'Unicode normalization form to compare words in for top_words: none (default), nfc, or nfkc',
+      enum: ['none', 'nfc', 'nfkc'],
+      type: 'string',
+    },
+    offset: {
+      description:
+        'Count only from this many characters into the text: runes, or bytes with count_mode bytes',
+      minimum: 0,
+      type: 'integer',
+    },
+    segmentation: {
+      description:
+        'Another name for tokenizer, limited to the Unicode segmentation choices: whitespace (default) or uax29',
+      enum: ['whitespace', 'uax29'],
+      type: 'string',
+    },
+    stats: {
+      description:
+        'Add the longest word, the average word length, and the top_words most frequent words (5 unless set) to the result',
+      type: 'boolean',
+    },
+    text: { description: 'Text to analyze', type: 'string' },
+    tokenizer: {
+      description:
+        "How text splits into words: whitespace (default); smart, which also splits at punctuation but keeps words like don't and well-known whole; or uax29, at Unicode word boundaries",
+      enum: ['whitespace', 'smart', 'uax29'],
+      type: 'string',
+    },
+    tokens: {
+      description:
+        'Add an estimate of the LLM tokens in the text: gpt-bpe-approx, about a token per four characters of each word',
+      enum: ['gpt-bpe-approx'],
+      type: 'string',
+    },
+    top: { description: 'Another name for top_words', minimum: 0, type: 'integer' },
+    top_words: {
+      description:
+        'Add this many of the most frequent words, and how many distinct words there are, to the result',
+      minimum: 0,
+      type: 'integer',
+    },
+    trim: {
+      description: 'Whitespace to normalize before counting: none (default), edges, or collapse',
+      type: 'string',
+    },
+    word_regex: {
+      description: 'Regular expression each word matches, instead of a tokenizer, e.g. \\p{L}+',
+      type: 'string',
+    },
+  },
+  required: [],
+  type: 'object',
+};
+
+// Whitespace as Go's unicode.IsSpace sees it. JavaScript's \s also matches
+// U+FEFF and misses U+0085, which would make word counts drift.
+const SPACE = /[\t\n\v\f\r \u0085\u00a0\u1680\u2000-\u200a\u2028\u2029\u202f\u205f\u3000]+/;
+const EDGES = new RegExp(`^${SPACE.source}|${SPACE.source}$`, 'g');
+
+// Normalizations for the `trim` arg, as in the Go template's trimmer.
+const TRIMMERS = {
+  '': (text) => text,
+  none: (text) => text,
+  edges: (text) => text.replace(EDGES, ''),
+  collapse: (text) =>
+    text
+      .split(SPACE)
+      .filter((word) => word !== '')
+      .join(' '),
+};
+
+function ok(output, data) {
+  return { success: true, output, data };
+}
+
+function fail(code, message) {
+  return { success: false, output: '', error: message, error_code: code };
+}
+
+/** An invalid_input failure listing errs, like the Go SDK's FailFields. */
+function failFields(errs) {
+  const message = errs.map((e) => (e.path ? `${e.path}: ${e.message}` : e.message)).join('; ');
+  return { ...fail('invalid_input', message), field_errors: errs };
+}
+
+/** The error for input[field] outside its schema enum, unless it is unset. */
+function oneOf(input, field) {
+  const value = input[field] ?? '';
+  const allowed = ARGS_SCHEMA.properties[field].enum;
+  if (value === '' || allowed.includes(value)) {
+    return null;
+  }
+  return {
+    path: `/${field}`,
+    message: `must be one of ${allowed.join(', ')}, got ${JSON.stringify(value)}`,
+  };
+}
+
+/** The error for a negative input[field]. */
+function atLeastZero(input, field) {
+  const value = input[field] ?? 0;
+  return value < 0 ? { path: `/${field}`, message: `must be at least 0, got ${value}` } : null;
+}
+
+/** Answer one request: a probe envelope is described, anything else counted. */
+function respond(bytes) {
+  // TextDecoder skips a leading BOM and repairs invalid UTF-8 with U+FFFD.
+  const raw = new TextDecoder().decode(bytes);
+  let input;
+  try {
+    input = JSON.parse(raw);
+  } catch (e) {
+    return fail('invalid_input', `invalid input JSON: ${e.message} — expected ${EXPECT}`);
+  }
+  if (input !== null && typeof input === 'object' && input.__probe === true) {
+    return ok('probe', {
+      tools: [{ name: '__SKILL_NAME__', schema: ARGS_SCHEMA }],
+      permissions: [],
+    });
+  }
+  if (input === null) {
+    input = {};
+  }
+  if (typeof input !== 'object' || Array.isArray(input)) {
+    return fail('invalid_input', `invalid input JSON: expected an object — expected ${EXPECT}`);
+  }
+  for (const field of [
+    'text',
+    'locale',
+    'trim',
+    'count_mode',
+    'tokenizer',
+    'segmentation',
+    'normalize_unicode',
+    'tokens',
+    'word_regex',
+  ]) {
+    if (input[field] != null && typeof input[field] !== 'string') {
+      return fail(
+        'invalid_input',
+        `invalid input JSON: field "${field}" must be a string — expected ${EXPECT}`,
+      );
+    }
+  }
+  for (const field of [
+    'fail_fast',
+    'length_histogram',
+    'fold_case',
+    'case_sensitive',
+    'stats',
+    'explain',
+  ]) {
+    if (input[field] != null && typeof input[field] !== 'boolean') {
+      return fail(
+        'invalid_input',
+        `invalid input JSON: field "${field}" must be a boolean — expected ${EXPECT}`,
+      );
+    }
+  }
+  for (const field of ['top_words', 'top', 'offset', 'length']) {
+    if (input[field] != null && !Number.isInteger(input[field])) {
+      return fail(
+        'invalid_input',
+        `invalid input JSON: field "${field}" must be an integer — expected ${EXPECT}`,
+      );
+    }
+    // Go decodes these into 64-bit ints and rejects anything wider.
+    if (input[field] < -(2 ** 63) || input[field] >= 2 ** 63) {
+      return fail(
+        'invalid_input',
+        `invalid input JSON: field "${field}" is out of range for a 64-bit integer — expected ${EXPECT}`,
+      );
+    }
+  }
+  const fields = input.fields ?? null;
+  if (fields !== null && (typeof fields !== 'object' || Array.isArray(fields))) {
+    return fail(
+      'invalid_input',
+      `invalid input JSON: field "fields" must map names to strings — expected ${EXPECT}`,
+    );
+  }
+  const mode = input.count_mode ?? '';
+  let tokenizer = input.tokenizer ?? '';
+  // In the Go template's field order, as its validate tags report them.
+  const invalid = [
+    oneOf(input, 'count_mode'),
+    oneOf(input, 'tokenizer'),
+    oneOf(input, 'segmentation'),
+    atLeastZero(input, 'top_words'),
+    atLeastZero(input, 'top'),
+    oneOf(input, 'normalize_unicode'),
+    oneOf(input, 'tokens'),
+    atLeastZero(input, 'offset'),
+    atLeastZero(input, 'length'),
+  ].filter((e) => e !== null);
+  if (invalid.length > 0) {
+    return failFields(invalid);
+  }
+  const trim = input.trim ?? '';
+  if (!Object.hasOwn(TRIMMERS, trim)) {
+    return fail(
+      'invalid_input',
+      `invalid trim ${JSON.stringify(trim)}: want none, edges, or collapse`,
+    );
+  }
+  if (input.top) {
+    if (input.top_words && input.top_words !== input.top) {
+      return fail('invalid_input', 'top and top_words disagree; set only one');
+    }
+    input = { ...input, top_words: input.top };
+  }
+  if (input.case_sensitive != null) {
+    if (input.fold_case != null && input.fold_case === input.case_sensitive) {
+      return fail('invalid_input', 'case_sensitive and fold_case disagree; set only one');
+    }
+    input = { ...input, fold_case: !input.case_sensitive };
+  }
+  if (input.segmentation) {
+    if (tokenizer !== '' && tokenizer !== input.segmentation) {
+      return fail('invalid_input', 'segmentation and tokenizer disagree; set only one');
+    }
+    tokenizer = input.segmentation;
+  }
+  if (input.word_regex) {
+    if (tokenizer !== '') {
+      return fail('invalid_input', 'word_regex cannot be combined with tokenizer');
+    }
+    try {
+      tokenizer = new RegExp(input.word_regex, 'gu');
+    } catch (e) {
+      return fail('invalid_input', `invalid word_regex: ${e.message}`);
+    }
+  }
+  if (fields !== null && ((input.offset ?? 0) !== 0 || input.length != null)) {
+    return fail('invalid_input', 'offset and length apply only to text or path');
+  }
+  if (fields !== null) {
+    if ((input.text ?? '') !== '') {
+      return fail('invalid_input', 'fields cannot be combined with text or path');
+    }
+    return warnBom(input, countFields(fields, input, TRIMMERS[trim], tokenizer, mode));
+  }
+  const [windowed, clamped] = window(stripBom(input.text ?? ''), input, mode);
+  const text = TRIMMERS[trim](windowed);
+  const counts = tally(text, tokenizer, mode);
+  let [output, fallback] = summary(counts, input.locale ?? '');
+  let tokens = 0;
+  if (input.tokens) {
+    tokens = estimateTokens(text);
+    output += tokensNote(tokens);
+  }
+  if (text !== '' && splitWords(text, 'whitespace').length === 0) {
+    counts.warning = BLANK_WARNING;
+    output += `; warning: ${counts.warning}`;
+  }
+  const words = splitWords(text, tokenizer);
+  if (input.length_histogram === true) {
+    addHistogram(counts, words, mode);
+  }
+  addTopWords(counts, words, input);
+  addStats(counts, words, input, mode);
+  if (tokens > 0) {
+    counts.estimated_tokens = tokens;
+  }
+  if (input.explain === true) {
+    counts.explain = explain(words, input);
+  }
+  return warnBom(input, warn(warn(ok(output, counts), fallback), clamped));
+}
+
+/**
+ * Cut text to the characters input.offset and input.length select, in code
+ * points or, for count_mode bytes, UTF-8 bytes, and say how a window that ran
+ * past the end of the text was clamped, as the Go template's window does.
+ */
+function window(text, input, mode) {
+  const offset = input.offset ?? 0;
+  const length = input.length ?? null;
+  if (offset === 0 && length === null) {
+    return [text, ''];
+  }
+  const chars = Array.from(text);
+  // starts[i] is where character i begins in units; a byte boundary inside
+  // a character moves back to its start.
+  const starts = [0];
+  for (const c of chars) {
+    starts.push(starts[starts.length - 1] + (mode === 'bytes' ? new TextEncoder().encode(c).length : 1));
+  }
+  const size = starts[starts.length - 1];
+  const at = (i) => starts.filter((start) => start <= i).length - 1;
+  const units = (n) => `${n} ${mode === 'bytes' ? 'byte' : 'rune'}${n === 1 ? '' : 's'}`;
+  if (offset > size) {
+    return ['', `offset ${offset} is past the end of the text (${units(size)}); nothing counted`];
+  }
+  if (length !== null && length > size - offset) {
+    return [
+      chars.slice(at(offset)).join(''),
+      `length ${length} runs past the end of the text; counted the ${units(size - offset)} from offset ${offset}`,
+    ];
+  }
+  const end = length === null ? chars.length : at(offset + length);
+  return [chars.slice(at(offset), end).join(''), ''];
+}
+
+/** Add BOM_WARNING to a successful result when any text in input had a BOM. */
+function warnBom(input, result) {
+  return result.success && hasBom(input) ? warn(result, BOM_WARNING) : result;
+}
+
+/** Whether input's text or any entry of its fields starts with a BOM. */
+function hasBom(input) {
+  const texts = [input.text, ...Object.values(input.fields ?? {})];
+  return texts.some((t) => typeof t === 'string' && t.startsWith('\ufeff'));
+}
+
+/** Append warning, when there is one, to result's warnings. */
+function warn(result, warning) {
+  if (warning) {
+    result.warnings = [...(result.warnings ?? []), warning];
+  }
+  return result;
+}
+
+/**
+ * Count each named text and their total, fields sorted by name as in Go.
+ * Entries that are not strings are listed with their error, and the call
+ * only fails when all of them are bad, or any is with fail_fast.
+ */
+function countFields(fields, input, normalize, tokenizer, mode) {
+  const total = { words: 0, lines: 0, characters: 0 };
+  const counted = [];
+  const bad = [];
+  const words = [];
+  let tokens = 0;
+  let bytes = 0;
+  let heaviest = null;
+  for (const name of Object.keys(fields).sort(byCodePoint)) {
+    const text = fields[name];
+    if (typeof text !== 'string') {
+      const message = `must be a string, got ${jsonKind(text)}`;
+      bad.push({ path: `/fields/${name.replaceAll('~', '~0').replaceAll('/', '~1')}`, message });
+      counted.push({
+        name,
+        words: 0,
+        lines: 0,
+        characters: 0,
+        bytes: 0,
+        error: message,
+        error_code: 'invalid_input',
+      });
+      continue;
+    }
+    const prepared = prepare(text, normalize);
+    const counts = tally(prepared, tokenizer, mode);
+    words.push(...splitWords(prepared, tokenizer));
+    tokens += estimateTokens(prepared);
+    total.words += counts.words;
+    total.lines += counts.lines;
+    total.characters += counts.characters;
+    const size = new TextEncoder().encode(prepared).length;
+    bytes += size;
+    // Names come sorted, so a tie keeps the first.
+    if (heaviest === null || size > heaviest.bytes) {
+      heaviest = { name, bytes: size };
+    }
+    counted.push({ name, ...counts, bytes: size });
+  }
+  if (bytes > 0) {
+    total.bytes = bytes;
+  }
+  if (bad.length > 0 && (input.fail_fast === true || bad.length === counted.length)) {
+    return failFields(bad);
+  }
+  let [output, fallback] = summary(total, input.locale ?? '');
+  if (input.tokens) {
+    output += tokensNote(tokens);
+  }
+  if (bad.length > 0) {
+    total.warning = `${bad.length} of ${counted.length} fields failed`;
+    output += `; warning: ${total.warning}`;
+  }
+  if (counted.length > 0) {
+    total.fields = counted;
+  }
+  if (heaviest !== null) {
+    total.heaviest_field = heaviest.name;
+  }
+  if (input.length_histogram === true) {
+    addHistogram(total, words, mode);
+  }
+  addTopWords(total, words, input);
+  addStats(total, words, input, mode);
+  if (input.tokens && tokens > 0) {
+    total.estimated_tokens = tokens;
+  }
+  if (input.explain === true) {
+    total.explain = explain(words, input);
+  }
+  return warn(ok(output, total), fallback);
+}
+
+/** The JSON type name of value, as the Go template reports it. */
+function jsonKind(value) {
+  if (value === null) {
+    return 'null';
+  }
+  if (Array.isArray(value)) {
+    return 'array';
+  }
+  return typeof value === 'object' ? 'object' : typeof value;
+}
+
+// Go sorts strings by UTF-8 bytes, which is code point order; JavaScript's
+// default sort compares UTF-16 code units and disagrees above U+FFFF.
+function byCodePoint(a, b) {
+  const x = [...a].map((c) => c.codePointAt(0));
+  const y = [...b].map((c) => c.codePointAt(0));
+  for (let i = 0; i < Math.min(x.length, y.length); i++) {
+    if (x[i] !== y[i]) {
+      return x[i] - y[i];
+    }
+  }
+  return x.length - y.length;
+}
+
+/** Drop a leading BOM, as the Go SDK's CleanText does, then normalize. */
+function prepare(text, normalize) {
+  return normalize(stripBom(text));
+}
+
+/** Drop a leading BOM. */
+function stripBom(text) {
+  return text.startsWith('\ufeff') ? text.slice(1) : text;
+}
+
+/**
+ * Split text into words as the tokenizer arg says, or at each non-empty
+ * match of tokenizer when it is word_regex compiled, the same way as the Go
+ * template's splitWords.
+ */
+function splitWords(text, tokenizer) {
+  if (tokenizer instanceof RegExp) {
+    return [...text.matchAll(tokenizer)].map((m) => m[0]).filter((word) => word !== '');
+  }
+  if (tokenizer === 'uax29') {
+    return segmentWords(text);
+  }
+  if (tokenizer === 'smart') {
+    return smartWords(text);
+  }
+  return text.split(SPACE).filter((word) => word !== '');
+}
+
+// smartWords splits text at anything that is not a letter or digit, keeping
+// the joiners joins allows inside a word.
+function smartWords(text) {
+  const chars = Array.from(text);
+  const words = [];
+  let start = -1; // where the word being read begins, or -1 between words
+  chars.forEach((c, i) => {
+    const inWord =
+      WORD_CHAR.test(c) ||
+      (start >= 0 &&
+        (EXTENDS.test(c) || (i + 1 < chars.length && joins(chars[i - 1], c, chars[i + 1]))));
+    if (inWord && start < 0) {
+      start = i;
+    } else if (!inWord && start >= 0) {
+      words.push(chars.slice(start, i).join(''));
+      start = -1;
+    }
+  });
+  if (start >= 0) {
+    words.push(chars.slice(start).join(''));
+  }
+  return words;
+}
+
+// Letters and digits, as the Go template's wordRune sees them.
+const WORD_CHAR = /^[\p{Alphabetic}\p{N}]$/u;
+const NUMBER = /^\p{N}$/u;
+
+/** Whether c, inside a word after prev, keeps the word going on to next. */
+function joins(prev, c, next) {
+  if ("'’-‐".includes(c)) {
+    return WORD_CHAR.test(next);
+  }
+  return (c === '.' || c === ',') && NUMBER.test(prev) && NUMBER.test(next);
+}
+
+/**
+ * Approximate the Unicode Word_Break property of c without tables, as the Go
+ * template's wordBreakOf does.
+ */
+function wordBreakOf(c) {
+  const cp = c.codePointAt(0);
+  const within = (lo, hi) => cp >= lo && cp <= hi;
+  if (EXTENDS.test(c) || c === '\u200d') {
+    return 'Extend';
+  }
+  if (within(0x30a0, 0x30ff) || within(0x31f0, 0x31ff) || within(0xff66, 0xff9f)) {
+    return 'Katakana';
+  }
+  if (
+    within(0x3040, 0x309f) ||
+    within(0x3400, 0x4dbf) ||
+    within(0x4e00, 0x9fff) ||
+    within(0xf900, 0xfaff) ||
+    within(0x20000, 0x3ffff)
+  ) {
+    return 'Ideograph';
+  }
+  if (NUMBER.test(c)) {
+    return 'Numeric';
+  }
+  if (WORD_CHAR.test(c)) {
+    return 'Letter';
+  }
+  return Object.keys(WORD_JOINERS).find((wb) => WORD_JOINERS[wb].includes(c)) ?? 'Other';
+}
+
+// The joiners UAX #29 lists by code point, by Word_Break property.
+const WORD_JOINERS = {
+  ExtendNumLet: '_\u202f\u203f\u2040\u2054\ufe33\ufe34\ufe4d\ufe4e\ufe4f\uff3f',
+  MidLetter: ':\u00b7\u0387\u05f4\u2027\ufe13\ufe55\uff1a',
+  MidNum: ',;\u037e\u0589\u060c\u060d\u066c\u07f8\u2044\ufe10\ufe14\ufe50\ufe54\uff0c\uff1b',
+  MidNumLet: ".'\u2018\u2019\u2024\ufe52\uff07\uff0e",
+};
+
+const ALNUM = new Set(['Letter', 'Numeric']);
+const MID_LETTER = new Set(['MidLetter', 'MidNumLet']);
+const MID_NUM = new Set(['MidNum', 'MidNumLet']);
+const WORD_LIKE = new Set(['Letter', 'Numeric', 'Katakana', 'Ideograph']);
+
+/**
+ * Split text at its Unicode word boundaries, keeping the segments with a
+ * letter, digit, or ideograph in them, as the Go template's segmentWords does.
+ */
+function segmentWords(text) {
+  // WB4: a mark or joiner belongs to the character before it.
+  const units = [];
+  for (const c of text) {
+    const wb = wordBreakOf(c);
+    if (wb === 'Extend' && units.length > 0) {
+      units[units.length - 1].text += c;
+    } else {
+      units.push({ text: c, wb: wb === 'Extend' ? 'Other' : wb });
+    }
+  }
+  const cls = (i) => (units[i] ? units[i].wb : 'Other');
+  // Whether no boundary falls before unit i.
+  const joined = (i) => {
+    const [a, b] = [cls(i - 1), cls(i)];
+    return (
+      (ALNUM.has(a) && ALNUM.has(b)) || // WB5, WB8 to WB10
+      (a === 'Letter' && MID_LETTER.has(b) && cls(i + 1) === 'Letter') || // WB6
+      (MID_LETTER.has(a) && cls(i - 2) === 'Letter' && b === 'Letter') || // WB7
+      (a === 'Numeric' && MID_NUM.has(b) && cls(i + 1) === 'Numeric') || // WB12
+      (MID_NUM.has(a) && cls(i - 2) === 'Numeric' && b === 'Numeric') || // WB11
+      (a === 'Katakana' && b === 'Katakana') || // WB13
+      ((ALNUM.has(a) || a === 'Katakana' || a === 'ExtendNumLet') && b === 'ExtendNumLet') || // WB13a
+      (a === 'ExtendNumLet' && (ALNUM.has(b) || b === 'Katakana')) // WB13b
+    );
+  };
+  const words = [];
+  let segment = '';
+  let wordLike = false;
+  units.forEach((unit, i) => {
+    if (i > 0 && !joined(i)) {
+      if (wordLike) {
+        words.push(segment);
+      }
+      segment = '';
+      wordLike = false;
+    }
+    segment += unit.text;
+    wordLike = wordLike || WORD_LIKE.has(unit.wb);
+  });
+  if (wordLike) {
+    words.push(segment);
+  }
+  return words;
+}
+
+function tally(text, tokenizer, mode) {
+  return {
+    words: splitWords(text, tokenizer).length,
+    lines: text === '' ? 0 : text.split('\n').length,
+    characters: characters(text, mode),
+  };
+}
+
+function characters(text, mode) {
+  if (mode === 'bytes') {
+    return new TextEncoder().encode(text).length;
+  }
+  return mode === 'graphemes' ? graphemes(text) : [...text].length;
+}
+
+/**
+ * Set counts.length_histogram to the number of words of each length,
+ * shortest first, as the Go template does; left unset when there are none.
+ */
+function addHistogram(counts, words, mode) {
+  const buckets = new Map();
+  for (const word of words) {
+    const length = characters(word, mode);
+    buckets.set(length, (buckets.get(length) ?? 0) + 1);
+  }
+  if (buckets.size > 0) {
+    counts.length_histogram = [...buckets]
+      .sort(([a], [b]) => a - b)
+      .map(([length, count]) => ({ length, count }));
+  }
+}
+
+/**
+ * Set counts.unique_words and counts.top_words for input.top_words as the Go
+ * template does: words in their canonical form, most frequent first, ties
+ * in code point order.
+ */
+function addTopWords(counts, words, input) {
+  const n = input.top_words || (input.stats === true ? STATS_TOP_WORDS : 0);
+  if (n === 0) {
+    return;
+  }
+  const freq = new Map();
+  for (const word of words) {
+    const key = canonical(word, input);
+    freq.set(key, (freq.get(key) ?? 0) + 1);
+  }
+  if (freq.size > 0) {
+    counts.unique_words = freq.size;
+    counts.top_words = [...freq]
+      .sort(([a, x], [b, y]) => y - x || byCodePoint(a, b))
+      .slice(0, n)
+      .map(([word, count]) => ({ word, count }));
+  }
+}
+
+// How many top words stats adds when top_words is not set.
+const STATS_TOP_WORDS = 5;
+
+/**
+ * Set counts.longest_word and counts.average_word_length for input.stats as
+ * the Go template does: the first longest word in code point order, and the
+ * mean length rounded to two decimals, in count_mode units.
+ */
+function addStats(counts, words, input, mode) {
+  if (input.stats !== true || words.length === 0) {
+    return;
+  }
+  let longest = '';
+  let most = 0;
+  let total = 0;
+  for (const word of words) {
+    const length = characters(word, mode);
+    total += length;
+    if (length > most || (length === most && byCodePoint(word, longest) < 0)) {
+      longest = word;
+      most = length;
+    }
+  }
+  counts.longest_word = longest;
+  counts.average_word_length = Math.round((total * 100) / words.length) / 100;
+}
+
+// The warning for text that is only whitespace, as in the Go template.
+const BLANK_WARNING = 'input has no words, only whitespace';
+// The warning for text that had a BOM, as the Go SDK's CleanText adds.
+const BOM_WARNING = 'stripped a leading byte order mark (U+FEFF)';
+
+// What each tokenizer does, with examples, for explain's tokenizer_rule, as
+// in the Go template.
+const TOKENIZER_RULES = {
+  whitespace: 'splits at whitespace only, so e.g., and hello,world are one word each',
+  smart:
+    "splits at anything but letters and digits, keeping don't, well-known, and 1,000 whole, so e.g., is two words (e, g)",
+  uax29:
+    "splits at Unicode word boundaries (UAX #29) and drops punctuation, so e.g., is one word (e.g), don't and 1,000 one each, and hello,world and well-known two each",
+  regex: 'takes each non-empty match of word_regex as a word, skipping the text between matches',
+};
+
+// How many words explain lists, as in the Go template.
+const EXPLAIN_TOKENS = 10;
+
+/** How input turned text into words, as the Go template's explain reports it. */
+function explain(words, input) {
+  const tokenizer = input.word_regex
+    ? 'regex'
+    : input.tokenizer || input.segmentation || 'whitespace';
+  const info = {
+    tokenizer,
+    ...(input.word_regex ? { word_regex: input.word_regex } : {}),
+    tokenizer_rule: TOKENIZER_RULES[tokenizer],
+    strip: hasBom(input) ? 'bom' : 'none',
+    trim: input.trim || 'none',
+    count_mode: input.count_mode || 'runes',
+  };
+  if (words.length > 0) {
+    info.tokens = words.slice(0, EXPLAIN_TOKENS);
+  }
+  return info;
+}
+
+// How many characters of a word the tokens estimate counts as one token.
+const CHARS_PER_TOKEN = 4;
+
+/**
+ * The gpt-bpe-approx estimate of the tokens in text, as in the Go template:
+ * each whitespace-separated word costs a token per CHARS_PER_TOKEN
+ * characters, rounded half up, and at least one.
+ */
+function estimateTokens(text) {
+  let n = 0;
+  for (const word of splitWords(text, 'whitespace')) {
+    const length = [...word].length;
+    n += Math.max(1, Math.floor((length + CHARS_PER_TOKEN / 2) / CHARS_PER_TOKEN));
+  }
+  return n;
+}
+
+/** What the tokens arg adds to the summary, saying the count is an estimate. */
+function tokensNote(tokens) {
+  const unit = tokens === 1 ? 'token' : 'tokens';
+  return `; ~${tokens} ${unit} (gpt-bpe-approx estimate, not an exact tokenizer count)`;
+}
+
+/**
+ * word as normalize_unicode and fold_case say top_words compares it. Each
+ * character is lowercased on its own, like Go's strings.ToLower, and U+0130
+ * becomes a plain "i" as it does there.
+ */
+function canonical(word, input) {
+  const form = input.normalize_unicode ?? 'none';
+  if (form !== 'none') {
+    word = word.normalize(form.toUpperCase());
+  }
+  if (input.fold_case === false) {
+    return word;
+  }
+  return Array.from(word, (c) => (c === '\u0130' ? 'i' : c.toLowerCase())).join('');
+}
+
+// Combining marks, variation selectors, and skin-tone modifiers: they attach
+// to the character before them.
+const EXTENDS = /^[\u0300-\u036f\u1ab0-\u1aff\u1dc0-\u1dff\u20d0-\u20ff\ufe20-\ufe2f\ufe00-\ufe0f\u{1f3fb}-\u{1f3ff}]$/u;
+
+/** Approximate user-perceived characters exactly as the Go template's graphemes does. */
+function graphemes(text) {
+  let n = 0;
+  let prev = '';
+  let flag = false; // prev is the first regional indicator of a pair
+  for (const c of text) {
+    const cp = c.codePointAt(0);
+    const regional = cp >= 0x1f1e6 && cp <= 0x1f1ff;
+    const joins =
+      prev !== '' &&
+      (EXTENDS.test(c) ||
+        c === '\u200d' ||
+        prev === '\u200d' ||
+        (prev === '\r' && c === '\n') ||
+        (regional && flag));
+    flag = regional && !flag;
+    if (!joins) {
+      n++;
+    }
+    prev = c;
+  }
+  return n;
+}
+
+// Plural forms of each counted unit per language, in CLDR order
+// (one, few, many / one, other).
+const UNITS = {
+  en: {
+    words: ['word', 'words'],
+    lines: ['line', 'lines'],
+    characters: ['character', 'characters'],
+  },
+  fr: {
+    words: ['mot', 'mots'],
+    lines: ['ligne', 'lignes'],
+    characters: ['caractère', 'caractères'],
+  },
+  pl: {
+    words: ['słowo', 'słowa', 'słów'],
+    lines: ['wiersz', 'wiersze', 'wierszy'],
+    characters: ['znak', 'znaki', 'znaków'],
+  },
+  ru: {
+    words: ['слово', 'слова', 'слов'],
+    lines: ['строка', 'строки', 'строк'],
+    characters: ['символ', 'символа', 'символов'],
+  },
+};
+
+function pluralIndex(lang, n) {
+  const few = n % 10 >= 2 && n % 10 <= 4 && !(n % 100 >= 12 && n % 100 <= 14);
+  switch (lang) {
+    case 'fr':
+      return n <= 1 ? 0 : 1;
+    case 'pl':
+      return n === 1 ? 0 : few ? 1 : 2;
+    case 'ru':
+      return n % 10 === 1 && n % 100 !== 11 ? 0 : few ? 1 : 2;
+    default:
+      return n === 1 ? 0 : 1;
+  }
+}
+
+function plural(lang, n, forms) {
+  return forms[Math.min(pluralIndex(lang, n), forms.length - 1)];
+}
+
+/** Reduce a locale such as "pl_PL.UTF-8" or "ru-RU" to its language subtag. */
+function language(locale) {
+  return locale.split('.')[0].split(/[-_]/)[0].toLowerCase();
+}
+
+/**
+ * Render counts as "2 words, 1 line, 11 characters" in the requested locale.
+ * For languages without translations it renders English, and returns with it
+ * a warning saying so.
+ */
+function summary(counts, locale) {
+  let lang = language(locale || 'en');
+  let warning = '';
+  if (!Object.hasOwn(UNITS, lang)) {
+    warning = `no ${JSON.stringify(lang)} translation of word_count.summary; using en`;
+    lang = 'en';
+  }
+  const names = UNITS[lang];
+  const output = ['words', 'lines', 'characters']
+    .map((unit) => `${counts[unit]} ${plural(lang, counts[unit], names[unit])}`)
+    .join(', ');
+  return [output, warning];
+}
+
+function readStdin() {
+  const chunks = [];
+  let total = 0;
+  for (;;) {
+    const chunk = new Uint8Array(4096);
+    const n = Javy.IO.readSync(0, chunk);
+    if (n === 0) {
+      break;
+    }
+    chunks.push(chunk.subarray(0, n));
+    total += n;
+  }
+  const input = new Uint8Array(total);
+  let offset = 0;
+  for (const chunk of chunks) {
+    input.set(chunk, offset);
+    offset += chunk.length;
+  }
+  return input;
+}
+
+let result;
+try {
+  result = respond(readStdin());
+} catch (e) {
+  result = fail('internal', String(e));
+}
+Javy.IO.writeSync(1, new TextEncoder().encode(JSON.stringify(result)));
@@ -0,0 +1,1796 @@
+//! __SKILL_NAME__ — ZeroClaw Skill (Rust / WASI)
+//!
+//! Counts words, lines, and characters in text, byte-for-byte compatible with
+//! the Go `word_count` template: same args, same `ToolResult` fields and
+//! error codes, same `--schema`, probe, and JSON-Lines behaviour.
+//! Protocol: read JSON from stdin, write JSON result to stdout.
+//! Build:    cargo build --target wasm32-wasip1 --release
+//!           cp target/wasm32-wasip1/release/__BIN_NAME__.wasm tool.wasm
+//! Test:     zeroclaw skill test . --args '{"text":"hello world"}'
+//! Files:    zeroclaw skill test . --preopen ./docs:/data --args '{"path":"/data/notes.txt"}'
+//! Folders:  zeroclaw skill test . --preopen ./docs:/data --args '{"dir":"/data","glob":"*.txt"}'
+
+use serde::{Deserialize, Serialize};
+use serde_json::{json, Value};
+use std::collections::BTreeMap;
+use std::io::{self, BufRead, Read, Write};
+use unicode_normalization::UnicodeNormalization;
+
+#[derive(Deserialize)]
+struct Args {
+    #[serde(default)]
+    text: String,
+    /// A file to analyze instead of `text`; must lie under a directory the
+    /// host preopened (manifest capabilities.fs).
+    #[serde(default)]
+    path: String,
+    /// Language of the summary (e.g. "pl", "ru"). Empty falls back to
+    /// ZEROCLAW_LOCALE, then English.
+    #[serde(default)]
+    locale: String,
+    /// Whitespace to normalize before counting: "none", "edges", or "collapse"
+    /// (see the Go template's `Args.Trim`).
+    #[serde(default)]
+    trim: String,
+    /// What `characters` counts: "runes" (the default), "bytes", or
+    /// "graphemes" (see the Go template's `Args.CountMode`).
+    #[serde(default)]
+    count_mode: String,
+    /// How text splits into words: "whitespace" (the default), "smart", or
+    /// "uax29" (see the Go template's `Args.Tokenizer`).
+    #[serde(default)]
+    tokenizer: String,
+    /// Another name for `tokenizer`, "whitespace" or "uax29" (see the Go
+    /// template's `Args.Segmentation`).
+    #[serde(default)]
+    segmentation: String,
+    /// A regular expression each word matches, instead of `tokenizer`
+    /// (see the Go template's `Args.WordRegex`); `word_re` is it compiled.
+    #[serde(default)]
+    word_regex: String,
+    #[serde(skip)]
+    word_re: Option<regex::Regex>,
+    /// Named texts to count separately and in total, instead of `text` or
+    /// `path`. A `BTreeMap` sorts them by name, as the Go template does.
+    /// Entries that are not strings fail on their own unless `fail_fast`.
+    #[serde(default)]
+    fields: Option<BTreeMap<String, Value>>,
+    /// Fail the whole call when any entry of `fields` is bad.
+    #[serde(default)]
+    fail_fast: bool,
+    /// A directory whose files, at any depth, to count separately and in
+    /// total, instead of `text`, `path`, or `fields`; `glob` keeps only the
+    /// files whose names match it (see the Go template's `Args.Dir`).
+    #[serde(default)]
+    dir: String,
+    #[serde(default)]
+    glob: String,
+    /// Add `length_histogram` to the result.
+    #[serde(default)]
+    length_histogram: bool,
+    /// Add `unique_words` and this many of the most frequent words; zero
+    /// leaves both out.
+    #[serde(default)]
+    top_words: i64,
+    /// Another name for `top_words` (see the Go template's `Args.Top`).
+    #[serde(default)]
+    top: i64,
+    /// Lowercase words before `top_words` compares them; on unless false.
+    #[serde(default)]
+    fold_case: Option<bool>,
+    /// Unicode normalization form ("nfc" or "nfkc") to compare words in for
+    /// `top_words` (see the Go template's `Args.NormalizeUnicode`).
+    #[serde(default)]
+    normalize_unicode: String,
+    /// `fold_case` the other way round (see the Go template's
+    /// `Args.CaseSensitive`).
+    #[serde(default)]
+    case_sensitive: Option<bool>,
+    /// Add `longest_word`, `average_word_length`, and `top_words`, at
+    /// [`STATS_TOP_WORDS`] unless that is set too.
+    #[serde(default)]
+    stats: bool,
+    /// Add `explain` to the result.
+    #[serde(default)]
+    explain: bool,
+    /// "gpt-bpe-approx" adds `estimated_tokens` (see the Go template's
+    /// `Args.Tokens`).
+    #[serde(default)]
+    tokens: String,
+    /// Count only `length` characters of `text` or `path` from `offset` on,
+    /// in `count_mode` units (see the Go template's `Args.Offset`).
+    #[serde(default)]
+    offset: i64,
+    #[serde(default)]
+    length: Option<i64>,
+}
+
+#[derive(Serialize)]
+struct CountResult {
+    words: usize,
+    lines: usize,
+    characters: usize,
+    /// Set only for `fields` and `dir`, as the total of their entries' bytes.
+    #[serde(skip_serializing_if = "is_zero")]
+    bytes: usize,
+    /// Set only for `path`: inline text is valid UTF-8 once JSON-decoded.
+    #[serde(skip_serializing_if = "Option::is_none")]
+    encoding: Option<&'static str>,
+    #[serde(skip_serializing_if = "is_zero")]
+    invalid_bytes: usize,
+    /// Invalid bytes in `path`, or text that is only whitespace.
+    #[serde(skip_serializing_if = "Option::is_none")]
+    warning: Option<String>,
+    /// Per-field counts for `fields`; the counts above are then their totals.
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    fields: Vec<FieldCount>,
+    /// The good field with the most bytes, the first by name on a tie.
+    #[serde(skip_serializing_if = "Option::is_none")]
+    heaviest_field: Option<String>,
+    /// Per-file counts for `dir`, sorted by path.
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    files: Vec<FileResult>,
+    /// Words per length in `count_mode` units, shortest first; each length
+    /// gets its own bucket, as in the Go template.
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    length_histogram: Vec<LengthBucket>,
+    /// Distinct words and the most frequent, in their canonical form, for
+    /// `top_words`: most frequent first, ties in byte order.
+    #[serde(skip_serializing_if = "is_zero")]
+    unique_words: usize,
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    top_words: Vec<WordFreq>,
+    /// The longest word as written, the first in byte order on a tie, and
+    /// the mean word length rounded to two decimals, in `count_mode` units,
+    /// for `stats`.
+    #[serde(skip_serializing_if = "String::is_empty")]
+    longest_word: String,
+    #[serde(
+        skip_serializing_if = "is_zero_f64",
+        serialize_with = "serialize_number"
+    )]
+    average_word_length: f64,
+    /// The `tokens` estimate, over every text counted.
+    #[serde(skip_serializing_if = "is_zero")]
+    estimated_tokens: usize,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    explain: Option<Explain>,
+}
+
+/// How the counts were derived, for `explain` (see the Go template's
+/// `ExplainInfo`).
+#[derive(Serialize)]
+struct Explain {
+    tokenizer: String,
+    #[serde(skip_serializing_if = "String::is_empty")]
+    word_regex: String,
+    tokenizer_rule: &'static str,
+    strip: &'static str,
+    trim: String,
+    count_mode: String,
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    tokens: Vec<String>,
+}
+
+/// The warning for text that is only whitespace.
+const BLANK_WARNING: &str = "input has no words, only whitespace";
+/// The warning for text that had a BOM, as the Go SDK's CleanText adds.
+const BOM_WARNING: &str = "stripped a leading byte order mark (U+FEFF)";
+
+/// `Explain::tokenizer_rule` for a tokenizer (see the Go template's
+/// `tokenizerRules`).
+fn tokenizer_rule(tokenizer: &str) -> &'static str {
+    match tokenizer {
+        "whitespace" => "splits at whitespace only, so e.g., and hello,world are one word each",
+        "smart" => "splits at anything but letters and digits, keeping don't, well-known, and 1,000 whole, so e.g., is two words (e, g)",
+        "uax29" => "splits at Unicode word boundaries (UAX #29) and drops punctuation, so e.g., is one word (e.g), don't and 1,000 one each, and hello,world and well-known two each",
+        "regex" => "takes each non-empty match of word_regex as a word, skipping the text between matches",
+        _ => "",
+    }
+}
+
+/// How many top words `stats` adds when `top_words` is not set.
+const STATS_TOP_WORDS: i64 = 5;
+
+/// How many words `Explain::tokens` lists.
+const EXPLAIN_TOKENS: usize = 10;
+
+/// How many characters of a word `tokens` counts as one token.
+const CHARS_PER_TOKEN: usize = 4;
+
+#[derive(Serialize)]
+struct LengthBucket {
+    length: usize,
+    count: usize,
+}
+
+#[derive(Serialize)]
+struct WordFreq {
+    word: String,
+    count: usize,
+}
+
+#[derive(Serialize)]
+struct FieldCount {
+    name: String,
+    words: usize,
+    lines: usize,
+    characters: usize,
+    /// The UTF-8 length of the text as counted, whatever `count_mode` is.
+    bytes: usize,
+    /// Set, with the counts zero, for a bad entry.
+    #[serde(skip_serializing_if = "Option::is_none")]
+    error: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    error_code: Option<&'static str>,
+}
+
+#[derive(Serialize)]
+struct FileResult {
+    /// Relative to `dir` and slash-separated.
+    path: String,
+    words: usize,
+    lines: usize,
+    characters: usize,
+    /// The UTF-8 length of the text as counted, after decoding.
+    bytes: usize,
+    encoding: &'static str,
+    #[serde(skip_serializing_if = "is_zero")]
+    invalid_bytes: usize,
+}
+
+/// Where a request's args went wrong; `path` is a JSON Pointer.
+#[derive(Serialize)]
+struct FieldError {
+    path: String,
+    message: String,
+}
+
+fn is_zero(n: &usize) -> bool {
+    *n == 0
+}
+
+fn is_zero_f64(n: &f64) -> bool {
+    *n == 0.0
+}
+
+/// Write `n` as Go's encoding/json does, without a `.0` when it is whole.
+fn serialize_number<S: serde::Serializer>(n: &f64, serializer: S) -> Result<S::Ok, S::Error> {
+    if n.fract() == 0.0 && n.abs() < 1e15 {
+        serializer.serialize_i64(*n as i64)
+    } else {
+        serializer.serialize_f64(*n)
+    }
+}
+
+/// Answer to a `{"__probe":true}` envelope.
+#[derive(Serialize)]
+struct ProbeReport {
+    tools: Vec<ProbeTool>,
+    permissions: &'static [&'static str],
+}
+
+#[derive(Serialize)]
+struct ProbeTool {
+    name: &'static str,
+    schema: Value,
+}
+
+/// `ToolResult.data`; structs keep their fields in declaration order on the wire.
+#[derive(Serialize)]
+#[serde(untagged)]
+enum Data {
+    Counts(CountResult),
+    Probe(ProbeReport),
+}
+
+#[derive(Serialize)]
+struct ToolResult {
+    success: bool,
+    output: String,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    error: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    error_code: Option<&'static str>,
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    field_errors: Vec<FieldError>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    data: Option<Data>,
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    warnings: Vec<String>,
+}
+
+impl ToolResult {
+    fn ok(output: String, data: Data) -> Self {
+        Self {
+            success: true,
+            output,
+            error: None,
+            error_code: None,
+            field_errors: Vec::new(),
+            data: Some(data),
+            warnings: Vec::new(),
+        }
+    }
+
+    fn fail(code: &'static str, msg: String) -> Self {
+        Self {
+            success: false,
+            output: String::new(),
+            error: Some(msg),
+            error_code: Some(code),
+            field_errors: Vec::new(),
+            data: None,
+            warnings: Vec::new(),
+        }
+    }
+
+    /// An `invalid_input` failure listing `errs`, like the Go SDK's FailFields.
+    fn fail_fields(errs: Vec<FieldError>) -> Self {
+        let msg = errs
+            .iter()
+            .map(|e| {
+                if e.path.is_empty() {
+                    e.message.clone()
+                } else {
+                    format!("{}: {}", e.path, e.message)
+                }
+            })
+            .collect::<Vec<_>>()
+            .join("; ");
+        Self {
+            field_errors: errs,
+            ..Self::fail("invalid_input", msg)
+        }
+    }
+}
+
+const EXPECT: &str = r#"{"text":"..."} or {"path":"..."}"#;
+/// Keep in sync with manifest capabilities.fs.
+const PERMISSIONS: &[&str] = &["fs:/data"];
+
+fn args_schema() -> Value {
+    json!({
+        "type": "object",
+        "required": [],
+        "properties": {
+            "text": {"type": "string", "description": "Text to analyze"},
+            "path": {
+                "type": "string",
+                "description": "File to analyze instead of text; must be under a preopened directory"
+            },
+            "locale": {
+                "type": "string",
+                "description": "Language of the summary (e.g. en, pl, ru); defaults to English"
+            },
+            "trim": {
+                "type": "string",
+                "description": "Whitespace to normalize before counting: none (default), edges, or collapse"
+            },
+            "count_mode": {
+                "type": "string",
+                "enum": COUNT_MODES,
+                "description": "What characters counts: runes (default), bytes, or graphemes"
+            },
+            "tokenizer": {
+                "type": "string",
+                "enum": TOKENIZERS,
+                "description": "How text splits into words: whitespace (default); smart, which also splits at punctuation but keeps words like don't and well-known whole; or uax29, at Unicode word boundaries"
+            },
+            "segmentation": {
+                "type": "string",
+                "enum": SEGMENTATIONS,
+                "description": "Another name for tokenizer, limited to the Unicode segmentation choices: whitespace (default) or uax29"
+            },
+            "word_regex": {
+                "type": "string",
+                "description": "Regular expression each word matches, instead of a tokenizer, e.g. \\p{L}+"
+            },
+            "fields": {
+                "type": "object",
+                "additionalProperties": {"type": "string"},
+                "description": "Named texts to count separately and in total, instead of text or path"
+            },
+            "fail_fast": {
+                "type": "boolean",
+                "description": "Fail the whole call if any field is bad, instead of reporting it per field"
+            },
+            "dir": {
+                "type": "string",
+                "description": "Directory whose files to count separately and in total, instead of text, path, or fields; must be under a preopened directory"
+            },
+            "glob": {
+                "type": "string",
+                "description": "Only count the files under dir whose names match this pattern, e.g. *.txt"
+            },
+            "length_histogram": {
+                "type": "boolean",
+                "description": "Add how many words there are of each length to the result"
+            },
+            "top_words": {
+                "type": "integer",
+                "minimum": 0,
+                "description": "Add this many of the most frequent words, and how many distinct words there are, to the result"
+            },
+            "top": {
+                "type": "integer",
+                "minimum": 0,
+                "description": "Another name for top_words"
+            },
+            "fold_case": {
+                "type": "boolean",
+                "description": "Treat words that differ only in case as one word for top_words; defaults to true"
+            },
+            "normalize_unicode": {
+                "type": "string",
+                "enum": NORMALIZATIONS,
+                "description": "Unicode normalization form to compare words in for top_words: none (default), nfc, or nfkc"
+            },
+            "case_sensitive": {
+                "type": "boolean",
+                "description": "Keep words that differ only in case apart for top_words; the opposite of fold_case, defaults to false"
+            },
+            "stats": {
+                "type": "boolean",
+                "description": "Add the longest word, the average word length, and the top_words most frequent words (5 unless set) to the result"
+            },
+            "explain": {
+                "type": "boolean",
+                "description": "Add how the counts were derived, and the first words found, to the result"
+            },
+            "tokens": {
+                "type": "string",
+                "enum": TOKEN_ESTIMATES,
+                "description": "Add an estimate of the LLM tokens in the text: gpt-bpe-approx, about a token per four characters of each word"
+            },
+            "offset": {
+                "type": "integer",
+                "minimum": 0,
+                "description": "Count only from this many characters into the text: runes, or bytes with count_mode bytes"
+            },
+            "length": {
+                "type": "integer",
+                "minimum": 0,
+                "description": "Count only this many characters from offset on, in the same unit; defaults to the rest of the text"
+            }
+        }
+    })
+}
+
+fn output_schema() -> Value {
+    json!({
+        "type": "object",
+        "required": ["words", "lines", "characters"],
+        "properties": {
+            "words": {"type": "integer"},
+            "lines": {"type": "integer"},
+            "characters": {"type": "integer"},
+            "bytes": {"type": "integer"},
+            "encoding": {"type": "string"},
+            "invalid_bytes": {"type": "integer"},
+            "warning": {"type": "string"},
+            "fields": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "required": ["name", "words", "lines", "characters", "bytes"],
+                    "properties": {
+                        "name": {"type": "string"},
+                        "words": {"type": "integer"},
+                        "lines": {"type": "integer"},
+                        "characters": {"type": "integer"},
+                        "bytes": {"type": "integer"},
+                        "error": {"type": "string"},
+                        "error_code": {"type": "string"}
+                    }
+                }
+            },
+            "heaviest_field": {"type": "string"},
+            "files": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "required": ["path", "words", "lines", "characters", "bytes", "encoding"],
+                    "properties": {
+                        "path": {"type": "string"},
+                        "words": {"type": "integer"},
+                        "lines": {"type": "integer"},
+                        "characters": {"type": "integer"},
+                        "bytes": {"type": "integer"},
+                        "encoding": {"type": "string"},
+                        "invalid_bytes": {"type": "integer"}
+                    }
+                }
+            },
+            "length_histogram": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "required": ["length", "count"],
+                    "properties": {
+                        "length": {"type": "integer"},
+                        "count": {"type": "integer"}
+                    }
+                }
+            },
+            "unique_words": {"type": "integer"},
+            "top_words": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "required": ["word", "count"],
+                    "properties": {
+                        "word": {"type": "string"},
+                        "count": {"type": "integer"}
+                    }
+                }
+            },
+            "longest_word": {"type": "string"},
+            "average_word_length": {"type": "number"},
+            "estimated_tokens": {"type": "integer"},
+            "explain": {
+                "type": "object",
+                "required": ["tokenizer", "tokenizer_rule", "strip", "trim", "count_mode"],
+                "properties": {
+                    "tokenizer": {"type": "string"},
+                    "word_regex": {"type": "string"},
+                    "tokenizer_rule": {"type": "string"},
+                    "strip": {"type": "string"},
+                    "trim": {"type": "string"},
+                    "count_mode": {"type": "string"},
+                    "tokens": {"type": "array", "items": {"type": "string"}}
+                }
+            }
+        }
+    })
+}
+
+fn write_value<T: Serialize>(value: &T) {
+    let out = serde_json::to_string(value).unwrap_or_else(|_| {
+        r#"{"success":false,"output":"","error":"serialization error","error_code":"internal"}"#
+            .to_string()
+    });
+    let _ = io::stdout().write_all(out.as_bytes());
+}
+
+fn main() {
+    match std::env::args().nth(1).as_deref() {
+        Some("--schema") => return write_value(&args_schema()),
+        Some("--output-schema") => return write_value(&output_schema()),
+        _ => {}
+    }
+
+    if std::env::var("ZEROCLAW_JSONL").as_deref() == Ok("1") {
+        // One result line per input line, written as soon as it is read.
+        let stdin = io::stdin();
+        let mut stdout = io::stdout();
+        for line in stdin.lock().split(b'\n') {
+            let result = match line {
+                Ok(line) if line.iter().all(u8::is_ascii_whitespace) => continue,
+                Ok(line) => respond(&line),
+                Err(e) => ToolResult::fail("internal", format!("failed to read stdin: {e}")),
+            };
+            write_value(&result);
+            let _ = stdout.write_all(b"\n");
+            let _ = stdout.flush();
+        }
+        return;
+    }
+
+    let mut buf = Vec::new();
+    let result = match io::stdin().read_to_end(&mut buf) {
+        Ok(_) => respond(&buf),
+        Err(e) => ToolResult::fail("internal", format!("failed to read stdin: {e}")),
+    };
+    write_value(&result);
+}
+
+/// Answer one request: a probe envelope is described, anything else counted.
+fn respond(input: &[u8]) -> ToolResult {
+    let text = match decode_text(input) {
+        Ok(text) => text,
+        Err(at) => {
+            return ToolResult::fail(
+                "invalid_input",
+                format!("input is not valid UTF-8 at byte {at}"),
+            )
+        }
+    };
+    if text.contains("__probe") {
+        let probe: Value = serde_json::from_str(&text).unwrap_or(Value::Null);
+        if probe.get("__probe") == Some(&Value::Bool(true)) {
+            return ToolResult::ok(
+                "probe".into(),
+                Data::Probe(ProbeReport {
+                    tools: vec![ProbeTool {
+                        name: "__SKILL_NAME__",
+                        schema: args_schema(),
+                    }],
+                    permissions: PERMISSIONS,
+                }),
+            );
+        }
+    }
+    match serde_json::from_str::<Args>(&text) {
+        Ok(args) => {
+            let bom = has_bom(&args);
+            let mut result = count(args);
+            if bom && result.success {
+                result.warnings.push(BOM_WARNING.to_string());
+            }
+            result
+        }
+        Err(e) => ToolResult::fail(
+            "invalid_input",
+            format!("invalid input JSON: {e} — expected {EXPECT}"),
+        ),
+    }
+}
+
+/// Values `count_mode`, `tokenizer`, `segmentation`, `normalize_unicode`,
+/// and `tokens` accept, as the Go template's `validate:"oneof"` tags list
+/// them.
+const COUNT_MODES: [&str; 3] = ["bytes", "runes", "graphemes"];
+const TOKENIZERS: [&str; 3] = ["whitespace", "smart", "uax29"];
+const SEGMENTATIONS: [&str; 2] = ["whitespace", "uax29"];
+const NORMALIZATIONS: [&str; 3] = ["none", "nfc", "nfkc"];
+const TOKEN_ESTIMATES: [&str; 1] = ["gpt-bpe-approx"];
+
+/// The error for `value` outside `allowed`, unless it is empty.
+fn one_of(path: &str, value: &str, allowed: &[&str]) -> Option<FieldError> {
+    (!value.is_empty() && !allowed.contains(&value)).then(|| FieldError {
+        path: path.to_string(),
+        message: format!("must be one of {}, got {value:?}", allowed.join(", ")),
+    })
+}
+
+/// The error for a negative `value`.
+fn at_least_zero(path: &str, value: i64) -> Option<FieldError> {
+    (value < 0).then(|| FieldError {
+        path: path.to_string(),
+        message: format!("must be at least 0, got {value}"),
+    })
+}
+
+fn count(mut args: Args) -> ToolResult {
+    // In the Go template's field order, as its validate tags report them.
+    let invalid: Vec<FieldError> = [
+        one_of("/count_mode", &args.count_mode, &COUNT_MODES),
+        one_of("/tokenizer", &args.tokenizer, &TOKENIZERS),
+        one_of("/segmentation", &args.segmentation, &SEGMENTATIONS),
+        at_least_zero("/top_words", args.top_words),
+        at_least_zero("/top", args.top),
+        one_of(
+            "/normalize_unicode",
+            &args.normalize_unicode,
+            &NORMALIZATIONS,
+        ),
+        one_of("/tokens", &args.tokens, &TOKEN_ESTIMATES),
+        at_least_zero("/offset", args.offset),
+        args.length
+            .and_then(|length| at_least_zero("/length", length)),
+    ]
+    .into_iter()
+    .flatten()
+    .collect();
+    if !invalid.is_empty() {
+        return ToolResult::fail_fields(invalid);
+    }
+    let normalize = match trimmer(&args.trim) {
+        Ok(normalize) => normalize,
+        Err(msg) => return ToolResult::fail("invalid_input", msg),
+    };
+    if args.top != 0 {
+        if args.top_words != 0 && args.top_words != args.top {
+            return ToolResult::fail(
+                "invalid_input",
+                "top and top_words disagree; set only one".to_string(),
+            );
+        }
+        args.top_words = args.top;
+    }
+    if let Some(case_sensitive) = args.case_sensitive {
+        if args.fold_case == Some(case_sensitive) {
+            return ToolResult::fail(
+                "invalid_input",
+                "case_sensitive and fold_case disagree; set only one".to_string(),
+            );
+        }
+        args.fold_case = Some(!case_sensitive);
+    }
+    if args.stats && args.top_words == 0 {
+        args.top_words = STATS_TOP_WORDS;
+    }
+    if !args.segmentation.is_empty() {
+        if !args.tokenizer.is_empty() && args.tokenizer != args.segmentation {
+            return ToolResult::fail(
+                "invalid_input",
+                "segmentation and tokenizer disagree; set only one".to_string(),
+            );
+        }
+        args.tokenizer = std::mem::take(&mut args.segmentation);
+    }
+    if !args.word_regex.is_empty() {
+        if !args.tokenizer.is_empty() {
+            return ToolResult::fail(
+                "invalid_input",
+                "word_regex cannot be combined with tokenizer".to_string(),
+            );
+        }
+        match regex::Regex::new(&args.word_regex) {
+            Ok(re) => args.word_re = Some(re),
+            Err(e) => return ToolResult::fail("invalid_input", format!("invalid word_regex: {e}")),
+        }
+    }
+    if (args.offset != 0 || args.length.is_some())
+        && (!args.dir.is_empty() || args.fields.is_some())
+    {
+        return ToolResult::fail(
+            "invalid_input",
+            "offset and length apply only to text or path".to_string(),
+        );
+    }
+    if !args.dir.is_empty() {
+        if !args.text.is_empty() || !args.path.is_empty() || args.fields.is_some() {
+            return ToolResult::fail(
+                "invalid_input",
+                "dir cannot be combined with text, path, or fields".to_string(),
+            );
+        }
+        return count_dir(normalize, &args);
+    }
+    if !args.glob.is_empty() {
+        return ToolResult::fail("invalid_input", "glob needs dir".to_string());
+    }
+    if let Some(fields) = &args.fields {
+        if !args.text.is_empty() || !args.path.is_empty() {
+            return ToolResult::fail(
+                "invalid_input",
+                "fields cannot be combined with text or path".to_string(),
+            );
+        }
+        return count_fields(fields, normalize, &args);
+    }
+    let mut decoded = None;
+    let mut file_bom = false;
+    if !args.path.is_empty() {
+        let path = match check_path(&args.path) {
+            Ok(path) => path,
+            Err(msg) => return ToolResult::fail("permission_denied", msg),
+        };
+        let bytes = match std::fs::read(&path) {
+            Ok(bytes) => bytes,
+            Err(e) => return ToolResult::fail("not_found", format!("open {path}: {e}")),
+        };
+        file_bom = starts_with_bom(&bytes);
+        let file = match inspect_text(&bytes) {
+            Ok(file) => file,
+            Err(at) => {
+                return ToolResult::fail(
+                    "invalid_input",
+                    format!("{}: invalid UTF-8 at byte {at}", args.path),
+                )
+            }
+        };
+        args.text = file.text.clone();
+        decoded = Some(file);
+    }
+    let (text, clamped) = window(strip_bom(&args.text), &args);
+    let text = normalize(text);
+    let mut counts = tally(&text, &args);
+    counts.encoding = decoded.as_ref().map(|d| d.encoding);
+    let words = split_words(&text, &args);
+    if args.length_histogram {
+        counts.length_histogram = histogram(words.iter().copied(), &args.count_mode);
+    }
+    if args.top_words > 0 {
+        (counts.unique_words, counts.top_words) = frequencies(words.iter().copied(), &args);
+    }
+    if args.stats {
+        (counts.longest_word, counts.average_word_length) =
+            word_stats(words.iter().copied(), &args.count_mode);
+    }
+    if args.explain {
+        let bom = file_bom || has_bom(&args);
+        counts.explain = Some(explain(words.iter().copied(), &args, bom));
+    }
+    let (mut output, fallback) = summary(&counts, &args.locale);
+    if !args.tokens.is_empty() {
+        counts.estimated_tokens = estimate_tokens(&text);
+        output += &tokens_note(counts.estimated_tokens);
+    }
+    if !text.is_empty() && text.trim().is_empty() {
+        output = format!("{output}; warning: {BLANK_WARNING}");
+        counts.warning = Some(BLANK_WARNING.to_string());
+    }
+    if let Some(d) = decoded.filter(|d| d.invalid_bytes > 0) {
+        let warning = format!(
+            "{} is not valid {}: {} {} replaced with U+FFFD (Latin-1 or corrupt?)",
+            args.path,
+            d.encoding,
+            d.invalid_bytes,
+            plural("en", d.invalid_bytes, &["byte", "bytes"]),
+        );
+        output = format!("{output}; warning: {warning}");
+        counts.invalid_bytes = d.invalid_bytes;
+        counts.warning = Some(warning);
+    }
+    let mut result = ToolResult::ok(output, Data::Counts(counts));
+    result.warnings.extend(fallback);
+    result.warnings.extend(clamped);
+    result
+}
+
+/// Cut `text` to the characters `offset` and `length` select, and say how a
+/// window that ran past the end of the text was clamped (see the Go
+/// template's `window`).
+fn window<'a>(text: &'a str, args: &Args) -> (&'a str, Option<String>) {
+    if args.offset == 0 && args.length.is_none() {
+        return (text, None);
+    }
+    let bytes = args.count_mode == "bytes";
+    let (units, size) = if bytes {
+        (["byte", "bytes"], text.len())
+    } else {
+        (["rune", "runes"], text.chars().count())
+    };
+    // The byte index where character `i` of `text` starts.
+    let at = |mut i: usize| {
+        if bytes {
+            while !text.is_char_boundary(i) {
+                i -= 1;
+            }
+            i
+        } else {
+            text.char_indices().nth(i).map_or(text.len(), |(b, _)| b)
+        }
+    };
+    let units = |n: usize| format!("{n} {}", plural("en", n, &units));
+    let offset = args.offset as usize;
+    if offset > size {
+        let warning = format!(
+            "offset {offset} is past the end of the text ({}); nothing counted",
+            units(size)
+        );
+        return ("", Some(warning));
+    }
+    match args.length.map(|length| length as usize) {
+        Some(length) if length <= size - offset => (&text[at(offset)..at(offset + length)], None),
+        Some(length) => {
+            let warning = format!(
+                "length {length} runs past the end of the text; counted the {} from offset {offset}",
+                units(size - offset)
+            );
+            (&text[at(offset)..], Some(warning))
+        }
+        None => (&text[at(offset)..], None),
+    }
+}
+
+/// Strip a leading BOM and repair invalid UTF-8 with U+FFFD, or, when the host
+/// set ZEROCLAW_STRICT_UTF8=1, return the offset of the first invalid byte.
+fn decode_text(bytes: &[u8]) -> Result<String, usize> {
+    let bytes = bytes.strip_prefix("\u{feff}".as_bytes()).unwrap_or(bytes);
+    match std::str::from_utf8(bytes) {
+        Ok(text) => Ok(text.to_string()),
+        Err(e) if std::env::var("ZEROCLAW_STRICT_UTF8").as_deref() == Ok("1") => {
+            Err(e.valid_up_to())
+        }
+        Err(_) => Ok(String::from_utf8_lossy(bytes).into_owned()),
+    }
+}
+
+/// Count each of `fields` and their total. Bad entries are listed with their
+/// error, and the call only fails when all of them are bad, or any is with
+/// `fail_fast`.
+fn count_fields(
+    fields: &BTreeMap<String, Value>,
+    normalize: fn(&str) -> String,
+    args: &Args,
+) -> ToolResult {
+    let mut total = tally("", args);
+    let mut bad = Vec::new();
+    let mut texts = Vec::new();
+    let mut heaviest = 0;
+    for (name, value) in fields {
+        let Value::String(text) = value else {
+            let message = format!("must be a string, got {}", json_kind(value));
+            bad.push(FieldError {
+                path: format!("/fields/{}", name.replace('~', "~0").replace('/', "~1")),
+                message: message.clone(),
+            });
+            total.fields.push(FieldCount {
+                name: name.clone(),
+                words: 0,
+                lines: 0,
+                characters: 0,
+                bytes: 0,
+                error: Some(message),
+                error_code: Some("invalid_input"),
+            });
+            continue;
+        };
+        let text = normalize(strip_bom(text));
+        let c = tally(&text, args);
+        total.words += c.words;
+        total.lines += c.lines;
+        total.characters += c.characters;
+        total.bytes += text.len();
+        if total.heaviest_field.is_none() || text.len() > heaviest {
+            total.heaviest_field = Some(name.clone());
+            heaviest = text.len();
+        }
+        total.fields.push(FieldCount {
+            name: name.clone(),
+            words: c.words,
+            lines: c.lines,
+            characters: c.characters,
+            bytes: text.len(),
+            error: None,
+            error_code: None,
+        });
+        texts.push(text);
+    }
+    if !bad.is_empty() && (args.fail_fast || bad.len() == fields.len()) {
+        return ToolResult::fail_fields(bad);
+    }
+    if args.length_histogram {
+        let words = texts.iter().flat_map(|t| split_words(t, args));
+        total.length_histogram = histogram(words, &args.count_mode);
+    }
+    if args.top_words > 0 {
+        let words = texts.iter().flat_map(|t| split_words(t, args));
+        (total.unique_words, total.top_words) = frequencies(words, args);
+    }
+    if args.stats {
+        let words = texts.iter().flat_map(|t| split_words(t, args));
+        (total.longest_word, total.average_word_length) = word_stats(words, &args.count_mode);
+    }
+    if args.explain {
+        let words = texts.iter().flat_map(|t| split_words(t, args));
+        total.explain = Some(explain(words, args, has_bom(args)));
+    }
+    let (mut output, fallback) = summary(&total, &args.locale);
+    if !args.tokens.is_empty() {
+        total.estimated_tokens = texts.iter().map(|t| estimate_tokens(t)).sum();
+        output += &tokens_note(total.estimated_tokens);
+    }
+    if !bad.is_empty() {
+        let warning = format!("{} of {} fields failed", bad.len(), fields.len());
+        output = format!("{output}; warning: {warning}");
+        total.warning = Some(warning);
+    }
+    let mut result = ToolResult::ok(output, Data::Counts(total));
+    result.warnings.extend(fallback);
+    result
+}
+
+/// Count each file under `args.dir` that `args.glob` matches, and their total.
+/// A file that cannot be read or decoded is left out of both, with a warning
+/// naming it.
+fn count_dir(normalize: fn(&str) -> String, args: &Args) -> ToolResult {
+    if !valid_glob(&args.glob.chars().collect::<Vec<_>>()) {
+        return ToolResult::fail(
+            "invalid_input",
+            format!("invalid glob {:?}: syntax error in pattern", args.glob),
+        );
+    }
+    let dir = match check_path(&args.dir) {
+        Ok(dir) => dir,
+        Err(msg) => return ToolResult::fail("permission_denied", msg),
+    };
+    let names = match list_files(&dir, &args.glob) {
+        Ok(names) => names,
+        Err(e) => return ToolResult::fail("not_found", format!("open {dir}: {e}")),
+    };
+    let mut total = tally("", args);
+    let mut skipped = Vec::new();
+    let mut texts = Vec::new();
+    let mut bom = false;
+    for name in &names {
+        let mut file_bom = false;
+        let decoded = match std::fs::read(format!("{dir}/{name}")) {
+            Ok(bytes) => {
+                file_bom = starts_with_bom(&bytes);
+                inspect_text(&bytes).map_err(|at| format!("invalid UTF-8 at byte {at}"))
+            }
+            Err(e) => Err(match e.kind() {
+                io::ErrorKind::NotFound => "not found".to_string(),
+                io::ErrorKind::PermissionDenied => "permission denied".to_string(),
+                _ => "cannot be read".to_string(),
+            }),
+        };
+        let decoded = match decoded {
+            Ok(decoded) => decoded,
+            Err(reason) => {
+                skipped.push(format!("skipped {name}: {reason}"));
+                continue;
+            }
+        };
+        bom |= file_bom;
+        let text = normalize(&decoded.text);
+        let c = tally(&text, args);
+        total.words += c.words;
+        total.lines += c.lines;
+        total.characters += c.characters;
+        total.bytes += text.len();
+        total.files.push(FileResult {
+            path: name.clone(),
+            words: c.words,
+            lines: c.lines,
+            characters: c.characters,
+            bytes: text.len(),
+            encoding: decoded.encoding,
+            invalid_bytes: decoded.invalid_bytes,
+        });
+        texts.push(text);
+    }
+    if args.length_histogram {
+        let words = texts.iter().flat_map(|t| split_words(t, args));
+        total.length_histogram = histogram(words, &args.count_mode);
+    }
+    if args.top_words > 0 {
+        let words = texts.iter().flat_map(|t| split_words(t, args));
+        (total.unique_words, total.top_words) = frequencies(words, args);
+    }
+    if args.stats {
+        let words = texts.iter().flat_map(|t| split_words(t, args));
+        (total.longest_word, total.average_word_length) = word_stats(words, &args.count_mode);
+    }
+    if args.explain {
+        let words = texts.iter().flat_map(|t| split_words(t, args));
+        total.explain = Some(explain(words, args, bom));
+    }
+    let (mut output, fallback) = summary(&total, &args.locale);
+    if !args.tokens.is_empty() {
+        total.estimated_tokens = texts.iter().map(|t| estimate_tokens(t)).sum();
+        output += &tokens_note(total.estimated_tokens);
+    }
+    if !skipped.is_empty() {
+        let warning = format!("{} of {} files skipped", skipped.len(), names.len());
+        output = format!("{output}; warning: {warning}");
+        total.warning = Some(warning);
+    }
+    let mut result = ToolResult::ok(output, Data::Counts(total));
+    result.warnings.extend(fallback);
+    result.warnings.extend(skipped);
+    result
+}
+
+/// The files under `dir`, at any depth, whose names match `glob`; an empty
+/// `glob` matches every file. Paths are relative to `dir` and sorted. A
+/// subdirectory that cannot be listed is returned as if it were a file, as
+/// the Go SDK's `skill.ListFiles` does.
+fn list_files(dir: &str, glob: &str) -> io::Result<Vec<String>> {
+    fn walk(dir: &str, rel: &str, glob: &[char], files: &mut Vec<String>) -> io::Result<()> {
+        for entry in std::fs::read_dir(format!("{dir}/{rel}"))? {
+            let entry = entry?;
+            let name = entry.file_name().to_string_lossy().into_owned();
+            let path = if rel.is_empty() {
+                name.clone()
+            } else {
+                format!("{rel}/{name}")
+            };
+            if entry.file_type()?.is_dir() && walk(dir, &path, glob, files).is_ok() {
+                continue;
+            }
+            if glob.is_empty() || glob_match(glob, &name.chars().collect::<Vec<_>>()) {
+                files.push(path);
+            }
+        }
+        Ok(())
+    }
+    let mut files = Vec::new();
+    walk(dir, "", &glob.chars().collect::<Vec<_>>(), &mut files)?;
+    files.sort();
+    Ok(files)
+}
+
+/// Whether `glob` is well formed for [`glob_match`], which, like Go's
+/// `path.Match`, knows `*`, `?`, `[...]` classes with ranges and a leading
+/// `^`, and `\` escapes.
+fn valid_glob(mut glob: &[char]) -> bool {
+    while let Some((&c, rest)) = glob.split_first() {
+        glob = match c {
+            '\\' if rest.is_empty() => return false,
+            '\\' => &rest[1..],
+            '[' => match class(rest, ' ') {
+                Some((_, rest)) => rest,
+                None => return false,
+            },
+            _ => rest,
+        };
+    }
+    true
+}
+
+/// Whether `name` matches all of `glob`, which [`valid_glob`] accepted.
+fn glob_match(glob: &[char], name: &[char]) -> bool {
+    match (glob.split_first(), name.split_first()) {
+        (None, _) => name.is_empty(),
+        (Some(('*', rest)), _) => (0..=name.len()).any(|i| glob_match(rest, &name[i..])),
+        (Some(_), None) => false,
+        (Some(('?', rest)), Some((_, tail))) => glob_match(rest, tail),
+        (Some(('[', rest)), Some((&c, tail))) => match class(rest, c) {
+            Some((true, rest)) => glob_match(rest, tail),
+            _ => false,
+        },
+        (Some(('\\', rest)), Some((&c, tail))) => {
+            rest.first() == Some(&c) && glob_match(&rest[1..], tail)
+        }
+        (Some((&p, rest)), Some((&c, tail))) => p == c && glob_match(rest, tail),
+    }
+}
+
+/// Parse the character class that `glob` continues after its `[`, returning
+/// whether it matches `c` and what follows its `]`, or `None` when it is
+/// malformed.
+fn class(glob: &[char], c: char) -> Option<(bool, &[char])> {
+    /// One possibly escaped class character; `-` and `]` must be escaped.
+    fn one(glob: &[char]) -> Option<(char, &[char])> {
+        match glob {
+            ['-' | ']', ..] | [] | ['\\'] => None,
+            ['\\', c, rest @ ..] | [c, rest @ ..] => Some((*c, rest)),
+        }
+    }
+    let (negate, mut glob) = match glob {
+        ['^', rest @ ..] => (true, rest),
+        _ => (false, glob),
+    };
+    let (mut matched, mut ranges) = (false, 0);
+    loop {
+        if let [']', rest @ ..] = glob {
+            if ranges > 0 {
+                return Some((matched != negate, rest));
+            }
+        }
+        let (lo, rest) = one(glob)?;
+        let (hi, rest) = match rest {
+            ['-', rest @ ..] => one(rest)?,
+            _ => (lo, rest),
+        };
+        matched |= lo <= c && c <= hi;
+        ranges += 1;
+        glob = rest;
+    }
+}
+
+/// The JSON type name of `value`, as the Go template reports it.
+fn json_kind(value: &Value) -> &'static str {
+    match value {
+        Value::Null => "null",
+        Value::Bool(_) => "boolean",
+        Value::Number(_) => "number",
+        Value::String(_) => "string",
+        Value::Array(_) => "array",
+        Value::Object(_) => "object",
+    }
+}
+
+/// Count the words, lines, and characters of `text`, the words as
+/// `tokenizer` or `word_regex` and the characters as `count_mode` says.
+fn tally(text: &str, args: &Args) -> CountResult {
+    CountResult {
+        words: split_words(text, args).len(),
+        lines: if text.is_empty() {
+            0
+        } else {
+            text.matches('\n').count() + 1
+        },
+        characters: characters(text, &args.count_mode),
+        bytes: 0,
+        encoding: None,
+        invalid_bytes: 0,
+        warning: None,
+        fields: Vec::new(),
+        heaviest_field: None,
+        files: Vec::new(),
+        length_histogram: Vec::new(),
+        unique_words: 0,
+        top_words: Vec::new(),
+        longest_word: String::new(),
+        average_word_length: 0.0,
+        estimated_tokens: 0,
+        explain: None,
+    }
+}
+
+/// Split `text` into words as `word_regex` or `tokenizer` says (see the Go
+/// template's `splitWords`).
+fn split_words<'a>(text: &'a str, args: &Args) -> Vec<&'a str> {
+    if let Some(re) = &args.word_re {
+        return re
+            .find_iter(text)
+            .map(|m| m.as_str())
+            .filter(|word| !word.is_empty())
+            .collect();
+    }
+    match args.tokenizer.as_str() {
+        "smart" => smart_words(text),
+        "uax29" => segment_words(text),
+        _ => text.split_whitespace().collect(),
+    }
+}
+
+/// Split `text` at anything that is not a letter or digit, keeping the
+/// joiners [`joins`] allows inside a word.
+fn smart_words(text: &str) -> Vec<&str> {
+    let chars: Vec<(usize, char)> = text.char_indices().collect();
+    let mut words = Vec::new();
+    // Where the word being read begins, or None between words.
+    let mut start = None;
+    for (i, &(at, c)) in chars.iter().enumerate() {
+        let in_word = word_char(c)
+            || start.is_some()
+                && (extends(c)
+                    || chars
+                        .get(i + 1)
+                        .is_some_and(|&(_, next)| joins(chars[i - 1].1, c, next)));
+        match (in_word, start) {
+            (true, None) => start = Some(at),
+            (false, Some(from)) => {
+                words.push(&text[from..at]);
+                start = None;
+            }
+            _ => {}
+        }
+    }
+    if let Some(from) = start {
+        words.push(&text[from..]);
+    }
+    words
+}
+
+/// Whether `c` is a letter or digit, as the Go template's `wordRune` sees it.
+fn word_char(c: char) -> bool {
+    c.is_alphabetic() || c.is_numeric()
+}
+
+/// Whether `c`, inside a word after `prev`, keeps the word going on to
+/// `next` for the smart tokenizer.
+fn joins(prev: char, c: char, next: char) -> bool {
+    match c {
+        '\'' | '’' | '-' | '‐' => word_char(next),
+        '.' | ',' => prev.is_numeric() && next.is_numeric(),
+        _ => false,
+    }
+}
+
+/// A character's Unicode `Word_Break` property, as far as the uax29
+/// tokenizer tells them apart (see the Go template's `wordBreak`).
+#[derive(Clone, Copy, PartialEq, Eq)]
+enum WordBreak {
+    Other,
+    Letter,
+    Numeric,
+    Katakana,
+    /// Han and Hiragana, which UAX #29 leaves a word each.
+    Ideograph,
+    ExtendNumLet,
+    MidLetter,
+    MidNum,
+    /// A `MidLetter` and a `MidNum` both.
+    MidNumLet,
+    Extend,
+}
+
+impl WordBreak {
+    /// Approximate the `Word_Break` of `c` without Unicode tables, as the Go
+    /// template's `wordBreakOf` does.
+    fn of(c: char) -> Self {
+        match c {
+            _ if extends(c) || c == '\u{200d}' => Self::Extend,
+            '\u{30a0}'..='\u{30ff}' | '\u{31f0}'..='\u{31ff}' | '\u{ff66}'..='\u{ff9f}' => {
+                Self::Katakana
+            }
+            '\u{3040}'..='\u{309f}'
+            | '\u{3400}'..='\u{4dbf}'
+            | '\u{4e00}'..='\u{9fff}'
+            | '\u{f900}'..='\u{faff}'
+            | '\u{20000}'..='\u{3ffff}' => Self::Ideograph,
+            _ if c.is_numeric() => Self::Numeric,
+            _ if word_char(c) => Self::Letter,
+            '_' | '\u{202f}' | '\u{203f}' | '\u{2040}' | '\u{2054}' | '\u{fe33}' | '\u{fe34}'
+            | '\u{fe4d}' | '\u{fe4e}' | '\u{fe4f}' | '\u{ff3f}' => Self::ExtendNumLet,
+            ':' | '\u{b7}' | '\u{387}' | '\u{5f4}' | '\u{2027}' | '\u{fe13}' | '\u{fe55}'
+            | '\u{ff1a}' => Self::MidLetter,
+            ',' | ';' | '\u{37e}' | '\u{589}' | '\u{60c}' | '\u{60d}' | '\u{66c}' | '\u{7f8}'
+            | '\u{2044}' | '\u{fe10}' | '\u{fe14}' | '\u{fe50}' | '\u{fe54}' | '\u{ff0c}'
+            | '\u{ff1b}' => Self::MidNum,
+            '.' | '\'' | '\u{2018}' | '\u{2019}' | '\u{2024}' | '\u{fe52}' | '\u{ff07}'
+            | '\u{ff0e}' => Self::MidNumLet,
+            _ => Self::Other,
+        }
+    }
+
+    fn alnum(self) -> bool {
+        matches!(self, Self::Letter | Self::Numeric)
+    }
+
+    fn mid_letter(self) -> bool {
+        matches!(self, Self::MidLetter | Self::MidNumLet)
+    }
+
+    fn mid_num(self) -> bool {
+        matches!(self, Self::MidNum | Self::MidNumLet)
+    }
+}
+
+/// Split `text` at its Unicode word boundaries, keeping the segments with a
+/// letter, digit, or ideograph in them (see the Go template's
+/// `segmentWords`).
+fn segment_words(text: &str) -> Vec<&str> {
+    // WB4: a mark or joiner belongs to the character before it.
+    let mut units: Vec<(usize, WordBreak)> = Vec::new();
+    for (at, c) in text.char_indices() {
+        match WordBreak::of(c) {
+            WordBreak::Extend if !units.is_empty() => {}
+            WordBreak::Extend => units.push((at, WordBreak::Other)),
+            wb => units.push((at, wb)),
+        }
+    }
+    let class = |i: usize| units.get(i).map_or(WordBreak::Other, |&(_, wb)| wb);
+    // Whether no boundary falls before unit `i`.
+    let joined = |i: usize| {
+        let (a, b) = (class(i - 1), class(i));
+        let before = i.checked_sub(2).map_or(WordBreak::Other, class);
+        use WordBreak::*;
+        (a.alnum() && b.alnum()) // WB5, WB8 to WB10
+            || (a == Letter && b.mid_letter() && class(i + 1) == Letter) // WB6
+            || (a.mid_letter() && before == Letter && b == Letter) // WB7
+            || (a == Numeric && b.mid_num() && class(i + 1) == Numeric) // WB12
+            || (a.mid_num() && before == Numeric && b == Numeric) // WB11
+            || (a == Katakana && b == Katakana) // WB13
+            || ((a.alnum() || a == Katakana || a == ExtendNumLet) && b == ExtendNumLet) // WB13a
+            || (a == ExtendNumLet && (b.alnum() || b == Katakana)) // WB13b
+    };
+    let mut words = Vec::new();
+    let (mut start, mut word_like) = (0, false);
+    for (i, &(_, wb)) in units.iter().enumerate() {
+        if i > 0 && !joined(i) {
+            if word_like {
+                words.push(&text[units[start].0..units[i].0]);
+            }
+            (start, word_like) = (i, false);
+        }
+        word_like |= matches!(
+            wb,
+            WordBreak::Letter | WordBreak::Numeric | WordBreak::Katakana | WordBreak::Ideograph
+        );
+    }
+    if word_like {
+        words.push(&text[units[start].0..]);
+    }
+    words
+}
+
+/// Count the characters of `text` as `count_mode` says.
+fn characters(text: &str, count_mode: &str) -> usize {
+    match count_mode {
+        "bytes" => text.len(),
+        "graphemes" => graphemes(text),
+        _ => text.chars().count(),
+    }
+}
+
+/// Bucket `words` by their length in characters, shortest first.
+fn histogram<'a>(words: impl Iterator<Item = &'a str>, count_mode: &str) -> Vec<LengthBucket> {
+    let mut counts = BTreeMap::new();
+    for word in words {
+        *counts.entry(characters(word, count_mode)).or_insert(0) += 1;
+    }
+    counts
+        .into_iter()
+        .map(|(length, count)| LengthBucket { length, count })
+        .collect()
+}
+
+/// Count `words` in their canonical form, returning how many distinct words
+/// there are and the `top_words` most frequent.
+fn frequencies<'a>(words: impl Iterator<Item = &'a str>, args: &Args) -> (usize, Vec<WordFreq>) {
+    let mut counts = BTreeMap::new();
+    for word in words {
+        *counts.entry(canonical(word, args)).or_insert(0) += 1;
+    }
+    let unique = counts.len();
+    // A stable sort of the byte-ordered map keeps ties in byte order.
+    let mut top: Vec<WordFreq> = counts
+        .into_iter()
+        .map(|(word, count)| WordFreq { word, count })
+        .collect();
+    top.sort_by(|a, b| b.count.cmp(&a.count));
+    top.truncate(usize::try_from(args.top_words).unwrap_or(usize::MAX));
+    (unique, top)
+}
+
+/// The longest of `words`, the first in byte order on a tie, and their mean
+/// length rounded to two decimals, both in `count_mode` units.
+fn word_stats<'a>(words: impl Iterator<Item = &'a str>, count_mode: &str) -> (String, f64) {
+    let (mut longest, mut most, mut total, mut n) = ("", 0, 0, 0);
+    for word in words {
+        let length = characters(word, count_mode);
+        total += length;
+        n += 1;
+        if length > most || length == most && word < longest {
+            (longest, most) = (word, length);
+        }
+    }
+    if n == 0 {
+        return (String::new(), 0.0);
+    }
+    let average = (total as f64 * 100.0 / n as f64).round() / 100.0;
+    (longest.to_string(), average)
+}
+
+/// How `args` turned text into `words`, listing the first of them; `bom`
+/// says some text counted started with a byte order mark.
+fn explain<'a>(words: impl Iterator<Item = &'a str>, args: &Args, bom: bool) -> Explain {
+    let trim = if args.trim.is_empty() {
+        "none"
+    } else {
+        &args.trim
+    };
+    let count_mode = if args.count_mode.is_empty() {
+        "runes"
+    } else {
+        &args.count_mode
+    };
+    let tokenizer = if !args.word_regex.is_empty() {
+        "regex"
+    } else if args.tokenizer.is_empty() {
+        "whitespace"
+    } else {
+        &args.tokenizer
+    };
+    Explain {
+        tokenizer: tokenizer.to_string(),
+        word_regex: args.word_regex.clone(),
+        tokenizer_rule: tokenizer_rule(tokenizer),
+        strip: if bom { "bom" } else { "none" },
+        trim: trim.to_string(),
+        count_mode: count_mode.to_string(),
+        tokens: words.take(EXPLAIN_TOKENS).map(str::to_string).collect(),
+    }
+}
+
+/// The "gpt-bpe-approx" estimate of the tokens in `text`: each
+/// whitespace-separated word costs a token per [`CHARS_PER_TOKEN`]
+/// characters, rounded half up, and at least one.
+fn estimate_tokens(text: &str) -> usize {
+    text.split_whitespace()
+        .map(|word| ((word.chars().count() + CHARS_PER_TOKEN / 2) / CHARS_PER_TOKEN).max(1))
+        .sum()
+}
+
+/// What `tokens` adds to the summary, saying the count is an estimate.
+fn tokens_note(tokens: usize) -> String {
+    format!(
+        "; ~{tokens} {} (gpt-bpe-approx estimate, not an exact tokenizer count)",
+        plural("en", tokens, &["token", "tokens"])
+    )
+}
+
+/// `word` as `normalize_unicode` and `fold_case` say `top_words` compares it.
+/// Each character is lowercased on its own, like Go's `strings.ToLower`, and
+/// U+0130 becomes a plain "i" as it does there.
+fn canonical(word: &str, args: &Args) -> String {
+    let word: String = match args.normalize_unicode.as_str() {
+        "nfc" => word.nfc().collect(),
+        "nfkc" => word.nfkc().collect(),
+        _ => word.to_string(),
+    };
+    if args.fold_case == Some(false) {
+        return word;
+    }
+    word.chars()
+        .flat_map(|c| match c {
+            '\u{130}' => 'i'.to_lowercase(),
+            c => c.to_lowercase(),
+        })
+        .collect()
+}
+
+/// Approximate the user-perceived characters in `text` exactly as the Go
+/// template's `graphemes` does.
+fn graphemes(text: &str) -> usize {
+    let mut n = 0;
+    let mut prev = '\0';
+    let mut flag = false; // prev is the first regional indicator of a pair
+    for (i, c) in text.char_indices() {
+        let regional = ('\u{1f1e6}'..='\u{1f1ff}').contains(&c);
+        let joins = i > 0
+            && (extends(c)
+                || c == '\u{200d}'
+                || prev == '\u{200d}'
+                || (prev == '\r' && c == '\n')
+                || (regional && flag));
+        flag = regional && !flag;
+        if !joins {
+            n += 1;
+        }
+        prev = c;
+    }
+    n
+}
+
+/// Whether `c` attaches to the character before it: a combining mark,
+/// variation selector, or skin-tone modifier.
+fn extends(c: char) -> bool {
+    matches!(c,
+        '\u{0300}'..='\u{036f}'
+        | '\u{1ab0}'..='\u{1aff}'
+        | '\u{1dc0}'..='\u{1dff}'
+        | '\u{20d0}'..='\u{20ff}'
+        | '\u{fe20}'..='\u{fe2f}'
+        | '\u{fe00}'..='\u{fe0f}'
+        | '\u{1f3fb}'..='\u{1f3ff}')
+}
+
+/// Whether `text` or any entry of `fields` starts with a BOM.
+fn has_bom(args: &Args) -> bool {
+    let bom = |text: &str| text.starts_with('\u{feff}');
+    let mut fields = args.fields.iter().flat_map(BTreeMap::values);
+    bom(&args.text) || fields.any(|v| v.as_str().is_some_and(bom))
+}
+
+/// Whether `bytes`, a file's contents, start with a UTF-8 or UTF-16 byte
+/// order mark, which [`inspect_text`] strips.
+fn starts_with_bom(bytes: &[u8]) -> bool {
+    bytes.starts_with("\u{feff}".as_bytes())
+        || bytes.starts_with(&[0xff, 0xfe])
+        || bytes.starts_with(&[0xfe, 0xff])
+}
+
+/// Drop a leading BOM, as the Go SDK's CleanText does for text fields.
+fn strip_bom(text: &str) -> &str {
+    text.strip_prefix('\u{feff}').unwrap_or(text)
+}
+
+/// The normalization for an `Args.trim` mode.
+fn trimmer(mode: &str) -> Result<fn(&str) -> String, String> {
+    match mode {
+        "" | "none" => Ok(str::to_string),
+        "edges" => Ok(|s| s.trim().to_string()),
+        "collapse" => Ok(|s| s.split_whitespace().collect::<Vec<_>>().join(" ")),
+        _ => Err(format!(
+            "invalid trim {mode:?}: want none, edges, or collapse"
+        )),
+    }
+}
+
+/// File contents decoded by [`inspect_text`].
+struct Decoded {
+    text: String,
+    /// The encoding a byte order mark declared, or "utf-8" without one.
+    encoding: &'static str,
+    /// Bytes not valid in `encoding`; each run became one U+FFFD.
+    invalid_bytes: usize,
+}
+
+/// Decode a file as the encoding its byte order mark declares (UTF-8,
+/// UTF-16LE or UTF-16BE), counting invalid bytes, as the Go SDK's
+/// `skill.InspectText` does. With ZEROCLAW_STRICT_UTF8=1, invalid bytes are
+/// an error carrying the offset of the first.
+fn inspect_text(bytes: &[u8]) -> Result<Decoded, usize> {
+    let (decoded, at) = match bytes {
+        [0xff, 0xfe, rest @ ..] => decode_utf16(rest, "utf-16le", u16::from_le_bytes),
+        [0xfe, 0xff, rest @ ..] => decode_utf16(rest, "utf-16be", u16::from_be_bytes),
+        _ => {
+            let bytes = bytes.strip_prefix("\u{feff}".as_bytes()).unwrap_or(bytes);
+            let (text, invalid_bytes, at) = repair_utf8(bytes);
+            let decoded = Decoded {
+                text,
+                encoding: "utf-8",
+                invalid_bytes,
+            };
+            (decoded, at)
+        }
+    };
+    match at {
+        Some(at) if std::env::var("ZEROCLAW_STRICT_UTF8").as_deref() == Ok("1") => Err(at),
+        _ => Ok(decoded),
+    }
+}
+
+/// Replace each run of invalid UTF-8 with one U+FFFD, returning the text, the
+/// number of invalid bytes, and the offset of the first.
+fn repair_utf8(mut bytes: &[u8]) -> (String, usize, Option<usize>) {
+    let (mut text, mut invalid, mut first, mut offset) = (String::new(), 0, None, 0);
+    let mut in_run = false;
+    loop {
+        match std::str::from_utf8(bytes) {
+            Ok(valid) => {
+                text.push_str(valid);
+                return (text, invalid, first);
+            }
+            Err(e) => {
+                let (valid, rest) = bytes.split_at(e.valid_up_to());
+                if !valid.is_empty() {
+                    text.push_str(std::str::from_utf8(valid).unwrap_or_default());
+                    in_run = false;
+                }
+                if !in_run {
+                    text.push(char::REPLACEMENT_CHARACTER);
+                    in_run = true;
+                }
+                let bad = e.error_len().unwrap_or(rest.len());
+                first.get_or_insert(offset + valid.len());
+                invalid += bad;
+                offset += valid.len() + bad;
+                bytes = &rest[bad..];
+            }
+        }
+    }
+}
+
+/// Decode UTF-16 code units after a BOM. Unpaired surrogates and a trailing
+/// odd byte are invalid; each run of them becomes one U+FFFD. The offset of
+/// the first counts the two BOM bytes.
+fn decode_utf16(
+    bytes: &[u8],
+    encoding: &'static str,
+    unit: fn([u8; 2]) -> u16,
+) -> (Decoded, Option<usize>) {
+    let units: Vec<u16> = bytes.chunks_exact(2).map(|b| unit([b[0], b[1]])).collect();
+    let (mut text, mut invalid, mut first) = (String::new(), 0, None);
+    let (mut in_run, mut at) = (false, 2);
+    for c in char::decode_utf16(units) {
+        match c {
+            Ok(c) => {
+                text.push(c);
+                in_run = false;
+                at += c.len_utf16() * 2;
+            }
+            Err(_) => {
+                first.get_or_insert(at);
+                if !in_run {
+                    text.push(char::REPLACEMENT_CHARACTER);
+                    in_run = true;
+                }
+                invalid += 2;
+                at += 2;
+            }
+        }
+    }
+    if bytes.len() % 2 == 1 {
+        first.get_or_insert(at);
+        if !in_run {
+            text.push(char::REPLACEMENT_CHARACTER);
+        }
+        invalid += 1;
+    }
+    let decoded = Decoded {
+        text,
+        encoding,
+        invalid_bytes: invalid,
+    };
+    (decoded, first)
+}
+
+/// Resolve `path` against the directories the host preopened
+/// (ZEROCLAW_PREOPENS, `:`-separated) and refuse anything outside them.
+fn check_path(path: &str) -> Result<String, String> {
+    let preopens = std::env::var("ZEROCLAW_PREOPENS").unwrap_or_default();
+    let dirs: Vec<&str> = preopens.split(':').filter(|d| !d.is_empty()).collect();
+    let Some(first) = dirs.first() else {
+        return Err("path is outside the preopened directories: no directories were preopened (ZEROCLAW_PREOPENS is empty)".to_string());
+    };
+    let joined = if path.starts_with('/') {
+        path.to_string()
+    } else {
+        format!("{first}/{path}")
+    };
+    let cleaned = clean(&joined);
+    for dir in &dirs {
+        let dir = clean(dir);
+        if cleaned == dir || dir == "/" || cleaned.starts_with(&format!("{dir}/")) {
+            return Ok(cleaned);
+        }
+    }
+    Err(format!(
+        "path is outside the preopened directories: {cleaned} (granted: {})",
+        dirs.join(", ")
+    ))
+}
+
+/// Lexically clean an absolute slash path, resolving `.` and `..`.
+fn clean(path: &str) -> String {
+    let mut parts: Vec<&str> = Vec::new();
+    for part in path.split('/') {
+        match part {
+            "" | "." => {}
+            ".." => {
+                parts.pop();
+            }
+            part => parts.push(part),
+        }
+    }
+    format!("/{}", parts.join("/"))
+}
+
+/// Plural forms per language, in CLDR order (one, few, many / one, other).
+fn units(lang: &str) -> Option<[&'static [&'static str]; 3]> {
+    match lang {
+        "en" => Some([
+            &["word", "words"],
+            &["line", "lines"],
+            &["character", "characters"],
+        ]),
+        "fr" => Some([
+            &["mot", "mots"],
+            &["ligne", "lignes"],
+            &["caractère", "caractères"],
+        ]),
+        "pl" => Some([
+            &["słowo", "słowa", "słów"],
+            &["wiersz", "wiersze", "wierszy"],
+            &["znak", "znaki", "znaków"],
+        ]),
+        "ru" => Some([
+            &["слово", "слова", "слов"],
+            &["строка", "строки", "строк"],
+            &["символ", "символа", "символов"],
+        ]),
+        _ => None,
+    }
+}
+
+/// The index of the plural form to use for `n`.
+fn plural_index(lang: &str, n: usize) -> usize {
+    let few = (2..=4).contains(&(n % 10)) && !(12..=14).contains(&(n % 100));
+    match lang {
+        "fr" if n <= 1 => 0,
+        "fr" => 1,
+        "pl" if n == 1 => 0,
+        "pl" if few => 1,
+        "pl" => 2,
+        "ru" if n % 10 == 1 && n % 100 != 11 => 0,
+        "ru" if few => 1,
+        "ru" => 2,
+        _ if n == 1 => 0,
+        _ => 1,
+    }
+}
+
+fn plural(lang: &str, n: usize, forms: &[&'static str]) -> &'static str {
+    forms[plural_index(lang, n).min(forms.len() - 1)]
+}
+
+/// Reduce a locale such as "pl_PL.UTF-8" or "ru-RU" to its language subtag.
+fn language(locale: &str) -> String {
+    let lang = locale.split('.').next().unwrap_or_default();
+    let lang = lang.split(['-', '_']).next().unwrap_or_default();
+    lang.to_lowercase()
+}
+
+/// Render counts as "2 words, 1 line, 11 characters" in the requested locale.
+/// For languages without translations it renders English, with a warning
+/// saying so.
+fn summary(counts: &CountResult, locale: &str) -> (String, Option<String>) {
+    let locale = if locale.is_empty() {
+        std::env::var("ZEROCLAW_LOCALE").unwrap_or_else(|_| "en".into())
+    } else {
+        locale.to_string()
+    };
+    let lang = language(&locale);
+    let (lang, [words, lines, characters], warning) = match units(&lang) {
+        Some(names) => (lang, names, None),
+        None => (
+            "en".to_string(),
+            units("en").expect("English is always defined"),
+            Some(format!(
+                "no {lang:?} translation of word_count.summary; using en"
+            )),
+        ),
+    };
+    let output = format!(
+        "{} {}, {} {}, {} {}",
+        counts.words,
+        plural(&lang, counts.words, words),
+        counts.lines,
+        plural(&lang, counts.lines, lines),
+        counts.characters,
+        plural(&lang, counts.characters, characters),
+    );
+    (output, warning)
+}
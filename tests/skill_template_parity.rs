@@ -0,0 +1,1078 @@
+//! The Rust, JavaScript, and Go `word_count` templates must speak the same
+//! skill protocol, byte for byte, so hosts cannot tell which language produced
+//! a module.
+//!
+//! The templates are run on the host rather than as `wasm32-wasip1` modules:
+//! the protocol is plain stdin/stdout plus environment variables, so a native
+//! build exercises the same code paths without needing wasmtime. The
+//! JavaScript template runs under `node` with a stand-in for Javy's `Javy.IO`.
+//! Each test is skipped when its toolchain (`go`, `cargo`, `node`) is missing
+//! or the template's crates cannot be fetched.
+
+use std::io::Write;
+use std::path::{Path, PathBuf};
+use std::process::{Command, Stdio};
+
+fn templates_dir() -> PathBuf {
+    Path::new(env!("CARGO_MANIFEST_DIR")).join("templates")
+}
+
+fn build_go(out_dir: &Path) -> Option<PathBuf> {
+    let bin = out_dir.join("word_count_go");
+    let status = Command::new("go")
+        .args(["build", "-o"])
+        .arg(&bin)
+        .arg(".")
+        .current_dir(templates_dir().join("go/word_count"))
+        .status()
+        .ok()?;
+    status.success().then_some(bin)
+}
+
+fn build_rust(out_dir: &Path) -> Option<PathBuf> {
+    let src = templates_dir().join("rust/word_count");
+    let crate_dir = out_dir.join("word_count_rs");
+    std::fs::create_dir_all(crate_dir.join("src")).ok()?;
+    // Substitute only the crate names, as `zeroclaw skill new` would; main.rs
+    // keeps its placeholder so probe output matches the unscaffolded Go template.
+    let cargo_toml = std::fs::read_to_string(src.join("Cargo.toml"))
+        .ok()?
+        .replace("__SKILL_NAME__", "word_count_rs")
+        .replace("__BIN_NAME__", "word_count_rs");
+    std::fs::write(crate_dir.join("Cargo.toml"), cargo_toml).ok()?;
+    std::fs::copy(src.join("src/main.rs"), crate_dir.join("src/main.rs")).ok()?;
+
+    let status = Command::new(env!("CARGO"))
+        .args(["build", "--quiet"])
+        .current_dir(&crate_dir)
+        .env("CARGO_TARGET_DIR", out_dir.join("target"))
+        .status()
+        .ok()?;
+    status
+        .success()
+        .then(|| out_dir.join("target/debug/word_count_rs"))
+}
+
+/// A `node` command that runs the JavaScript template with `Javy.IO` mapped
+/// onto the process's stdin and stdout.
+fn js_runner(out_dir: &Path) -> Option<(PathBuf, Vec<String>)> {
+    let node = PathBuf::from("node");
+    let version = Command::new(&node).arg("--version").output().ok()?;
+    if !version.status.success() {
+        return None;
+    }
+    let shim = out_dir.join("javy_io.js");
+    std::fs::write(
+        &shim,
+        r#"const fs = require("fs");
+globalThis.Javy = {
+  IO: {
+    readSync(fd, buf) {
+      try {
+        return fs.readSync(fd, buf);
+      } catch (e) {
+        if (e.code === "EOF") return 0;
+        throw e;
+      }
+    },
+    writeSync: (fd, buf) => fs.writeSync(fd, buf),
+  },
+};
+"#,
+    )
+    .ok()?;
+    let script = templates_dir().join("js/word_count/src/index.js");
+    Some((
+        node,
+        vec![
+            "--require".to_string(),
+            shim.to_str()?.to_string(),
+            script.to_str()?.to_string(),
+        ],
+    ))
+}
+
+fn run(bin: &Path, args: &[&str], env: &[(&str, &str)], stdin: &[u8]) -> String {
+    run_with(bin, &[], args, env, stdin)
+}
+
+fn run_with(
+    bin: &Path,
+    leading: &[String],
+    args: &[&str],
+    env: &[(&str, &str)],
+    stdin: &[u8],
+) -> String {
+    let mut child = Command::new(bin)
+        .args(leading)
+        .args(args)
+        .env_remove("ZEROCLAW_LOCALE")
+        .envs(env.iter().copied())
+        .stdin(Stdio::piped())
+        .stdout(Stdio::piped())
+        .spawn()
+        .unwrap();
+    child.stdin.take().unwrap().write_all(stdin).unwrap();
+    let output = child.wait_with_output().unwrap();
+    String::from_utf8(output.stdout).unwrap()
+}
+
+/// The parts of a failed result that are part of the contract; decoder error
+/// messages are language-specific and left out.
+fn failure_shape(stdout: &str) -> (bool, String) {
+    let result: serde_json::Value = serde_json::from_str(stdout).unwrap();
+    (
+        result["success"].as_bool().unwrap(),
+        result["error_code"]
+            .as_str()
+            .unwrap_or_default()
+            .to_string(),
+    )
+}
+
+#[test]
+fn rust_and_go_word_count_templates_agree() {
+    let out_dir = tempfile::tempdir().unwrap();
+    let (Some(go), Some(rust)) = (build_go(out_dir.path()), build_rust(out_dir.path())) else {
+        eprintln!("skipping: could not build both word_count templates (go/cargo unavailable?)");
+        return;
+    };
+
+    let data = out_dir.path().join("data");
+    std::fs::create_dir_all(&data).unwrap();
+    std::fs::write(data.join("notes.txt"), "\u{feff}hello there\nsecond line").unwrap();
+    std::fs::write(data.join("latin1.txt"), b"caf\xe9 \xff\xfe ok \xe2\x82").unwrap();
+    std::fs::write(data.join("utf16.txt"), b"\xff\xfeh\x00i\x00 \x00\x00\xd8x").unwrap();
+    // A tree for `dir`, with one entry that cannot be read.
+    let docs = data.join("docs");
+    std::fs::create_dir_all(docs.join("sub")).unwrap();
+    std::fs::write(docs.join("a.txt"), "one two\n").unwrap();
+    std::fs::write(docs.join("b.md"), "\u{feff}three").unwrap();
+    std::fs::write(docs.join("sub/c.txt"), b"four caf\xe9 six").unwrap();
+    std::os::unix::fs::symlink("missing.txt", docs.join("gone.txt")).unwrap();
+    let preopens = data.to_str().unwrap();
+
+    let cases: &[(&[&str], &[(&str, &str)], &[u8])] = &[
+        (&[], &[], br#"{"text":"hello world"}"#),
+        (&[], &[], br#"{"text":"a b c d e","locale":"pl"}"#),
+        (
+            &[],
+            &[],
+            br#"{"text":"a b c d e f g h i j k l m n o p q r s t u","locale":"ru-RU"}"#,
+        ),
+        (
+            &[],
+            &[("ZEROCLAW_LOCALE", "pl_PL.UTF-8")],
+            br#"{"text":"x"}"#,
+        ),
+        (&[], &[], br#"{"text":"x","locale":"de"}"#),
+        (&[], &[], br#"{"text":"","locale":"fr"}"#),
+        (&[], &[], br#"{"text":"un deux\ntrois","locale":"fr-CA"}"#),
+        (&[], &[], b"{}"),
+        (
+            &[],
+            &[],
+            "\u{feff}{\"text\":\"\u{feff}hi  there\"}".as_bytes(),
+        ),
+        (&[], &[], b"{\"text\":\"bad \xffx\"}"),
+        (
+            &[],
+            &[("ZEROCLAW_STRICT_UTF8", "1")],
+            b"{\"text\":\"bad \xff\"}",
+        ),
+        (
+            &[],
+            &[("ZEROCLAW_PREOPENS", preopens)],
+            br#"{"path":"notes.txt"}"#,
+        ),
+        (
+            &[],
+            &[("ZEROCLAW_PREOPENS", preopens)],
+            br#"{"path":"latin1.txt"}"#,
+        ),
+        (
+            &[],
+            &[("ZEROCLAW_PREOPENS", preopens)],
+            br#"{"path":"utf16.txt"}"#,
+        ),
+        (
+            &[],
+            &[
+                ("ZEROCLAW_PREOPENS", preopens),
+                ("ZEROCLAW_STRICT_UTF8", "1"),
+            ],
+            br#"{"path":"utf16.txt"}"#,
+        ),
+        (
+            &[],
+            &[("ZEROCLAW_PREOPENS", preopens)],
+            br#"{"path":"../../etc/passwd"}"#,
+        ),
+        (&[], &[], br#"{"path":"/etc/passwd"}"#),
+        (&[], &[], br#"{"text":"  a  b \n\n c  \n","trim":"none"}"#),
+        (&[], &[], br#"{"text":"  a  b \n\n c  \n","trim":"edges"}"#),
+        (
+            &[],
+            &[],
+            br#"{"text":"  a  b \n\n c  \n","trim":"collapse"}"#,
+        ),
+        (
+            &[],
+            &[("ZEROCLAW_PREOPENS", preopens)],
+            br#"{"path":"notes.txt","trim":"collapse"}"#,
+        ),
+        (&[], &[], br#"{"text":"x","trim":"all"}"#),
+        (
+            &[],
+            &[],
+            br#"{"text":"e\u0301 \ud83d\udc68\u200d\ud83d\udc67 \ud83c\uddfb\ud83c\uddf3\r\n","count_mode":"graphemes"}"#,
+        ),
+        (&[], &[], br#"{"text":"h\u00e9llo","count_mode":"bytes"}"#),
+        (&[], &[], br#"{"text":"x","count_mode":"words","trim":"all"}"#),
+        (
+            &[],
+            &[],
+            "{\"fields\":{\"title\":\"\u{feff}Hello world\",\"body\":\"one\\ntwo three\\n\"},\"trim\":\"edges\"}".as_bytes(),
+        ),
+        (&[], &[], br#"{"fields":{},"locale":"pl"}"#),
+        (&[], &[], br#"{"fields":{"a":"x"},"text":"y"}"#),
+        (
+            &[],
+            &[],
+            br#"{"fields":{"title":"Hi there","body":7,"a/b":null}}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"fields":{"title":"Hi there","body":7},"fail_fast":true}"#,
+        ),
+        (&[], &[], br#"{"fields":{"a":1,"b":[]}}"#),
+        (
+            &[],
+            &[],
+            br#"{"fields":{"b":"xy","a":"\u00e9","c":"z","d":1},"count_mode":"graphemes"}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"text":"the quick brown fox jumps over a lazy dog","length_histogram":true}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"text":"h\u00e9llo \ud83d\udc4b\ud83c\udffd e\u0301","count_mode":"graphemes","length_histogram":true}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"fields":{"a":"one two","b":"three","c":5},"count_mode":"bytes","length_histogram":true}"#,
+        ),
+        (&[], &[], br#"{"text":"  ","length_histogram":true}"#),
+        (&[], &[], br#"{"text":"   \n "}"#),
+        (&[], &[], br#"{"text":"\n","explain":true}"#),
+        (
+            &[],
+            &[],
+            br#"{"text":"Word word WORD cafe\u0301 caf\u00e9 \ufb01ne fine \u0130stanbul istanbul","top_words":3}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"text":"Word word WORD cafe\u0301 caf\u00e9 \ufb01ne fine \u0130stanbul istanbul","top_words":10,"fold_case":false,"normalize_unicode":"nfc"}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"text":"Word word WORD cafe\u0301 caf\u00e9 \ufb01ne fine \u0130stanbul istanbul","top_words":10,"normalize_unicode":"nfkc"}"#,
+        ),
+        (&[], &[], br#"{"fields":{"a":"the cat","b":"The end","c":5},"top_words":2}"#),
+        (&[], &[], br#"{"text":"","top_words":5}"#),
+        (&[], &[], br#"{"text":"x","top_words":-1}"#),
+        (&[], &[], br#"{"text":"x","trim":"all","top_words":-1}"#),
+        (
+            &[],
+            &[],
+            br#"{"text":"x","count_mode":"words","top_words":-1,"normalize_unicode":"nfd"}"#,
+        ),
+        (&[], &[], br#"{"text":"x","count_mode":"words","normalize_unicode":"nfd"}"#),
+        (
+            &[],
+            &[],
+            br#"{"text":"  one two  three ","trim":"edges","explain":true}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"text":"a b c d e f g h i j k l","explain":true}"#,
+        ),
+        (&[], &[], br#"{"text":"","explain":true}"#),
+        (
+            &[],
+            &[],
+            br#"{"fields":{"a":"x y","b":5,"c":"z"},"count_mode":"graphemes","explain":true}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"text":"hello,world don't rock\u2019n\u2019roll well-known 3.14 1,000 U.S.A. \u2014 -x- cafe\u0301 \ud83d\udc4b\ud83c\udffd \u0645\u0631\u062d\u0628\u0627!","tokenizer":"smart","top_words":20,"length_histogram":true,"explain":true}"#,
+        ),
+        (&[], &[], br#"{"text":"... \u2014 !?","tokenizer":"smart"}"#),
+        (&[], &[], br#"{"text":"hello,world","tokenizer":"whitespace","explain":true}"#),
+        (&[], &[], br#"{"fields":{"a":"it's","b":"x,y"},"tokenizer":"smart"}"#),
+        (&[], &[], br#"{"text":"x","tokenizer":"words","top_words":-1}"#),
+        (
+            &[],
+            &[],
+            br#"{"text":"e.g., 1,000 items","tokenizer":"uax29","explain":true}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"text":"hello,world don't well-known snake_case a:b 3.14. U.S.A. \u4f60\u597d \u30ab\u30bf\u30ab\u30ca cafe\u0301 \u2014 \ud83d\udc4b\ud83c\udffd _x_ \u0645\u0631\u062d\u0628\u0627!","tokenizer":"uax29","top_words":20,"explain":true}"#,
+        ),
+        (&[], &[], br#"{"fields":{"a":"e.g.","b":"... !?"},"tokenizer":"uax29"}"#),
+        (
+            &[],
+            &[],
+            br#"{"text":"e.g., 1,000 items","segmentation":"uax29","explain":true}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"text":"e.g., 1,000 items","segmentation":"whitespace","tokenizer":"whitespace","explain":true}"#,
+        ),
+        (&[], &[], br#"{"text":"x","segmentation":"smart"}"#),
+        (
+            &[],
+            &[],
+            br#"{"text":"x","segmentation":"uax29","tokenizer":"smart"}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"text":"x","segmentation":"uax29","word_regex":"x"}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"text":"The quick brown fox, \u041f\u0440\u0438\u0432\u0435\u0442!","tokens":"gpt-bpe-approx","explain":true}"#,
+        ),
+        (&[], &[], br#"{"text":" \n","tokens":"gpt-bpe-approx"}"#),
+        (
+            &[],
+            &[],
+            br#"{"fields":{"a":"internationalization","b":5,"c":"a b"},"tokenizer":"smart","tokens":"gpt-bpe-approx"}"#,
+        ),
+        (&[], &[], br#"{"text":"x","tokens":"tiktoken","top_words":-1}"#),
+        (&[], &[], br#"{"text":"The cat saw the CAT and a dog","stats":true}"#),
+        (
+            &[],
+            &[],
+            br#"{"text":"The cat saw the CAT","stats":true,"top_words":2,"fold_case":false}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"text":"The cat saw the CAT and a dog","stats":true,"top":3}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"text":"The cat saw the CAT","stats":true,"top":2,"case_sensitive":true}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"text":"a A","top_words":2,"top":2,"case_sensitive":false,"fold_case":true}"#,
+        ),
+        (&[], &[], br#"{"text":"x","top":2,"top_words":3}"#),
+        (&[], &[], br#"{"text":"x","case_sensitive":true,"fold_case":true}"#),
+        (&[], &[], br#"{"text":"x","top_words":-1,"top":-1}"#),
+        (&[], &[], br#"{"text":" ","stats":true}"#),
+        (
+            &[],
+            &[],
+            br#"{"fields":{"a":"caf\u00e9 au","b":"lait","c":5},"count_mode":"bytes","stats":true}"#,
+        ),
+        (
+            &[],
+            &[("ZEROCLAW_PREOPENS", preopens)],
+            br#"{"dir":"docs","stats":true}"#,
+        ),
+        (
+            &[],
+            &[("ZEROCLAW_PREOPENS", preopens)],
+            br#"{"dir":"docs","tokens":"gpt-bpe-approx"}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"text":"one two three four","offset":4,"length":9}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"text":"caf\u00e9 au lait","offset":2,"length":4,"count_mode":"bytes"}"#,
+        ),
+        (&[], &[], br#"{"text":"short","offset":9}"#),
+        (&[], &[], br#"{"text":"one two","offset":4,"length":50}"#),
+        (&[], &[], br#"{"text":"x","offset":-1,"length":-2}"#),
+        (&[], &[], br#"{"fields":{"a":"x"},"offset":1}"#),
+        (
+            &[],
+            &[("ZEROCLAW_PREOPENS", preopens)],
+            br#"{"path":"notes.txt","offset":2,"length":3}"#,
+        ),
+        (
+            &[],
+            &[("ZEROCLAW_PREOPENS", preopens)],
+            br#"{"fields":{"a":"x"},"path":"notes.txt"}"#,
+        ),
+        (
+            &[],
+            &[("ZEROCLAW_PREOPENS", preopens)],
+            br#"{"dir":"docs"}"#,
+        ),
+        (
+            &[],
+            &[("ZEROCLAW_PREOPENS", preopens)],
+            br#"{"dir":"docs","glob":"*.txt","top_words":2}"#,
+        ),
+        (
+            &[],
+            &[
+                ("ZEROCLAW_PREOPENS", preopens),
+                ("ZEROCLAW_STRICT_UTF8", "1"),
+            ],
+            br#"{"dir":"docs","glob":"[^b]*"}"#,
+        ),
+        (
+            &[],
+            &[("ZEROCLAW_PREOPENS", preopens)],
+            br#"{"dir":"docs","glob":"[a-"}"#,
+        ),
+        (
+            &[],
+            &[("ZEROCLAW_PREOPENS", preopens)],
+            br#"{"dir":"docs","text":"x"}"#,
+        ),
+        (
+            &[],
+            &[("ZEROCLAW_PREOPENS", preopens)],
+            br#"{"dir":"../.."}"#,
+        ),
+        (&[], &[], br#"{"glob":"*.txt"}"#),
+        (
+            &[],
+            &[],
+            br#"{"text":"don't stop-me now, 42!","word_regex":"[a-z]+","explain":true}"#,
+        ),
+        (
+            &[],
+            &[],
+            br#"{"text":"caf\u00e9 na\u00efve x1","word_regex":"\\p{L}+","top_words":5}"#,
+        ),
+        (&[], &[], br#"{"text":"\ufeffhello there","explain":true}"#),
+        (&[], &[], br#"{"fields":{"a":"\ufeffx","b":"y"},"explain":true}"#),
+        (
+            &[],
+            &[("ZEROCLAW_PREOPENS", preopens)],
+            br#"{"path":"notes.txt","explain":true}"#,
+        ),
+        (&[], &[], br#"{"__probe":true}"#),
+        (&["--schema"], &[], b""),
+        (&["--output-schema"], &[], b""),
+        (
+            &[],
+            &[("ZEROCLAW_JSONL", "1")],
+            b"{\"text\":\"a\"}\n\n{\"text\":\"b c\"}\n",
+        ),
+    ];
+    for (args, env, stdin) in cases {
+        let (go_out, rust_out) = (run(&go, args, env, stdin), run(&rust, args, env, stdin));
+        assert_eq!(
+            go_out,
+            rust_out,
+            "templates disagree for args {args:?}, env {env:?}, stdin {:?}",
+            String::from_utf8_lossy(stdin)
+        );
+    }
+
+    for invalid in [
+        &br#"{"text":"#[..],
+        br#"{"text":"x","top_words":9999999999999999999}"#,
+        br#"{"text":"x","word_regex":"("}"#,
+        br#"{"text":"x","word_regex":"a","tokenizer":"smart"}"#,
+    ] {
+        assert_eq!(
+            failure_shape(&run(&go, &[], &[], invalid)),
+            failure_shape(&run(&rust, &[], &[], invalid)),
+            "templates disagree for stdin {:?}",
+            String::from_utf8_lossy(invalid)
+        );
+        assert_eq!(
+            failure_shape(&run(&rust, &[], &[], invalid)),
+            (false, "invalid_input".to_string())
+        );
+    }
+
+    // The error text is the platform's, so only its shape is compared.
+    let env = [("ZEROCLAW_PREOPENS", preopens)];
+    let missing = br#"{"dir":"docs/missing"}"#;
+    assert_eq!(
+        failure_shape(&run(&go, &[], &env, missing)),
+        failure_shape(&run(&rust, &[], &env, missing)),
+    );
+    assert_eq!(
+        failure_shape(&run(&rust, &[], &env, missing)),
+        (false, "not_found".to_string())
+    );
+}
+
+/// Blank text counts as zero words but keeps its lines and characters; only
+/// text that is not empty gets the whitespace warning. The other templates
+/// match Go byte for byte, so pinning Go pins them all.
+#[test]
+fn go_word_count_template_counts_blank_text() {
+    let out_dir = tempfile::tempdir().unwrap();
+    let Some(go) = build_go(out_dir.path()) else {
+        eprintln!("skipping: could not build the Go word_count template (go unavailable?)");
+        return;
+    };
+    let warning = "input has no words, only whitespace";
+    let cases: [(&[u8], String, serde_json::Value); 3] = [
+        (
+            br#"{"text":""}"#,
+            "0 words, 0 lines, 0 characters".to_string(),
+            serde_json::json!({"words": 0, "lines": 0, "characters": 0}),
+        ),
+        (
+            br#"{"text":" "}"#,
+            format!("0 words, 1 line, 1 character; warning: {warning}"),
+            serde_json::json!({"words": 0, "lines": 1, "characters": 1, "warning": warning}),
+        ),
+        (
+            br#"{"text":"\n"}"#,
+            format!("0 words, 2 lines, 1 character; warning: {warning}"),
+            serde_json::json!({"words": 0, "lines": 2, "characters": 1, "warning": warning}),
+        ),
+    ];
+    for (stdin, output, data) in cases {
+        let result: serde_json::Value = serde_json::from_str(&run(&go, &[], &[], stdin)).unwrap();
+        assert_eq!(result["output"], output.as_str(), "stdin {stdin:?}");
+        assert_eq!(result["data"], data, "stdin {stdin:?}");
+    }
+}
+
+/// `"tokens": "gpt-bpe-approx"` charges each whitespace-separated word a
+/// token per four characters, rounded half up and at least one, and says in
+/// `output` that this is an estimate. Without it `estimated_tokens` is left
+/// out. As for blank text, pinning Go pins every template.
+#[test]
+fn go_word_count_estimates_tokens() {
+    let out_dir = tempfile::tempdir().unwrap();
+    let Some(go) = build_go(out_dir.path()) else {
+        eprintln!("skipping: could not build the Go word_count template (go unavailable?)");
+        return;
+    };
+    let cases: &[(&str, u64)] = &[
+        ("hello world", 2),
+        ("The quick brown fox jumps over the lazy dog", 9),
+        ("internationalization", 5),
+        ("supercalifragilisticexpialidocious", 9),
+        ("a, b; c!", 3),
+        ("don't stop", 2),
+        (
+            r"\u041f\u0440\u0438\u0432\u0435\u0442 \u043c\u0438\u0440",
+            3,
+        ),
+        (r"one\ntwo  three\t", 3),
+    ];
+    for (text, tokens) in cases {
+        let stdin = format!(r#"{{"text":"{text}","tokens":"gpt-bpe-approx"}}"#);
+        let result: serde_json::Value =
+            serde_json::from_str(&run(&go, &[], &[], stdin.as_bytes())).unwrap();
+        assert_eq!(result["data"]["estimated_tokens"], *tokens, "text {text:?}");
+        let note =
+            format!("; ~{tokens} tokens (gpt-bpe-approx estimate, not an exact tokenizer count)");
+        assert!(
+            result["output"].as_str().unwrap().ends_with(&note),
+            "text {text:?}: {}",
+            result["output"]
+        );
+    }
+
+    for stdin in [
+        &br#"{"text":"hello world"}"#[..],
+        br#"{"text":"hello world","tokens":""}"#,
+        br#"{"text":"","tokens":"gpt-bpe-approx"}"#,
+    ] {
+        let result: serde_json::Value = serde_json::from_str(&run(&go, &[], &[], stdin)).unwrap();
+        assert!(
+            result["data"].get("estimated_tokens").is_none(),
+            "stdin {:?}: {result}",
+            String::from_utf8_lossy(stdin)
+        );
+    }
+}
+
+/// `"stats": true` adds the longest word, the first in byte order on a tie,
+/// the average word length to two decimals, and, unless `top_words` says
+/// otherwise, the five most frequent words. As for blank text, pinning Go
+/// pins every template.
+#[test]
+fn go_word_count_reports_stats() {
+    let out_dir = tempfile::tempdir().unwrap();
+    let Some(go) = build_go(out_dir.path()) else {
+        eprintln!("skipping: could not build the Go word_count template (go unavailable?)");
+        return;
+    };
+    let stats = |stdin: &[u8]| -> serde_json::Value {
+        let result: serde_json::Value = serde_json::from_str(&run(&go, &[], &[], stdin)).unwrap();
+        result["data"].clone()
+    };
+    let freq = |pairs: &[(&str, u64)]| -> serde_json::Value {
+        pairs
+            .iter()
+            .map(|(word, count)| serde_json::json!({"word": word, "count": count}))
+            .collect()
+    };
+
+    let data = stats(br#"{"text":"The cat saw the CAT and a dog","stats":true}"#);
+    assert_eq!(data["longest_word"], "CAT", "{data}");
+    assert_eq!(data["average_word_length"], 2.75, "{data}");
+    assert_eq!(data["unique_words"], 6, "{data}");
+    assert_eq!(
+        data["top_words"],
+        freq(&[("cat", 2), ("the", 2), ("a", 1), ("and", 1), ("dog", 1)]),
+        "{data}"
+    );
+
+    let data =
+        stats(br#"{"text":"The cat saw the CAT","stats":true,"top_words":2,"fold_case":false}"#);
+    assert_eq!(data["top_words"], freq(&[("CAT", 1), ("The", 1)]), "{data}");
+    // "top" and "case_sensitive" are other names for the same settings.
+    let data = stats(br#"{"text":"The cat saw the CAT and a dog","stats":true,"top":2}"#);
+    assert_eq!(data["top_words"], freq(&[("cat", 2), ("the", 2)]), "{data}");
+    let data =
+        stats(br#"{"text":"The cat saw the CAT","stats":true,"top":2,"case_sensitive":true}"#);
+    assert_eq!(data["top_words"], freq(&[("CAT", 1), ("The", 1)]), "{data}");
+
+    let data = stats(br#"{"text":"one three","stats":true}"#);
+    assert_eq!(data["longest_word"], "three", "{data}");
+    assert_eq!(data["average_word_length"], 4, "{data}");
+
+    for stdin in [
+        &br#"{"text":"The cat saw the CAT"}"#[..],
+        br#"{"text":"","stats":true}"#,
+    ] {
+        let data = stats(stdin);
+        for field in ["longest_word", "average_word_length", "top_words"] {
+            assert!(
+                data.get(field).is_none(),
+                "stdin {:?}: {data}",
+                String::from_utf8_lossy(stdin)
+            );
+        }
+    }
+}
+
+/// `offset` and `length` count a window of the text, in runes or, with
+/// `count_mode` bytes, in bytes moved back to the start of a character. A
+/// window past the end counts nothing, and one that runs over is cut short;
+/// both say so in `warnings`.
+#[test]
+fn go_word_count_counts_a_window() {
+    let out_dir = tempfile::tempdir().unwrap();
+    let Some(go) = build_go(out_dir.path()) else {
+        eprintln!("skipping: could not build the Go word_count template (go unavailable?)");
+        return;
+    };
+    let cases: &[(&[u8], u64, u64, Option<&str>)] = &[
+        (
+            br#"{"text":"one two three four","offset":4,"length":9}"#,
+            2,
+            9,
+            None,
+        ),
+        (br#"{"text":"one two three four","offset":8}"#, 2, 10, None),
+        (
+            br#"{"text":"\u00e9t\u00e9 chaud","offset":1,"length":3}"#,
+            1,
+            3,
+            None,
+        ),
+        (
+            br#"{"text":"\u00e9t\u00e9 chaud","offset":1,"length":3,"count_mode":"bytes"}"#,
+            1,
+            3,
+            None,
+        ),
+        (
+            br#"{"text":"short","offset":9}"#,
+            0,
+            0,
+            Some("offset 9 is past the end of the text (5 runes); nothing counted"),
+        ),
+        (
+            br#"{"text":"one two","offset":4,"length":50,"count_mode":"bytes"}"#,
+            1,
+            3,
+            Some("length 50 runs past the end of the text; counted the 3 bytes from offset 4"),
+        ),
+    ];
+    for (stdin, words, characters, warning) in cases {
+        let result: serde_json::Value = serde_json::from_str(&run(&go, &[], &[], stdin)).unwrap();
+        let stdin = String::from_utf8_lossy(stdin);
+        assert_eq!(result["data"]["words"], *words, "stdin {stdin:?}: {result}");
+        assert_eq!(
+            result["data"]["characters"], *characters,
+            "stdin {stdin:?}: {result}"
+        );
+        assert_eq!(
+            result["warnings"][0].as_str(),
+            *warning,
+            "stdin {stdin:?}: {result}"
+        );
+    }
+
+    let result: serde_json::Value =
+        serde_json::from_str(&run(&go, &[], &[], br#"{"fields":{"a":"x"},"length":1}"#)).unwrap();
+    assert_eq!(result["error_code"], "invalid_input", "{result}");
+}
+
+/// Every field reports its UTF-8 bytes whatever `count_mode` says, and the
+/// heaviest is the first by name among those tied for the most.
+#[test]
+fn go_word_count_reports_field_bytes_and_the_heaviest() {
+    let out_dir = tempfile::tempdir().unwrap();
+    let Some(go) = build_go(out_dir.path()) else {
+        eprintln!("skipping: could not build the Go word_count template (go unavailable?)");
+        return;
+    };
+    let stdin = br#"{"fields":{"title":"Caf\u00e9 menu","body":"soup\nbread\n","tags":"x"}}"#;
+    let result: serde_json::Value = serde_json::from_str(&run(&go, &[], &[], stdin)).unwrap();
+    let data = &result["data"];
+    let bytes: Vec<_> = data["fields"]
+        .as_array()
+        .unwrap()
+        .iter()
+        .map(|f| (f["name"].as_str().unwrap(), f["bytes"].as_u64().unwrap()))
+        .collect();
+    assert_eq!(
+        bytes,
+        [("body", 11), ("tags", 1), ("title", 10)],
+        "{result}"
+    );
+    assert_eq!(data["bytes"], 22, "{result}");
+    assert_eq!(data["characters"], 21, "{result}");
+    assert_eq!(data["heaviest_field"], "body", "{result}");
+
+    let cases: &[(&[u8], Option<&str>)] = &[
+        (br#"{"fields":{"b":"xy","a":"\u00e9","c":"z"}}"#, Some("a")),
+        (br#"{"fields":{"b":"xy","a":"z","c":7}}"#, Some("b")),
+        (br#"{"fields":{"b":"","a":""}}"#, Some("a")),
+        (br#"{"fields":{}}"#, None),
+    ];
+    for (stdin, heaviest) in cases {
+        let result: serde_json::Value = serde_json::from_str(&run(&go, &[], &[], stdin)).unwrap();
+        assert_eq!(
+            result["data"]["heaviest_field"].as_str(),
+            *heaviest,
+            "stdin {:?}: {result}",
+            String::from_utf8_lossy(stdin)
+        );
+    }
+}
+
+/// The summary in `output` follows the locale while `data` does not, which is
+/// what lets `skill test --stable-output` snapshot a result in any locale.
+#[test]
+fn go_word_count_localizes_output_but_not_data() {
+    let out_dir = tempfile::tempdir().unwrap();
+    let Some(go) = build_go(out_dir.path()) else {
+        eprintln!("skipping: could not build the Go word_count template (go unavailable?)");
+        return;
+    };
+    let results: Vec<serde_json::Value> = ["en", "pl", "ru"]
+        .iter()
+        .map(|locale| {
+            let stdin = format!(r#"{{"text":"hello wide world","locale":"{locale}"}}"#);
+            serde_json::from_str(&run(&go, &[], &[], stdin.as_bytes())).unwrap()
+        })
+        .collect();
+    assert_eq!(results[0]["output"], "3 words, 1 line, 16 characters");
+    assert_eq!(results[1]["output"], "3 słowa, 1 wiersz, 16 znaków");
+    assert_eq!(results[2]["output"], "3 слова, 1 строка, 16 символов");
+    for result in &results {
+        assert_eq!(
+            result["data"],
+            serde_json::json!({"words": 3, "lines": 1, "characters": 16})
+        );
+    }
+}
+
+/// The smart tokenizer splits at punctuation but keeps apostrophes and
+/// hyphens inside words, and points and commas inside numbers. As for blank
+/// text, pinning Go pins every template.
+#[test]
+fn go_word_count_smart_tokenizer_counts_tricky_words() {
+    let out_dir = tempfile::tempdir().unwrap();
+    let Some(go) = build_go(out_dir.path()) else {
+        eprintln!("skipping: could not build the Go word_count template (go unavailable?)");
+        return;
+    };
+    let cases: &[(&str, u64)] = &[
+        ("hello,world", 2),
+        ("hello, world", 2),
+        ("don't", 1),
+        (r"rock\u2019n\u2019roll", 1),
+        ("well-known", 1),
+        ("state-of-the-art", 1),
+        ("-x-", 1),
+        ("'quoted'", 1),
+        ("a--b", 2),
+        ("hyphen- ated", 2),
+        ("3.14", 1),
+        ("1,000,000", 1),
+        ("v1.2.3", 1),
+        ("U.S.A.", 3),
+        ("end.Start", 2),
+        ("e-mail@example.com", 3),
+        (r"cafe\u0301", 1),
+        (r"\u00fcber-cool", 1),
+        (
+            r"\u041f\u0440\u0438\u0432\u0435\u0442,\u043c\u0438\u0440",
+            2,
+        ),
+        (r"\u4f60\u597d", 1),
+        (r"\u2014", 0),
+        (r"\ud83d\udc4b\ud83c\udffd hi", 1),
+        ("... !?", 0),
+    ];
+    for (text, words) in cases {
+        let stdin = format!(r#"{{"text":"{text}","tokenizer":"smart"}}"#);
+        let result: serde_json::Value =
+            serde_json::from_str(&run(&go, &[], &[], stdin.as_bytes())).unwrap();
+        assert_eq!(result["data"]["words"], *words, "text {text:?}");
+    }
+}
+
+/// The uax29 tokenizer splits at Unicode word boundaries: a point between
+/// letters and a comma between digits stay inside a word, a trailing point
+/// does not, and punctuation alone is no word. Whitespace splitting counts
+/// the same three words in "e.g., 1,000 items" but keeps the trailing
+/// punctuation on them.
+#[test]
+fn go_word_count_uax29_tokenizer_follows_word_boundaries() {
+    let out_dir = tempfile::tempdir().unwrap();
+    let Some(go) = build_go(out_dir.path()) else {
+        eprintln!("skipping: could not build the Go word_count template (go unavailable?)");
+        return;
+    };
+    let words = |text: &str, tokenizer: &str| -> serde_json::Value {
+        let stdin = format!(r#"{{"text":"{text}","tokenizer":"{tokenizer}","explain":true}}"#);
+        let result: serde_json::Value =
+            serde_json::from_str(&run(&go, &[], &[], stdin.as_bytes())).unwrap();
+        result["data"].clone()
+    };
+    let data = words("e.g., 1,000 items", "whitespace");
+    assert_eq!(data["words"], 3);
+    assert_eq!(
+        data["explain"]["tokens"],
+        serde_json::json!(["e.g.,", "1,000", "items"])
+    );
+    let data = words("e.g., 1,000 items", "uax29");
+    assert_eq!(data["words"], 3);
+    assert_eq!(
+        data["explain"]["tokens"],
+        serde_json::json!(["e.g", "1,000", "items"])
+    );
+    assert!(data["explain"]["tokenizer_rule"]
+        .as_str()
+        .unwrap()
+        .contains("UAX #29"));
+    // "segmentation" is another name for the tokenizer.
+    let stdin = br#"{"text":"e.g., 1,000 items","segmentation":"uax29","explain":true}"#;
+    let result: serde_json::Value = serde_json::from_str(&run(&go, &[], &[], stdin)).unwrap();
+    assert_eq!(result["data"], data);
+    let stdin = br#"{"text":"x","segmentation":"uax29","tokenizer":"smart"}"#;
+    assert_eq!(
+        failure_shape(&run(&go, &[], &[], stdin)),
+        (false, "invalid_input".to_string())
+    );
+
+    let cases: &[(&str, u64, u64)] = &[
+        ("hello,world", 1, 2),
+        ("well-known", 1, 2),
+        ("don't", 1, 1),
+        ("snake_case", 1, 1),
+        ("3.14.", 1, 1),
+        ("U.S.A.", 1, 1),
+        ("end.Start", 1, 1),
+        (r"\u4f60\u597d", 1, 2),
+        (r"\u30ab\u30bf\u30ab\u30ca", 1, 1),
+        (r"cafe\u0301", 1, 1),
+        (r"\u2014 ... !?", 3, 0),
+    ];
+    for (text, whitespace, uax29) in cases {
+        assert_eq!(
+            words(text, "whitespace")["words"],
+            *whitespace,
+            "text {text:?}"
+        );
+        assert_eq!(words(text, "uax29")["words"], *uax29, "text {text:?}");
+    }
+}
+
+#[test]
+fn js_and_go_word_count_templates_agree_on_counts() {
+    let out_dir = tempfile::tempdir().unwrap();
+    let (Some(go), Some((node, script))) = (build_go(out_dir.path()), js_runner(out_dir.path()))
+    else {
+        eprintln!("skipping: could not run both word_count templates (go/node unavailable?)");
+        return;
+    };
+    let js = |stdin: &[u8]| run_with(&node, &script, &[], &[], stdin);
+
+    // Javy modules cannot see argv, the environment, or preopens, so only the
+    // stdin-driven part of the protocol is compared.
+    let cases: &[&[u8]] = &[
+        br#"{"text":"hello world"}"#,
+        br#"{"text":"one\ntwo three\n"}"#,
+        br#"{"text":"a b c d e","locale":"pl"}"#,
+        br#"{"text":"a b c d e f g h i j k l m n o p q r s t u","locale":"ru-RU"}"#,
+        br#"{"text":"x","locale":"de"}"#,
+        br#"{"text":"","locale":"fr"}"#,
+        br#"{"text":"un deux\ntrois","locale":"fr-CA"}"#,
+        br#"{"text":"tab\tand\u00a0nbsp\u0085nel \u2003em"}"#,
+        br#"{"text":"zero\ufeffwidth"}"#,
+        br#"{"text":"\ud83d\ude00 emoji"}"#,
+        b"{}",
+        b"null",
+        "\u{feff}{\"text\":\"\u{feff}hi  there\"}".as_bytes(),
+        b"{\"text\":\"bad \xffx\"}",
+        br#"{"text":"  a  b \n\n c  \n","trim":"none"}"#,
+        br#"{"text":"\u2003 a  b \n\n c \u0085","trim":"edges"}"#,
+        br#"{"text":"\u2003 a  b \n\n c \u0085","trim":"collapse"}"#,
+        br#"{"text":"\ufeff x \ufeff","trim":"edges"}"#,
+        br#"{"text":"x","trim":"all"}"#,
+        br#"{"text":"e\u0301 \ud83d\udc68\u200d\ud83d\udc67 \ud83c\uddfb\ud83c\uddf3\r\n","count_mode":"graphemes"}"#,
+        br#"{"fields":{"a":"h\u00e9llo","b":"\ud83d\udc4b\ud83c\udffd"},"count_mode":"bytes"}"#,
+        br#"{"text":"x","count_mode":"words"}"#,
+        br#"{"fields":{"title":"Hello world","body":"one\ntwo three\n"}}"#,
+        br#"{"fields":{"\ud83d\ude00":"a","\uffff":"b c"},"locale":"ru"}"#,
+        br#"{"fields":{},"text":""}"#,
+        br#"{"fields":null,"text":"x"}"#,
+        br#"{"fields":{"a":"x"},"text":"y"}"#,
+        br#"{"fields":{"title":"Hi there","body":7,"a/b":null}}"#,
+        br#"{"fields":{"title":"Hi there","body":7},"fail_fast":true}"#,
+        br#"{"fields":{"a":1,"b":[],"c":{},"d":true}}"#,
+        br#"{"fields":{"b":"xy","a":"\u00e9","c":"z","d":1},"count_mode":"graphemes"}"#,
+        br#"{"fields":{"":"","a":""}}"#,
+        br#"{"text":"the quick brown fox jumps over a lazy dog","length_histogram":true}"#,
+        br#"{"text":"h\u00e9llo \ud83d\udc4b\ud83c\udffd e\u0301","count_mode":"graphemes","length_histogram":true}"#,
+        br#"{"fields":{"a":"one two","b":"three","c":5},"count_mode":"bytes","length_histogram":true}"#,
+        br#"{"text":"  ","length_histogram":true}"#,
+        br#"{"text":"   \n "}"#,
+        br#"{"text":"\n","explain":true}"#,
+        br#"{"text":"Word word WORD cafe\u0301 caf\u00e9 \ufb01ne fine \u0130stanbul istanbul","top_words":3}"#,
+        br#"{"text":"Word word WORD cafe\u0301 caf\u00e9 \ufb01ne fine \u0130stanbul istanbul","top_words":10,"fold_case":false,"normalize_unicode":"nfc"}"#,
+        br#"{"text":"Word word WORD cafe\u0301 caf\u00e9 \ufb01ne fine \u0130stanbul istanbul","top_words":10,"normalize_unicode":"nfkc"}"#,
+        br#"{"fields":{"a":"the cat","b":"The end","c":5},"top_words":2}"#,
+        br#"{"text":"","top_words":5}"#,
+        br#"{"text":"x","top_words":-1}"#,
+        br#"{"text":"x","trim":"all","top_words":-1}"#,
+        br#"{"text":"x","count_mode":"words","top_words":-1,"normalize_unicode":"nfd"}"#,
+        br#"{"text":"x","count_mode":"words","normalize_unicode":"nfd"}"#,
+        br#"{"text":"  one two  three ","trim":"edges","explain":true}"#,
+        br#"{"text":"a b c d e f g h i j k l","explain":true}"#,
+        br#"{"text":"","explain":true}"#,
+        br#"{"fields":{"a":"x y","b":5,"c":"z"},"count_mode":"graphemes","explain":true}"#,
+        br#"{"text":"hello,world don't rock\u2019n\u2019roll well-known 3.14 1,000 U.S.A. \u2014 -x- cafe\u0301 \ud83d\udc4b\ud83c\udffd \u0645\u0631\u062d\u0628\u0627!","tokenizer":"smart","top_words":20,"length_histogram":true,"explain":true}"#,
+        br#"{"text":"... \u2014 !?","tokenizer":"smart"}"#,
+        br#"{"text":"hello,world","tokenizer":"whitespace","explain":true}"#,
+        br#"{"fields":{"a":"it's","b":"x,y"},"tokenizer":"smart"}"#,
+        br#"{"text":"x","tokenizer":"words","top_words":-1}"#,
+        br#"{"text":"e.g., 1,000 items","tokenizer":"uax29","explain":true}"#,
+        br#"{"text":"e.g., 1,000 items","segmentation":"uax29","explain":true}"#,
+        br#"{"fields":{"a":"e.g.","b":"x"},"segmentation":"uax29","explain":true}"#,
+        br#"{"text":"x","segmentation":"smart"}"#,
+        br#"{"text":"x","segmentation":"uax29","tokenizer":"smart"}"#,
+        br#"{"text":"x","segmentation":"uax29","word_regex":"x"}"#,
+        br#"{"text":"hello,world don't well-known snake_case a:b 3.14. U.S.A. \u4f60\u597d \u30ab\u30bf\u30ab\u30ca cafe\u0301 \u2014 \ud83d\udc4b\ud83c\udffd _x_ \u0645\u0631\u062d\u0628\u0627!","tokenizer":"uax29","top_words":20,"explain":true}"#,
+        br#"{"fields":{"a":"e.g.","b":"... !?"},"tokenizer":"uax29"}"#,
+        br#"{"text":"The quick brown fox, \u041f\u0440\u0438\u0432\u0435\u0442!","tokens":"gpt-bpe-approx","explain":true}"#,
+        br#"{"text":" \n","tokens":"gpt-bpe-approx"}"#,
+        br#"{"fields":{"a":"internationalization","b":5,"c":"a b"},"tokenizer":"smart","tokens":"gpt-bpe-approx"}"#,
+        br#"{"text":"x","tokens":"tiktoken","top_words":-1}"#,
+        br#"{"text":"The cat saw the CAT and a dog","stats":true}"#,
+        br#"{"text":"The cat saw the CAT","stats":true,"top_words":2,"fold_case":false}"#,
+        br#"{"text":"The cat saw the CAT and a dog","stats":true,"top":3}"#,
+        br#"{"text":"The cat saw the CAT","stats":true,"top":2,"case_sensitive":true}"#,
+        br#"{"text":"a A","top_words":2,"top":2,"case_sensitive":false,"fold_case":true}"#,
+        br#"{"text":"x","top":2,"top_words":3}"#,
+        br#"{"text":"x","case_sensitive":true,"fold_case":true}"#,
+        br#"{"text":"x","top_words":-1,"top":-1}"#,
+        br#"{"text":" ","stats":true}"#,
+        br#"{"fields":{"a":"caf\u00e9 au","b":"lait","c":5},"count_mode":"bytes","stats":true}"#,
+        br#"{"text":"\ud83d\ude00a \uffffb","stats":true}"#,
+        br#"{"text":"one two three four","offset":4,"length":9}"#,
+        br#"{"text":"caf\u00e9 au lait","offset":2,"length":4,"count_mode":"bytes"}"#,
+        br#"{"text":"\ud83d\ude00 ok","offset":1,"count_mode":"bytes"}"#,
+        br#"{"text":"short","offset":9}"#,
+        br#"{"text":"one two","offset":4,"length":50}"#,
+        br#"{"text":"x","offset":-1,"length":-2}"#,
+        br#"{"fields":{"a":"x"},"offset":1}"#,
+        br#"{"text":"don't stop-me now, 42!","word_regex":"[a-z]+","explain":true}"#,
+        br#"{"text":"caf\u00e9 na\u00efve x1","word_regex":"\\p{L}+","top_words":5}"#,
+        br#"{"text":"\ufeffhello there","explain":true}"#,
+        br#"{"fields":{"a":"\ufeffx","b":"y"},"explain":true}"#,
+    ];
+    for stdin in cases {
+        assert_eq!(
+            run(&go, &[], &[], stdin),
+            js(stdin),
+            "templates disagree for stdin {:?}",
+            String::from_utf8_lossy(stdin)
+        );
+    }
+
+    for invalid in [
+        &br#"{"text":"#[..],
+        br#"{"text":5}"#,
+        b"[]",
+        br#"{"trim":1}"#,
+        br#"{"fields":{"a":1}}"#,
+        br#"{"fields":[]}"#,
+        br#"{"fail_fast":"yes"}"#,
+        br#"{"length_histogram":1}"#,
+        br#"{"top_words":1.5}"#,
+        br#"{"top_words":9999999999999999999}"#,
+        br#"{"fold_case":"no"}"#,
+        br#"{"normalize_unicode":1}"#,
+        br#"{"explain":"yes"}"#,
+        br#"{"tokenizer":true}"#,
+        br#"{"tokens":4}"#,
+        br#"{"stats":"yes"}"#,
+        br#"{"offset":1.5}"#,
+        br#"{"length":"all"}"#,
+        br#"{"word_regex":5}"#,
+        br#"{"segmentation":5}"#,
+        br#"{"top":"5"}"#,
+        br#"{"case_sensitive":"yes"}"#,
+        br#"{"text":"x","word_regex":"("}"#,
+        br#"{"text":"x","word_regex":"a","tokenizer":"smart"}"#,
+    ] {
+        assert_eq!(
+            failure_shape(&run(&go, &[], &[], invalid)),
+            failure_shape(&js(invalid)),
+            "templates disagree for stdin {:?}",
+            String::from_utf8_lossy(invalid)
+        );
+    }
+    assert_eq!(
+        failure_shape(&js(br#"{"text":"#)),
+        (false, "invalid_input".to_string())
+    );
+}